@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// runConfig handles `joe config validate`, which checks the config file
+// strictly and lists every problem with its line
+func runConfig(path string, args []string) error {
+	if len(args) != 1 || args[0] != "validate" {
+		return fmt.Errorf("usage: joe [-config path] config validate")
+	}
+	return validateConfig(os.Stdout, path)
+}
+
+// validateConfig reports the problems in the config file at path, and
+// fails if there are any
+func validateConfig(w io.Writer, path string) error {
+	problems, err := config.Check(path)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(w, "%s doesn't exist; joe uses the built-in defaults\n", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s is valid\n", path)
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s: %s\n", path, p)
+	}
+	return fmt.Errorf("%d problem(s) in %s", len(problems), path)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaimegago/joe/internal/client"
+	"github.com/jaimegago/joe/internal/config"
+)
+
+const graphUsage = `Usage:
+  joe graph export [flags]             Write the graph as DOT (Graphviz), GraphML (Gephi), or JSON
+  joe graph ask [-limit n] <question>  Find nodes by asking in plain language, e.g. "services that depend on postgres"
+
+Flags for export:
+`
+
+// runGraph works with the infrastructure graph joecored keeps
+func runGraph(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "ask") {
+		fmt.Fprint(os.Stderr, graphUsage)
+		exportFlags(&client.GraphExport{}, new(string)).PrintDefaults()
+		if len(args) == 0 {
+			return fmt.Errorf("missing graph command")
+		}
+		return fmt.Errorf("unknown graph command %q", args[0])
+	}
+
+	c := client.New("http://" + cfg.Server.Address)
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if args[0] == "ask" {
+		return graphAsk(ctx, c, args[1:])
+	}
+
+	var e client.GraphExport
+	var out string
+	fs := exportFlags(&e, &out)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: joe graph export [flags]")
+	}
+	if e.Format == "" {
+		e.Format = client.ExportFormat(out)
+	}
+
+	data, err := c.ExportGraph(ctx, e)
+	if err != nil {
+		return err
+	}
+	if out == "" || out == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(out, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (%s)\n", out, e.Format)
+	return nil
+}
+
+// graphAsk prints the query joecored translated a question into and the
+// nodes it matched
+func graphAsk(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("graph ask", flag.ContinueOnError)
+	limit := fs.Int("limit", 0, "show at most `n` nodes (default 100)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	question := strings.Join(fs.Args(), " ")
+	if question == "" {
+		return fmt.Errorf("usage: joe graph ask [-limit n] <question>")
+	}
+
+	answer, err := c.AskGraph(ctx, question, *limit)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Query: %s\n\n", answer.Query)
+	if answer.Total == 0 {
+		fmt.Println("No matching nodes.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tSOURCE")
+	for _, n := range answer.Nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", n.ID, n.Type, dash(n.SourceID))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if answer.Total > len(answer.Nodes) {
+		fmt.Printf("\n%d of %d nodes shown; use -limit for more.\n", len(answer.Nodes), answer.Total)
+	}
+	return nil
+}
+
+// exportFlags binds export flags to e and the output file to out
+func exportFlags(e *client.GraphExport, out *string) *flag.FlagSet {
+	fs := flag.NewFlagSet("graph export", flag.ContinueOnError)
+	fs.StringVar(&e.Format, "format", "", "dot, graphml, or json (default: by the -o extension, else json)")
+	fs.StringVar(out, "o", "", "write to `file` instead of stdout")
+	fs.StringVar(&e.Node, "node", "", "export only the neighborhood of this node ID")
+	fs.IntVar(&e.Depth, "depth", 0, "hops from -node to include (default 1)")
+	fs.StringVar(&e.Source, "source", "", "keep only nodes from this source ID")
+	fs.Func("type", "keep only nodes of these types, e.g. deployment,service (repeatable)", func(v string) error {
+		e.Types = append(e.Types, strings.Split(v, ",")...)
+		return nil
+	})
+	return fs
+}
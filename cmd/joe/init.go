@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/client"
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/llmfactory"
+	"github.com/jaimegago/joe/internal/onboard"
+)
+
+// runInit handles `joe init`: it offers the models whose API keys are set
+// and the sources found on this machine, writes the config file at path,
+// then registers the sources and checks that everything can be reached
+func runInit(ctx context.Context, path string) error {
+	models := onboard.DetectModels(os.Getenv)
+	if !slices.ContainsFunc(models, func(m onboard.Model) bool { return m.Found != "" }) {
+		var envs []string
+		for _, m := range models {
+			for _, env := range m.KeyEnv {
+				if !slices.Contains(envs, env) {
+					envs = append(envs, env)
+				}
+			}
+		}
+		return fmt.Errorf("no LLM API key found; set one of %s and run joe init again", strings.Join(envs, ", "))
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	sources := onboard.DetectSources(ctx, dir, onboard.KubeconfigPath(os.Getenv))
+
+	expanded, err := config.ExpandHome(path)
+	if err != nil {
+		return err
+	}
+	_, statErr := os.Stat(expanded)
+	wizard, err := onboard.RunWizard(models, sources, path, statErr == nil)
+	if err != nil {
+		return err
+	}
+	if !wizard.Confirmed() {
+		fmt.Println("Cancelled; nothing was written")
+		return nil
+	}
+
+	data, err := onboard.Render(wizard.Model())
+	if err != nil {
+		return err
+	}
+	if err := onboard.WriteConfig(path, data); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n\n", path)
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	return verifySetup(ctx, os.Stdout, cfg, wizard.Sources())
+}
+
+// verifySetup asks the default model for a short answer, then registers
+// sources with joecored and tests them, reporting each step. It fails if
+// any step did.
+func verifySetup(ctx context.Context, w io.Writer, cfg *config.Config, sources []client.Source) error {
+	failed := 0
+	check := func(what string, err error) {
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "✗ %s: %v\n", what, err)
+			return
+		}
+		fmt.Fprintf(w, "✓ %s\n", what)
+	}
+
+	check(fmt.Sprintf("%s answers", cfg.LLM.Current), pingModel(ctx, cfg))
+
+	c := client.New("http://" + cfg.Server.Address)
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err := c.Ping(pingCtx)
+	cancel()
+	check("joecored is running at "+cfg.Server.Address, err)
+	if err != nil {
+		if len(sources) > 0 {
+			fmt.Fprintln(w, "\nStart joecored, then register the sources with:")
+			for _, src := range sources {
+				fmt.Fprintf(w, "  %s\n", addSourceCommand(src))
+			}
+		}
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+
+	for _, src := range sources {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		_, err := c.AddSource(ctx, src)
+		check("registered "+src.ID, err)
+		if err == nil {
+			result, err := c.TestSource(ctx, src.ID)
+			if err == nil && !result.Connected {
+				err = fmt.Errorf("%s", result.Message)
+			}
+			check(src.ID+" is reachable", err)
+		}
+		cancel()
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	fmt.Fprintln(w, "\nJoe is ready. Run: joe")
+	return nil
+}
+
+// pingModel sends the default model a one-line prompt
+func pingModel(ctx context.Context, cfg *config.Config) error {
+	mc, err := cfg.LLM.CurrentModel()
+	if err != nil {
+		return err
+	}
+	adapter, err := llmfactory.NewAdapter(ctx, mc)
+	if err != nil {
+		return err
+	}
+	if closer, ok := adapter.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	_, err = adapter.Chat(ctx, llm.ChatRequest{
+		Messages:  []llm.Message{{Role: "user", Content: "Reply with OK."}},
+		MaxTokens: 16,
+	})
+	return err
+}
+
+// addSourceCommand is the joe sources add command that registers src
+func addSourceCommand(src client.Source) string {
+	args := []string{"joe sources add", "-type", src.Type}
+	if src.URL != "" {
+		args = append(args, "-url", src.URL)
+	}
+	for _, key := range []string{"context", "kubeconfig", "path"} {
+		if v, ok := src.ConnectionDetails[key].(string); ok {
+			args = append(args, "-"+key, v)
+		}
+	}
+	return strings.Join(append(args, src.ID), " ")
+}
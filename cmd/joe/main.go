@@ -2,41 +2,127 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/jaimegago/joe/internal/audit"
 	"github.com/jaimegago/joe/internal/client"
 	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/cost"
 	"github.com/jaimegago/joe/internal/llm"
 	"github.com/jaimegago/joe/internal/llmfactory"
 	"github.com/jaimegago/joe/internal/logging"
+	"github.com/jaimegago/joe/internal/mcp"
+	"github.com/jaimegago/joe/internal/memory"
+	"github.com/jaimegago/joe/internal/observability"
+	"github.com/jaimegago/joe/internal/prompt"
+	"github.com/jaimegago/joe/internal/redact"
 	"github.com/jaimegago/joe/internal/repl"
+	"github.com/jaimegago/joe/internal/store"
+	storesqlite "github.com/jaimegago/joe/internal/store/sqlite"
 	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/tools/local/runcmd"
+	"github.com/jaimegago/joe/internal/tools/local/sqlquery"
+	"github.com/jaimegago/joe/internal/tools/local/sshrun"
+	"github.com/jaimegago/joe/internal/tools/plugin"
 	"github.com/jaimegago/joe/internal/useragent"
 )
 
 func main() {
 	// Parse command-line flags
 	configPath := flag.String("config", "~/.joe/config.yaml", "path to config file")
+	resume := flag.Bool("resume", false, "resume the most recent session")
+	autoApprove := flag.Bool("auto-approve", false, "run tools that require confirmation without asking (denied tools stay denied)")
+	question := flag.String("p", "", "answer `question` without the REPL, print the answer, and exit")
+	var files []string
+	flag.Func("f", "attach `file` to the -p question (repeatable)", func(path string) error {
+		files = append(files, path)
+		return nil
+	})
 	flag.Parse()
+	if len(files) > 0 && *question == "" {
+		fmt.Fprintln(os.Stderr, "Error: -f attaches files to a -p question")
+		os.Exit(2)
+	}
+
+	// Piped input and -f files go along with the -p question; read them
+	// before setting anything up so a bad file fails fast
+	var attachments []attachment
+	if *question != "" {
+		var err error
+		if attachments, err = readAttachments(os.Stdin, files); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// With -p, stdout is only the answer; status messages go to stderr
+	status := io.Writer(os.Stdout)
+	if *question != "" {
+		status = os.Stderr
+	}
 
 	ctx := context.Background()
 
+	// Checking the config must work even when Load would fail on it, and
+	// init writes it
+	switch flag.Arg(0) {
+	case "config":
+		if err := runConfig(*configPath, flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "init":
+		if err := runInit(ctx, *configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Subcommands that don't need an LLM
+	switch flag.Arg(0) {
+	case "":
+	case "mcp-serve":
+		if err := runMCPServe(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "sources":
+		if err := runSources(ctx, cfg, flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "graph":
+		if err := runGraph(ctx, cfg, flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q. Usage: joe [flags] [init | mcp-serve | sources | graph export | graph ask | config validate]\n", flag.Arg(0))
+		os.Exit(2)
+	}
+
 	// Validate LLM configuration and check API keys
 	currentModel, err := cfg.LLM.CurrentModel()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "You need to connect Joe to an LLM.\n\n%v\n\nCheck your config file's llm.current and llm.available sections.\n", err)
+		fmt.Fprintf(os.Stderr, "You need to connect Joe to an LLM.\n\n%v\n\nCheck your config file's llm.current and llm.available sections, or run: joe init\n", err)
 		os.Exit(1)
 	}
 	if err := config.ValidateAPIKeysWithUserMessage(currentModel); err != nil {
@@ -44,6 +130,10 @@ func main() {
 		fmt.Fprintln(os.Stderr)
 		os.Exit(1)
 	}
+	if _, err := llmfactory.FilterRules(cfg.LLM.Filter); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
 
 	// Connect to joecored
 	joecoreURL := "http://" + cfg.Server.Address
@@ -58,15 +148,54 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Set up structured logging based on config
-	logger, logCleanup := logging.SetupLoggerWithFile(cfg.Logging.Level, cfg.Logging.File)
+	// Mask secrets in tool results, logs, and transcripts unless
+	// redaction.enabled is false; a nil redactor masks nothing
+	var redactor *redact.Redactor
+	if cfg.Redaction.Enabled {
+		if redactor, err = redact.New(cfg.Redaction.Patterns); err != nil {
+			log.Fatalf("Invalid redaction config: %v", err)
+		}
+	}
+
+	// Set up structured logging based on config; /reload can change the level
+	logLevel := new(slog.LevelVar)
+	logger, logCleanup := logging.SetupLoggerWithFile(cfg.Logging.Level, cfg.Logging.File,
+		logging.WithRedactor(redactor), logging.WithLevel(logLevel))
 	defer logCleanup()
 
 	// Log debug mode if enabled
 	if cfg.Logging.Level == "debug" {
 		slog.Debug("running in debug mode")
-		fmt.Println("Debug mode enabled")
+		fmt.Fprintln(status, "Debug mode enabled")
+	}
+
+	// OpenTelemetry traces and metrics, per the telemetry config section.
+	// joe runs without them if they can't be set up.
+	shutdownTelemetry, err := observability.Setup(ctx, observability.FromConfig(cfg.Telemetry, "joe", cfg.Telemetry.JoeMetricsPort))
+	if err != nil {
+		slog.Warn("telemetry disabled", "error", err)
+		fmt.Fprintf(os.Stderr, "Warning: telemetry disabled: %v\n", err)
+	} else {
+		defer shutdownTelemetry(context.Background())
+	}
+
+	// Open the local store for session persistence and cost tracking. joe
+	// keeps working without it; sessions just aren't saved.
+	storePath, err := config.ExpandHome(cfg.Store.Path)
+	if err != nil {
+		log.Fatalf("Invalid store path: %v", err)
+	}
+	var costStore cost.Store
+	sessionStore, err := storesqlite.Open(ctx, storePath)
+	if err != nil {
+		slog.Warn("session persistence disabled", "path", storePath, "error", err)
+		fmt.Fprintf(os.Stderr, "Warning: sessions will not be saved: %v\n", err)
+	} else {
+		defer sessionStore.Close()
+		costStore = sessionStore
 	}
+	costs := cost.NewTracker(costStore, cfg.LLM.Cost.DailyBudgetUSD)
+	stats := llm.NewStatsRegistry()
 
 	// Initialize LLM adapter using factory
 	baseAdapter, err := llmfactory.NewAdapter(ctx, currentModel)
@@ -79,30 +208,109 @@ func main() {
 		defer closer.Close()
 	}
 
-	// Wrap with instrumentation
-	llmAdapter := llm.NewInstrumentedAdapter(baseAdapter, logger, currentModel.Provider, currentModel.Model)
+	// Wrap with instrumentation, retries, and failover to llm.fallback
+	llmAdapter, err := withFailover(wrapAdapter(baseAdapter, cfg, logger, currentModel, costs, stats), cfg, logger, cfg.LLM.Current, costs, stats)
+	if err != nil {
+		log.Fatalf("Invalid LLM config: %v", err)
+	}
+	if closer, ok := llmAdapter.(io.Closer); ok {
+		// The fallback in use, if the run failed over
+		defer closer.Close()
+	}
 
 	// Log which model we're using
 	slog.Info("LLM initialized",
 		"provider", currentModel.Provider,
 		"model", currentModel.Model,
 	)
-	fmt.Printf("Using %s/%s\n", currentModel.Provider, currentModel.Model)
+	fmt.Fprintf(status, "Using %s/%s\n", currentModel.Provider, currentModel.Model)
 
-	// Create tool registry with default tools (echo, ask_user)
-	registry := tools.NewDefaultRegistry()
+	// Session memory and remembered facts live in the local store
+	var mem *memory.Memory
+	if sessionStore != nil && cfg.Memory.Enabled {
+		mem = memory.New(llmAdapter, sessionStore,
+			memory.WithTopK(cfg.Memory.TopK),
+			memory.WithMinSimilarity(cfg.Memory.MinSimilarity),
+			memory.WithLogger(logger))
+	}
+
+	// Create tool registry with the built-in tools allowed by config, the
+	// graph tools backed by joecored, and remember/recall with memory
+	toolOpts, err := toolOptions(cfg)
+	if err != nil {
+		log.Fatalf("Invalid tool config: %v", err)
+	}
+	toolOpts = append(toolOpts, tools.WithGraph(coreClient))
+	if cfg.Tools.SourceTools == "registered" {
+		if sources, err := coreClient.ListSources(ctx); err != nil {
+			slog.Warn("offering all source tools, sources unavailable", "error", err)
+		} else {
+			var types []string
+			for _, s := range sources {
+				types = append(types, s.Type)
+			}
+			toolOpts = append(toolOpts, tools.WithSourceTypes(types))
+		}
+	}
+	if mem != nil {
+		toolOpts = append(toolOpts, tools.WithFacts(mem))
+	}
+	registry := tools.NewDefaultRegistry(toolOpts...)
+	registerPlugins(ctx, cfg, registry, status)
 
-	// Create tool executor
-	executor := tools.NewExecutor(registry)
+	// Add tools from configured MCP servers. A server that fails to start
+	// is reported but doesn't stop joe.
+	if len(cfg.MCP.Servers) > 0 {
+		mcpManager, err := mcp.ConnectAll(ctx, cfg.MCP.Servers, registry)
+		defer mcpManager.Close()
+		if err != nil {
+			slog.Warn("some MCP servers are unavailable", "error", err)
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		for _, c := range mcpManager.Clients() {
+			fmt.Fprintf(status, "Connected to MCP server %s\n", c.Name())
+		}
+	}
+
+	// Create tool executor; tools with an "ask" policy prompt in the
+	// terminal. With -p no one is there to answer, so they are refused
+	// (unless -auto-approve), and so is ask_user.
+	policies, err := tools.ParseApprovalPolicies(cfg.Tools.Approval)
+	if err != nil {
+		log.Fatalf("Invalid tool approval config: %v", err)
+	}
+	approver := repl.NewApprover(os.Stdin, os.Stdout)
+	if *question != "" {
+		approver = nil
+		registry.Unregister("ask_user")
+	}
+	defaultTimeout, timeouts := tools.ParseTimeouts(cfg.Tools.TimeoutSeconds, cfg.Tools.Timeouts)
+	executorOpts := []tools.ExecutorOption{
+		tools.WithApproval(policies, approver, *autoApprove),
+		tools.WithTimeouts(defaultTimeout, timeouts),
+		tools.WithRedactor(redactor),
+	}
+	if len(cfg.Tools.Cache.TTLSeconds) > 0 {
+		executorOpts = append(executorOpts, tools.WithResultCache(
+			tools.ParseCacheTTLs(cfg.Tools.Cache.TTLSeconds), cfg.Tools.Cache.InvalidateOn))
+	}
+	if instrument, err := tools.NewInstrumentation(); err != nil {
+		slog.Warn("tool telemetry unavailable", "error", err)
+	} else {
+		executorOpts = append(executorOpts, tools.WithMiddleware(instrument))
+	}
+	executor := tools.NewExecutor(registry, executorOpts...)
 
 	// Create adapter factory for hot-swapping models
 	adapterFactory := func(ctx context.Context, provider, model string) (llm.LLMAdapter, error) {
 		// Find the model config
 		var modelCfg config.ModelConfig
+		var modelKey string
 		found := false
-		for _, mc := range cfg.LLM.Available {
+		for key, mc := range cfg.LLM.Available {
 			if mc.Provider == provider && mc.Model == model {
 				modelCfg = mc
+				modelKey = key
 				found = true
 				break
 			}
@@ -122,30 +330,306 @@ func main() {
 			return nil, err
 		}
 
-		// Wrap with instrumentation
-		return llm.NewInstrumentedAdapter(baseAdptr, logger, provider, model), nil
+		// Wrap with instrumentation, retries, and failover
+		return withFailover(wrapAdapter(baseAdptr, cfg, logger, modelCfg, costs, stats), cfg, logger, modelKey, costs, stats)
+	}
+
+	// Render the system prompt from config and JOE.md context files;
+	// /reload renders it again
+	loadContext := func() ([]prompt.ContextFile, error) {
+		return prompt.LoadContext(cfg.Prompt.ContextFiles)
+	}
+	buildPrompt := func() (string, error) {
+		var toolNames []string
+		for _, def := range registry.ToDefinitions() {
+			toolNames = append(toolNames, def.Name)
+		}
+		base, err := prompt.Build(cfg.Prompt, prompt.CurrentVars(toolNames, cfg.LLM.Current))
+		if err != nil {
+			return "", err
+		}
+		files, err := loadContext()
+		if err != nil {
+			return "", err
+		}
+		return prompt.WithContext(base, files), nil
+	}
+	systemPrompt, err := buildPrompt()
+	if err != nil {
+		log.Fatalf("Invalid prompt config: %v", err)
 	}
 
 	// Create agent with system prompt and adapter factory
-	systemPrompt := "You are Joe, an infrastructure assistant. You can use tools to help answer questions. Be concise."
-	agentInstance := useragent.NewAgent(
-		llmAdapter,
-		executor,
-		registry,
-		systemPrompt,
+	agentOpts := []useragent.AgentOption{
 		useragent.WithAdapterFactory(adapterFactory),
 		useragent.WithCurrentModelName(cfg.LLM.Current),
-	)
+		useragent.WithCompaction(cfg.LLM.Compaction.ThresholdTokens, cfg.LLM.Compaction.KeepTurns),
+		useragent.WithModelInfo(func(name string) llm.ModelInfo { return modelInfo(cfg.LLM.Available[name]) }),
+	}
+	historyPath, err := config.ExpandHome("~/.joe/history")
+	if err != nil {
+		log.Fatalf("Invalid history path: %v", err)
+	}
+	replOpts := []repl.Option{
+		repl.WithHistoryFile(historyPath),
+		repl.WithPromptBuilder(buildPrompt),
+		repl.WithConfigReload(*configPath, logLevel),
+		repl.WithContextLoader(loadContext),
+		repl.WithCostTracker(costs),
+		repl.WithStats(stats),
+		repl.WithSources(coreClient),
+		repl.WithGraphExport(coreClient),
+		repl.WithTools(registry),
+	}
+
+	// Keep a transcript of every run when audit.enabled is set
+	if cfg.Audit.Enabled {
+		dir, err := config.ExpandHome(cfg.Audit.Dir)
+		if err != nil {
+			log.Fatalf("Invalid transcript directory: %v", err)
+		}
+		transcripts, err := audit.NewWriter(dir, audit.WithRedactor(redactor))
+		if err != nil {
+			log.Fatalf("Failed to set up transcripts: %v", err)
+		}
+		agentOpts = append(agentOpts, useragent.WithAuditor(transcripts))
+		replOpts = append(replOpts, repl.WithTranscripts(transcripts))
+	}
+
+	// Recall similar past sessions, summarize this one when it ends, and
+	// manage remembered facts with /memory
+	if mem != nil {
+		agentOpts = append(agentOpts, useragent.WithRecall(mem))
+		replOpts = append(replOpts, repl.WithMemory(mem), repl.WithFacts(mem))
+	}
+
+	// Have multi-step tasks in the REPL start with a plan; -p prints only
+	// the answer, so there is no one to show it to
+	switch {
+	case *question != "" || cfg.Tools.Plan == "off":
+	case cfg.Tools.Plan == "approve":
+		agentOpts = append(agentOpts, useragent.WithPlanning(repl.NewPlanApprover(os.Stdin, os.Stdout)))
+	default:
+		agentOpts = append(agentOpts, useragent.WithPlanning(nil))
+	}
+
+	// Offer each question only the tools relevant to it
+	if sel := cfg.Tools.Selection; sel.Mode != "off" {
+		agentOpts = append(agentOpts, useragent.WithToolSelection(sel.Mode, sel.MaxTools, sel.Always))
+	}
+
+	agentInstance := useragent.NewAgent(llmAdapter, executor, registry, systemPrompt, agentOpts...)
 
-	// Create session with message history limit to prevent unbounded growth
 	session := useragent.NewSession()
-	session.MaxMessages = 100 // Limit to 100 messages
+	if sessionStore != nil {
+		replOpts = append(replOpts, repl.WithSessionStore(sessionStore))
+
+		if *resume {
+			previous, err := useragent.LatestSession(ctx, sessionStore, "")
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				fmt.Fprintln(status, "No previous session to resume")
+			case err != nil:
+				log.Fatalf("Failed to load previous session: %v", err)
+			default:
+				session.Restore(previous)
+				fmt.Fprintf(status, "Resumed session %s (%d messages)\n", previous.ID, len(previous.Messages))
+			}
+		}
+	}
+
+	// Answer a single question for scripts and cron
+	if *question != "" {
+		var sessions useragent.SessionStore
+		if sessionStore != nil {
+			sessions = sessionStore
+		}
+		if err := runPrompt(ctx, os.Stdout, agentInstance, session, sessions, mem, *question, attachments); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Create and run REPL (pass config for model management and the session)
-	replInstance := repl.NewWithSession(agentInstance, cfg, session)
+	replInstance := repl.NewWithSession(agentInstance, cfg, session, replOpts...)
 	if err := replInstance.Run(ctx); err != nil {
 		log.Fatalf("REPL failed: %v", err)
 	}
+}
+
+// toolOptions turns the tools and aws config sections into registry options
+func toolOptions(cfg *config.Config) ([]tools.DefaultOption, error) {
+	sc := cfg.Tools.RunCommand.Sandbox
+	sandbox, err := runcmd.NewSandbox(sc.Backend, sc.Runtime, sc.Image, sc.Network)
+	if err != nil {
+		return nil, fmt.Errorf("tools.run_command.sandbox: %w", err)
+	}
+	ssh := cfg.Tools.SSH
+	sshHosts := make(map[string]sshrun.Host, len(ssh.Hosts))
+	for alias, h := range ssh.Hosts {
+		sshHosts[alias] = sshrun.Host(h)
+	}
+	databases := make(map[string]sqlquery.Database, len(cfg.Tools.SQL.Databases))
+	for alias, d := range cfg.Tools.SQL.Databases {
+		databases[alias] = sqlquery.Database(d)
+	}
+	return []tools.DefaultOption{
+		tools.WithAWS(cfg.AWS.Region, cfg.AWS.Profile),
+		tools.WithDisabledTools(cfg.Tools.Disabled),
+		tools.WithAllowedCommands(cfg.Tools.RunCommand.Allowed),
+		tools.WithCommandSandbox(sandbox),
+		tools.WithCommandDirs(cfg.Tools.RunCommand.AllowedDirs),
+		tools.WithCommandEnv(cfg.Tools.RunCommand.AllowedEnv),
+		tools.WithWritablePaths(cfg.Tools.WriteFile.AllowedPaths),
+		tools.WithSSH(sshHosts, ssh.Allowed, time.Duration(ssh.ConnectTimeoutSeconds)*time.Second),
+		tools.WithSQL(databases, cfg.Tools.SQL.MaxRows),
+		tools.WithFetchDomains(cfg.Tools.FetchURL.AllowedDomains, cfg.Tools.FetchURL.DeniedDomains, cfg.Tools.FetchURL.AllowPrivate),
+	}, nil
+}
+
+// registerPlugins adds the tools of executables in tools.plugin_dir, except
+// disabled ones. Plugins that fail to load are reported but don't stop joe.
+func registerPlugins(ctx context.Context, cfg *config.Config, registry *tools.Registry, status io.Writer) {
+	if cfg.Tools.PluginDir == "" {
+		return
+	}
+	dir, err := config.ExpandHome(cfg.Tools.PluginDir)
+	if err != nil {
+		slog.Warn("tool plugins unavailable", "error", err)
+		return
+	}
+	registered, err := plugin.RegisterTools(ctx, dir, registry)
+	if err != nil {
+		slog.Warn("some tool plugins are unavailable", "error", err)
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	registry.UnregisterMatching(cfg.Tools.Disabled)
+	if len(registered) > 0 {
+		fmt.Fprintf(status, "Loaded %d tool plugin(s) from %s\n", len(registered), dir)
+	}
+}
+
+// wrapAdapter adds the llm.filter rules, the model's generation settings,
+// instrumentation (logs, /stats, and OpenTelemetry spans and metrics),
+// retries, cost tracking, and the optional response cache to a provider
+// adapter. Retries wrap instrumentation so every attempt is counted; cache
+// hits never reach either, and cost nothing.
+func wrapAdapter(base llm.LLMAdapter, cfg *config.Config, logger *slog.Logger, mc config.ModelConfig, costs *cost.Tracker, stats *llm.StatsRegistry) llm.LLMAdapter {
+	tuned := llm.NewSettingsAdapter(filter(base, cfg, logger), llm.GenerationSettings{
+		MaxTokens:   mc.MaxTokens,
+		Temperature: mc.Temperature,
+		TopP:        mc.TopP,
+	})
+	instrumented := instrument(stats.Track(llm.NewInstrumentedAdapter(tuned, logger, mc.Provider, mc.Model)), logger, mc)
+	retry := cfg.LLM.Retry
+	var adapter llm.LLMAdapter = llm.NewRetryAdapter(instrumented, llm.RetryPolicy{
+		MaxAttempts: retry.MaxAttempts,
+		BaseDelay:   time.Duration(retry.BaseDelayMS) * time.Millisecond,
+		MaxDelay:    time.Duration(retry.MaxDelaySec) * time.Second,
+	}, logger)
+	adapter = costs.Wrap(adapter, mc.Model, mc.Pricing)
+
+	if cache := cfg.LLM.Cache; cache.TTLSeconds > 0 {
+		adapter = llm.NewCachingAdapter(adapter, time.Duration(cache.TTLSeconds)*time.Second, cache.MaxEntries)
+	}
+	return adapter
+}
+
+// instrument adds OpenTelemetry spans and metrics to adapter, which is
+// returned as is if they can't be set up
+func instrument(adapter llm.LLMAdapter, logger *slog.Logger, mc config.ModelConfig) llm.LLMAdapter {
+	traced, err := observability.NewLLMMiddleware(adapter, mc.Provider, mc.Model)
+	if err != nil {
+		logger.Warn("LLM telemetry unavailable", "error", err)
+		return adapter
+	}
+	return traced
+}
+
+// filter applies the llm.filter rules, checked at startup, to requests
+// sent through adapter. Each match is reported once, on stderr, so a dry
+// run shows what would be redacted without repeating it every turn.
+func filter(adapter llm.LLMAdapter, cfg *config.Config, logger *slog.Logger) llm.LLMAdapter {
+	rules, _ := llmfactory.FilterRules(cfg.LLM.Filter)
+	if len(rules) == 0 {
+		return adapter
+	}
+
+	var mu sync.Mutex
+	seen := make(map[llm.FilterMatch]bool)
+	opts := []llm.FilterOption{llm.WithFilterReport(func(matches []llm.FilterMatch, dryRun bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range matches {
+			key := llm.FilterMatch{Rule: m.Rule, Text: m.Text}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			switch {
+			case dryRun:
+				fmt.Fprintf(os.Stderr, "LLM filter (dry run): would %s %q (%s, %s)\n", m.Action, m.Text, m.Rule, m.Where)
+			case m.Action == llm.FilterRedact:
+				fmt.Fprintf(os.Stderr, "LLM filter: redacted %q (%s) before sending\n", m.Text, m.Rule)
+			}
+		}
+	})}
+	if cfg.LLM.Filter.DryRun {
+		opts = append(opts, llm.WithFilterDryRun())
+	}
+	return llm.NewFilterAdapter(adapter, rules, logger, opts...)
+}
+
+// modelInfo returns a model's capabilities from the built-in table, with
+// its configured context window and tool limit taking precedence
+func modelInfo(mc config.ModelConfig) llm.ModelInfo {
+	info, _ := llm.LookupModel(mc.Model)
+	if mc.ContextWindow > 0 {
+		info.ContextWindow = mc.ContextWindow
+	}
+	if mc.MaxTools > 0 {
+		info.MaxTools = mc.MaxTools
+	}
+	return info
+}
+
+// withFailover switches adapter over to the llm.fallback models, in order,
+// when the model with key current keeps failing. Fallback adapters are only
+// created when needed, and closed when switched away from.
+func withFailover(adapter llm.LLMAdapter, cfg *config.Config, logger *slog.Logger, current string, costs *cost.Tracker, stats *llm.StatsRegistry) (llm.LLMAdapter, error) {
+	keys, err := cfg.LLM.FallbackModels(current)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return adapter, nil
+	}
+
+	fallbacks := make([]llm.Fallback, len(keys))
+	for i, key := range keys {
+		mc := cfg.LLM.Available[key]
+		fallbacks[i] = llm.Fallback{
+			Name: key,
+			Connect: func(ctx context.Context) (llm.LLMAdapter, error) {
+				base, err := llmfactory.NewAdapter(ctx, mc)
+				if err != nil {
+					return nil, err
+				}
+				adapter := wrapAdapter(base, cfg, logger, mc, costs, stats)
+				if closer, ok := base.(io.Closer); ok {
+					return struct {
+						llm.LLMAdapter
+						io.Closer
+					}{adapter, closer}, nil
+				}
+				return adapter, nil
+			},
+		}
+	}
 
-	os.Exit(0)
+	return llm.NewFailoverAdapter(adapter, current, fallbacks, logger,
+		llm.WithFailoverNotify(func(from, to string, err error) {
+			fmt.Fprintf(os.Stderr, "\n%s is unavailable; switching to %s for the rest of this run\n", from, to)
+		})), nil
 }
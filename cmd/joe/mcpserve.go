@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/mcp"
+	"github.com/jaimegago/joe/internal/redact"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+// runMCPServe exposes Joe's local tools over MCP on stdin/stdout so other
+// agents (Claude Desktop, IDE agents) can call them. stdout carries the
+// protocol, so nothing else may be printed there. Calls get the same
+// policies, timeouts, and redaction as in joe, except that no one can
+// answer approval prompts, so tools with an "ask" policy are refused.
+func runMCPServe(ctx context.Context, cfg *config.Config) error {
+	opts, err := toolOptions(cfg)
+	if err != nil {
+		return err
+	}
+	registry := tools.NewDefaultRegistry(opts...)
+	registerPlugins(ctx, cfg, registry, io.Discard)
+
+	// ask_user reads from stdin, which belongs to the protocol in this mode
+	registry.Unregister("ask_user")
+
+	var redactor *redact.Redactor
+	if cfg.Redaction.Enabled {
+		if redactor, err = redact.New(cfg.Redaction.Patterns); err != nil {
+			return fmt.Errorf("invalid redaction config: %w", err)
+		}
+	}
+	policies, err := tools.ParseApprovalPolicies(cfg.Tools.Approval)
+	if err != nil {
+		return fmt.Errorf("invalid tool approval config: %w", err)
+	}
+	defaultTimeout, timeouts := tools.ParseTimeouts(cfg.Tools.TimeoutSeconds, cfg.Tools.Timeouts)
+	executor := tools.NewExecutor(registry,
+		tools.WithApproval(policies, nil, false),
+		tools.WithTimeouts(defaultTimeout, timeouts),
+		tools.WithRedactor(redactor),
+	)
+
+	slog.Info("serving tools over MCP stdio", "tools", len(registry.GetAll()))
+
+	srv := mcp.NewServer(registry, executor, mcp.Implementation{Name: "joe", Version: "0.1.0"})
+	if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		return fmt.Errorf("mcp server failed: %w", err)
+	}
+	return nil
+}
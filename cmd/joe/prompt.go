@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jaimegago/joe/internal/memory"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// Attachments are cut at maxAttachmentBytes each, so one large log can't
+// crowd out the rest, and may not exceed maxAttachmentsBytes together
+const (
+	maxAttachmentBytes  = 64 * 1024
+	maxAttachmentsBytes = 256 * 1024
+)
+
+// attachment is piped input or a file given with -f, sent along with the
+// -p question
+type attachment struct {
+	name    string // "stdin" or the file path
+	content []byte
+	size    int // Before truncation
+}
+
+// readAttachments reads stdin, when it is piped rather than a terminal, and
+// files. Binary content is refused.
+func readAttachments(stdin *os.File, files []string) ([]attachment, error) {
+	var attachments []attachment
+	add := func(name string, r io.Reader) error {
+		data, err := io.ReadAll(io.LimitReader(r, maxAttachmentBytes+1))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		size := len(data)
+		if size > maxAttachmentBytes {
+			// The rest is only counted, for the truncation note
+			rest, err := io.Copy(io.Discard, r)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			size += int(rest)
+			// Cut before the rune straddling the limit, so the text stays
+			// valid UTF-8
+			cut := maxAttachmentBytes
+			for cut > 0 && !utf8.RuneStart(data[cut]) {
+				cut--
+			}
+			data = data[:cut]
+		}
+		if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+			return fmt.Errorf("%s looks like a binary file; only text can be attached", name)
+		}
+		attachments = append(attachments, attachment{name: name, content: data, size: size})
+		return nil
+	}
+
+	if info, err := stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		if err := add("stdin", stdin); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach %s: %w", path, err)
+		}
+		err = add(path, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	total := 0
+	for _, a := range attachments {
+		total += len(a.content)
+	}
+	if total > maxAttachmentsBytes {
+		return nil, fmt.Errorf("attachments total %d KiB, over the %d KiB limit; attach less or trim the input",
+			total/1024, maxAttachmentsBytes/1024)
+	}
+	return attachments, nil
+}
+
+// withAttachments appends attachments to the question, each in a tag
+// naming where it came from
+func withAttachments(question string, attachments []attachment) string {
+	if len(attachments) == 0 {
+		return question
+	}
+	var b strings.Builder
+	b.WriteString(question)
+	for _, a := range attachments {
+		tag := "file"
+		attr := fmt.Sprintf(" path=%q", a.name)
+		if a.name == "stdin" {
+			tag, attr = "stdin", ""
+		}
+		fmt.Fprintf(&b, "\n\n<%s%s>\n%s", tag, attr, strings.TrimRight(string(a.content), "\n"))
+		if len(a.content) < a.size {
+			fmt.Fprintf(&b, "\n(truncated: first %d of %d bytes)", len(a.content), a.size)
+		}
+		fmt.Fprintf(&b, "\n</%s>", tag)
+	}
+	return b.String()
+}
+
+// runPrompt handles `joe -p`: it runs a single agent turn on question, with
+// any attachments, and writes the answer to w. The session is saved, when
+// sessions is set, so `joe -resume` can follow up on it, and then
+// summarized for recall when mem is set.
+func runPrompt(ctx context.Context, w io.Writer, agent *useragent.Agent, session *useragent.Session, sessions useragent.SessionStore, mem *memory.Memory, question string, attachments []attachment) error {
+	answer, err := agent.Run(ctx, session, withAttachments(question, attachments))
+	if err == nil {
+		if _, err := fmt.Fprintln(w, answer); err != nil {
+			return err
+		}
+		if session.RunIncomplete {
+			fmt.Fprintln(os.Stderr, "Warning: the answer is incomplete; joe hit its limit of steps. Follow up with --resume.")
+		}
+	}
+	if sessions != nil {
+		if err := useragent.SaveSession(ctx, sessions, session); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: session not saved: %v\n", err)
+		} else if mem != nil {
+			if err := mem.Remember(ctx, session.ID); err != nil {
+				slog.Warn("failed to summarize session", "session", session.ID, "error", err)
+			}
+		}
+	}
+	return err
+}
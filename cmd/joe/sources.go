@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jaimegago/joe/internal/client"
+	"github.com/jaimegago/joe/internal/config"
+)
+
+const sourcesUsage = `Usage:
+  joe sources                          List registered sources
+  joe sources add [flags] <id>         Register a source
+  joe sources update [flags] <id>      Change a source's settings
+  joe sources remove <id>              Remove a source
+  joe sources test <id>                Check that joecored can reach a source
+
+Flags for add and update:
+`
+
+// runSources manages the sources joecored refreshes the graph from
+func runSources(ctx context.Context, cfg *config.Config, args []string) error {
+	c := client.New("http://" + cfg.Server.Address)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if len(args) == 0 || args[0] == "list" {
+		return listSources(ctx, c, os.Stdout)
+	}
+
+	switch args[0] {
+	case "add", "update":
+		return saveSource(ctx, c, args[0], args[1:])
+	case "remove", "rm":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: joe sources remove <id>")
+		}
+		if err := c.DeleteSource(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s\n", args[1])
+		return nil
+	case "test":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: joe sources test <id>")
+		}
+		result, err := c.TestSource(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		if !result.Connected {
+			return fmt.Errorf("%s is unreachable: %s", args[1], result.Message)
+		}
+		fmt.Printf("%s is reachable: %s\n", args[1], result.Message)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, sourcesUsage)
+		sourceFlags(&client.Source{}).PrintDefaults()
+		return fmt.Errorf("unknown sources command %q", args[0])
+	}
+}
+
+// listSources prints sources as a table
+func listSources(ctx context.Context, c *client.Client, out io.Writer) error {
+	sources, err := c.ListSources(ctx)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		fmt.Fprintln(out, "No sources registered. Add one with: joe sources add -type kubernetes -url <api-server> <id>")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tENV\tSTATUS\tLAST CONNECTED\tURL")
+	for _, s := range sources {
+		last := "never"
+		if s.LastConnected != nil {
+			last = s.LastConnected.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.ID, s.Type, dash(s.Environment), dash(s.Status), last, dash(s.URL))
+	}
+	return w.Flush()
+}
+
+// saveSource registers a new source, or updates one with the flags given
+func saveSource(ctx context.Context, c *client.Client, action string, args []string) error {
+	src := client.Source{}
+	if action == "update" && len(args) > 0 {
+		id := args[len(args)-1]
+		sources, err := c.ListSources(ctx)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, s := range sources {
+			if s.ID == id {
+				src, found = s, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("no source %q", id)
+		}
+	}
+
+	fs := sourceFlags(&src)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: joe sources %s [flags] <id>", action)
+	}
+	src.ID = fs.Arg(0)
+
+	if action == "add" {
+		added, err := c.AddSource(ctx, src)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Added %s (%s). Check it with: joe sources test %s\n", added.ID, added.Type, added.ID)
+		return nil
+	}
+	if _, err := c.UpdateSource(ctx, src); err != nil {
+		return err
+	}
+	fmt.Printf("Updated %s\n", src.ID)
+	return nil
+}
+
+// sourceFlags binds add/update flags to src, starting from its values
+func sourceFlags(src *client.Source) *flag.FlagSet {
+	fs := flag.NewFlagSet("sources", flag.ContinueOnError)
+	fs.StringVar(&src.Type, "type", src.Type, "source type: kubernetes, git, aws, prometheus, argocd, loki, or http")
+	fs.StringVar(&src.URL, "url", src.URL, "API server, repository, or endpoint URL")
+	fs.StringVar(&src.Name, "name", src.Name, "display name")
+	fs.StringVar(&src.Environment, "env", src.Environment, "environment, e.g. prod or staging")
+	fs.Func("category", "category, e.g. orchestration (repeatable)", func(v string) error {
+		src.Categories = append(src.Categories, v)
+		return nil
+	})
+	fs.Func("context", "kubeconfig context, for kubernetes sources reached through kubectl", func(v string) error {
+		setDetail(src, "context", v)
+		return nil
+	})
+	fs.Func("kubeconfig", "kubeconfig file for -context", func(v string) error {
+		setDetail(src, "kubeconfig", v)
+		return nil
+	})
+	fs.Func("path", "local checkout, for git sources; its .joe/ files describe what the repository deploys", func(v string) error {
+		setDetail(src, "path", v)
+		return nil
+	})
+	fs.Func("region", "AWS region, for aws sources (default: aws.region in config.yaml)", func(v string) error {
+		setDetail(src, "region", v)
+		return nil
+	})
+	fs.Func("profile", "AWS shared config profile, for aws sources", func(v string) error {
+		setDetail(src, "profile", v)
+		return nil
+	})
+	fs.Func("role-arn", "IAM role to assume, for aws sources, e.g. a read-only one", func(v string) error {
+		setDetail(src, "role_arn", v)
+		return nil
+	})
+	return fs
+}
+
+func setDetail(src *client.Source, key, value string) {
+	if src.ConnectionDetails == nil {
+		src.ConnectionDetails = make(map[string]any)
+	}
+	src.ConnectionDetails[key] = value
+}
+
+func dash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
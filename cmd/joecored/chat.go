@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jaimegago/joe/internal/audit"
+	"github.com/jaimegago/joe/internal/client"
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/prompt"
+	"github.com/jaimegago/joe/internal/redact"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/tools/local/runcmd"
+	"github.com/jaimegago/joe/internal/tools/local/sqlquery"
+	"github.com/jaimegago/joe/internal/tools/local/sshrun"
+	"github.com/jaimegago/joe/internal/tools/plugin"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// newChatRunner sets up the agent loop behind POST /api/v1/chat, with the
+// same tools, prompt, compaction, and transcripts as joe. No one can answer
+// approval prompts here, so tools with an "ask" policy are refused,
+// ask_user is left out, and plans are shown without waiting for approval.
+// The graph tools reach the graph through joecored's own API. The agent is
+// returned for switching models on a config reload.
+func newChatRunner(cfg *config.Config, adapter llm.LLMAdapter, st store.Store, redactor *redact.Redactor, factory useragent.AdapterFactory) (*useragent.Runner, *useragent.Agent, error) {
+	sc := cfg.Tools.RunCommand.Sandbox
+	sandbox, err := runcmd.NewSandbox(sc.Backend, sc.Runtime, sc.Image, sc.Network)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid tools.run_command.sandbox config: %w", err)
+	}
+	ssh := cfg.Tools.SSH
+	sshHosts := make(map[string]sshrun.Host, len(ssh.Hosts))
+	for alias, h := range ssh.Hosts {
+		sshHosts[alias] = sshrun.Host(h)
+	}
+	databases := make(map[string]sqlquery.Database, len(cfg.Tools.SQL.Databases))
+	for alias, d := range cfg.Tools.SQL.Databases {
+		databases[alias] = sqlquery.Database(d)
+	}
+	toolOpts := []tools.DefaultOption{
+		tools.WithAWS(cfg.AWS.Region, cfg.AWS.Profile),
+		tools.WithDisabledTools(cfg.Tools.Disabled),
+		tools.WithAllowedCommands(cfg.Tools.RunCommand.Allowed),
+		tools.WithCommandSandbox(sandbox),
+		tools.WithCommandDirs(cfg.Tools.RunCommand.AllowedDirs),
+		tools.WithCommandEnv(cfg.Tools.RunCommand.AllowedEnv),
+		tools.WithWritablePaths(cfg.Tools.WriteFile.AllowedPaths),
+		tools.WithSSH(sshHosts, ssh.Allowed, time.Duration(ssh.ConnectTimeoutSeconds)*time.Second),
+		tools.WithSQL(databases, cfg.Tools.SQL.MaxRows),
+		tools.WithFetchDomains(cfg.Tools.FetchURL.AllowedDomains, cfg.Tools.FetchURL.DeniedDomains, cfg.Tools.FetchURL.AllowPrivate),
+		tools.WithGraph(client.New("http://" + cfg.Server.Address)),
+	}
+	if cfg.Tools.SourceTools == "registered" {
+		sources, err := st.ListSources(context.Background())
+		if err != nil {
+			return nil, nil, fmt.Errorf("list sources for tools: %w", err)
+		}
+		var types []string
+		for _, s := range sources {
+			types = append(types, s.Type)
+		}
+		toolOpts = append(toolOpts, tools.WithSourceTypes(types))
+	}
+	registry := tools.NewDefaultRegistry(toolOpts...)
+	registry.Unregister("ask_user")
+	if cfg.Tools.PluginDir != "" {
+		dir, err := config.ExpandHome(cfg.Tools.PluginDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid tools.plugin_dir: %w", err)
+		}
+		registered, err := plugin.RegisterTools(context.Background(), dir, registry)
+		if err != nil {
+			slog.Warn("some tool plugins are unavailable", "error", err)
+		}
+		registry.UnregisterMatching(cfg.Tools.Disabled)
+		slog.Info("tool plugins loaded", "dir", dir, "tools", registered)
+	}
+
+	policies, err := tools.ParseApprovalPolicies(cfg.Tools.Approval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid tool approval config: %w", err)
+	}
+	defaultTimeout, timeouts := tools.ParseTimeouts(cfg.Tools.TimeoutSeconds, cfg.Tools.Timeouts)
+	executorOpts := []tools.ExecutorOption{
+		tools.WithApproval(policies, nil, false),
+		tools.WithTimeouts(defaultTimeout, timeouts),
+		tools.WithRedactor(redactor),
+	}
+	if len(cfg.Tools.Cache.TTLSeconds) > 0 {
+		executorOpts = append(executorOpts, tools.WithResultCache(
+			tools.ParseCacheTTLs(cfg.Tools.Cache.TTLSeconds), cfg.Tools.Cache.InvalidateOn))
+	}
+	if instrument, err := tools.NewInstrumentation(); err != nil {
+		slog.Warn("tool telemetry unavailable", "error", err)
+	} else {
+		executorOpts = append(executorOpts, tools.WithMiddleware(instrument))
+	}
+	executor := tools.NewExecutor(registry, executorOpts...)
+
+	var toolNames []string
+	for _, def := range registry.ToDefinitions() {
+		toolNames = append(toolNames, def.Name)
+	}
+	systemPrompt, err := prompt.Build(cfg.Prompt, prompt.CurrentVars(toolNames, cfg.LLM.Current))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid prompt config: %w", err)
+	}
+	files, err := prompt.LoadContext(cfg.Prompt.ContextFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agentOpts := []useragent.AgentOption{
+		useragent.WithAdapterFactory(factory),
+		useragent.WithCurrentModelName(cfg.LLM.Current),
+		useragent.WithCompaction(cfg.LLM.Compaction.ThresholdTokens, cfg.LLM.Compaction.KeepTurns),
+		useragent.WithModelInfo(func(name string) llm.ModelInfo {
+			mc := cfg.LLM.Available[name]
+			info, _ := llm.LookupModel(mc.Model)
+			if mc.ContextWindow > 0 {
+				info.ContextWindow = mc.ContextWindow
+			}
+			if mc.MaxTools > 0 {
+				info.MaxTools = mc.MaxTools
+			}
+			return info
+		}),
+	}
+	if cfg.Tools.Plan != "off" {
+		agentOpts = append(agentOpts, useragent.WithPlanning(nil))
+	}
+	if sel := cfg.Tools.Selection; sel.Mode != "off" {
+		agentOpts = append(agentOpts, useragent.WithToolSelection(sel.Mode, sel.MaxTools, sel.Always))
+	}
+	if cfg.Audit.Enabled {
+		dir, err := config.ExpandHome(cfg.Audit.Dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		transcripts, err := audit.NewWriter(dir, audit.WithRedactor(redactor))
+		if err != nil {
+			return nil, nil, err
+		}
+		agentOpts = append(agentOpts, useragent.WithAuditor(transcripts))
+	}
+
+	agent := useragent.NewAgent(adapter, executor, registry, prompt.WithContext(systemPrompt, files), agentOpts...)
+	runner := useragent.NewRunner(agent, st,
+		useragent.WithSessionExpiry(time.Duration(cfg.Server.SessionTTLHours)*time.Hour),
+		useragent.WithMaxSessions(cfg.Server.MaxSessions))
+	return runner, agent, nil
+}
@@ -2,34 +2,83 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/jaimegago/joe/internal/api"
 	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/coreagent"
+	"github.com/jaimegago/joe/internal/cost"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/llmfactory"
 	"github.com/jaimegago/joe/internal/logging"
+	"github.com/jaimegago/joe/internal/notify"
+	"github.com/jaimegago/joe/internal/observability"
+	"github.com/jaimegago/joe/internal/redact"
+	"github.com/jaimegago/joe/internal/sources"
+	awssource "github.com/jaimegago/joe/internal/sources/aws"
+	gitsource "github.com/jaimegago/joe/internal/sources/git"
+	kubesource "github.com/jaimegago/joe/internal/sources/kubernetes"
+	"github.com/jaimegago/joe/internal/useragent"
 )
 
 func main() {
+	configPath := flag.String("config", "~/.joe/config.yaml", "path to config file")
+	listenAddr := flag.String("addr", "", "address to listen on, e.g. localhost:7778 (overrides server.address)")
+	level := flag.String("log-level", "", "debug, info, warn, or error (overrides logging.level)")
+	checkConfig := flag.Bool("check-config", false, "check the config file strictly, report every problem, and exit")
+	flag.Parse()
+	if *level != "" && !slices.Contains([]string{"debug", "info", "warn", "error"}, *level) {
+		fmt.Fprintf(os.Stderr, "Error: invalid -log-level %q (want debug, info, warn, or error)\n", *level)
+		os.Exit(2)
+	}
+
 	// Setup initial logger at info level
 	initialLogger := logging.SetupLogger("info")
 	slog.SetDefault(initialLogger)
 
-	// Load config (defaults to ~/.joe/config.yaml if exists, otherwise uses hardcoded defaults)
-	configPath := "~/.joe/config.yaml"
-	cfg, err := config.Load(configPath)
+	// Load config (defaults to ~/.joe/config.yaml if exists, otherwise uses
+	// hardcoded defaults). Flags override the file, also on a reload.
+	if *checkConfig {
+		os.Exit(checkConfigFile(*configPath))
+	}
+	applyFlags := func(cfg *config.Config) {
+		if *listenAddr != "" {
+			cfg.Server.Address = *listenAddr
+		}
+		if *level != "" {
+			cfg.Logging.Level = *level
+		}
+	}
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		slog.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	applyFlags(cfg)
+
+	// Mask secrets in tool results, logs, and transcripts unless
+	// redaction.enabled is false; a nil redactor masks nothing
+	var redactor *redact.Redactor
+	if cfg.Redaction.Enabled {
+		if redactor, err = redact.New(cfg.Redaction.Patterns); err != nil {
+			slog.Error("invalid redaction config", "error", err)
+			os.Exit(1)
+		}
+	}
 
-	// Reconfigure logger based on config level
-	logger := logging.SetupLogger(cfg.Logging.Level)
+	// Reconfigure logger based on config level, which SIGHUP can change
+	logLevel := new(slog.LevelVar)
+	logger := logging.SetupLogger(cfg.Logging.Level, logging.WithRedactor(redactor), logging.WithLevel(logLevel))
 	slog.SetDefault(logger)
 
 	// Log debug mode if enabled
@@ -48,16 +97,95 @@ func main() {
 		"refresh.interval_minutes", cfg.Refresh.IntervalMinutes,
 		"logging.level", cfg.Logging.Level,
 		"llm.model", modelInfo,
+		"store.path", cfg.Store.Path,
+		"graph.path", cfg.Graph.Path,
 	)
 
-	// Get listen address from config (defaults to localhost:7777)
+	// OpenTelemetry traces and metrics, per the telemetry config section
+	shutdownTelemetry, err := observability.Setup(context.Background(),
+		observability.FromConfig(cfg.Telemetry, "joecored", cfg.Telemetry.MetricsPort))
+	if err != nil {
+		slog.Error("failed to set up telemetry", "error", err)
+		os.Exit(1)
+	}
+
+	// Open core services (SQL store, graph)
+	services, err := core.New(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize core services", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := services.Close(); err != nil {
+			slog.Error("failed to close core services", "error", err)
+		}
+	}()
+
+	// The current model, for chat and, held to refresh.llm_budget, the
+	// refresh path. joecored runs without one, e.g. when the API key isn't
+	// set; chat is then unavailable.
+	var chatAgent *useragent.Agent
+	models := newModels(cfg, services.Costs, logger)
+	defer models.close()
+	apiOpts := []api.Option{api.WithStats(models.stats), api.WithRedactor(redactor)}
+	chatLLM, err := models.connect(context.Background(), cfg.LLM.Current)
+	if err != nil {
+		slog.Warn("LLM unavailable", "error", err)
+	} else {
+		services.LLM = llm.NewRateLimitAdapter(chatLLM, cfg.Refresh.LLMBudget.MaxCallsPerHour, logger)
+
+		runner, agent, err := newChatRunner(cfg, chatLLM, services.Store, redactor, models.factory)
+		if err != nil {
+			slog.Error("failed to set up chat", "error", err)
+			os.Exit(1)
+		}
+		apiOpts = append(apiOpts, api.WithChat(runner))
+		chatAgent = agent
+	}
+
+	// Get listen address from config or -addr (defaults to localhost:7777)
 	addr := cfg.Server.Address
 
 	// Setup HTTP server
 	mux := http.NewServeMux()
 
+	// Notifications, e.g. on the desktop, when sources start failing to
+	// refresh
+	notifier, err := notify.FromConfig(cfg.Notifications, logger)
+	if err != nil {
+		slog.Error("invalid notifications config", "error", err)
+		os.Exit(1)
+	}
+
+	// Core Agent background refresh, reading each source with the connector
+	// for its type; runs record which sources were scanned and skip types
+	// without one. Git repositories' .joe/ files are interpreted with the
+	// refresh path's LLM, when there is one, batched while its budget is
+	// spent. Connection tests use the same connectors.
+	connectors := sources.NewRegistry()
+	for sourceType, c := range map[string]sources.SourceConnector{
+		"aws": awssource.New(cfg.AWS),
+		"git": gitsource.New(services.Store, services.LLM,
+			gitsource.WithModel(cfg.LLM.Current), gitsource.WithLogger(logger),
+			gitsource.WithBatching(cfg.Refresh.LLMBudget.BatchThreshold, cfg.Refresh.LLMBudget.BatchTimeout)),
+		"kubernetes": kubesource.New(),
+	} {
+		if err := connectors.Register(sourceType, c); err != nil {
+			slog.Error("failed to register source connector", "error", err)
+			os.Exit(1)
+		}
+	}
+	refresher := coreagent.NewRefresher(services.Graph, services.Store, cfg.Refresh.Interval,
+		coreagent.WithConnectors(connectors),
+		coreagent.WithRefreshEvents(services.Events),
+		coreagent.WithRefreshNotifier(notifier),
+		coreagent.WithNodeExpiry(cfg.Refresh.StaleAfter, cfg.Refresh.StaleTTL),
+		coreagent.WithEdgePromotion(cfg.Refresh.PromoteAfter),
+		coreagent.WithRefreshLogger(logger))
+
 	// Register API routes
-	apiServer := api.New()
+	apiServer := api.New(services, append(apiOpts,
+		api.WithRefresher(refresher), api.WithConnectors(connectors))...)
 	apiServer.RegisterRoutes(mux)
 
 	server := &http.Server{
@@ -77,20 +205,139 @@ func main() {
 		}
 	}()
 
-	// TODO: Start Core Agent background refresh here
-	slog.Info("core agent ready (background refresh not yet implemented)")
+	// Start Core Agent background refresh, and the sending of notifications
+	// held back by quiet hours
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	refreshDone := make(chan struct{})
+	go func() {
+		defer close(refreshDone)
+		refresher.Run(refreshCtx)
+	}()
+	go notifier.Run(refreshCtx)
+	slog.Info("core agent ready")
+
+	// Re-read the config file on SIGHUP
+	reloader := &reloader{
+		path:      *configPath,
+		override:  applyFlags,
+		cfg:       cfg,
+		logLevel:  logLevel,
+		refresher: refresher,
+		models:    models,
+		agent:     chatAgent,
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloader.reload(context.Background())
+		}
+	}()
 
 	// Wait for shutdown signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	signal.Stop(hup)
 
 	slog.Info("shutting down...")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Let a refresh in progress finish its current source and record the
+	// run before the store closes
+	stopRefresh()
+	select {
+	case <-refreshDone:
+	case <-ctx.Done():
+		slog.Warn("background refresh did not stop in time")
+	}
+
+	// End event streams, which would otherwise hold Shutdown until ctx
+	// expires
+	services.Events.Close()
 	if err := server.Shutdown(ctx); err != nil {
 		slog.Error("shutdown error", "error", err)
 	}
+	if err := shutdownTelemetry(ctx); err != nil {
+		slog.Error("telemetry shutdown error", "error", err)
+	}
 	slog.Info("joecored stopped")
 }
+
+// checkConfigFile prints the problems in the config file at path and
+// returns the exit code: 0 if there are none
+func checkConfigFile(path string) int {
+	problems, err := config.Check(path)
+	switch {
+	case os.IsNotExist(err):
+		fmt.Printf("%s doesn't exist; joecored uses the built-in defaults\n", path)
+		return 0
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, p := range problems {
+		fmt.Printf("%s: %s\n", path, p)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	fmt.Printf("%s is valid\n", path)
+	return 0
+}
+
+// newLLM connects to the model with key name in llm.available, with its
+// filter rules, settings, instrumentation, retries, and cost tracking like
+// joe. The returned func releases the provider client.
+func newLLM(ctx context.Context, cfg *config.Config, name string, costs *cost.Tracker, stats *llm.StatsRegistry, logger *slog.Logger) (llm.LLMAdapter, func(), error) {
+	mc, ok := cfg.LLM.Available[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("model %q not found in llm.available", name)
+	}
+	if err := config.ValidateAPIKeys(mc); err != nil {
+		return nil, nil, err
+	}
+	base, err := llmfactory.NewAdapter(ctx, mc)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeBase := func() {}
+	if closer, ok := base.(io.Closer); ok {
+		closeBase = func() { closer.Close() }
+	}
+
+	// Requests pass the llm.filter rules before anything else sees them
+	var filtered llm.LLMAdapter = base
+	rules, err := llmfactory.FilterRules(cfg.LLM.Filter)
+	if err != nil {
+		closeBase()
+		return nil, nil, err
+	}
+	if len(rules) > 0 {
+		var opts []llm.FilterOption
+		if cfg.LLM.Filter.DryRun {
+			opts = append(opts, llm.WithFilterDryRun())
+		}
+		filtered = llm.NewFilterAdapter(base, rules, logger, opts...)
+	}
+
+	tuned := llm.NewSettingsAdapter(filtered, llm.GenerationSettings{
+		MaxTokens:   mc.MaxTokens,
+		Temperature: mc.Temperature,
+		TopP:        mc.TopP,
+	})
+	retry := cfg.LLM.Retry
+	var instrumented llm.LLMAdapter = stats.Track(llm.NewInstrumentedAdapter(tuned, logger, mc.Provider, mc.Model))
+	if traced, err := observability.NewLLMMiddleware(instrumented, mc.Provider, mc.Model); err != nil {
+		logger.Warn("LLM telemetry unavailable", "error", err)
+	} else {
+		instrumented = traced
+	}
+	adapter := llm.NewRetryAdapter(instrumented, llm.RetryPolicy{
+		MaxAttempts: retry.MaxAttempts,
+		BaseDelay:   time.Duration(retry.BaseDelayMS) * time.Millisecond,
+		MaxDelay:    time.Duration(retry.MaxDelaySec) * time.Second,
+	}, logger)
+	return costs.Wrap(adapter, mc.Model, mc.Pricing), closeBase, nil
+}
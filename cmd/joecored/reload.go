@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/coreagent"
+	"github.com/jaimegago/joe/internal/cost"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/logging"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// models connects to the models in llm.available, as of the last config
+// reload, and releases their clients on shutdown
+type models struct {
+	costs  *cost.Tracker
+	stats  *llm.StatsRegistry
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	cfg     *config.Config
+	closers []func()
+}
+
+func newModels(cfg *config.Config, costs *cost.Tracker, logger *slog.Logger) *models {
+	return &models{cfg: cfg, costs: costs, stats: llm.NewStatsRegistry(), logger: logger}
+}
+
+// setConfig makes later connections use cfg
+func (m *models) setConfig(cfg *config.Config) {
+	m.mu.Lock()
+	m.cfg = cfg
+	m.mu.Unlock()
+}
+
+// connect connects to the model with key name
+func (m *models) connect(ctx context.Context, name string) (llm.LLMAdapter, error) {
+	m.mu.Lock()
+	cfg := m.cfg
+	m.mu.Unlock()
+
+	adapter, closeFn, err := newLLM(ctx, cfg, name, m.costs, m.stats, m.logger)
+	if err != nil {
+		return nil, err
+	}
+	// Requests may still be using an adapter after a switch, so clients are
+	// only released on shutdown
+	m.mu.Lock()
+	m.closers = append(m.closers, closeFn)
+	m.mu.Unlock()
+	return adapter, nil
+}
+
+// factory is the chat agent's useragent.AdapterFactory
+func (m *models) factory(ctx context.Context, provider, model string) (llm.LLMAdapter, error) {
+	m.mu.Lock()
+	available := m.cfg.LLM.Available
+	m.mu.Unlock()
+	for name, mc := range available {
+		if mc.Provider == provider && mc.Model == model {
+			return m.connect(ctx, name)
+		}
+	}
+	return nil, fmt.Errorf("model config not found for provider=%s model=%s", provider, model)
+}
+
+func (m *models) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, closeFn := range m.closers {
+		closeFn()
+	}
+}
+
+// reloader applies changes to the config file while joecored runs
+type reloader struct {
+	path      string
+	override  func(*config.Config) // Applies command-line flags over the file
+	cfg       *config.Config       // In effect: as loaded, plus the changes applied since
+	logLevel  *slog.LevelVar
+	refresher *coreagent.Refresher
+	models    *models
+	agent     *useragent.Agent // nil when chat is unavailable
+}
+
+// reload re-reads the config file and applies changes to logging.level,
+// refresh.interval_minutes, and the models. Other changes are logged as
+// needing a restart, on every reload until joecored is restarted.
+func (r *reloader) reload(ctx context.Context) {
+	next, err := config.Load(r.path)
+	if err != nil {
+		slog.Error("config reload failed; keeping the current config", "path", r.path, "error", err)
+		return
+	}
+	r.override(next)
+	changes := config.Changes(r.cfg, next)
+	if len(changes) == 0 {
+		slog.Info("config reloaded: no changes", "path", r.path)
+		return
+	}
+
+	applied := *r.cfg
+	applied.Logging.Level = next.Logging.Level
+	applied.Refresh.IntervalMinutes = next.Refresh.IntervalMinutes
+	applied.Refresh.Interval = next.Refresh.Interval
+	applied.LLM.Current = next.LLM.Current
+	applied.LLM.Available = maps.Clone(next.LLM.Available)
+
+	r.logLevel.Set(logging.ParseLevel(applied.Logging.Level))
+	r.refresher.SetInterval(applied.Refresh.Interval)
+	r.models.setConfig(&applied)
+	if err := r.switchModel(ctx, changes, &applied); err != nil {
+		slog.Error("config reload: failed to switch models; keeping the current one", "error", err)
+		applied.LLM.Current = r.cfg.LLM.Current
+		applied.LLM.Available[r.cfg.LLM.Current] = r.cfg.LLM.Available[r.cfg.LLM.Current]
+	}
+	done, pending := config.Changes(r.cfg, &applied), config.Changes(&applied, next)
+	r.cfg = &applied
+
+	logger := slog.With("path", r.path)
+	for _, c := range done {
+		logger.Info("config reloaded: applied", "change", c.String())
+	}
+	for _, c := range pending {
+		logger.Warn("config reloaded: restart joecored to apply", "change", c.String())
+	}
+	if r.agent == nil && slices.ContainsFunc(done, func(c config.Change) bool { return c.In("llm") }) {
+		logger.Info("chat is unavailable; restart joecored to enable it with the new models")
+	}
+}
+
+// switchModel moves chat to the current model if it, or its settings,
+// changed
+func (r *reloader) switchModel(ctx context.Context, changes []config.Change, cfg *config.Config) error {
+	switched := false
+	for _, c := range changes {
+		if c.In("llm.current", "llm.available."+cfg.LLM.Current) {
+			switched = true
+		}
+	}
+	if !switched || r.agent == nil {
+		return nil
+	}
+	mc, err := cfg.LLM.CurrentModel()
+	if err != nil {
+		return err
+	}
+	return r.agent.SwitchModel(ctx, mc.Provider, mc.Model, cfg.LLM.Current)
+}
@@ -0,0 +1,117 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// Source types with built-in support
+const (
+	TypeKubernetes = "kubernetes"
+	TypeGit        = "git"
+	TypePrometheus = "prometheus"
+	TypeArgoCD     = "argocd"
+	TypeLoki       = "loki"
+	TypeHTTP       = "http"
+	TypeAWS        = "aws"
+)
+
+// SourceTypes lists the source types that can be registered
+var SourceTypes = []string{TypeKubernetes, TypeGit, TypePrometheus, TypeArgoCD, TypeLoki, TypeHTTP, TypeAWS}
+
+// probeTimeout bounds a single connection test
+const probeTimeout = 10 * time.Second
+
+// ValidateSource checks that a source has what Probe and the refresh need
+// for its type
+func ValidateSource(source store.Source) error {
+	if source.ID == "" {
+		return fmt.Errorf("source id is required")
+	}
+	known := false
+	for _, t := range SourceTypes {
+		known = known || source.Type == t
+	}
+	if !known {
+		return fmt.Errorf("unknown source type %q (expected one of %s)", source.Type, strings.Join(SourceTypes, ", "))
+	}
+	// A cluster can be reached through a kubeconfig context instead of its
+	// URL, and an AWS account through the SDK's credentials and region
+	if source.Type == TypeAWS {
+		return nil
+	}
+	if source.URL == "" && !(source.Type == TypeKubernetes && detail(source, "context") != "") {
+		return fmt.Errorf("%s source requires a url", source.Type)
+	}
+	return nil
+}
+
+// Probe checks that a source can be reached, without reading any of its
+// state. Kubernetes sources with a kubeconfig context and git sources are
+// checked with kubectl and git, so their credentials apply; everything
+// else with an HTTP request to the source URL.
+func Probe(ctx context.Context, source store.Source) Status {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	switch {
+	case source.Type == TypeKubernetes && detail(source, "context") != "":
+		args := []string{"--context", detail(source, "context")}
+		if kubeconfig := detail(source, "kubeconfig"); kubeconfig != "" {
+			args = append(args, "--kubeconfig", kubeconfig)
+		}
+		return probeCommand(ctx, "kubectl", append(args, "version", "--request-timeout=5s")...)
+	case source.Type == TypeGit:
+		return probeCommand(ctx, "git", "ls-remote", "--exit-code", source.URL, "HEAD")
+	default:
+		return probeHTTP(ctx, source.URL)
+	}
+}
+
+// probeHTTP treats any response below 500 as reachable; auth failures are
+// reported in the message since the source's credentials aren't sent
+func probeHTTP(ctx context.Context, url string) Status {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Status{Message: fmt.Sprintf("invalid url: %v", err)}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Status{Message: err.Error()}
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return Status{Message: fmt.Sprintf("server error: %s", resp.Status)}
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return Status{Connected: true, Message: fmt.Sprintf("reachable, authentication required (%s)", resp.Status)}
+	default:
+		return Status{Connected: true, Message: resp.Status}
+	}
+}
+
+// probeCommand runs a CLI that exits non-zero when the source is unreachable
+func probeCommand(ctx context.Context, name string, args ...string) Status {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return Status{Message: fmt.Sprintf("%s failed: %s", name, msg)}
+	}
+	return Status{Connected: true, Message: "ok"}
+}
+
+// detail returns a string from the source's connection details
+func detail(source store.Source, key string) string {
+	v, _ := source.ConnectionDetails[key].(string)
+	return v
+}
@@ -0,0 +1,62 @@
+package adapters
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+func TestValidateSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  store.Source
+		wantErr bool
+	}{
+		{name: "prometheus", source: store.Source{ID: "prom", Type: TypePrometheus, URL: "http://prom:9090"}},
+		{name: "kubernetes by context", source: store.Source{ID: "k8s/prod", Type: TypeKubernetes,
+			ConnectionDetails: map[string]any{"context": "prod"}}},
+		{name: "aws without url", source: store.Source{ID: "aws-prod", Type: TypeAWS,
+			ConnectionDetails: map[string]any{"region": "eu-west-1"}}},
+		{name: "missing id", source: store.Source{Type: TypeGit, URL: "git@github.com:acme/infra.git"}, wantErr: true},
+		{name: "unknown type", source: store.Source{ID: "x", Type: "mainframe", URL: "http://x"}, wantErr: true},
+		{name: "missing url", source: store.Source{ID: "prom", Type: TypePrometheus}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSource(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbe_HTTP(t *testing.T) {
+	codes := map[string]int{"/ok": 200, "/auth": 401, "/down": 503}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(codes[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path          string
+		wantConnected bool
+	}{
+		{path: "/ok", wantConnected: true},
+		{path: "/auth", wantConnected: true},
+		{path: "/down", wantConnected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			status := Probe(context.Background(), store.Source{ID: "prom", Type: TypePrometheus, URL: srv.URL + tt.path})
+			if status.Connected != tt.wantConnected || status.Message == "" {
+				t.Errorf("Probe() = %+v, want connected %v with a message", status, tt.wantConnected)
+			}
+		})
+	}
+}
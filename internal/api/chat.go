@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/cost"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// Chatter runs the agent loop on a message in a stored conversation
+type Chatter interface {
+	Chat(ctx context.Context, sessionID, message string, onEvent useragent.EventHandler) (*useragent.ChatResult, error)
+}
+
+// chatRequest is the body of POST /api/v1/chat. An empty SessionID starts
+// a new conversation.
+type chatRequest struct {
+	SessionID string `json:"session_id,omitempty"`
+	Message   string `json:"message"`
+	Stream    bool   `json:"stream,omitempty"`
+}
+
+// chatUsage is the token usage and cost of answering one message
+type chatUsage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	LLMCalls     int     `json:"llm_calls"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// chatResponse is the answer to a message, and the final event of a stream
+type chatResponse struct {
+	SessionID  string    `json:"session_id"`
+	Answer     string    `json:"answer"`
+	Incomplete bool      `json:"incomplete,omitempty"` // The run hit the iteration limit; the answer sums up what was found so far
+	Usage      chatUsage `json:"usage"`
+}
+
+func newChatResponse(result *useragent.ChatResult) chatResponse {
+	return chatResponse{
+		SessionID:  result.SessionID,
+		Answer:     result.Answer,
+		Incomplete: result.Incomplete,
+		Usage: chatUsage{
+			InputTokens:  result.InputTokens,
+			OutputTokens: result.OutputTokens,
+			LLMCalls:     result.LLMCalls,
+			CostUSD:      result.CostUSD,
+		},
+	}
+}
+
+// chatToolCall and chatToolResult are the tool events of a chat stream
+type chatToolCall struct {
+	ID   string         `json:"id"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+type chatToolResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	IsError bool   `json:"is_error,omitempty"`
+}
+
+// chatPlan is a plan the model laid out before running tools
+type chatPlan struct {
+	ID    string   `json:"id"`
+	Goal  string   `json:"goal,omitempty"`
+	Steps []string `json:"steps"`
+}
+
+// handleChat answers a message with the agent loop running in joecored.
+// With "stream": true or Accept: text/event-stream, the run is streamed as
+// server-sent events: text, plan, tool_call, and tool_result as they
+// happen, then answer, or error if the run fails.
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if s.chatter == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "chat is unavailable: joecored has no LLM configured"})
+		return
+	}
+	var body chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(body.Message) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "message is required"})
+		return
+	}
+
+	if body.Stream || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.streamChat(w, r, body)
+		return
+	}
+
+	// A run can take longer than the server's WriteTimeout, which would
+	// drop the answer once it's ready
+	http.NewResponseController(w).SetWriteDeadline(time.Time{}) // Unsupported by some writers, e.g. in tests
+	result, err := s.chatter.Chat(r.Context(), body.SessionID, body.Message, nil)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, cost.ErrBudgetExceeded):
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatResponse(result))
+}
+
+// streamChat runs a chat as an event stream. Events are sent from the
+// agent's goroutine, which is this one, so writes never interleave.
+func (s *Server) streamChat(w http.ResponseWriter, r *http.Request, body chatRequest) {
+	sse := newSSEWriter(w)
+	onEvent := func(e useragent.Event) {
+		switch e.Type {
+		case useragent.EventText:
+			sse.send("text", map[string]string{"text": e.Text})
+		case useragent.EventToolCall:
+			sse.send("tool_call", chatToolCall{ID: e.ToolCall.ID, Name: e.ToolCall.Name, Args: s.redactArgs(e.ToolCall.Args)})
+		case useragent.EventPlan:
+			sse.send("plan", chatPlan{ID: e.ToolCall.ID, Goal: e.Plan.Goal, Steps: e.Plan.Steps})
+		case useragent.EventToolResult:
+			sse.send("tool_result", chatToolResult{
+				ID:      e.Result.ToolResultID,
+				Name:    e.Result.ToolName,
+				Content: e.Result.Content,
+				IsError: e.Result.IsError,
+			})
+		}
+	}
+
+	result, err := s.chatter.Chat(r.Context(), body.SessionID, body.Message, onEvent)
+	if err != nil {
+		event := map[string]string{"error": err.Error()}
+		if result != nil {
+			event["session_id"] = result.SessionID
+		}
+		sse.send("error", event)
+		return
+	}
+	sse.send("answer", newChatResponse(result))
+}
+
+// redactArgs returns a copy of tool arguments with the secrets in their
+// strings masked
+func (s *Server) redactArgs(args map[string]any) map[string]any {
+	if s.redactor == nil || args == nil {
+		return args
+	}
+	return s.redactValue(args).(map[string]any)
+}
+
+// redactValue masks the strings in a decoded JSON value
+func (s *Server) redactValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return s.redactor.Redact(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = s.redactValue(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = s.redactValue(val)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// fakeChatter answers by echoing the message after one echo tool call.
+// Session "missing" doesn't exist.
+type fakeChatter struct{}
+
+func (fakeChatter) Chat(ctx context.Context, sessionID, message string, onEvent useragent.EventHandler) (*useragent.ChatResult, error) {
+	if sessionID == "missing" {
+		return nil, fmt.Errorf("failed to load session missing: %w", store.ErrNotFound)
+	}
+	if sessionID == "" {
+		sessionID = "s1"
+	}
+	if onEvent != nil {
+		onEvent(useragent.Event{Type: useragent.EventToolCall, ToolCall: llm.ToolCall{ID: "c1", Name: "echo", Args: map[string]any{"message": message}}})
+		onEvent(useragent.Event{Type: useragent.EventToolResult, Result: llm.Message{ToolResultID: "c1", ToolName: "echo", Content: message}})
+	}
+	return &useragent.ChatResult{SessionID: sessionID, Answer: "you said " + message, InputTokens: 7}, nil
+}
+
+func TestHandleChat(t *testing.T) {
+	mux := http.NewServeMux()
+	New(&core.Services{}, WithChat(fakeChatter{})).RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantAnswer string
+	}{
+		{name: "new session", body: `{"message": "hi"}`, wantStatus: http.StatusOK, wantAnswer: "you said hi"},
+		{name: "existing session", body: `{"session_id": "s2", "message": "hi"}`, wantStatus: http.StatusOK, wantAnswer: "you said hi"},
+		{name: "unknown session", body: `{"session_id": "missing", "message": "hi"}`, wantStatus: http.StatusNotFound},
+		{name: "empty message", body: `{"message": " "}`, wantStatus: http.StatusBadRequest},
+		{name: "invalid JSON", body: `{`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/chat", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got chatResponse
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.SessionID == "" || got.Answer != tt.wantAnswer || got.Usage.InputTokens != 7 {
+				t.Errorf("response = %+v, want answer %q with usage", got, tt.wantAnswer)
+			}
+		})
+	}
+}
+
+func TestHandleChat_Stream(t *testing.T) {
+	mux := http.NewServeMux()
+	New(&core.Services{}, WithChat(fakeChatter{})).RegisterRoutes(mux)
+
+	req := httptest.NewRequest("POST", "/api/v1/chat", strings.NewReader(`{"message": "hi"}`))
+	req.Header.Set("Accept", "text/event-stream")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	var events []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, name)
+		}
+	}
+	if got := strings.Join(events, ","); got != "tool_call,tool_result,answer" {
+		t.Errorf("events = %s, want tool_call,tool_result,answer", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"answer":"you said hi"`) {
+		t.Errorf("stream has no answer: %s", rec.Body)
+	}
+}
+
+// slowChatter answers after delay
+type slowChatter struct {
+	delay time.Duration
+}
+
+func (c slowChatter) Chat(ctx context.Context, sessionID, message string, onEvent useragent.EventHandler) (*useragent.ChatResult, error) {
+	time.Sleep(c.delay)
+	return &useragent.ChatResult{SessionID: "s1", Answer: "finally"}, nil
+}
+
+func TestHandleChat_OutlastsWriteTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	New(&core.Services{}, WithChat(slowChatter{delay: 300 * time.Millisecond})).RegisterRoutes(mux)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/v1/chat", "application/json", strings.NewReader(`{"message": "hi"}`))
+	if err != nil {
+		t.Fatalf("POST /api/v1/chat error: %v", err)
+	}
+	defer resp.Body.Close()
+	var got chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Answer != "finally" {
+		t.Errorf("answer = %q, want finally", got.Answer)
+	}
+}
+
+// maskRedactor masks hunter2
+type maskRedactor struct{}
+
+func (maskRedactor) Redact(s string) string { return strings.ReplaceAll(s, "hunter2", "[REDACTED]") }
+
+func TestHandleChat_StreamRedactsToolArgs(t *testing.T) {
+	mux := http.NewServeMux()
+	New(&core.Services{}, WithChat(fakeChatter{}), WithRedactor(maskRedactor{})).RegisterRoutes(mux)
+
+	body := `{"message": "my password is hunter2", "stream": true}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/chat", strings.NewReader(body)))
+
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.Contains(line, `"name":"echo"`) && strings.Contains(line, `"args"`) {
+			if strings.Contains(line, "hunter2") || !strings.Contains(line, "[REDACTED]") {
+				t.Errorf("tool_call event = %s, want its args redacted", line)
+			}
+			return
+		}
+	}
+	t.Errorf("stream has no tool_call event: %s", rec.Body)
+}
+
+func TestHandleChat_Unavailable(t *testing.T) {
+	mux := http.NewServeMux()
+	New(&core.Services{}).RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/chat", strings.NewReader(`{"message": "hi"}`)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jaimegago/joe/internal/coreagent"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// clarification is a question for the user in the clarifications API
+type clarification struct {
+	ID         int64          `json:"id"`
+	Type       string         `json:"type"`
+	Subject    string         `json:"subject"`
+	Question   string         `json:"question"`
+	Options    []string       `json:"options,omitempty"`
+	Context    map[string]any `json:"context,omitempty"`
+	Status     string         `json:"status"`
+	Answer     string         `json:"answer,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	AnsweredAt *time.Time     `json:"answered_at,omitempty"`
+}
+
+// answerRequest is the body of POST /api/v1/clarifications/{id}/answer
+type answerRequest struct {
+	Answer string `json:"answer"`
+}
+
+// handleListClarifications lists the clarifications with ?status=
+// (pending by default, or all), oldest first
+func (s *Server) handleListClarifications(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = store.ClarificationPending
+	case "all":
+		status = ""
+	case store.ClarificationPending, store.ClarificationAnswered, store.ClarificationDismissed:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be pending, answered, dismissed, or all"})
+		return
+	}
+
+	list, err := s.services.Store.ListClarifications(r.Context(), status)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	result := make([]clarification, len(list))
+	for i, c := range list {
+		result[i] = clarification(c)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleAnswerClarification answers a pending clarification and applies
+// the answer to the graph
+func (s *Server) handleAnswerClarification(w http.ResponseWriter, r *http.Request) {
+	id, ok := clarificationID(w, r)
+	if !ok {
+		return
+	}
+	var req answerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	c, err := coreagent.AnswerClarification(r.Context(), s.services.Graph, s.services.Store, id, req.Answer)
+	writeClarification(w, c, err)
+}
+
+// handleDismissClarification closes a pending clarification unanswered
+func (s *Server) handleDismissClarification(w http.ResponseWriter, r *http.Request) {
+	id, ok := clarificationID(w, r)
+	if !ok {
+		return
+	}
+	c, err := coreagent.DismissClarification(r.Context(), s.services.Store, id)
+	writeClarification(w, c, err)
+}
+
+// clarificationID parses the {id} path value, writing an error response
+// if it isn't a number
+func clarificationID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid clarification id: " + r.PathValue("id")})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeClarification writes c, or the status err calls for
+func writeClarification(w http.ResponseWriter, c *store.Clarification, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, coreagent.ErrInvalidAnswer):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, coreagent.ErrNotPending):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusOK, clarification(*c))
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+func TestClarificationsAPI(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+
+	g := graph.NewMemoryStore()
+	g.AddNode(ctx, graph.Node{ID: "api", Type: "service"})
+	g.AddNode(ctx, graph.Node{ID: "postgres", Type: "database"})
+	g.AddEdge(ctx, graph.Edge{From: "api", To: "postgres", Relation: "uses", Confidence: graph.Inferred})
+	edge := func(to string) store.Clarification {
+		return store.Clarification{
+			Type:     store.ClarifyEdge,
+			Subject:  "api -[uses]-> " + to,
+			Question: "Is it right that api uses " + to + "?",
+			Options:  []string{"yes", "no"},
+			Context:  map[string]any{"from": "api", "to": to, "relation": "uses"},
+		}
+	}
+	confirm, _ := st.AddClarification(ctx, edge("postgres"))
+	dismiss, _ := st.AddClarification(ctx, edge("redis"))
+
+	mux := http.NewServeMux()
+	New(&core.Services{Store: st, Graph: g}).RegisterRoutes(mux)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	list := func(query string) []clarification {
+		t.Helper()
+		rec := do("GET", "/api/v1/clarifications"+query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status = %d: %s", query, rec.Code, rec.Body)
+		}
+		var got []clarification
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return got
+	}
+
+	if got := list(""); len(got) != 2 || got[0].Question != "Is it right that api uses postgres?" {
+		t.Errorf("pending = %+v, want both clarifications", got)
+	}
+
+	answer := fmt.Sprintf("/api/v1/clarifications/%d/answer", confirm.ID)
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "invalid status", method: "GET", path: "/api/v1/clarifications?status=open", wantStatus: http.StatusBadRequest},
+		{name: "invalid id", method: "POST", path: "/api/v1/clarifications/x/answer", body: `{"answer":"yes"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing", method: "POST", path: "/api/v1/clarifications/999/answer", body: `{"answer":"yes"}`, wantStatus: http.StatusNotFound},
+		{name: "invalid JSON", method: "POST", path: answer, body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "invalid answer", method: "POST", path: answer, body: `{"answer":"maybe"}`, wantStatus: http.StatusBadRequest},
+		{name: "answer", method: "POST", path: answer, body: `{"answer":"yes"}`, wantStatus: http.StatusOK},
+		{name: "answer again", method: "POST", path: answer, body: `{"answer":"no"}`, wantStatus: http.StatusConflict},
+		{name: "dismiss", method: "POST", path: fmt.Sprintf("/api/v1/clarifications/%d/dismiss", dismiss.ID), wantStatus: http.StatusOK},
+	}
+	for _, step := range steps {
+		if rec := do(step.method, step.path, step.body); rec.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", step.name, rec.Code, step.wantStatus, rec.Body)
+		}
+	}
+
+	if got := list(""); len(got) != 0 {
+		t.Errorf("pending = %+v, want none left", got)
+	}
+	if got := list("?status=answered"); len(got) != 1 || got[0].Answer != "yes" || got[0].AnsweredAt == nil {
+		t.Errorf("answered = %+v, want the confirmed edge", got)
+	}
+	if got := list("?status=all"); len(got) != 2 {
+		t.Errorf("all = %+v, want 2", got)
+	}
+	related, err := g.Related(ctx, "api", 1)
+	if err != nil {
+		t.Fatalf("Related() error: %v", err)
+	}
+	if len(related.Edges) != 1 || related.Edges[0].Confidence != graph.UserConfirmed {
+		t.Errorf("edges = %+v, want api -[uses]-> postgres confirmed", related.Edges)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/events"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// eventsKeepAlive is how often an idle event stream gets a comment, so
+// proxies don't close it
+const eventsKeepAlive = 25 * time.Second
+
+// graphChange is the data of a graph.changed event
+type graphChange struct {
+	SourceID     string `json:"source_id"`
+	NodesUpdated int    `json:"nodes_updated"`
+	EdgesUpdated int    `json:"edges_updated"`
+	NodesRemoved int    `json:"nodes_removed"`
+	EdgesRemoved int    `json:"edges_removed"`
+	NodesStale   int    `json:"nodes_stale"`
+}
+
+// handleEvents streams events as they are published, as server-sent
+// events named by type: refresh.started, refresh.progress,
+// refresh.finished, graph.changed, and source.discovered. ?type= limits
+// the stream to some types, or families of types, e.g. ?type=refresh. The
+// stream ends when the client falls too far behind or joecored shuts down;
+// clients should reconnect and catch up from the other endpoints.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if s.services.Events == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "events are unavailable"})
+		return
+	}
+	types := listParam(r.URL.Query()["type"])
+	published, unsubscribe := s.services.Events.Subscribe()
+	defer unsubscribe()
+
+	sse := newSSEWriter(w)
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := sse.comment("keep-alive"); err != nil {
+				return
+			}
+		case e, ok := <-published:
+			if !ok {
+				return
+			}
+			if !matchesType(e.Type, types) {
+				continue
+			}
+			data := map[string]any{"time": e.Time, "data": eventData(e.Data)}
+			if err := sse.sendID(strconv.FormatUint(e.ID, 10), e.Type, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// matchesType reports whether eventType is one of types, or in a family
+// of them: "refresh" matches "refresh.started". No types match everything.
+func matchesType(eventType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if eventType == t || strings.HasPrefix(eventType, t+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// eventData converts event data to its API form
+func eventData(data any) any {
+	switch v := data.(type) {
+	case store.RefreshRun:
+		return newRefreshRun(v)
+	case events.GraphChange:
+		return graphChange(v)
+	case store.Source:
+		return source(v)
+	default:
+		return v
+	}
+}
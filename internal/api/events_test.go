@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/events"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+func TestHandleEvents(t *testing.T) {
+	bus := events.NewBus()
+	mux := http.NewServeMux()
+	New(&core.Services{Events: bus}).RegisterRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/events?type=graph")
+	if err != nil {
+		t.Fatalf("GET /api/v1/events: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// The handler has subscribed once the headers are sent
+	bus.Publish(events.RefreshStarted, store.RefreshRun{ID: "r1"}) // Filtered out
+	bus.Publish(events.GraphChanged, events.GraphChange{SourceID: "prom", NodesUpdated: 4})
+	bus.Close() // Ends the stream
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	want := []string{"id: 2", "event: graph.changed"}
+	if len(lines) != 3 || lines[0] != want[0] || lines[1] != want[1] {
+		t.Fatalf("stream = %q, want only the graph.changed event", lines)
+	}
+	if !strings.Contains(lines[2], `"source_id":"prom"`) || !strings.Contains(lines[2], `"nodes_updated":4`) {
+		t.Errorf("data = %s, want the graph change", lines[2])
+	}
+}
+
+func TestMatchesType(t *testing.T) {
+	tests := []struct {
+		eventType string
+		types     []string
+		want      bool
+	}{
+		{eventType: events.RefreshStarted, want: true},
+		{eventType: events.RefreshStarted, types: []string{"refresh"}, want: true},
+		{eventType: events.RefreshStarted, types: []string{"refresh.started"}, want: true},
+		{eventType: events.GraphChanged, types: []string{"refresh"}, want: false},
+		{eventType: events.GraphChanged, types: []string{"gra"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType+"/"+strings.Join(tt.types, ","), func(t *testing.T) {
+			if got := matchesType(tt.eventType, tt.types); got != tt.want {
+				t.Errorf("matchesType(%q, %v) = %v, want %v", tt.eventType, tt.types, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/graphquery"
+)
+
+// Graph endpoint limits
+const (
+	defaultPageSize     = 100
+	maxPageSize         = 1000
+	defaultRelatedDepth = 1
+	maxRelatedDepth     = 5
+)
+
+// graphNode is a node in the graph responses
+type graphNode struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	SourceID  string         `json:"source_id,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	FirstSeen time.Time      `json:"first_seen"`
+	LastSeen  time.Time      `json:"last_seen"`
+}
+
+// graphEdge is an edge in the graph responses. Confidence is 1 for edges
+// inferred by the LLM, 2 for inferred edges that kept being reported, 3
+// for explicit ones, and 4 for those the user confirmed.
+type graphEdge struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Relation   string    `json:"relation"`
+	Confidence int       `json:"confidence"`
+	Source     string    `json:"source,omitempty"`
+	Context    string    `json:"context,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newGraphNodes(nodes []graph.Node) []graphNode {
+	result := make([]graphNode, len(nodes))
+	for i, n := range nodes {
+		result[i] = graphNode(n)
+	}
+	return result
+}
+
+func newGraphEdges(edges []graph.Edge) []graphEdge {
+	result := make([]graphEdge, len(edges))
+	for i, e := range edges {
+		result[i] = graphEdge{
+			From:       e.From,
+			To:         e.To,
+			Relation:   e.Relation,
+			Confidence: int(e.Confidence),
+			Source:     e.Source,
+			Context:    e.Context,
+			CreatedAt:  e.CreatedAt,
+		}
+	}
+	return result
+}
+
+// handleGraphQuery searches nodes. ?q= holds a query (see
+// graph.Pattern): search terms matched against node IDs and metadata,
+// optionally chained with hops along edges. ?type= and ?source= filter the
+// results exactly, and ?limit= and ?offset= page through them, sorted by
+// ID.
+func (s *Server) handleGraphQuery(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	limit, offset, err := pageParams(params.Get("limit"), params.Get("offset"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	nodes, err := s.services.Graph.Query(r.Context(), params.Get("q"))
+	if errors.Is(err, graph.ErrInvalidQuery) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	nodes = filterTypes(nodes, listParam(params["type"]))
+	if source := params.Get("source"); source != "" {
+		nodes = slices.DeleteFunc(nodes, func(n graph.Node) bool { return n.SourceID != source })
+	}
+	writeNodePage(w, nodes, limit, offset)
+}
+
+// writeNodePage writes the page of nodes from offset
+func writeNodePage(w http.ResponseWriter, nodes []graph.Node, limit, offset int) {
+	total := len(nodes)
+	// Clamped before adding, so a huge offset can't overflow
+	start := min(offset, total)
+	end := start + min(limit, total-start)
+	result := map[string]any{
+		"nodes":  newGraphNodes(nodes[start:end]),
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	}
+	if end < total {
+		result["next_offset"] = end
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// askRequest is the body of POST /api/v1/graph/ask
+type askRequest struct {
+	Question string `json:"question"`
+	Limit    int    `json:"limit"`
+}
+
+// handleGraphAsk answers a question about the infrastructure in plain
+// language, e.g. "all services that depend on postgres in prod": the LLM
+// translates it into a query, checked against the graph's node types,
+// relations, and sources, which comes back with the first limit nodes it
+// matched
+func (s *Server) handleGraphAsk(w http.ResponseWriter, r *http.Request) {
+	if s.services.LLM == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "graph questions are unavailable: joecored has no LLM configured"})
+		return
+	}
+	var req askRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Question) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "question is required"})
+		return
+	}
+	if req.Limit < 0 || req.Limit > maxPageSize {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a number from 1 to " + strconv.Itoa(maxPageSize)})
+		return
+	}
+	limit := cmp.Or(req.Limit, defaultPageSize)
+
+	answer, err := graphquery.New(s.services.LLM, s.services.Graph).Ask(r.Context(), req.Question)
+	if errors.Is(err, graph.ErrInvalidQuery) {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	nodes := answer.Nodes[:min(limit, len(answer.Nodes))]
+	writeJSON(w, http.StatusOK, map[string]any{
+		"question": req.Question,
+		"query":    answer.Query,
+		"nodes":    newGraphNodes(nodes),
+		"total":    len(answer.Nodes),
+	})
+}
+
+// handleGraphRelated returns the nodes within ?depth= hops (default 1) of
+// a node and the edges between them. ?type= keeps only nodes of those
+// types besides the starting node, and ?relation= only edges of those
+// relations.
+func (s *Server) handleGraphRelated(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	depth := defaultRelatedDepth
+	if v := params.Get("depth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxRelatedDepth {
+			writeJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "depth must be a number from 0 to " + strconv.Itoa(maxRelatedDepth),
+			})
+			return
+		}
+		depth = n
+	}
+
+	nodeID := r.PathValue("nodeID")
+	sub, err := s.services.Graph.Related(r.Context(), nodeID, depth)
+	if errors.Is(err, graph.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	nodes := sub.Nodes
+	if types := listParam(params["type"]); len(types) > 0 {
+		nodes = nodes[:0:0]
+		for _, n := range sub.Nodes {
+			if n.ID == nodeID || slices.Contains(types, n.Type) {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	kept := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		kept[n.ID] = true
+	}
+	relations := listParam(params["relation"])
+	var edges []graph.Edge
+	for _, e := range sub.Edges {
+		if kept[e.From] && kept[e.To] && (len(relations) == 0 || slices.Contains(relations, e.Relation)) {
+			edges = append(edges, e)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"node_id": nodeID,
+		"depth":   depth,
+		"nodes":   newGraphNodes(nodes),
+		"edges":   newGraphEdges(edges),
+	})
+}
+
+// exportTypes are the Content-Types of the export formats besides JSON
+var exportTypes = map[string]string{
+	"dot":     "text/vnd.graphviz; charset=utf-8",
+	"graphml": "application/graphml+xml; charset=utf-8",
+}
+
+// handleGraphExport returns the graph, or part of it, as ?format=json
+// (the default), dot for Graphviz, or graphml for Gephi and yEd. ?node=
+// limits it to the nodes within ?depth= hops (default 1) of a node; ?type=
+// and ?source= keep only nodes of those types and that source. Edges are
+// kept when both their nodes are.
+func (s *Server) handleGraphExport(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if _, ok := exportTypes[format]; !ok && format != "json" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json, dot, or graphml"})
+		return
+	}
+
+	var sub *graph.Subgraph
+	var err error
+	if nodeID := params.Get("node"); nodeID != "" {
+		depth := defaultRelatedDepth
+		if v := params.Get("depth"); v != "" {
+			if depth, err = strconv.Atoi(v); err != nil || depth < 0 || depth > maxRelatedDepth {
+				writeJSON(w, http.StatusBadRequest, map[string]string{
+					"error": "depth must be a number from 0 to " + strconv.Itoa(maxRelatedDepth),
+				})
+				return
+			}
+		}
+		sub, err = s.services.Graph.Related(r.Context(), nodeID, depth)
+	} else {
+		sub, err = s.services.Graph.Snapshot(r.Context())
+	}
+	if errors.Is(err, graph.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	nodes := filterTypes(sub.Nodes, listParam(params["type"]))
+	if source := params.Get("source"); source != "" {
+		nodes = slices.DeleteFunc(nodes, func(n graph.Node) bool { return n.SourceID != source })
+	}
+	kept := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		kept[n.ID] = true
+	}
+	export := &graph.Subgraph{Nodes: nodes}
+	for _, e := range sub.Edges {
+		if kept[e.From] && kept[e.To] {
+			export.Edges = append(export.Edges, e)
+		}
+	}
+
+	switch format {
+	case "dot":
+		w.Header().Set("Content-Type", exportTypes[format])
+		err = graph.WriteDOT(w, export)
+	case "graphml":
+		w.Header().Set("Content-Type", exportTypes[format])
+		err = graph.WriteGraphML(w, export)
+	default:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"nodes": newGraphNodes(export.Nodes),
+			"edges": newGraphEdges(export.Edges),
+		})
+	}
+	if err != nil {
+		// The status is sent; the client sees a truncated document
+		println("ERROR: failed to write graph export:", err.Error())
+	}
+}
+
+// handleGraphSummary reports node and edge counts and recent changes
+func (s *Server) handleGraphSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := s.services.Graph.Summary(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"node_count":       summary.NodeCount,
+		"edge_count":       summary.EdgeCount,
+		"nodes_by_type":    summary.NodesByType,
+		"recently_added":   newGraphNodes(summary.RecentlyAdded),
+		"recently_updated": newGraphNodes(summary.RecentlyUpdated),
+	})
+}
+
+// pageParams parses ?limit= and ?offset=
+func pageParams(limitParam, offsetParam string) (limit, offset int, err error) {
+	limit = defaultPageSize
+	if limitParam != "" {
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, errors.New("limit must be a number from 1 to " + strconv.Itoa(maxPageSize))
+		}
+	}
+	if offsetParam != "" {
+		offset, err = strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// listParam returns the values of a repeatable parameter, also accepting
+// comma-separated lists, e.g. ?type=pod,service
+func listParam(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// filterTypes keeps nodes of any of types, or all nodes if types is empty
+func filterTypes(nodes []graph.Node, types []string) []graph.Node {
+	if len(types) == 0 {
+		return nodes
+	}
+	var result []graph.Node
+	for _, n := range nodes {
+		if slices.Contains(types, n.Type) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
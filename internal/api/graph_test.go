@@ -0,0 +1,273 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+func newGraphTestMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	ctx := context.Background()
+	g := graph.NewMemoryStore()
+	for _, n := range []graph.Node{
+		{ID: "deploy/api", Type: "deployment", SourceID: "k8s-prod"},
+		{ID: "deploy/worker", Type: "deployment", SourceID: "k8s-prod"},
+		{ID: "svc/api", Type: "service", SourceID: "k8s-prod"},
+		{ID: "db/payments", Type: "database", SourceID: "manual"},
+	} {
+		g.AddNode(ctx, n)
+	}
+	g.AddEdge(ctx, graph.Edge{From: "svc/api", To: "deploy/api", Relation: "routes_to", Confidence: graph.Explicit})
+	g.AddEdge(ctx, graph.Edge{From: "deploy/api", To: "db/payments", Relation: "uses", Confidence: graph.Inferred})
+
+	mux := http.NewServeMux()
+	New(&core.Services{Graph: g}).RegisterRoutes(mux)
+	return mux
+}
+
+func TestHandleGraphQuery(t *testing.T) {
+	mux := newGraphTestMux(t)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []string
+		wantTotal  int
+		wantNext   bool
+	}{
+		{name: "all", wantStatus: http.StatusOK, wantIDs: []string{"db/payments", "deploy/api", "deploy/worker", "svc/api"}, wantTotal: 4},
+		{name: "type filters", query: "?type=service,database", wantStatus: http.StatusOK, wantIDs: []string{"db/payments", "svc/api"}, wantTotal: 2},
+		{name: "search and source", query: "?q=api&source=k8s-prod", wantStatus: http.StatusOK, wantIDs: []string{"deploy/api", "svc/api"}, wantTotal: 2},
+		{name: "first page", query: "?limit=3", wantStatus: http.StatusOK, wantIDs: []string{"db/payments", "deploy/api", "deploy/worker"}, wantTotal: 4, wantNext: true},
+		{name: "last page", query: "?limit=3&offset=3", wantStatus: http.StatusOK, wantIDs: []string{"svc/api"}, wantTotal: 4},
+		{name: "past the end", query: "?offset=10", wantStatus: http.StatusOK, wantIDs: []string{}, wantTotal: 4},
+		{name: "huge offset", query: "?offset=9223372036854775807", wantStatus: http.StatusOK, wantIDs: []string{}, wantTotal: 4},
+		{name: "invalid limit", query: "?limit=0", wantStatus: http.StatusBadRequest},
+		{name: "hops", query: "?q=" + url.QueryEscape("type:service -> type:deployment -[uses]-> payments"), wantStatus: http.StatusOK, wantIDs: []string{"svc/api"}, wantTotal: 1},
+		{name: "hop to nothing", query: "?q=" + url.QueryEscape("worker -> payments"), wantStatus: http.StatusOK, wantIDs: []string{}, wantTotal: 0},
+		{name: "invalid query", query: "?q=" + url.QueryEscape("api ->"), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/graph/query"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Nodes      []graphNode `json:"nodes"`
+				Total      int         `json:"total"`
+				NextOffset *int        `json:"next_offset"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			ids := []string{}
+			for _, n := range body.Nodes {
+				ids = append(ids, n.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("nodes = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("nodes = %v, want %v", ids, tt.wantIDs)
+					break
+				}
+			}
+			if body.Total != tt.wantTotal || (body.NextOffset != nil) != tt.wantNext {
+				t.Errorf("total = %d, next_offset = %v; want %d, next %v", body.Total, body.NextOffset, tt.wantTotal, tt.wantNext)
+			}
+		})
+	}
+}
+
+// queryLLM writes the same graph query for every question
+type queryLLM string
+
+func (q queryLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	return &llm.ChatResponse{ToolCalls: []llm.ToolCall{{Name: "graph_query", Args: map[string]any{"query": string(q)}}}}, nil
+}
+
+func (queryLLM) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, nil
+}
+
+func (queryLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+func TestHandleGraphAsk(t *testing.T) {
+	g := graph.NewMemoryStore()
+	ctx := context.Background()
+	g.AddNode(ctx, graph.Node{ID: "svc/api", Type: "service"})
+	g.AddNode(ctx, graph.Node{ID: "db/payments", Type: "database"})
+	g.AddEdge(ctx, graph.Edge{From: "svc/api", To: "db/payments", Relation: "uses"})
+
+	tests := []struct {
+		name       string
+		llm        llm.LLMAdapter
+		body       string
+		wantStatus int
+		wantQuery  string
+	}{
+		{"answers", queryLLM("type:service -[uses]-> payments"), `{"question": "what uses the payments db"}`, http.StatusOK, "type:service -[uses]-> payments"},
+		{"query naming what isn't there", queryLLM("type:queue"), `{"question": "which queues are there"}`, http.StatusUnprocessableEntity, ""},
+		{"no question", queryLLM(""), `{}`, http.StatusBadRequest, ""},
+		{"no LLM", nil, `{"question": "what uses the payments db"}`, http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			New(&core.Services{Graph: g, LLM: tt.llm}).RegisterRoutes(mux)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/graph/ask", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Query string      `json:"query"`
+				Nodes []graphNode `json:"nodes"`
+				Total int         `json:"total"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Query != tt.wantQuery || body.Total != 1 || body.Nodes[0].ID != "svc/api" {
+				t.Errorf("body = %+v, want svc/api from %q", body, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestHandleGraphRelated(t *testing.T) {
+	mux := newGraphTestMux(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantNodes  int
+		wantEdges  int
+	}{
+		{name: "default depth", path: "/api/v1/graph/related/svc%2Fapi", wantStatus: http.StatusOK, wantNodes: 2, wantEdges: 1},
+		{name: "depth 2", path: "/api/v1/graph/related/svc%2Fapi?depth=2", wantStatus: http.StatusOK, wantNodes: 3, wantEdges: 2},
+		{name: "type filter", path: "/api/v1/graph/related/deploy%2Fapi?type=database", wantStatus: http.StatusOK, wantNodes: 2, wantEdges: 1},
+		{name: "relation filter", path: "/api/v1/graph/related/deploy%2Fapi?relation=uses", wantStatus: http.StatusOK, wantNodes: 3, wantEdges: 1},
+		{name: "unknown node", path: "/api/v1/graph/related/missing", wantStatus: http.StatusNotFound},
+		{name: "depth too large", path: "/api/v1/graph/related/svc%2Fapi?depth=9", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Nodes []graphNode `json:"nodes"`
+				Edges []graphEdge `json:"edges"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(body.Nodes) != tt.wantNodes || len(body.Edges) != tt.wantEdges {
+				t.Errorf("got %d nodes and %d edges, want %d and %d", len(body.Nodes), len(body.Edges), tt.wantNodes, tt.wantEdges)
+			}
+		})
+	}
+}
+
+func TestHandleGraphSummary(t *testing.T) {
+	mux := newGraphTestMux(t)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/graph/summary", nil))
+
+	var body struct {
+		NodeCount   int            `json:"node_count"`
+		EdgeCount   int            `json:"edge_count"`
+		NodesByType map[string]int `json:"nodes_by_type"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.NodeCount != 4 || body.EdgeCount != 2 || body.NodesByType["deployment"] != 2 {
+		t.Errorf("summary = %+v", body)
+	}
+}
+
+func TestHandleGraphExport(t *testing.T) {
+	mux := newGraphTestMux(t)
+
+	tests := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantType    string
+		wantContain []string
+		wantAbsent  []string
+	}{
+		{name: "json", wantStatus: http.StatusOK, wantType: "application/json",
+			wantContain: []string{`"id":"deploy/worker"`, `"relation":"routes_to"`, `"relation":"uses"`}},
+		{name: "dot", query: "?format=dot", wantStatus: http.StatusOK, wantType: "text/vnd.graphviz; charset=utf-8",
+			wantContain: []string{"digraph joe {", `"svc/api" -> "deploy/api" [label="routes_to"];`, `[label="uses", style=dashed]`}},
+		{name: "graphml by source", query: "?format=graphml&source=k8s-prod", wantStatus: http.StatusOK,
+			wantType:    "application/graphml+xml; charset=utf-8",
+			wantContain: []string{`<node id="deploy/worker">`, `source="svc/api" target="deploy/api"`},
+			wantAbsent:  []string{"db/payments"}},
+		{name: "neighborhood by type", query: "?format=dot&node=deploy/api&type=deployment,database", wantStatus: http.StatusOK,
+			wantContain: []string{`"deploy/api" -> "db/payments"`},
+			wantAbsent:  []string{"svc/api", "deploy/worker"}},
+		{name: "unknown node", query: "?node=missing", wantStatus: http.StatusNotFound},
+		{name: "unknown format", query: "?format=png", wantStatus: http.StatusBadRequest},
+		{name: "invalid depth", query: "?node=deploy/api&depth=9", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/graph/export"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantType != "" && rec.Header().Get("Content-Type") != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", rec.Header().Get("Content-Type"), tt.wantType)
+			}
+			body := rec.Body.String()
+			for _, s := range tt.wantContain {
+				if !strings.Contains(body, s) {
+					t.Errorf("body does not contain %s:\n%s", s, body)
+				}
+			}
+			for _, s := range tt.wantAbsent {
+				if strings.Contains(body, s) {
+					t.Errorf("body contains %s:\n%s", s, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds each readiness check
+const readyTimeout = 2 * time.Second
+
+// pinger is implemented by stores backed by a database connection
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// handleHealthz reports that the process is up and serving requests; it
+// checks nothing else, so a slow dependency never gets joecored restarted
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports whether the store and graph can be reached, with
+// the result of each check. Stores without a connection to check, e.g. the
+// in-memory graph, are ready if they are configured.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{
+		"store": s.check(r.Context(), s.services.Store != nil, s.services.Store),
+		"graph": s.check(r.Context(), s.services.Graph != nil, s.services.Graph),
+	}
+
+	status, code := "ready", http.StatusOK
+	for _, result := range checks {
+		if result != "ok" {
+			status, code = "not ready", http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, code, map[string]any{"status": status, "checks": checks})
+}
+
+// check returns "ok" if a dependency is configured and, when it can be
+// pinged, answers; otherwise what is wrong
+func (s *Server) check(ctx context.Context, configured bool, dep any) string {
+	if !configured {
+		return "not configured"
+	}
+	p, ok := dep.(pinger)
+	if !ok {
+		return "ok"
+	}
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	if err := p.Ping(ctx); err != nil {
+		return err.Error()
+	}
+	return "ok"
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+func TestHandleReadyz(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	closed, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "closed.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		services   *core.Services
+		wantStatus int
+		wantStore  string
+	}{
+		{name: "ready", services: &core.Services{Store: st, Graph: graph.NewMemoryStore()}, wantStatus: http.StatusOK, wantStore: "ok"},
+		{name: "store closed", services: &core.Services{Store: closed, Graph: graph.NewMemoryStore()}, wantStatus: http.StatusServiceUnavailable},
+		{name: "no graph", services: &core.Services{Store: st}, wantStatus: http.StatusServiceUnavailable, wantStore: "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			New(tt.services).RegisterRoutes(mux)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body struct {
+				Checks map[string]string `json:"checks"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if tt.wantStore != "" && body.Checks["store"] != tt.wantStore {
+				t.Errorf("store check = %q, want %q", body.Checks["store"], tt.wantStore)
+			}
+		})
+	}
+}
+
+func TestHealthzAndMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	New(&core.Services{}).RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz status = %d, want 200 even with nothing configured", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "go_goroutines") {
+		t.Errorf("/metrics status = %d, want 200 with Go runtime metrics", rec.Code)
+	}
+}
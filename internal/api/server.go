@@ -1,19 +1,76 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/coreagent"
+	"github.com/jaimegago/joe/internal/notify"
+	"github.com/jaimegago/joe/internal/sources"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// maxCostDays caps the days parameter of the cost endpoint
+const maxCostDays = 366
+
+// Refresher starts Core Agent refreshes on request
+type Refresher interface {
+	Start(ctx context.Context, trigger string) (*store.RefreshRun, error)
+}
+
 // Server handles HTTP API requests for joecored
 type Server struct {
-	// TODO: Add dependencies (core services, core agent, etc.)
+	services  *core.Services
+	refresher Refresher
+	chatter   Chatter
+	stats     StatsReporter
+	probe     probeFunc
+	redactor  Redactor
 }
 
-// New creates a new API server
-func New() *Server {
-	return &Server{}
+// Redactor masks secrets in text
+type Redactor interface {
+	Redact(s string) string
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithRefresher enables POST /api/v1/refresh
+func WithRefresher(r Refresher) Option {
+	return func(s *Server) { s.refresher = r }
+}
+
+// WithChat enables POST /api/v1/chat
+func WithChat(c Chatter) Option {
+	return func(s *Server) { s.chatter = c }
+}
+
+// WithRedactor masks secrets in the tool arguments of chat streams. Tool
+// results are masked by the chat's executor before they get here.
+func WithRedactor(r Redactor) Option {
+	return func(s *Server) { s.redactor = r }
+}
+
+// WithConnectors tests sources with the health check of the connector
+// for their type, when there is one, instead of adapters.Probe
+func WithConnectors(reg *sources.Registry) Option {
+	return func(s *Server) { s.probe = connectorProbe(reg) }
+}
+
+// New creates a new API server backed by the core services
+func New(services *core.Services, opts ...Option) *Server {
+	s := &Server{services: services, probe: defaultProbe}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // RegisterRoutes registers all API routes on the given mux
@@ -21,23 +78,48 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	// Status
 	mux.HandleFunc("GET /api/v1/status", s.handleStatus)
 
-	// Graph (placeholder)
-	mux.HandleFunc("GET /api/v1/graph/query", s.handleNotImplemented)
-	mux.HandleFunc("GET /api/v1/graph/related/{nodeID}", s.handleNotImplemented)
-	mux.HandleFunc("GET /api/v1/graph/summary", s.handleNotImplemented)
+	// Probes and metrics, at the paths systemd and Kubernetes setups expect.
+	// /metrics serves the default Prometheus registry, where the OTel
+	// Prometheus exporter registers.
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	// LLM spending
+	mux.HandleFunc("GET /api/v1/cost", s.handleCost)
+	mux.HandleFunc("GET /api/v1/stats", s.handleStats)
+
+	// Event stream
+	mux.HandleFunc("GET /api/v1/events", s.handleEvents)
+
+	// Conversations with the agent
+	mux.HandleFunc("POST /api/v1/chat", s.handleChat)
 
-	// Sources (placeholder)
-	mux.HandleFunc("GET /api/v1/sources", s.handleNotImplemented)
-	mux.HandleFunc("POST /api/v1/sources", s.handleNotImplemented)
+	// Graph
+	mux.HandleFunc("GET /api/v1/graph/query", s.handleGraphQuery)
+	mux.HandleFunc("POST /api/v1/graph/ask", s.handleGraphAsk)
+	mux.HandleFunc("GET /api/v1/graph/related/{nodeID}", s.handleGraphRelated)
+	mux.HandleFunc("GET /api/v1/graph/summary", s.handleGraphSummary)
+	mux.HandleFunc("GET /api/v1/graph/export", s.handleGraphExport)
 
-	// Clarifications (placeholder)
-	mux.HandleFunc("GET /api/v1/clarifications", s.handleNotImplemented)
-	mux.HandleFunc("POST /api/v1/clarifications/{id}/answer", s.handleNotImplemented)
-	mux.HandleFunc("POST /api/v1/clarifications/{id}/dismiss", s.handleNotImplemented)
+	// Sources
+	mux.HandleFunc("GET /api/v1/sources", s.handleListSources)
+	mux.HandleFunc("POST /api/v1/sources", s.handleAddSource)
+	mux.HandleFunc("GET /api/v1/sources/{id}", s.handleGetSource)
+	mux.HandleFunc("PUT /api/v1/sources/{id}", s.handleUpdateSource)
+	mux.HandleFunc("DELETE /api/v1/sources/{id}", s.handleDeleteSource)
+	mux.HandleFunc("POST /api/v1/sources/{id}/test", s.handleTestSource)
 
-	// Control (placeholder)
+	// Clarifications
+	mux.HandleFunc("GET /api/v1/clarifications", s.handleListClarifications)
+	mux.HandleFunc("POST /api/v1/clarifications/{id}/answer", s.handleAnswerClarification)
+	mux.HandleFunc("POST /api/v1/clarifications/{id}/dismiss", s.handleDismissClarification)
+
+	// Control
 	mux.HandleFunc("POST /api/v1/onboarding", s.handleNotImplemented)
-	mux.HandleFunc("POST /api/v1/refresh", s.handleNotImplemented)
+	mux.HandleFunc("POST /api/v1/refresh", s.handleRefresh)
+	mux.HandleFunc("GET /api/v1/refresh/{id}", s.handleGetRefresh)
+	mux.HandleFunc("GET /api/v1/refresh/{id}/changes", s.handleRefreshChanges)
 }
 
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
@@ -48,6 +130,192 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// costDay is one day's LLM usage of a model in the cost response
+type costDay struct {
+	Day          string  `json:"day"`
+	Model        string  `json:"model"`
+	Calls        int     `json:"calls"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// handleCost reports estimated LLM spending per day and model for the last
+// ?days= days (default 7), recorded by both joe and joecored
+func (s *Server) handleCost(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxCostDays {
+			writeJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "days must be a number from 1 to " + strconv.Itoa(maxCostDays),
+			})
+			return
+		}
+		days = n
+	}
+
+	costs := s.services.Costs
+	records, err := costs.Days(r.Context(), days)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	today := time.Now().Format("2006-01-02")
+	todayUSD := 0.0
+	result := make([]costDay, len(records))
+	for i, c := range records {
+		result[i] = costDay(c)
+		if c.Day == today {
+			todayUSD += c.CostUSD
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"today_usd":        todayUSD,
+		"daily_budget_usd": costs.DailyBudget(),
+		"days":             result,
+	})
+}
+
+// refreshRun is a refresh job's progress in the refresh responses
+type refreshRun struct {
+	ID           string            `json:"id"`
+	Trigger      string            `json:"trigger"`
+	Status       string            `json:"status"`
+	StartedAt    time.Time         `json:"started_at"`
+	FinishedAt   *time.Time        `json:"finished_at,omitempty"`
+	Sources      int               `json:"sources"`
+	Scanned      int               `json:"sources_scanned"`
+	NodesUpdated int               `json:"nodes_updated"`
+	EdgesUpdated int               `json:"edges_updated"`
+	NodesRemoved int               `json:"nodes_removed"`
+	EdgesRemoved int               `json:"edges_removed"`
+	NodesStale   int               `json:"nodes_stale"`
+	Errors       map[string]string `json:"errors"`
+}
+
+func newRefreshRun(run store.RefreshRun) refreshRun {
+	errs := run.Errors
+	if errs == nil {
+		errs = map[string]string{}
+	}
+	return refreshRun{
+		ID:           run.ID,
+		Trigger:      run.Trigger,
+		Status:       run.Status,
+		StartedAt:    run.StartedAt,
+		FinishedAt:   run.FinishedAt,
+		Sources:      run.Sources,
+		Scanned:      run.Refreshed,
+		NodesUpdated: run.NodesUpdated,
+		EdgesUpdated: run.EdgesUpdated,
+		NodesRemoved: run.NodesRemoved,
+		EdgesRemoved: run.EdgesRemoved,
+		NodesStale:   run.NodesStale,
+		Errors:       errs,
+	}
+}
+
+// handleRefresh starts a refresh right away and returns its job, to be
+// polled at the Location it gives. Only one refresh runs at a time.
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if s.refresher == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": coreagent.ErrRefresherStopped.Error()})
+		return
+	}
+
+	run, err := s.refresher.Start(r.Context(), coreagent.TriggerManual)
+	switch {
+	case errors.Is(err, coreagent.ErrRefreshRunning):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, coreagent.ErrRefresherStopped):
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/refresh/"+run.ID)
+	writeJSON(w, http.StatusAccepted, newRefreshRun(*run))
+}
+
+// handleGetRefresh reports the progress of a refresh job
+func (s *Server) handleGetRefresh(w http.ResponseWriter, r *http.Request) {
+	run, err := s.services.Store.GetRefreshRun(r.Context(), r.PathValue("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, newRefreshRun(*run))
+}
+
+// refreshChange is a node or edge a refresh changed, in the changes
+// response
+type refreshChange struct {
+	SourceID  string         `json:"source_id"`
+	Kind      string         `json:"kind"`
+	Action    string         `json:"action"`
+	Target    string         `json:"target"`
+	Before    map[string]any `json:"before,omitempty"`
+	After     map[string]any `json:"after,omitempty"`
+	Priority  string         `json:"priority"`
+	Reason    string         `json:"reason,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// handleRefreshChanges lists the nodes and edges a refresh added, removed,
+// or modified. ?priority= keeps those at or above a priority, e.g. high.
+func (s *Server) handleRefreshChanges(w http.ResponseWriter, r *http.Request) {
+	threshold := notify.Low
+	if p := r.URL.Query().Get("priority"); p != "" {
+		var err error
+		if threshold, err = notify.ParsePriority(p); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if _, err := s.services.Store.GetRefreshRun(r.Context(), r.PathValue("id")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+	changes, err := s.services.Store.ListGraphChanges(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	result := []refreshChange{}
+	for _, c := range changes {
+		if p, _ := notify.ParsePriority(c.Priority); p < threshold {
+			continue
+		}
+		result = append(result, refreshChange{
+			SourceID:  c.SourceID,
+			Kind:      c.Kind,
+			Action:    c.Action,
+			Target:    c.Target,
+			Before:    c.Before,
+			After:     c.After,
+			Priority:  c.Priority,
+			Reason:    c.Reason,
+			CreatedAt: c.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (s *Server) handleNotImplemented(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusNotImplemented, map[string]string{
 		"error": "not implemented",
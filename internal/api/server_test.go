@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/coreagent"
+	"github.com/jaimegago/joe/internal/cost"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+func TestHandleCost(t *testing.T) {
+	costs := cost.NewTracker(nil, 5)
+	costs.Record(context.Background(), "claude-sonnet-4", llm.TokenUsage{InputTokens: 100, OutputTokens: 10, CostUSD: 1.25})
+
+	mux := http.NewServeMux()
+	New(&core.Services{Costs: costs}).RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "default days", wantStatus: http.StatusOK},
+		{name: "explicit days", query: "?days=30", wantStatus: http.StatusOK},
+		{name: "invalid days", query: "?days=0", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/cost"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				TodayUSD       float64   `json:"today_usd"`
+				DailyBudgetUSD float64   `json:"daily_budget_usd"`
+				Days           []costDay `json:"days"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.TodayUSD != 1.25 || body.DailyBudgetUSD != 5 || len(body.Days) != 1 || body.Days[0].Calls != 1 {
+				t.Errorf("body = %+v", body)
+			}
+		})
+	}
+}
+
+// fakeRefresher records a run as started, or fails with err
+type fakeRefresher struct {
+	st  *sqlite.Store
+	err error
+}
+
+func (f *fakeRefresher) Start(ctx context.Context, trigger string) (*store.RefreshRun, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	run := store.RefreshRun{ID: "r1", Trigger: trigger, Status: store.RefreshRunning, StartedAt: time.Now(), Sources: 2}
+	return &run, f.st.CreateRefreshRun(ctx, run)
+}
+
+func TestHandleRefresh(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+
+	tests := []struct {
+		name       string
+		refresher  Refresher
+		wantStatus int
+	}{
+		{name: "started", refresher: &fakeRefresher{st: st}, wantStatus: http.StatusAccepted},
+		{name: "already running", refresher: &fakeRefresher{err: coreagent.ErrRefreshRunning}, wantStatus: http.StatusConflict},
+		{name: "not running", refresher: &fakeRefresher{err: coreagent.ErrRefresherStopped}, wantStatus: http.StatusServiceUnavailable},
+		{name: "no refresher", wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			var opts []Option
+			if tt.refresher != nil {
+				opts = append(opts, WithRefresher(tt.refresher))
+			}
+			New(&core.Services{Store: st}, opts...).RegisterRoutes(mux)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/refresh", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusAccepted {
+				return
+			}
+
+			location := rec.Header().Get("Location")
+			if location != "/api/v1/refresh/r1" {
+				t.Fatalf("Location = %q, want /api/v1/refresh/r1", location)
+			}
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", location, nil))
+			var run refreshRun
+			if err := json.NewDecoder(rec.Body).Decode(&run); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if rec.Code != http.StatusOK || run.Status != store.RefreshRunning || run.Sources != 2 {
+				t.Errorf("GET %s = %d %+v, want the running job", location, rec.Code, run)
+			}
+
+			rec = httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/refresh/missing", nil))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("GET unknown job status = %d, want 404", rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandleRefreshChanges(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.CreateRefreshRun(ctx, store.RefreshRun{ID: "r1", Status: store.RefreshCompleted})
+	st.AddGraphChanges(ctx, []store.GraphChange{
+		{RunID: "r1", SourceID: "aws-prod", Kind: store.ChangeNode, Action: store.ChangeAdded, Target: "lb/web",
+			Priority: "high", Reason: "new public load_balancer lb/web: internet-facing"},
+		{RunID: "r1", SourceID: "aws-prod", Kind: store.ChangeEdge, Action: store.ChangeAdded, Target: "vpc/vpc-1 -[contains]-> lb/web",
+			Priority: "low"},
+	})
+
+	mux := http.NewServeMux()
+	New(&core.Services{Store: st}).RegisterRoutes(mux)
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantChanges int
+	}{
+		{path: "/api/v1/refresh/r1/changes", wantStatus: http.StatusOK, wantChanges: 2},
+		{path: "/api/v1/refresh/r1/changes?priority=high", wantStatus: http.StatusOK, wantChanges: 1},
+		{path: "/api/v1/refresh/r1/changes?priority=severe", wantStatus: http.StatusBadRequest},
+		{path: "/api/v1/refresh/missing/changes", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var changes []refreshChange
+			if err := json.NewDecoder(rec.Body).Decode(&changes); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(changes) != tt.wantChanges || changes[0].Target != "lb/web" {
+				t.Errorf("changes = %+v, want %d starting with lb/web", changes, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestHandleStats(t *testing.T) {
+	mux := http.NewServeMux()
+	New(&core.Services{}).RegisterRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/stats", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status without stats = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	stats := llm.NewStatsRegistry()
+	adapter := stats.Track(llm.NewInstrumentedAdapter(&fixedLLM{}, nil, "claude", "claude-sonnet-4"))
+	adapter.Chat(context.Background(), llm.ChatRequest{})
+
+	mux = http.NewServeMux()
+	New(&core.Services{}, WithStats(stats)).RegisterRoutes(mux)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/stats", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var body struct {
+		Models []modelStats `json:"models"`
+		Total  modelStats   `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Models) != 1 || body.Models[0].Model != "claude-sonnet-4" || body.Models[0].Calls != 1 || body.Total.Calls != 1 {
+		t.Errorf("body = %+v", body)
+	}
+}
+
+// fixedLLM answers every request with the same response
+type fixedLLM struct{}
+
+func (fixedLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	return &llm.ChatResponse{Content: "ok", Usage: llm.TokenUsage{InputTokens: 10, OutputTokens: 2}}, nil
+}
+
+func (fixedLLM) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, nil
+}
+
+func (fixedLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jaimegago/joe/internal/adapters"
+	"github.com/jaimegago/joe/internal/coreagent"
+	"github.com/jaimegago/joe/internal/sources"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// source is a registered source in the sources API. IDs may contain "/",
+// which clients escape as %2F in paths.
+type source struct {
+	ID                string         `json:"id"`
+	Type              string         `json:"type"`
+	URL               string         `json:"url,omitempty"`
+	Name              string         `json:"name,omitempty"`
+	Environment       string         `json:"environment,omitempty"`
+	Categories        []string       `json:"categories,omitempty"`
+	ConnectionDetails map[string]any `json:"connection_details,omitempty"`
+	Status            string         `json:"status,omitempty"`
+	LastConnected     *time.Time     `json:"last_connected,omitempty"`
+	DiscoveredFrom    string         `json:"discovered_from,omitempty"`
+	DiscoveryContext  string         `json:"discovery_context,omitempty"`
+	Metadata          map[string]any `json:"metadata,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+}
+
+// connectionTest is the result of testing a source's connection
+type connectionTest struct {
+	Connected bool   `json:"connected"`
+	Message   string `json:"message"`
+}
+
+// probeFunc tests a source's connection; replaced in tests
+type probeFunc func(r *http.Request, src store.Source) adapters.Status
+
+func defaultProbe(r *http.Request, src store.Source) adapters.Status {
+	return adapters.Probe(r.Context(), src)
+}
+
+// connectorProbe checks sources the way their refresh reads them, falling
+// back to adapters.Probe for types without a connector
+func connectorProbe(reg *sources.Registry) probeFunc {
+	return func(r *http.Request, src store.Source) adapters.Status {
+		c, ok := reg.Get(src.Type)
+		if !ok {
+			return defaultProbe(r, src)
+		}
+		if err := c.HealthCheck(r.Context(), src); err != nil {
+			return adapters.Status{Message: err.Error()}
+		}
+		return adapters.Status{Connected: true, Message: "ok"}
+	}
+}
+
+func (s *Server) handleListSources(w http.ResponseWriter, r *http.Request) {
+	sources, err := s.services.Store.ListSources(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	result := make([]source, len(sources))
+	for i, src := range sources {
+		result[i] = source(src)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleGetSource(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.lookupSource(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, source(*src))
+}
+
+// handleAddSource registers a source. Sources added through the API are
+// recorded as discovered from user input unless the body says otherwise.
+func (s *Server) handleAddSource(w http.ResponseWriter, r *http.Request) {
+	var body source
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	src := store.Source(body)
+	if err := adapters.ValidateSource(src); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if _, err := s.services.Store.GetSource(r.Context(), src.ID); err == nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "source " + src.ID + " already exists"})
+		return
+	}
+
+	if src.DiscoveredFrom == "" {
+		src.DiscoveredFrom = "user_input"
+	}
+	src.Status, src.LastConnected = "", nil // Set by connection tests and refreshes
+	src.CreatedAt = time.Now()
+	if err := s.services.Store.AddSource(r.Context(), src); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusCreated, source(src))
+}
+
+// handleUpdateSource replaces a source's settings, keeping its status and
+// history
+func (s *Server) handleUpdateSource(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.lookupSource(w, r)
+	if !ok {
+		return
+	}
+	var body source
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	src := store.Source(body)
+	src.ID = existing.ID
+	src.Status, src.LastConnected, src.CreatedAt = existing.Status, existing.LastConnected, existing.CreatedAt
+	if src.DiscoveredFrom == "" {
+		src.DiscoveredFrom, src.DiscoveryContext = existing.DiscoveredFrom, existing.DiscoveryContext
+	}
+	if err := adapters.ValidateSource(src); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := s.services.Store.UpdateSource(r.Context(), src); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, source(src))
+}
+
+func (s *Server) handleDeleteSource(w http.ResponseWriter, r *http.Request) {
+	err := s.services.Store.DeleteSource(r.Context(), r.PathValue("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTestSource checks that a source can be reached and records the
+// outcome in its status
+func (s *Server) handleTestSource(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.lookupSource(w, r)
+	if !ok {
+		return
+	}
+
+	status := s.probe(r, *src)
+	if status.Connected {
+		now := time.Now()
+		src.Status, src.LastConnected = coreagent.SourceConnected, &now
+	} else {
+		src.Status = coreagent.SourceError
+	}
+	if err := s.services.Store.UpdateSource(r.Context(), *src); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, connectionTest(status))
+}
+
+// lookupSource loads the source named in the path, writing the error
+// response if it can't
+func (s *Server) lookupSource(w http.ResponseWriter, r *http.Request) (*store.Source, bool) {
+	src, err := s.services.Store.GetSource(r.Context(), r.PathValue("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	return src, true
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/adapters"
+	"github.com/jaimegago/joe/internal/core"
+	"github.com/jaimegago/joe/internal/sources"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+func TestSourcesAPI(t *testing.T) {
+	st, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+
+	srv := New(&core.Services{Store: st})
+	srv.probe = func(r *http.Request, src store.Source) adapters.Status {
+		return adapters.Status{Connected: src.URL == "https://k8s.prod:6443", Message: "probed"}
+	}
+	mux := http.NewServeMux()
+	srv.RegisterRoutes(mux)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	// The ID contains a slash, escaped in paths
+	const path = "/api/v1/sources/k8s%2Fprod"
+	steps := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "add", method: "POST", path: "/api/v1/sources", body: `{"id":"k8s/prod","type":"kubernetes","url":"https://k8s.prod:6443","environment":"prod"}`, wantStatus: http.StatusCreated},
+		{name: "add duplicate", method: "POST", path: "/api/v1/sources", body: `{"id":"k8s/prod","type":"kubernetes","url":"https://k8s.prod:6443"}`, wantStatus: http.StatusConflict},
+		{name: "add invalid", method: "POST", path: "/api/v1/sources", body: `{"id":"prom","type":"prometheus"}`, wantStatus: http.StatusBadRequest},
+		{name: "get", method: "GET", path: path, wantStatus: http.StatusOK},
+		{name: "test connection", method: "POST", path: path + "/test", wantStatus: http.StatusOK},
+		{name: "update", method: "PUT", path: path, body: `{"type":"kubernetes","url":"https://k8s.prod:6443","name":"Production"}`, wantStatus: http.StatusOK},
+		{name: "get missing", method: "GET", path: "/api/v1/sources/missing", wantStatus: http.StatusNotFound},
+	}
+	for _, step := range steps {
+		if rec := do(step.method, step.path, step.body); rec.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", step.name, rec.Code, step.wantStatus, rec.Body)
+		}
+	}
+
+	rec := do("GET", "/api/v1/sources", "")
+	var sources []source
+	if err := json.NewDecoder(rec.Body).Decode(&sources); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(sources) != 1 {
+		t.Fatalf("sources = %+v, want 1", sources)
+	}
+	got := sources[0]
+	// Update keeps the status from the connection test and the origin
+	if got.Name != "Production" || got.Status != "connected" || got.LastConnected == nil || got.DiscoveredFrom != "user_input" {
+		t.Errorf("source = %+v", got)
+	}
+
+	if rec := do("DELETE", path, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", rec.Code)
+	}
+	if rec := do("DELETE", path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d, want 404", rec.Code)
+	}
+}
+
+// failingConnector fails its health check
+type failingConnector struct{ sources.SourceConnector }
+
+func (failingConnector) HealthCheck(ctx context.Context, src store.Source) error {
+	return errors.New("no credentials")
+}
+
+func TestConnectorProbe(t *testing.T) {
+	reg := sources.NewRegistry()
+	reg.Register("aws", failingConnector{})
+	probe := connectorProbe(reg)
+	r := httptest.NewRequest("POST", "/api/v1/sources/aws-prod/test", nil)
+
+	if got := probe(r, store.Source{ID: "aws-prod", Type: "aws"}); got.Connected || got.Message != "no credentials" {
+		t.Errorf("probe(aws) = %+v, want the health check error", got)
+	}
+	// Types without a connector are probed over HTTP
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+	if got := probe(r, store.Source{ID: "prom", Type: "prometheus", URL: backend.URL}); !got.Connected {
+		t.Errorf("probe(prometheus) = %+v, want connected", got)
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sseWriter writes server-sent events, flushing each one to the client
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newSSEWriter starts an event stream response. The server's write timeout
+// is lifted for it, since a stream lasts as long as its work does.
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{}) // Unsupported by some writers, e.g. in tests
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+	return &sseWriter{w: w, rc: rc}
+}
+
+// send writes an event with data encoded as JSON
+func (s *sseWriter) send(event string, data any) error {
+	return s.sendID("", event, data)
+}
+
+// sendID writes an event with an ID, from which clients can tell whether
+// they missed any
+func (s *sseWriter) sendID(id, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// comment writes a comment line, which clients ignore; used to keep idle
+// connections open through proxies
+func (s *sseWriter) comment(text string) error {
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// StatsReporter provides joecored's LLM call statistics
+type StatsReporter interface {
+	Stats() []llm.ModelStats
+}
+
+// WithStats enables GET /api/v1/stats
+func WithStats(r StatsReporter) Option {
+	return func(s *Server) { s.stats = r }
+}
+
+// modelStats is one model's LLM calls in the stats response
+type modelStats struct {
+	Provider     string  `json:"provider,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	Calls        int64   `json:"calls"`
+	Errors       int64   `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	TotalTokens  int64   `json:"total_tokens"`
+	AvgLatencyMS int64   `json:"avg_latency_ms"`
+}
+
+func newModelStats(provider, model string, st llm.Stats) modelStats {
+	return modelStats{
+		Provider:     provider,
+		Model:        model,
+		Calls:        st.TotalCalls,
+		Errors:       st.TotalErrors,
+		ErrorRate:    st.ErrorRate(),
+		InputTokens:  st.TotalInputTokens,
+		OutputTokens: st.TotalOutputTokens,
+		TotalTokens:  st.TotalTokens,
+		AvgLatencyMS: st.AverageLatency().Milliseconds(),
+	}
+}
+
+// handleStats reports the LLM calls joecored has made since it started,
+// per model and in total. Retried attempts count as calls.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if s.stats == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "LLM stats are unavailable"})
+		return
+	}
+
+	var total llm.Stats
+	models := []modelStats{}
+	for _, m := range s.stats.Stats() {
+		models = append(models, newModelStats(m.Provider, m.Model, m.Stats))
+		total = total.Add(m.Stats)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"models": models,
+		"total":  newModelStats("", "", total),
+	})
+}
@@ -0,0 +1,146 @@
+// Package audit keeps transcripts of agent runs: what was asked, every
+// tool call with its arguments and result, and the answer, as JSONL with
+// one file per session
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Record is one agent run
+type Record struct {
+	Time       time.Time  `json:"time"`
+	SessionID  string     `json:"session_id"`
+	Model      string     `json:"model"`
+	Prompt     string     `json:"prompt"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	Answer     string     `json:"answer,omitempty"`
+	Incomplete bool       `json:"incomplete,omitempty"` // The run hit the iteration limit
+	Error      string     `json:"error,omitempty"`
+	Usage      Usage      `json:"usage"`
+	DurationMS int64      `json:"duration_ms"`
+}
+
+// ToolCall is a tool call made during a run, with its result
+type ToolCall struct {
+	ID      string         `json:"id"`
+	Name    string         `json:"name"`
+	Args    map[string]any `json:"args,omitempty"`
+	Result  string         `json:"result"`
+	IsError bool           `json:"is_error,omitempty"`
+}
+
+// Usage is the token usage and cost of a run
+type Usage struct {
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	LLMCalls     int     `json:"llm_calls"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// Writer appends records to <dir>/<session ID>.jsonl. Files are readable
+// by the owner only, since they hold whatever the tools returned.
+type Writer struct {
+	dir      string
+	redactor Redactor
+	mu       sync.Mutex
+}
+
+// Redactor masks secrets in text
+type Redactor interface {
+	Redact(s string) string
+}
+
+// Option configures a Writer
+type Option func(*Writer)
+
+// WithRedactor masks secrets in the prompt, answer, and tool calls of each
+// record before it is written
+func WithRedactor(r Redactor) Option {
+	return func(w *Writer) {
+		w.redactor = r
+	}
+}
+
+// NewWriter creates a writer for transcripts in dir, creating it if needed
+func NewWriter(dir string, opts ...Option) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	w := &Writer{dir: dir}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, nil
+}
+
+// Path returns the transcript file of a session
+func (w *Writer) Path(sessionID string) string {
+	return filepath.Join(w.dir, sessionID+".jsonl")
+}
+
+// Write appends a record to its session's transcript
+func (w *Writer) Write(r Record) error {
+	if w.redactor != nil {
+		r = w.redact(r)
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript record: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f, err := os.OpenFile(w.Path(r.SessionID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return f.Close()
+}
+
+// redact returns a copy of r with secrets masked
+func (w *Writer) redact(r Record) Record {
+	r.Prompt = w.redactor.Redact(r.Prompt)
+	r.Answer = w.redactor.Redact(r.Answer)
+	r.Error = w.redactor.Redact(r.Error)
+	calls := make([]ToolCall, len(r.ToolCalls))
+	for i, tc := range r.ToolCalls {
+		tc.Result = w.redactor.Redact(tc.Result)
+		if tc.Args != nil {
+			tc.Args = w.redactValue(tc.Args).(map[string]any)
+		}
+		calls[i] = tc
+	}
+	r.ToolCalls = calls
+	return r
+}
+
+// redactValue masks the strings in a decoded JSON value
+func (w *Writer) redactValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return w.redactor.Redact(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = w.redactValue(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = w.redactValue(val)
+		}
+		return out
+	}
+	return v
+}
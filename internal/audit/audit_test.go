@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "transcripts")
+	w, err := NewWriter(dir)
+	if err != nil {
+		t.Fatalf("NewWriter() error: %v", err)
+	}
+
+	records := []Record{
+		{SessionID: "s1", Prompt: "what pods are failing?", ToolCalls: []ToolCall{
+			{ID: "c1", Name: "run_command", Args: map[string]any{"command": "kubectl get pods"}, Result: "api-0 CrashLoopBackOff"},
+		}, Answer: "api-0 is crash looping"},
+		{SessionID: "s1", Prompt: "why?", Error: "llm chat failed"},
+	}
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+
+	path := w.Path("s1")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("transcript not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("transcript permissions = %o, want 600", perm)
+	}
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	var got []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %q is not a record: %v", scanner.Text(), err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != 2 || got[0].ToolCalls[0].Result != "api-0 CrashLoopBackOff" || got[1].Error == "" {
+		t.Errorf("transcript = %+v, want both records in order", got)
+	}
+}
+
+// wordRedactor masks the word "hunter2"
+type wordRedactor struct{}
+
+func (wordRedactor) Redact(s string) string { return strings.ReplaceAll(s, "hunter2", "[REDACTED]") }
+
+func TestWriter_Redacted(t *testing.T) {
+	w, err := NewWriter(t.TempDir(), WithRedactor(wordRedactor{}))
+	if err != nil {
+		t.Fatalf("NewWriter() error: %v", err)
+	}
+	args := map[string]any{"command": "login -p hunter2", "env": []any{"PASS=hunter2"}}
+	err = w.Write(Record{SessionID: "s1", Prompt: "my password is hunter2", ToolCalls: []ToolCall{
+		{ID: "c1", Name: "run_command", Args: args, Result: "logged in with hunter2"},
+	}, Answer: "done, hunter2 worked"})
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	data, err := os.ReadFile(w.Path("s1"))
+	if err != nil {
+		t.Fatalf("transcript not written: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("transcript not redacted: %s", data)
+	}
+	if args["command"] != "login -p hunter2" {
+		t.Error("Write() modified the caller's args")
+	}
+}
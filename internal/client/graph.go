@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Node is a node of the infrastructure graph
+type Node struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	SourceID  string         `json:"source_id,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	FirstSeen time.Time      `json:"first_seen"`
+	LastSeen  time.Time      `json:"last_seen"`
+}
+
+// Edge is a relationship between two nodes. Confidence is 1 for edges
+// inferred by the LLM, 2 for inferred edges that kept being reported, 3
+// for explicit ones, and 4 for those the user confirmed.
+type Edge struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Relation   string    `json:"relation"`
+	Confidence int       `json:"confidence"`
+	Source     string    `json:"source,omitempty"`
+	Context    string    `json:"context,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GraphQuery selects nodes: Text is a query matched against node IDs and
+// metadata, which can follow edges with hops such as -[depends_on]->;
+// Types and Source filter the matches exactly
+type GraphQuery struct {
+	Text   string
+	Types  []string
+	Source string
+	Limit  int // 0 for the server default
+	Offset int
+}
+
+// GraphQueryResult is one page of matching nodes
+type GraphQueryResult struct {
+	Nodes      []Node `json:"nodes"`
+	Total      int    `json:"total"`
+	NextOffset *int   `json:"next_offset,omitempty"`
+}
+
+// Subgraph is a set of nodes and the edges between them
+type Subgraph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// GraphSummary gives counts and recent changes of the graph
+type GraphSummary struct {
+	NodeCount       int            `json:"node_count"`
+	EdgeCount       int            `json:"edge_count"`
+	NodesByType     map[string]int `json:"nodes_by_type"`
+	RecentlyAdded   []Node         `json:"recently_added"`
+	RecentlyUpdated []Node         `json:"recently_updated"`
+}
+
+// QueryGraph searches the graph's nodes
+func (c *Client) QueryGraph(ctx context.Context, q GraphQuery) (*GraphQueryResult, error) {
+	params := url.Values{}
+	if q.Text != "" {
+		params.Set("q", q.Text)
+	}
+	if len(q.Types) > 0 {
+		params.Set("type", strings.Join(q.Types, ","))
+	}
+	if q.Source != "" {
+		params.Set("source", q.Source)
+	}
+	if q.Limit > 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		params.Set("offset", strconv.Itoa(q.Offset))
+	}
+
+	var result GraphQueryResult
+	if err := c.do(ctx, "GET", "/api/v1/graph/query?"+params.Encode(), nil, http.StatusOK, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RelatedNodes returns the nodes within depth hops of nodeID and the edges
+// between them, keeping only nodes of types (besides nodeID) and edges of
+// relations when those are given
+func (c *Client) RelatedNodes(ctx context.Context, nodeID string, depth int, types, relations []string) (*Subgraph, error) {
+	params := url.Values{"depth": {strconv.Itoa(depth)}}
+	if len(types) > 0 {
+		params.Set("type", strings.Join(types, ","))
+	}
+	if len(relations) > 0 {
+		params.Set("relation", strings.Join(relations, ","))
+	}
+
+	var sub Subgraph
+	path := "/api/v1/graph/related/" + url.PathEscape(nodeID) + "?" + params.Encode()
+	if err := c.do(ctx, "GET", path, nil, http.StatusOK, &sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GraphAnswer is the query joecored translated a question into and the
+// nodes it matched
+type GraphAnswer struct {
+	Query string `json:"query"`
+	Nodes []Node `json:"nodes"`
+	Total int    `json:"total"`
+}
+
+// AskGraph asks a question about the infrastructure in plain language,
+// returning up to limit matching nodes (0 for the server default)
+func (c *Client) AskGraph(ctx context.Context, question string, limit int) (*GraphAnswer, error) {
+	body := map[string]any{"question": question}
+	if limit > 0 {
+		body["limit"] = limit
+	}
+	var answer GraphAnswer
+	if err := c.do(ctx, "POST", "/api/v1/graph/ask", body, http.StatusOK, &answer); err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
+
+// GraphExport selects the part of the graph to export, and its format:
+// "json", "dot", or "graphml". Node limits it to the nodes within Depth
+// hops of a node; Types and Source keep only nodes of those types and that
+// source.
+type GraphExport struct {
+	Format string
+	Node   string
+	Depth  int // 0 for the server default when Node is set
+	Types  []string
+	Source string
+}
+
+// ExportFormat returns the export format for a file name, by its
+// extension: dot for .dot and .gv, graphml for .graphml, else json
+func ExportFormat(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".dot", ".gv":
+		return "dot"
+	case ".graphml":
+		return "graphml"
+	default:
+		return "json"
+	}
+}
+
+// ExportGraph returns the graph, or the part e selects, serialized in e's
+// format
+func (c *Client) ExportGraph(ctx context.Context, e GraphExport) ([]byte, error) {
+	params := url.Values{}
+	if e.Format != "" {
+		params.Set("format", e.Format)
+	}
+	if e.Node != "" {
+		params.Set("node", e.Node)
+	}
+	if e.Depth > 0 {
+		params.Set("depth", strconv.Itoa(e.Depth))
+	}
+	if len(e.Types) > 0 {
+		params.Set("type", strings.Join(e.Types, ","))
+	}
+	if e.Source != "" {
+		params.Set("source", e.Source)
+	}
+
+	var data []byte
+	if err := c.do(ctx, "GET", "/api/v1/graph/export?"+params.Encode(), nil, http.StatusOK, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetGraphSummary returns node and edge counts and recent changes
+func (c *Client) GetGraphSummary(ctx context.Context) (*GraphSummary, error) {
+	var summary GraphSummary
+	if err := c.do(ctx, "GET", "/api/v1/graph/summary", nil, http.StatusOK, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Source is a source registered with joecored
+type Source struct {
+	ID                string         `json:"id"`
+	Type              string         `json:"type"`
+	URL               string         `json:"url,omitempty"`
+	Name              string         `json:"name,omitempty"`
+	Environment       string         `json:"environment,omitempty"`
+	Categories        []string       `json:"categories,omitempty"`
+	ConnectionDetails map[string]any `json:"connection_details,omitempty"`
+	Status            string         `json:"status,omitempty"`
+	LastConnected     *time.Time     `json:"last_connected,omitempty"`
+	DiscoveredFrom    string         `json:"discovered_from,omitempty"`
+	DiscoveryContext  string         `json:"discovery_context,omitempty"`
+	Metadata          map[string]any `json:"metadata,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+}
+
+// ConnectionTest is the result of testing a source's connection
+type ConnectionTest struct {
+	Connected bool   `json:"connected"`
+	Message   string `json:"message"`
+}
+
+// ListSources returns the registered sources ordered by ID
+func (c *Client) ListSources(ctx context.Context) ([]Source, error) {
+	var sources []Source
+	err := c.do(ctx, "GET", "/api/v1/sources", nil, http.StatusOK, &sources)
+	return sources, err
+}
+
+// AddSource registers a new source
+func (c *Client) AddSource(ctx context.Context, source Source) (*Source, error) {
+	var added Source
+	if err := c.do(ctx, "POST", "/api/v1/sources", source, http.StatusCreated, &added); err != nil {
+		return nil, err
+	}
+	return &added, nil
+}
+
+// UpdateSource replaces the settings of the source with source.ID
+func (c *Client) UpdateSource(ctx context.Context, source Source) (*Source, error) {
+	var updated Source
+	if err := c.do(ctx, "PUT", sourcePath(source.ID), source, http.StatusOK, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteSource removes a source
+func (c *Client) DeleteSource(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", sourcePath(id), nil, http.StatusNoContent, nil)
+}
+
+// TestSource checks that joecored can reach a source
+func (c *Client) TestSource(ctx context.Context, id string) (*ConnectionTest, error) {
+	var result ConnectionTest
+	if err := c.do(ctx, "POST", sourcePath(id)+"/test", nil, http.StatusOK, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// sourcePath escapes id, which may contain "/", into a single path segment
+func sourcePath(id string) string {
+	return "/api/v1/sources/" + url.PathEscape(id)
+}
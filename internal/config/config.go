@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"log/slog"
 	"os"
@@ -14,27 +15,317 @@ import (
 // Config represents the Joe configuration
 type Config struct {
 	LLM           LLMConfig          `yaml:"llm"`
+	Prompt        PromptConfig       `yaml:"prompt"`
 	Server        ServerConfig       `yaml:"server"`
+	Store         StoreConfig        `yaml:"store"`
+	Memory        MemoryConfig       `yaml:"memory"`
+	Graph         GraphConfig        `yaml:"graph"`
+	MCP           MCPConfig          `yaml:"mcp"`
+	AWS           AWSConfig          `yaml:"aws"`
+	Tools         ToolsConfig        `yaml:"tools"`
 	Refresh       RefreshConfig      `yaml:"refresh"`
 	Notifications NotificationConfig `yaml:"notifications"`
 	Logging       LoggingConfig      `yaml:"logging"`
+	Telemetry     TelemetryConfig    `yaml:"telemetry"`
+	Audit         AuditConfig        `yaml:"audit"`
+	Redaction     RedactionConfig    `yaml:"redaction"`
+}
+
+// PromptConfig sets joe's system prompt. Both forms are Go templates that
+// can use {{.Hostname}}, {{.Cwd}}, {{.Tools}}, {{.Time}}, and {{.Model}}.
+type PromptConfig struct {
+	System string `yaml:"system"` // Inline prompt; empty uses the built-in prompt
+	File   string `yaml:"file"`   // Path to a prompt file; takes precedence over system
+
+	// JOE.md files appended to the prompt, user-wide first; missing files
+	// are skipped and an empty list disables them
+	ContextFiles []string `yaml:"context_files"`
 }
 
 // ServerConfig holds joecored server settings
 type ServerConfig struct {
 	Address string `yaml:"address"` // e.g., ":7777" or "localhost:7777"
+
+	// Sessions of the chat API: they can't be continued once idle for
+	// SessionTTLHours, and only the MaxSessions most recent are kept.
+	// 0 disables either limit.
+	SessionTTLHours int `yaml:"session_ttl_hours"`
+	MaxSessions     int `yaml:"max_sessions"`
+}
+
+// StoreConfig configures the SQLite store used by joecored
+type StoreConfig struct {
+	Path string `yaml:"path"` // e.g., "~/.joe/joe.db"
+}
+
+// MemoryConfig configures recall of past sessions: joe summarizes each
+// session in the store when it ends and starts new ones with the most
+// similar
+type MemoryConfig struct {
+	Enabled       bool    `yaml:"enabled"`
+	TopK          int     `yaml:"top_k"`          // Past sessions recalled at most
+	MinSimilarity float64 `yaml:"min_similarity"` // Cosine similarity (0-1) a past session needs to be recalled
+}
+
+// GraphConfig configures the persistent infrastructure graph used by joecored
+type GraphConfig struct {
+	Path string `yaml:"path"` // e.g., "~/.joe/graph/graph.db"
+}
+
+// MCPConfig declares external MCP servers whose tools are added to Joe's
+// tool registry
+type MCPConfig struct {
+	Servers map[string]MCPServerConfig `yaml:"servers"` // Keyed by a short server name
+}
+
+// MCPServerConfig describes how to reach one MCP server
+type MCPServerConfig struct {
+	Transport string            `yaml:"transport"` // "stdio" (default) or "sse"
+	Command   string            `yaml:"command"`   // stdio: executable to run
+	Args      []string          `yaml:"args"`      // stdio: command arguments
+	Env       map[string]string `yaml:"env"`       // stdio: extra environment variables
+	URL       string            `yaml:"url"`       // sse: event stream URL, e.g. "http://localhost:8080/sse"
+}
+
+// AWSConfig selects the account and region used by the AWS tools, and by
+// aws sources that don't set their own. Empty values fall back to the AWS
+// SDK defaults (AWS_REGION, AWS_PROFILE, ~/.aws/config).
+type AWSConfig struct {
+	Region   string            `yaml:"region"`   // e.g. "us-east-1"
+	Profile  string            `yaml:"profile"`  // Shared config profile name
+	Services AWSServicesConfig `yaml:"services"` // What aws sources map into the graph
+}
+
+// AWSServicesConfig enables each service the aws source connector reads
+type AWSServicesConfig struct {
+	VPC bool `yaml:"vpc"` // VPCs and subnets
+	EC2 bool `yaml:"ec2"` // Instances
+	RDS bool `yaml:"rds"` // Database instances
+	ELB bool `yaml:"elb"` // Application and network load balancers, and their targets
+}
+
+// ToolsConfig controls how local tools may be used
+type ToolsConfig struct {
+	// Approval maps tool names to "ask" (confirm in the REPL before each
+	// call), "allow", or "deny". Unlisted tools are allowed.
+	Approval map[string]string `yaml:"approval"`
+
+	// Disabled lists tools to leave out entirely, by name or glob
+	// (e.g. "docker_*")
+	Disabled []string `yaml:"disabled"`
+
+	// TimeoutSeconds bounds each tool call; Timeouts overrides it per tool.
+	// 0 means no timeout.
+	TimeoutSeconds int            `yaml:"timeout_seconds"`
+	Timeouts       map[string]int `yaml:"timeouts"`
+
+	Cache     ToolCacheConfig     `yaml:"cache"`
+	Selection ToolSelectionConfig `yaml:"selection"`
+
+	// Progress shows tool calls in the REPL as they run: "lines" keeps a
+	// line per call, "collapse" sums them up in one line once the run ends,
+	// and "off" hides them
+	Progress string `yaml:"progress"`
+
+	// Plan has the model lay out multi-step tasks as a numbered plan
+	// before running tools: "show" prints the plan, "approve" also waits
+	// for a y/N in the REPL, and "off" doesn't ask for plans
+	Plan string `yaml:"plan"`
+
+	// PluginDir holds executables that each add a tool (see package
+	// plugin); empty loads none
+	PluginDir string `yaml:"plugin_dir"`
+
+	// SourceTools picks which source-specific tools (e.g. the aws ones) are
+	// offered: "registered" only those for the types of sources registered
+	// with joecored, "all" every one
+	SourceTools string `yaml:"source_tools"`
+
+	RunCommand RunCommandConfig `yaml:"run_command"`
+	WriteFile  WriteFileConfig  `yaml:"write_file"`
+	SSH        SSHConfig        `yaml:"ssh"`
+	SQL        SQLConfig        `yaml:"sql"`
+	FetchURL   FetchURLConfig   `yaml:"fetch_url"`
+}
+
+// ToolCacheConfig configures reuse of tool results within a run
+type ToolCacheConfig struct {
+	TTLSeconds   map[string]int `yaml:"ttl_seconds"`   // Tools to cache and for how long; empty disables caching
+	InvalidateOn []string       `yaml:"invalidate_on"` // Tools whose calls clear the cache
+}
+
+// ToolSelectionConfig offers each question only the tools relevant to it
+type ToolSelectionConfig struct {
+	Mode     string   `yaml:"mode"`      // off, keywords (shared words), or embeddings (similarity, needs a model that embeds)
+	MaxTools int      `yaml:"max_tools"` // Tools picked per question, besides Always and those already called
+	Always   []string `yaml:"always"`    // Tools offered with every question
+}
+
+// RunCommandConfig restricts the run_command tool
+type RunCommandConfig struct {
+	Allowed     []string      `yaml:"allowed"`      // Binaries that may be run; empty uses the built-in list
+	AllowedDirs []string      `yaml:"allowed_dirs"` // Directories commands may run in (cwd); empty allows any
+	AllowedEnv  []string      `yaml:"allowed_env"`  // Environment variables commands may be given (env)
+	Sandbox     SandboxConfig `yaml:"sandbox"`      // Where commands run
+}
+
+// SandboxConfig isolates the commands run_command runs from the machine
+type SandboxConfig struct {
+	Backend string `yaml:"backend"` // none (on the host), container, or restricted (user and network namespaces)
+	Runtime string `yaml:"runtime"` // Container CLI: docker or podman
+	Image   string `yaml:"image"`   // Image commands run in with the container backend
+	Network bool   `yaml:"network"` // Allow network access inside the sandbox
+}
+
+// SSHConfig defines the remote hosts ssh_run may run commands on
+type SSHConfig struct {
+	Hosts                 map[string]SSHHostConfig `yaml:"hosts"`                   // Aliases the model refers to hosts by; none leaves ssh_run out
+	Allowed               []string                 `yaml:"allowed"`                 // Binaries that may be run; empty uses the built-in list
+	ConnectTimeoutSeconds int                      `yaml:"connect_timeout_seconds"` // How long connecting may take
+}
+
+// SSHHostConfig is how to reach one host
+type SSHHostConfig struct {
+	Address string `yaml:"address"`  // host or host:port
+	User    string `yaml:"user"`     // Empty uses ssh's default (~/.ssh/config, then the local user)
+	KeyFile string `yaml:"key_file"` // Private key; empty uses ssh's default keys and agent
+}
+
+// SQLConfig defines the databases sql_query may read
+type SQLConfig struct {
+	Databases map[string]DatabaseConfig `yaml:"databases"` // Aliases the model refers to databases by; none leaves sql_query out
+	MaxRows   int                       `yaml:"max_rows"`  // Most rows a query returns
+}
+
+// DatabaseConfig is how to reach one database. Set DSNEnv rather than DSN
+// when the connection string holds a password.
+type DatabaseConfig struct {
+	Driver string `yaml:"driver"`  // postgres, mysql, or sqlite
+	DSN    string `yaml:"dsn"`     // Connection string, or for sqlite a file path
+	DSNEnv string `yaml:"dsn_env"` // Environment variable holding the connection string
+}
+
+// FetchURLConfig restricts the pages fetch_url may download
+type FetchURLConfig struct {
+	AllowedDomains []string `yaml:"allowed_domains"` // Domains (with their subdomains) pages may come from; empty allows any
+	DeniedDomains  []string `yaml:"denied_domains"`  // Domains (with their subdomains) never fetched
+	AllowPrivate   bool     `yaml:"allow_private"`   // Allow hosts on loopback, private, and link-local addresses, such as internal wikis
+}
+
+// WriteFileConfig restricts write_file and edit_file
+type WriteFileConfig struct {
+	AllowedPaths []string `yaml:"allowed_paths"` // Directories files may be written under; empty allows any
 }
 
 // LLMConfig configures LLM providers with support for multiple models
 type LLMConfig struct {
-	Current   string                 `yaml:"current"`   // Key into Available for the active model
-	Available map[string]ModelConfig `yaml:"available"` // All configured models
+	Current    string                 `yaml:"current"`    // Key into Available for the active model
+	Available  map[string]ModelConfig `yaml:"available"`  // All configured models
+	ShowUsage  bool                   `yaml:"show_usage"` // Print token usage (and cost, if priced) after each answer
+	Retry      RetryConfig            `yaml:"retry"`      // Retries for rate limit and server errors
+	Fallback   []string               `yaml:"fallback"`   // Keys into Available to switch to, in order, when the active model keeps failing
+	Cache      ResponseCacheConfig    `yaml:"cache"`      // Reuse of responses to identical requests
+	Compaction CompactionConfig       `yaml:"compaction"` // Summarization of older turns as the history grows
+	Cost       CostConfig             `yaml:"cost"`       // Spending limits
+	Filter     FilterConfig           `yaml:"filter"`     // Content kept from being sent to the provider
+}
+
+// FilterConfig blocks or redacts request content, such as customer data,
+// hostnames, or IP ranges, before it is sent to the LLM provider
+type FilterConfig struct {
+	DryRun bool               `yaml:"dry_run"` // Report what would be redacted or blocked, but send requests unchanged
+	Rules  []FilterRuleConfig `yaml:"rules"`
+}
+
+// FilterRuleConfig matches content by regex or by IP range; set one of
+// Pattern and CIDR
+type FilterRuleConfig struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"` // Regular expression
+	CIDR    string `yaml:"cidr"`    // IP addresses in this range, e.g. "10.0.0.0/8"
+	Action  string `yaml:"action"`  // "redact" (default) or "block"
+}
+
+// CostConfig limits LLM spending, estimated from each model's pricing
+type CostConfig struct {
+	DailyBudgetUSD float64 `yaml:"daily_budget_usd"` // LLM calls fail once today's spending reaches this; 0 for no limit
+}
+
+// CompactionConfig controls summarizing older conversation turns so long
+// sessions stay within the model's context window
+type CompactionConfig struct {
+	ThresholdTokens int `yaml:"threshold_tokens"` // Estimated history size that triggers compaction; 0 disables
+	KeepTurns       int `yaml:"keep_turns"`       // Most recent user turns kept verbatim
+}
+
+// ResponseCacheConfig controls caching of LLM responses to identical
+// requests (same system prompt, messages, and tools)
+type ResponseCacheConfig struct {
+	TTLSeconds int `yaml:"ttl_seconds"` // 0 disables the cache
+	MaxEntries int `yaml:"max_entries"` // Least recently used responses are evicted beyond this
+}
+
+// RetryConfig controls retries of LLM requests that fail with a rate limit
+// (429) or server (5xx) error
+type RetryConfig struct {
+	MaxAttempts int `yaml:"max_attempts"`  // Total attempts per request; 1 disables retries
+	BaseDelayMS int `yaml:"base_delay_ms"` // First backoff, doubled for each further retry
+	MaxDelaySec int `yaml:"max_delay_sec"` // Backoff cap; a longer Retry-After fails instead of waiting
 }
 
 // ModelConfig describes a single LLM model
 type ModelConfig struct {
-	Provider string `yaml:"provider"` // "claude", "gemini"
-	Model    string `yaml:"model"`    // e.g. "claude-sonnet-4-20250514"
+	Provider string        `yaml:"provider"`          // "claude", "gemini", "openai-compatible", "bedrock"
+	Model    string        `yaml:"model"`             // e.g. "claude-sonnet-4-20250514"
+	Pricing  *ModelPricing `yaml:"pricing,omitempty"` // Optional; enables cost estimates
+
+	// Output limit and sampling; unset uses the provider default
+	MaxTokens   int      `yaml:"max_tokens,omitempty"`
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	TopP        *float64 `yaml:"top_p,omitempty"`
+
+	// Input tokens the model accepts; unset uses Joe's built-in table of
+	// known models. Needed for self-hosted models to enforce a limit.
+	ContextWindow int `yaml:"context_window,omitempty"`
+
+	// Tools a request may offer; unset uses the built-in table (128 for
+	// OpenAI models, no limit otherwise). Above it, the tools most
+	// relevant to the user's message are offered.
+	MaxTools int `yaml:"max_tools,omitempty"`
+
+	// openai-compatible only
+	BaseURL    string `yaml:"base_url,omitempty"`    // Chat completions API root, e.g. "http://localhost:8000/v1"
+	APIKeyEnv  string `yaml:"api_key_env,omitempty"` // Environment variable holding the API key; empty sends no key
+	APIVersion string `yaml:"api_version,omitempty"` // Azure OpenAI api-version; selects Azure-style auth
+
+	// bedrock only; empty uses the AWS environment and shared config
+	Region  string `yaml:"region,omitempty"`
+	Profile string `yaml:"profile,omitempty"`
+
+	// gemini only: serve the model from Vertex AI with Application Default
+	// Credentials instead of an API key
+	Vertex   bool   `yaml:"vertex,omitempty"`
+	Project  string `yaml:"project,omitempty"`  // GCP project; defaults to GOOGLE_CLOUD_PROJECT
+	Location string `yaml:"location,omitempty"` // e.g. "us-central1" (default) or "global"
+}
+
+// VertexProject returns the GCP project for a Vertex AI model
+func (mc ModelConfig) VertexProject() string {
+	if mc.Project != "" {
+		return mc.Project
+	}
+	return os.Getenv("GOOGLE_CLOUD_PROJECT")
+}
+
+// ModelPricing holds a model's token prices in USD per million tokens
+type ModelPricing struct {
+	InputPerMillion  float64 `yaml:"input_per_million"`
+	OutputPerMillion float64 `yaml:"output_per_million"`
+}
+
+// Cost returns the estimated USD cost of the given token counts
+func (p ModelPricing) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.InputPerMillion + float64(outputTokens)*p.OutputPerMillion) / 1_000_000
 }
 
 // CurrentModel returns the ModelConfig for the currently selected model
@@ -46,6 +337,21 @@ func (c *LLMConfig) CurrentModel() (ModelConfig, error) {
 	return mc, nil
 }
 
+// FallbackModels returns the configured fallbacks for the model key
+// current, skipping current itself. Unknown keys are an error.
+func (c *LLMConfig) FallbackModels(current string) ([]string, error) {
+	var keys []string
+	for _, key := range c.Fallback {
+		if _, ok := c.Available[key]; !ok {
+			return nil, fmt.Errorf("llm.fallback: model %q not found in llm.available", key)
+		}
+		if key != current {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
 // ModelNames returns the sorted list of available model keys
 func (c *LLMConfig) ModelNames() []string {
 	names := make([]string, 0, len(c.Available))
@@ -61,6 +367,16 @@ type RefreshConfig struct {
 	IntervalMinutes int           `yaml:"interval_minutes"`
 	Interval        time.Duration `yaml:"-"` // Computed from IntervalMinutes
 	LLMBudget       LLMBudget     `yaml:"llm_budget"`
+
+	// Nodes a source stops reporting are marked stale once missing from
+	// StaleAfter of its refreshes in a row, and deleted StaleTTLHours later
+	StaleAfter    int           `yaml:"stale_after"`
+	StaleTTLHours int           `yaml:"stale_ttl_hours"`
+	StaleTTL      time.Duration `yaml:"-"` // Computed from StaleTTLHours
+
+	// Inferred edges are promoted to corroborated once reported by
+	// PromoteAfter refreshes of their source; 0 never promotes
+	PromoteAfter int `yaml:"promote_after"`
 }
 
 // LLMBudget limits LLM usage during background refresh
@@ -74,22 +390,75 @@ type LLMBudget struct {
 // NotificationConfig configures notifications
 type NotificationConfig struct {
 	Desktop    ChannelConfig    `yaml:"desktop"`
-	Slack      ChannelConfig    `yaml:"slack"`
+	Slack      SlackConfig      `yaml:"slack"`
+	Webhook    WebhookConfig    `yaml:"webhook"`
+	Email      EmailConfig      `yaml:"email"`
+	PagerDuty  PagerDutyConfig  `yaml:"pagerduty"`
 	QuietHours QuietHoursConfig `yaml:"quiet_hours"`
 }
 
+// Channels returns the settings every notification channel has, keyed by
+// its section, e.g. "slack"
+func (nc NotificationConfig) Channels() map[string]ChannelConfig {
+	return map[string]ChannelConfig{
+		"desktop":   nc.Desktop,
+		"slack":     {Enabled: nc.Slack.Enabled, PriorityThreshold: nc.Slack.PriorityThreshold},
+		"webhook":   {Enabled: nc.Webhook.Enabled, PriorityThreshold: nc.Webhook.PriorityThreshold},
+		"email":     {Enabled: nc.Email.Enabled, PriorityThreshold: nc.Email.PriorityThreshold},
+		"pagerduty": {Enabled: nc.PagerDuty.Enabled, PriorityThreshold: nc.PagerDuty.PriorityThreshold},
+	}
+}
+
 // ChannelConfig configures a notification channel
 type ChannelConfig struct {
 	Enabled           bool   `yaml:"enabled"`
 	PriorityThreshold string `yaml:"priority_threshold"` // "low", "medium", "high", "urgent"
 }
 
+// SlackConfig configures Slack notifications, posted to an incoming
+// webhook or, with a bot token, to a channel
+type SlackConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	PriorityThreshold string `yaml:"priority_threshold"` // "low", "medium", "high", "urgent"
+	WebhookURL        string `yaml:"webhook_url"`        // Incoming webhook; used instead of a bot token when set
+	TokenEnv          string `yaml:"token_env"`          // Environment variable holding the bot token (xoxb-...)
+	Channel           string `yaml:"channel"`            // Channel the bot posts to, e.g. "#ops"
+}
+
+// WebhookConfig configures notifications posted as JSON to any URL
+type WebhookConfig struct {
+	Enabled           bool              `yaml:"enabled"`
+	PriorityThreshold string            `yaml:"priority_threshold"` // "low", "medium", "high", "urgent"
+	URL               string            `yaml:"url"`
+	Headers           map[string]string `yaml:"headers"` // Sent with each post; ${VAR} expands from the environment
+}
+
+// EmailConfig configures notifications sent by email over SMTP
+type EmailConfig struct {
+	Enabled           bool     `yaml:"enabled"`
+	PriorityThreshold string   `yaml:"priority_threshold"` // "low", "medium", "high", "urgent"
+	SMTPHost          string   `yaml:"smtp_host"`
+	SMTPPort          int      `yaml:"smtp_port"`    // 587 (STARTTLS) by default
+	Username          string   `yaml:"username"`     // Empty sends without authenticating
+	PasswordEnv       string   `yaml:"password_env"` // Environment variable holding the SMTP password
+	From              string   `yaml:"from"`
+	To                []string `yaml:"to"`
+}
+
+// PagerDutyConfig configures notifications sent as PagerDuty events
+type PagerDutyConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	PriorityThreshold string `yaml:"priority_threshold"` // "low", "medium", "high", "urgent"
+	RoutingKeyEnv     string `yaml:"routing_key_env"`    // Environment variable holding the Events API v2 integration key
+}
+
 // QuietHoursConfig configures quiet hours
 type QuietHoursConfig struct {
 	Enabled  bool   `yaml:"enabled"`
 	Start    string `yaml:"start"`
 	End      string `yaml:"end"`
 	Timezone string `yaml:"timezone"`
+	Action   string `yaml:"action"` // Notifications below urgent: "queue" until the end, or "drop"
 }
 
 // LoggingConfig configures logging
@@ -98,6 +467,30 @@ type LoggingConfig struct {
 	File  string `yaml:"file"`
 }
 
+// TelemetryConfig configures OpenTelemetry traces and metrics for joe and
+// joecored
+type TelemetryConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	TracesExporter  string `yaml:"traces_exporter"`  // "otlp", "stdout", or "none"
+	OTLPEndpoint    string `yaml:"otlp_endpoint"`    // OTLP gRPC collector, e.g. "localhost:4317"
+	MetricsExporter string `yaml:"metrics_exporter"` // "prometheus" or "none"
+	MetricsPort     int    `yaml:"metrics_port"`     // Port joecored serves Prometheus metrics on besides its API; 0 for only the API
+	JoeMetricsPort  int    `yaml:"joe_metrics_port"` // Port joe serves Prometheus metrics on while running; 0 for none
+}
+
+// AuditConfig configures transcripts of agent runs
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled"` // Write a JSONL transcript of every run
+	Dir     string `yaml:"dir"`     // One <session ID>.jsonl file per session, e.g. "~/.joe/transcripts"
+}
+
+// RedactionConfig configures masking of secrets in tool results, logs, and
+// transcripts
+type RedactionConfig struct {
+	Enabled  bool     `yaml:"enabled"`  // Mask API keys, AWS credentials, bearer tokens, and private keys
+	Patterns []string `yaml:"patterns"` // Extra regexes to mask, besides the built-in ones
+}
+
 // Load loads configuration from the specified file path
 // Falls back to defaults if file doesn't exist
 // Environment variables override config file values
@@ -107,12 +500,9 @@ func Load(configPath string) (*Config, error) {
 	slog.Debug("config: initialized with defaults")
 
 	// Expand home directory if path starts with ~
-	if len(configPath) > 0 && configPath[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		configPath = filepath.Join(home, configPath[1:])
+	configPath, err := ExpandHome(configPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Track config source
@@ -146,6 +536,7 @@ func Load(configPath string) (*Config, error) {
 	// Compute derived fields
 	cfg.Refresh.Interval = time.Duration(cfg.Refresh.IntervalMinutes) * time.Minute
 	cfg.Refresh.LLMBudget.BatchTimeout = time.Duration(cfg.Refresh.LLMBudget.BatchTimeoutSec) * time.Second
+	cfg.Refresh.StaleTTL = time.Duration(cfg.Refresh.StaleTTLHours) * time.Hour
 
 	// Log final configuration
 	slog.Debug("config: loaded",
@@ -160,6 +551,18 @@ func Load(configPath string) (*Config, error) {
 	return cfg, nil
 }
 
+// ExpandHome replaces a leading ~ in path with the user's home directory
+func ExpandHome(path string) (string, error) {
+	if len(path) == 0 || path[0] != '~' {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
 // defaultConfig returns a config with sensible defaults
 func defaultConfig() *Config {
 	return &Config{
@@ -168,9 +571,78 @@ func defaultConfig() *Config {
 			Available: map[string]ModelConfig{
 				"claude-sonnet": {Provider: "claude", Model: "claude-sonnet-4-20250514"},
 			},
+			Retry: RetryConfig{
+				MaxAttempts: 3,
+				BaseDelayMS: 1000,
+				MaxDelaySec: 30,
+			},
+			Cache: ResponseCacheConfig{
+				MaxEntries: 256,
+			},
+			Compaction: CompactionConfig{
+				ThresholdTokens: 100000,
+				KeepTurns:       4,
+			},
+		},
+		Prompt: PromptConfig{
+			ContextFiles: []string{"~/.joe/JOE.md", ".joe/JOE.md"},
 		},
 		Server: ServerConfig{
-			Address: "localhost:7777",
+			Address:         "localhost:7777",
+			SessionTTLHours: 168,
+			MaxSessions:     1000,
+		},
+		AWS: AWSConfig{
+			Services: AWSServicesConfig{VPC: true, EC2: true, RDS: true, ELB: true},
+		},
+		Store: StoreConfig{
+			Path: "~/.joe/joe.db",
+		},
+		Memory: MemoryConfig{
+			Enabled:       true,
+			TopK:          3,
+			MinSimilarity: 0.25,
+		},
+		Graph: GraphConfig{
+			Path: "~/.joe/graph/graph.db",
+		},
+		Tools: ToolsConfig{
+			Approval: map[string]string{
+				"write_file":  "ask",
+				"edit_file":   "ask",
+				"run_command": "ask",
+				"ssh_run":     "ask",
+			},
+			TimeoutSeconds: 60,
+			Timeouts: map[string]int{
+				"ask_user": 0, // waits for the user
+			},
+			Cache: ToolCacheConfig{
+				InvalidateOn: []string{"write_file", "edit_file", "run_command"},
+			},
+			Selection: ToolSelectionConfig{
+				Mode:     "off",
+				MaxTools: 20,
+				Always:   []string{"ask_user", "read_file", "run_command"},
+			},
+			Progress:    "lines",
+			Plan:        "show",
+			PluginDir:   "~/.joe/tools",
+			SourceTools: "registered",
+			RunCommand: RunCommandConfig{
+				AllowedEnv: []string{"KUBECONFIG", "AWS_PROFILE", "AWS_REGION", "TZ"},
+				Sandbox: SandboxConfig{
+					Backend: "none",
+					Runtime: "docker",
+					Image:   "alpine:3",
+				},
+			},
+			SSH: SSHConfig{
+				ConnectTimeoutSeconds: 10,
+			},
+			SQL: SQLConfig{
+				MaxRows: 200,
+			},
 		},
 		Refresh: RefreshConfig{
 			IntervalMinutes: 5,
@@ -179,27 +651,59 @@ func defaultConfig() *Config {
 				BatchThreshold:  10,
 				BatchTimeoutSec: 30,
 			},
+			StaleAfter:    3,
+			StaleTTLHours: 24,
+			PromoteAfter:  3,
 		},
 		Notifications: NotificationConfig{
 			Desktop: ChannelConfig{
 				Enabled:           false,
 				PriorityThreshold: "medium",
 			},
-			Slack: ChannelConfig{
+			Slack: SlackConfig{
+				Enabled:           false,
+				PriorityThreshold: "high",
+				TokenEnv:          "SLACK_BOT_TOKEN",
+			},
+			Webhook: WebhookConfig{
 				Enabled:           false,
 				PriorityThreshold: "high",
 			},
+			Email: EmailConfig{
+				Enabled:           false,
+				PriorityThreshold: "high",
+				SMTPPort:          587,
+				PasswordEnv:       "JOE_SMTP_PASSWORD",
+			},
+			PagerDuty: PagerDutyConfig{
+				Enabled:           false,
+				PriorityThreshold: "urgent",
+				RoutingKeyEnv:     "PAGERDUTY_ROUTING_KEY",
+			},
 			QuietHours: QuietHoursConfig{
 				Enabled:  false,
 				Start:    "22:00",
 				End:      "08:00",
 				Timezone: "Local",
+				Action:   "queue",
 			},
 		},
 		Logging: LoggingConfig{
 			Level: "info",
 			File:  "",
 		},
+		Telemetry: TelemetryConfig{
+			TracesExporter:  "none",
+			OTLPEndpoint:    "localhost:4317",
+			MetricsExporter: "prometheus",
+			MetricsPort:     9464,
+		},
+		Audit: AuditConfig{
+			Dir: "~/.joe/transcripts",
+		},
+		Redaction: RedactionConfig{
+			Enabled: true,
+		},
 	}
 }
 
@@ -214,6 +718,11 @@ func loadFromFile(cfg *Config, path string) error {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Unknown keys are usually typos. They are ignored here; Check reports them.
+	if errs := strictDecode(data, defaultConfig()); len(errs) > 0 {
+		slog.Warn("config file has unknown keys; run `joe config validate` for details", "path", path, "errors", errs)
+	}
+
 	return nil
 }
 
@@ -273,12 +782,9 @@ func applyEnvOverrides(cfg *Config) []string {
 // Save saves the config to a YAML file
 func Save(cfg *Config, path string) error {
 	// Expand home directory if path starts with ~
-	if len(path) > 0 && path[0] == '~' {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		path = filepath.Join(home, path[1:])
+	path, err := ExpandHome(path)
+	if err != nil {
+		return err
 	}
 
 	// Ensure directory exists
@@ -300,3 +806,72 @@ func Save(cfg *Config, path string) error {
 
 	return nil
 }
+
+// SaveCurrentModel sets llm.current in the config file at path, creating
+// the file if needed. Unlike Save, it edits the file in place, keeping its
+// other settings and comments as they are.
+func SaveCurrentModel(path, name string) error {
+	path, err := ExpandHome(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update config file: %s is not a mapping", path)
+	}
+	current := mappingValue(mappingValue(root, "llm", yaml.MappingNode), "current", yaml.ScalarNode)
+	current.Tag = "!!str"
+	current.Value = name
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in the mapping node m, adding the
+// key, or replacing a value that isn't of kind (such as an empty one), as
+// needed
+func mappingValue(m *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v := m.Content[i+1]
+			if v.Kind != kind {
+				*v = yaml.Node{Kind: kind, LineComment: v.LineComment}
+			}
+			return v
+		}
+	}
+	v := &yaml.Node{Kind: kind}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+	return v
+}
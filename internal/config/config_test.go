@@ -3,8 +3,12 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -32,6 +36,24 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Logging.Level != "info" {
 		t.Errorf("default logging level = %s, want info", cfg.Logging.Level)
 	}
+
+	if want := (RetryConfig{MaxAttempts: 3, BaseDelayMS: 1000, MaxDelaySec: 30}); cfg.LLM.Retry != want {
+		t.Errorf("default retry = %+v, want %+v", cfg.LLM.Retry, want)
+	}
+
+	if want := (CompactionConfig{ThresholdTokens: 100000, KeepTurns: 4}); cfg.LLM.Compaction != want {
+		t.Errorf("default compaction = %+v, want %+v", cfg.LLM.Compaction, want)
+	}
+
+	// Telemetry is opt-in
+	if cfg.Telemetry.Enabled || cfg.Telemetry.MetricsPort != 9464 {
+		t.Errorf("default telemetry = %+v, want disabled with metrics port 9464", cfg.Telemetry)
+	}
+
+	// Secrets are masked unless turned off
+	if !cfg.Redaction.Enabled {
+		t.Error("default redaction is disabled, want enabled")
+	}
 }
 
 func TestCurrentModel(t *testing.T) {
@@ -82,6 +104,169 @@ func TestModelNames(t *testing.T) {
 	}
 }
 
+func TestFallbackModels(t *testing.T) {
+	llm := LLMConfig{
+		Available: map[string]ModelConfig{
+			"claude-sonnet": {Provider: "claude", Model: "claude-sonnet-4-20250514"},
+			"gemini-flash":  {Provider: "gemini", Model: "gemini-2.5-flash"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		fallback []string
+		current  string
+		want     []string
+		wantErr  bool
+	}{
+		{"none", nil, "claude-sonnet", nil, false},
+		{"skips current", []string{"claude-sonnet", "gemini-flash"}, "claude-sonnet", []string{"gemini-flash"}, false},
+		{"unknown key", []string{"gpt"}, "claude-sonnet", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm.Fallback = tt.fallback
+			got, err := llm.FallbackModels(tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FallbackModels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FallbackModels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Pricing(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `llm:
+  current: claude-sonnet
+  show_usage: true
+  available:
+    claude-sonnet:
+      provider: claude
+      model: claude-sonnet-4-20250514
+      pricing:
+        input_per_million: 3
+        output_per_million: 15
+`
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if !cfg.LLM.ShowUsage {
+		t.Error("ShowUsage = false, want true")
+	}
+
+	mc, _ := cfg.LLM.CurrentModel()
+	if mc.Pricing == nil {
+		t.Fatal("Pricing not loaded")
+	}
+	// 1,240 in at $3/M + 380 out at $15/M = $0.00372 + $0.0057
+	if got, want := mc.Pricing.Cost(1240, 380), 0.00942; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("Cost() = %v, want %v", got, want)
+	}
+}
+
+func TestLoad_ToolApproval(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `tools:
+  approval:
+    run_command: allow
+    aws_iam_roles: deny
+`
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	// Configured entries are merged over the defaults
+	want := map[string]string{
+		"write_file":    "ask",
+		"edit_file":     "ask",
+		"run_command":   "allow",
+		"ssh_run":       "ask",
+		"aws_iam_roles": "deny",
+	}
+	if !reflect.DeepEqual(cfg.Tools.Approval, want) {
+		t.Errorf("Tools.Approval = %v, want %v", cfg.Tools.Approval, want)
+	}
+}
+
+func TestLoad_PromptContextFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{name: "defaults", yaml: "", want: []string{"~/.joe/JOE.md", ".joe/JOE.md"}},
+		{name: "replaced", yaml: "prompt:\n  context_files: [docs/ops.md]\n", want: []string{"docs/ops.md"}},
+		{name: "disabled", yaml: "prompt:\n  context_files: []\n", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.yaml), 0644); err != nil {
+				t.Fatalf("Failed to create test config: %v", err)
+			}
+			cfg, err := Load(configPath)
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if len(cfg.Prompt.ContextFiles) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(cfg.Prompt.ContextFiles, tt.want)) {
+				t.Errorf("Prompt.ContextFiles = %v, want %v", cfg.Prompt.ContextFiles, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_ToolPolicy(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configYAML := `tools:
+  disabled: [echo, "docker_*"]
+  run_command:
+    allowed: [df, ps]
+  write_file:
+    allowed_paths: ["~/work"]
+  timeouts:
+    run_command: 120
+`
+	if err := os.WriteFile(configPath, []byte(configYAML), 0644); err != nil {
+		t.Fatalf("Failed to create test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	if want := []string{"echo", "docker_*"}; !reflect.DeepEqual(cfg.Tools.Disabled, want) {
+		t.Errorf("Tools.Disabled = %v, want %v", cfg.Tools.Disabled, want)
+	}
+	if want := []string{"df", "ps"}; !reflect.DeepEqual(cfg.Tools.RunCommand.Allowed, want) {
+		t.Errorf("Tools.RunCommand.Allowed = %v, want %v", cfg.Tools.RunCommand.Allowed, want)
+	}
+	if want := []string{"~/work"}; !reflect.DeepEqual(cfg.Tools.WriteFile.AllowedPaths, want) {
+		t.Errorf("Tools.WriteFile.AllowedPaths = %v, want %v", cfg.Tools.WriteFile.AllowedPaths, want)
+	}
+	if cfg.Tools.TimeoutSeconds != 60 {
+		t.Errorf("Tools.TimeoutSeconds = %d, want default 60", cfg.Tools.TimeoutSeconds)
+	}
+	if want := map[string]int{"ask_user": 0, "run_command": 120}; !reflect.DeepEqual(cfg.Tools.Timeouts, want) {
+		t.Errorf("Tools.Timeouts = %v, want %v", cfg.Tools.Timeouts, want)
+	}
+}
+
 func TestLoad_NoFile(t *testing.T) {
 	// Load with non-existent file should return defaults
 	cfg, err := Load("/nonexistent/path/config.yaml")
@@ -215,6 +400,67 @@ func TestSave(t *testing.T) {
 	}
 }
 
+func TestSaveCurrentModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // "" means no file
+		keep     []string
+	}{
+		{
+			name: "replaces llm.current",
+			existing: `# My config
+llm:
+  current: claude # the default
+  available:
+    claude: {provider: claude, model: claude-sonnet-4}
+    gemini-flash: {provider: gemini, model: gemini-2.5-flash}
+logging:
+  level: debug
+`,
+			keep: []string{"# My config", "# the default", "level: debug"},
+		},
+		{
+			name: "adds llm.current",
+			existing: `llm:
+  available:
+    gemini-flash: {provider: gemini, model: gemini-2.5-flash}
+`,
+		},
+		{name: "creates the file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := SaveCurrentModel(path, "gemini-flash"); err != nil {
+				t.Fatalf("SaveCurrentModel() error: %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.keep {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("saved config lost %q:\n%s", want, data)
+				}
+			}
+			var file struct {
+				LLM struct {
+					Current string `yaml:"current"`
+				} `yaml:"llm"`
+			}
+			if err := yaml.Unmarshal(data, &file); err != nil || file.LLM.Current != "gemini-flash" {
+				t.Errorf("llm.current = %q (%v), want gemini-flash:\n%s", file.LLM.Current, err, data)
+			}
+		})
+	}
+}
+
 func TestLoad_InvalidYAML(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.yaml")
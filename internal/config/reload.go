@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Change is a setting that differs between two configs
+type Change struct {
+	Field string // Dotted path, e.g. "logging.level", "llm.available.opus", or "tools.approval"
+	Desc  string // e.g. `"info" → "debug"`, "added", "removed", or "changed"
+}
+
+func (c Change) String() string {
+	return c.Field + ": " + c.Desc
+}
+
+// In reports whether the change is to one of fields or a setting under it
+func (c Change) In(fields ...string) bool {
+	for _, f := range fields {
+		if c.Field == f || strings.HasPrefix(c.Field, f+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// changeDepth is how deep Changes looks into nested settings, e.g.
+// llm.available.<name> but not llm.available.<name>.model
+const changeDepth = 3
+
+// Changes lists the settings that differ from old to new, by key path,
+// down to individual models in llm.available. Lists and maps other than
+// llm.available are reported as a whole.
+func Changes(old, new *Config) []Change {
+	var changes []Change
+	diff(reflect.ValueOf(*old), reflect.ValueOf(*new), "", changeDepth, &changes)
+	return changes
+}
+
+func diff(a, b reflect.Value, path string, depth int, changes *[]Change) {
+	if reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return
+	}
+
+	switch {
+	case a.Kind() == reflect.Struct && depth > 0:
+		for i := range a.NumField() {
+			name, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			diff(a.Field(i), b.Field(i), joinPath(path, name), depth-1, changes)
+		}
+		return
+
+	case a.Kind() == reflect.Map && a.Type().Elem().Kind() == reflect.Struct && depth > 0:
+		var keys []string
+		for _, k := range append(a.MapKeys(), b.MapKeys()...) {
+			if !slices.Contains(keys, k.String()) {
+				keys = append(keys, k.String())
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			av, bv := a.MapIndex(reflect.ValueOf(k)), b.MapIndex(reflect.ValueOf(k))
+			switch {
+			case !av.IsValid():
+				*changes = append(*changes, Change{Field: joinPath(path, k), Desc: "added"})
+			case !bv.IsValid():
+				*changes = append(*changes, Change{Field: joinPath(path, k), Desc: "removed"})
+			case !reflect.DeepEqual(av.Interface(), bv.Interface()):
+				*changes = append(*changes, Change{Field: joinPath(path, k), Desc: "changed"})
+			}
+		}
+		return
+	}
+
+	desc := "changed"
+	switch a.Kind() {
+	case reflect.String:
+		desc = fmt.Sprintf("%q → %q", a.String(), b.String())
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		desc = fmt.Sprintf("%v → %v", a.Interface(), b.Interface())
+	}
+	*changes = append(*changes, Change{Field: path, Desc: desc})
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
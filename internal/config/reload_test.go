@@ -0,0 +1,44 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	old := defaultConfig()
+	new := defaultConfig()
+	new.Logging.Level = "debug"
+	new.Refresh.IntervalMinutes = 15
+	new.LLM.Available = map[string]ModelConfig{
+		"claude-sonnet": {Provider: "claude", Model: "claude-sonnet-4-5"},
+		"opus":          {Provider: "claude", Model: "claude-opus-4-1"},
+	}
+	new.Tools.Approval = map[string]string{"run_command": "ask"}
+
+	var got []string
+	for _, c := range Changes(old, new) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"llm.available.claude-sonnet: changed",
+		"llm.available.opus: added",
+		"tools.approval: changed",
+		"refresh.interval_minutes: 5 → 15",
+		`logging.level: "info" → "debug"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Changes() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if changes := Changes(old, defaultConfig()); len(changes) != 0 {
+		t.Errorf("Changes() of equal configs = %v", changes)
+	}
+}
+
+func TestChange_In(t *testing.T) {
+	c := Change{Field: "llm.available.opus"}
+	if !c.In("llm.available") || !c.In("logging", "llm") || c.In("llm.avail") {
+		t.Errorf("In() matches the wrong fields for %s", c.Field)
+	}
+}
@@ -1,8 +1,24 @@
 package config
 
 import (
+	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // ValidateAPIKeys validates that required API keys are set for the given model configuration.
@@ -14,11 +30,27 @@ func ValidateAPIKeys(mc ModelConfig) error {
 			return fmt.Errorf("ANTHROPIC_API_KEY environment variable is required for Claude provider")
 		}
 	case "gemini":
+		if mc.Vertex {
+			// Credentials come from ADC and are checked on first use
+			if mc.VertexProject() == "" {
+				return fmt.Errorf("project (or GOOGLE_CLOUD_PROJECT) is required for Gemini on Vertex AI")
+			}
+			return nil
+		}
 		geminiKey := os.Getenv("GEMINI_API_KEY")
 		googleKey := os.Getenv("GOOGLE_API_KEY")
 		if geminiKey == "" && googleKey == "" {
 			return fmt.Errorf("GEMINI_API_KEY or GOOGLE_API_KEY environment variable is required for Gemini provider")
 		}
+	case "openai-compatible":
+		if mc.BaseURL == "" {
+			return fmt.Errorf("base_url is required for openai-compatible provider")
+		}
+		if mc.APIKeyEnv != "" && os.Getenv(mc.APIKeyEnv) == "" {
+			return fmt.Errorf("%s environment variable is required for model %s at %s", mc.APIKeyEnv, mc.Model, mc.BaseURL)
+		}
+	case "bedrock":
+		// Credentials come from the AWS chain and are checked on first use
 	default:
 		return fmt.Errorf("unsupported LLM provider: %s", mc.Provider)
 	}
@@ -29,7 +61,7 @@ func ValidateAPIKeys(mc ModelConfig) error {
 // This is suitable for CLI output where we want to show detailed setup instructions.
 func ValidateAPIKeysWithUserMessage(mc ModelConfig) error {
 	// Check if provider is supported
-	supportedProviders := []string{"claude", "gemini"}
+	supportedProviders := []string{"claude", "gemini", "openai-compatible", "bedrock"}
 	providerSupported := false
 	for _, p := range supportedProviders {
 		if mc.Provider == p {
@@ -39,7 +71,7 @@ func ValidateAPIKeysWithUserMessage(mc ModelConfig) error {
 	}
 
 	if !providerSupported {
-		return fmt.Errorf("You need to connect Joe to an LLM.\n\nCurrently supported LLMs:\n  - Claude (Anthropic)\n  - Gemini (Google)\n\nConfigured provider '%s' is not supported.", mc.Provider)
+		return fmt.Errorf("You need to connect Joe to an LLM.\n\nCurrently supported LLMs:\n  - Claude (Anthropic)\n  - Gemini (Google)\n  - Any OpenAI-compatible endpoint (openai-compatible)\n  - AWS Bedrock (bedrock)\n\nConfigured provider '%s' is not supported.", mc.Provider)
 	}
 
 	// Check for API keys
@@ -50,12 +82,371 @@ func ValidateAPIKeysWithUserMessage(mc ModelConfig) error {
 			return fmt.Errorf("You need to connect Joe to an LLM.\n\nClaude is configured but ANTHROPIC_API_KEY is not set or is empty.\n\nCurrently supported LLMs:\n  - Claude (Anthropic) - requires ANTHROPIC_API_KEY\n  - Gemini (Google) - requires GEMINI_API_KEY or GOOGLE_API_KEY\n\nTo use Claude:\n  export ANTHROPIC_API_KEY=your-api-key-here\n\nTo use Gemini, update your config to use a Gemini model")
 		}
 	case "gemini":
+		if mc.Vertex {
+			if err := ValidateAPIKeys(mc); err != nil {
+				return fmt.Errorf("You need to connect Joe to an LLM.\n\n%v\n\nSet llm.available.<name>.project, or:\n  export GOOGLE_CLOUD_PROJECT=your-project-id", err)
+			}
+			return nil
+		}
 		geminiKey := os.Getenv("GEMINI_API_KEY")
 		googleKey := os.Getenv("GOOGLE_API_KEY")
 		if geminiKey == "" && googleKey == "" {
 			return fmt.Errorf("You need to connect Joe to an LLM.\n\nGemini is configured but neither GEMINI_API_KEY nor GOOGLE_API_KEY is set or both are empty.\n\nCurrently supported LLMs:\n  - Claude (Anthropic) - requires ANTHROPIC_API_KEY\n  - Gemini (Google) - requires GEMINI_API_KEY or GOOGLE_API_KEY\n\nTo use Gemini:\n  export GEMINI_API_KEY=your-api-key-here\n\nTo use Claude, update your config to use a Claude model")
 		}
+	case "openai-compatible":
+		if err := ValidateAPIKeys(mc); err != nil {
+			return fmt.Errorf("You need to connect Joe to an LLM.\n\n%v\n\nSet llm.available.<name>.base_url to the endpoint's API root and, if it needs a key,\napi_key_env to the environment variable that holds it:\n  export %s=your-api-key-here", err, cmp.Or(mc.APIKeyEnv, "OPENAI_API_KEY"))
+		}
 	}
 
 	return nil
 }
+
+// Problem is a mistake found in a config file
+type Problem struct {
+	Line    int // 1-based; 0 when the line isn't known
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// Check reads the config file at path strictly and returns every problem
+// in it: YAML syntax errors, unknown keys (usually typos, which Load
+// ignores), values of the wrong type, and invalid settings such as an
+// unknown logging.level. The error is for a file that can't be read.
+func Check(path string) ([]Problem, error) {
+	path, err := ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []Problem{yamlProblem(err.Error())}, nil
+	}
+	keys := keyLines(&root)
+
+	cfg := defaultConfig()
+	var problems []Problem
+	for _, msg := range strictDecode(data, cfg) {
+		p := yamlProblem(msg)
+		if m := unknownField.FindStringSubmatch(p.Message); m != nil {
+			p.Message = fmt.Sprintf("unknown key %q", keys.path(p.Line, m[1]))
+		}
+		problems = append(problems, p)
+	}
+	for _, fe := range cfg.validate() {
+		problems = append(problems, Problem{Line: keys.line(fe.field), Message: fe.field + ": " + fe.message})
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems, nil
+}
+
+// strictDecode decodes data into cfg, rejecting unknown keys, and returns
+// the decoding errors
+func strictDecode(data []byte, cfg *Config) []string {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(cfg)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Errors
+	}
+	return []string{err.Error()}
+}
+
+var (
+	yamlLine     = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	unknownField = regexp.MustCompile(`^field (\S+) not found in type`)
+)
+
+// yamlProblem turns a yaml.v3 error message into a Problem
+func yamlProblem(msg string) Problem {
+	m := yamlLine.FindStringSubmatch(msg)
+	if m == nil {
+		return Problem{Message: strings.TrimPrefix(msg, "yaml: ")}
+	}
+	line, _ := strconv.Atoi(m[1])
+	return Problem{Line: line, Message: m[2]}
+}
+
+// keyPosition is the dotted path and line of a key in a file, e.g.
+// "llm.filter.rules.0.action"; list items are keyed by index
+type keyPosition struct {
+	path string
+	line int
+}
+
+type keyPositions []keyPosition
+
+// keyLines returns the position of every key in a parsed file
+func keyLines(root *yaml.Node) keyPositions {
+	var keys keyPositions
+	var walk func(n *yaml.Node, prefix string)
+	walk = func(n *yaml.Node, prefix string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, prefix)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				path := n.Content[i].Value
+				if prefix != "" {
+					path = prefix + "." + path
+				}
+				keys = append(keys, keyPosition{path, n.Content[i].Line})
+				walk(n.Content[i+1], path)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				path := prefix + "." + strconv.Itoa(i)
+				keys = append(keys, keyPosition{path, c.Line})
+				walk(c, path)
+			}
+		}
+	}
+	walk(root, "")
+	return keys
+}
+
+// line returns the line of the key at path, or of its closest parent
+// present in the file
+func (k keyPositions) line(path string) int {
+	for {
+		for _, key := range k {
+			if key.path == path {
+				return key.line
+			}
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return 0
+		}
+		path = path[:i]
+	}
+}
+
+// path returns the dotted path of the key name on line, or just name
+func (k keyPositions) path(line int, name string) string {
+	for _, key := range k {
+		if key.line == line && (key.path == name || strings.HasSuffix(key.path, "."+name)) {
+			return key.path
+		}
+	}
+	return name
+}
+
+// fieldError is an invalid value, by the dotted path of its key
+type fieldError struct {
+	field   string
+	message string
+}
+
+// validate checks the values Load accepts but joe and joecored would
+// reject or silently ignore
+func (c *Config) validate() []fieldError {
+	var errs []fieldError
+	oneOf := func(field, value string, allowed ...string) {
+		if value != "" && !slices.Contains(allowed, value) {
+			errs = append(errs, fieldError{field, fmt.Sprintf("invalid value %q (use %s)", value, strings.Join(allowed, ", "))})
+		}
+	}
+	add := func(field, format string, args ...any) {
+		errs = append(errs, fieldError{field, fmt.Sprintf(format, args...)})
+	}
+
+	// llm
+	if _, ok := c.LLM.Available[c.LLM.Current]; !ok {
+		add("llm.current", "%q is not a model in llm.available", c.LLM.Current)
+	}
+	for _, name := range c.LLM.ModelNames() {
+		mc := c.LLM.Available[name]
+		field := "llm.available." + name
+		if mc.Provider == "" {
+			add(field, "provider is required")
+		}
+		oneOf(field+".provider", mc.Provider, "claude", "gemini", "openai-compatible", "bedrock")
+		if mc.Model == "" {
+			add(field, "model is required")
+		}
+		if mc.Provider == "openai-compatible" && mc.BaseURL == "" {
+			add(field, "base_url is required for openai-compatible")
+		}
+	}
+	for i, key := range c.LLM.Fallback {
+		if _, ok := c.LLM.Available[key]; !ok {
+			add(fmt.Sprintf("llm.fallback.%d", i), "%q is not a model in llm.available", key)
+		}
+	}
+	for i, rule := range c.LLM.Filter.Rules {
+		field := fmt.Sprintf("llm.filter.rules.%d", i)
+		oneOf(field+".action", rule.Action, "redact", "block")
+		switch {
+		case rule.Pattern == "" && rule.CIDR == "":
+			add(field, "pattern or cidr is required")
+		case rule.Pattern != "" && rule.CIDR != "":
+			add(field, "set pattern or cidr, not both")
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			add(field+".pattern", "invalid regular expression: %v", err)
+		}
+		if rule.CIDR != "" {
+			if _, err := netip.ParsePrefix(rule.CIDR); err != nil {
+				add(field+".cidr", "invalid range: %v", err)
+			}
+		}
+	}
+
+	// server, tools, refresh
+	if _, _, err := net.SplitHostPort(c.Server.Address); err != nil {
+		add("server.address", "want host:port: %v", err)
+	}
+	if c.Server.SessionTTLHours < 0 {
+		add("server.session_ttl_hours", "must not be negative")
+	}
+	if c.Server.MaxSessions < 0 {
+		add("server.max_sessions", "must not be negative")
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Tools.Approval)) {
+		oneOf("tools.approval."+name, c.Tools.Approval[name], "allow", "ask", "deny")
+	}
+	oneOf("tools.progress", c.Tools.Progress, "lines", "collapse", "off")
+	oneOf("tools.plan", c.Tools.Plan, "off", "show", "approve")
+	oneOf("tools.source_tools", c.Tools.SourceTools, "registered", "all")
+	sandbox := c.Tools.RunCommand.Sandbox
+	oneOf("tools.run_command.sandbox.backend", sandbox.Backend, "none", "container", "restricted")
+	if sandbox.Backend == "container" {
+		oneOf("tools.run_command.sandbox.runtime", sandbox.Runtime, "docker", "podman")
+		if sandbox.Image == "" {
+			add("tools.run_command.sandbox.image", "is required for the container backend")
+		}
+	}
+	for _, alias := range slices.Sorted(maps.Keys(c.Tools.SSH.Hosts)) {
+		host := c.Tools.SSH.Hosts[alias]
+		field := "tools.ssh.hosts." + alias
+		switch {
+		case host.Address == "":
+			add(field+".address", "is required")
+		case strings.HasPrefix(host.Address, "-") || strings.ContainsAny(host.Address, " \t\n"):
+			add(field+".address", "invalid address %q", host.Address)
+		}
+		if strings.HasPrefix(host.User, "-") || strings.ContainsAny(host.User, " \t\n@") {
+			add(field+".user", "invalid user %q", host.User)
+		}
+	}
+	if c.Tools.SSH.ConnectTimeoutSeconds < 0 {
+		add("tools.ssh.connect_timeout_seconds", "must not be negative")
+	}
+	for _, alias := range slices.Sorted(maps.Keys(c.Tools.SQL.Databases)) {
+		db := c.Tools.SQL.Databases[alias]
+		field := "tools.sql.databases." + alias
+		oneOf(field+".driver", db.Driver, "postgres", "mysql", "sqlite")
+		switch {
+		case db.DSN == "" && db.DSNEnv == "":
+			add(field, "dsn or dsn_env is required")
+		case db.DSN != "" && db.DSNEnv != "":
+			add(field, "set dsn or dsn_env, not both")
+		}
+	}
+	oneOf("tools.selection.mode", c.Tools.Selection.Mode, "off", "keywords", "embeddings")
+	if c.Tools.Selection.MaxTools < 1 {
+		add("tools.selection.max_tools", "must be at least 1")
+	}
+	if c.Tools.SQL.MaxRows < 1 {
+		add("tools.sql.max_rows", "must be at least 1")
+	}
+	if c.Refresh.IntervalMinutes < 0 {
+		add("refresh.interval_minutes", "must not be negative")
+	}
+	if c.Refresh.StaleAfter < 1 {
+		add("refresh.stale_after", "must be at least 1")
+	}
+	if c.Refresh.StaleTTLHours < 0 {
+		add("refresh.stale_ttl_hours", "must not be negative")
+	}
+	if c.Refresh.PromoteAfter < 0 {
+		add("refresh.promote_after", "must not be negative")
+	}
+
+	// notifications
+	channels := c.Notifications.Channels()
+	for _, name := range slices.Sorted(maps.Keys(channels)) {
+		oneOf("notifications."+name+".priority_threshold", channels[name].PriorityThreshold, "low", "medium", "high", "urgent")
+	}
+	if slack := c.Notifications.Slack; slack.Enabled {
+		switch {
+		case slack.WebhookURL != "":
+			if u, err := url.Parse(slack.WebhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+				add("notifications.slack.webhook_url", "want an https URL")
+			}
+		case slack.Channel == "":
+			add("notifications.slack", "webhook_url, or channel with a bot token, is required")
+		case slack.TokenEnv == "":
+			add("notifications.slack.token_env", "is required to post to a channel")
+		}
+	}
+	if webhook := c.Notifications.Webhook; webhook.Enabled {
+		if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("notifications.webhook.url", "want an http or https URL")
+		}
+	}
+	if email := c.Notifications.Email; email.Enabled {
+		if email.SMTPHost == "" {
+			add("notifications.email.smtp_host", "is required")
+		}
+		if email.From == "" {
+			add("notifications.email.from", "is required")
+		}
+		if len(email.To) == 0 {
+			add("notifications.email.to", "needs at least one address")
+		}
+	}
+	if pd := c.Notifications.PagerDuty; pd.Enabled && pd.RoutingKeyEnv == "" {
+		add("notifications.pagerduty.routing_key_env", "is required")
+	}
+	qh := c.Notifications.QuietHours
+	for field, value := range map[string]string{"notifications.quiet_hours.start": qh.Start, "notifications.quiet_hours.end": qh.End} {
+		if _, err := time.Parse("15:04", value); value != "" && err != nil {
+			add(field, "invalid time %q (use HH:MM)", value)
+		}
+	}
+	if _, err := time.LoadLocation(qh.Timezone); err != nil {
+		add("notifications.quiet_hours.timezone", "unknown time zone %q", qh.Timezone)
+	}
+	oneOf("notifications.quiet_hours.action", qh.Action, "queue", "drop")
+
+	// memory
+	if c.Memory.TopK < 1 {
+		add("memory.top_k", "must be at least 1")
+	}
+	if c.Memory.MinSimilarity < 0 || c.Memory.MinSimilarity > 1 {
+		add("memory.min_similarity", "must be between 0 and 1")
+	}
+
+	// logging, telemetry, redaction
+	oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "error")
+	oneOf("telemetry.traces_exporter", c.Telemetry.TracesExporter, "otlp", "stdout", "none")
+	oneOf("telemetry.metrics_exporter", c.Telemetry.MetricsExporter, "prometheus", "none")
+	for i, pattern := range c.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			add(fmt.Sprintf("redaction.patterns.%d", i), "invalid regular expression: %v", err)
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].field < errs[j].field })
+	return errs
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string // each problem, as "line N: message" substrings
+	}{
+		{
+			name: "valid",
+			yaml: "logging:\n  level: debug\nllm:\n  current: claude-sonnet\n",
+		},
+		{
+			name: "unknown keys",
+			yaml: "logging:\n  levle: debug\nrefesh:\n  interval_minutes: 5\n",
+			want: []string{`line 2: unknown key "logging.levle"`, `line 3: unknown key "refesh"`},
+		},
+		{
+			name: "invalid enums",
+			yaml: "logging:\n  level: verbose\nnotifications:\n  slack:\n    priority_threshold: critical\n",
+			want: []string{`line 2: logging.level: invalid value "verbose"`, `line 5: notifications.slack.priority_threshold: invalid value "critical"`},
+		},
+		{
+			name: "slack without a destination",
+			yaml: "notifications:\n  slack:\n    enabled: true\n  desktop:\n    enabled: true\n",
+			want: []string{"line 2: notifications.slack: webhook_url, or channel with a bot token, is required"},
+		},
+		{
+			name: "incomplete channels",
+			yaml: "notifications:\n  email:\n    enabled: true\n    smtp_host: smtp.example.com\n  webhook:\n    enabled: true\n    url: example.com/hook\n",
+			want: []string{"line 2: notifications.email.from: is required", "line 2: notifications.email.to: needs at least one address", "line 7: notifications.webhook.url: want an http or https URL"},
+		},
+		{
+			name: "stale node expiry out of range",
+			yaml: "refresh:\n  stale_after: 0\n  stale_ttl_hours: -1\n  promote_after: -1\n",
+			want: []string{"line 2: refresh.stale_after: must be at least 1", "line 3: refresh.stale_ttl_hours: must not be negative", "line 4: refresh.promote_after: must not be negative"},
+		},
+		{
+			name: "negative chat session limits",
+			yaml: "server:\n  address: localhost:7777\n  session_ttl_hours: -1\n  max_sessions: -1\n",
+			want: []string{"line 3: server.session_ttl_hours: must not be negative", "line 4: server.max_sessions: must not be negative"},
+		},
+		{
+			name: "memory out of range",
+			yaml: "memory:\n  top_k: 0\n  min_similarity: 1.5\n",
+			want: []string{"line 2: memory.top_k: must be at least 1", "line 3: memory.min_similarity: must be between 0 and 1"},
+		},
+		{
+			name: "invalid plan mode",
+			yaml: "tools:\n  plan: always\n",
+			want: []string{`line 2: tools.plan: invalid value "always"`},
+		},
+		{
+			name: "invalid source tools mode",
+			yaml: "tools:\n  source_tools: some\n",
+			want: []string{`line 2: tools.source_tools: invalid value "some"`},
+		},
+		{
+			name: "invalid tool selection",
+			yaml: "tools:\n  selection:\n    mode: vectors\n    max_tools: 0\n",
+			want: []string{`line 3: tools.selection.mode: invalid value "vectors"`, "line 4: tools.selection.max_tools: must be at least 1"},
+		},
+		{
+			name: "incomplete container sandbox",
+			yaml: "tools:\n  run_command:\n    sandbox:\n      backend: container\n      runtime: lxc\n      image: \"\"\n",
+			want: []string{`line 5: tools.run_command.sandbox.runtime: invalid value "lxc"`, "line 6: tools.run_command.sandbox.image: is required for the container backend"},
+		},
+		{
+			name: "invalid ssh hosts",
+			yaml: "tools:\n  ssh:\n    hosts:\n      db:\n        user: ops\n      web:\n        address: -oProxyCommand=sh\n",
+			want: []string{"line 4: tools.ssh.hosts.db.address: is required", `line 7: tools.ssh.hosts.web.address: invalid address "-oProxyCommand=sh"`},
+		},
+		{
+			name: "invalid sql databases",
+			yaml: "tools:\n  sql:\n    databases:\n      jobs:\n        driver: oracle\n        dsn: x\n      billing:\n        driver: postgres\n",
+			want: []string{`line 5: tools.sql.databases.jobs.driver: invalid value "oracle"`, "line 7: tools.sql.databases.billing: dsn or dsn_env is required"},
+		},
+		{
+			name: "wrong type",
+			yaml: "refresh:\n  interval_minutes: often\n",
+			want: []string{"line 2: cannot unmarshal"},
+		},
+		{
+			name: "unknown model",
+			yaml: "llm:\n  current: gpt\n  fallback: [claude-sonnet, opus]\n",
+			want: []string{`line 2: llm.current: "gpt" is not a model`, `line 3: llm.fallback.1: "opus" is not a model`},
+		},
+		{
+			name: "bad filter rule",
+			yaml: "llm:\n  filter:\n    rules:\n      - name: hosts\n        pattern: '('\n        action: drop\n",
+			want: []string{"line 5: llm.filter.rules.0.pattern: invalid regular expression", `line 6: llm.filter.rules.0.action: invalid value "drop"`},
+		},
+		{
+			name: "syntax error",
+			yaml: "llm:\n  current: [\n",
+			want: []string{"line 2: did not find expected node content"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			problems, err := Check(path)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if len(problems) != len(tt.want) {
+				t.Fatalf("Check() = %v, want %d problems", problems, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := problems[i].String(); !strings.Contains(got, want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_NoFile(t *testing.T) {
+	if _, err := Check(filepath.Join(t.TempDir(), "missing.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Check() error = %v, want not exist", err)
+	}
+}
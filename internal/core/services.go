@@ -1,10 +1,19 @@
 package core
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
 	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/cost"
+	"github.com/jaimegago/joe/internal/events"
 	"github.com/jaimegago/joe/internal/graph"
+	graphsqlite "github.com/jaimegago/joe/internal/graph/sqlite"
 	"github.com/jaimegago/joe/internal/llm"
 	"github.com/jaimegago/joe/internal/store"
+	storesqlite "github.com/jaimegago/joe/internal/store/sqlite"
 )
 
 // Services provides access to all core functionality
@@ -14,18 +23,64 @@ type Services struct {
 	LLM    llm.LLMAdapter
 	Graph  graph.GraphStore
 	Store  store.Store
+	Costs  *cost.Tracker // Daily LLM spending and budget, shared with joe through the store
+	Events *events.Bus   // What joecored is doing, streamed by GET /api/v1/events
+
+	graphCloser io.Closer // closes the persistent graph database
 }
 
 // New creates a new Services instance
-// For now this is a placeholder - we'll wire up real implementations in later phases
-func New(cfg *config.Config) (*Services, error) {
+// Opens the SQL store at cfg.Store.Path (running migrations as needed) and
+// loads the persistent graph from cfg.Graph.Path.
+// The LLM is left for the caller to connect; joecored sets one held to the
+// refresh LLM budget.
+func New(ctx context.Context, cfg *config.Config) (*Services, error) {
+	storePath, err := config.ExpandHome(cfg.Store.Path)
+	if err != nil {
+		return nil, err
+	}
+	graphPath, err := config.ExpandHome(cfg.Graph.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlStore, err := storesqlite.Open(ctx, storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+
+	graphStore, err := graphsqlite.Open(ctx, graphPath)
+	if err != nil {
+		sqlStore.Close()
+		return nil, fmt.Errorf("failed to open graph: %w", err)
+	}
+
 	return &Services{
-		Config: cfg,
+		Config:      cfg,
+		Store:       sqlStore,
+		Costs:       cost.NewTracker(sqlStore, cfg.LLM.Cost.DailyBudgetUSD),
+		Events:      events.NewBus(),
+		Graph:       graphStore,
+		graphCloser: graphStore,
 	}, nil
 }
 
 // Close cleans up resources
 func (s *Services) Close() error {
-	// TODO: Close LLM, Graph, Store connections
-	return nil
+	// TODO: Close LLM connections
+	var errs []error
+	if s.Events != nil {
+		s.Events.Close()
+	}
+	if s.graphCloser != nil {
+		if err := s.graphCloser.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close graph: %w", err))
+		}
+	}
+	if s.Store != nil {
+		if err := s.Store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close store: %w", err))
+		}
+	}
+	return errors.Join(errs...)
 }
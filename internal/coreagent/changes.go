@@ -0,0 +1,179 @@
+package coreagent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/notify"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// maxNotifiedChanges caps the changes a notification lists
+const maxNotifiedChanges = 5
+
+// openCIDRs are address ranges that admit the whole internet
+var openCIDRs = []string{"0.0.0.0/0", "::/0"}
+
+// recordChanges stores the changes a refresh of sourceID made and returns
+// those rated high priority or above, for the notification at the end of
+// the run
+func (r *Refresher) recordChanges(ctx context.Context, run *store.RefreshRun, sourceID string, c sourceChanges) []store.GraphChange {
+	records := changeRecords(run.ID, sourceID, c, r.now())
+	if err := r.store.AddGraphChanges(ctx, records); err != nil {
+		r.logger.Warn("failed to record graph changes", "run", run.ID, "source", sourceID, "error", err)
+	}
+	var high []store.GraphChange
+	for _, rec := range records {
+		if p, _ := notify.ParsePriority(rec.Priority); p >= notify.High {
+			high = append(high, rec)
+		}
+	}
+	return high
+}
+
+// notifyChanges tells the user about the high-priority changes of a run
+func (r *Refresher) notifyChanges(ctx context.Context, run *store.RefreshRun, high []store.GraphChange) {
+	if r.notifier == nil || len(high) == 0 {
+		return
+	}
+	n := notify.Notification{
+		Title:    fmt.Sprintf("joe: %d high-priority infrastructure changes", len(high)),
+		Priority: notify.High,
+	}
+	if len(high) == 1 {
+		n.Title = "joe: " + high[0].Reason
+	}
+	var lines []string
+	for _, c := range high[:min(len(high), maxNotifiedChanges)] {
+		lines = append(lines, c.SourceID+": "+c.Reason)
+	}
+	if extra := len(high) - maxNotifiedChanges; extra > 0 {
+		lines = append(lines, fmt.Sprintf("and %d more", extra))
+	}
+	n.Message = strings.Join(lines, "\n")
+	if err := r.notifier.Notify(ctx, n); err != nil {
+		r.logger.Warn("failed to send notification", "run", run.ID, "error", err)
+	}
+}
+
+// changeRecords turns the changes a refresh made for a source into the
+// records stored for its run, each rated for notification
+func changeRecords(runID, sourceID string, c sourceChanges, at time.Time) []store.GraphChange {
+	var records []store.GraphChange
+	add := func(kind, action, target string, before, after map[string]any, priority notify.Priority, reason string) {
+		records = append(records, store.GraphChange{
+			RunID:     runID,
+			SourceID:  sourceID,
+			Kind:      kind,
+			Action:    action,
+			Target:    target,
+			Before:    before,
+			After:     after,
+			Priority:  priority.String(),
+			Reason:    reason,
+			CreatedAt: at,
+		})
+	}
+
+	for _, n := range c.AddedNodes {
+		p, reason := rateNode(nil, &n)
+		add(store.ChangeNode, store.ChangeAdded, n.ID, nil, nodeRecord(n), p, reason)
+	}
+	for _, m := range c.ModifiedNodes {
+		p, reason := rateNode(&m.Before, &m.After)
+		add(store.ChangeNode, store.ChangeModified, m.After.ID, nodeRecord(m.Before), nodeRecord(m.After), p, reason)
+	}
+	for _, n := range c.RemovedNodes {
+		p, reason := rateNode(&n, nil)
+		add(store.ChangeNode, store.ChangeRemoved, n.ID, nodeRecord(n), nil, p, reason)
+	}
+	for _, m := range c.Stale {
+		add(store.ChangeNode, store.ChangeStale, m.After.ID, nodeRecord(m.Before), nodeRecord(m.After), notify.Low, "")
+	}
+	for _, e := range c.AddedEdges {
+		add(store.ChangeEdge, store.ChangeAdded, edgeTarget(e), nil, edgeRecord(e), notify.Low, "")
+	}
+	for _, m := range c.ModifiedEdges {
+		add(store.ChangeEdge, store.ChangeModified, edgeTarget(m.After), edgeRecord(m.Before), edgeRecord(m.After), notify.Low, "")
+	}
+	for _, e := range c.RemovedEdges {
+		add(store.ChangeEdge, store.ChangeRemoved, edgeTarget(e), edgeRecord(e), nil, notify.Low, "")
+	}
+	return records
+}
+
+// rateNode rates a node being added (before nil), modified, or removed
+// (after nil): newly reachable from the internet is high, a removal is
+// medium, anything else low
+func rateNode(before, after *graph.Node) (notify.Priority, string) {
+	switch {
+	case after == nil:
+		return notify.Medium, fmt.Sprintf("%s %s removed", before.Type, before.ID)
+	case exposure(*after) == "":
+		return notify.Low, ""
+	case before == nil:
+		return notify.High, fmt.Sprintf("new public %s %s: %s", after.Type, after.ID, exposure(*after))
+	case exposure(*before) == "":
+		return notify.High, fmt.Sprintf("%s %s is now public: %s", after.Type, after.ID, exposure(*after))
+	default:
+		return notify.Low, ""
+	}
+}
+
+// exposure returns why a node's metadata makes it reachable from the
+// internet, or "" when it doesn't
+func exposure(n graph.Node) string {
+	m := n.Metadata
+	switch {
+	case m["public"] == true:
+		return "marked public"
+	case m["public_ip"] != nil && m["public_ip"] != "":
+		return fmt.Sprintf("public IP %v", m["public_ip"])
+	case m["scheme"] == "internet-facing":
+		return "internet-facing"
+	case n.Type == "service" && m["type"] == "LoadBalancer":
+		return "LoadBalancer service"
+	}
+	// Firewall and security group rules, e.g. cidr: 0.0.0.0/0
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		values, ok := m[k].([]any)
+		if !ok {
+			values = []any{m[k]}
+		}
+		for _, v := range values {
+			if s, ok := v.(string); ok && slices.Contains(openCIDRs, s) {
+				return fmt.Sprintf("%s open to %s", k, s)
+			}
+		}
+	}
+	return ""
+}
+
+// nodeRecord and edgeRecord are the details stored for a change
+func nodeRecord(n graph.Node) map[string]any {
+	return map[string]any{"type": n.Type, "source_id": n.SourceID, "metadata": n.Metadata}
+}
+
+func edgeRecord(e graph.Edge) map[string]any {
+	return map[string]any{
+		"from":       e.From,
+		"to":         e.To,
+		"relation":   e.Relation,
+		"confidence": int(e.Confidence),
+		"source":     e.Source,
+		"context":    e.Context,
+	}
+}
+
+func edgeTarget(e graph.Edge) string {
+	return fmt.Sprintf("%s -[%s]-> %s", e.From, e.Relation, e.To)
+}
@@ -0,0 +1,125 @@
+package coreagent
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+func TestRefresher_Changes(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "aws-prod", Type: "aws"})
+
+	aws := &fakeCollector{state: &graph.Subgraph{
+		Nodes: []graph.Node{
+			{ID: "vpc/vpc-1", Type: "vpc"},
+			{ID: "ec2/i-1", Type: "ec2_instance", Metadata: map[string]any{"private_ip": "10.0.1.5"}},
+		},
+		Edges: []graph.Edge{{From: "vpc/vpc-1", To: "ec2/i-1", Relation: "contains"}},
+	}}
+	g := graph.NewMemoryStore()
+	notifier := &recordingNotifier{}
+	r := NewRefresher(g, st, time.Minute,
+		WithCollector("aws", aws),
+		WithRefreshNotifier(notifier),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	if _, err := r.Refresh(ctx, TriggerManual); err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if len(notifier.sent) != 0 {
+		t.Errorf("sent %+v for a private account, want nothing", notifier.sent)
+	}
+
+	// The instance gets a public IP and moves behind a new internet-facing
+	// load balancer
+	aws.state = &graph.Subgraph{
+		Nodes: []graph.Node{
+			{ID: "vpc/vpc-1", Type: "vpc"},
+			{ID: "ec2/i-1", Type: "ec2_instance", Metadata: map[string]any{"private_ip": "10.0.1.5", "public_ip": "203.0.113.7"}},
+			{ID: "lb/web", Type: "load_balancer", Metadata: map[string]any{"scheme": "internet-facing"}},
+		},
+		Edges: []graph.Edge{
+			{From: "vpc/vpc-1", To: "lb/web", Relation: "contains"},
+			{From: "lb/web", To: "ec2/i-1", Relation: "routes_to"},
+		},
+	}
+	run, err := r.Refresh(ctx, TriggerManual)
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if run.NodesUpdated != 2 || run.EdgesUpdated != 2 || run.EdgesRemoved != 1 {
+		t.Errorf("run = %+v, want 2 nodes and 2 edges updated, 1 edge removed", run)
+	}
+	if _, err := g.Path(ctx, "vpc/vpc-1", "ec2/i-1"); err != nil {
+		t.Errorf("Path() error: %v, want a path through the load balancer", err)
+	}
+	if related, _ := g.Related(ctx, "vpc/vpc-1", 1); len(related.Edges) != 1 {
+		t.Errorf("edges of vpc/vpc-1 = %+v, want the stale one removed", related.Edges)
+	}
+
+	changes, err := st.ListGraphChanges(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("ListGraphChanges() error: %v", err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Action+" "+c.Target+" "+c.Priority)
+	}
+	want := []string{
+		"added lb/web high",
+		"modified ec2/i-1 high",
+		"added vpc/vpc-1 -[contains]-> lb/web low",
+		"added lb/web -[routes_to]-> ec2/i-1 low",
+		"removed vpc/vpc-1 -[contains]-> ec2/i-1 low",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if len(notifier.sent) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(notifier.sent))
+	}
+	n := notifier.sent[0]
+	wantMessage := "aws-prod: new public load_balancer lb/web: internet-facing\n" +
+		"aws-prod: ec2_instance ec2/i-1 is now public: public IP 203.0.113.7"
+	if n.Title != "joe: 2 high-priority infrastructure changes" || n.Message != wantMessage {
+		t.Errorf("notification = %+v", n)
+	}
+}
+
+func TestExposure(t *testing.T) {
+	tests := []struct {
+		name string
+		node graph.Node
+		want string
+	}{
+		{"private", graph.Node{Type: "ec2_instance", Metadata: map[string]any{"private_ip": "10.0.1.5"}}, ""},
+		{"public ip", graph.Node{Type: "ec2_instance", Metadata: map[string]any{"public_ip": "203.0.113.7"}}, "public IP 203.0.113.7"},
+		{"internal lb", graph.Node{Type: "load_balancer", Metadata: map[string]any{"scheme": "internal"}}, ""},
+		{"lb service", graph.Node{Type: "service", Metadata: map[string]any{"type": "LoadBalancer"}}, "LoadBalancer service"},
+		{"open rule", graph.Node{Type: "security_group_rule", Metadata: map[string]any{"port": 22.0, "cidrs": []any{"10.0.0.0/8", "0.0.0.0/0"}}},
+			"cidrs open to 0.0.0.0/0"},
+		{"no metadata", graph.Node{Type: "vpc"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exposure(tt.node); got != tt.want {
+				t.Errorf("exposure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package coreagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// Answers to ClarifyEdge clarifications
+const (
+	AnswerYes = "yes"
+	AnswerNo  = "no"
+)
+
+var (
+	// ErrInvalidAnswer is returned for an answer that isn't one of the
+	// clarification's options
+	ErrInvalidAnswer = errors.New("invalid answer")
+
+	// ErrNotPending is returned for a clarification that was already
+	// answered or dismissed
+	ErrNotPending = errors.New("clarification is not pending")
+)
+
+// ClarificationStore is the subset of store.Store clarifications need
+type ClarificationStore interface {
+	GetClarification(ctx context.Context, id int64) (*store.Clarification, error)
+	UpdateClarification(ctx context.Context, c store.Clarification) error
+}
+
+// edgeClarification asks the user whether e holds
+func edgeClarification(e graph.Edge) store.Clarification {
+	return store.Clarification{
+		Type:     store.ClarifyEdge,
+		Subject:  edgeTarget(e),
+		Question: fmt.Sprintf("Is it right that %s %s %s?", e.From, strings.ReplaceAll(e.Relation, "_", " "), e.To),
+		Options:  []string{AnswerYes, AnswerNo},
+		Context:  edgeRecord(e),
+	}
+}
+
+// AnswerClarification records the answer to a pending clarification and
+// applies it to g. For ClarifyEdge, yes makes the edge user-confirmed,
+// adding it back if its source has since stopped reporting it, and no
+// deletes it; the refresher keeps to the answer from then on.
+func AnswerClarification(ctx context.Context, g graph.GraphStore, st ClarificationStore, id int64, answer string) (*store.Clarification, error) {
+	c, err := pending(ctx, st, id)
+	if err != nil {
+		return nil, err
+	}
+	if answer == "" {
+		return nil, fmt.Errorf("%w: the answer is empty", ErrInvalidAnswer)
+	}
+	if len(c.Options) > 0 && !slices.Contains(c.Options, answer) {
+		return nil, fmt.Errorf("%w %q: must be one of %v", ErrInvalidAnswer, answer, c.Options)
+	}
+
+	if c.Type == store.ClarifyEdge {
+		if err := applyEdgeAnswer(ctx, g, c, answer == AnswerYes); err != nil {
+			return nil, err
+		}
+	}
+	return resolve(ctx, st, c, store.ClarificationAnswered, answer)
+}
+
+// DismissClarification closes a pending clarification without answering
+// it. It won't be asked again.
+func DismissClarification(ctx context.Context, st ClarificationStore, id int64) (*store.Clarification, error) {
+	c, err := pending(ctx, st, id)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(ctx, st, c, store.ClarificationDismissed, "")
+}
+
+// pending returns clarification id if it is pending
+func pending(ctx context.Context, st ClarificationStore, id int64) (*store.Clarification, error) {
+	c, err := st.GetClarification(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != store.ClarificationPending {
+		return nil, fmt.Errorf("clarification %d is %s: %w", id, c.Status, ErrNotPending)
+	}
+	return c, nil
+}
+
+// resolve records c as status with answer
+func resolve(ctx context.Context, st ClarificationStore, c *store.Clarification, status, answer string) (*store.Clarification, error) {
+	now := time.Now()
+	c.Status = status
+	c.Answer = answer
+	c.AnsweredAt = &now
+	if err := st.UpdateClarification(ctx, *c); err != nil {
+		return nil, fmt.Errorf("failed to record answer: %w", err)
+	}
+	return c, nil
+}
+
+// applyEdgeAnswer confirms or deletes the edge c asks about
+func applyEdgeAnswer(ctx context.Context, g graph.GraphStore, c *store.Clarification, holds bool) error {
+	from, _ := c.Context["from"].(string)
+	to, _ := c.Context["to"].(string)
+	relation, _ := c.Context["relation"].(string)
+	if from == "" || to == "" || relation == "" {
+		return fmt.Errorf("clarification %d doesn't name an edge", c.ID)
+	}
+
+	if !holds {
+		if err := g.DeleteEdge(ctx, from, to, relation); err != nil && !errors.Is(err, graph.ErrNotFound) {
+			return fmt.Errorf("failed to delete edge: %w", err)
+		}
+		return nil
+	}
+
+	edge, err := findEdge(ctx, g, from, to, relation)
+	if err != nil {
+		return err
+	}
+	if edge == nil {
+		// No longer reported; add it back if both ends are still there
+		for _, id := range []string{from, to} {
+			if _, err := g.GetNode(ctx, id); errors.Is(err, graph.ErrNotFound) {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("failed to get node %s: %w", id, err)
+			}
+		}
+		source, _ := c.Context["source"].(string)
+		edgeContext, _ := c.Context["context"].(string)
+		edge = &graph.Edge{From: from, To: to, Relation: relation, Source: source, Context: edgeContext}
+	}
+	edge.Confidence = graph.UserConfirmed
+	if err := g.AddEdge(ctx, *edge); err != nil {
+		return fmt.Errorf("failed to confirm edge: %w", err)
+	}
+	return nil
+}
+
+// findEdge returns the edge in g, or nil if there is none
+func findEdge(ctx context.Context, g graph.GraphStore, from, to, relation string) (*graph.Edge, error) {
+	related, err := g.Related(ctx, from, 1)
+	if errors.Is(err, graph.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edges of %s: %w", from, err)
+	}
+	for _, e := range related.Edges {
+		if e.From == from && e.To == to && e.Relation == relation {
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
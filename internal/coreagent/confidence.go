@@ -0,0 +1,110 @@
+package coreagent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// WithEdgePromotion promotes an inferred edge to corroborated once its
+// source has reported it on n refreshes. The default, 0, never promotes.
+func WithEdgePromotion(n int) RefreshOption {
+	return func(r *Refresher) { r.promoteAfter = max(n, 0) }
+}
+
+// rescore carries the confidence of the edges in before over to those the
+// source reports in after, where edges are written with the confidence
+// the source gives them:
+//   - an edge never loses confidence it has gained, e.g. by being
+//     confirmed by the user
+//   - inferred edges count the refreshes that reported them, and are
+//     promoted to corroborated as described at WithEdgePromotion
+//   - edges the user confirmed are user-confirmed, and those the user
+//     rejected are dropped unless the source reports them explicitly
+//
+// Edges the source wrote but no longer reports are contradicted by it:
+// user-confirmed ones are kept and corroborated ones are demoted to
+// inferred, and the rest are left out of after for Diff to remove.
+func (r *Refresher) rescore(sourceID string, before, after *graph.Subgraph, answers map[string]bool) {
+	old := make(map[string]graph.Edge, len(before.Edges))
+	for _, e := range before.Edges {
+		old[edgeTarget(e)] = e
+	}
+
+	reported := make(map[string]bool, len(after.Edges))
+	edges := after.Edges[:0]
+	for _, e := range after.Edges {
+		target := edgeTarget(e)
+		reported[target] = true
+		prev, known := old[target]
+		if known {
+			e.Confidence = max(e.Confidence, prev.Confidence)
+			e.Observations = prev.Observations
+		}
+		if holds, answered := answers[target]; answered && holds {
+			e.Confidence = graph.UserConfirmed
+		} else if answered && e.Confidence < graph.Explicit {
+			continue
+		}
+		if e.Confidence == graph.Inferred && r.promoteAfter > 0 {
+			e.Observations++
+			if e.Observations >= r.promoteAfter {
+				e.Confidence = graph.Corroborated
+			}
+		}
+		edges = append(edges, e)
+	}
+
+	for _, e := range before.Edges {
+		if e.Source != sourceID || reported[edgeTarget(e)] {
+			continue
+		}
+		switch e.Confidence {
+		case graph.UserConfirmed:
+			edges = append(edges, e)
+		case graph.Corroborated:
+			e.Confidence = graph.Inferred
+			e.Observations = 0
+			edges = append(edges, e)
+		}
+	}
+	after.Edges = edges
+}
+
+// edgeAnswers returns whether each edge the user was asked about holds,
+// by edgeTarget
+func (r *Refresher) edgeAnswers(ctx context.Context) (map[string]bool, error) {
+	answered, err := r.store.ListClarifications(ctx, store.ClarificationAnswered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clarifications: %w", err)
+	}
+	answers := make(map[string]bool)
+	for _, c := range answered {
+		if c.Type == store.ClarifyEdge {
+			answers[c.Subject] = c.Answer == AnswerYes
+		}
+	}
+	return answers, nil
+}
+
+// askAbout queues a clarification for each inferred edge changes added or
+// demoted, for the user to confirm or reject. A question already asked
+// isn't asked again.
+func (r *Refresher) askAbout(ctx context.Context, changes graph.ChangeSet) {
+	edges := changes.AddedEdges
+	for _, m := range changes.ModifiedEdges {
+		if m.After.Confidence < m.Before.Confidence {
+			edges = append(edges, m.After)
+		}
+	}
+	for _, e := range edges {
+		if e.Confidence != graph.Inferred {
+			continue
+		}
+		if _, err := r.store.AddClarification(ctx, edgeClarification(e)); err != nil {
+			r.logger.Warn("failed to queue clarification", "edge", edgeTarget(e), "error", err)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package coreagent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+func TestRefresher_EdgeConfidence(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "repo", Type: "git"})
+
+	g := graph.NewMemoryStore()
+	nodes := []graph.Node{{ID: "api", Type: "service"}, {ID: "postgres", Type: "database"}, {ID: "redis", Type: "cache"}}
+	uses := graph.Edge{From: "api", To: "postgres", Relation: "uses", Confidence: graph.Inferred}
+	caches := graph.Edge{From: "api", To: "redis", Relation: "uses", Confidence: graph.Inferred}
+	git := &fakeCollector{state: &graph.Subgraph{Nodes: nodes, Edges: []graph.Edge{uses, caches}}}
+	r := NewRefresher(g, st, time.Minute,
+		WithCollector("git", git),
+		WithEdgePromotion(2),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	refresh := func(edges ...graph.Edge) {
+		t.Helper()
+		git.state.Edges = edges
+		if _, err := r.Refresh(ctx, TriggerManual); err != nil {
+			t.Fatalf("Refresh() error: %v", err)
+		}
+	}
+	confidence := func(e graph.Edge) graph.ConfidenceLevel {
+		t.Helper()
+		found, err := findEdge(ctx, g, e.From, e.To, e.Relation)
+		if err != nil {
+			t.Fatalf("findEdge() error: %v", err)
+		}
+		if found == nil {
+			return 0
+		}
+		return found.Confidence
+	}
+	pending := func() map[string]int64 {
+		t.Helper()
+		list, err := st.ListClarifications(ctx, store.ClarificationPending)
+		if err != nil {
+			t.Fatalf("ListClarifications() error: %v", err)
+		}
+		ids := make(map[string]int64)
+		for _, c := range list {
+			ids[c.Subject] = c.ID
+		}
+		return ids
+	}
+
+	// New inferred edges are asked about
+	refresh(uses, caches)
+	if got := confidence(uses); got != graph.Inferred {
+		t.Errorf("after 1 refresh, confidence = %v, want inferred", got)
+	}
+	asked := pending()
+	if len(asked) != 2 || asked[edgeTarget(uses)] == 0 {
+		t.Fatalf("pending clarifications = %v, want one per edge", asked)
+	}
+
+	// Reported again: promoted
+	refresh(uses, caches)
+	if got := confidence(uses); got != graph.Corroborated {
+		t.Errorf("after 2 refreshes, confidence = %v, want corroborated", got)
+	}
+
+	// No longer reported: demoted, then removed
+	refresh(caches)
+	if got := confidence(uses); got != graph.Inferred {
+		t.Errorf("contradicted once, confidence = %v, want inferred", got)
+	}
+	refresh(caches)
+	if got := confidence(uses); got != 0 {
+		t.Errorf("contradicted twice, confidence = %v, want the edge removed", got)
+	}
+
+	// Confirmed by the user: added back, and kept though not reported
+	if _, err := AnswerClarification(ctx, g, st, asked[edgeTarget(uses)], AnswerYes); err != nil {
+		t.Fatalf("AnswerClarification(yes) error: %v", err)
+	}
+	if got := confidence(uses); got != graph.UserConfirmed {
+		t.Errorf("after yes, confidence = %v, want confirmed", got)
+	}
+	refresh(caches)
+	if got := confidence(uses); got != graph.UserConfirmed {
+		t.Errorf("confirmed and not reported, confidence = %v, want confirmed", got)
+	}
+
+	// Rejected by the user: deleted, and not added back
+	if _, err := AnswerClarification(ctx, g, st, asked[edgeTarget(caches)], AnswerNo); err != nil {
+		t.Fatalf("AnswerClarification(no) error: %v", err)
+	}
+	refresh(uses, caches)
+	if got := confidence(caches); got != 0 {
+		t.Errorf("rejected, confidence = %v, want the edge removed", got)
+	}
+	if got := pending(); len(got) != 0 {
+		t.Errorf("pending clarifications = %v, want none asked again", got)
+	}
+}
+
+func TestAnswerClarification(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	g := graph.NewMemoryStore()
+
+	edge := graph.Edge{From: "api", To: "postgres", Relation: "uses", Confidence: graph.Inferred}
+	c, err := st.AddClarification(ctx, edgeClarification(edge))
+	if err != nil {
+		t.Fatalf("AddClarification() error: %v", err)
+	}
+	other, _ := st.AddClarification(ctx, edgeClarification(graph.Edge{From: "api", To: "redis", Relation: "uses"}))
+
+	tests := []struct {
+		name   string
+		id     int64
+		answer string
+		want   error
+	}{
+		{"empty answer", c.ID, "", ErrInvalidAnswer},
+		{"not an option", c.ID, "maybe", ErrInvalidAnswer},
+		{"unknown", 999, AnswerYes, store.ErrNotFound},
+		{"answered", c.ID, AnswerYes, nil},
+		{"answered twice", c.ID, AnswerNo, ErrNotPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := AnswerClarification(ctx, g, st, tt.id, tt.answer)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("AnswerClarification() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Neither end is in the graph, so there is no edge to confirm
+	if sub, _ := g.Snapshot(ctx); len(sub.Edges) != 0 {
+		t.Errorf("edges = %+v, want none", sub.Edges)
+	}
+	got, err := st.GetClarification(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("GetClarification() error: %v", err)
+	}
+	if got.Status != store.ClarificationAnswered || got.Answer != AnswerYes || got.AnsweredAt == nil {
+		t.Errorf("clarification = %+v, want answered yes", *got)
+	}
+
+	dismissed, err := DismissClarification(ctx, st, other.ID)
+	if err != nil {
+		t.Fatalf("DismissClarification() error: %v", err)
+	}
+	if dismissed.Status != store.ClarificationDismissed || dismissed.Answer != "" {
+		t.Errorf("DismissClarification() = %+v, want dismissed", *dismissed)
+	}
+}
@@ -0,0 +1,84 @@
+package coreagent
+
+import (
+	"maps"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+)
+
+// Metadata the refresher marks nodes with while their source no longer
+// reports them; a node reported again is rewritten without it
+const (
+	// MissedRefreshesKey counts the source's refreshes in a row that
+	// didn't report the node
+	MissedRefreshesKey = "missed_refreshes"
+
+	// StaleSinceKey holds when the node was marked stale (RFC 3339)
+	StaleSinceKey = "stale_since"
+)
+
+// WithNodeExpiry keeps the nodes a source stops reporting until they have
+// been missing from staleAfter of its refreshes in a row, when they are
+// marked stale, and deletes them once they have been stale for ttl. The
+// default, 1 and 0, deletes them on the first refresh that misses them.
+func WithNodeExpiry(staleAfter int, ttl time.Duration) RefreshOption {
+	return func(r *Refresher) {
+		r.staleAfter = max(staleAfter, 1)
+		r.staleTTL = max(ttl, 0)
+	}
+}
+
+// expire decides what becomes of the nodes changes removes. Those missing
+// long enough are left to be deleted; the rest are taken out of changes
+// and returned to be kept with another miss counted, along with the
+// marking of those this refresh made stale.
+func (r *Refresher) expire(changes *graph.ChangeSet) (kept []graph.Node, stale []graph.NodeChange) {
+	now := r.now()
+	var removed []graph.Node
+	for _, n := range changes.RemovedNodes {
+		since, isStale := staleSince(n)
+		becameStale := !isStale && missedRefreshes(n)+1 >= r.staleAfter
+		if becameStale {
+			since, isStale = now, true
+		}
+		if isStale && now.Sub(since) >= r.staleTTL {
+			removed = append(removed, n)
+			continue
+		}
+
+		marked := n
+		marked.Metadata = maps.Clone(n.Metadata)
+		if marked.Metadata == nil {
+			marked.Metadata = make(map[string]any)
+		}
+		marked.Metadata[MissedRefreshesKey] = missedRefreshes(n) + 1
+		if isStale {
+			marked.Metadata[StaleSinceKey] = since.UTC().Format(time.RFC3339)
+		}
+		if becameStale {
+			stale = append(stale, graph.NodeChange{Before: n, After: marked})
+		}
+		kept = append(kept, marked)
+	}
+	changes.RemovedNodes = removed
+	return kept, stale
+}
+
+// missedRefreshes returns the refreshes in a row that missed n
+func missedRefreshes(n graph.Node) int {
+	switch v := n.Metadata[MissedRefreshesKey].(type) {
+	case int:
+		return v
+	case float64: // Read back from JSON
+		return int(v)
+	}
+	return 0
+}
+
+// staleSince returns when n was marked stale, if it was
+func staleSince(n graph.Node) (time.Time, bool) {
+	s, _ := n.Metadata[StaleSinceKey].(string)
+	t, err := time.Parse(time.RFC3339, s)
+	return t, err == nil
+}
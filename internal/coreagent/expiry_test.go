@@ -0,0 +1,106 @@
+package coreagent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+func TestRefresher_NodeExpiry(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "k8s-prod", Type: "kubernetes"})
+
+	g := graph.NewMemoryStore()
+	g.AddNode(ctx, graph.Node{ID: "deploy/old", Type: "deployment", SourceID: "k8s-prod", Metadata: map[string]any{"replicas": 1}})
+	g.AddNode(ctx, graph.Node{ID: "deploy/back", Type: "deployment", SourceID: "k8s-prod"})
+	k8s := &fakeCollector{state: &graph.Subgraph{Nodes: []graph.Node{{ID: "deploy/api", Type: "deployment"}}}}
+	r := NewRefresher(g, st, time.Minute,
+		WithCollector("kubernetes", k8s),
+		WithNodeExpiry(2, time.Hour),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	start := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	refresh := func(at time.Duration) *store.RefreshRun {
+		t.Helper()
+		r.now = func() time.Time { return start.Add(at) }
+		run, err := r.Refresh(ctx, TriggerManual)
+		if err != nil {
+			t.Fatalf("Refresh() error: %v", err)
+		}
+		return run
+	}
+	metadata := func(id string) map[string]any {
+		t.Helper()
+		node, err := g.GetNode(ctx, id)
+		if err != nil {
+			t.Fatalf("GetNode(%s) error: %v", id, err)
+		}
+		return node.Metadata
+	}
+
+	// Missing once: kept, with the miss counted
+	run := refresh(0)
+	if run.NodesRemoved != 0 || run.NodesStale != 0 {
+		t.Errorf("first run = %+v, want nothing removed or stale", run)
+	}
+	if m := metadata("deploy/old"); m[MissedRefreshesKey] != 1 || m[StaleSinceKey] != nil || m["replicas"] != 1 {
+		t.Errorf("deploy/old metadata = %v, want one miss counted", m)
+	}
+
+	// A node reported again loses its marks
+	k8s.state.Nodes = append(k8s.state.Nodes, graph.Node{ID: "deploy/back", Type: "deployment"})
+	run = refresh(time.Minute)
+	if m := metadata("deploy/back"); m[MissedRefreshesKey] != nil {
+		t.Errorf("deploy/back metadata = %v, want no marks", m)
+	}
+
+	// Missing twice: stale
+	if run.NodesStale != 1 {
+		t.Errorf("second run = %+v, want 1 node stale", run)
+	}
+	if m := metadata("deploy/old"); m[MissedRefreshesKey] != 2 || m[StaleSinceKey] != "2024-03-02T09:01:00Z" {
+		t.Errorf("deploy/old metadata = %v, want stale since 09:01", m)
+	}
+	changes, err := st.ListGraphChanges(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("ListGraphChanges() error: %v", err)
+	}
+	var stale []string
+	for _, c := range changes {
+		if c.Action == store.ChangeStale {
+			stale = append(stale, c.Target)
+		}
+	}
+	if len(stale) != 1 || stale[0] != "deploy/old" {
+		t.Errorf("stale changes = %v, want deploy/old", stale)
+	}
+
+	// Stale for less than the TTL: still kept, and not stale again
+	if run := refresh(30 * time.Minute); run.NodesRemoved != 0 || run.NodesStale != 0 {
+		t.Errorf("third run = %+v, want nothing removed or newly stale", run)
+	}
+	if m := metadata("deploy/old"); m[StaleSinceKey] != "2024-03-02T09:01:00Z" {
+		t.Errorf("deploy/old metadata = %v, want still stale since 09:01", m)
+	}
+
+	// Stale for the TTL: deleted
+	if run := refresh(2 * time.Hour); run.NodesRemoved != 1 {
+		t.Errorf("last run = %+v, want 1 node removed", run)
+	}
+	if _, err := g.GetNode(ctx, "deploy/old"); !errors.Is(err, graph.ErrNotFound) {
+		t.Errorf("GetNode(deploy/old) error = %v, want ErrNotFound", err)
+	}
+}
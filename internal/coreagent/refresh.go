@@ -1,11 +1,661 @@
 package coreagent
 
-// Refresher handles background refresh of the graph
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaimegago/joe/internal/events"
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/notify"
+	"github.com/jaimegago/joe/internal/sources"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// Refresh triggers, recorded on each run
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+)
+
+// Source statuses set by a refresh
+const (
+	SourceConnected = "connected"
+	SourceError     = "error"
+)
+
+var (
+	// ErrRefreshRunning is returned when a refresh is requested while
+	// another one is in progress
+	ErrRefreshRunning = errors.New("a refresh is already running")
+
+	// ErrRefresherStopped is returned by Start when Run is not active
+	ErrRefresherStopped = errors.New("background refresh is not running")
+)
+
+// Collector reads the current state of a source as graph nodes and edges.
+// A collector handles one source type, e.g. "kubernetes".
+type Collector interface {
+	Collect(ctx context.Context, source store.Source) (*graph.Subgraph, error)
+}
+
+// RefreshStore is the subset of store.Store the refresher needs
+type RefreshStore interface {
+	ListSources(ctx context.Context) ([]store.Source, error)
+	UpdateSource(ctx context.Context, source store.Source) error
+	CreateRefreshRun(ctx context.Context, run store.RefreshRun) error
+	UpdateRefreshRun(ctx context.Context, run store.RefreshRun) error
+	AddGraphChanges(ctx context.Context, changes []store.GraphChange) error
+	AddClarification(ctx context.Context, c store.Clarification) (*store.Clarification, error)
+	ListClarifications(ctx context.Context, status string) ([]store.Clarification, error)
+}
+
+// Publisher broadcasts refresh progress and graph changes
+type Publisher interface {
+	Publish(eventType string, data any)
+}
+
+// discoverer is a Collector that also finds the sources its source points
+// at
+type discoverer interface {
+	Discover(ctx context.Context, source store.Source) ([]store.Source, error)
+}
+
+// connectorCollector collects a source with its connector
+type connectorCollector struct {
+	sources.SourceConnector
+}
+
+// Collect implements Collector
+func (c connectorCollector) Collect(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	if err := c.Connect(ctx, source); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return c.Refresh(ctx, source)
+}
+
+// Notifier tells the user about problems a refresh finds
+type Notifier interface {
+	Notify(ctx context.Context, n notify.Notification) error
+}
+
+// maxNotifiedErrors caps the failing sources a notification lists
+const maxNotifiedErrors = 3
+
+// Refresher handles background refresh of the graph: on every interval it
+// collects the state of each registered source and brings the source's
+// part of the graph in line with it
 type Refresher struct {
-	// TODO: Implement background refresh in Phase 5
+	graph        graph.GraphStore
+	store        RefreshStore
+	collectors   map[string]Collector
+	logger       *slog.Logger
+	events       Publisher // optional
+	notifier     Notifier  // optional
+	now          func() time.Time
+	staleAfter   int           // Refreshes a node may be missing from before it is stale
+	staleTTL     time.Duration // How long a stale node is kept
+	promoteAfter int           // Refreshes that must report an inferred edge to promote it
+
+	running sync.Mutex // Held for the duration of a refresh
+
+	mu         sync.Mutex
+	ctx        context.Context // Run's context while it is active, for Start
+	wg         sync.WaitGroup  // Refreshes begun by Start
+	interval   time.Duration
+	rescheds   chan struct{}   // Signals Run that interval changed
+	discovered map[string]bool // IDs of the sources published as discovered
+}
+
+// RefreshOption configures a Refresher
+type RefreshOption func(*Refresher)
+
+// WithCollector registers the collector for sources of sourceType
+func WithCollector(sourceType string, c Collector) RefreshOption {
+	return func(r *Refresher) { r.collectors[sourceType] = c }
+}
+
+// WithConnectors refreshes the sources of each type in reg with its
+// connector: Connect, then Refresh, then Discover to publish the sources
+// found as source.discovered events
+func WithConnectors(reg *sources.Registry) RefreshOption {
+	return func(r *Refresher) {
+		for _, sourceType := range reg.Types() {
+			c, _ := reg.Get(sourceType)
+			r.collectors[sourceType] = connectorCollector{c}
+		}
+	}
+}
+
+// WithRefreshLogger sets the logger, slog.Default() otherwise
+func WithRefreshLogger(logger *slog.Logger) RefreshOption {
+	return func(r *Refresher) { r.logger = logger }
+}
+
+// WithRefreshEvents publishes the start, progress, and end of each run,
+// and the graph changes made for each source, to p
+func WithRefreshEvents(p Publisher) RefreshOption {
+	return func(r *Refresher) { r.events = p }
+}
+
+// WithRefreshNotifier tells the user through n when sources start failing
+// to refresh; a source failing run after run is only reported once
+func WithRefreshNotifier(n Notifier) RefreshOption {
+	return func(r *Refresher) { r.notifier = n }
+}
+
+// NewRefresher creates a refresher updating g from the sources in st every
+// interval
+func NewRefresher(g graph.GraphStore, st RefreshStore, interval time.Duration, opts ...RefreshOption) *Refresher {
+	r := &Refresher{
+		graph:      g,
+		store:      st,
+		collectors: make(map[string]Collector),
+		logger:     slog.Default(),
+		now:        time.Now,
+		staleAfter: 1,
+		interval:   interval,
+		rescheds:   make(chan struct{}, 1),
+		discovered: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run refreshes once right away and then every interval until ctx is
+// cancelled, and lets Start begin refreshes in between. A refresh in
+// progress at cancellation stops after the source it is on and is recorded
+// as cancelled; Run returns once it has. An interval of 0 or less disables
+// the schedule but not Start.
+func (r *Refresher) Run(ctx context.Context) {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.ctx = nil
+		r.mu.Unlock()
+		r.wg.Wait()
+		r.logger.Info("background refresh stopped")
+	}()
+
+	var ticker *time.Ticker
+	var tick <-chan time.Time // nil while the schedule is disabled
+	reschedule := func() {
+		if ticker != nil {
+			ticker.Stop()
+			ticker, tick = nil, nil
+		}
+		interval := r.Interval()
+		if interval <= 0 {
+			r.logger.Info("scheduled refresh disabled")
+			return
+		}
+		r.logger.Info("background refresh started", "interval", interval)
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+	reschedule()
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	refresh := tick != nil
+	for {
+		if refresh {
+			if _, err := r.Refresh(ctx, TriggerScheduled); err != nil && !errors.Is(err, ErrRefreshRunning) {
+				r.logger.Error("refresh failed", "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			refresh = true
+		case <-r.rescheds:
+			reschedule()
+			refresh = false
+		}
+	}
+}
+
+// Interval returns the time between scheduled refreshes
+func (r *Refresher) Interval() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.interval
+}
+
+// SetInterval changes the time between scheduled refreshes; the next one
+// is an interval from now. 0 or less disables the schedule.
+func (r *Refresher) SetInterval(interval time.Duration) {
+	r.mu.Lock()
+	r.interval = interval
+	r.mu.Unlock()
+	select {
+	case r.rescheds <- struct{}{}:
+	default: // Run hasn't picked up the last change yet; it will see this one
+	}
+}
+
+// Refresh runs one pass over the registered sources and returns its
+// record. Failing sources are noted in the run's Errors and marked with
+// status "error"; the error return is for failures of the run itself.
+func (r *Refresher) Refresh(ctx context.Context, trigger string) (*store.RefreshRun, error) {
+	if !r.running.TryLock() {
+		return nil, ErrRefreshRunning
+	}
+	defer r.running.Unlock()
+
+	run, targets, err := r.begin(ctx, trigger)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.execute(ctx, run, targets); err != nil {
+		return run, err
+	}
+	return run, nil
+}
+
+// Start begins a refresh in the background and returns its record as first
+// saved, with Status "running"; its progress is saved to the store after
+// each source. The refresh runs under Run's context, so ctx only bounds
+// recording the start.
+func (r *Refresher) Start(ctx context.Context, trigger string) (*store.RefreshRun, error) {
+	r.mu.Lock()
+	base := r.ctx
+	if base != nil {
+		r.wg.Add(1)
+	}
+	r.mu.Unlock()
+	if base == nil {
+		return nil, ErrRefresherStopped
+	}
+
+	if !r.running.TryLock() {
+		r.wg.Done()
+		return nil, ErrRefreshRunning
+	}
+	run, targets, err := r.begin(ctx, trigger)
+	if err != nil {
+		r.running.Unlock()
+		r.wg.Done()
+		return nil, err
+	}
+	started := *run
+	started.Errors = maps.Clone(run.Errors)
+
+	go func() {
+		defer r.wg.Done()
+		defer r.running.Unlock()
+		if err := r.execute(base, run, targets); err != nil {
+			r.logger.Error("refresh failed", "run", run.ID, "error", err)
+		}
+	}()
+	return &started, nil
+}
+
+// begin records a new run over the sources that have a collector
+func (r *Refresher) begin(ctx context.Context, trigger string) (*store.RefreshRun, []store.Source, error) {
+	sources, err := r.store.ListSources(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	var targets []store.Source
+	for _, src := range sources {
+		if _, ok := r.collectors[src.Type]; ok {
+			targets = append(targets, src)
+		} else {
+			r.logger.Debug("no collector for source, skipping", "source", src.ID, "type", src.Type)
+		}
+	}
+
+	run := &store.RefreshRun{
+		ID:        newRunID(r.now()),
+		Trigger:   trigger,
+		Status:    store.RefreshRunning,
+		StartedAt: r.now(),
+		Sources:   len(targets),
+		Errors:    make(map[string]string),
+	}
+	if err := r.store.CreateRefreshRun(ctx, *run); err != nil {
+		return nil, nil, fmt.Errorf("failed to record refresh run: %w", err)
+	}
+	r.logger.Debug("refresh started", "run", run.ID, "trigger", trigger, "sources", run.Sources)
+	r.publishRun(events.RefreshStarted, run)
+	return run, targets, nil
+}
+
+// execute refreshes targets in turn, saving run after each, and records
+// the outcome
+func (r *Refresher) execute(ctx context.Context, run *store.RefreshRun, targets []store.Source) error {
+	var failing []string         // Sources that worked on their last refresh
+	var high []store.GraphChange // Changes to notify the user of
+	for _, src := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		before := *run
+		changes, err := r.refreshSource(ctx, src, run)
+		if !changes.Empty() || len(changes.Stale) > 0 {
+			high = append(high, r.recordChanges(ctx, run, src.ID, changes)...)
+		}
+		if err != nil {
+			run.Errors[src.ID] = err.Error()
+			r.logger.Warn("source refresh failed", "source", src.ID, "error", err)
+			if src.Status != SourceError {
+				failing = append(failing, src.ID)
+			}
+		} else {
+			r.discover(ctx, src)
+		}
+		run.Refreshed++
+		if err := r.store.UpdateRefreshRun(ctx, *run); err != nil {
+			r.logger.Warn("failed to record refresh progress", "run", run.ID, "error", err)
+		}
+
+		change := events.GraphChange{
+			SourceID:     src.ID,
+			NodesUpdated: run.NodesUpdated - before.NodesUpdated,
+			EdgesUpdated: run.EdgesUpdated - before.EdgesUpdated,
+			NodesRemoved: run.NodesRemoved - before.NodesRemoved,
+			EdgesRemoved: run.EdgesRemoved - before.EdgesRemoved,
+			NodesStale:   run.NodesStale - before.NodesStale,
+		}
+		if r.events != nil && change != (events.GraphChange{SourceID: src.ID}) {
+			r.events.Publish(events.GraphChanged, change)
+		}
+		r.publishRun(events.RefreshProgress, run)
+	}
+
+	// Record the outcome even when shutting down
+	finished := r.now()
+	run.FinishedAt = &finished
+	run.Status = store.RefreshCompleted
+	if run.Refreshed < run.Sources {
+		run.Status = store.RefreshCancelled
+	}
+	err := r.store.UpdateRefreshRun(context.WithoutCancel(ctx), *run)
+	r.publishRun(events.RefreshFinished, run)
+	if err != nil {
+		return fmt.Errorf("failed to record refresh run: %w", err)
+	}
+
+	r.logger.Info("refresh finished",
+		"run", run.ID,
+		"status", run.Status,
+		"sources", run.Refreshed,
+		"errors", len(run.Errors),
+		"nodes_updated", run.NodesUpdated,
+		"edges_updated", run.EdgesUpdated,
+		"nodes_removed", run.NodesRemoved,
+		"edges_removed", run.EdgesRemoved,
+		"nodes_stale", run.NodesStale,
+		"duration", finished.Sub(run.StartedAt).Round(time.Millisecond),
+	)
+	r.notifyFailing(context.WithoutCancel(ctx), run, failing)
+	r.notifyChanges(context.WithoutCancel(ctx), run, high)
+	return nil
+}
+
+// notifyFailing tells the user about sources that started failing, with
+// the first few errors
+func (r *Refresher) notifyFailing(ctx context.Context, run *store.RefreshRun, failing []string) {
+	if r.notifier == nil || len(failing) == 0 {
+		return
+	}
+	n := notify.Notification{
+		Title:    fmt.Sprintf("joe: %d source(s) failing to refresh", len(failing)),
+		Priority: notify.High,
+	}
+	if len(failing) == 1 {
+		n.Title = fmt.Sprintf("joe: %s is failing to refresh", failing[0])
+	}
+	var lines []string
+	for _, id := range failing[:min(len(failing), maxNotifiedErrors)] {
+		lines = append(lines, id+": "+run.Errors[id])
+	}
+	if extra := len(failing) - maxNotifiedErrors; extra > 0 {
+		lines = append(lines, fmt.Sprintf("and %d more", extra))
+	}
+	n.Message = strings.Join(lines, "\n")
+	if err := r.notifier.Notify(ctx, n); err != nil {
+		r.logger.Warn("failed to send notification", "run", run.ID, "error", err)
+	}
+}
+
+// discover publishes the unregistered sources src points at, each once
+// while joecored runs, for the user to decide whether to add them
+func (r *Refresher) discover(ctx context.Context, src store.Source) {
+	d, ok := r.collectors[src.Type].(discoverer)
+	if !ok {
+		return
+	}
+	found, err := d.Discover(ctx, src)
+	if err != nil {
+		r.logger.Warn("source discovery failed", "source", src.ID, "error", err)
+		return
+	}
+	if len(found) == 0 {
+		return
+	}
+	registered, err := r.store.ListSources(ctx)
+	if err != nil {
+		r.logger.Warn("source discovery failed", "source", src.ID, "error", fmt.Errorf("failed to list sources: %w", err))
+		return
+	}
+	known := make(map[string]bool, len(registered))
+	for _, s := range registered {
+		known[s.ID] = true
+	}
+
+	for _, f := range found {
+		if f.DiscoveredFrom == "" {
+			f.DiscoveredFrom = src.ID
+		}
+		r.mu.Lock()
+		announce := !known[f.ID] && !r.discovered[f.ID]
+		r.discovered[f.ID] = true
+		r.mu.Unlock()
+		if !announce {
+			continue
+		}
+		r.logger.Info("source discovered", "source", f.ID, "type", f.Type, "from", f.DiscoveredFrom)
+		if r.events != nil {
+			r.events.Publish(events.SourceDiscovered, f)
+		}
+	}
+}
+
+// publishRun publishes a snapshot of run, which keeps changing
+func (r *Refresher) publishRun(eventType string, run *store.RefreshRun) {
+	if r.events == nil {
+		return
+	}
+	snapshot := *run
+	snapshot.Errors = maps.Clone(run.Errors)
+	r.events.Publish(eventType, snapshot)
+}
+
+// sourceChanges is what refreshing a source changed in the graph
+type sourceChanges struct {
+	graph.ChangeSet
+	Stale []graph.NodeChange // Nodes no longer reported, marked stale by this refresh
+}
+
+// refreshSource collects one source, brings its part of the graph in line
+// with what it reports, and updates its status. Only what changed is
+// written, so a node's LastSeen is when it last changed. Edges the source
+// no longer reports are removed or demoted (see rescore), and nodes once
+// they expire (see WithNodeExpiry). The user is asked about the inferred
+// edges it adds.
+func (r *Refresher) refreshSource(ctx context.Context, src store.Source, run *store.RefreshRun) (sourceChanges, error) {
+	state, err := r.collectors[src.Type].Collect(ctx, src)
+	if err != nil {
+		src.Status = SourceError
+		if uerr := r.store.UpdateSource(context.WithoutCancel(ctx), src); uerr != nil {
+			r.logger.Warn("failed to update source status", "source", src.ID, "error", uerr)
+		}
+		return sourceChanges{}, err
+	}
+
+	after := &graph.Subgraph{}
+	for _, node := range state.Nodes {
+		if node.SourceID == "" {
+			node.SourceID = src.ID
+		}
+		after.Nodes = append(after.Nodes, node)
+	}
+	for _, edge := range state.Edges {
+		if edge.Source == "" {
+			edge.Source = src.ID
+		}
+		if edge.Confidence == 0 {
+			edge.Confidence = graph.Explicit // Read from the source's API
+		}
+		after.Edges = append(after.Edges, edge)
+	}
+	before, err := r.current(ctx, src.ID, after)
+	if err != nil {
+		return sourceChanges{}, err
+	}
+	answers, err := r.edgeAnswers(ctx)
+	if err != nil {
+		return sourceChanges{}, err
+	}
+	r.rescore(src.ID, before, after, answers)
+	changes := sourceChanges{ChangeSet: graph.Diff(before, after)}
+	kept, stale := r.expire(&changes.ChangeSet)
+	if err := r.apply(ctx, changes.ChangeSet); err != nil {
+		return sourceChanges{}, err
+	}
+	for _, node := range kept {
+		if err := r.graph.AddNode(ctx, node); err != nil {
+			return sourceChanges{}, err
+		}
+	}
+	r.askAbout(ctx, changes.ChangeSet)
+	changes.Stale = stale
+	run.NodesUpdated += len(changes.AddedNodes) + len(changes.ModifiedNodes)
+	run.EdgesUpdated += len(changes.AddedEdges) + len(changes.ModifiedEdges)
+	run.NodesRemoved += len(changes.RemovedNodes)
+	run.EdgesRemoved += len(changes.RemovedEdges)
+	run.NodesStale += len(changes.Stale)
+
+	now := r.now()
+	src.Status = SourceConnected
+	src.LastConnected = &now
+	if err := r.store.UpdateSource(ctx, src); err != nil {
+		return changes, fmt.Errorf("failed to update source: %w", err)
+	}
+	return changes, nil
+}
+
+// current returns the part of the graph a source's new state replaces:
+// its nodes and the edges it wrote, plus whatever the state overwrites
+// that another source wrote
+func (r *Refresher) current(ctx context.Context, sourceID string, state *graph.Subgraph) (*graph.Subgraph, error) {
+	nodes, err := r.graph.Query(ctx, "source:"+sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list graph nodes: %w", err)
+	}
+	before := &graph.Subgraph{Nodes: nodes}
+	owned := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		owned[n.ID] = true
+	}
+	for _, n := range state.Nodes {
+		if owned[n.ID] {
+			continue
+		}
+		if existing, err := r.graph.GetNode(ctx, n.ID); err == nil {
+			before.Nodes = append(before.Nodes, *existing)
+		}
+	}
+
+	// Edges leave the source's nodes, or the nodes its edges leave
+	reported := make(map[[3]string]bool, len(state.Edges))
+	from := slices.Collect(maps.Keys(owned))
+	for _, e := range state.Edges {
+		reported[[3]string{e.From, e.To, e.Relation}] = true
+		from = append(from, e.From)
+	}
+	slices.Sort(from)
+	for _, id := range slices.Compact(from) {
+		for _, e := range r.edgesFrom(ctx, id) {
+			if e.Source == sourceID || reported[[3]string{e.From, e.To, e.Relation}] {
+				before.Edges = append(before.Edges, e)
+			}
+		}
+	}
+	return before, nil
+}
+
+// apply writes changes to the graph
+func (r *Refresher) apply(ctx context.Context, changes graph.ChangeSet) error {
+	for _, node := range changes.AddedNodes {
+		if err := r.graph.AddNode(ctx, node); err != nil {
+			return err
+		}
+	}
+	for _, m := range changes.ModifiedNodes {
+		if err := r.graph.AddNode(ctx, m.After); err != nil {
+			return err
+		}
+	}
+	for _, edge := range changes.RemovedEdges {
+		if err := r.graph.DeleteEdge(ctx, edge.From, edge.To, edge.Relation); err != nil && !errors.Is(err, graph.ErrNotFound) {
+			return err
+		}
+	}
+	for _, edge := range changes.AddedEdges {
+		if err := r.graph.AddEdge(ctx, edge); err != nil {
+			return err
+		}
+	}
+	for _, m := range changes.ModifiedEdges {
+		if err := r.graph.AddEdge(ctx, m.After); err != nil {
+			return err
+		}
+	}
+	for _, node := range changes.RemovedNodes {
+		if err := r.graph.DeleteNode(ctx, node.ID); err != nil && !errors.Is(err, graph.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// edgesFrom returns the edges in the graph leaving node id
+func (r *Refresher) edgesFrom(ctx context.Context, id string) []graph.Edge {
+	related, err := r.graph.Related(ctx, id, 1)
+	if err != nil {
+		return nil // Not in the graph yet
+	}
+	var edges []graph.Edge
+	for _, e := range related.Edges {
+		if e.From == id {
+			edges = append(edges, e)
+		}
+	}
+	return edges
 }
 
-// NewRefresher creates a new background refresher
-func NewRefresher() *Refresher {
-	return &Refresher{}
+// newRunID returns a sortable, human-readable ID like 20240302-090000-a1b2c3
+func newRunID(now time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(b)
 }
@@ -0,0 +1,448 @@
+package coreagent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/events"
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/notify"
+	"github.com/jaimegago/joe/internal/sources"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+// fakeCollector reports a fixed state, or fails
+type fakeCollector struct {
+	state *graph.Subgraph
+	err   error
+}
+
+func (f *fakeCollector) Collect(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	return f.state, f.err
+}
+
+func TestRefresher_Refresh(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+
+	for _, src := range []store.Source{
+		{ID: "k8s-prod", Type: "kubernetes"},
+		{ID: "argocd", Type: "argocd"},
+		{ID: "grafana", Type: "grafana"}, // No collector
+	} {
+		if err := st.AddSource(ctx, src); err != nil {
+			t.Fatalf("AddSource() error: %v", err)
+		}
+	}
+
+	g := graph.NewMemoryStore()
+	// A deployment removed from the cluster since the last refresh
+	g.AddNode(ctx, graph.Node{ID: "deploy/old", Type: "deployment", SourceID: "k8s-prod"})
+
+	k8s := &fakeCollector{state: &graph.Subgraph{
+		Nodes: []graph.Node{
+			{ID: "deploy/api", Type: "deployment"},
+			{ID: "svc/api", Type: "service"},
+		},
+		Edges: []graph.Edge{{From: "svc/api", To: "deploy/api", Relation: "routes_to"}},
+	}}
+	argo := &fakeCollector{err: errors.New("connection refused")}
+	bus := events.NewBus()
+	published, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	r := NewRefresher(g, st, time.Minute,
+		WithCollector("kubernetes", k8s),
+		WithCollector("argocd", argo),
+		WithRefreshEvents(bus),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	run, err := r.Refresh(ctx, TriggerManual)
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if run.Status != store.RefreshCompleted || run.Sources != 2 || run.Refreshed != 2 {
+		t.Errorf("run = %+v, want 2 of 2 sources completed", run)
+	}
+	if run.NodesUpdated != 2 || run.EdgesUpdated != 1 || run.NodesRemoved != 1 {
+		t.Errorf("run = %+v, want 2 nodes and 1 edge updated, 1 node removed", run)
+	}
+	if run.Errors["argocd"] != "connection refused" || len(run.Errors) != 1 {
+		t.Errorf("run.Errors = %v, want the argocd failure", run.Errors)
+	}
+
+	if _, err := g.GetNode(ctx, "deploy/old"); !errors.Is(err, graph.ErrNotFound) {
+		t.Errorf("stale node still in graph, GetNode() error = %v", err)
+	}
+	if node, err := g.GetNode(ctx, "deploy/api"); err != nil || node.SourceID != "k8s-prod" {
+		t.Errorf("GetNode(deploy/api) = %+v, %v; want it attributed to k8s-prod", node, err)
+	}
+
+	saved, err := st.GetRefreshRun(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("GetRefreshRun() error: %v", err)
+	}
+	if saved.Status != store.RefreshCompleted || saved.FinishedAt == nil {
+		t.Errorf("saved run = %+v, want completed", saved)
+	}
+
+	for id, want := range map[string]string{"k8s-prod": SourceConnected, "argocd": SourceError, "grafana": ""} {
+		src, _ := st.GetSource(ctx, id)
+		if src.Status != want {
+			t.Errorf("source %s status = %q, want %q", id, src.Status, want)
+		}
+	}
+	if src, _ := st.GetSource(ctx, "k8s-prod"); src.LastConnected == nil {
+		t.Error("k8s-prod LastConnected not set")
+	}
+
+	// Only the source that changed the graph reports a change
+	var types []string
+	for len(published) > 0 {
+		e := <-published
+		types = append(types, e.Type)
+		if change, ok := e.Data.(events.GraphChange); ok && change != (events.GraphChange{
+			SourceID: "k8s-prod", NodesUpdated: 2, EdgesUpdated: 1, NodesRemoved: 1,
+		}) {
+			t.Errorf("graph change = %+v, want k8s-prod's", change)
+		}
+	}
+	want := []string{events.RefreshStarted, events.RefreshProgress, events.GraphChanged, events.RefreshProgress, events.RefreshFinished}
+	if !slices.Equal(types, want) {
+		t.Errorf("published %v, want %v", types, want)
+	}
+}
+
+func TestRefresher_Unchanged(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "k8s-prod", Type: "kubernetes"})
+
+	k8s := &fakeCollector{state: &graph.Subgraph{
+		Nodes: []graph.Node{
+			{ID: "deploy/api", Type: "deployment", Metadata: map[string]any{"replicas": 2}},
+			{ID: "svc/api", Type: "service"},
+		},
+		Edges: []graph.Edge{{From: "svc/api", To: "deploy/api", Relation: "routes_to"}},
+	}}
+	bus := events.NewBus()
+	published, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	r := NewRefresher(graph.NewMemoryStore(), st, time.Minute,
+		WithCollector("kubernetes", k8s),
+		WithRefreshEvents(bus),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	tests := []struct {
+		name             string
+		change           func()
+		wantNodes, edges int
+	}{
+		{"first refresh", func() {}, 2, 1},
+		{"unchanged", func() {}, 0, 0},
+		{"scaled", func() { k8s.state.Nodes[0].Metadata = map[string]any{"replicas": 3} }, 1, 0},
+		{"edge context", func() { k8s.state.Edges[0].Context = "selector app=api" }, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			for len(published) > 0 {
+				<-published
+			}
+			run, err := r.Refresh(ctx, TriggerManual)
+			if err != nil {
+				t.Fatalf("Refresh() error: %v", err)
+			}
+			if run.NodesUpdated != tt.wantNodes || run.EdgesUpdated != tt.edges || run.NodesRemoved != 0 {
+				t.Errorf("run = %+v, want %d nodes and %d edges updated", run, tt.wantNodes, tt.edges)
+			}
+			changed := false
+			for len(published) > 0 {
+				if (<-published).Type == events.GraphChanged {
+					changed = true
+				}
+			}
+			if want := tt.wantNodes+tt.edges > 0; changed != want {
+				t.Errorf("graph.changed published = %v, want %v", changed, want)
+			}
+		})
+	}
+}
+
+func TestRefresher_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "k8s-prod", Type: "kubernetes"})
+	st.AddSource(ctx, store.Source{ID: "k8s-staging", Type: "kubernetes"})
+
+	// Shutdown arrives while the first source is being collected
+	collector := collectorFunc(func(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+		cancel()
+		return &graph.Subgraph{}, nil
+	})
+	r := NewRefresher(graph.NewMemoryStore(), st, time.Minute,
+		WithCollector("kubernetes", collector),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	// Run returns once ctx is done, after recording the interrupted pass
+	r.Run(ctx)
+
+	runs, err := st.ListRefreshRuns(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("ListRefreshRuns() error: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != store.RefreshCancelled || runs[0].Refreshed != 1 || runs[0].FinishedAt == nil {
+		t.Errorf("runs = %+v, want one run cancelled after 1 source", runs)
+	}
+}
+
+// recordingNotifier keeps the notifications sent
+type recordingNotifier struct {
+	sent []notify.Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n notify.Notification) error {
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func TestRefresher_Notify(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "k8s-prod", Type: "kubernetes"})
+	st.AddSource(ctx, store.Source{ID: "argocd", Type: "argocd"})
+
+	notifier := &recordingNotifier{}
+	r := NewRefresher(graph.NewMemoryStore(), st, time.Minute,
+		WithCollector("kubernetes", &fakeCollector{state: &graph.Subgraph{}}),
+		WithCollector("argocd", &fakeCollector{err: errors.New("connection refused")}),
+		WithRefreshNotifier(notifier),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	// The second run finds argocd still failing, which was already reported
+	for range 2 {
+		if _, err := r.Refresh(ctx, TriggerManual); err != nil {
+			t.Fatalf("Refresh() error: %v", err)
+		}
+	}
+	want := []notify.Notification{{
+		Title:    "joe: argocd is failing to refresh",
+		Message:  "argocd: connection refused",
+		Priority: notify.High,
+	}}
+	if !slices.Equal(notifier.sent, want) {
+		t.Errorf("sent %+v, want %+v", notifier.sent, want)
+	}
+}
+
+// fakeConnector reports a fixed state and discovered sources, or fails to
+// connect
+type fakeConnector struct {
+	state      *graph.Subgraph
+	discovered []store.Source
+	connectErr error
+}
+
+func (f *fakeConnector) Connect(ctx context.Context, source store.Source) error {
+	return f.connectErr
+}
+
+func (f *fakeConnector) Discover(ctx context.Context, source store.Source) ([]store.Source, error) {
+	return f.discovered, nil
+}
+
+func (f *fakeConnector) Refresh(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	return f.state, nil
+}
+
+func (f *fakeConnector) HealthCheck(ctx context.Context, source store.Source) error {
+	return f.connectErr
+}
+
+func TestRefresher_Connectors(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "k8s-prod", Type: "kubernetes"})
+	st.AddSource(ctx, store.Source{ID: "gitlab", Type: "git"})
+
+	// The cluster points at its Prometheus, which isn't registered, and at
+	// gitlab, which is
+	reg := sources.NewRegistry()
+	reg.Register("kubernetes", &fakeConnector{
+		state: &graph.Subgraph{Nodes: []graph.Node{{ID: "deploy/api", Type: "deployment"}}},
+		discovered: []store.Source{
+			{ID: "prometheus-prod", Type: "prometheus", URL: "http://prometheus.monitoring:9090"},
+			{ID: "gitlab", Type: "git"},
+		},
+	})
+	reg.Register("git", &fakeConnector{connectErr: errors.New("authentication required")})
+
+	bus := events.NewBus()
+	published, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	g := graph.NewMemoryStore()
+	r := NewRefresher(g, st, time.Minute,
+		WithConnectors(reg),
+		WithRefreshEvents(bus),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	// Discovered sources are published once, not on every run
+	var run *store.RefreshRun
+	for range 2 {
+		if run, err = r.Refresh(ctx, TriggerManual); err != nil {
+			t.Fatalf("Refresh() error: %v", err)
+		}
+	}
+	if run.Refreshed != 2 || run.Errors["gitlab"] != "failed to connect: authentication required" {
+		t.Errorf("run = %+v, want the cluster refreshed and gitlab failing to connect", run)
+	}
+	if _, err := g.GetNode(ctx, "deploy/api"); err != nil {
+		t.Errorf("GetNode(deploy/api) error: %v", err)
+	}
+
+	var discovered []store.Source
+	for len(published) > 0 {
+		if e := <-published; e.Type == events.SourceDiscovered {
+			discovered = append(discovered, e.Data.(store.Source))
+		}
+	}
+	if len(discovered) != 1 || discovered[0].ID != "prometheus-prod" || discovered[0].DiscoveredFrom != "k8s-prod" {
+		t.Errorf("discovered %+v, want prometheus-prod from k8s-prod once", discovered)
+	}
+}
+
+type collectorFunc func(ctx context.Context, source store.Source) (*graph.Subgraph, error)
+
+func (f collectorFunc) Collect(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	return f(ctx, source)
+}
+
+func TestRefresher_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "k8s-prod", Type: "kubernetes"})
+
+	release := make(chan struct{})
+	collector := collectorFunc(func(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+		<-release
+		return &graph.Subgraph{Nodes: []graph.Node{{ID: "deploy/api", Type: "deployment"}}}, nil
+	})
+	// No schedule, so only Start refreshes
+	r := NewRefresher(graph.NewMemoryStore(), st, 0,
+		WithCollector("kubernetes", collector),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	if _, err := r.Start(ctx, TriggerManual); !errors.Is(err, ErrRefresherStopped) {
+		t.Fatalf("Start() before Run error = %v, want ErrRefresherStopped", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	var run *store.RefreshRun
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if run, err = r.Start(ctx, TriggerManual); !errors.Is(err, ErrRefresherStopped) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	if run.Status != store.RefreshRunning || run.Trigger != TriggerManual || run.Sources != 1 {
+		t.Errorf("Start() = %+v, want a running manual refresh of 1 source", run)
+	}
+	if _, err := r.Start(ctx, TriggerManual); !errors.Is(err, ErrRefreshRunning) {
+		t.Errorf("second Start() error = %v, want ErrRefreshRunning", err)
+	}
+
+	close(release)
+	cancel()
+	<-done // Run waits for the refresh it started
+
+	saved, err := st.GetRefreshRun(context.Background(), run.ID)
+	if err != nil {
+		t.Fatalf("GetRefreshRun() error: %v", err)
+	}
+	if saved.Status == store.RefreshRunning || saved.FinishedAt == nil {
+		t.Errorf("saved run = %+v, want it finished", saved)
+	}
+}
+
+func TestRefresher_SetInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+	st.AddSource(ctx, store.Source{ID: "k8s-prod", Type: "kubernetes"})
+
+	collected := make(chan struct{}, 10)
+	collector := collectorFunc(func(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+		select {
+		case collected <- struct{}{}:
+		default:
+		}
+		return &graph.Subgraph{}, nil
+	})
+	// Starts with no schedule; a new interval turns it on
+	r := NewRefresher(graph.NewMemoryStore(), st, 0,
+		WithCollector("kubernetes", collector),
+		WithRefreshLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	r.SetInterval(10 * time.Millisecond)
+	if r.Interval() != 10*time.Millisecond {
+		t.Errorf("Interval() = %v, want 10ms", r.Interval())
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-collected:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%d scheduled refreshes after SetInterval, want 2", i)
+		}
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,211 @@
+// Package cost turns LLM token usage into estimated dollar costs, keeps
+// daily totals per model in the store, and enforces an optional daily
+// budget.
+package cost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// ErrBudgetExceeded is returned instead of calling the LLM once the day's
+// spending has reached the daily budget
+var ErrBudgetExceeded = errors.New("daily LLM budget exceeded")
+
+// dayLayout is the format of store.DailyCost.Day
+const dayLayout = "2006-01-02"
+
+// Store is the subset of store.Store that persists daily totals
+type Store interface {
+	AddDailyCost(ctx context.Context, cost store.DailyCost) error
+	ListDailyCosts(ctx context.Context, since string) ([]store.DailyCost, error)
+}
+
+// Tracker records the usage of every LLM call made through the adapters it
+// wraps and stops calls once the daily budget is spent. Daily totals live
+// in the store, so a budget covers joe and joecored together.
+type Tracker struct {
+	store  Store
+	budget float64 // USD per day; 0 means no limit
+	now    func() time.Time
+}
+
+// Option configures a Tracker
+type Option func(*Tracker)
+
+// WithClock sets the time source used to date usage (for tests)
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracker) { t.now = now }
+}
+
+// NewTracker records into st, or into memory if st is nil, and refuses
+// LLM calls once a day's total reaches dailyBudget USD (0 for no limit)
+func NewTracker(st Store, dailyBudget float64, opts ...Option) *Tracker {
+	if st == nil {
+		st = newMemoryStore()
+	}
+	t := &Tracker{store: st, budget: dailyBudget, now: time.Now}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// DailyBudget returns the daily budget in USD, 0 if there is none
+func (t *Tracker) DailyBudget() float64 {
+	return t.budget
+}
+
+// Record adds one call's usage of model to today's totals
+func (t *Tracker) Record(ctx context.Context, model string, usage llm.TokenUsage) error {
+	return t.store.AddDailyCost(ctx, store.DailyCost{
+		Day:          t.today(),
+		Model:        model,
+		Calls:        1,
+		InputTokens:  usage.InputTokens + usage.CacheReadTokens + usage.CacheWriteTokens,
+		OutputTokens: usage.OutputTokens,
+		CostUSD:      usage.CostUSD,
+	})
+}
+
+// Days returns per-model totals for the last n days including today,
+// oldest first
+func (t *Tracker) Days(ctx context.Context, n int) ([]store.DailyCost, error) {
+	since := t.now().AddDate(0, 0, 1-n).Format(dayLayout)
+	return t.store.ListDailyCosts(ctx, since)
+}
+
+// Today returns the estimated USD spent today across all models
+func (t *Tracker) Today(ctx context.Context) (float64, error) {
+	days, err := t.Days(ctx, 1)
+	if err != nil {
+		return 0, err
+	}
+	total := 0.0
+	for _, d := range days {
+		total += d.CostUSD
+	}
+	return total, nil
+}
+
+// CheckBudget returns ErrBudgetExceeded once today's spending has reached
+// the daily budget
+func (t *Tracker) CheckBudget(ctx context.Context) error {
+	if t.budget <= 0 {
+		return nil
+	}
+	spent, err := t.Today(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check LLM budget: %w", err)
+	}
+	if spent >= t.budget {
+		return fmt.Errorf("%w: $%.2f spent of $%.2f today (llm.cost.daily_budget_usd)", ErrBudgetExceeded, spent, t.budget)
+	}
+	return nil
+}
+
+func (t *Tracker) today() string {
+	return t.now().Format(dayLayout)
+}
+
+// Wrap returns adapter with its calls priced, recorded under model, and
+// subject to the daily budget. pricing may be nil, in which case calls are
+// counted but cost nothing.
+func (t *Tracker) Wrap(adapter llm.LLMAdapter, model string, pricing *config.ModelPricing) llm.LLMAdapter {
+	return &trackedAdapter{adapter: adapter, tracker: t, model: model, pricing: pricing}
+}
+
+// trackedAdapter sets the cost of each response and records it
+type trackedAdapter struct {
+	adapter llm.LLMAdapter
+	tracker *Tracker
+	model   string
+	pricing *config.ModelPricing
+}
+
+// Chat implements llm.LLMAdapter
+func (a *trackedAdapter) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	if err := a.tracker.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := a.adapter.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.pricing != nil {
+		// Cache reads and writes are billed at different rates by each
+		// provider; pricing them as input keeps the estimate on the high side
+		input := resp.Usage.InputTokens + resp.Usage.CacheReadTokens + resp.Usage.CacheWriteTokens
+		resp.Usage.CostUSD = a.pricing.Cost(input, resp.Usage.OutputTokens)
+	}
+	if err := a.tracker.Record(ctx, a.model, resp.Usage); err != nil {
+		slog.Warn("failed to record LLM cost", "model", a.model, "error", err)
+	}
+	return resp, nil
+}
+
+// ChatStream implements llm.LLMAdapter. Streams report no usage, so only
+// the budget applies.
+func (a *trackedAdapter) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	if err := a.tracker.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	return a.adapter.ChatStream(ctx, req)
+}
+
+// Embed implements llm.LLMAdapter
+func (a *trackedAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	return a.adapter.Embed(ctx, text)
+}
+
+// memoryStore keeps daily totals for a process without a store
+type memoryStore struct {
+	mu    sync.Mutex
+	costs map[[2]string]store.DailyCost // keyed by day and model
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{costs: make(map[[2]string]store.DailyCost)}
+}
+
+func (m *memoryStore) AddDailyCost(ctx context.Context, cost store.DailyCost) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{cost.Day, cost.Model}
+	total := m.costs[key]
+	total.Day, total.Model = cost.Day, cost.Model
+	total.Calls += cost.Calls
+	total.InputTokens += cost.InputTokens
+	total.OutputTokens += cost.OutputTokens
+	total.CostUSD += cost.CostUSD
+	m.costs[key] = total
+	return nil
+}
+
+func (m *memoryStore) ListDailyCosts(ctx context.Context, since string) ([]store.DailyCost, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var costs []store.DailyCost
+	for _, c := range m.costs {
+		if c.Day >= since {
+			costs = append(costs, c)
+		}
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].Day != costs[j].Day {
+			return costs[i].Day < costs[j].Day
+		}
+		return costs[i].Model < costs[j].Model
+	})
+	return costs, nil
+}
@@ -0,0 +1,95 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// fixedLLM answers every request with the same usage
+type fixedLLM struct {
+	usage llm.TokenUsage
+	calls int
+}
+
+func (f *fixedLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	f.calls++
+	return &llm.ChatResponse{Content: "ok", Usage: f.usage}, nil
+}
+
+func (f *fixedLLM) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fixedLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestTracker_Wrap(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.Local)
+	tracker := NewTracker(nil, 0, WithClock(func() time.Time { return now }))
+
+	base := &fixedLLM{usage: llm.TokenUsage{InputTokens: 1_000_000, OutputTokens: 100_000}}
+	priced := tracker.Wrap(base, "claude-sonnet-4", &config.ModelPricing{InputPerMillion: 3, OutputPerMillion: 15})
+	unpriced := tracker.Wrap(base, "llama3.1", nil)
+
+	resp, err := priced.Chat(ctx, llm.ChatRequest{})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Usage.CostUSD != 4.5 {
+		t.Errorf("CostUSD = %v, want 4.5", resp.Usage.CostUSD)
+	}
+	if resp, _ := unpriced.Chat(ctx, llm.ChatRequest{}); resp.Usage.CostUSD != 0 {
+		t.Errorf("unpriced CostUSD = %v, want 0", resp.Usage.CostUSD)
+	}
+
+	// Yesterday's spending doesn't count towards today
+	now = now.AddDate(0, 0, 1)
+	priced.Chat(ctx, llm.ChatRequest{})
+	priced.Chat(ctx, llm.ChatRequest{})
+
+	days, err := tracker.Days(ctx, 7)
+	if err != nil {
+		t.Fatalf("Days() error = %v", err)
+	}
+	if len(days) != 3 {
+		t.Fatalf("Days() = %+v, want 3 day/model totals", days)
+	}
+	if d := days[2]; d.Day != "2024-03-03" || d.Calls != 2 || d.CostUSD != 9 {
+		t.Errorf("today = %+v, want 2 calls costing $9", d)
+	}
+	if spent, _ := tracker.Today(ctx); spent != 9 {
+		t.Errorf("Today() = %v, want 9", spent)
+	}
+}
+
+func TestTracker_Budget(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewTracker(nil, 5)
+	base := &fixedLLM{usage: llm.TokenUsage{InputTokens: 1_000_000}}
+	adapter := tracker.Wrap(base, "claude-sonnet-4", &config.ModelPricing{InputPerMillion: 3})
+
+	// $3 then $6: the second call is allowed because the budget was not yet
+	// reached when it started, the third is refused
+	for i := 0; i < 2; i++ {
+		if _, err := adapter.Chat(ctx, llm.ChatRequest{}); err != nil {
+			t.Fatalf("call %d error = %v", i+1, err)
+		}
+	}
+	_, err := adapter.Chat(ctx, llm.ChatRequest{})
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Chat() over budget error = %v, want ErrBudgetExceeded", err)
+	}
+	if base.calls != 2 {
+		t.Errorf("LLM called %d times, want 2", base.calls)
+	}
+	if llm.IsRetryable(err) {
+		t.Error("budget errors must not be retried")
+	}
+}
@@ -0,0 +1,109 @@
+// Package events broadcasts what joecored is doing, e.g. refresh progress
+// and graph changes, to clients following GET /api/v1/events
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	RefreshStarted   = "refresh.started"   // Data: store.RefreshRun
+	RefreshProgress  = "refresh.progress"  // Data: store.RefreshRun, after each source
+	RefreshFinished  = "refresh.finished"  // Data: store.RefreshRun
+	GraphChanged     = "graph.changed"     // Data: GraphChange
+	SourceDiscovered = "source.discovered" // Data: store.Source, not yet registered
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind by
+// before it is dropped
+const subscriberBuffer = 64
+
+// Event is something that happened in joecored
+type Event struct {
+	ID   uint64 // Increases by one with each event published
+	Type string
+	Time time.Time
+	Data any
+}
+
+// GraphChange reports the graph writes made for one source
+type GraphChange struct {
+	SourceID     string
+	NodesUpdated int
+	EdgesUpdated int
+	NodesRemoved int
+	EdgesRemoved int
+	NodesStale   int
+}
+
+// Bus delivers published events to every current subscriber. Publishing
+// never blocks: a subscriber that falls too far behind has its channel
+// closed, and is expected to subscribe again and catch up from the API.
+type Bus struct {
+	mu     sync.Mutex
+	subs   map[chan Event]struct{}
+	lastID uint64
+	closed bool
+	now    func() time.Time
+}
+
+// NewBus creates an event bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{subs: make(map[chan Event]struct{}), now: time.Now}
+}
+
+// Publish sends an event to all subscribers
+func (b *Bus) Publish(eventType string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.lastID++
+	e := Event{ID: b.lastID, Type: eventType, Time: b.now(), Data: data}
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events published from now on, and
+// the func that ends the subscription. The channel is closed when the
+// subscription ends, the subscriber falls behind, or the bus is closed.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends all subscriptions, so streams to clients finish; later
+// events are discarded
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,50 @@
+package events
+
+import "testing"
+
+func TestBus(t *testing.T) {
+	b := NewBus()
+	first, unsubscribe := b.Subscribe()
+	second, _ := b.Subscribe()
+
+	b.Publish(GraphChanged, GraphChange{SourceID: "prom", NodesUpdated: 3})
+	for name, ch := range map[string]<-chan Event{"first": first, "second": second} {
+		e := <-ch
+		if e.ID != 1 || e.Type != GraphChanged || e.Data.(GraphChange).NodesUpdated != 3 {
+			t.Errorf("%s subscriber got %+v", name, e)
+		}
+	}
+
+	unsubscribe()
+	if _, ok := <-first; ok {
+		t.Error("channel still open after unsubscribe")
+	}
+	unsubscribe() // Ending twice is harmless
+
+	b.Close()
+	if _, ok := <-second; ok {
+		t.Error("channel still open after Close")
+	}
+	b.Publish(GraphChanged, nil) // Discarded
+	ch, _ := b.Subscribe()
+	if _, ok := <-ch; ok {
+		t.Error("Subscribe() after Close returned an open channel")
+	}
+}
+
+func TestBus_DropsSlowSubscriber(t *testing.T) {
+	b := NewBus()
+	ch, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	for range subscriberBuffer + 1 {
+		b.Publish(RefreshProgress, nil)
+	}
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("received %d events before the channel closed, want %d", received, subscriberBuffer)
+	}
+}
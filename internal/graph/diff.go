@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ChangeSet is what changed between two states of part of the graph
+type ChangeSet struct {
+	AddedNodes    []Node
+	RemovedNodes  []Node
+	ModifiedNodes []NodeChange
+	AddedEdges    []Edge
+	RemovedEdges  []Edge
+	ModifiedEdges []EdgeChange
+}
+
+// NodeChange is a node whose details changed
+type NodeChange struct {
+	Before, After Node
+}
+
+// EdgeChange is an edge whose details changed
+type EdgeChange struct {
+	Before, After Edge
+}
+
+// Empty reports whether nothing changed
+func (c ChangeSet) Empty() bool {
+	return len(c.AddedNodes) == 0 && len(c.RemovedNodes) == 0 && len(c.ModifiedNodes) == 0 &&
+		len(c.AddedEdges) == 0 && len(c.RemovedEdges) == 0 && len(c.ModifiedEdges) == 0
+}
+
+// Diff compares two states of part of the graph, either of which may be
+// nil. Nodes are matched by ID and edges by from, to, and relation; one is
+// modified when anything but its timestamps differs. Changes are listed in
+// the order of after, removals in the order of before.
+func Diff(before, after *Subgraph) ChangeSet {
+	if before == nil {
+		before = &Subgraph{}
+	}
+	if after == nil {
+		after = &Subgraph{}
+	}
+	var c ChangeSet
+
+	oldNodes := make(map[string]Node, len(before.Nodes))
+	for _, n := range before.Nodes {
+		oldNodes[n.ID] = n
+	}
+	newNodes := make(map[string]bool, len(after.Nodes))
+	for _, n := range after.Nodes {
+		newNodes[n.ID] = true
+		old, ok := oldNodes[n.ID]
+		switch {
+		case !ok:
+			c.AddedNodes = append(c.AddedNodes, n)
+		case !SameNode(old, n):
+			c.ModifiedNodes = append(c.ModifiedNodes, NodeChange{Before: old, After: n})
+		}
+	}
+	for _, n := range before.Nodes {
+		if !newNodes[n.ID] {
+			c.RemovedNodes = append(c.RemovedNodes, n)
+		}
+	}
+
+	oldEdges := make(map[edgeKey]Edge, len(before.Edges))
+	for _, e := range before.Edges {
+		oldEdges[edgeKey{e.From, e.To, e.Relation}] = e
+	}
+	newEdges := make(map[edgeKey]bool, len(after.Edges))
+	for _, e := range after.Edges {
+		key := edgeKey{e.From, e.To, e.Relation}
+		newEdges[key] = true
+		old, ok := oldEdges[key]
+		switch {
+		case !ok:
+			c.AddedEdges = append(c.AddedEdges, e)
+		case !SameEdge(old, e):
+			c.ModifiedEdges = append(c.ModifiedEdges, EdgeChange{Before: old, After: e})
+		}
+	}
+	for _, e := range before.Edges {
+		if !newEdges[edgeKey{e.From, e.To, e.Relation}] {
+			c.RemovedEdges = append(c.RemovedEdges, e)
+		}
+	}
+	return c
+}
+
+// SameNode reports whether writing b over a would change nothing but its
+// LastSeen. Metadata is compared as JSON, as stored graphs decode it.
+func SameNode(a, b Node) bool {
+	return a.ID == b.ID && a.Type == b.Type && a.SourceID == b.SourceID && sameJSON(a.Metadata, b.Metadata)
+}
+
+// SameEdge reports whether a and b are the same edge with the same details
+func SameEdge(a, b Edge) bool {
+	return a.From == b.From && a.To == b.To && a.Relation == b.Relation &&
+		a.Confidence == b.Confidence && a.Source == b.Source && a.Context == b.Context &&
+		a.Observations == b.Observations
+}
+
+func sameJSON(a, b map[string]any) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
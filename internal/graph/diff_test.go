@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	before := &Subgraph{
+		Nodes: []Node{
+			{ID: "svc/api", Type: "service", Metadata: map[string]any{"type": "ClusterIP", "port": 8080}},
+			{ID: "deploy/api", Type: "deployment", LastSeen: time.Now()},
+			{ID: "deploy/old", Type: "deployment"},
+		},
+		Edges: []Edge{
+			{From: "svc/api", To: "deploy/api", Relation: "routes_to", Context: "port 8080"},
+			{From: "svc/api", To: "deploy/old", Relation: "routes_to"},
+		},
+	}
+	after := &Subgraph{
+		Nodes: []Node{
+			// Decoded from JSON, numbers are float64
+			{ID: "svc/api", Type: "service", Metadata: map[string]any{"type": "LoadBalancer", "port": 8080.0}},
+			{ID: "deploy/api", Type: "deployment"}, // Only LastSeen differs
+			{ID: "deploy/new", Type: "deployment"},
+		},
+		Edges: []Edge{
+			{From: "svc/api", To: "deploy/api", Relation: "routes_to", Context: "port 80"},
+			{From: "svc/api", To: "deploy/new", Relation: "routes_to"},
+		},
+	}
+
+	c := Diff(before, after)
+	if len(c.AddedNodes) != 1 || c.AddedNodes[0].ID != "deploy/new" {
+		t.Errorf("AddedNodes = %+v, want deploy/new", c.AddedNodes)
+	}
+	if len(c.RemovedNodes) != 1 || c.RemovedNodes[0].ID != "deploy/old" {
+		t.Errorf("RemovedNodes = %+v, want deploy/old", c.RemovedNodes)
+	}
+	if len(c.ModifiedNodes) != 1 || c.ModifiedNodes[0].Before.Metadata["type"] != "ClusterIP" ||
+		c.ModifiedNodes[0].After.Metadata["type"] != "LoadBalancer" {
+		t.Errorf("ModifiedNodes = %+v, want svc/api's type", c.ModifiedNodes)
+	}
+	if len(c.AddedEdges) != 1 || c.AddedEdges[0].To != "deploy/new" {
+		t.Errorf("AddedEdges = %+v, want the edge to deploy/new", c.AddedEdges)
+	}
+	if len(c.RemovedEdges) != 1 || c.RemovedEdges[0].To != "deploy/old" {
+		t.Errorf("RemovedEdges = %+v, want the edge to deploy/old", c.RemovedEdges)
+	}
+	if len(c.ModifiedEdges) != 1 || c.ModifiedEdges[0].After.Context != "port 80" {
+		t.Errorf("ModifiedEdges = %+v, want the edge to deploy/api", c.ModifiedEdges)
+	}
+
+	if !Diff(after, after).Empty() {
+		t.Error("Diff() of a state with itself is not empty")
+	}
+	if c := Diff(nil, after); len(c.AddedNodes) != 3 || len(c.AddedEdges) != 2 {
+		t.Errorf("Diff(nil, after) = %+v, want everything added", c)
+	}
+}
@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes sub as a Graphviz digraph: nodes labelled with their ID
+// and type, edges with their relation, and inferred or corroborated edges
+// dashed
+func WriteDOT(w io.Writer, sub *Subgraph) error {
+	var b strings.Builder
+	b.WriteString("digraph joe {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, n := range sub.Nodes {
+		fmt.Fprintf(&b, "\t%s [label=%s];\n", dotID(n.ID), dotID(n.ID+"\n"+n.Type))
+	}
+	for _, e := range sub.Edges {
+		style := ""
+		if e.Confidence < Explicit {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s%s];\n", dotID(e.From), dotID(e.To), dotID(e.Relation), style)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotID quotes s as a DOT ID
+func dotID(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// GraphML document, as read by Gephi, yEd, and networkx
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLItem `xml:"node"`
+		Edges       []graphMLItem `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLItem struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphMLKeys are the attributes of nodes and edges; metadata is written
+// as a JSON object
+var graphMLKeys = []graphMLKey{
+	{ID: "label", For: "node", Name: "label", Type: "string"},
+	{ID: "type", For: "node", Name: "type", Type: "string"},
+	{ID: "source_id", For: "node", Name: "source_id", Type: "string"},
+	{ID: "metadata", For: "node", Name: "metadata", Type: "string"},
+	{ID: "relation", For: "edge", Name: "relation", Type: "string"},
+	{ID: "confidence", For: "edge", Name: "confidence", Type: "int"},
+	{ID: "source", For: "edge", Name: "source", Type: "string"},
+	{ID: "context", For: "edge", Name: "context", Type: "string"},
+}
+
+// WriteGraphML writes sub as a directed GraphML graph, with each node's
+// type, source, and metadata and each edge's relation, confidence,
+// source, and context as attributes
+func WriteGraphML(w io.Writer, sub *Subgraph) error {
+	doc := graphML{XMLNS: "http://graphml.graphdrawing.org/xmlns", Keys: graphMLKeys}
+	doc.Graph.ID = "joe"
+	doc.Graph.EdgeDefault = "directed"
+
+	for _, n := range sub.Nodes {
+		item := graphMLItem{ID: n.ID, Data: []graphMLData{{Key: "label", Value: n.ID}, {Key: "type", Value: n.Type}}}
+		if n.SourceID != "" {
+			item.Data = append(item.Data, graphMLData{Key: "source_id", Value: n.SourceID})
+		}
+		if len(n.Metadata) > 0 {
+			metadata, err := json.Marshal(n.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to encode metadata of %s: %w", n.ID, err)
+			}
+			item.Data = append(item.Data, graphMLData{Key: "metadata", Value: string(metadata)})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, item)
+	}
+	for i, e := range sub.Edges {
+		item := graphMLItem{ID: fmt.Sprintf("e%d", i), Source: e.From, Target: e.To, Data: []graphMLData{
+			{Key: "relation", Value: e.Relation},
+			{Key: "confidence", Value: fmt.Sprint(int(e.Confidence))},
+		}}
+		if e.Source != "" {
+			item.Data = append(item.Data, graphMLData{Key: "source", Value: e.Source})
+		}
+		if e.Context != "" {
+			item.Data = append(item.Data, graphMLData{Key: "context", Value: e.Context})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+var exportGraph = &Subgraph{
+	Nodes: []Node{
+		{ID: "deploy/default/api", Type: "deployment", SourceID: "k8s-prod", Metadata: map[string]any{"replicas": 2}},
+		{ID: `svc/default/"api"`, Type: "service"},
+	},
+	Edges: []Edge{
+		{From: `svc/default/"api"`, To: "deploy/default/api", Relation: "routes_to", Confidence: Explicit, Source: "k8s-prod"},
+		{From: "deploy/default/api", To: `svc/default/"api"`, Relation: "calls", Confidence: Inferred, Context: "env API_URL"},
+	},
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b strings.Builder
+	if err := WriteDOT(&b, exportGraph); err != nil {
+		t.Fatalf("WriteDOT() error: %v", err)
+	}
+	want := `digraph joe {
+	rankdir=LR;
+	node [shape=box];
+	"deploy/default/api" [label="deploy/default/api\ndeployment"];
+	"svc/default/\"api\"" [label="svc/default/\"api\"\nservice"];
+	"svc/default/\"api\"" -> "deploy/default/api" [label="routes_to"];
+	"deploy/default/api" -> "svc/default/\"api\"" [label="calls", style=dashed];
+}
+`
+	if b.String() != want {
+		t.Errorf("WriteDOT() =\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var b strings.Builder
+	if err := WriteGraphML(&b, exportGraph); err != nil {
+		t.Fatalf("WriteGraphML() error: %v", err)
+	}
+
+	// It reads back as the same graph
+	var doc graphML
+	if err := xml.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatalf("output is not XML: %v\n%s", err, b.String())
+	}
+	if doc.Graph.EdgeDefault != "directed" || len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 2 {
+		t.Fatalf("graph = %+v, want 2 nodes and 2 directed edges", doc.Graph)
+	}
+	node := doc.Graph.Nodes[0]
+	wantData := []graphMLData{
+		{Key: "label", Value: "deploy/default/api"},
+		{Key: "type", Value: "deployment"},
+		{Key: "source_id", Value: "k8s-prod"},
+		{Key: "metadata", Value: `{"replicas":2}`},
+	}
+	if len(node.Data) != len(wantData) {
+		t.Fatalf("node data = %+v, want %+v", node.Data, wantData)
+	}
+	for i := range wantData {
+		if node.Data[i] != wantData[i] {
+			t.Errorf("node data[%d] = %+v, want %+v", i, node.Data[i], wantData[i])
+		}
+	}
+	edge := doc.Graph.Edges[1]
+	if edge.Source != "deploy/default/api" || edge.Target != `svc/default/"api"` || edge.Data[1].Value != "1" {
+		t.Errorf("edge = %+v, want the inferred calls edge", edge)
+	}
+}
@@ -0,0 +1,414 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a node or edge does not exist
+var ErrNotFound = errors.New("not found")
+
+// recentLimit caps the RecentlyAdded/RecentlyUpdated lists in Summary
+const recentLimit = 10
+
+// MemoryStore is an in-memory GraphStore. It is safe for concurrent use and
+// is also the read path for the persistent store, which loads into it on start.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	nodes map[string]Node
+	edges map[edgeKey]Edge
+}
+
+// edgeKey uniquely identifies an edge
+type edgeKey struct {
+	from, to, relation string
+}
+
+// Compile-time check that MemoryStore implements GraphStore
+var _ GraphStore = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-memory graph
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		nodes: make(map[string]Node),
+		edges: make(map[edgeKey]Edge),
+	}
+}
+
+// AddNode inserts or updates a node. FirstSeen is preserved across updates;
+// LastSeen defaults to now.
+func (m *MemoryStore) AddNode(ctx context.Context, node Node) error {
+	if node.ID == "" {
+		return fmt.Errorf("node id is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := m.nodes[node.ID]; ok && !existing.FirstSeen.IsZero() {
+		node.FirstSeen = existing.FirstSeen
+	}
+	if node.FirstSeen.IsZero() {
+		node.FirstSeen = now
+	}
+	if node.LastSeen.IsZero() {
+		node.LastSeen = now
+	}
+
+	m.nodes[node.ID] = node
+	return nil
+}
+
+// AddEdge inserts or updates an edge keyed by (From, To, Relation)
+func (m *MemoryStore) AddEdge(ctx context.Context, edge Edge) error {
+	if edge.From == "" || edge.To == "" || edge.Relation == "" {
+		return fmt.Errorf("edge requires from, to, and relation")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := edgeKey{edge.From, edge.To, edge.Relation}
+	if existing, ok := m.edges[key]; ok && !existing.CreatedAt.IsZero() {
+		edge.CreatedAt = existing.CreatedAt
+	}
+	if edge.CreatedAt.IsZero() {
+		edge.CreatedAt = time.Now()
+	}
+
+	m.edges[key] = edge
+	return nil
+}
+
+// GetNode retrieves a node by ID, or ErrNotFound
+func (m *MemoryStore) GetNode(ctx context.Context, id string) (*Node, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, ok := m.nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("node %s: %w", id, ErrNotFound)
+	}
+	return &node, nil
+}
+
+// Query returns nodes matching query, sorted by ID. A query is a node
+// filter of whitespace-separated terms, all of which must match: "type:<t>"
+// and "source:<id>" filter exactly, "key=value" matches a metadata value,
+// and other terms match case-insensitively against the node ID, source,
+// and string metadata values. Filters can be chained with hops to follow
+// edges, e.g. "type:service -[depends_on]-> postgres"; see Pattern. An
+// empty query returns every node.
+func (m *MemoryStore) Query(ctx context.Context, query string) ([]Node, error) {
+	p, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Work back from the last filter: the nodes matching each filter that
+	// reach a match of the next one
+	var adjacency map[string][]Edge
+	if len(p.Hops) > 0 {
+		adjacency = m.adjacency()
+	}
+	var result []Node
+	var targets map[string]bool
+	for i := len(p.Filters) - 1; i >= 0; i-- {
+		var reached map[string]bool
+		if targets != nil {
+			reached = p.Hops[i].reaching(targets, adjacency)
+		}
+		result = result[:0]
+		matched := make(map[string]bool)
+		for _, node := range m.nodes {
+			if (reached == nil || reached[node.ID]) && p.Filters[i].Matches(node) {
+				result = append(result, node)
+				matched[node.ID] = true
+			}
+		}
+		targets = matched
+	}
+
+	sortNodes(result)
+	return result, nil
+}
+
+// matchesAll reports whether every term appears in the node ID, source,
+// or metadata
+func matchesAll(node Node, terms []string) bool {
+	for _, term := range terms {
+		if !matchesTerm(node, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesTerm(node Node, term string) bool {
+	if strings.Contains(strings.ToLower(node.ID), term) || strings.Contains(strings.ToLower(node.SourceID), term) {
+		return true
+	}
+	return containsString(node.Metadata, term)
+}
+
+// containsString reports whether a string in v, which may be a map or
+// list of further values, contains term
+func containsString(v any, term string) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.Contains(strings.ToLower(v), term)
+	case map[string]any:
+		for _, item := range v {
+			if containsString(item, term) {
+				return true
+			}
+		}
+	case map[string]string:
+		for _, item := range v {
+			if containsString(item, term) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if containsString(item, term) {
+				return true
+			}
+		}
+	case []string:
+		for _, item := range v {
+			if containsString(item, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Related returns the subgraph within depth hops of nodeID, following
+// edges in either direction
+func (m *MemoryStore) Related(ctx context.Context, nodeID string, depth int) (*Subgraph, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.nodes[nodeID]; !ok {
+		return nil, fmt.Errorf("node %s: %w", nodeID, ErrNotFound)
+	}
+	if depth < 0 {
+		depth = 0
+	}
+
+	adjacency := m.adjacency()
+	visited := map[string]bool{nodeID: true}
+	frontier := []string{nodeID}
+
+	for d := 0; d < depth && len(frontier) > 0; d++ {
+		var next []string
+		for _, id := range frontier {
+			for _, edge := range adjacency[id] {
+				other := edge.To
+				if other == id {
+					other = edge.From
+				}
+				if !visited[other] {
+					visited[other] = true
+					next = append(next, other)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	sub := &Subgraph{}
+	for id := range visited {
+		if node, ok := m.nodes[id]; ok {
+			sub.Nodes = append(sub.Nodes, node)
+		}
+	}
+	for _, edge := range m.edges {
+		if visited[edge.From] && visited[edge.To] {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+
+	sortNodes(sub.Nodes)
+	sortEdges(sub.Edges)
+	return sub, nil
+}
+
+// Snapshot returns every node and edge, sorted
+func (m *MemoryStore) Snapshot(ctx context.Context) (*Subgraph, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sub := &Subgraph{}
+	for _, node := range m.nodes {
+		sub.Nodes = append(sub.Nodes, node)
+	}
+	for _, edge := range m.edges {
+		sub.Edges = append(sub.Edges, edge)
+	}
+	sortNodes(sub.Nodes)
+	sortEdges(sub.Edges)
+	return sub, nil
+}
+
+// Path returns the shortest chain of edges connecting from and to, following
+// edges in either direction. Returns ErrNotFound if no path exists.
+func (m *MemoryStore) Path(ctx context.Context, from, to string) ([]Edge, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, id := range []string{from, to} {
+		if _, ok := m.nodes[id]; !ok {
+			return nil, fmt.Errorf("node %s: %w", id, ErrNotFound)
+		}
+	}
+	if from == to {
+		return []Edge{}, nil
+	}
+
+	adjacency := m.adjacency()
+	// via records the edge used to reach each node
+	via := map[string]Edge{}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range adjacency[id] {
+			other := edge.To
+			if other == id {
+				other = edge.From
+			}
+			if visited[other] {
+				continue
+			}
+			visited[other] = true
+			via[other] = edge
+
+			if other == to {
+				return reconstructPath(via, from, to), nil
+			}
+			queue = append(queue, other)
+		}
+	}
+
+	return nil, fmt.Errorf("path %s -> %s: %w", from, to, ErrNotFound)
+}
+
+func reconstructPath(via map[string]Edge, from, to string) []Edge {
+	var path []Edge
+	for cur := to; cur != from; {
+		edge := via[cur]
+		path = append(path, edge)
+		if edge.To == cur {
+			cur = edge.From
+		} else {
+			cur = edge.To
+		}
+	}
+	// Reverse so the path reads from -> to
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// adjacency indexes edges by both endpoints. Callers must hold m.mu.
+func (m *MemoryStore) adjacency() map[string][]Edge {
+	adj := make(map[string][]Edge)
+	for _, edge := range m.edges {
+		adj[edge.From] = append(adj[edge.From], edge)
+		if edge.To != edge.From {
+			adj[edge.To] = append(adj[edge.To], edge)
+		}
+	}
+	for id := range adj {
+		sortEdges(adj[id])
+	}
+	return adj
+}
+
+// DeleteNode removes a node and all edges touching it
+func (m *MemoryStore) DeleteNode(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.nodes[id]; !ok {
+		return fmt.Errorf("node %s: %w", id, ErrNotFound)
+	}
+	delete(m.nodes, id)
+	for key := range m.edges {
+		if key.from == id || key.to == id {
+			delete(m.edges, key)
+		}
+	}
+	return nil
+}
+
+// DeleteEdge removes a single edge
+func (m *MemoryStore) DeleteEdge(ctx context.Context, from, to, relation string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := edgeKey{from, to, relation}
+	if _, ok := m.edges[key]; !ok {
+		return fmt.Errorf("edge %s -[%s]-> %s: %w", from, relation, to, ErrNotFound)
+	}
+	delete(m.edges, key)
+	return nil
+}
+
+// Summary returns node/edge counts and the most recently added and updated nodes
+func (m *MemoryStore) Summary(ctx context.Context) (GraphSummary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := GraphSummary{
+		NodeCount:   len(m.nodes),
+		EdgeCount:   len(m.edges),
+		NodesByType: make(map[string]int),
+	}
+
+	nodes := make([]Node, 0, len(m.nodes))
+	for _, node := range m.nodes {
+		summary.NodesByType[node.Type]++
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].FirstSeen.After(nodes[j].FirstSeen) })
+	summary.RecentlyAdded = append([]Node(nil), nodes[:min(recentLimit, len(nodes))]...)
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].LastSeen.After(nodes[j].LastSeen) })
+	summary.RecentlyUpdated = append([]Node(nil), nodes[:min(recentLimit, len(nodes))]...)
+
+	return summary, nil
+}
+
+func sortNodes(nodes []Node) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Relation < edges[j].Relation
+	})
+}
@@ -0,0 +1,216 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newTestGraph builds: ingress/web -routes-to-> svc/api -depends-on-> db/postgres
+// plus an isolated node cm/unused
+func newTestGraph(t *testing.T) *MemoryStore {
+	t.Helper()
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	nodes := []Node{
+		{ID: "ingress/web", Type: "ingress", SourceID: "k8s/prod"},
+		{ID: "svc/api", Type: "service", SourceID: "k8s/prod", Metadata: map[string]any{"team": "Payments"}},
+		{ID: "db/postgres", Type: "database", SourceID: "aws/prod"},
+		{ID: "cm/unused", Type: "configmap", SourceID: "k8s/prod"},
+	}
+	for _, n := range nodes {
+		if err := m.AddNode(ctx, n); err != nil {
+			t.Fatalf("AddNode(%s) error: %v", n.ID, err)
+		}
+	}
+
+	edges := []Edge{
+		{From: "ingress/web", To: "svc/api", Relation: "routes-to", Confidence: Explicit},
+		{From: "svc/api", To: "db/postgres", Relation: "depends-on", Confidence: Inferred},
+	}
+	for _, e := range edges {
+		if err := m.AddEdge(ctx, e); err != nil {
+			t.Fatalf("AddEdge(%s->%s) error: %v", e.From, e.To, err)
+		}
+	}
+	return m
+}
+
+func nodeIDs(nodes []Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func TestMemoryStore_AddNodePreservesFirstSeen(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := m.AddNode(ctx, Node{ID: "a", Type: "pod", FirstSeen: first, LastSeen: first}); err != nil {
+		t.Fatalf("AddNode() error: %v", err)
+	}
+	if err := m.AddNode(ctx, Node{ID: "a", Type: "pod"}); err != nil {
+		t.Fatalf("AddNode() update error: %v", err)
+	}
+
+	got, err := m.GetNode(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetNode() error: %v", err)
+	}
+	if !got.FirstSeen.Equal(first) {
+		t.Errorf("FirstSeen = %v, want %v", got.FirstSeen, first)
+	}
+	if !got.LastSeen.After(first) {
+		t.Errorf("LastSeen = %v, want after %v", got.LastSeen, first)
+	}
+}
+
+func TestMemoryStore_Validation(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	if err := m.AddNode(ctx, Node{Type: "pod"}); err == nil {
+		t.Error("AddNode() without ID should fail")
+	}
+	if err := m.AddEdge(ctx, Edge{From: "a", To: "b"}); err == nil {
+		t.Error("AddEdge() without relation should fail")
+	}
+	if _, err := m.GetNode(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetNode() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStore_Query(t *testing.T) {
+	m := newTestGraph(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"empty query returns all", "", []string{"cm/unused", "db/postgres", "ingress/web", "svc/api"}},
+		{"type filter", "type:service", []string{"svc/api"}},
+		{"source filter", "source:aws/prod", []string{"db/postgres"}},
+		{"id substring", "POSTGRES", []string{"db/postgres"}},
+		{"metadata match", "payments", []string{"svc/api"}},
+		{"filter and term", "type:ingress web", []string{"ingress/web"}},
+		{"no match", "redis", []string{}},
+		{"source term", "aws", []string{"db/postgres"}},
+		{"metadata value", "team=payments", []string{"svc/api"}},
+		{"outgoing hop", "type:service -> type:database", []string{"svc/api"}},
+		{"incoming hop with relation", "postgres <-[depends-on]- team=payments", []string{"db/postgres"}},
+		{"hop of the wrong relation", "type:service -[routes-to]-> postgres", []string{}},
+		{"either direction", "type:service -- type:ingress", []string{"svc/api"}},
+		{"one hop is not enough", "ingress/web -> postgres", []string{}},
+		{"several hops", "ingress/web -[*2]-> postgres", []string{"ingress/web"}},
+		{"chain", "source:k8s/prod -> type:service -> type:database", []string{"ingress/web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Query() error: %v", err)
+			}
+			if ids := nodeIDs(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Query(%q) = %v, want %v", tt.query, ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_Related(t *testing.T) {
+	m := newTestGraph(t)
+
+	tests := []struct {
+		name      string
+		node      string
+		depth     int
+		wantNodes []string
+		wantEdges int
+		wantErr   bool
+	}{
+		{"depth 0 is just the node", "svc/api", 0, []string{"svc/api"}, 0, false},
+		{"depth 1 follows both directions", "svc/api", 1, []string{"db/postgres", "ingress/web", "svc/api"}, 2, false},
+		{"depth 1 from the edge", "ingress/web", 1, []string{"ingress/web", "svc/api"}, 1, false},
+		{"depth 2 reaches the end", "ingress/web", 2, []string{"db/postgres", "ingress/web", "svc/api"}, 2, false},
+		{"isolated node", "cm/unused", 3, []string{"cm/unused"}, 0, false},
+		{"unknown node", "missing", 1, nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := m.Related(context.Background(), tt.node, tt.depth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Related() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if ids := nodeIDs(sub.Nodes); !reflect.DeepEqual(ids, tt.wantNodes) {
+				t.Errorf("Related() nodes = %v, want %v", ids, tt.wantNodes)
+			}
+			if len(sub.Edges) != tt.wantEdges {
+				t.Errorf("Related() edges = %d, want %d", len(sub.Edges), tt.wantEdges)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_Path(t *testing.T) {
+	m := newTestGraph(t)
+	ctx := context.Background()
+
+	path, err := m.Path(ctx, "db/postgres", "ingress/web")
+	if err != nil {
+		t.Fatalf("Path() error: %v", err)
+	}
+	if len(path) != 2 || path[0].Relation != "depends-on" || path[1].Relation != "routes-to" {
+		t.Errorf("Path() = %+v, want depends-on then routes-to", path)
+	}
+
+	if _, err := m.Path(ctx, "svc/api", "cm/unused"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Path() to isolated node error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStore_Delete(t *testing.T) {
+	m := newTestGraph(t)
+	ctx := context.Background()
+
+	if err := m.DeleteNode(ctx, "svc/api"); err != nil {
+		t.Fatalf("DeleteNode() error: %v", err)
+	}
+	summary, _ := m.Summary(ctx)
+	if summary.NodeCount != 3 || summary.EdgeCount != 0 {
+		t.Errorf("after DeleteNode: nodes=%d edges=%d, want 3 and 0", summary.NodeCount, summary.EdgeCount)
+	}
+
+	if err := m.DeleteEdge(ctx, "a", "b", "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteEdge() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStore_Summary(t *testing.T) {
+	m := newTestGraph(t)
+
+	summary, err := m.Summary(context.Background())
+	if err != nil {
+		t.Fatalf("Summary() error: %v", err)
+	}
+	if summary.NodeCount != 4 || summary.EdgeCount != 2 {
+		t.Errorf("Summary() counts = %d/%d, want 4/2", summary.NodeCount, summary.EdgeCount)
+	}
+	if summary.NodesByType["service"] != 1 {
+		t.Errorf("NodesByType[service] = %d, want 1", summary.NodesByType["service"])
+	}
+	if len(summary.RecentlyAdded) != 4 || len(summary.RecentlyUpdated) != 4 {
+		t.Errorf("recent lists = %d/%d, want 4/4", len(summary.RecentlyAdded), len(summary.RecentlyUpdated))
+	}
+}
@@ -0,0 +1,333 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidQuery is returned for queries that don't parse
+var ErrInvalidQuery = errors.New("invalid query")
+
+// MaxHopDepth caps how many edges a single hop of a query may follow
+const MaxHopDepth = 5
+
+// Pattern is a parsed graph query: node filters joined by hops, e.g.
+//
+//	type:service env=prod -[depends_on*3]-> type:database postgres
+//
+// matches the services labelled env=prod that depend on a postgres
+// database, directly or through up to two other nodes. A query matches the
+// nodes of its first filter from which the rest of the chain can be
+// followed; a query without hops is a single filter.
+type Pattern struct {
+	Filters []Filter
+	Hops    []Hop // Hops[i] joins Filters[i] and Filters[i+1]
+}
+
+// Filter selects nodes. Every condition must hold; an empty filter
+// matches every node.
+type Filter struct {
+	Types    []string   // "type:a,b": the node is of any of these types
+	Source   string     // "source:id": the node comes from this source
+	Metadata []KeyValue // "key=value": the metadata value, nested keys joined by dots, equals value
+	Terms    []string   // Other words: each appears in the ID, source, or a string metadata value
+}
+
+// KeyValue is a metadata condition of a Filter
+type KeyValue struct {
+	Key, Value string
+}
+
+// Direction is which way a hop follows edges
+type Direction int
+
+const (
+	// Outgoing follows edges from the left node to the right one: ->
+	Outgoing Direction = iota
+	// Incoming follows edges pointing at the left node: <-
+	Incoming
+	// Either follows edges both ways: --
+	Either
+)
+
+// Hop joins two filters, following between 1 and MaxDepth edges of any
+// of Relations (or any relation if empty) in Direction
+type Hop struct {
+	Relations []string
+	Direction Direction
+	MaxDepth  int
+}
+
+// hopPattern matches the hop tokens ->, <-, --, and -[...]-> and friends
+var hopPattern = regexp.MustCompile(`^(<?)-(?:\[([^\]]*)\])?-(>?)$|^(<?)-(>?)$`)
+
+// ParseQuery parses a query in the syntax described by Pattern. Hops are
+// written as separate words: -> or -[rel]-> for outgoing edges, <- or
+// <-[rel]- for incoming ones, and -- or -[rel]- for either. Several
+// relations are separated by |, and *N follows up to N edges, e.g.
+// -[depends_on|uses*2]->.
+func ParseQuery(query string) (*Pattern, error) {
+	p := &Pattern{Filters: []Filter{{}}}
+	empty := true // whether the current filter has no words yet
+	for _, field := range strings.Fields(query) {
+		if hop, ok, err := parseHop(field); err != nil {
+			return nil, err
+		} else if ok {
+			if empty {
+				return nil, fmt.Errorf("%w: %s needs a node filter on each side", ErrInvalidQuery, field)
+			}
+			p.Hops = append(p.Hops, hop)
+			p.Filters = append(p.Filters, Filter{})
+			empty = true
+			continue
+		}
+
+		f := &p.Filters[len(p.Filters)-1]
+		empty = false
+		switch {
+		case strings.HasPrefix(field, "type:"):
+			for t := range strings.SplitSeq(strings.TrimPrefix(field, "type:"), ",") {
+				if t != "" {
+					f.Types = append(f.Types, t)
+				}
+			}
+		case strings.HasPrefix(field, "source:"):
+			f.Source = strings.TrimPrefix(field, "source:")
+		case strings.Contains(field, "=") && !strings.HasPrefix(field, "="):
+			key, value, _ := strings.Cut(field, "=")
+			f.Metadata = append(f.Metadata, KeyValue{Key: key, Value: value})
+		default:
+			f.Terms = append(f.Terms, strings.ToLower(field))
+		}
+	}
+	if empty && len(p.Hops) > 0 {
+		return nil, fmt.Errorf("%w: the last hop needs a node filter after it", ErrInvalidQuery)
+	}
+	return p, nil
+}
+
+// parseHop parses field if it is a hop
+func parseHop(field string) (Hop, bool, error) {
+	m := hopPattern.FindStringSubmatch(field)
+	if m == nil {
+		return Hop{}, false, nil
+	}
+	in, spec, out := m[1]+m[4], m[2], m[3]+m[5]
+	if in == "" && out == "" && field == "-" {
+		return Hop{}, false, nil // a lone dash is just a word
+	}
+
+	hop := Hop{Direction: Either, MaxDepth: 1}
+	switch {
+	case in != "" && out != "":
+		return Hop{}, false, fmt.Errorf("%w: hop %s points both ways", ErrInvalidQuery, field)
+	case out != "":
+		hop.Direction = Outgoing
+	case in != "":
+		hop.Direction = Incoming
+	}
+
+	relations, depth, deep := strings.Cut(spec, "*")
+	if deep {
+		hop.MaxDepth = MaxHopDepth
+		if depth != "" {
+			n, err := strconv.Atoi(depth)
+			if err != nil || n < 1 || n > MaxHopDepth {
+				return Hop{}, false, fmt.Errorf("%w: depth in %s must be a number from 1 to %d", ErrInvalidQuery, field, MaxHopDepth)
+			}
+			hop.MaxDepth = n
+		}
+	}
+	for r := range strings.SplitSeq(relations, "|") {
+		if r = strings.TrimSpace(r); r != "" {
+			hop.Relations = append(hop.Relations, r)
+		}
+	}
+	return hop, true, nil
+}
+
+// String formats p in the query syntax
+func (p *Pattern) String() string {
+	var parts []string
+	for i, f := range p.Filters {
+		if i > 0 {
+			parts = append(parts, p.Hops[i-1].String())
+		}
+		parts = append(parts, f.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// String formats f in the query syntax
+func (f Filter) String() string {
+	var words []string
+	if len(f.Types) > 0 {
+		words = append(words, "type:"+strings.Join(f.Types, ","))
+	}
+	if f.Source != "" {
+		words = append(words, "source:"+f.Source)
+	}
+	for _, kv := range f.Metadata {
+		words = append(words, kv.Key+"="+kv.Value)
+	}
+	return strings.Join(append(words, f.Terms...), " ")
+}
+
+// String formats h in the query syntax
+func (h Hop) String() string {
+	spec := strings.Join(h.Relations, "|")
+	if h.MaxDepth > 1 {
+		spec += "*" + strconv.Itoa(h.MaxDepth)
+	}
+	if spec == "" {
+		return [...]string{Outgoing: "->", Incoming: "<-", Either: "--"}[h.Direction]
+	}
+	switch h.Direction {
+	case Outgoing:
+		return "-[" + spec + "]->"
+	case Incoming:
+		return "<-[" + spec + "]-"
+	}
+	return "-[" + spec + "]-"
+}
+
+// Matches reports whether node satisfies every condition of f
+func (f Filter) Matches(node Node) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, node.Type) {
+		return false
+	}
+	if f.Source != "" && node.SourceID != f.Source {
+		return false
+	}
+	for _, kv := range f.Metadata {
+		v, ok := lookup(node.Metadata, kv.Key)
+		if !ok || !strings.EqualFold(fmt.Sprint(v), kv.Value) {
+			return false
+		}
+	}
+	return matchesAll(node, f.Terms)
+}
+
+// lookup returns the metadata value at key, descending into nested maps
+// at each dot, e.g. labels.app
+func lookup(metadata map[string]any, key string) (any, bool) {
+	if v, ok := metadata[key]; ok {
+		return v, true
+	}
+	head, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	switch nested := metadata[head].(type) {
+	case map[string]any:
+		return lookup(nested, rest)
+	case map[string]string:
+		v, ok := nested[rest]
+		return v, ok
+	}
+	return nil, false
+}
+
+// follows reports whether edge can be followed along h into node id, and
+// returns the node it comes from
+func (h Hop) follows(edge Edge, id string) (string, bool) {
+	if len(h.Relations) > 0 && !slices.Contains(h.Relations, edge.Relation) {
+		return "", false
+	}
+	switch {
+	case (h.Direction == Outgoing || h.Direction == Either) && edge.To == id:
+		return edge.From, true
+	case (h.Direction == Incoming || h.Direction == Either) && edge.From == id:
+		return edge.To, true
+	}
+	return "", false
+}
+
+// reaching returns the nodes from which targets can be reached along h,
+// following adjacency
+func (h Hop) reaching(targets map[string]bool, adjacency map[string][]Edge) map[string]bool {
+	reached := make(map[string]bool)
+	seen := make(map[string]bool, len(targets))
+	var frontier []string
+	for id := range targets {
+		seen[id] = true
+		frontier = append(frontier, id)
+	}
+	for d := 0; d < max(h.MaxDepth, 1) && len(frontier) > 0; d++ {
+		var next []string
+		for _, id := range frontier {
+			for _, edge := range adjacency[id] {
+				other, ok := h.follows(edge, id)
+				if !ok {
+					continue
+				}
+				reached[other] = true
+				if !seen[other] {
+					seen[other] = true
+					next = append(next, other)
+				}
+			}
+		}
+		frontier = next
+	}
+	return reached
+}
+
+// Schema is what a graph holds: the node types, edge relations, and
+// sources queries can refer to, sorted
+type Schema struct {
+	Types     []string
+	Relations []string
+	Sources   []string
+}
+
+// SchemaOf returns the schema of sub
+func SchemaOf(sub *Subgraph) Schema {
+	var s Schema
+	for _, n := range sub.Nodes {
+		s.Types = append(s.Types, n.Type)
+		if n.SourceID != "" {
+			s.Sources = append(s.Sources, n.SourceID)
+		}
+	}
+	for _, e := range sub.Edges {
+		s.Relations = append(s.Relations, e.Relation)
+	}
+	for _, list := range []*[]string{&s.Types, &s.Relations, &s.Sources} {
+		slices.Sort(*list)
+		*list = slices.Compact(*list)
+	}
+	return s
+}
+
+// Validate checks that p only refers to types, relations, and sources in
+// s, so a query can't come back empty for naming something the graph
+// doesn't hold
+func (p *Pattern) Validate(s Schema) error {
+	var problems []string
+	for _, f := range p.Filters {
+		for _, t := range f.Types {
+			if !slices.Contains(s.Types, t) {
+				problems = append(problems, fmt.Sprintf("unknown node type %q (known: %s)", t, strings.Join(s.Types, ", ")))
+			}
+		}
+		if f.Source != "" && !slices.Contains(s.Sources, f.Source) {
+			problems = append(problems, fmt.Sprintf("unknown source %q (known: %s)", f.Source, strings.Join(s.Sources, ", ")))
+		}
+	}
+	for _, h := range p.Hops {
+		for _, r := range h.Relations {
+			if !slices.Contains(s.Relations, r) {
+				problems = append(problems, fmt.Sprintf("unknown relation %q (known: %s)", r, strings.Join(s.Relations, ", ")))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidQuery, strings.Join(problems, "; "))
+	}
+	return nil
+}
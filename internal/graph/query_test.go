@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  *Pattern
+	}{
+		{"empty", "", &Pattern{Filters: []Filter{{}}}},
+		{"filter", "type:service,deployment source:k8s/prod labels.env=prod API", &Pattern{Filters: []Filter{{
+			Types:    []string{"service", "deployment"},
+			Source:   "k8s/prod",
+			Metadata: []KeyValue{{Key: "labels.env", Value: "prod"}},
+			Terms:    []string{"api"},
+		}}}},
+		{"hops", "a -> b <-[owns|uses*3]- c -- d", &Pattern{
+			Filters: []Filter{{Terms: []string{"a"}}, {Terms: []string{"b"}}, {Terms: []string{"c"}}, {Terms: []string{"d"}}},
+			Hops: []Hop{
+				{Direction: Outgoing, MaxDepth: 1},
+				{Relations: []string{"owns", "uses"}, Direction: Incoming, MaxDepth: 3},
+				{Direction: Either, MaxDepth: 1},
+			},
+		}},
+		{"unbounded depth", "a -[*]- b", &Pattern{
+			Filters: []Filter{{Terms: []string{"a"}}, {Terms: []string{"b"}}},
+			Hops:    []Hop{{Direction: Either, MaxDepth: MaxHopDepth}},
+		}},
+		{"lone dash is a word", "a - b", &Pattern{Filters: []Filter{{Terms: []string{"a", "-", "b"}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for _, query := range []string{"-> b", "a ->", "a -> -> b", "a <-> b", "a -[x*9]-> b", "a -[*0]-> b"} {
+		if _, err := ParseQuery(query); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("ParseQuery(%q) error = %v, want ErrInvalidQuery", query, err)
+		}
+	}
+}
+
+func TestPattern_String(t *testing.T) {
+	for _, query := range []string{
+		"type:service env=prod -[depends_on*3]-> type:database postgres",
+		"a <- b -- c -[x|y]- d <-[owns]- source:s e",
+	} {
+		p, err := ParseQuery(query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) error: %v", query, err)
+		}
+		if got := p.String(); got != query {
+			t.Errorf("String() = %q, want %q", got, query)
+		}
+	}
+}
+
+func TestPattern_Validate(t *testing.T) {
+	schema := SchemaOf(&Subgraph{
+		Nodes: []Node{{ID: "svc/api", Type: "service", SourceID: "k8s"}, {ID: "db/pg", Type: "database"}},
+		Edges: []Edge{{From: "svc/api", To: "db/pg", Relation: "depends_on"}},
+	})
+	want := Schema{Types: []string{"database", "service"}, Relations: []string{"depends_on"}, Sources: []string{"k8s"}}
+	if !reflect.DeepEqual(schema, want) {
+		t.Fatalf("SchemaOf() = %+v, want %+v", schema, want)
+	}
+
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{"type:service source:k8s -[depends_on]-> type:database", false},
+		{"anything at all", false},
+		{"type:queue", true},
+		{"source:aws", true},
+		{"a -[calls]-> b", true},
+	}
+	for _, tt := range tests {
+		p, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) error: %v", tt.query, err)
+		}
+		if err := p.Validate(schema); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+		}
+	}
+}
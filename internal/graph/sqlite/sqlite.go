@@ -0,0 +1,315 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS nodes (
+    id         TEXT PRIMARY KEY,
+    type       TEXT NOT NULL,
+    source_id  TEXT NOT NULL DEFAULT '',
+    metadata   TEXT NOT NULL DEFAULT '{}',
+    first_seen TEXT NOT NULL,
+    last_seen  TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS edges (
+    from_id    TEXT NOT NULL,
+    to_id      TEXT NOT NULL,
+    relation   TEXT NOT NULL,
+    confidence INTEGER NOT NULL,
+    source     TEXT NOT NULL DEFAULT '',
+    context    TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    observations INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (from_id, to_id, relation)
+);
+
+CREATE INDEX IF NOT EXISTS idx_edges_to ON edges (to_id);
+`
+
+// addedColumns were added to the schema after it was first released, and
+// are added to databases created before them
+var addedColumns = []struct{ table, column, definition string }{
+	{"edges", "observations", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// Store is a disk-backed GraphStore. Every write goes to SQLite first and
+// then to an in-memory graph that serves all reads; on Open the in-memory
+// graph is rebuilt from disk so the graph survives joecored restarts.
+type Store struct {
+	db  *sql.DB
+	mem *graph.MemoryStore
+}
+
+// Compile-time check that Store implements graph.GraphStore
+var _ graph.GraphStore = (*Store)(nil)
+
+// Open opens (or creates) the graph database at path and loads it into memory
+func Open(ctx context.Context, path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create graph directory: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open graph database %s: %w", path, err)
+	}
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create graph schema: %w", err)
+	}
+	if err := addColumns(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Store{db: db, mem: graph.NewMemoryStore()}
+	if err := s.load(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// addColumns adds the addedColumns a database doesn't have yet
+func addColumns(ctx context.Context, db *sql.DB) error {
+	for _, c := range addedColumns {
+		var n int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("failed to inspect graph schema: %w", err)
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, `ALTER TABLE `+c.table+` ADD COLUMN `+c.column+` `+c.definition); err != nil {
+			return fmt.Errorf("failed to add %s.%s to graph schema: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Ping checks that the database can still be queried
+func (s *Store) Ping(ctx context.Context) error {
+	var one int
+	return s.db.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
+
+// load reads all nodes and edges from disk into the in-memory graph
+func (s *Store) load(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, type, source_id, metadata, first_seen, last_seen FROM nodes`)
+	if err != nil {
+		return fmt.Errorf("failed to load nodes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			node                          graph.Node
+			metadata, firstSeen, lastSeen string
+		)
+		if err := rows.Scan(&node.ID, &node.Type, &node.SourceID, &metadata, &firstSeen, &lastSeen); err != nil {
+			return fmt.Errorf("failed to scan node: %w", err)
+		}
+		if err := json.Unmarshal([]byte(metadata), &node.Metadata); err != nil {
+			return fmt.Errorf("invalid metadata for node %s: %w", node.ID, err)
+		}
+		if node.FirstSeen, err = parseTime(firstSeen); err != nil {
+			return err
+		}
+		if node.LastSeen, err = parseTime(lastSeen); err != nil {
+			return err
+		}
+		if err := s.mem.AddNode(ctx, node); err != nil {
+			return fmt.Errorf("failed to load node %s: %w", node.ID, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load nodes: %w", err)
+	}
+
+	edgeRows, err := s.db.QueryContext(ctx, `SELECT from_id, to_id, relation, confidence, source, context, created_at, observations FROM edges`)
+	if err != nil {
+		return fmt.Errorf("failed to load edges: %w", err)
+	}
+	defer edgeRows.Close()
+
+	for edgeRows.Next() {
+		var (
+			edge      graph.Edge
+			createdAt string
+		)
+		if err := edgeRows.Scan(&edge.From, &edge.To, &edge.Relation, &edge.Confidence, &edge.Source, &edge.Context, &createdAt, &edge.Observations); err != nil {
+			return fmt.Errorf("failed to scan edge: %w", err)
+		}
+		if edge.CreatedAt, err = parseTime(createdAt); err != nil {
+			return err
+		}
+		if err := s.mem.AddEdge(ctx, edge); err != nil {
+			return fmt.Errorf("failed to load edge %s -> %s: %w", edge.From, edge.To, err)
+		}
+	}
+	return edgeRows.Err()
+}
+
+// AddNode persists and inserts or updates a node. FirstSeen is preserved
+// across updates; LastSeen defaults to now.
+func (s *Store) AddNode(ctx context.Context, node graph.Node) error {
+	if node.ID == "" {
+		return fmt.Errorf("node id is required")
+	}
+
+	now := time.Now()
+	if node.FirstSeen.IsZero() {
+		node.FirstSeen = now
+	}
+	if node.LastSeen.IsZero() {
+		node.LastSeen = now
+	}
+
+	metadata, err := json.Marshal(node.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata for node %s: %w", node.ID, err)
+	}
+	if node.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO nodes (id, type, source_id, metadata, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			type = excluded.type,
+			source_id = excluded.source_id,
+			metadata = excluded.metadata,
+			last_seen = excluded.last_seen`,
+		node.ID, node.Type, node.SourceID, string(metadata), formatTime(node.FirstSeen), formatTime(node.LastSeen))
+	if err != nil {
+		return fmt.Errorf("failed to persist node %s: %w", node.ID, err)
+	}
+
+	return s.mem.AddNode(ctx, node)
+}
+
+// AddEdge persists and inserts or updates an edge keyed by (From, To, Relation)
+func (s *Store) AddEdge(ctx context.Context, edge graph.Edge) error {
+	if edge.From == "" || edge.To == "" || edge.Relation == "" {
+		return fmt.Errorf("edge requires from, to, and relation")
+	}
+	if edge.CreatedAt.IsZero() {
+		edge.CreatedAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO edges (from_id, to_id, relation, confidence, source, context, created_at, observations)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (from_id, to_id, relation) DO UPDATE SET
+			confidence = excluded.confidence,
+			source = excluded.source,
+			context = excluded.context,
+			observations = excluded.observations`,
+		edge.From, edge.To, edge.Relation, int(edge.Confidence), edge.Source, edge.Context, formatTime(edge.CreatedAt), edge.Observations)
+	if err != nil {
+		return fmt.Errorf("failed to persist edge %s -[%s]-> %s: %w", edge.From, edge.Relation, edge.To, err)
+	}
+
+	return s.mem.AddEdge(ctx, edge)
+}
+
+// GetNode retrieves a node by ID, or graph.ErrNotFound
+func (s *Store) GetNode(ctx context.Context, id string) (*graph.Node, error) {
+	return s.mem.GetNode(ctx, id)
+}
+
+// Query searches for nodes matching a query (see graph.MemoryStore.Query)
+func (s *Store) Query(ctx context.Context, query string) ([]graph.Node, error) {
+	return s.mem.Query(ctx, query)
+}
+
+// Related finds nodes within depth hops of the given node
+func (s *Store) Related(ctx context.Context, nodeID string, depth int) (*graph.Subgraph, error) {
+	return s.mem.Related(ctx, nodeID, depth)
+}
+
+// Snapshot returns every node and edge
+func (s *Store) Snapshot(ctx context.Context) (*graph.Subgraph, error) {
+	return s.mem.Snapshot(ctx)
+}
+
+// Path finds the shortest path between two nodes
+func (s *Store) Path(ctx context.Context, from, to string) ([]graph.Edge, error) {
+	return s.mem.Path(ctx, from, to)
+}
+
+// DeleteNode removes a node and all edges touching it
+func (s *Store) DeleteNode(ctx context.Context, id string) error {
+	if _, err := s.mem.GetNode(ctx, id); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin delete of node %s: %w", id, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM edges WHERE from_id = ? OR to_id = ?`, id, id); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete edges of node %s: %w", id, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM nodes WHERE id = ?`, id); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to delete node %s: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit delete of node %s: %w", id, err)
+	}
+
+	return s.mem.DeleteNode(ctx, id)
+}
+
+// DeleteEdge removes a single edge
+func (s *Store) DeleteEdge(ctx context.Context, from, to, relation string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM edges WHERE from_id = ? AND to_id = ? AND relation = ?`, from, to, relation)
+	if err != nil {
+		return fmt.Errorf("failed to delete edge %s -[%s]-> %s: %w", from, relation, to, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("edge %s -[%s]-> %s: %w", from, relation, to, graph.ErrNotFound)
+	}
+
+	return s.mem.DeleteEdge(ctx, from, to, relation)
+}
+
+// Summary returns a summary of the graph for LLM context
+func (s *Store) Summary(ctx context.Context) (graph.GraphSummary, error) {
+	return s.mem.Summary(ctx)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", s, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+)
+
+func TestStore_PersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "graph", "graph.db")
+
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+
+	firstSeen := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	nodes := []graph.Node{
+		{ID: "svc/api", Type: "service", SourceID: "k8s/prod", Metadata: map[string]any{"replicas": float64(3)}, FirstSeen: firstSeen},
+		{ID: "db/postgres", Type: "database"},
+		{ID: "cm/old", Type: "configmap"},
+	}
+	for _, n := range nodes {
+		if err := s.AddNode(ctx, n); err != nil {
+			t.Fatalf("AddNode(%s) error: %v", n.ID, err)
+		}
+	}
+	if err := s.AddEdge(ctx, graph.Edge{From: "svc/api", To: "db/postgres", Relation: "depends-on", Confidence: graph.Inferred, Source: "k8s/prod", Observations: 2}); err != nil {
+		t.Fatalf("AddEdge() error: %v", err)
+	}
+	if err := s.AddEdge(ctx, graph.Edge{From: "svc/api", To: "cm/old", Relation: "mounts"}); err != nil {
+		t.Fatalf("AddEdge() error: %v", err)
+	}
+	if err := s.DeleteNode(ctx, "cm/old"); err != nil {
+		t.Fatalf("DeleteNode() error: %v", err)
+	}
+	s.Close()
+
+	s, err = Open(ctx, path)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer s.Close()
+
+	got, err := s.GetNode(ctx, "svc/api")
+	if err != nil {
+		t.Fatalf("GetNode() after reopen error: %v", err)
+	}
+	if got.Type != "service" || got.SourceID != "k8s/prod" || got.Metadata["replicas"] != float64(3) {
+		t.Errorf("GetNode() after reopen = %+v", got)
+	}
+	if !got.FirstSeen.Equal(firstSeen) {
+		t.Errorf("FirstSeen = %v, want %v", got.FirstSeen, firstSeen)
+	}
+
+	if _, err := s.GetNode(ctx, "cm/old"); !errors.Is(err, graph.ErrNotFound) {
+		t.Errorf("deleted node error = %v, want ErrNotFound", err)
+	}
+
+	sub, err := s.Related(ctx, "svc/api", 1)
+	if err != nil {
+		t.Fatalf("Related() error: %v", err)
+	}
+	if len(sub.Nodes) != 2 || len(sub.Edges) != 1 {
+		t.Fatalf("Related() = %d nodes, %d edges; want 2 and 1", len(sub.Nodes), len(sub.Edges))
+	}
+	if e := sub.Edges[0]; e.Confidence != graph.Inferred || e.Source != "k8s/prod" || e.Observations != 2 {
+		t.Errorf("edge after reopen = %+v", sub.Edges[0])
+	}
+}
+
+func TestStore_DeleteEdge(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(ctx, filepath.Join(t.TempDir(), "graph.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+
+	s.AddNode(ctx, graph.Node{ID: "a", Type: "pod"})
+	s.AddNode(ctx, graph.Node{ID: "b", Type: "pod"})
+	s.AddEdge(ctx, graph.Edge{From: "a", To: "b", Relation: "talks-to"})
+
+	if err := s.DeleteEdge(ctx, "a", "b", "talks-to"); err != nil {
+		t.Fatalf("DeleteEdge() error: %v", err)
+	}
+	if err := s.DeleteEdge(ctx, "a", "b", "talks-to"); !errors.Is(err, graph.ErrNotFound) {
+		t.Errorf("second DeleteEdge() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOpen_AddsColumns(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "graph.db")
+
+	// A graph saved before edges had observations
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.ExecContext(ctx, `CREATE TABLE edges (
+		from_id TEXT NOT NULL, to_id TEXT NOT NULL, relation TEXT NOT NULL, confidence INTEGER NOT NULL,
+		source TEXT NOT NULL DEFAULT '', context TEXT NOT NULL DEFAULT '', created_at TEXT NOT NULL,
+		PRIMARY KEY (from_id, to_id, relation));
+		INSERT INTO edges VALUES ('a', 'b', 'calls', 1, '', '', '2024-03-02T09:00:00Z')`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer s.Close()
+	if err := s.AddEdge(ctx, graph.Edge{From: "a", To: "b", Relation: "calls", Confidence: graph.Inferred, Observations: 3}); err != nil {
+		t.Fatalf("AddEdge() error: %v", err)
+	}
+}
@@ -33,6 +33,9 @@ type GraphStore interface {
 
 	// Summary returns a summary of the graph for LLM context
 	Summary(ctx context.Context) (GraphSummary, error)
+
+	// Snapshot returns every node and edge
+	Snapshot(ctx context.Context) (*Subgraph, error)
 }
 
 // Node represents a node in the infrastructure graph
@@ -54,6 +57,10 @@ type Edge struct {
 	Source     string
 	Context    string
 	CreatedAt  time.Time
+
+	// Observations counts the refreshes in a row that reported an
+	// inferred edge, towards its promotion to Corroborated
+	Observations int
 }
 
 // ConfidenceLevel represents how certain we are about an edge
@@ -63,13 +70,31 @@ const (
 	// Inferred means the edge was guessed by the LLM, not yet confirmed
 	Inferred ConfidenceLevel = 1
 
+	// Corroborated means an inferred edge kept being reported, refresh
+	// after refresh
+	Corroborated ConfidenceLevel = 2
+
 	// Explicit means the edge was discovered from API or .joe/ file
 	Explicit ConfidenceLevel = 3
 
 	// UserConfirmed means the user explicitly confirmed this edge
-	UserConfirmed ConfidenceLevel = 3
+	UserConfirmed ConfidenceLevel = 4
 )
 
+// String returns the level's name, e.g. "inferred"
+func (c ConfidenceLevel) String() string {
+	switch {
+	case c >= UserConfirmed:
+		return "confirmed"
+	case c >= Explicit:
+		return "explicit"
+	case c >= Corroborated:
+		return "corroborated"
+	default:
+		return "inferred"
+	}
+}
+
 // Subgraph represents a subset of the graph
 type Subgraph struct {
 	Nodes []Node
@@ -0,0 +1,187 @@
+// Package graphquery answers questions about the infrastructure, e.g. "all
+// services that depend on postgres in prod", by having the LLM write them
+// as graph queries (see graph.Pattern) and checking those against what the
+// graph holds before running them
+package graphquery
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// queryTool is the tool the LLM writes its query with
+const queryTool = "graph_query"
+
+// maxAttempts is how many queries the LLM may write for a question; a
+// rejected one is sent back with the reason
+const maxAttempts = 2
+
+// Limits on the schema described to the LLM
+const (
+	maxExampleIDs   = 3
+	maxMetadataKeys = 20
+)
+
+const planPrompt = `You translate questions about infrastructure into queries of joe's infrastructure graph. Nodes have an ID, a type, the source they were discovered from, and metadata; edges have a relation and point from one node to another. Call the graph_query tool once with a query in this syntax:
+
+- A node filter is space-separated conditions, all of which must hold: type:<t> (several types separated by commas), source:<id>, <key>=<value> for a metadata value (nested keys joined by dots, e.g. labels.env=prod), and plain words, which must appear in the node's ID, source, or metadata.
+- Filters are chained with hops, written as separate words: -> follows an edge from the left node to the right one, <- an edge pointing at the left node, and -- either way. Restrict a hop to relations with -[rel]->, <-[rel]-, or -[rel]-, several separated by |, and follow up to N edges with *N, e.g. -[depends_on|uses*3]->.
+- The query returns the nodes of its first filter from which the rest of the chain can be followed, so put what the question asks for first.
+
+Examples:
+- "all services that depend on postgres in prod": type:service prod -[depends_on*3]-> postgres
+- "what routes to the payment deployment": type:ingress,service -[routes_to*2]-> type:deployment payment
+- "databases nothing uses" cannot be expressed; query type:database and say so.
+
+Only use the node types, relations, and sources listed in the graph schema. Reply with the tool call only.`
+
+// tools is the definition of queryTool
+var tools = []llm.ToolDefinition{{
+	Name:        queryTool,
+	Description: "Run a query of the infrastructure graph",
+	Parameters: llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"query": {Type: "string", Description: "The query, e.g. type:service -[depends_on]-> postgres"},
+		},
+		Required: []string{"query"},
+	},
+}}
+
+// Planner translates questions into graph queries and runs them
+type Planner struct {
+	llm   llm.LLMAdapter
+	graph graph.GraphStore
+}
+
+// New creates a Planner asking adapter to write queries of g
+func New(adapter llm.LLMAdapter, g graph.GraphStore) *Planner {
+	return &Planner{llm: adapter, graph: g}
+}
+
+// Answer is the query a question was translated into and the nodes it
+// matched
+type Answer struct {
+	Query string
+	Nodes []graph.Node
+}
+
+// Ask translates question into a query and runs it
+func (p *Planner) Ask(ctx context.Context, question string) (*Answer, error) {
+	pattern, err := p.Plan(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+	query := pattern.String()
+	nodes, err := p.graph.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query %q: %w", query, err)
+	}
+	return &Answer{Query: query, Nodes: nodes}, nil
+}
+
+// Plan asks the LLM to write question as a query, and checks that the
+// query parses and only names node types, relations, and sources the
+// graph holds. Errors wrap graph.ErrInvalidQuery when the LLM didn't
+// manage to.
+func (p *Planner) Plan(ctx context.Context, question string) (*graph.Pattern, error) {
+	sub, err := p.graph.Snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	schema := graph.SchemaOf(sub)
+
+	messages := []llm.Message{{Role: "user", Content: describe(sub, schema) + "\nQuestion: " + question}}
+	for attempt := 1; ; attempt++ {
+		resp, err := p.llm.Chat(ctx, llm.ChatRequest{
+			SystemPrompt: planPrompt,
+			Messages:     messages,
+			Tools:        tools,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan query: %w", err)
+		}
+		i := slices.IndexFunc(resp.ToolCalls, func(tc llm.ToolCall) bool { return tc.Name == queryTool })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: the LLM wrote no query for %q", graph.ErrInvalidQuery, question)
+		}
+		call := resp.ToolCalls[i]
+		query, _ := call.Args["query"].(string)
+
+		pattern, err := graph.ParseQuery(query)
+		if err == nil {
+			err = pattern.Validate(schema)
+		}
+		if err == nil {
+			return pattern, nil
+		}
+		if attempt == maxAttempts {
+			return nil, fmt.Errorf("query %q for %q: %w", query, question, err)
+		}
+
+		// Send the problem back for another try
+		id := call.ID
+		if id == "" {
+			id = call.Name
+		}
+		messages = append(messages,
+			llm.Message{Role: "assistant", Content: resp.Content, ToolCalls: []llm.ToolCall{call}},
+			llm.Message{Role: "user", Content: err.Error() + ". Write the query again.", ToolResultID: id, ToolName: call.Name, IsError: true})
+	}
+}
+
+// describe lists the schema for the LLM: each node type with its count,
+// example IDs, and metadata keys, then the relations and sources
+func describe(sub *graph.Subgraph, schema graph.Schema) string {
+	counts := make(map[string]int)
+	examples := make(map[string][]string)
+	keys := make(map[string]map[string]bool)
+	for _, n := range sub.Nodes {
+		counts[n.Type]++
+		if len(examples[n.Type]) < maxExampleIDs {
+			examples[n.Type] = append(examples[n.Type], n.ID)
+		}
+		if keys[n.Type] == nil {
+			keys[n.Type] = make(map[string]bool)
+		}
+		for k, v := range n.Metadata {
+			keys[n.Type][k] = true
+			switch nested := v.(type) {
+			case map[string]any:
+				for nk := range nested {
+					keys[n.Type][k+"."+nk] = true
+				}
+			case map[string]string:
+				for nk := range nested {
+					keys[n.Type][k+"."+nk] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("Graph schema\n\nNode types:\n")
+	for _, t := range schema.Types {
+		fmt.Fprintf(&b, "- %s (%d), e.g. %s", t, counts[t], strings.Join(examples[t], ", "))
+		if sorted := slices.Sorted(maps.Keys(keys[t])); len(sorted) > 0 {
+			fmt.Fprintf(&b, "; metadata: %s", strings.Join(sorted[:min(len(sorted), maxMetadataKeys)], ", "))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nRelations: %s\n", orNone(schema.Relations))
+	fmt.Fprintf(&b, "Sources: %s\n", orNone(schema.Sources))
+	return b.String()
+}
+
+func orNone(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,120 @@
+package graphquery
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// scriptedLLM writes the given queries in turn
+type scriptedLLM struct {
+	queries  []string
+	requests []llm.ChatRequest
+}
+
+func (s *scriptedLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	s.requests = append(s.requests, req)
+	if len(s.queries) == 0 {
+		return &llm.ChatResponse{Content: "I can't answer that"}, nil
+	}
+	query := s.queries[0]
+	s.queries = s.queries[1:]
+	return &llm.ChatResponse{ToolCalls: []llm.ToolCall{{ID: "call-1", Name: queryTool, Args: map[string]any{"query": query}}}}, nil
+}
+
+func (s *scriptedLLM) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, nil
+}
+
+func (s *scriptedLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+func newTestGraph(t *testing.T) *graph.MemoryStore {
+	t.Helper()
+	ctx := context.Background()
+	g := graph.NewMemoryStore()
+	for _, n := range []graph.Node{
+		{ID: "svc/prod/api", Type: "service", SourceID: "k8s-prod", Metadata: map[string]any{"labels": map[string]any{"env": "prod"}}},
+		{ID: "svc/staging/api", Type: "service", SourceID: "k8s-staging"},
+		{ID: "db/postgres", Type: "database", SourceID: "aws"},
+	} {
+		if err := g.AddNode(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []graph.Edge{
+		{From: "svc/prod/api", To: "db/postgres", Relation: "depends_on"},
+		{From: "svc/staging/api", To: "db/postgres", Relation: "depends_on"},
+	} {
+		if err := g.AddEdge(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestPlanner_Ask(t *testing.T) {
+	adapter := &scriptedLLM{queries: []string{
+		"type:service prod -[uses]-> postgres",
+		"type:service labels.env=prod -[depends_on]-> postgres",
+	}}
+	answer, err := New(adapter, newTestGraph(t)).Ask(context.Background(), "services that depend on postgres in prod")
+	if err != nil {
+		t.Fatalf("Ask() error: %v", err)
+	}
+
+	if answer.Query != "type:service labels.env=prod -[depends_on]-> postgres" {
+		t.Errorf("Query = %q", answer.Query)
+	}
+	var ids []string
+	for _, n := range answer.Nodes {
+		ids = append(ids, n.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"svc/prod/api"}) {
+		t.Errorf("Nodes = %v, want [svc/prod/api]", ids)
+	}
+
+	// The schema went with the question, and the rejected query came back
+	// with the reason
+	question := adapter.requests[0].Messages[0].Content
+	for _, want := range []string{"- service (2), e.g. svc/prod/api, svc/staging/api; metadata: labels, labels.env", "Relations: depends_on", "Sources: aws, k8s-prod, k8s-staging", "Question: services that depend"} {
+		if !strings.Contains(question, want) {
+			t.Errorf("question missing %q:\n%s", want, question)
+		}
+	}
+	if len(adapter.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(adapter.requests))
+	}
+	retry := adapter.requests[1].Messages
+	if last := retry[len(retry)-1]; !last.IsError || last.ToolResultID != "call-1" || !strings.Contains(last.Content, `unknown relation "uses"`) {
+		t.Errorf("retry message = %+v", last)
+	}
+}
+
+func TestPlanner_Plan_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []string
+	}{
+		{"no query", nil},
+		{"invalid every time", []string{"a ->", "type:queue"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := &scriptedLLM{queries: tt.queries}
+			_, err := New(adapter, newTestGraph(t)).Plan(context.Background(), "queues")
+			if !errors.Is(err, graph.ErrInvalidQuery) {
+				t.Errorf("Plan() error = %v, want ErrInvalidQuery", err)
+			}
+			if len(adapter.requests) > maxAttempts {
+				t.Errorf("requests = %d, want at most %d", len(adapter.requests), maxAttempts)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // LLMAdapter is the interface for AI providers (Claude, OpenAI, Ollama, etc.)
 // Joe is AI-agnostic - different providers implement this interface
@@ -20,7 +23,9 @@ type ChatRequest struct {
 	SystemPrompt string
 	Messages     []Message
 	Tools        []ToolDefinition
-	MaxTokens    int
+	MaxTokens    int      // 0 uses the provider default
+	Temperature  *float64 // nil uses the provider default
+	TopP         *float64 // nil uses the provider default
 }
 
 // ChatResponse represents a response from the LLM
@@ -46,6 +51,7 @@ type Message struct {
 	ToolResultID string     // For tool result messages: references the tool call ID
 	ToolName     string     // For tool result messages: the tool name (needed by Gemini)
 	IsError      bool       // For tool result messages: whether the result is an error
+	Timestamp    time.Time  // When the message was added to the session (display only, not sent to providers)
 }
 
 // ToolDefinition describes a tool available to the LLM
@@ -81,4 +87,12 @@ type TokenUsage struct {
 	InputTokens  int
 	OutputTokens int
 	TotalTokens  int
+
+	// Prompt caching (Anthropic): tokens read from or written to the cache.
+	// They are not included in InputTokens.
+	CacheReadTokens  int
+	CacheWriteTokens int
+
+	// Estimated USD cost, set by cost tracking for models with pricing
+	CostUSD float64
 }
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// Batcher queues work that needs the LLM, such as .joe/ directories to
+// interpret, so that one call can handle many items. A batch is ready once
+// threshold items are queued or the oldest has waited timeout; items
+// beyond the threshold stay queued for the next batch.
+type Batcher[T any] struct {
+	threshold int
+	timeout   time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	items  []T
+	oldest time.Time // When the first queued item was added
+}
+
+// NewBatcher creates a Batcher releasing up to threshold items at a time,
+// or fewer once they have waited timeout. A threshold below 1 releases
+// items one at a time.
+func NewBatcher[T any](threshold int, timeout time.Duration) *Batcher[T] {
+	return &Batcher[T]{
+		threshold: max(threshold, 1),
+		timeout:   timeout,
+		now:       time.Now,
+	}
+}
+
+// Add queues items
+func (b *Batcher[T]) Add(items ...T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.items) == 0 && len(items) > 0 {
+		b.oldest = b.now()
+	}
+	b.items = append(b.items, items...)
+}
+
+// Len returns the number of queued items
+func (b *Batcher[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.items)
+}
+
+// Next removes and returns the next batch if one is ready, or nil
+func (b *Batcher[T]) Next() []T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.items) == 0 {
+		return nil
+	}
+	if len(b.items) < b.threshold && b.now().Sub(b.oldest) < b.timeout {
+		return nil
+	}
+
+	n := min(len(b.items), b.threshold)
+	batch := append([]T(nil), b.items[:n]...)
+	// Leftovers keep the current oldest time: they queued up behind a full
+	// batch and shouldn't start waiting again from now
+	b.items = b.items[n:]
+	return batch
+}
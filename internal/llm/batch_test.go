@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBatcher(t *testing.T) {
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	b := NewBatcher[string](3, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	if got := b.Next(); got != nil {
+		t.Errorf("Next() on empty batcher = %v, want nil", got)
+	}
+
+	b.Add("deploy/api", "ns/payments")
+	if got := b.Next(); got != nil {
+		t.Errorf("Next() below threshold = %v, want nil until the timeout", got)
+	}
+
+	b.Add("crd/widgets", "deploy/worker")
+	if got, want := b.Next(), []string{"deploy/api", "ns/payments", "crd/widgets"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() at threshold = %v, want %v", got, want)
+	}
+	if b.Len() != 1 {
+		t.Errorf("Len() = %d, want the overflow item queued", b.Len())
+	}
+
+	now = now.Add(30 * time.Second)
+	if got, want := b.Next(), []string{"deploy/worker"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Next() after timeout = %v, want %v", got, want)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", b.Len())
+	}
+}
@@ -0,0 +1,332 @@
+// Package bedrock implements the LLMAdapter interface using the AWS Bedrock
+// Converse API, which serves Claude, Nova, and other Bedrock models through
+// one request format. Credentials come from the standard AWS chain.
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// converseAPI is the part of the Bedrock runtime client the adapter uses
+type converseAPI interface {
+	Converse(ctx context.Context, params *bedrockruntime.ConverseInput, optFns ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error)
+}
+
+// Client implements the LLMAdapter interface using the Bedrock Converse API
+type Client struct {
+	api   converseAPI
+	model string
+}
+
+// APIError represents an error from the Bedrock API with structured details
+type APIError struct {
+	Code            int           // HTTP status code
+	Message         string        // Raw API error message
+	Err             error         // Enhanced error with user-friendly message
+	RetryAfterDelay time.Duration // Retry-After from the response, if any
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// APICode returns the HTTP status code from the API
+func (e *APIError) APICode() int {
+	return e.Code
+}
+
+// APIMessage returns the raw error message from the API
+func (e *APIError) APIMessage() string {
+	return e.Message
+}
+
+// RetryAfter returns how long the API asked us to wait before retrying
+func (e *APIError) RetryAfter() time.Duration {
+	return e.RetryAfterDelay
+}
+
+// NewClient creates a Bedrock client for a model or inference profile ID,
+// e.g. "anthropic.claude-sonnet-4-20250514-v1:0" or "us.amazon.nova-pro-v1:0".
+// Empty region and profile fall back to the AWS environment and shared config.
+func NewClient(ctx context.Context, model, region, profile string) (*Client, error) {
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	if profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region configured for Bedrock: set region on the model or AWS_REGION")
+	}
+
+	// Retries are handled by llm.RetryAdapter so they follow the configured
+	// policy for every provider
+	api := bedrockruntime.NewFromConfig(cfg, func(o *bedrockruntime.Options) {
+		o.Retryer = aws.NopRetryer{}
+	})
+
+	return &Client{api: api, model: model}, nil
+}
+
+// Chat sends a chat request and returns a response
+func (c *Client) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	out, err := c.api.Converse(ctx, c.buildInput(req))
+	if err != nil {
+		return nil, c.enhanceError(err)
+	}
+	return convertResponse(out)
+}
+
+// ChatStream is not yet implemented
+func (c *Client) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, fmt.Errorf("streaming not yet implemented")
+}
+
+// Embed is not yet implemented
+func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, fmt.Errorf("embeddings not yet implemented")
+}
+
+// buildInput converts a ChatRequest to a Converse request
+func (c *Client) buildInput(req llm.ChatRequest) *bedrockruntime.ConverseInput {
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 4096
+	}
+
+	inference := &types.InferenceConfiguration{MaxTokens: aws.Int32(int32(maxTokens))}
+	if req.Temperature != nil {
+		inference.Temperature = aws.Float32(float32(*req.Temperature))
+	}
+	if req.TopP != nil {
+		inference.TopP = aws.Float32(float32(*req.TopP))
+	}
+
+	input := &bedrockruntime.ConverseInput{
+		ModelId:         aws.String(c.model),
+		Messages:        convertMessages(req.Messages),
+		InferenceConfig: inference,
+	}
+	if req.SystemPrompt != "" {
+		input.System = []types.SystemContentBlock{
+			&types.SystemContentBlockMemberText{Value: req.SystemPrompt},
+		}
+	}
+	if len(req.Tools) > 0 {
+		tools := make([]types.Tool, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			tools = append(tools, convertToolDefinition(tool))
+		}
+		input.ToolConfig = &types.ToolConfiguration{Tools: tools}
+	}
+	return input
+}
+
+// convertMessages builds the Bedrock conversation. Consecutive tool results
+// are sent as one user message, since Converse expects every toolResult for a
+// turn's toolUse blocks in the message that follows it.
+func convertMessages(msgs []llm.Message) []types.Message {
+	var out []types.Message
+	for _, msg := range msgs {
+		switch {
+		case msg.ToolResultID != "":
+			status := types.ToolResultStatusSuccess
+			if msg.IsError {
+				status = types.ToolResultStatusError
+			}
+			block := &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+				ToolUseId: aws.String(msg.ToolResultID),
+				Content:   []types.ToolResultContentBlock{&types.ToolResultContentBlockMemberText{Value: msg.Content}},
+				Status:    status,
+			}}
+			if n := len(out); n > 0 && isToolResults(out[n-1]) {
+				out[n-1].Content = append(out[n-1].Content, block)
+				continue
+			}
+			out = append(out, types.Message{Role: types.ConversationRoleUser, Content: []types.ContentBlock{block}})
+		case msg.Role == "assistant":
+			var content []types.ContentBlock
+			if msg.Content != "" {
+				content = append(content, &types.ContentBlockMemberText{Value: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				args := tc.Args
+				if args == nil {
+					args = map[string]any{}
+				}
+				content = append(content, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String(tc.ID),
+					Name:      aws.String(tc.Name),
+					Input:     document.NewLazyDocument(args),
+				}})
+			}
+			out = append(out, types.Message{Role: types.ConversationRoleAssistant, Content: content})
+		default:
+			out = append(out, types.Message{
+				Role:    types.ConversationRoleUser,
+				Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: msg.Content}},
+			})
+		}
+	}
+	return out
+}
+
+// isToolResults reports whether m is a user message of tool results
+func isToolResults(m types.Message) bool {
+	if m.Role != types.ConversationRoleUser || len(m.Content) == 0 {
+		return false
+	}
+	_, ok := m.Content[0].(*types.ContentBlockMemberToolResult)
+	return ok
+}
+
+// convertToolDefinition converts our tool definition to a Bedrock tool spec
+func convertToolDefinition(tool llm.ToolDefinition) types.Tool {
+	properties := make(map[string]any, len(tool.Parameters.Properties))
+	for name, prop := range tool.Parameters.Properties {
+		properties[name] = propertySchema(prop)
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(tool.Parameters.Required) > 0 {
+		schema["required"] = tool.Parameters.Required
+	}
+
+	// Bedrock rejects empty descriptions
+	desc := tool.Description
+	if desc == "" {
+		desc = tool.Name
+	}
+
+	return &types.ToolMemberToolSpec{Value: types.ToolSpecification{
+		Name:        aws.String(tool.Name),
+		Description: aws.String(desc),
+		InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(schema)},
+	}}
+}
+
+// propertySchema converts a property to JSON Schema
+func propertySchema(prop llm.Property) map[string]any {
+	schema := map[string]any{"type": prop.Type}
+	if prop.Description != "" {
+		schema["description"] = prop.Description
+	}
+	if prop.Items != nil {
+		schema["items"] = propertySchema(*prop.Items)
+	}
+	return schema
+}
+
+// convertResponse converts a Converse response to our response format
+func convertResponse(out *bedrockruntime.ConverseOutput) (*llm.ChatResponse, error) {
+	result := &llm.ChatResponse{}
+	if u := out.Usage; u != nil {
+		result.Usage = llm.TokenUsage{
+			InputTokens:      int(aws.ToInt32(u.InputTokens)),
+			OutputTokens:     int(aws.ToInt32(u.OutputTokens)),
+			TotalTokens:      int(aws.ToInt32(u.TotalTokens)),
+			CacheReadTokens:  int(aws.ToInt32(u.CacheReadInputTokens)),
+			CacheWriteTokens: int(aws.ToInt32(u.CacheWriteInputTokens)),
+		}
+	}
+
+	msg, ok := out.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected Bedrock output type %T", out.Output)
+	}
+
+	var text []string
+	for _, block := range msg.Value.Content {
+		switch b := block.(type) {
+		case *types.ContentBlockMemberText:
+			text = append(text, b.Value)
+		case *types.ContentBlockMemberToolUse:
+			var args map[string]any
+			if b.Value.Input != nil {
+				// Round-trip through JSON: works for both lazy and decoded documents
+				raw, err := b.Value.Input.MarshalSmithyDocument()
+				if err == nil {
+					err = json.Unmarshal(raw, &args)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("invalid input for tool %s: %w", aws.ToString(b.Value.Name), err)
+				}
+			}
+			result.ToolCalls = append(result.ToolCalls, llm.ToolCall{
+				ID:   aws.ToString(b.Value.ToolUseId),
+				Name: aws.ToString(b.Value.Name),
+				Args: args,
+			})
+		}
+	}
+	result.Content = strings.Join(text, "")
+	return result, nil
+}
+
+// enhanceError provides better error messages for common API errors
+// Returns *APIError with structured details for logging
+func (c *Client) enhanceError(err error) error {
+	var respErr *awshttp.ResponseError
+	if !errors.As(err, &respErr) {
+		return fmt.Errorf("Bedrock API call failed: %w", err)
+	}
+
+	code := respErr.HTTPStatusCode()
+	msg := respErr.Err.Error()
+
+	var enhancedErr error
+	switch {
+	case code == 401 || code == 403:
+		enhancedErr = fmt.Errorf("access denied by Bedrock for model '%s'.\n\nCheck your AWS credentials and that model access is enabled in the Bedrock console:\n  %s", c.model, msg)
+	case code == 404:
+		enhancedErr = fmt.Errorf("model '%s' not found on Bedrock in this region.\n\nSome models are only available through an inference profile (e.g. 'us.%s'):\n  %s", c.model, c.model, msg)
+	case code == 429:
+		enhancedErr = fmt.Errorf("Bedrock throttled the request for model '%s':\n  %s", c.model, msg)
+	case code == 400:
+		enhancedErr = fmt.Errorf("invalid request to Bedrock:\n  %s", msg)
+	case code >= 500:
+		enhancedErr = fmt.Errorf("Bedrock is unavailable (%d): %w", code, err)
+	default:
+		return fmt.Errorf("Bedrock API call failed: %w", err)
+	}
+
+	var retryAfter time.Duration
+	if respErr.Response != nil {
+		retryAfter = llm.ParseRetryAfter(respErr.Response.Header.Get("Retry-After"), time.Now())
+	}
+
+	return &APIError{
+		Code:            code,
+		Message:         msg,
+		Err:             enhancedErr,
+		RetryAfterDelay: retryAfter,
+	}
+}
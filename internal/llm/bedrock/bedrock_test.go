@@ -0,0 +1,132 @@
+package bedrock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// fakeConverse records the request and returns a canned response
+type fakeConverse struct {
+	input *bedrockruntime.ConverseInput
+	out   *bedrockruntime.ConverseOutput
+	err   error
+}
+
+func (f *fakeConverse) Converse(ctx context.Context, in *bedrockruntime.ConverseInput, _ ...func(*bedrockruntime.Options)) (*bedrockruntime.ConverseOutput, error) {
+	f.input = in
+	return f.out, f.err
+}
+
+func TestChat(t *testing.T) {
+	fake := &fakeConverse{out: &bedrockruntime.ConverseOutput{
+		Output: &types.ConverseOutputMemberMessage{Value: types.Message{
+			Role: types.ConversationRoleAssistant,
+			Content: []types.ContentBlock{
+				&types.ContentBlockMemberText{Value: "Reading it."},
+				&types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String("tu_1"),
+					Name:      aws.String("read_file"),
+					Input:     document.NewLazyDocument(map[string]any{"path": "go.mod"}),
+				}},
+			},
+		}},
+		Usage: &types.TokenUsage{InputTokens: aws.Int32(20), OutputTokens: aws.Int32(8), TotalTokens: aws.Int32(28)},
+	}}
+	c := &Client{api: fake, model: "us.amazon.nova-pro-v1:0"}
+
+	resp, err := c.Chat(context.Background(), llm.ChatRequest{
+		SystemPrompt: "be brief",
+		Messages:     []llm.Message{{Role: "user", Content: "what module is this?"}},
+		Tools:        []llm.ToolDefinition{{Name: "read_file", Parameters: llm.ParameterSchema{Type: "object"}}},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	in := fake.input
+	if aws.ToString(in.ModelId) != "us.amazon.nova-pro-v1:0" || len(in.System) != 1 || len(in.Messages) != 1 {
+		t.Errorf("input = %+v", in)
+	}
+	if aws.ToInt32(in.InferenceConfig.MaxTokens) != 4096 {
+		t.Errorf("MaxTokens = %d, want default 4096", aws.ToInt32(in.InferenceConfig.MaxTokens))
+	}
+	spec := in.ToolConfig.Tools[0].(*types.ToolMemberToolSpec).Value
+	if aws.ToString(spec.Description) != "read_file" {
+		t.Errorf("empty description should fall back to the tool name, got %q", aws.ToString(spec.Description))
+	}
+
+	if resp.Content != "Reading it." {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "tu_1" || resp.ToolCalls[0].Args["path"] != "go.mod" {
+		t.Errorf("ToolCalls = %+v", resp.ToolCalls)
+	}
+	if resp.Usage.InputTokens != 20 || resp.Usage.OutputTokens != 8 || resp.Usage.TotalTokens != 28 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestConvertMessages_GroupsToolResults(t *testing.T) {
+	msgs := []llm.Message{
+		{Role: "user", Content: "check both"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{
+			{ID: "a", Name: "local_git_status"},
+			{ID: "b", Name: "run_command", Args: map[string]any{"command": "ls"}},
+		}},
+		{Role: "user", Content: "clean", ToolResultID: "a", ToolName: "local_git_status"},
+		{Role: "user", Content: "denied", ToolResultID: "b", ToolName: "run_command", IsError: true},
+	}
+
+	got := convertMessages(msgs)
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3", len(got))
+	}
+	if len(got[2].Content) != 2 {
+		t.Fatalf("tool results message has %d blocks, want 2", len(got[2].Content))
+	}
+	second := got[2].Content[1].(*types.ContentBlockMemberToolResult).Value
+	if aws.ToString(second.ToolUseId) != "b" || second.Status != types.ToolResultStatusError {
+		t.Errorf("second result = %+v, want error result for b", second)
+	}
+}
+
+func TestEnhanceError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantCode int
+	}{
+		{name: "throttled", status: http.StatusTooManyRequests, wantCode: 429},
+		{name: "access denied", status: http.StatusForbidden, wantCode: 403},
+		{name: "unavailable", status: http.StatusServiceUnavailable, wantCode: 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{model: "anthropic.claude-sonnet-4-20250514-v1:0"}
+			raw := &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: tt.status, Header: http.Header{}}},
+				Err:      errors.New("service error"),
+			}}
+
+			err := c.enhanceError(raw)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *APIError", err)
+			}
+			if apiErr.Code != tt.wantCode {
+				t.Errorf("Code = %d, want %d", apiErr.Code, tt.wantCode)
+			}
+		})
+	}
+}
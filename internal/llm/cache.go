@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CachingAdapter wraps an LLMAdapter and reuses Chat responses for identical
+// requests (system prompt, messages, tools, and generation settings) for a
+// short TTL.
+// It suits repeated classification prompts such as those of the background
+// refresh loop. Streaming and embeddings pass through uncached.
+type CachingAdapter struct {
+	adapter    LLMAdapter
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+}
+
+type cachedResponse struct {
+	key     string
+	resp    ChatResponse
+	expires time.Time
+}
+
+// NewCachingAdapter caches up to maxEntries responses for ttl, evicting the
+// least recently used entry when full
+func NewCachingAdapter(adapter LLMAdapter, ttl time.Duration, maxEntries int) *CachingAdapter {
+	return &CachingAdapter{
+		adapter:    adapter,
+		ttl:        ttl,
+		maxEntries: max(maxEntries, 1),
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Chat implements LLMAdapter, answering from the cache when possible.
+// Cached responses report zero token usage since no tokens were spent.
+func (c *CachingAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	key, err := requestKey(req)
+	if err != nil {
+		return c.adapter.Chat(ctx, req)
+	}
+
+	if resp, ok := c.get(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.adapter.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, resp)
+	return resp, nil
+}
+
+// ChatStream implements LLMAdapter without caching
+func (c *CachingAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	return c.adapter.ChatStream(ctx, req)
+}
+
+// Embed implements LLMAdapter without caching
+func (c *CachingAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	return c.adapter.Embed(ctx, text)
+}
+
+func (c *CachingAdapter) get(key string) (*ChatResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cachedResponse)
+	if c.now().After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	resp := entry.resp
+	resp.ToolCalls = append([]ToolCall(nil), entry.resp.ToolCalls...)
+	resp.Usage = TokenUsage{}
+	return &resp, true
+}
+
+func (c *CachingAdapter) put(key string, resp *ChatResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cachedResponse{key: key, resp: *resp, expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// requestKey hashes the parts of a request that affect the answer. Message
+// timestamps are display-only and left out.
+func requestKey(req ChatRequest) (string, error) {
+	type keyMessage struct {
+		Role         string
+		Content      string
+		ToolCalls    []ToolCall
+		ToolResultID string
+		ToolName     string
+		IsError      bool
+	}
+	messages := make([]keyMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = keyMessage{m.Role, m.Content, m.ToolCalls, m.ToolResultID, m.ToolName, m.IsError}
+	}
+
+	data, err := json.Marshal(struct {
+		SystemPrompt string
+		Messages     []keyMessage
+		Tools        []ToolDefinition
+		MaxTokens    int
+		Temperature  *float64
+		TopP         *float64
+	}{req.SystemPrompt, messages, req.Tools, req.MaxTokens, req.Temperature, req.TopP})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingLLM numbers its responses so cache hits are visible
+type countingLLM struct {
+	calls int
+}
+
+func (c *countingLLM) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	c.calls++
+	return &ChatResponse{
+		Content: req.Messages[len(req.Messages)-1].Content,
+		Usage:   TokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (c *countingLLM) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	return nil, nil
+}
+
+func (c *countingLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+func TestCachingAdapter_Chat(t *testing.T) {
+	mock := &countingLLM{}
+	cache := NewCachingAdapter(mock, time.Minute, 2)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	ask := func(content string, ts time.Time) *ChatResponse {
+		t.Helper()
+		resp, err := cache.Chat(context.Background(), ChatRequest{
+			SystemPrompt: "classify",
+			Messages:     []Message{{Role: "user", Content: content, Timestamp: ts}},
+		})
+		if err != nil {
+			t.Fatalf("Chat() error: %v", err)
+		}
+		return resp
+	}
+
+	first := ask("a", now)
+	if first.Usage.TotalTokens != 15 {
+		t.Errorf("first response usage = %d, want 15", first.Usage.TotalTokens)
+	}
+
+	// Same request with a different timestamp is a hit, with no usage
+	hit := ask("a", now.Add(time.Second))
+	if mock.calls != 1 {
+		t.Errorf("calls = %d after repeat, want 1", mock.calls)
+	}
+	if hit.Content != "a" || hit.Usage.TotalTokens != 0 {
+		t.Errorf("cached response = %+v, want content a and zero usage", hit)
+	}
+
+	// Evicts the least recently used entry beyond maxEntries
+	ask("b", now)
+	ask("a", now) // a is now most recently used
+	ask("c", now) // evicts b
+	calls := mock.calls
+	ask("a", now)
+	if mock.calls != calls {
+		t.Error("recently used entry was evicted")
+	}
+	ask("b", now)
+	if mock.calls != calls+1 {
+		t.Error("least recently used entry was not evicted")
+	}
+
+	// Expired entries are refetched
+	now = now.Add(2 * time.Minute)
+	calls = mock.calls
+	ask("a", now)
+	if mock.calls != calls+1 {
+		t.Error("expired entry was served from the cache")
+	}
+}
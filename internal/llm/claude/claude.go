@@ -3,9 +3,11 @@ package claude
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -20,9 +22,10 @@ type Client struct {
 
 // APIError represents an error from the Claude API with structured details
 type APIError struct {
-	Code    int    // HTTP status code (inferred from error message)
-	Message string // Raw API error message
-	Err     error  // Enhanced error with user-friendly message
+	Code            int           // HTTP status code (from the SDK error, or inferred from the message)
+	Message         string        // Raw API error message
+	Err             error         // Enhanced error with user-friendly message
+	RetryAfterDelay time.Duration // Retry-After from the response, if any
 }
 
 func (e *APIError) Error() string {
@@ -43,6 +46,11 @@ func (e *APIError) APIMessage() string {
 	return e.Message
 }
 
+// RetryAfter returns how long the API asked us to wait before retrying
+func (e *APIError) RetryAfter() time.Duration {
+	return e.RetryAfterDelay
+}
+
 // NewClient creates a new Claude client
 // API key is read from ANTHROPIC_API_KEY environment variable
 func NewClient(model string) (*Client, error) {
@@ -51,7 +59,9 @@ func NewClient(model string) (*Client, error) {
 		return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
 	}
 
-	client := anthropic.NewClient(option.WithAPIKey(apiKey))
+	// Retries are handled by llm.RetryAdapter so they follow the configured
+	// policy for every provider
+	client := anthropic.NewClient(option.WithAPIKey(apiKey), option.WithMaxRetries(0))
 
 	if model == "" {
 		model = "claude-sonnet-4-20250514"
@@ -65,31 +75,22 @@ func NewClient(model string) (*Client, error) {
 
 // Chat sends a chat request and returns a response
 func (c *Client) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
-	// Build messages for Anthropic API
-	messages := make([]anthropic.MessageParam, 0, len(req.Messages))
-	for _, msg := range req.Messages {
-		if msg.Role == "assistant" {
-			var blocks []anthropic.ContentBlockParamUnion
-			if msg.Content != "" {
-				blocks = append(blocks, anthropic.NewTextBlock(msg.Content))
-			}
-			// Include tool_use blocks so Claude sees its own tool calls in history
-			for _, tc := range msg.ToolCalls {
-				blocks = append(blocks, anthropic.NewToolUseBlock(tc.ID, tc.Args, tc.Name))
-			}
-			if len(blocks) > 0 {
-				messages = append(messages, anthropic.NewAssistantMessage(blocks...))
-			}
-		} else if msg.ToolResultID != "" {
-			// Tool result message - must use tool_result block referencing the tool call ID
-			messages = append(messages, anthropic.NewUserMessage(
-				anthropic.NewToolResultBlock(msg.ToolResultID, msg.Content, msg.IsError),
-			))
-		} else {
-			messages = append(messages, anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content)))
-		}
+	params := c.buildParams(req)
+
+	// Make the API call
+	response, err := c.client.Messages.New(ctx, params)
+	if err != nil {
+		return nil, c.enhanceError(err)
 	}
 
+	// Convert response
+	return c.convertResponse(response), nil
+}
+
+// buildParams converts a ChatRequest to Anthropic message parameters
+func (c *Client) buildParams(req llm.ChatRequest) anthropic.MessageNewParams {
+	messages := convertMessages(req.Messages)
+
 	// Build tool definitions if provided
 	var tools []anthropic.ToolUnionParam
 	if len(req.Tools) > 0 {
@@ -112,28 +113,33 @@ func (c *Client) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatRespon
 		Messages:  messages,
 	}
 
-	// Add system prompt if provided
+	if req.Temperature != nil {
+		params.Temperature = anthropic.Float(*req.Temperature)
+	}
+	if req.TopP != nil {
+		params.TopP = anthropic.Float(*req.TopP)
+	}
+
+	// Add system prompt if provided. Cache breakpoints on the system prompt
+	// and the last tool let later turns read both from the prompt cache
+	// instead of paying full input price (prompts under the model's minimum
+	// cacheable length are simply not cached).
 	if req.SystemPrompt != "" {
 		params.System = []anthropic.TextBlockParam{
 			{
-				Text: req.SystemPrompt,
+				Text:         req.SystemPrompt,
+				CacheControl: anthropic.NewCacheControlEphemeralParam(),
 			},
 		}
 	}
 
 	// Add tools if provided
 	if len(tools) > 0 {
+		tools[len(tools)-1].OfTool.CacheControl = anthropic.NewCacheControlEphemeralParam()
 		params.Tools = tools
 	}
 
-	// Make the API call
-	response, err := c.client.Messages.New(ctx, params)
-	if err != nil {
-		return nil, c.enhanceError(err)
-	}
-
-	// Convert response
-	return c.convertResponse(response), nil
+	return params
 }
 
 // ChatStream is not yet implemented
@@ -146,6 +152,47 @@ func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
 	return nil, fmt.Errorf("embeddings not yet implemented")
 }
 
+// convertMessages builds the Anthropic conversation. Consecutive tool
+// results are sent as one user message, since Claude expects every
+// tool_result for a turn's tool_use blocks in the message that follows it.
+func convertMessages(msgs []llm.Message) []anthropic.MessageParam {
+	messages := make([]anthropic.MessageParam, 0, len(msgs))
+	var results []anthropic.ContentBlockParamUnion
+	flushResults := func() {
+		if len(results) > 0 {
+			messages = append(messages, anthropic.NewUserMessage(results...))
+			results = nil
+		}
+	}
+
+	for _, msg := range msgs {
+		if msg.ToolResultID != "" {
+			results = append(results, anthropic.NewToolResultBlock(msg.ToolResultID, msg.Content, msg.IsError))
+			continue
+		}
+		flushResults()
+
+		if msg.Role == "assistant" {
+			var blocks []anthropic.ContentBlockParamUnion
+			if msg.Content != "" {
+				blocks = append(blocks, anthropic.NewTextBlock(msg.Content))
+			}
+			// Include tool_use blocks so Claude sees its own tool calls in history
+			for _, tc := range msg.ToolCalls {
+				blocks = append(blocks, anthropic.NewToolUseBlock(tc.ID, tc.Args, tc.Name))
+			}
+			if len(blocks) > 0 {
+				messages = append(messages, anthropic.NewAssistantMessage(blocks...))
+			}
+		} else {
+			messages = append(messages, anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Content)))
+		}
+	}
+	flushResults()
+
+	return messages
+}
+
 // convertToolDefinition converts our tool definition to Anthropic format
 func (c *Client) convertToolDefinition(tool llm.ToolDefinition) anthropic.ToolUnionParam {
 	// Convert properties
@@ -173,9 +220,11 @@ func (c *Client) convertToolDefinition(tool llm.ToolDefinition) anthropic.ToolUn
 func (c *Client) convertResponse(response *anthropic.Message) *llm.ChatResponse {
 	result := &llm.ChatResponse{
 		Usage: llm.TokenUsage{
-			InputTokens:  int(response.Usage.InputTokens),
-			OutputTokens: int(response.Usage.OutputTokens),
-			TotalTokens:  int(response.Usage.InputTokens + response.Usage.OutputTokens),
+			InputTokens:      int(response.Usage.InputTokens),
+			OutputTokens:     int(response.Usage.OutputTokens),
+			TotalTokens:      int(response.Usage.InputTokens + response.Usage.OutputTokens),
+			CacheReadTokens:  int(response.Usage.CacheReadInputTokens),
+			CacheWriteTokens: int(response.Usage.CacheCreationInputTokens),
 		},
 	}
 
@@ -210,11 +259,23 @@ func (c *Client) convertResponse(response *anthropic.Message) *llm.ChatResponse
 func (c *Client) enhanceError(err error) error {
 	errMsg := err.Error()
 	var code int
+	var retryAfter time.Duration
 	var enhancedErr error
 
-	// Check for common error patterns and infer status code
-	if strings.Contains(errMsg, "404") || strings.Contains(errMsg, "not found") {
-		code = 404
+	// Prefer the status code from the SDK; fall back to the message text
+	var sdkErr *anthropic.Error
+	if errors.As(err, &sdkErr) {
+		code = sdkErr.StatusCode
+		if sdkErr.Response != nil {
+			retryAfter = llm.ParseRetryAfter(sdkErr.Response.Header.Get("Retry-After"), time.Now())
+		}
+	}
+	if code == 0 {
+		code = inferStatusCode(errMsg)
+	}
+
+	switch {
+	case code == 404:
 		modelName := c.model
 		suggestions := []string{
 			"claude-sonnet-4-20250514",
@@ -231,23 +292,39 @@ func (c *Client) enhanceError(err error) error {
 
 		enhancedErr = fmt.Errorf("model '%s' not found for Claude provider.%s\n\nValid Claude models include:\n  - %s\n\nUpdate your config file or use:\n  export JOE_LLM_MODEL=claude-sonnet-4-20250514",
 			modelName, hint, strings.Join(suggestions, "\n  - "))
-	} else if strings.Contains(errMsg, "401") || strings.Contains(errMsg, "authentication") {
-		code = 401
+	case code == 401:
 		enhancedErr = fmt.Errorf("authentication failed with Claude API.\n\nCheck that your ANTHROPIC_API_KEY is valid:\n  %s", errMsg)
-	} else if strings.Contains(errMsg, "429") || strings.Contains(errMsg, "rate limit") {
-		code = 429
+	case code == 429:
 		enhancedErr = fmt.Errorf("rate limit exceeded for Claude API.\n\nPlease wait a moment before retrying:\n  %s", errMsg)
-	} else if strings.Contains(errMsg, "400") || strings.Contains(errMsg, "invalid") {
-		code = 400
+	case code == 400:
 		enhancedErr = fmt.Errorf("invalid request to Claude API.\n\nThis might indicate unsupported parameters:\n  %s", errMsg)
-	} else {
+	case code >= 500:
+		enhancedErr = fmt.Errorf("Claude API is unavailable (%d): %w", code, err)
+	default:
 		// Return original error with context if we can't enhance it
 		return fmt.Errorf("Claude API call failed: %w", err)
 	}
 
 	return &APIError{
-		Code:    code,
-		Message: errMsg,
-		Err:     enhancedErr,
+		Code:            code,
+		Message:         errMsg,
+		Err:             enhancedErr,
+		RetryAfterDelay: retryAfter,
+	}
+}
+
+// inferStatusCode guesses the HTTP status from an error message for errors
+// that don't come from the SDK
+func inferStatusCode(errMsg string) int {
+	switch {
+	case strings.Contains(errMsg, "404") || strings.Contains(errMsg, "not found"):
+		return 404
+	case strings.Contains(errMsg, "401") || strings.Contains(errMsg, "authentication"):
+		return 401
+	case strings.Contains(errMsg, "429") || strings.Contains(errMsg, "rate limit"):
+		return 429
+	case strings.Contains(errMsg, "400") || strings.Contains(errMsg, "invalid"):
+		return 400
 	}
+	return 0
 }
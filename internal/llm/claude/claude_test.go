@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/jaimegago/joe/internal/llm"
 )
 
@@ -161,3 +162,108 @@ func TestConvertResponse(t *testing.T) {
 		t.Errorf("Client model = %v, want %v", client.model, expectedModel)
 	}
 }
+
+func TestConvertMessages(t *testing.T) {
+	msgs := []llm.Message{
+		{Role: "user", Content: "check both files"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{
+			{ID: "call-1", Name: "read_file", Args: map[string]any{"path": "a"}},
+			{ID: "call-2", Name: "read_file", Args: map[string]any{"path": "b"}},
+		}},
+		{Role: "user", Content: `{"content":"a"}`, ToolResultID: "call-1", ToolName: "read_file"},
+		{Role: "user", Content: "Error executing tool: not found", ToolResultID: "call-2", ToolName: "read_file", IsError: true},
+		{Role: "assistant", Content: "b is missing"},
+	}
+
+	got := convertMessages(msgs)
+
+	wantRoles := []string{"user", "assistant", "user", "assistant"}
+	if len(got) != len(wantRoles) {
+		t.Fatalf("convertMessages() returned %d messages, want %d", len(got), len(wantRoles))
+	}
+	for i, role := range wantRoles {
+		if string(got[i].Role) != role {
+			t.Errorf("message %d role = %s, want %s", i, got[i].Role, role)
+		}
+	}
+
+	// Both tool results share one user message, in order
+	results := got[2].Content
+	if len(results) != 2 {
+		t.Fatalf("tool result message has %d blocks, want 2", len(results))
+	}
+	for i, id := range []string{"call-1", "call-2"} {
+		block := results[i].OfToolResult
+		if block == nil {
+			t.Fatalf("block %d is not a tool_result", i)
+		}
+		if block.ToolUseID != id {
+			t.Errorf("block %d tool_use_id = %s, want %s", i, block.ToolUseID, id)
+		}
+	}
+	if !results[1].OfToolResult.IsError.Value {
+		t.Error("failed tool result is not marked is_error")
+	}
+}
+
+func TestBuildParams_PromptCaching(t *testing.T) {
+	client := &Client{model: "claude-sonnet-4-20250514"}
+	params := client.buildParams(llm.ChatRequest{
+		SystemPrompt: "You are Joe",
+		Messages:     []llm.Message{{Role: "user", Content: "hi"}},
+		Tools: []llm.ToolDefinition{
+			{Name: "echo", Parameters: llm.ParameterSchema{Type: "object"}},
+			{Name: "read_file", Parameters: llm.ParameterSchema{Type: "object"}},
+		},
+	})
+
+	if len(params.System) != 1 || params.System[0].CacheControl.Type != "ephemeral" {
+		t.Errorf("system prompt has no cache breakpoint: %+v", params.System)
+	}
+	if params.Tools[0].OfTool.CacheControl.Type != "" {
+		t.Error("first tool has a cache breakpoint, want only the last")
+	}
+	if params.Tools[1].OfTool.CacheControl.Type != "ephemeral" {
+		t.Error("last tool has no cache breakpoint")
+	}
+}
+
+func TestBuildParams_GenerationSettings(t *testing.T) {
+	client := &Client{model: "claude-sonnet-4-20250514"}
+	temp, topP := 0.1, 0.5
+
+	params := client.buildParams(llm.ChatRequest{
+		Messages:    []llm.Message{{Role: "user", Content: "hi"}},
+		MaxTokens:   1024,
+		Temperature: &temp,
+		TopP:        &topP,
+	})
+	if params.MaxTokens != 1024 {
+		t.Errorf("MaxTokens = %d, want 1024", params.MaxTokens)
+	}
+	if params.Temperature.Value != 0.1 || params.TopP.Value != 0.5 {
+		t.Errorf("Temperature = %v, TopP = %v; want 0.1, 0.5", params.Temperature.Value, params.TopP.Value)
+	}
+
+	params = client.buildParams(llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+	if params.Temperature.Valid() || params.TopP.Valid() {
+		t.Error("unset Temperature/TopP should be omitted")
+	}
+}
+
+func TestConvertResponse_CacheUsage(t *testing.T) {
+	client := &Client{}
+	resp := client.convertResponse(&anthropic.Message{
+		Usage: anthropic.Usage{
+			InputTokens:              20,
+			OutputTokens:             10,
+			CacheReadInputTokens:     1500,
+			CacheCreationInputTokens: 300,
+		},
+	})
+
+	want := llm.TokenUsage{InputTokens: 20, OutputTokens: 10, TotalTokens: 30, CacheReadTokens: 1500, CacheWriteTokens: 300}
+	if resp.Usage != want {
+		t.Errorf("Usage = %+v, want %+v", resp.Usage, want)
+	}
+}
@@ -0,0 +1,200 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Fallback is a model to switch to when the active one keeps failing.
+// Connect is only called when the fallback is needed, so its credentials
+// aren't required up front. An adapter it returns that is an io.Closer is
+// closed once it is switched away from.
+type Fallback struct {
+	Name    string
+	Connect func(ctx context.Context) (LLMAdapter, error)
+}
+
+// FailoverOption configures optional FailoverAdapter behavior
+type FailoverOption func(*FailoverAdapter)
+
+// WithFailoverNotify calls fn after every switch, e.g. to tell the user
+func WithFailoverNotify(fn func(from, to string, err error)) FailoverOption {
+	return func(f *FailoverAdapter) {
+		f.notify = fn
+	}
+}
+
+// FailoverAdapter sends requests to the active model and, when it fails
+// with a rate limit, quota, or server error (after any retries), switches to
+// the next fallback in order. The switch lasts until ResetFailover, which
+// the agent calls at the start of each run.
+type FailoverAdapter struct {
+	logger      *slog.Logger
+	notify      func(from, to string, err error)
+	primary     LLMAdapter
+	primaryName string
+	all         []Fallback // Every fallback, for ResetFailover
+
+	// switching is held while a fallback connects, so concurrent failures
+	// switch once, without blocking requests on mu meanwhile
+	switching sync.Mutex
+
+	mu         sync.Mutex
+	active     LLMAdapter
+	activeName string
+	fallbacks  []Fallback // not yet used, in order
+}
+
+// NewFailoverAdapter creates a FailoverAdapter starting on primary
+func NewFailoverAdapter(primary LLMAdapter, primaryName string, fallbacks []Fallback, logger *slog.Logger, opts ...FailoverOption) *FailoverAdapter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	f := &FailoverAdapter{
+		logger:      logger,
+		primary:     primary,
+		primaryName: primaryName,
+		all:         fallbacks,
+		active:      primary,
+		activeName:  primaryName,
+		fallbacks:   fallbacks,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// ActiveModel returns the name of the model currently receiving requests
+func (f *FailoverAdapter) ActiveModel() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.activeName
+}
+
+// Chat implements LLMAdapter with failover
+func (f *FailoverAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	for {
+		adapter, name := f.current()
+		resp, err := adapter.Chat(ctx, req)
+		if err == nil || !IsRetryable(err) || !f.failover(ctx, name, err) {
+			return resp, err
+		}
+	}
+}
+
+// ChatStream implements LLMAdapter; only starting the stream fails over
+func (f *FailoverAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	for {
+		adapter, name := f.current()
+		ch, err := adapter.ChatStream(ctx, req)
+		if err == nil || !IsRetryable(err) || !f.failover(ctx, name, err) {
+			return ch, err
+		}
+	}
+}
+
+// Embed implements LLMAdapter with failover
+func (f *FailoverAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	for {
+		adapter, name := f.current()
+		embedding, err := adapter.Embed(ctx, text)
+		if err == nil || !IsRetryable(err) || !f.failover(ctx, name, err) {
+			return embedding, err
+		}
+	}
+}
+
+// ResetFailover switches back to the primary model, with every fallback
+// available again, so a failover lasts for the rest of one run
+func (f *FailoverAdapter) ResetFailover() {
+	f.switching.Lock()
+	defer f.switching.Unlock()
+
+	f.mu.Lock()
+	old, oldName := f.active, f.activeName
+	f.active, f.activeName = f.primary, f.primaryName
+	f.fallbacks = f.all
+	f.mu.Unlock()
+
+	if oldName != f.primaryName {
+		f.logger.Info("LLM failover reset", "from", oldName, "to", f.primaryName)
+		f.close(old, oldName)
+	}
+}
+
+// Close closes the active fallback, if any. The primary belongs to the
+// caller.
+func (f *FailoverAdapter) Close() error {
+	f.switching.Lock()
+	defer f.switching.Unlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.activeName == f.primaryName {
+		return nil
+	}
+	if closer, ok := f.active.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (f *FailoverAdapter) current() (LLMAdapter, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.active, f.activeName
+}
+
+// failover switches away from failed and reports whether there is a new
+// model to try. Fallbacks that can't connect are skipped.
+func (f *FailoverAdapter) failover(ctx context.Context, failed string, cause error) bool {
+	f.switching.Lock()
+	defer f.switching.Unlock()
+
+	for {
+		f.mu.Lock()
+		if f.activeName != failed {
+			// Another request already switched away from the failed model
+			f.mu.Unlock()
+			return true
+		}
+		if len(f.fallbacks) == 0 {
+			f.mu.Unlock()
+			return false
+		}
+		next := f.fallbacks[0]
+		f.fallbacks = f.fallbacks[1:]
+		f.mu.Unlock()
+
+		adapter, err := next.Connect(ctx)
+		if err != nil {
+			f.logger.Warn("skipping LLM fallback", "model", next.Name, "error", err)
+			continue
+		}
+
+		f.logger.Warn("LLM failover", "from", failed, "to", next.Name, "error", cause)
+		f.mu.Lock()
+		old := f.active
+		f.active, f.activeName = adapter, next.Name
+		f.mu.Unlock()
+		if failed != f.primaryName {
+			f.close(old, failed)
+		}
+		if f.notify != nil {
+			f.notify(failed, next.Name, cause)
+		}
+		return true
+	}
+}
+
+// close closes a fallback adapter that was switched away from
+func (f *FailoverAdapter) close(adapter LLMAdapter, name string) {
+	if closer, ok := adapter.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			f.logger.Warn("failed to close LLM fallback", "model", name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// namedLLM answers with its name, or fails with err
+type namedLLM struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (n *namedLLM) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	n.calls++
+	if n.err != nil {
+		return nil, n.err
+	}
+	return &ChatResponse{Content: n.name}, nil
+}
+
+func (n *namedLLM) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	return nil, n.err
+}
+
+func (n *namedLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, n.err
+}
+
+func fallbackTo(adapter LLMAdapter, name string, connectErr error) Fallback {
+	return Fallback{
+		Name: name,
+		Connect: func(ctx context.Context) (LLMAdapter, error) {
+			if connectErr != nil {
+				return nil, connectErr
+			}
+			return adapter, nil
+		},
+	}
+}
+
+func TestFailoverAdapter_Chat(t *testing.T) {
+	quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name       string
+		primaryErr error
+		fallbacks  func() []Fallback
+		want       string
+		wantActive string
+		wantErr    bool
+	}{
+		{
+			name:       "primary works",
+			fallbacks:  func() []Fallback { return []Fallback{fallbackTo(&namedLLM{name: "gemini"}, "gemini", nil)} },
+			want:       "claude",
+			wantActive: "claude",
+		},
+		{
+			name:       "quota exhausted switches",
+			primaryErr: &testAPIError{code: 429},
+			fallbacks:  func() []Fallback { return []Fallback{fallbackTo(&namedLLM{name: "gemini"}, "gemini", nil)} },
+			want:       "gemini",
+			wantActive: "gemini",
+		},
+		{
+			name:       "skips fallback that cannot connect",
+			primaryErr: &testAPIError{code: 503},
+			fallbacks: func() []Fallback {
+				return []Fallback{
+					fallbackTo(nil, "gemini", errors.New("GEMINI_API_KEY not set")),
+					fallbackTo(&namedLLM{name: "haiku"}, "haiku", nil),
+				}
+			},
+			want:       "haiku",
+			wantActive: "haiku",
+		},
+		{
+			name:       "failing fallback moves on",
+			primaryErr: &testAPIError{code: 500},
+			fallbacks: func() []Fallback {
+				return []Fallback{
+					fallbackTo(&namedLLM{name: "gemini", err: &testAPIError{code: 429}}, "gemini", nil),
+					fallbackTo(&namedLLM{name: "haiku"}, "haiku", nil),
+				}
+			},
+			want:       "haiku",
+			wantActive: "haiku",
+		},
+		{
+			name:       "client errors do not fail over",
+			primaryErr: &testAPIError{code: 400},
+			fallbacks:  func() []Fallback { return []Fallback{fallbackTo(&namedLLM{name: "gemini"}, "gemini", nil)} },
+			wantActive: "claude",
+			wantErr:    true,
+		},
+		{
+			name:       "no fallbacks left",
+			primaryErr: &testAPIError{code: 429},
+			fallbacks:  func() []Fallback { return nil },
+			wantActive: "claude",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &namedLLM{name: "claude", err: tt.primaryErr}
+			var notified []string
+			adapter := NewFailoverAdapter(primary, "claude", tt.fallbacks(), quiet,
+				WithFailoverNotify(func(from, to string, err error) {
+					notified = append(notified, from+"->"+to)
+				}))
+
+			resp, err := adapter.Chat(context.Background(), ChatRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Chat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && resp.Content != tt.want {
+				t.Errorf("Chat() answered by %q, want %q", resp.Content, tt.want)
+			}
+			if got := adapter.ActiveModel(); got != tt.wantActive {
+				t.Errorf("ActiveModel() = %q, want %q", got, tt.wantActive)
+			}
+			if tt.wantActive != "claude" && len(notified) == 0 {
+				t.Error("failover was not notified")
+			}
+		})
+	}
+}
+
+func TestFailoverAdapter_StaysSwitched(t *testing.T) {
+	primary := &namedLLM{name: "claude", err: &testAPIError{code: 429}}
+	fallback := &namedLLM{name: "gemini"}
+	adapter := NewFailoverAdapter(primary, "claude", []Fallback{fallbackTo(fallback, "gemini", nil)}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for range 3 {
+		if _, err := adapter.Chat(context.Background(), ChatRequest{}); err != nil {
+			t.Fatalf("Chat() error: %v", err)
+		}
+	}
+	if primary.calls != 1 || fallback.calls != 3 {
+		t.Errorf("primary calls = %d, fallback calls = %d, want 1 and 3", primary.calls, fallback.calls)
+	}
+}
+
+// closingLLM is a namedLLM recording whether it was closed
+type closingLLM struct {
+	namedLLM
+	closed bool
+}
+
+func (c *closingLLM) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestFailoverAdapter_ResetFailover(t *testing.T) {
+	primary := &namedLLM{name: "claude", err: &testAPIError{code: 429}}
+	var connected []*closingLLM
+	fallback := Fallback{
+		Name: "gemini",
+		Connect: func(ctx context.Context) (LLMAdapter, error) {
+			connected = append(connected, &closingLLM{namedLLM: namedLLM{name: "gemini"}})
+			return connected[len(connected)-1], nil
+		},
+	}
+	adapter := NewFailoverAdapter(primary, "claude", []Fallback{fallback}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if _, err := adapter.Chat(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if got := adapter.ActiveModel(); got != "gemini" {
+		t.Fatalf("ActiveModel() = %q, want gemini", got)
+	}
+
+	// The next run starts on the primary again, and the fallback is closed
+	adapter.ResetFailover()
+	if got := adapter.ActiveModel(); got != "claude" {
+		t.Errorf("ActiveModel() after reset = %q, want claude", got)
+	}
+	if !connected[0].closed {
+		t.Error("fallback not closed after reset")
+	}
+	primary.err = nil
+	if resp, err := adapter.Chat(context.Background(), ChatRequest{}); err != nil || resp.Content != "claude" {
+		t.Fatalf("Chat() after reset = %v, %v; want claude", resp, err)
+	}
+
+	// Every fallback is available to the next failover
+	primary.err = &testAPIError{code: 503}
+	if resp, err := adapter.Chat(context.Background(), ChatRequest{}); err != nil || resp.Content != "gemini" {
+		t.Fatalf("Chat() failing again = %v, %v; want gemini", resp, err)
+	}
+	if len(connected) != 2 {
+		t.Errorf("fallback connected %d times, want 2", len(connected))
+	}
+	if err := adapter.Close(); err != nil || !connected[1].closed {
+		t.Errorf("Close() = %v, closed = %v; want the active fallback closed", err, connected[1].closed)
+	}
+}
@@ -0,0 +1,269 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// ErrRequestBlocked is returned instead of sending a request that matches
+// a blocking filter rule
+var ErrRequestBlocked = errors.New("request blocked by LLM filter")
+
+// FilterAction is what a FilterRule does with content it matches
+type FilterAction string
+
+const (
+	FilterRedact FilterAction = "redact" // Replace the match with [REDACTED:<rule>]
+	FilterBlock  FilterAction = "block"  // Refuse to send the request
+)
+
+// FilterRule matches content by regular expression or by IP address range
+type FilterRule struct {
+	Name    string
+	Pattern *regexp.Regexp // nil for a range rule
+	Prefix  netip.Prefix   // addresses in this range match; unset for a pattern rule
+	Action  FilterAction
+}
+
+// NewFilterRule creates a rule from a pattern or a CIDR range, exactly one
+// of which must be set. An empty action means redact.
+func NewFilterRule(name, pattern, cidr, action string) (FilterRule, error) {
+	rule := FilterRule{Name: name, Action: FilterAction(action)}
+	if rule.Action == "" {
+		rule.Action = FilterRedact
+	}
+	if rule.Action != FilterRedact && rule.Action != FilterBlock {
+		return FilterRule{}, fmt.Errorf("filter rule %q: unknown action %q (want redact or block)", name, action)
+	}
+
+	switch {
+	case pattern != "" && cidr != "":
+		return FilterRule{}, fmt.Errorf("filter rule %q: set pattern or cidr, not both", name)
+	case pattern != "":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return FilterRule{}, fmt.Errorf("filter rule %q: invalid pattern: %w", name, err)
+		}
+		rule.Pattern = re
+	case cidr != "":
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return FilterRule{}, fmt.Errorf("filter rule %q: invalid cidr: %w", name, err)
+		}
+		rule.Prefix = prefix.Masked()
+	default:
+		return FilterRule{}, fmt.Errorf("filter rule %q: pattern or cidr is required", name)
+	}
+	return rule, nil
+}
+
+// ipCandidate finds text that may be an IPv4 or IPv6 address
+var ipCandidate = regexp.MustCompile(`(?i)\b(?:\d{1,3}\.){3}\d{1,3}\b|[0-9a-f]*(?::[0-9a-f]*){2,7}`)
+
+// find returns the [start, end) offsets of the rule's matches in s
+func (r FilterRule) find(s string) [][]int {
+	if r.Pattern != nil {
+		return r.Pattern.FindAllStringIndex(s, -1)
+	}
+	var matches [][]int
+	for _, loc := range ipCandidate.FindAllStringIndex(s, -1) {
+		addr, err := netip.ParseAddr(s[loc[0]:loc[1]])
+		if err == nil && r.Prefix.Contains(addr.Unmap()) {
+			matches = append(matches, loc)
+		}
+	}
+	return matches
+}
+
+// FilterMatch is content a rule matched in a request
+type FilterMatch struct {
+	Rule   string
+	Action FilterAction
+	Where  string // e.g. "system prompt" or "message 3"
+	Text   string // what matched
+}
+
+// FilterOption configures optional FilterAdapter behavior
+type FilterOption func(*FilterAdapter)
+
+// WithFilterDryRun sends requests unchanged; matches are only reported
+func WithFilterDryRun() FilterOption {
+	return func(f *FilterAdapter) {
+		f.dryRun = true
+	}
+}
+
+// WithFilterReport calls fn with the matches in each request that has any,
+// e.g. to show the user what was (or in a dry run, would be) redacted
+func WithFilterReport(fn func(matches []FilterMatch, dryRun bool)) FilterOption {
+	return func(f *FilterAdapter) {
+		f.report = fn
+	}
+}
+
+// FilterAdapter wraps an LLMAdapter and applies filter rules to the system
+// prompt, messages, and tool call arguments of each request before it
+// leaves the machine: matches of redact rules are masked, and a match of a
+// block rule fails the request with ErrRequestBlocked.
+type FilterAdapter struct {
+	adapter LLMAdapter
+	rules   []FilterRule
+	logger  *slog.Logger
+	dryRun  bool
+	report  func(matches []FilterMatch, dryRun bool)
+}
+
+// NewFilterAdapter filters requests sent through adapter with rules
+func NewFilterAdapter(adapter LLMAdapter, rules []FilterRule, logger *slog.Logger, opts ...FilterOption) *FilterAdapter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	f := &FilterAdapter{adapter: adapter, rules: rules, logger: logger}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Chat implements LLMAdapter
+func (f *FilterAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	req, err := f.filterRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return f.adapter.Chat(ctx, req)
+}
+
+// ChatStream implements LLMAdapter
+func (f *FilterAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	req, err := f.filterRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return f.adapter.ChatStream(ctx, req)
+}
+
+// Embed implements LLMAdapter
+func (f *FilterAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	var matches []FilterMatch
+	text = f.filter(text, "embedding input", &matches)
+	if err := f.apply(matches); err != nil {
+		return nil, err
+	}
+	return f.adapter.Embed(ctx, text)
+}
+
+// filterRequest returns req with redact rules applied, or the original
+// request in a dry run. The caller's messages are never modified.
+func (f *FilterAdapter) filterRequest(req ChatRequest) (ChatRequest, error) {
+	var matches []FilterMatch
+	filtered := req
+	filtered.SystemPrompt = f.filter(req.SystemPrompt, "system prompt", &matches)
+	filtered.Messages = make([]Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		where := fmt.Sprintf("message %d", i+1)
+		msg.Content = f.filter(msg.Content, where, &matches)
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]ToolCall, len(msg.ToolCalls))
+			for j, tc := range msg.ToolCalls {
+				if tc.Args != nil {
+					tc.Args = f.filterValue(tc.Args, where+" "+tc.Name+" args", &matches).(map[string]any)
+				}
+				calls[j] = tc
+			}
+			msg.ToolCalls = calls
+		}
+		filtered.Messages[i] = msg
+	}
+
+	if err := f.apply(matches); err != nil {
+		return ChatRequest{}, err
+	}
+	if f.dryRun {
+		return req, nil
+	}
+	return filtered, nil
+}
+
+// apply reports and logs matches, and returns ErrRequestBlocked for a
+// match of a block rule unless this is a dry run
+func (f *FilterAdapter) apply(matches []FilterMatch) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	if f.report != nil {
+		f.report(matches, f.dryRun)
+	}
+	if f.dryRun {
+		for _, m := range matches {
+			f.logger.Info("LLM filter dry run", "rule", m.Rule, "action", m.Action, "where", m.Where, "text", m.Text)
+		}
+		return nil
+	}
+	// Outside a dry run, the matched text is what must not leave the
+	// machine, so it isn't logged either
+	for _, m := range matches {
+		f.logger.Debug("LLM request filtered", "rule", m.Rule, "action", m.Action, "where", m.Where)
+	}
+	for _, m := range matches {
+		if m.Action == FilterBlock {
+			return fmt.Errorf("%w: %s matches rule %q", ErrRequestBlocked, m.Where, m.Rule)
+		}
+	}
+	return nil
+}
+
+// filter returns s with the matches of redact rules masked, adding every
+// match to matches
+func (f *FilterAdapter) filter(s, where string, matches *[]FilterMatch) string {
+	if s == "" {
+		return s
+	}
+	for _, rule := range f.rules {
+		locs := rule.find(s)
+		if len(locs) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, loc := range locs {
+			*matches = append(*matches, FilterMatch{Rule: rule.Name, Action: rule.Action, Where: where, Text: s[loc[0]:loc[1]]})
+			b.WriteString(s[last:loc[0]])
+			if rule.Action == FilterRedact {
+				b.WriteString("[REDACTED:" + rule.Name + "]")
+			} else {
+				b.WriteString(s[loc[0]:loc[1]])
+			}
+			last = loc[1]
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// filterValue applies filter to the strings in a decoded JSON value
+func (f *FilterAdapter) filterValue(v any, where string, matches *[]FilterMatch) any {
+	switch v := v.(type) {
+	case string:
+		return f.filter(v, where, matches)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = f.filterValue(val, where, matches)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = f.filterValue(val, where, matches)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func testFilterRules(t *testing.T) []FilterRule {
+	t.Helper()
+	var rules []FilterRule
+	for _, spec := range []struct{ name, pattern, cidr, action string }{
+		{name: "customer-id", pattern: `cust-[0-9]{6}`},
+		{name: "internal-hosts", pattern: `[a-z0-9-]+\.corp\.example\.com`},
+		{name: "office-network", cidr: "10.20.0.0/16"},
+		{name: "payroll", pattern: `(?i)payroll`, action: "block"},
+	} {
+		rule, err := NewFilterRule(spec.name, spec.pattern, spec.cidr, spec.action)
+		if err != nil {
+			t.Fatalf("NewFilterRule(%s) error = %v", spec.name, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func TestFilterAdapter_Chat(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         ChatRequest
+		wantErr     error
+		wantContent string
+		wantMatches int
+	}{
+		{
+			name:        "nothing to filter",
+			req:         ChatRequest{Messages: []Message{{Role: "user", Content: "why is api-0 failing?"}}},
+			wantContent: "why is api-0 failing?",
+		},
+		{
+			name:        "patterns redacted",
+			req:         ChatRequest{Messages: []Message{{Role: "user", Content: "cust-123456 can't reach db1.corp.example.com"}}},
+			wantContent: "[REDACTED:customer-id] can't reach [REDACTED:internal-hosts]",
+			wantMatches: 2,
+		},
+		{
+			name:        "addresses in range redacted",
+			req:         ChatRequest{Messages: []Message{{Role: "user", Content: "10.20.3.4 and 10.30.3.4 time out"}}},
+			wantContent: "[REDACTED:office-network] and 10.30.3.4 time out",
+			wantMatches: 1,
+		},
+		{
+			name:        "block rule",
+			req:         ChatRequest{Messages: []Message{{Role: "user", Content: "summarize the payroll export"}}},
+			wantErr:     ErrRequestBlocked,
+			wantMatches: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &recordingLLM{}
+			var reported []FilterMatch
+			adapter := NewFilterAdapter(mock, testFilterRules(t), nil,
+				WithFilterReport(func(matches []FilterMatch, dryRun bool) { reported = matches }))
+
+			_, err := adapter.Chat(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Chat() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(reported) != tt.wantMatches {
+				t.Errorf("reported %d matches, want %d: %+v", len(reported), tt.wantMatches, reported)
+			}
+			if tt.wantErr != nil {
+				if mock.req.Messages != nil {
+					t.Error("blocked request was sent")
+				}
+				return
+			}
+			if got := mock.req.Messages[0].Content; got != tt.wantContent {
+				t.Errorf("sent %q, want %q", got, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestFilterAdapter_ToolCallArgs(t *testing.T) {
+	mock := &recordingLLM{}
+	adapter := NewFilterAdapter(mock, testFilterRules(t), nil)
+	args := map[string]any{"command": "ping db1.corp.example.com"}
+	req := ChatRequest{Messages: []Message{{Role: "assistant", ToolCalls: []ToolCall{{ID: "c1", Name: "run_command", Args: args}}}}}
+
+	if _, err := adapter.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got := mock.req.Messages[0].ToolCalls[0].Args["command"]; got != "ping [REDACTED:internal-hosts]" {
+		t.Errorf("sent args %q, want the host redacted", got)
+	}
+	if args["command"] != "ping db1.corp.example.com" {
+		t.Error("Chat() modified the caller's args")
+	}
+}
+
+func TestFilterAdapter_DryRun(t *testing.T) {
+	mock := &recordingLLM{}
+	var reported []FilterMatch
+	adapter := NewFilterAdapter(mock, testFilterRules(t), nil, WithFilterDryRun(),
+		WithFilterReport(func(matches []FilterMatch, dryRun bool) {
+			if !dryRun {
+				t.Error("report says this is not a dry run")
+			}
+			reported = matches
+		}))
+
+	content := "payroll for cust-123456"
+	if _, err := adapter.Chat(context.Background(), ChatRequest{Messages: []Message{{Role: "user", Content: content}}}); err != nil {
+		t.Fatalf("Chat() in a dry run error = %v", err)
+	}
+	if got := mock.req.Messages[0].Content; got != content {
+		t.Errorf("dry run sent %q, want it unchanged", got)
+	}
+	if len(reported) != 2 || reported[0].Text != "cust-123456" && reported[1].Text != "cust-123456" {
+		t.Errorf("reported %+v, want the payroll and customer ID matches", reported)
+	}
+}
+
+func TestNewFilterRule_Invalid(t *testing.T) {
+	tests := []struct {
+		name, pattern, cidr, action string
+	}{
+		{name: "neither"},
+		{name: "both", pattern: "x", cidr: "10.0.0.0/8"},
+		{name: "bad pattern", pattern: "("},
+		{name: "bad cidr", cidr: "10.0.0.0/99"},
+		{name: "bad action", pattern: "x", action: "drop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFilterRule(tt.name, tt.pattern, tt.cidr, tt.action); err == nil {
+				t.Error("NewFilterRule() succeeded, want an error")
+			}
+		})
+	}
+}
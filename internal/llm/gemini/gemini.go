@@ -23,9 +23,10 @@ type Client struct {
 
 // APIError represents an error from the Gemini API with structured details
 type APIError struct {
-	Code    int    // HTTP status code
-	Message string // Raw API error message
-	Err     error  // Enhanced error with user-friendly message
+	Code            int           // HTTP status code
+	Message         string        // Raw API error message
+	Err             error         // Enhanced error with user-friendly message
+	RetryAfterDelay time.Duration // Retry-After header or RetryInfo delay, if any
 }
 
 func (e *APIError) Error() string {
@@ -46,6 +47,11 @@ func (e *APIError) APIMessage() string {
 	return e.Message
 }
 
+// RetryAfter returns how long the API asked us to wait before retrying
+func (e *APIError) RetryAfter() time.Duration {
+	return e.RetryAfterDelay
+}
+
 // NewClient creates a new Gemini client
 // API key is read from GEMINI_API_KEY or GOOGLE_API_KEY environment variable
 func NewClient(ctx context.Context, model string) (*Client, error) {
@@ -80,6 +86,15 @@ func NewClient(ctx context.Context, model string) (*Client, error) {
 // Chat sends a chat request and returns a response
 func (c *Client) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
 	model := c.client.GenerativeModel(c.model)
+	if req.MaxTokens > 0 {
+		model.SetMaxOutputTokens(int32(req.MaxTokens))
+	}
+	if req.Temperature != nil {
+		model.SetTemperature(float32(*req.Temperature))
+	}
+	if req.TopP != nil {
+		model.SetTopP(float32(*req.TopP))
+	}
 
 	// Set system instruction if provided
 	if req.SystemPrompt != "" {
@@ -113,13 +128,32 @@ func (c *Client) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatRespon
 		model.Tools = tools
 	}
 
-	// Build conversation history
-	var history []*genai.Content
-	var lastParts []genai.Part
-	var lastRole string
+	history, lastParts := convertMessages(req.Messages)
+
+	// Start chat session with history
+	chat := model.StartChat()
+	chat.History = history
+
+	// Send the last message
+	resp, err := chat.SendMessage(ctx, lastParts...)
+	if err != nil {
+		// Add debug info about what we sent
+		debugInfo := fmt.Sprintf("\n\nDebug info:\n- Model: %s\n- System prompt: %v\n- Tools count: %d\n- History messages: %d\n- Last message parts: %d",
+			c.model, req.SystemPrompt != "", len(req.Tools), len(history), len(lastParts))
+		return nil, c.enhanceErrorWithDebug(ctx, err, debugInfo)
+	}
+
+	// Convert response
+	return c.convertResponse(resp), nil
+}
 
-	for i, msg := range req.Messages {
-		// Determine the parts and role for this message
+// convertMessages builds the Gemini history and the parts of the final user
+// turn, which SendMessage sends separately. Consecutive tool results become
+// one turn of FunctionResponse parts, since Gemini expects a response for
+// every call of the previous model turn together.
+func convertMessages(msgs []llm.Message) ([]*genai.Content, []genai.Part) {
+	var contents []*genai.Content
+	for _, msg := range msgs {
 		var parts []genai.Part
 		var role string
 
@@ -138,55 +172,57 @@ func (c *Client) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatRespon
 		} else if msg.ToolResultID != "" {
 			// Tool result message - use FunctionResponse
 			role = "user"
-			var responseData map[string]any
-			if err := json.Unmarshal([]byte(msg.Content), &responseData); err != nil {
-				// If content isn't valid JSON, wrap it
-				responseData = map[string]any{"result": msg.Content}
-			}
 			parts = append(parts, genai.FunctionResponse{
 				Name:     msg.ToolName,
-				Response: responseData,
+				Response: functionResponse(msg),
 			})
+			if n := len(contents); n > 0 && isFunctionResponse(contents[n-1]) {
+				contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+				continue
+			}
 		} else {
 			role = "user"
 			parts = append(parts, genai.Text(msg.Content))
 		}
 
-		// Gemini API wants the last user message separate for SendMessage
-		if i == len(req.Messages)-1 && role == "user" {
-			lastParts = parts
-			lastRole = role
-			break
-		}
-
 		if len(parts) > 0 {
-			history = append(history, &genai.Content{
+			contents = append(contents, &genai.Content{
 				Parts: parts,
 				Role:  role,
 			})
 		}
 	}
 
-	// Start chat session with history
-	chat := model.StartChat()
-	chat.History = history
+	// Gemini API wants the last user message separate for SendMessage
+	if n := len(contents); n > 0 && contents[n-1].Role == "user" {
+		return contents[:n-1], contents[n-1].Parts
+	}
+	return contents, []genai.Part{genai.Text("")}
+}
 
-	// Send the last message
-	if lastParts == nil {
-		lastParts = []genai.Part{genai.Text("")}
-		lastRole = "user"
+// functionResponse converts a tool result to the object Gemini expects.
+// Errors are reported under "error"; results that aren't JSON objects are
+// wrapped under "result".
+func functionResponse(msg llm.Message) map[string]any {
+	if msg.IsError {
+		return map[string]any{"error": msg.Content}
 	}
-	_ = lastRole // role is implicit in SendMessage
-	resp, err := chat.SendMessage(ctx, lastParts...)
-	if err != nil {
-		// Add debug info about what we sent
-		debugInfo := fmt.Sprintf("\n\nDebug info:\n- Model: %s\n- System prompt: %v\n- Tools count: %d\n- History messages: %d\n- Last message parts: %d",
-			c.model, req.SystemPrompt != "", len(req.Tools), len(history), len(lastParts))
-		return nil, c.enhanceErrorWithDebug(ctx, err, debugInfo)
+	var value any
+	if err := json.Unmarshal([]byte(msg.Content), &value); err != nil {
+		return map[string]any{"result": msg.Content}
 	}
+	if obj, ok := value.(map[string]any); ok {
+		return obj
+	}
+	return map[string]any{"result": value}
+}
 
-	// Convert response
-	return c.convertResponse(resp), nil
+func isFunctionResponse(c *genai.Content) bool {
+	if c.Role != "user" || len(c.Parts) == 0 {
+		return false
+	}
+	_, ok := c.Parts[0].(genai.FunctionResponse)
+	return ok
 }
 
 // ChatStream is not yet implemented
@@ -407,9 +443,10 @@ func (c *Client) enhanceErrorWithDebug(ctx context.Context, err error, debugInfo
 		}
 
 		return &APIError{
-			Code:    apiErr.Code,
-			Message: apiErr.Message,
-			Err:     enhancedErr,
+			Code:            apiErr.Code,
+			Message:         apiErr.Message,
+			Err:             enhancedErr,
+			RetryAfterDelay: retryAfter(apiErr),
 		}
 	}
 
@@ -417,6 +454,26 @@ func (c *Client) enhanceErrorWithDebug(ctx context.Context, err error, debugInfo
 	return fmt.Errorf("gemini API call failed: %w", err)
 }
 
+// retryAfter reads the Retry-After header, or the retryDelay of a
+// google.rpc.RetryInfo detail that Gemini sends with quota errors
+func retryAfter(apiErr *googleapi.Error) time.Duration {
+	if d := llm.ParseRetryAfter(apiErr.Header.Get("Retry-After"), time.Now()); d > 0 {
+		return d
+	}
+	for _, detail := range apiErr.Details {
+		info, ok := detail.(map[string]any)
+		if !ok || !strings.HasSuffix(fmt.Sprint(info["@type"]), "google.rpc.RetryInfo") {
+			continue
+		}
+		if delay, ok := info["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(delay); err == nil {
+				return d
+			}
+		}
+	}
+	return 0
+}
+
 // listAvailableModels fetches the list of available models from Gemini API
 func (c *Client) listAvailableModels(ctx context.Context) []string {
 	// Create a context with timeout to avoid blocking too long
@@ -2,10 +2,15 @@ package gemini
 
 import (
 	"context"
+	"net/http"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/google/generative-ai-go/genai"
 	"github.com/jaimegago/joe/internal/llm"
+	"google.golang.org/api/googleapi"
 )
 
 func TestNewClient(t *testing.T) {
@@ -188,3 +193,78 @@ func TestClose(t *testing.T) {
 		t.Errorf("Close() returned error: %v", err)
 	}
 }
+
+func TestConvertMessages(t *testing.T) {
+	msgs := []llm.Message{
+		{Role: "user", Content: "check both files"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{
+			{ID: "read_file", Name: "read_file", Args: map[string]any{"path": "a"}},
+			{ID: "read_file", Name: "read_file", Args: map[string]any{"path": "b"}},
+		}},
+		{Role: "user", Content: `{"content":"a"}`, ToolResultID: "read_file", ToolName: "read_file"},
+		{Role: "user", Content: "Error executing tool: not found", ToolResultID: "read_file", ToolName: "read_file", IsError: true},
+	}
+
+	history, last := convertMessages(msgs)
+
+	if len(history) != 2 || history[0].Role != "user" || history[1].Role != "model" {
+		t.Fatalf("history = %+v, want user and model turns", history)
+	}
+
+	// Both tool results are sent together as the final turn
+	if len(last) != 2 {
+		t.Fatalf("last turn has %d parts, want 2", len(last))
+	}
+	first, ok := last[0].(genai.FunctionResponse)
+	if !ok || first.Response["content"] != "a" {
+		t.Errorf("first part = %#v, want read_file response with content", last[0])
+	}
+	second, ok := last[1].(genai.FunctionResponse)
+	if !ok || second.Response["error"] != "Error executing tool: not found" {
+		t.Errorf("second part = %#v, want error response", last[1])
+	}
+}
+
+func TestFunctionResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]any
+	}{
+		{"object", `{"ok":true}`, map[string]any{"ok": true}},
+		{"array", `[1,2]`, map[string]any{"result": []any{float64(1), float64(2)}}},
+		{"plain text", `hello`, map[string]any{"result": "hello"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := functionResponse(llm.Message{Content: tt.content})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("functionResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiErr *googleapi.Error
+		want   time.Duration
+	}{
+		{"none", &googleapi.Error{Code: 429}, 0},
+		{"header", &googleapi.Error{Code: 503, Header: http.Header{"Retry-After": {"5"}}}, 5 * time.Second},
+		{"retry info", &googleapi.Error{Code: 429, Details: []any{
+			map[string]any{"@type": "type.googleapis.com/google.rpc.QuotaFailure"},
+			map[string]any{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "37s"},
+		}}, 37 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.apiErr); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+)
+
+// VertexClient implements the LLMAdapter interface for Gemini models served
+// by Vertex AI. It authenticates with Application Default Credentials
+// (gcloud auth application-default login, a service account key, or the
+// metadata server) instead of an API key.
+type VertexClient struct {
+	httpClient *http.Client
+	endpoint   string // generateContent URL for the model
+	model      string
+}
+
+// NewVertexClient creates a Vertex AI client for model in the given GCP
+// project and location (e.g. "us-central1" or "global").
+func NewVertexClient(ctx context.Context, model, project, location string) (*VertexClient, error) {
+	if project == "" {
+		return nil, fmt.Errorf("a GCP project is required for Vertex AI")
+	}
+	if location == "" {
+		location = "us-central1"
+	}
+	if model == "" {
+		model = "gemini-2.5-flash"
+	}
+
+	hc, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Google Application Default Credentials (run 'gcloud auth application-default login'): %w", err)
+	}
+
+	return &VertexClient{
+		httpClient: hc,
+		endpoint:   vertexEndpoint(project, location, model),
+		model:      model,
+	}, nil
+}
+
+// vertexEndpoint returns the generateContent URL for a publisher model
+func vertexEndpoint(project, location, model string) string {
+	host := location + "-aiplatform.googleapis.com"
+	if location == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	return fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/google/models/%s:generateContent",
+		host, project, location, model)
+}
+
+// Chat sends a chat request and returns a response
+func (c *VertexClient) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	body, err := json.Marshal(buildVertexRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("Vertex AI request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return nil, c.enhanceError(err)
+	}
+
+	var out vertexResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode Vertex AI response: %w", err)
+	}
+	return convertVertexResponse(&out), nil
+}
+
+// ChatStream is not yet implemented
+func (c *VertexClient) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, fmt.Errorf("streaming not yet implemented")
+}
+
+// Embed is not yet implemented
+func (c *VertexClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, fmt.Errorf("embeddings not yet implemented")
+}
+
+// Wire types for the Vertex AI generateContent API
+
+type vertexRequest struct {
+	Contents          []vertexContent         `json:"contents"`
+	SystemInstruction *vertexContent          `json:"systemInstruction,omitempty"`
+	Tools             []vertexTool            `json:"tools,omitempty"`
+	GenerationConfig  *vertexGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type vertexContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []vertexPart `json:"parts"`
+}
+
+type vertexPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *vertexFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *vertexFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type vertexFunctionCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+}
+
+type vertexFunctionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type vertexTool struct {
+	FunctionDeclarations []vertexFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type vertexFunctionDeclaration struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type vertexGenerationConfig struct {
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+}
+
+type vertexResponse struct {
+	Candidates []struct {
+		Content vertexContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// buildVertexRequest converts a ChatRequest to a generateContent request.
+// Like the API key client, consecutive tool results are sent as one turn of
+// functionResponse parts.
+func buildVertexRequest(req llm.ChatRequest) vertexRequest {
+	out := vertexRequest{}
+	if req.SystemPrompt != "" {
+		out.SystemInstruction = &vertexContent{Parts: []vertexPart{{Text: req.SystemPrompt}}}
+	}
+	if req.MaxTokens > 0 || req.Temperature != nil || req.TopP != nil {
+		out.GenerationConfig = &vertexGenerationConfig{
+			MaxOutputTokens: req.MaxTokens,
+			Temperature:     req.Temperature,
+			TopP:            req.TopP,
+		}
+	}
+
+	for _, msg := range req.Messages {
+		switch {
+		case msg.Role == "assistant":
+			content := vertexContent{Role: "model"}
+			if msg.Content != "" {
+				content.Parts = append(content.Parts, vertexPart{Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				args := tc.Args
+				if args == nil {
+					args = map[string]any{}
+				}
+				content.Parts = append(content.Parts, vertexPart{FunctionCall: &vertexFunctionCall{Name: tc.Name, Args: args}})
+			}
+			if len(content.Parts) > 0 {
+				out.Contents = append(out.Contents, content)
+			}
+		case msg.ToolResultID != "":
+			part := vertexPart{FunctionResponse: &vertexFunctionResponse{Name: msg.ToolName, Response: functionResponse(msg)}}
+			if n := len(out.Contents); n > 0 && out.Contents[n-1].Role == "user" && out.Contents[n-1].Parts[0].FunctionResponse != nil {
+				out.Contents[n-1].Parts = append(out.Contents[n-1].Parts, part)
+				continue
+			}
+			out.Contents = append(out.Contents, vertexContent{Role: "user", Parts: []vertexPart{part}})
+		default:
+			out.Contents = append(out.Contents, vertexContent{Role: "user", Parts: []vertexPart{{Text: msg.Content}}})
+		}
+	}
+
+	if len(req.Tools) > 0 {
+		decls := make([]vertexFunctionDeclaration, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			decls = append(decls, convertVertexTool(tool))
+		}
+		out.Tools = []vertexTool{{FunctionDeclarations: decls}}
+	}
+	return out
+}
+
+// convertVertexTool converts a tool definition to a function
+// declaration. Vertex uses the OpenAPI schema subset with upper-case types.
+func convertVertexTool(tool llm.ToolDefinition) vertexFunctionDeclaration {
+	properties := make(map[string]any, len(tool.Parameters.Properties))
+	for name, prop := range tool.Parameters.Properties {
+		properties[name] = vertexSchema(prop)
+	}
+	params := map[string]any{
+		"type":       "OBJECT",
+		"properties": properties,
+	}
+	if len(tool.Parameters.Required) > 0 {
+		params["required"] = tool.Parameters.Required
+	}
+
+	// Gemini requires non-empty descriptions
+	desc := tool.Description
+	if desc == "" {
+		desc = tool.Name
+	}
+	return vertexFunctionDeclaration{Name: tool.Name, Description: desc, Parameters: params}
+}
+
+// vertexSchema converts a property to a Vertex schema
+func vertexSchema(prop llm.Property) map[string]any {
+	typ := strings.ToUpper(prop.Type)
+	switch typ {
+	case "STRING", "NUMBER", "INTEGER", "BOOLEAN", "ARRAY", "OBJECT":
+	default:
+		typ = "STRING"
+	}
+	schema := map[string]any{"type": typ}
+	if prop.Description != "" {
+		schema["description"] = prop.Description
+	}
+	if prop.Items != nil {
+		schema["items"] = vertexSchema(*prop.Items)
+	}
+	return schema
+}
+
+// convertVertexResponse converts a generateContent response to our format
+func convertVertexResponse(resp *vertexResponse) *llm.ChatResponse {
+	result := &llm.ChatResponse{
+		Usage: llm.TokenUsage{
+			InputTokens:  resp.UsageMetadata.PromptTokenCount,
+			OutputTokens: resp.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:  resp.UsageMetadata.TotalTokenCount,
+		},
+	}
+	if len(resp.Candidates) == 0 {
+		return result
+	}
+
+	var text []string
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if part.FunctionCall != nil {
+			result.ToolCalls = append(result.ToolCalls, llm.ToolCall{
+				ID:   part.FunctionCall.Name, // Vertex has no call IDs; results are matched by name
+				Name: part.FunctionCall.Name,
+				Args: part.FunctionCall.Args,
+			})
+			continue
+		}
+		if part.Text != "" {
+			text = append(text, part.Text)
+		}
+	}
+	result.Content = strings.Join(text, "")
+	return result
+}
+
+// enhanceError provides better error messages for common API errors
+// Returns *APIError with structured details for logging
+func (c *VertexClient) enhanceError(err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return fmt.Errorf("Vertex AI call failed: %w", err)
+	}
+
+	var enhancedErr error
+	switch apiErr.Code {
+	case 401, 403:
+		enhancedErr = fmt.Errorf("permission denied by Vertex AI: %s\n\nCheck that your Application Default Credentials can use Vertex AI (roles/aiplatform.user) in this project.", apiErr.Message)
+	case 404:
+		enhancedErr = fmt.Errorf("model '%s' not found on Vertex AI in this location: %s", c.model, apiErr.Message)
+	case 429:
+		enhancedErr = fmt.Errorf("Vertex AI quota exceeded for model '%s': %s", c.model, apiErr.Message)
+	case 400:
+		enhancedErr = fmt.Errorf("invalid request to Vertex AI: %s", apiErr.Message)
+	default:
+		enhancedErr = fmt.Errorf("Vertex AI error (%d): %s", apiErr.Code, apiErr.Message)
+	}
+
+	return &APIError{
+		Code:            apiErr.Code,
+		Message:         apiErr.Message,
+		Err:             enhancedErr,
+		RetryAfterDelay: retryAfter(apiErr),
+	}
+}
@@ -0,0 +1,119 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+func TestVertexEndpoint(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"us-central1", "https://us-central1-aiplatform.googleapis.com/v1/projects/p/locations/us-central1/publishers/google/models/gemini-2.5-flash:generateContent"},
+		{"global", "https://aiplatform.googleapis.com/v1/projects/p/locations/global/publishers/google/models/gemini-2.5-flash:generateContent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			if got := vertexEndpoint("p", tt.location, "gemini-2.5-flash"); got != tt.want {
+				t.Errorf("vertexEndpoint() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildVertexRequest(t *testing.T) {
+	req := buildVertexRequest(llm.ChatRequest{
+		SystemPrompt: "be brief",
+		MaxTokens:    512,
+		Messages: []llm.Message{
+			{Role: "user", Content: "check both"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "a", Name: "local_git_status"}, {ID: "b", Name: "read_file"}}},
+			{Role: "user", Content: `{"clean":true}`, ToolResultID: "a", ToolName: "local_git_status"},
+			{Role: "user", Content: "no such file", ToolResultID: "b", ToolName: "read_file", IsError: true},
+		},
+		Tools: []llm.ToolDefinition{{
+			Name:       "read_file",
+			Parameters: llm.ParameterSchema{Properties: map[string]llm.Property{"path": {Type: "string"}}},
+		}},
+	})
+
+	if len(req.Contents) != 3 {
+		t.Fatalf("got %d contents, want 3", len(req.Contents))
+	}
+	results := req.Contents[2]
+	if results.Role != "user" || len(results.Parts) != 2 {
+		t.Fatalf("tool results = %+v, want one user turn with 2 parts", results)
+	}
+	if got := results.Parts[1].FunctionResponse.Response["error"]; got != "no such file" {
+		t.Errorf("error response = %v, want 'no such file'", got)
+	}
+	if req.GenerationConfig.MaxOutputTokens != 512 {
+		t.Errorf("MaxOutputTokens = %d, want 512", req.GenerationConfig.MaxOutputTokens)
+	}
+
+	decl := req.Tools[0].FunctionDeclarations[0]
+	if decl.Description != "read_file" {
+		t.Errorf("empty description should fall back to the tool name, got %q", decl.Description)
+	}
+	prop := decl.Parameters["properties"].(map[string]any)["path"].(map[string]any)
+	if prop["type"] != "STRING" {
+		t.Errorf("property type = %v, want STRING", prop["type"])
+	}
+}
+
+func TestVertexChat(t *testing.T) {
+	var got vertexRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{
+			"candidates": [{"content": {"role": "model", "parts": [
+				{"functionCall": {"name": "read_file", "args": {"path": "go.mod"}}}
+			]}}],
+			"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 4, "totalTokenCount": 14}
+		}`))
+	}))
+	defer srv.Close()
+
+	c := &VertexClient{httpClient: srv.Client(), endpoint: srv.URL, model: "gemini-2.5-flash"}
+	resp, err := c.Chat(context.Background(), llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(got.Contents) != 1 || got.Contents[0].Parts[0].Text != "hi" {
+		t.Errorf("request contents = %+v", got.Contents)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "read_file" || resp.ToolCalls[0].Args["path"] != "go.mod" {
+		t.Errorf("ToolCalls = %+v", resp.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 14 {
+		t.Errorf("TotalTokens = %d, want 14", resp.Usage.TotalTokens)
+	}
+}
+
+func TestVertexChat_QuotaError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"code": 429, "message": "Quota exceeded", "status": "RESOURCE_EXHAUSTED",
+			"details": [{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "3s"}]}}`))
+	}))
+	defer srv.Close()
+
+	c := &VertexClient{httpClient: srv.Client(), endpoint: srv.URL, model: "gemini-2.5-flash"}
+	_, err := c.Chat(context.Background(), llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want *APIError", err)
+	}
+	if apiErr.Code != 429 || apiErr.RetryAfter() != 3*time.Second {
+		t.Errorf("APIError code = %d, retry after = %v; want 429 after 3s", apiErr.Code, apiErr.RetryAfter())
+	}
+}
@@ -34,6 +34,7 @@ type InstrumentedAdapter struct {
 	totalErrors       atomic.Int64
 	totalInputTokens  atomic.Int64
 	totalOutputTokens atomic.Int64
+	totalLatency      atomic.Int64 // Nanoseconds
 
 	// OTel metrics
 	requestCounter     metric.Int64Counter
@@ -138,6 +139,7 @@ func (i *InstrumentedAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatR
 	// Make the actual API call
 	resp, err := i.adapter.Chat(ctx, req)
 	duration := time.Since(start)
+	i.totalLatency.Add(int64(duration))
 
 	// Record OTel latency
 	latencyAttrs := append(attrs, attribute.Bool("error", err != nil))
@@ -178,6 +180,15 @@ func (i *InstrumentedAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatR
 	safeAddCounter(ctx, i.inputTokenCounter, int64(resp.Usage.InputTokens), attrs...)
 	safeAddCounter(ctx, i.outputTokenCounter, int64(resp.Usage.OutputTokens), attrs...)
 
+	if resp.Usage.CacheReadTokens > 0 || resp.Usage.CacheWriteTokens > 0 {
+		i.logger.Debug("LLM prompt cache",
+			"provider", i.provider,
+			"model", i.model,
+			"cache_read_tokens", resp.Usage.CacheReadTokens,
+			"cache_write_tokens", resp.Usage.CacheWriteTokens,
+		)
+	}
+
 	return resp, nil
 }
 
@@ -196,6 +207,7 @@ func (i *InstrumentedAdapter) ChatStream(ctx context.Context, req ChatRequest) (
 
 	stream, err := i.adapter.ChatStream(ctx, req)
 	duration := time.Since(start)
+	i.totalLatency.Add(int64(duration))
 
 	latencyAttrs := append(attrs, attribute.Bool("error", err != nil))
 	safeRecordHistogram(ctx, i.latencyHistogram, float64(duration.Milliseconds()), latencyAttrs...)
@@ -230,6 +242,7 @@ func (i *InstrumentedAdapter) Embed(ctx context.Context, text string) ([]float32
 
 	embedding, err := i.adapter.Embed(ctx, text)
 	duration := time.Since(start)
+	i.totalLatency.Add(int64(duration))
 
 	latencyAttrs := append(attrs, attribute.Bool("error", err != nil))
 	safeRecordHistogram(ctx, i.latencyHistogram, float64(duration.Milliseconds()), latencyAttrs...)
@@ -256,6 +269,35 @@ type Stats struct {
 	TotalInputTokens  int64
 	TotalOutputTokens int64
 	TotalTokens       int64
+	TotalLatency      time.Duration // Summed over all calls, failed ones included
+}
+
+// ErrorRate is the fraction of calls that failed, 0 without calls
+func (s Stats) ErrorRate() float64 {
+	if s.TotalCalls == 0 {
+		return 0
+	}
+	return float64(s.TotalErrors) / float64(s.TotalCalls)
+}
+
+// AverageLatency is the mean time a call took, 0 without calls
+func (s Stats) AverageLatency() time.Duration {
+	if s.TotalCalls == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.TotalCalls)
+}
+
+// Add returns the sum of s and o
+func (s Stats) Add(o Stats) Stats {
+	return Stats{
+		TotalCalls:        s.TotalCalls + o.TotalCalls,
+		TotalErrors:       s.TotalErrors + o.TotalErrors,
+		TotalInputTokens:  s.TotalInputTokens + o.TotalInputTokens,
+		TotalOutputTokens: s.TotalOutputTokens + o.TotalOutputTokens,
+		TotalTokens:       s.TotalTokens + o.TotalTokens,
+		TotalLatency:      s.TotalLatency + o.TotalLatency,
+	}
 }
 
 // GetStats returns the current instrumentation statistics
@@ -269,5 +311,6 @@ func (i *InstrumentedAdapter) GetStats() Stats {
 		TotalInputTokens:  input,
 		TotalOutputTokens: output,
 		TotalTokens:       input + output,
+		TotalLatency:      time.Duration(i.totalLatency.Load()),
 	}
 }
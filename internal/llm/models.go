@@ -0,0 +1,109 @@
+package llm
+
+import "strings"
+
+// ModelInfo describes what a model can do
+type ModelInfo struct {
+	Model         string // Provider model name, as passed to LookupModel
+	ContextWindow int    // Input tokens the model accepts; 0 if unknown
+	Tools         bool   // Supports tool (function) calling
+	Streaming     bool   // Supports streamed responses
+	MaxTools      int    // Tool definitions a request may carry; 0 if unlimited
+}
+
+// openAIMaxTools is the most tools the OpenAI API accepts per request
+const openAIMaxTools = 128
+
+// unknownModel is assumed for models missing from the table: no known
+// limit, and the features every supported provider offers
+var unknownModel = ModelInfo{Tools: true, Streaming: true}
+
+// knownModels maps model name prefixes to their capabilities. The longest
+// matching prefix wins, so specific versions can override their family.
+var knownModels = map[string]ModelInfo{
+	// Anthropic
+	"claude-": {ContextWindow: 200_000, Tools: true, Streaming: true},
+
+	// Google
+	"gemini-":        {ContextWindow: 1_048_576, Tools: true, Streaming: true},
+	"gemini-1.0":     {ContextWindow: 32_768, Tools: true, Streaming: true},
+	"gemini-1.5-pro": {ContextWindow: 2_097_152, Tools: true, Streaming: true},
+	"gemini-pro":     {ContextWindow: 32_768, Tools: true, Streaming: true},
+	"gemma":          {ContextWindow: 8_192, Tools: false, Streaming: true},
+	"gemma3":         {ContextWindow: 131_072, Tools: false, Streaming: true},
+
+	// OpenAI
+	"gpt-3.5-turbo": {ContextWindow: 16_385, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"gpt-4":         {ContextWindow: 8_192, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"gpt-4-turbo":   {ContextWindow: 128_000, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"gpt-4o":        {ContextWindow: 128_000, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"gpt-4.1":       {ContextWindow: 1_047_576, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"gpt-5":         {ContextWindow: 400_000, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"o1":            {ContextWindow: 200_000, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"o1-mini":       {ContextWindow: 128_000, Tools: false, Streaming: true},
+	"o1-preview":    {ContextWindow: 128_000, Tools: false, Streaming: true},
+	"o3":            {ContextWindow: 200_000, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+	"o4-mini":       {ContextWindow: 200_000, Tools: true, Streaming: true, MaxTools: openAIMaxTools},
+
+	// Open-weight models, as served by Ollama, vLLM, or Bedrock
+	"llama3":        {ContextWindow: 8_192, Tools: false, Streaming: true},
+	"llama3.1":      {ContextWindow: 131_072, Tools: true, Streaming: true},
+	"llama3-1":      {ContextWindow: 131_072, Tools: true, Streaming: true},
+	"llama3.2":      {ContextWindow: 131_072, Tools: true, Streaming: true},
+	"llama3-2":      {ContextWindow: 131_072, Tools: true, Streaming: true},
+	"llama3.3":      {ContextWindow: 131_072, Tools: true, Streaming: true},
+	"llama3-3":      {ContextWindow: 131_072, Tools: true, Streaming: true},
+	"mistral":       {ContextWindow: 32_768, Tools: true, Streaming: true},
+	"mistral-large": {ContextWindow: 128_000, Tools: true, Streaming: true},
+	"qwen2.5":       {ContextWindow: 32_768, Tools: true, Streaming: true},
+	"qwen3":         {ContextWindow: 40_960, Tools: true, Streaming: true},
+
+	// Amazon
+	"nova-lite":  {ContextWindow: 300_000, Tools: true, Streaming: true},
+	"nova-micro": {ContextWindow: 128_000, Tools: true, Streaming: true},
+	"nova-pro":   {ContextWindow: 300_000, Tools: true, Streaming: true},
+	"titan-text": {ContextWindow: 8_192, Tools: false, Streaming: true},
+}
+
+// modelNamePrefixes are stripped before lookup: Bedrock cross-region
+// inference profiles and vendor namespaces
+var modelNamePrefixes = []string{
+	"us.", "eu.", "apac.", "global.",
+	"anthropic.", "meta.", "amazon.", "mistral.",
+}
+
+// LookupModel returns the capabilities of a model by its provider model
+// name, e.g. "claude-sonnet-4-20250514", "models/gemini-2.0-flash",
+// "us.anthropic.claude-3-5-haiku-20241022-v1:0", or "llama3.1:8b". The
+// second result is false for unknown models, which get no context limit
+// and are assumed to support tools and streaming.
+func LookupModel(model string) (ModelInfo, bool) {
+	name := normalizeModelName(model)
+	best := ""
+	for prefix := range knownModels {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		info := unknownModel
+		info.Model = model
+		return info, false
+	}
+	info := knownModels[best]
+	info.Model = model
+	return info, true
+}
+
+// normalizeModelName lowercases a model name and strips any path and
+// provider namespace, leaving the name the tables are keyed by
+func normalizeModelName(model string) string {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, prefix := range modelNamePrefixes {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return name
+}
@@ -0,0 +1,38 @@
+package llm
+
+import "testing"
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		model     string
+		wantOK    bool
+		wantLimit int
+		wantTools bool
+	}{
+		{model: "claude-sonnet-4-20250514", wantOK: true, wantLimit: 200_000, wantTools: true},
+		{model: "us.anthropic.claude-3-5-haiku-20241022-v1:0", wantOK: true, wantLimit: 200_000, wantTools: true},
+		{model: "models/gemini-2.0-flash", wantOK: true, wantLimit: 1_048_576, wantTools: true},
+		{model: "gemini-1.5-pro-002", wantOK: true, wantLimit: 2_097_152, wantTools: true},
+		{model: "gpt-4o-mini", wantOK: true, wantLimit: 128_000, wantTools: true},
+		{model: "gpt-4", wantOK: true, wantLimit: 8_192, wantTools: true},
+		{model: "o1-mini", wantOK: true, wantLimit: 128_000, wantTools: false},
+		{model: "llama3.1:8b", wantOK: true, wantLimit: 131_072, wantTools: true},
+		{model: "meta-llama/Llama-3.1-70B-Instruct", wantOK: false, wantTools: true},
+		{model: "my-finetune", wantOK: false, wantTools: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			info, ok := LookupModel(tt.model)
+			if ok != tt.wantOK {
+				t.Errorf("LookupModel() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if info.ContextWindow != tt.wantLimit {
+				t.Errorf("ContextWindow = %d, want %d", info.ContextWindow, tt.wantLimit)
+			}
+			if info.Tools != tt.wantTools {
+				t.Errorf("Tools = %v, want %v", info.Tools, tt.wantTools)
+			}
+		})
+	}
+}
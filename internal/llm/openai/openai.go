@@ -0,0 +1,379 @@
+// Package openai implements the LLMAdapter interface for any endpoint that
+// speaks the OpenAI chat completions API: OpenAI itself, Azure OpenAI, and
+// self-hosted or proxied servers such as vLLM and LiteLLM.
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// Client implements the LLMAdapter interface against a chat completions endpoint
+type Client struct {
+	baseURL    string
+	model      string
+	apiKey     string
+	apiVersion string
+	httpClient *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey sets the key sent with each request. Without one, requests are
+// sent unauthenticated (common for local vLLM servers).
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithAPIVersion selects Azure OpenAI mode: the version is sent as the
+// api-version query parameter and the key in the api-key header.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// APIError represents an error response from the endpoint
+type APIError struct {
+	Code            int           // HTTP status code
+	Message         string        // Error message from the response body
+	Err             error         // Enhanced error with user-friendly message
+	RetryAfterDelay time.Duration // Retry-After from the response, if any
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// APICode returns the HTTP status code from the API
+func (e *APIError) APICode() int {
+	return e.Code
+}
+
+// APIMessage returns the raw error message from the API
+func (e *APIError) APIMessage() string {
+	return e.Message
+}
+
+// RetryAfter returns how long the API asked us to wait before retrying
+func (e *APIError) RetryAfter() time.Duration {
+	return e.RetryAfterDelay
+}
+
+// NewClient creates a client for the chat completions API under baseURL,
+// e.g. "https://api.openai.com/v1" or "http://localhost:8000/v1". For Azure,
+// baseURL is the deployment URL
+// ("https://<resource>.openai.azure.com/openai/deployments/<deployment>").
+func NewClient(baseURL, model string, opts ...Option) (*Client, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+	}
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		model:      model,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Chat sends a chat request and returns a response
+func (c *Client) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	body, err := json.Marshal(c.buildRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		if c.apiVersion != "" {
+			httpReq.Header.Set("api-key", c.apiKey)
+		} else {
+			httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, c.apiError(resp, respBody)
+	}
+
+	var completion chatResponse
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return convertResponse(&completion)
+}
+
+// ChatStream is not yet implemented
+func (c *Client) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, fmt.Errorf("streaming not yet implemented")
+}
+
+// Embed is not yet implemented
+func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, fmt.Errorf("embeddings not yet implemented")
+}
+
+// endpoint returns the chat completions URL
+func (c *Client) endpoint() string {
+	u := c.baseURL + "/chat/completions"
+	if c.apiVersion != "" {
+		u += "?api-version=" + url.QueryEscape(c.apiVersion)
+	}
+	return u
+}
+
+// Wire types for the chat completions API
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Tools       []chatTool    `json:"tools,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+}
+
+type chatMessage struct {
+	Role       string         `json:"role"`
+	Content    *string        `json:"content"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+type chatToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function chatFunctionCall `json:"function"`
+}
+
+type chatFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// buildRequest converts a ChatRequest to the chat completions format
+func (c *Client) buildRequest(req llm.ChatRequest) chatRequest {
+	out := chatRequest{
+		Model:       c.model,
+		Messages:    convertMessages(req.SystemPrompt, req.Messages),
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+	}
+	for _, tool := range req.Tools {
+		out.Tools = append(out.Tools, convertToolDefinition(tool))
+	}
+	return out
+}
+
+// convertMessages builds the conversation. Tool results become "tool" role
+// messages answering the assistant's tool_calls by ID.
+func convertMessages(systemPrompt string, msgs []llm.Message) []chatMessage {
+	var out []chatMessage
+	if systemPrompt != "" {
+		out = append(out, chatMessage{Role: "system", Content: &systemPrompt})
+	}
+
+	for _, msg := range msgs {
+		content := msg.Content
+		switch {
+		case msg.ToolResultID != "":
+			out = append(out, chatMessage{Role: "tool", Content: &content, ToolCallID: msg.ToolResultID})
+		case msg.Role == "assistant":
+			m := chatMessage{Role: "assistant"}
+			if content != "" || len(msg.ToolCalls) == 0 {
+				m.Content = &content
+			}
+			for _, tc := range msg.ToolCalls {
+				args, err := json.Marshal(tc.Args)
+				if err != nil || tc.Args == nil {
+					args = []byte("{}")
+				}
+				m.ToolCalls = append(m.ToolCalls, chatToolCall{
+					ID:       tc.ID,
+					Type:     "function",
+					Function: chatFunctionCall{Name: tc.Name, Arguments: string(args)},
+				})
+			}
+			out = append(out, m)
+		default:
+			out = append(out, chatMessage{Role: "user", Content: &content})
+		}
+	}
+	return out
+}
+
+// convertToolDefinition converts our tool definition to a function tool
+func convertToolDefinition(tool llm.ToolDefinition) chatTool {
+	properties := make(map[string]any, len(tool.Parameters.Properties))
+	for name, prop := range tool.Parameters.Properties {
+		properties[name] = propertySchema(prop)
+	}
+
+	params := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(tool.Parameters.Required) > 0 {
+		params["required"] = tool.Parameters.Required
+	}
+
+	return chatTool{
+		Type: "function",
+		Function: chatFunction{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  params,
+		},
+	}
+}
+
+// propertySchema converts a property to JSON Schema
+func propertySchema(prop llm.Property) map[string]any {
+	schema := map[string]any{"type": prop.Type}
+	if prop.Description != "" {
+		schema["description"] = prop.Description
+	}
+	if prop.Items != nil {
+		schema["items"] = propertySchema(*prop.Items)
+	}
+	return schema
+}
+
+// convertResponse converts a chat completion to our response format
+func convertResponse(resp *chatResponse) (*llm.ChatResponse, error) {
+	result := &llm.ChatResponse{
+		Usage: llm.TokenUsage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+			TotalTokens:  resp.Usage.TotalTokens,
+		},
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("response has no choices")
+	}
+
+	msg := resp.Choices[0].Message
+	if msg.Content != nil {
+		result.Content = *msg.Content
+	}
+	for _, tc := range msg.ToolCalls {
+		var args map[string]any
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool %s: %w", tc.Function.Name, err)
+			}
+		}
+		result.ToolCalls = append(result.ToolCalls, llm.ToolCall{
+			ID:   tc.ID,
+			Name: tc.Function.Name,
+			Args: args,
+		})
+	}
+	return result, nil
+}
+
+// apiError builds an *APIError from a non-2xx response
+func (c *Client) apiError(resp *http.Response, body []byte) error {
+	var parsed errorResponse
+	msg := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Message != "" {
+		msg = parsed.Error.Message
+	}
+
+	var enhancedErr error
+	switch code := resp.StatusCode; {
+	case code == 401 || code == 403:
+		enhancedErr = fmt.Errorf("authentication failed with %s (%d).\n\nCheck the API key environment variable configured for this model:\n  %s", c.baseURL, code, msg)
+	case code == 404:
+		enhancedErr = fmt.Errorf("model or endpoint not found at %s (model %q):\n  %s", c.baseURL, c.model, msg)
+	case code == 429:
+		enhancedErr = fmt.Errorf("rate limit exceeded at %s:\n  %s", c.baseURL, msg)
+	case code >= 500:
+		enhancedErr = fmt.Errorf("%s is unavailable (%d): %s", c.baseURL, code, msg)
+	default:
+		enhancedErr = fmt.Errorf("chat completion request failed (%d): %s", code, msg)
+	}
+
+	return &APIError{
+		Code:            resp.StatusCode,
+		Message:         msg,
+		Err:             enhancedErr,
+		RetryAfterDelay: llm.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
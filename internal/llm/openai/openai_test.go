@@ -0,0 +1,168 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		model   string
+		wantErr bool
+	}{
+		{name: "valid", baseURL: "http://localhost:8000/v1", model: "llama3"},
+		{name: "missing base URL", model: "llama3", wantErr: true},
+		{name: "missing model", baseURL: "http://localhost:8000/v1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.baseURL, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChat(t *testing.T) {
+	var got chatRequest
+	var gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Write([]byte(`{
+			"choices": [{"message": {"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "read_file", "arguments": "{\"path\":\"go.mod\"}"}}
+			]}}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 5, "total_tokens": 17}
+		}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL+"/v1/", "llama3", WithAPIKey("secret"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	resp, err := c.Chat(context.Background(), llm.ChatRequest{
+		SystemPrompt: "be brief",
+		Messages:     []llm.Message{{Role: "user", Content: "what module is this?"}},
+		Tools: []llm.ToolDefinition{{
+			Name:        "read_file",
+			Description: "Read a file",
+			Parameters: llm.ParameterSchema{
+				Type:       "object",
+				Properties: map[string]llm.Property{"path": {Type: "string"}},
+				Required:   []string{"path"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if gotPath != "/v1/chat/completions" {
+		t.Errorf("path = %s, want /v1/chat/completions", gotPath)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want Bearer secret", gotAuth)
+	}
+	if got.Model != "llama3" || len(got.Messages) != 2 || got.Messages[0].Role != "system" {
+		t.Errorf("request = %+v, want model llama3 with system and user messages", got)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Function.Name != "read_file" || got.Tools[0].Function.Description != "Read a file" {
+		t.Errorf("tools = %+v, want read_file with description", got.Tools)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("got %d tool calls, want 1", len(resp.ToolCalls))
+	}
+	if tc := resp.ToolCalls[0]; tc.ID != "call_1" || tc.Name != "read_file" || tc.Args["path"] != "go.mod" {
+		t.Errorf("tool call = %+v", tc)
+	}
+	if resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 5 || resp.Usage.TotalTokens != 17 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestChat_Azure(t *testing.T) {
+	var gotQuery, gotKey, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hi"}}]}`))
+	}))
+	defer srv.Close()
+
+	c, _ := NewClient(srv.URL+"/openai/deployments/gpt4o", "gpt-4o", WithAPIKey("secret"), WithAPIVersion("2024-10-21"))
+	resp, err := c.Chat(context.Background(), llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if resp.Content != "hi" {
+		t.Errorf("Content = %q, want hi", resp.Content)
+	}
+	if gotQuery != "2024-10-21" || gotKey != "secret" || gotAuth != "" {
+		t.Errorf("api-version = %q, api-key = %q, Authorization = %q", gotQuery, gotKey, gotAuth)
+	}
+}
+
+func TestChat_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "slow down"}}`))
+	}))
+	defer srv.Close()
+
+	c, _ := NewClient(srv.URL, "llama3")
+	_, err := c.Chat(context.Background(), llm.ChatRequest{Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want *APIError", err)
+	}
+	if apiErr.Code != 429 || apiErr.Message != "slow down" || apiErr.RetryAfter() != 7*time.Second {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	if !llm.IsRetryable(err) {
+		t.Error("429 should be retryable")
+	}
+}
+
+func TestConvertMessages(t *testing.T) {
+	msgs := []llm.Message{
+		{Role: "user", Content: "list files"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{
+			{ID: "call_1", Name: "run_command", Args: map[string]any{"command": "ls"}},
+		}},
+		{Role: "user", Content: `{"output":"go.mod"}`, ToolResultID: "call_1", ToolName: "run_command"},
+	}
+
+	got := convertMessages("", msgs)
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3", len(got))
+	}
+	if got[1].Content != nil {
+		t.Errorf("assistant content = %q, want null for a tool-call-only turn", *got[1].Content)
+	}
+	if len(got[1].ToolCalls) != 1 || got[1].ToolCalls[0].Function.Arguments != `{"command":"ls"}` {
+		t.Errorf("assistant tool calls = %+v", got[1].ToolCalls)
+	}
+	if got[2].Role != "tool" || got[2].ToolCallID != "call_1" {
+		t.Errorf("tool result = %+v, want role tool answering call_1", got[2])
+	}
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitAdapter wraps an LLMAdapter with a token bucket so background
+// work stays within a number of calls per hour. Calls over the limit wait
+// for the bucket to refill instead of failing; a cancelled context stops
+// the wait.
+type RateLimitAdapter struct {
+	adapter LLMAdapter
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	capacity float64 // Burst size
+	tokens   float64 // Calls available now
+	perSec   float64 // Refill rate
+	last     time.Time
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRateLimitAdapter allows callsPerHour calls through adapter, in bursts
+// of up to a tenth of that, so the budget isn't spent in the first minute
+// of the hour. A limit of 0 or less returns adapter unchanged.
+func NewRateLimitAdapter(adapter LLMAdapter, callsPerHour int, logger *slog.Logger) LLMAdapter {
+	if callsPerHour <= 0 {
+		return adapter
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	burst := math.Max(1, math.Ceil(float64(callsPerHour)/10))
+	return &RateLimitAdapter{
+		adapter:  adapter,
+		logger:   logger,
+		capacity: burst,
+		tokens:   burst,
+		perSec:   float64(callsPerHour) / time.Hour.Seconds(),
+		last:     time.Now(),
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Chat implements LLMAdapter, waiting for the budget first
+func (r *RateLimitAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := r.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.adapter.Chat(ctx, req)
+}
+
+// ChatStream implements LLMAdapter, waiting for the budget first
+func (r *RateLimitAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	if err := r.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.adapter.ChatStream(ctx, req)
+}
+
+// Embed implements LLMAdapter, waiting for the budget first
+func (r *RateLimitAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := r.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.adapter.Embed(ctx, text)
+}
+
+// wait takes a token from the bucket, sleeping until one is available
+func (r *RateLimitAdapter) wait(ctx context.Context) error {
+	for {
+		delay := r.take()
+		if delay == 0 {
+			return nil
+		}
+		r.logger.Info("LLM budget reached, waiting", "delay", delay.Round(time.Second))
+		if err := r.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Exhausted reports whether a call now would have to wait for the budget,
+// so callers can queue work instead of blocking on it
+func (r *RateLimitAdapter) Exhausted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refill()
+	return r.tokens < 1
+}
+
+// take consumes a token and returns 0, or returns how long until the next
+// token is available
+func (r *RateLimitAdapter) take() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refill()
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - r.tokens) / r.perSec * float64(time.Second)))
+}
+
+// refill adds the tokens earned since the last refill. r.mu must be held.
+func (r *RateLimitAdapter) refill() {
+	now := r.now()
+	r.tokens = math.Min(r.capacity, r.tokens+now.Sub(r.last).Seconds()*r.perSec)
+	r.last = now
+}
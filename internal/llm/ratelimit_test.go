@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRateLimitAdapter(t *testing.T) {
+	mock := &flakyLLM{}
+	adapter := NewRateLimitAdapter(mock, 60, slog.New(slog.NewTextHandler(io.Discard, nil))).(*RateLimitAdapter)
+
+	// A fake clock that only moves when the adapter sleeps
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	adapter.last = now
+	adapter.now = func() time.Time { return now }
+	var slept []time.Duration
+	adapter.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	// 60 calls an hour allows bursts of 6, then one call a minute
+	for i := 0; i < 8; i++ {
+		if _, err := adapter.Chat(context.Background(), ChatRequest{}); err != nil {
+			t.Fatalf("call %d error = %v", i+1, err)
+		}
+	}
+	if mock.calls != 8 {
+		t.Errorf("calls = %d, want 8", mock.calls)
+	}
+	if len(slept) != 2 || slept[0] != time.Minute || slept[1] != time.Minute {
+		t.Errorf("slept %v, want a minute before each call past the burst", slept)
+	}
+}
+
+func TestRateLimitAdapter_Exhausted(t *testing.T) {
+	mock := &flakyLLM{}
+	adapter := NewRateLimitAdapter(mock, 10, nil).(*RateLimitAdapter)
+	now := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	adapter.last = now
+	adapter.now = func() time.Time { return now }
+
+	// 10 calls an hour allows a burst of 1, then one call every 6 minutes
+	if adapter.Exhausted() {
+		t.Fatal("Exhausted() = true before any call")
+	}
+	if _, err := adapter.Chat(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if !adapter.Exhausted() {
+		t.Error("Exhausted() = false after the burst")
+	}
+	now = now.Add(6 * time.Minute)
+	if adapter.Exhausted() {
+		t.Error("Exhausted() = true once the bucket refilled")
+	}
+	if mock.calls != 1 {
+		t.Errorf("calls = %d, want Exhausted not to call the LLM", mock.calls)
+	}
+}
+
+func TestRateLimitAdapter_ContextCancelled(t *testing.T) {
+	mock := &flakyLLM{}
+	adapter := NewRateLimitAdapter(mock, 1, nil)
+
+	if _, err := adapter.Chat(context.Background(), ChatRequest{}); err != nil {
+		t.Fatalf("first call error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := adapter.Chat(ctx, ChatRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("over-limit call error = %v, want context.Canceled", err)
+	}
+	if mock.calls != 1 {
+		t.Errorf("calls = %d, want 1", mock.calls)
+	}
+}
+
+func TestNewRateLimitAdapter_Unlimited(t *testing.T) {
+	mock := &flakyLLM{}
+	if got := NewRateLimitAdapter(mock, 0, nil); got != LLMAdapter(mock) {
+		t.Errorf("NewRateLimitAdapter(0) = %T, want the adapter unchanged", got)
+	}
+}
@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterHint is implemented by API errors that carry the provider's
+// Retry-After delay
+type RetryAfterHint interface {
+	RetryAfter() time.Duration
+}
+
+// RetryPolicy controls how transient provider errors are retried
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first; 1 or less disables retries
+	BaseDelay   time.Duration // Backoff before the first retry, doubled for each one after
+	MaxDelay    time.Duration // Cap on backoff; a longer Retry-After gives up instead of waiting
+}
+
+// DefaultRetryPolicy retries twice, starting at one second
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// RetryAdapter wraps an LLMAdapter and retries rate limit (429) and server
+// (5xx) errors with jittered exponential backoff, honoring Retry-After
+type RetryAdapter struct {
+	adapter LLMAdapter
+	policy  RetryPolicy
+	logger  *slog.Logger
+
+	// sleep waits for d or until ctx is done; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryAdapter wraps adapter with retries according to policy
+func NewRetryAdapter(adapter LLMAdapter, policy RetryPolicy, logger *slog.Logger) *RetryAdapter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &RetryAdapter{
+		adapter: adapter,
+		policy:  policy,
+		logger:  logger,
+		sleep:   sleepContext,
+	}
+}
+
+// Chat implements LLMAdapter, retrying transient errors
+func (r *RetryAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := r.do(ctx, "chat", func() error {
+		var err error
+		resp, err = r.adapter.Chat(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// ChatStream implements LLMAdapter. Only starting the stream is retried;
+// errors delivered on the channel are passed through.
+func (r *RetryAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	var ch <-chan StreamChunk
+	err := r.do(ctx, "chat_stream", func() error {
+		var err error
+		ch, err = r.adapter.ChatStream(ctx, req)
+		return err
+	})
+	return ch, err
+}
+
+// Embed implements LLMAdapter, retrying transient errors
+func (r *RetryAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	var embedding []float32
+	err := r.do(ctx, "embed", func() error {
+		var err error
+		embedding, err = r.adapter.Embed(ctx, text)
+		return err
+	})
+	return embedding, err
+}
+
+func (r *RetryAdapter) do(ctx context.Context, operation string, call func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= r.policy.MaxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		delay, ok := r.delay(attempt, err)
+		if !ok {
+			return err
+		}
+		r.logger.Warn("retrying LLM request",
+			"operation", operation,
+			"attempt", attempt,
+			"max_attempts", r.policy.MaxAttempts,
+			"delay", delay,
+			"error", err,
+		)
+		if sleepErr := r.sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// delay returns how long to wait before the next attempt. A Retry-After
+// hint wins over backoff; one longer than MaxDelay means give up.
+func (r *RetryAdapter) delay(attempt int, err error) (time.Duration, bool) {
+	var hint RetryAfterHint
+	if errors.As(err, &hint) && hint.RetryAfter() > 0 {
+		after := hint.RetryAfter()
+		if r.policy.MaxDelay > 0 && after > r.policy.MaxDelay {
+			return 0, false
+		}
+		return after, true
+	}
+
+	backoff := r.policy.BaseDelay << (attempt - 1)
+	if r.policy.MaxDelay > 0 && (backoff > r.policy.MaxDelay || backoff <= 0) {
+		backoff = r.policy.MaxDelay
+	}
+	// Jitter between half and the full backoff so concurrent callers spread out
+	half := backoff / 2
+	return half + rand.N(half+1), true
+}
+
+// IsRetryable reports whether err is a rate limit or transient server error
+func IsRetryable(err error) bool {
+	var apiErr APIErrorDetails
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	code := apiErr.APICode()
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// ParseRetryAfter parses a Retry-After header value given in seconds or as
+// an HTTP date. It returns zero if the value is empty or invalid.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
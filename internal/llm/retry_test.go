@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testAPIError is an APIErrorDetails with an optional Retry-After hint
+type testAPIError struct {
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *testAPIError) Error() string             { return "api error" }
+func (e *testAPIError) APICode() int              { return e.code }
+func (e *testAPIError) APIMessage() string        { return "api error" }
+func (e *testAPIError) RetryAfter() time.Duration { return e.retryAfter }
+
+// flakyLLM fails with the queued errors before succeeding
+type flakyLLM struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyLLM) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &ChatResponse{Content: "ok"}, nil
+}
+
+func (f *flakyLLM) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *flakyLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestRetryAdapter_Chat(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		name       string
+		errs       []error
+		wantCalls  int
+		wantErr    bool
+		wantDelays []time.Duration // exact delays; nil skips the check
+	}{
+		{
+			name:      "success first try",
+			wantCalls: 1,
+		},
+		{
+			name:      "rate limited then ok",
+			errs:      []error{&testAPIError{code: 429}},
+			wantCalls: 2,
+		},
+		{
+			name:       "honors retry-after",
+			errs:       []error{&testAPIError{code: 503, retryAfter: 7 * time.Second}},
+			wantCalls:  2,
+			wantDelays: []time.Duration{7 * time.Second},
+		},
+		{
+			name:      "gives up after max attempts",
+			errs:      []error{&testAPIError{code: 500}, &testAPIError{code: 502}, &testAPIError{code: 503}},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "client errors are not retried",
+			errs:      []error{&testAPIError{code: 400}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "plain errors are not retried",
+			errs:      []error{errors.New("connection refused")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:       "retry-after beyond max delay gives up",
+			errs:       []error{&testAPIError{code: 429, retryAfter: time.Minute}},
+			wantCalls:  1,
+			wantErr:    true,
+			wantDelays: []time.Duration{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &flakyLLM{errs: tt.errs}
+			adapter := NewRetryAdapter(mock, policy, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			delays := []time.Duration{}
+			adapter.sleep = func(ctx context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			resp, err := adapter.Chat(context.Background(), ChatRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Chat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && resp.Content != "ok" {
+				t.Errorf("Chat() content = %q, want ok", resp.Content)
+			}
+			if mock.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", mock.calls, tt.wantCalls)
+			}
+			if tt.wantDelays != nil && !slices.Equal(delays, tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", delays, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func TestRetryAdapter_Backoff(t *testing.T) {
+	adapter := NewRetryAdapter(nil, RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 5 * time.Second}, nil)
+	err := &testAPIError{code: 429}
+
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		got, ok := adapter.delay(attempt+1, err)
+		if !ok || got < want/2 || got > want {
+			t.Errorf("delay(%d) = %v, want between %v and %v", attempt+1, got, want/2, want)
+		}
+	}
+}
+
+func TestRetryAdapter_ContextCancelled(t *testing.T) {
+	mock := &flakyLLM{errs: []error{&testAPIError{code: 429}, &testAPIError{code: 429}}}
+	adapter := NewRetryAdapter(mock, DefaultRetryPolicy(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := adapter.Chat(ctx, ChatRequest{}); err == nil {
+		t.Fatal("Chat() succeeded, want the API error")
+	}
+	if mock.calls != 1 {
+		t.Errorf("calls = %d, want 1", mock.calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"12", 12 * time.Second},
+		{"Fri, 01 Mar 2024 12:00:30 GMT", 30 * time.Second},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
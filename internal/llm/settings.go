@@ -0,0 +1,53 @@
+package llm
+
+import "context"
+
+// GenerationSettings are a model's configured output limit and sampling
+// parameters. Zero values leave the provider default in place.
+type GenerationSettings struct {
+	MaxTokens   int
+	Temperature *float64
+	TopP        *float64
+}
+
+// Apply returns req with any field it leaves unset taken from s
+func (s GenerationSettings) Apply(req ChatRequest) ChatRequest {
+	if req.MaxTokens == 0 {
+		req.MaxTokens = s.MaxTokens
+	}
+	if req.Temperature == nil {
+		req.Temperature = s.Temperature
+	}
+	if req.TopP == nil {
+		req.TopP = s.TopP
+	}
+	return req
+}
+
+// SettingsAdapter wraps an LLMAdapter and applies a model's generation
+// settings to every request, so callers don't need to know which model
+// they are talking to.
+type SettingsAdapter struct {
+	adapter  LLMAdapter
+	settings GenerationSettings
+}
+
+// NewSettingsAdapter applies settings to requests sent through adapter
+func NewSettingsAdapter(adapter LLMAdapter, settings GenerationSettings) *SettingsAdapter {
+	return &SettingsAdapter{adapter: adapter, settings: settings}
+}
+
+// Chat implements LLMAdapter
+func (s *SettingsAdapter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return s.adapter.Chat(ctx, s.settings.Apply(req))
+}
+
+// ChatStream implements LLMAdapter
+func (s *SettingsAdapter) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	return s.adapter.ChatStream(ctx, s.settings.Apply(req))
+}
+
+// Embed implements LLMAdapter
+func (s *SettingsAdapter) Embed(ctx context.Context, text string) ([]float32, error) {
+	return s.adapter.Embed(ctx, text)
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+// recordingLLM keeps the last request it was sent
+type recordingLLM struct {
+	req ChatRequest
+}
+
+func (r *recordingLLM) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	r.req = req
+	return &ChatResponse{}, nil
+}
+
+func (r *recordingLLM) ChatStream(ctx context.Context, req ChatRequest) (<-chan StreamChunk, error) {
+	return nil, nil
+}
+
+func (r *recordingLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+func TestGenerationSettings_Apply(t *testing.T) {
+	temp, topP, reqTemp := 0.2, 0.9, 1.0
+	settings := GenerationSettings{MaxTokens: 2048, Temperature: &temp, TopP: &topP}
+
+	tests := []struct {
+		name          string
+		req           ChatRequest
+		wantMaxTokens int
+		wantTemp      float64
+	}{
+		{
+			name:          "unset fields take the settings",
+			req:           ChatRequest{},
+			wantMaxTokens: 2048,
+			wantTemp:      0.2,
+		},
+		{
+			name:          "request values win",
+			req:           ChatRequest{MaxTokens: 100, Temperature: &reqTemp},
+			wantMaxTokens: 100,
+			wantTemp:      1.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &recordingLLM{}
+			if _, err := NewSettingsAdapter(mock, settings).Chat(context.Background(), tt.req); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			got := mock.req
+			if got.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("MaxTokens = %d, want %d", got.MaxTokens, tt.wantMaxTokens)
+			}
+			if got.Temperature == nil || *got.Temperature != tt.wantTemp {
+				t.Errorf("Temperature = %v, want %v", got.Temperature, tt.wantTemp)
+			}
+			if got.TopP == nil || *got.TopP != 0.9 {
+				t.Errorf("TopP = %v, want 0.9", got.TopP)
+			}
+		})
+	}
+}
+
+func TestGenerationSettings_ApplyZero(t *testing.T) {
+	got := GenerationSettings{}.Apply(ChatRequest{SystemPrompt: "hi"})
+	if got.MaxTokens != 0 || got.Temperature != nil || got.TopP != nil {
+		t.Errorf("zero settings changed the request: %+v", got)
+	}
+}
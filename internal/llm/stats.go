@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// ModelStats is the instrumentation statistics of one provider and model
+type ModelStats struct {
+	Provider string
+	Model    string
+	Stats
+}
+
+// StatsRegistry collects the statistics of the InstrumentedAdapters a
+// process creates, so they add up across model switches
+type StatsRegistry struct {
+	mu       sync.Mutex
+	adapters []*InstrumentedAdapter
+}
+
+// NewStatsRegistry creates an empty StatsRegistry
+func NewStatsRegistry() *StatsRegistry {
+	return &StatsRegistry{}
+}
+
+// Track adds adapter's statistics to the registry and returns adapter
+func (r *StatsRegistry) Track(adapter *InstrumentedAdapter) *InstrumentedAdapter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.adapters = append(r.adapters, adapter)
+	return adapter
+}
+
+// Stats returns the statistics per provider and model, sorted by provider
+// then model. Adapters for the same model are summed.
+func (r *StatsRegistry) Stats() []ModelStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var all []ModelStats
+	for _, a := range r.adapters {
+		i := slices.IndexFunc(all, func(m ModelStats) bool { return m.Provider == a.provider && m.Model == a.model })
+		if i < 0 {
+			all = append(all, ModelStats{Provider: a.provider, Model: a.model})
+			i = len(all) - 1
+		}
+		all[i].Stats = all[i].Stats.Add(a.GetStats())
+	}
+	slices.SortFunc(all, func(a, b ModelStats) int {
+		return cmp.Or(cmp.Compare(a.Provider, b.Provider), cmp.Compare(a.Model, b.Model))
+	})
+	return all
+}
@@ -0,0 +1,45 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStatsRegistry(t *testing.T) {
+	ok := &mockLLMForInstrumentation{response: &ChatResponse{Usage: TokenUsage{InputTokens: 10, OutputTokens: 5}}}
+	failing := &mockLLMForInstrumentation{shouldError: true}
+
+	registry := NewStatsRegistry()
+	claude := registry.Track(NewInstrumentedAdapter(ok, nil, "claude", "claude-sonnet-4"))
+	claudeAgain := registry.Track(NewInstrumentedAdapter(failing, nil, "claude", "claude-sonnet-4"))
+	gemini := registry.Track(NewInstrumentedAdapter(ok, nil, "gemini", "gemini-2.5-flash"))
+
+	ctx := context.Background()
+	claude.Chat(ctx, ChatRequest{})
+	claude.Chat(ctx, ChatRequest{})
+	claudeAgain.Chat(ctx, ChatRequest{})
+	gemini.Chat(ctx, ChatRequest{})
+
+	all := registry.Stats()
+	if len(all) != 2 || all[0].Provider != "claude" || all[1].Provider != "gemini" {
+		t.Fatalf("Stats() = %+v, want claude then gemini", all)
+	}
+	s := all[0].Stats
+	if s.TotalCalls != 3 || s.TotalErrors != 1 || s.TotalInputTokens != 20 || s.TotalTokens != 30 {
+		t.Errorf("claude stats = %+v, want both adapters summed", s)
+	}
+	if rate := s.ErrorRate(); rate < 0.33 || rate > 0.34 {
+		t.Errorf("ErrorRate() = %v, want 1/3", rate)
+	}
+}
+
+func TestStats_Averages(t *testing.T) {
+	if (Stats{}).ErrorRate() != 0 || (Stats{}).AverageLatency() != 0 {
+		t.Error("Stats without calls should have no error rate or latency")
+	}
+	s := Stats{TotalCalls: 4, TotalLatency: 2 * time.Second}
+	if got := s.AverageLatency(); got != 500*time.Millisecond {
+		t.Errorf("AverageLatency() = %v, want 500ms", got)
+	}
+}
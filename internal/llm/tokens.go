@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// messageOverhead is what each message costs beyond its content: role
+// markers and separators in the provider's prompt format
+const messageOverhead = 4
+
+// charsPerToken is the rough average number of ASCII characters per token
+// of each model family's tokenizer on English, shell output, and JSON.
+// Unknown models use defaultCharsPerToken, which errs towards overcounting.
+var charsPerToken = map[string]float64{
+	"claude-": 3.5,
+	"gemini-": 4.0,
+	"gemma":   4.0,
+	"gpt-":    4.0,
+	"o1":      4.0,
+	"o3":      4.0,
+	"o4":      4.0,
+	"llama":   3.8,
+	"mistral": 3.8,
+	"qwen":    3.6,
+	"nova-":   3.8,
+}
+
+const defaultCharsPerToken = 3.5
+
+// CountTokens estimates the prompt tokens messages take for a model,
+// including tool calls and results. It approximates the provider's
+// tokenizer without calling it, for deciding whether a request fits before
+// sending it; the usage reported in responses is authoritative.
+func CountTokens(model string, messages []Message) int {
+	ratio := tokenRatio(model)
+	total := 0
+	for _, m := range messages {
+		total += messageOverhead + countText(ratio, m.Content)
+		for _, tc := range m.ToolCalls {
+			args, _ := json.Marshal(tc.Args)
+			total += countText(ratio, tc.Name) + countText(ratio, string(args))
+		}
+		if m.ToolResultID != "" {
+			total += countText(ratio, m.ToolName)
+		}
+	}
+	return total
+}
+
+// CountRequestTokens estimates the prompt tokens of a whole request: the
+// system prompt, tool definitions, and messages
+func CountRequestTokens(model string, req ChatRequest) int {
+	ratio := tokenRatio(model)
+	total := countText(ratio, req.SystemPrompt)
+	for _, def := range req.Tools {
+		schema, _ := json.Marshal(def.Parameters)
+		total += messageOverhead + countText(ratio, def.Name) +
+			countText(ratio, def.Description) + countText(ratio, string(schema))
+	}
+	return total + CountTokens(model, req.Messages)
+}
+
+// tokenRatio returns the characters per token of a model's tokenizer
+func tokenRatio(model string) float64 {
+	name := normalizeModelName(model)
+	best, ratio := "", defaultCharsPerToken
+	for prefix, r := range charsPerToken {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best, ratio = prefix, r
+		}
+	}
+	return ratio
+}
+
+// countText estimates the tokens in text. Tokenizers split non-ASCII text
+// (accents, CJK, emoji) far more finely than English, so each such
+// character is counted as a token of its own.
+func countText(ratio float64, text string) int {
+	if text == "" {
+		return 0
+	}
+	ascii, other := 0, 0
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		other++
+		i += size
+	}
+	return int(math.Ceil(float64(ascii)/ratio)) + other
+}
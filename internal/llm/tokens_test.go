@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountText(t *testing.T) {
+	tests := []struct {
+		name  string
+		ratio float64
+		text  string
+		want  int
+	}{
+		{name: "empty", ratio: 4, text: "", want: 0},
+		{name: "ascii rounds up", ratio: 4, text: "hello", want: 2},
+		{name: "ascii", ratio: 3.5, text: strings.Repeat("x", 350), want: 100},
+		{name: "non-ascii counts per character", ratio: 4, text: "日本語", want: 3},
+		{name: "mixed", ratio: 4, text: "café", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countText(tt.ratio, tt.text); got != tt.want {
+				t.Errorf("countText() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: strings.Repeat("a", 400)},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "1", Name: "read_file", Args: map[string]any{"path": "/etc/hosts"}}}},
+		{Role: "user", ToolResultID: "1", ToolName: "read_file", Content: strings.Repeat("b", 400)},
+	}
+
+	gpt := CountTokens("gpt-4o", messages)
+	claude := CountTokens("claude-sonnet-4-20250514", messages)
+	if gpt < 200 || gpt > 250 {
+		t.Errorf("CountTokens(gpt-4o) = %d, want about 220", gpt)
+	}
+	if claude <= gpt {
+		t.Errorf("CountTokens(claude) = %d, want more than gpt-4o's %d for its smaller tokens", claude, gpt)
+	}
+	if got := CountTokens("my-finetune", messages); got < claude {
+		t.Errorf("unknown model counted %d, want at least the conservative %d", got, claude)
+	}
+
+	req := ChatRequest{
+		SystemPrompt: strings.Repeat("s", 400),
+		Messages:     messages,
+		Tools:        []ToolDefinition{{Name: "read_file", Description: "Read a file"}},
+	}
+	if got := CountRequestTokens("gpt-4o", req); got <= gpt+100 {
+		t.Errorf("CountRequestTokens() = %d, want messages (%d) plus system prompt and tools", got, gpt)
+	}
+}
@@ -3,11 +3,14 @@ package llmfactory
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/jaimegago/joe/internal/config"
 	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/llm/bedrock"
 	"github.com/jaimegago/joe/internal/llm/claude"
 	"github.com/jaimegago/joe/internal/llm/gemini"
+	"github.com/jaimegago/joe/internal/llm/openai"
 )
 
 // NewAdapter creates an LLMAdapter from a ModelConfig.
@@ -26,8 +29,22 @@ func NewAdapter(ctx context.Context, mc config.ModelConfig) (llm.LLMAdapter, err
 	case "claude":
 		return claude.NewClient(mc.Model)
 	case "gemini":
+		if mc.Vertex {
+			return gemini.NewVertexClient(ctx, mc.Model, mc.VertexProject(), mc.Location)
+		}
 		return gemini.NewClient(ctx, mc.Model)
+	case "openai-compatible":
+		var opts []openai.Option
+		if mc.APIKeyEnv != "" {
+			opts = append(opts, openai.WithAPIKey(os.Getenv(mc.APIKeyEnv)))
+		}
+		if mc.APIVersion != "" {
+			opts = append(opts, openai.WithAPIVersion(mc.APIVersion))
+		}
+		return openai.NewClient(mc.BaseURL, mc.Model, opts...)
+	case "bedrock":
+		return bedrock.NewClient(ctx, mc.Model, mc.Region, mc.Profile)
 	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %q (supported: claude, gemini)", mc.Provider)
+		return nil, fmt.Errorf("unsupported LLM provider: %q (supported: claude, gemini, openai-compatible, bedrock)", mc.Provider)
 	}
 }
@@ -68,3 +68,43 @@ func TestNewAdapter_GeminiMissingKey(t *testing.T) {
 		t.Errorf("error = %q, want to mention GEMINI_API_KEY", err.Error())
 	}
 }
+
+func TestNewAdapter_OpenAICompatible(t *testing.T) {
+	t.Setenv("JOE_TEST_LLM_KEY", "")
+
+	tests := []struct {
+		name    string
+		mc      config.ModelConfig
+		wantErr string
+	}{
+		{
+			name: "no key needed",
+			mc:   config.ModelConfig{Provider: "openai-compatible", Model: "llama3", BaseURL: "http://localhost:8000/v1"},
+		},
+		{
+			name:    "missing base_url",
+			mc:      config.ModelConfig{Provider: "openai-compatible", Model: "llama3"},
+			wantErr: "base_url",
+		},
+		{
+			name:    "key env var not set",
+			mc:      config.ModelConfig{Provider: "openai-compatible", Model: "llama3", BaseURL: "http://localhost:8000/v1", APIKeyEnv: "JOE_TEST_LLM_KEY"},
+			wantErr: "JOE_TEST_LLM_KEY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAdapter(context.Background(), tt.mc)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewAdapter() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want to mention %s", err, tt.wantErr)
+			}
+		})
+	}
+}
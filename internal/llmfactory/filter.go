@@ -0,0 +1,26 @@
+package llmfactory
+
+import (
+	"fmt"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// FilterRules turns the llm.filter config section into rules for
+// llm.NewFilterAdapter. Rules without a name are named after their index.
+func FilterRules(fc config.FilterConfig) ([]llm.FilterRule, error) {
+	rules := make([]llm.FilterRule, 0, len(fc.Rules))
+	for i, rc := range fc.Rules {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("rule-%d", i+1)
+		}
+		rule, err := llm.NewFilterRule(name, rc.Pattern, rc.CIDR, rc.Action)
+		if err != nil {
+			return nil, fmt.Errorf("invalid llm.filter config: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
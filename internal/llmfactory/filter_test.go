@@ -0,0 +1,25 @@
+package llmfactory
+
+import (
+	"testing"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+func TestFilterRules(t *testing.T) {
+	rules, err := FilterRules(config.FilterConfig{Rules: []config.FilterRuleConfig{
+		{Name: "hosts", Pattern: `\.corp\.example\.com`},
+		{CIDR: "10.0.0.0/8", Action: "block"},
+	}})
+	if err != nil {
+		t.Fatalf("FilterRules() error = %v", err)
+	}
+	if len(rules) != 2 || rules[0].Action != llm.FilterRedact || rules[1].Name != "rule-2" || rules[1].Action != llm.FilterBlock {
+		t.Errorf("FilterRules() = %+v", rules)
+	}
+
+	if _, err := FilterRules(config.FilterConfig{Rules: []config.FilterRuleConfig{{Name: "empty"}}}); err == nil {
+		t.Error("FilterRules() accepted a rule with no pattern or cidr")
+	}
+}
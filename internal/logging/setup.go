@@ -6,27 +6,67 @@ import (
 	"os"
 )
 
-// SetupLogger creates a structured logger based on the provided log level.
-// Supported levels: "debug", "info", "warn", "error"
-// Returns a configured slog.Logger using text output to stdout.
-func SetupLogger(level string) *slog.Logger {
-	var lvl slog.Level
+// Redactor masks secrets in text
+type Redactor interface {
+	Redact(s string) string
+}
+
+// Option configures the handler of a logger
+type Option func(*slog.HandlerOptions)
+
+// ParseLevel returns the slog level for "debug", "info", "warn", or
+// "error"; anything else is info
+func ParseLevel(level string) slog.Level {
 	switch level {
 	case "debug":
-		lvl = slog.LevelDebug
-	case "info":
-		lvl = slog.LevelInfo
+		return slog.LevelDebug
 	case "warn":
-		lvl = slog.LevelWarn
+		return slog.LevelWarn
 	case "error":
-		lvl = slog.LevelError
+		return slog.LevelError
 	default:
-		lvl = slog.LevelInfo
+		return slog.LevelInfo
+	}
+}
+
+// WithLevel sets v to the configured level and takes the level from v, so
+// it can be changed while the logger is in use, e.g. on a config reload
+func WithLevel(v *slog.LevelVar) Option {
+	return func(o *slog.HandlerOptions) {
+		v.Set(o.Level.Level())
+		o.Level = v
 	}
+}
+
+// WithRedactor masks secrets in log messages and in string and error
+// attribute values
+func WithRedactor(r Redactor) Option {
+	return func(o *slog.HandlerOptions) {
+		o.ReplaceAttr = func(_ []string, a slog.Attr) slog.Attr {
+			switch a.Value.Kind() {
+			case slog.KindString:
+				return slog.String(a.Key, r.Redact(a.Value.String()))
+			case slog.KindAny:
+				if err, ok := a.Value.Any().(error); ok {
+					return slog.String(a.Key, r.Redact(err.Error()))
+				}
+			}
+			return a
+		}
+	}
+}
 
-	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: lvl,
-	})
+// SetupLogger creates a structured logger based on the provided log level.
+// Supported levels: "debug", "info", "warn", "error"
+// Returns a configured slog.Logger using text output to stdout.
+func SetupLogger(level string, opts ...Option) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{
+		Level: ParseLevel(level),
+	}
+	for _, opt := range opts {
+		opt(handlerOpts)
+	}
+	handler := slog.NewTextHandler(os.Stdout, handlerOpts)
 	return slog.New(handler)
 }
 
@@ -34,23 +74,12 @@ func SetupLogger(level string) *slog.Logger {
 // If logFile is empty, output is discarded (useful for keeping REPL clean).
 // If logFile is specified, logs are written as JSON to that file.
 // Returns the logger and a cleanup function that must be called to close the file.
-func SetupLoggerWithFile(level, logFile string) (*slog.Logger, func()) {
-	var lvl slog.Level
-	switch level {
-	case "debug":
-		lvl = slog.LevelDebug
-	case "info":
-		lvl = slog.LevelInfo
-	case "warn":
-		lvl = slog.LevelWarn
-	case "error":
-		lvl = slog.LevelError
-	default:
-		lvl = slog.LevelInfo
-	}
-
+func SetupLoggerWithFile(level, logFile string, logOpts ...Option) (*slog.Logger, func()) {
 	opts := &slog.HandlerOptions{
-		Level: lvl,
+		Level: ParseLevel(level),
+	}
+	for _, opt := range logOpts {
+		opt(opts)
 	}
 
 	var handler slog.Handler
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// wordRedactor masks the word "hunter2"
+type wordRedactor struct{}
+
+func (wordRedactor) Redact(s string) string { return strings.ReplaceAll(s, "hunter2", "[REDACTED]") }
+
+func TestWithRedactor(t *testing.T) {
+	var buf bytes.Buffer
+	opts := &slog.HandlerOptions{}
+	WithRedactor(wordRedactor{})(opts)
+	logger := slog.New(slog.NewTextHandler(&buf, opts))
+
+	logger.Info("login with hunter2", "password", "hunter2", "error", errors.New("bad password hunter2"), "attempts", 3)
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("log output not redacted: %s", out)
+	}
+	if !strings.Contains(out, "attempts=3") {
+		t.Errorf("log output lost a non-string attribute: %s", out)
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	opts := &slog.HandlerOptions{Level: ParseLevel("info")}
+	WithLevel(level)(opts)
+	if level.Level() != slog.LevelInfo {
+		t.Errorf("level = %v, want the configured info", level.Level())
+	}
+	logger := slog.New(slog.NewTextHandler(&buf, opts))
+
+	logger.Debug("hidden")
+	level.Set(ParseLevel("debug"))
+	logger.Debug("shown")
+
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("log output = %q, want only the message after the level changed", out)
+	}
+}
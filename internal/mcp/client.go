@@ -0,0 +1,267 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// ErrClosed is returned for requests made after the connection has ended
+var ErrClosed = errors.New("mcp connection closed")
+
+// transport carries newline-free JSON-RPC messages to and from a server
+type transport interface {
+	// send writes a single message
+	send(ctx context.Context, msg []byte) error
+
+	// receive returns incoming messages; the channel is closed when the
+	// connection ends
+	receive() <-chan []byte
+
+	// close shuts down the connection
+	close() error
+}
+
+// Client is a connection to a single MCP server
+type Client struct {
+	name      string
+	transport transport
+	nextID    atomic.Int64
+
+	mu      sync.Mutex
+	pending map[int64]chan *message
+	done    chan struct{}
+
+	serverInfo Implementation
+}
+
+// Connect starts the server described by cfg, performs the MCP initialize
+// handshake, and returns a ready client. name is used in logs and errors.
+func Connect(ctx context.Context, name string, cfg config.MCPServerConfig) (*Client, error) {
+	var (
+		t   transport
+		err error
+	)
+	switch cfg.Transport {
+	case "", "stdio":
+		t, err = newStdioTransport(name, cfg)
+	case "sse":
+		t, err = newSSETransport(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("mcp server %s: unsupported transport %q (use stdio or sse)", name, cfg.Transport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mcp server %s: %w", name, err)
+	}
+
+	c := newClient(name, t)
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("mcp server %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// newClient wraps a transport and starts dispatching incoming messages
+func newClient(name string, t transport) *Client {
+	c := &Client{
+		name:      name,
+		transport: t,
+		pending:   make(map[int64]chan *message),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// Name returns the configured server name
+func (c *Client) Name() string {
+	return c.name
+}
+
+// ServerInfo returns the name and version the server reported
+func (c *Client) ServerInfo() Implementation {
+	return c.serverInfo
+}
+
+// Close shuts down the connection
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
+// initialize performs the MCP handshake
+func (c *Client) initialize(ctx context.Context) error {
+	var result initializeResult
+	err := c.request(ctx, "initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: "joe", Version: "0.1.0"},
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("initialize failed: %w", err)
+	}
+	c.serverInfo = result.ServerInfo
+
+	slog.Debug("mcp server initialized",
+		"server", c.name,
+		"server_name", result.ServerInfo.Name,
+		"server_version", result.ServerInfo.Version,
+		"protocol_version", result.ProtocolVersion,
+	)
+
+	return c.notify(ctx, "notifications/initialized", nil)
+}
+
+// ListTools returns every tool the server offers, following pagination
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var all []Tool
+	cursor := ""
+	for {
+		var page listToolsResult
+		if err := c.request(ctx, "tools/list", listToolsParams{Cursor: cursor}, &page); err != nil {
+			return nil, fmt.Errorf("failed to list tools on %s: %w", c.name, err)
+		}
+		all = append(all, page.Tools...)
+		if page.NextCursor == "" {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a tool on the server
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (*CallToolResult, error) {
+	var result CallToolResult
+	if err := c.request(ctx, "tools/call", callToolParams{Name: name, Arguments: args}, &result); err != nil {
+		return nil, fmt.Errorf("failed to call %s on %s: %w", name, c.name, err)
+	}
+	return &result, nil
+}
+
+// request sends a JSON-RPC request and decodes the response into result
+func (c *Client) request(ctx context.Context, method string, params, result any) error {
+	id := c.nextID.Add(1)
+	ch := make(chan *message, 1)
+
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	msg, err := encodeMessage(json.RawMessage(strconv.FormatInt(id, 10)), method, params)
+	if err != nil {
+		return err
+	}
+	if err := c.transport.send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("invalid %s result: %w", method, err)
+		}
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify sends a JSON-RPC notification (no response expected)
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	msg, err := encodeMessage(nil, method, params)
+	if err != nil {
+		return err
+	}
+	if err := c.transport.send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+	return nil
+}
+
+// readLoop dispatches responses to waiting requests and answers the few
+// server-initiated requests a tools-only client has to handle
+func (c *Client) readLoop() {
+	defer close(c.done)
+
+	for raw := range c.transport.receive() {
+		var msg message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			slog.Warn("mcp: ignoring malformed message", "server", c.name, "error", err)
+			continue
+		}
+
+		if msg.Method != "" {
+			c.handleServerMessage(&msg)
+			continue
+		}
+
+		id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+		if err != nil {
+			slog.Warn("mcp: ignoring response with unexpected id", "server", c.name, "id", string(msg.ID))
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[id]
+		c.mu.Unlock()
+		if ok {
+			ch <- &msg
+		}
+	}
+}
+
+// handleServerMessage replies to server requests. Notifications (no ID) are
+// ignored; "ping" is answered; everything else is rejected.
+func (c *Client) handleServerMessage(msg *message) {
+	if len(msg.ID) == 0 {
+		return
+	}
+
+	reply := message{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		reply.Result = json.RawMessage("{}")
+	} else {
+		reply.Error = &RPCError{Code: codeMethodNotFound, Message: "method not supported: " + msg.Method}
+	}
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+	if err := c.transport.send(context.Background(), data); err != nil {
+		slog.Warn("mcp: failed to reply to server request", "server", c.name, "method", msg.Method, "error", err)
+	}
+}
+
+// encodeMessage builds a request (id set) or notification (id nil)
+func encodeMessage(id json.RawMessage, method string, params any) ([]byte, error) {
+	msg := message{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		msg.Params = data
+	}
+	return json.Marshal(msg)
+}
@@ -0,0 +1,309 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/tools/local/echo"
+)
+
+// fakeServer answers MCP requests in-process. It serves two pages of tools
+// and implements tools/call for "shout".
+func fakeServer(req message) *message {
+	reply := &message{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "initialize":
+		reply.Result = mustJSON(initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			ServerInfo:      Implementation{Name: "fake", Version: "1.0"},
+		})
+	case "tools/list":
+		var params listToolsParams
+		json.Unmarshal(req.Params, &params)
+		if params.Cursor == "" {
+			reply.Result = mustJSON(listToolsResult{
+				Tools: []Tool{{
+					Name:        "shout",
+					Description: "Uppercases text",
+					InputSchema: InputSchema{
+						Type: "object",
+						Properties: map[string]SchemaProperty{
+							"text":  {Type: json.RawMessage(`"string"`), Description: "Text to shout"},
+							"times": {Type: json.RawMessage(`["integer","null"]`)},
+						},
+						Required: []string{"text"},
+					},
+				}},
+				NextCursor: "page2",
+			})
+		} else {
+			reply.Result = mustJSON(listToolsResult{Tools: []Tool{{Name: "echo"}, {Name: "fail"}}})
+		}
+	case "tools/call":
+		var params callToolParams
+		json.Unmarshal(req.Params, &params)
+		switch params.Name {
+		case "shout":
+			text, _ := params.Arguments["text"].(string)
+			reply.Result = mustJSON(CallToolResult{Content: []Content{{Type: "text", Text: strings.ToUpper(text)}, {Type: "image"}}})
+		default:
+			reply.Result = mustJSON(CallToolResult{Content: []Content{{Type: "text", Text: "boom"}}, IsError: true})
+		}
+	default:
+		if len(req.ID) == 0 {
+			return nil // notification
+		}
+		reply.Error = &RPCError{Code: codeMethodNotFound, Message: "unknown method"}
+	}
+	return reply
+}
+
+func mustJSON(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// memTransport delivers messages to a handler and its replies back
+type memTransport struct {
+	handle   func(message) *message
+	incoming chan []byte
+	once     sync.Once
+}
+
+func newMemTransport(handle func(message) *message) *memTransport {
+	return &memTransport{handle: handle, incoming: make(chan []byte, 16)}
+}
+
+func (m *memTransport) send(ctx context.Context, data []byte) error {
+	var req message
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	if reply := m.handle(req); reply != nil {
+		m.incoming <- mustJSON(reply)
+	}
+	return nil
+}
+
+func (m *memTransport) receive() <-chan []byte { return m.incoming }
+
+func (m *memTransport) close() error {
+	m.once.Do(func() { close(m.incoming) })
+	return nil
+}
+
+func connectFake(t *testing.T) *Client {
+	t.Helper()
+	c := newClient("fake", newMemTransport(fakeServer))
+	if err := c.initialize(context.Background()); err != nil {
+		t.Fatalf("initialize() error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient_ListToolsPaginates(t *testing.T) {
+	c := connectFake(t)
+
+	if got := c.ServerInfo().Name; got != "fake" {
+		t.Errorf("ServerInfo().Name = %q, want fake", got)
+	}
+
+	got, err := c.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools() error: %v", err)
+	}
+	var names []string
+	for _, tool := range got {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"shout", "echo", "fail"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListTools() = %v, want %v", names, want)
+	}
+}
+
+func TestClient_RPCError(t *testing.T) {
+	c := connectFake(t)
+
+	err := c.request(context.Background(), "resources/list", nil, nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != codeMethodNotFound {
+		t.Errorf("request() error = %v, want RPCError %d", err, codeMethodNotFound)
+	}
+}
+
+func TestClient_ClosedConnection(t *testing.T) {
+	// A server that never answers; closing the transport must unblock callers
+	c := newClient("silent", newMemTransport(func(message) *message { return nil }))
+
+	errc := make(chan error, 1)
+	go func() { errc <- c.request(context.Background(), "tools/list", nil, nil) }()
+	time.Sleep(10 * time.Millisecond)
+	c.Close()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("request() error = %v, want ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request() did not return after Close()")
+	}
+}
+
+func TestRegisterTools(t *testing.T) {
+	c := connectFake(t)
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+
+	registered, err := RegisterTools(context.Background(), c, registry)
+	if err != nil {
+		t.Fatalf("RegisterTools() error: %v", err)
+	}
+	sort.Strings(registered)
+	if want := []string{"fail", "fake_echo", "shout"}; !reflect.DeepEqual(registered, want) {
+		t.Errorf("registered = %v, want %v (local echo must keep its name)", registered, want)
+	}
+	if got := registry.QualifiedName("fake_echo"); got != "fake.echo" {
+		t.Errorf("QualifiedName(fake_echo) = %q, want fake.echo", got)
+	}
+	if tool, _ := registry.Get("echo"); tool.Description() != echo.NewTool().Description() {
+		t.Error("the local echo was replaced")
+	}
+
+	shout, err := registry.Get("shout")
+	if err != nil {
+		t.Fatalf("Get(shout) error: %v", err)
+	}
+
+	params := shout.Parameters()
+	wantParams := llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"text":  {Type: "string", Description: "Text to shout"},
+			"times": {Type: "integer"},
+		},
+		Required: []string{"text"},
+	}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("Parameters() = %+v, want %+v", params, wantParams)
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		want    any
+		wantErr string
+	}{
+		{"text result", "shout", map[string]any{"content": "HI\n[image content omitted]"}, ""},
+		{"tool error", "fail", nil, "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, _ := registry.Get(tt.tool)
+			got, err := tool.Execute(context.Background(), map[string]any{"text": "hi"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnect_SSE(t *testing.T) {
+	messages := make(chan []byte, 16)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case msg := <-messages:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("session") != "1" {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		var req message
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if reply := fakeServer(req); reply != nil {
+			messages <- mustJSON(reply)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := Connect(ctx, "remote", config.MCPServerConfig{Transport: "sse", URL: srv.URL + "/sse"})
+	if err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+	defer c.Close()
+
+	result, err := c.CallTool(ctx, "shout", map[string]any{"text": "over sse"})
+	if err != nil {
+		t.Fatalf("CallTool() error: %v", err)
+	}
+	if got := result.Content[0].Text; got != "OVER SSE" {
+		t.Errorf("CallTool() text = %q, want OVER SSE", got)
+	}
+}
+
+func TestConnect_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.MCPServerConfig
+	}{
+		{"unknown transport", config.MCPServerConfig{Transport: "carrier-pigeon"}},
+		{"stdio without command", config.MCPServerConfig{Transport: "stdio"}},
+		{"sse without url", config.MCPServerConfig{Transport: "sse"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Connect(context.Background(), "bad", tt.cfg); err == nil {
+				t.Error("Connect() should fail")
+			}
+		})
+	}
+}
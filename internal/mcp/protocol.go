@@ -0,0 +1,115 @@
+// Package mcp implements the parts of the Model Context Protocol that Joe
+// needs: a client that imports tools from external MCP servers, over stdio
+// or SSE, into the tool registry, and a stdio server that exposes Joe's own
+// tools to other agents.
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the MCP revision Joe speaks
+const ProtocolVersion = "2024-11-05"
+
+// codeMethodNotFound is the JSON-RPC error code for unsupported methods
+const codeMethodNotFound = -32601
+
+// message is a JSON-RPC 2.0 request, notification, or response. Requests and
+// notifications set Method; notifications omit ID; responses set Result or
+// Error.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is a JSON-RPC error returned by the peer
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// Implementation identifies an MCP client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// initializeParams is sent by the client to start a session
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+// initializeResult is the server's reply to initialize
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// Tool describes a tool offered by an MCP server
+type Tool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema InputSchema `json:"inputSchema"`
+}
+
+// InputSchema is the JSON Schema subset MCP tools use to describe arguments
+type InputSchema struct {
+	Type       string                    `json:"type"`
+	Properties map[string]SchemaProperty `json:"properties,omitempty"`
+	Required   []string                  `json:"required,omitempty"`
+}
+
+// SchemaProperty is a single JSON Schema property. Type may be a string or,
+// for nullable values, an array such as ["string", "null"].
+type SchemaProperty struct {
+	Type        json.RawMessage `json:"type,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Items       *SchemaProperty `json:"items,omitempty"`
+}
+
+// listToolsParams requests a page of tools
+type listToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// listToolsResult is one page of tools
+type listToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// callToolParams invokes a tool by name
+type callToolParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// CallToolResult is the outcome of a tool call. IsError reports a tool-level
+// failure, as opposed to a protocol error.
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Content is one item of tool output. Only text content is interpreted;
+// other types (image, audio, resource) are summarized.
+type Content struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	MimeType string          `json:"mimeType,omitempty"`
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+// JSON-RPC error codes returned by Server
+const (
+	codeParseError    = -32700
+	codeInvalidParams = -32602
+	codeInternalError = -32603
+)
+
+// Server exposes a tool registry to MCP clients over newline-delimited
+// JSON-RPC (the stdio transport)
+type Server struct {
+	registry *tools.Registry
+	executor *tools.Executor
+	info     Implementation
+}
+
+// NewServer creates a server for the tools in registry. Calls run through
+// executor, so its approval policies, timeouts, and redaction apply to
+// MCP clients too.
+func NewServer(registry *tools.Registry, executor *tools.Executor, info Implementation) *Server {
+	return &Server{registry: registry, executor: executor, info: info}
+}
+
+// Serve reads requests from r and writes responses to w until r reaches EOF.
+// Requests are handled concurrently so a slow tool call doesn't block pings
+// or other calls; ctx is passed to every tool call.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		writeMu sync.Mutex
+		wg      sync.WaitGroup
+	)
+	write := func(msg *message) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			slog.Error("mcp server: failed to encode response", "error", err)
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		w.Write(append(data, '\n'))
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var req message
+			if err := json.Unmarshal(line, &req); err != nil {
+				write(&message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &RPCError{Code: codeParseError, Message: err.Error()}})
+			} else {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if resp := s.handle(ctx, &req); resp != nil {
+						write(resp)
+					}
+				}()
+			}
+		}
+
+		if readErr != nil {
+			// Let in-flight calls finish before returning
+			wg.Wait()
+			if readErr == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read request: %w", readErr)
+		}
+	}
+}
+
+// handle processes one message and returns the response, or nil for
+// notifications
+func (s *Server) handle(ctx context.Context, req *message) *message {
+	if len(req.ID) == 0 {
+		// Notifications (e.g. notifications/initialized) need no reply
+		return nil
+	}
+
+	resp := &message{JSONRPC: "2.0", ID: req.ID}
+	result, rpcErr := s.dispatch(ctx, req)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &RPCError{Code: codeInternalError, Message: fmt.Sprintf("failed to encode result: %v", err)}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+func (s *Server) dispatch(ctx context.Context, req *message) (any, *RPCError) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      s.info,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return listToolsResult{Tools: s.listTools()}, nil
+	case "tools/call":
+		var params callToolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &RPCError{Code: codeInvalidParams, Message: "tools/call requires a tool name"}
+		}
+		return s.callTool(ctx, params), nil
+	default:
+		return nil, &RPCError{Code: codeMethodNotFound, Message: "method not supported: " + req.Method}
+	}
+}
+
+// listTools describes every registered tool, sorted by name
+func (s *Server) listTools() []Tool {
+	registered := s.registry.GetAll()
+	list := make([]Tool, 0, len(registered))
+	for _, tool := range registered {
+		list = append(list, Tool{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: toInputSchema(tool.Parameters()),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// callTool runs a tool. Unknown tools, refused calls, and execution
+// failures are reported as tool errors so the calling agent can see and
+// react to them.
+func (s *Server) callTool(ctx context.Context, params callToolParams) CallToolResult {
+	args := params.Arguments
+	if args == nil {
+		args = map[string]any{}
+	}
+	result, err := s.executor.Execute(ctx, params.Name, args)
+	if err != nil {
+		return s.errorResult(err)
+	}
+
+	text, ok := result.(string)
+	if !ok {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return s.errorResult(fmt.Errorf("failed to encode result: %w", err))
+		}
+		text = string(data)
+	}
+	return CallToolResult{Content: []Content{{Type: "text", Text: s.executor.Redact(text)}}}
+}
+
+func (s *Server) errorResult(err error) CallToolResult {
+	return CallToolResult{Content: []Content{{Type: "text", Text: s.executor.Redact(err.Error())}}, IsError: true}
+}
+
+// toInputSchema converts Joe's parameter schema to JSON Schema
+func toInputSchema(params llm.ParameterSchema) InputSchema {
+	schema := InputSchema{
+		Type:       "object",
+		Properties: make(map[string]SchemaProperty, len(params.Properties)),
+		Required:   params.Required,
+	}
+	for name, prop := range params.Properties {
+		schema.Properties[name] = toSchemaProperty(prop)
+	}
+	return schema
+}
+
+func toSchemaProperty(prop llm.Property) SchemaProperty {
+	typ, _ := json.Marshal(prop.Type)
+	p := SchemaProperty{Type: typ, Description: prop.Description}
+	if prop.Items != nil {
+		items := toSchemaProperty(*prop.Items)
+		p.Items = &items
+	}
+	return p
+}
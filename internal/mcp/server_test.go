@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/tools/local/echo"
+)
+
+// pipeTransport connects a Client to an in-process Server over io.Pipes,
+// exercising the same newline-delimited framing as stdio
+type pipeTransport struct {
+	w        *io.PipeWriter
+	incoming chan []byte
+}
+
+func newPipeTransport(t *testing.T, srv *Server) *pipeTransport {
+	t.Helper()
+	clientToServerR, clientToServerW := io.Pipe()
+	serverToClientR, serverToClientW := io.Pipe()
+
+	go func() {
+		srv.Serve(context.Background(), clientToServerR, serverToClientW)
+		serverToClientW.Close()
+	}()
+
+	p := &pipeTransport{w: clientToServerW, incoming: make(chan []byte)}
+	go func() {
+		defer close(p.incoming)
+		scanner := bufio.NewScanner(serverToClientR)
+		for scanner.Scan() {
+			p.incoming <- []byte(scanner.Text())
+		}
+	}()
+	return p
+}
+
+func (p *pipeTransport) send(ctx context.Context, msg []byte) error {
+	_, err := p.w.Write(append(msg, '\n'))
+	return err
+}
+
+func (p *pipeTransport) receive() <-chan []byte { return p.incoming }
+
+func (p *pipeTransport) close() error { return p.w.Close() }
+
+// deniedEcho is echo under another name, for a policy to deny
+type deniedEcho struct {
+	tools.Tool
+}
+
+func (deniedEcho) Name() string { return "denied" }
+
+func TestServer_RoundTrip(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	policies := map[string]tools.ApprovalPolicy{"echo_denied": tools.PolicyDeny}
+	registry.RegisterIn("echo", &deniedEcho{echo.NewTool()})
+	srv := NewServer(registry, tools.NewExecutor(registry, tools.WithApproval(policies, nil, false)),
+		Implementation{Name: "joe", Version: "test"})
+
+	c := newClient("joe", newPipeTransport(t, srv))
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.initialize(ctx); err != nil {
+		t.Fatalf("initialize() error: %v", err)
+	}
+	if got := c.ServerInfo().Name; got != "joe" {
+		t.Errorf("ServerInfo().Name = %q, want joe", got)
+	}
+
+	list, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools() error: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "echo" || list[0].InputSchema.Required[0] != "message" {
+		t.Fatalf("ListTools() = %+v", list)
+	}
+	if typ := string(list[0].InputSchema.Properties["message"].Type); typ != `"string"` {
+		t.Errorf("message type = %s, want \"string\"", typ)
+	}
+
+	tests := []struct {
+		name      string
+		tool      string
+		wantText  string
+		wantError bool
+	}{
+		{"successful call", "echo", `{"echoed":"hello"}`, false},
+		{"unknown tool", "nope", "failed to get tool nope: tool not found: nope", true},
+		{"denied tool", "echo_denied", "tool call not approved: echo_denied is disabled by policy", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := c.CallTool(ctx, tt.tool, map[string]any{"message": "hello"})
+			if err != nil {
+				t.Fatalf("CallTool() error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Errorf("IsError = %v, want %v", result.IsError, tt.wantError)
+			}
+			if got := result.Content[0].Text; got != tt.wantText {
+				t.Errorf("text = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestServer_ProtocolErrors(t *testing.T) {
+	registry := tools.NewRegistry()
+	srv := NewServer(registry, tools.NewExecutor(registry), Implementation{Name: "joe"})
+
+	input := strings.Join([]string{
+		`not json`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{}}`,
+	}, "\n")
+
+	var out strings.Builder
+	if err := srv.Serve(context.Background(), strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+
+	codes := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var resp message
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		if resp.Error == nil {
+			t.Fatalf("response %q has no error", line)
+		}
+		codes[string(resp.ID)] = resp.Error.Code
+	}
+
+	want := map[string]int{"null": codeParseError, "1": codeMethodNotFound, "2": codeInvalidParams}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("error codes = %v, want %v (notifications get no reply)", codes, want)
+	}
+}
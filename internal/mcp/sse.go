@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// sseTransport implements the MCP HTTP+SSE transport: the client holds a GET
+// event stream open for server messages, and POSTs its own messages to the
+// endpoint announced in the stream's first "endpoint" event
+type sseTransport struct {
+	client   *http.Client
+	endpoint string
+	incoming chan []byte
+
+	ctx       context.Context // cancelled by close; ends the stream
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+func newSSETransport(ctx context.Context, cfg config.MCPServerConfig) (*sseTransport, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("sse transport requires a url")
+	}
+	base, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", cfg.URL, err)
+	}
+
+	// The stream outlives ctx, which only bounds the connection attempt
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, cfg.URL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	t := &sseTransport{
+		client:   &http.Client{},
+		incoming: make(chan []byte),
+		ctx:      streamCtx,
+		cancel:   cancel,
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to connect to %s: %w", cfg.URL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("failed to connect to %s: %s", cfg.URL, resp.Status)
+	}
+
+	endpoint := make(chan string, 1)
+	go t.readEvents(resp.Body, endpoint)
+
+	select {
+	case e, ok := <-endpoint:
+		if !ok {
+			cancel()
+			return nil, fmt.Errorf("stream from %s ended before an endpoint event", cfg.URL)
+		}
+		ref, err := url.Parse(e)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("invalid endpoint %q: %w", e, err)
+		}
+		t.endpoint = base.ResolveReference(ref).String()
+	case <-ctx.Done():
+		cancel()
+		return nil, ctx.Err()
+	}
+
+	return t, nil
+}
+
+// readEvents parses the event stream, reporting the endpoint event on
+// endpoint and forwarding "message" events as incoming messages
+func (t *sseTransport) readEvents(body io.ReadCloser, endpoint chan<- string) {
+	defer body.Close()
+	defer close(t.incoming)
+
+	endpointSent := false
+	defer func() {
+		if !endpointSent {
+			close(endpoint)
+		}
+	}()
+
+	var (
+		event string
+		data  bytes.Buffer
+	)
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A blank line dispatches the accumulated event
+		if line == "" {
+			switch {
+			case event == "endpoint" && !endpointSent:
+				endpoint <- data.String()
+				endpointSent = true
+			case event == "" || event == "message":
+				if data.Len() > 0 {
+					select {
+					case t.incoming <- bytes.Clone(data.Bytes()):
+					case <-t.ctx.Done():
+						return
+					}
+				}
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+}
+
+func (t *sseTransport) send(ctx context.Context, msg []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (t *sseTransport) receive() <-chan []byte {
+	return t.incoming
+}
+
+func (t *sseTransport) close() error {
+	t.closeOnce.Do(t.cancel)
+	return nil
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// stdioShutdownTimeout is how long a server gets to exit after its stdin is
+// closed before it is killed
+const stdioShutdownTimeout = 2 * time.Second
+
+// stdioTransport runs the server as a subprocess and exchanges
+// newline-delimited JSON over its stdin/stdout
+type stdioTransport struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	incoming chan []byte
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	exited    chan struct{}
+}
+
+func newStdioTransport(name string, cfg config.MCPServerConfig) (*stdioTransport, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("stdio transport requires a command")
+	}
+
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cfg.Command, err)
+	}
+
+	t := &stdioTransport{
+		cmd:      cmd,
+		stdin:    stdin,
+		incoming: make(chan []byte),
+		exited:   make(chan struct{}),
+	}
+
+	// Wait must not be called until both pipes are drained
+	stderrDone := make(chan struct{})
+	go func() {
+		logStderr(name, stderr)
+		close(stderrDone)
+	}()
+	go func() {
+		t.readStdout(stdout)
+		<-stderrDone
+		err := cmd.Wait()
+		slog.Debug("mcp server exited", "server", name, "error", err)
+		close(t.exited)
+	}()
+
+	return t, nil
+}
+
+// readStdout forwards each line of server output as a message
+func (t *stdioTransport) readStdout(stdout io.Reader) {
+	defer close(t.incoming)
+
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			t.incoming <- line
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// logStderr surfaces server diagnostics in Joe's debug log
+func logStderr(name string, stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		slog.Debug("mcp server stderr", "server", name, "line", scanner.Text())
+	}
+}
+
+func (t *stdioTransport) send(ctx context.Context, msg []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if _, err := t.stdin.Write(append(msg, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *stdioTransport) receive() <-chan []byte {
+	return t.incoming
+}
+
+// close closes stdin, which asks the server to exit, and kills it if it
+// does not exit promptly
+func (t *stdioTransport) close() error {
+	t.closeOnce.Do(func() {
+		t.stdin.Close()
+		select {
+		case <-t.exited:
+		case <-time.After(stdioShutdownTimeout):
+			t.cmd.Process.Kill()
+			<-t.exited
+		}
+	})
+	return nil
+}
@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+// remoteTool adapts a tool on an MCP server to tools.Tool
+type remoteTool struct {
+	client *Client
+	tool   Tool
+}
+
+// Compile-time check that remoteTool implements tools.Tool
+var _ tools.Tool = (*remoteTool)(nil)
+
+// Name returns the tool's name as reported by the server
+func (t *remoteTool) Name() string {
+	return t.tool.Name
+}
+
+// Description returns the server's description, noting where the tool lives
+func (t *remoteTool) Description() string {
+	return fmt.Sprintf("%s (via MCP server %s)", t.tool.Description, t.client.Name())
+}
+
+// Parameters converts the tool's JSON Schema to Joe's parameter schema
+func (t *remoteTool) Parameters() llm.ParameterSchema {
+	schema := llm.ParameterSchema{
+		Type:       "object",
+		Properties: make(map[string]llm.Property, len(t.tool.InputSchema.Properties)),
+		Required:   t.tool.InputSchema.Required,
+	}
+	for name, prop := range t.tool.InputSchema.Properties {
+		schema.Properties[name] = convertProperty(prop)
+	}
+	return schema
+}
+
+// Execute calls the tool on the server. Text output is returned as
+// {"content": "..."}; a tool-level failure becomes an error.
+func (t *remoteTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	result, err := t.client.CallTool(ctx, t.tool.Name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	content := contentText(result.Content)
+	if result.IsError {
+		if content == "" {
+			content = "tool reported an error"
+		}
+		return nil, errors.New(content)
+	}
+	return map[string]any{"content": content}, nil
+}
+
+// convertProperty maps a JSON Schema property onto llm.Property. Types given
+// as arrays (e.g. ["string", "null"]) use their first non-null entry.
+func convertProperty(prop SchemaProperty) llm.Property {
+	p := llm.Property{
+		Type:        schemaType(prop.Type),
+		Description: prop.Description,
+	}
+	if prop.Items != nil {
+		items := convertProperty(*prop.Items)
+		p.Items = &items
+	}
+	return p
+}
+
+func schemaType(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "string"
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(raw, &multiple); err == nil {
+		for _, t := range multiple {
+			if t != "null" {
+				return t
+			}
+		}
+	}
+	return "string"
+}
+
+// contentText joins text content and summarizes anything else
+func contentText(content []Content) string {
+	parts := make([]string, 0, len(content))
+	for _, c := range content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "resource":
+			var res struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			}
+			if json.Unmarshal(c.Resource, &res) == nil && res.Text != "" {
+				parts = append(parts, res.Text)
+			} else {
+				parts = append(parts, fmt.Sprintf("[resource %s omitted]", res.URI))
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content omitted]", c.Type))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// RegisterTools discovers the client's tools and adds them to registry.
+// A tool whose name is already registered is added in the server's
+// namespace instead (server_name, qualified server.name), so local tools
+// keep their names; it is skipped if that name is taken too. Returns the
+// names of the tools registered.
+func RegisterTools(ctx context.Context, c *Client, registry *tools.Registry) ([]string, error) {
+	remote, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var registered []string
+	for _, tool := range remote {
+		if !taken(registry, tool.Name) {
+			registry.Register(&remoteTool{client: c, tool: tool})
+			registered = append(registered, tool.Name)
+			continue
+		}
+		if !validNamespace.MatchString(c.Name()) || taken(registry, c.Name()+"_"+tool.Name) {
+			slog.Warn("mcp: skipping tool that shadows an existing tool", "server", c.Name(), "tool", tool.Name)
+			continue
+		}
+		registered = append(registered, registry.RegisterIn(c.Name(), &remoteTool{client: c, tool: tool}))
+	}
+	return registered, nil
+}
+
+// validNamespace matches server names usable as a tool name prefix
+var validNamespace = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// taken reports whether name is registered, disabled or not
+func taken(registry *tools.Registry, name string) bool {
+	if _, err := registry.Get(name); err == nil {
+		return true
+	}
+	return registry.IsDisabled(name)
+}
+
+// Manager owns the connections to all configured MCP servers
+type Manager struct {
+	clients []*Client
+}
+
+// ConnectAll connects to every configured server (in name order) and
+// registers its tools. A server that fails does not prevent the others from
+// connecting; the returned error joins all failures.
+func ConnectAll(ctx context.Context, servers map[string]config.MCPServerConfig, registry *tools.Registry) (*Manager, error) {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m := &Manager{}
+	var errs []error
+	for _, name := range names {
+		c, err := Connect(ctx, name, servers[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		registered, err := RegisterTools(ctx, c, registry)
+		if err != nil {
+			c.Close()
+			errs = append(errs, err)
+			continue
+		}
+
+		slog.Info("mcp server connected", "server", name, "tools", len(registered))
+		m.clients = append(m.clients, c)
+	}
+
+	return m, errors.Join(errs...)
+}
+
+// Clients returns the connected clients
+func (m *Manager) Clients() []*Client {
+	return m.clients
+}
+
+// Close disconnects from every server
+func (m *Manager) Close() error {
+	var errs []error
+	for _, c := range m.clients {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
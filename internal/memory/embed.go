@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// localDims is the size of local embeddings. It differs from the sizes
+// embedding models use, so the two never get compared.
+const localDims = 384
+
+// stopWords are too common to tell conversations apart
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"did": true, "do": true, "for": true, "from": true, "how": true, "i": true, "in": true, "is": true,
+	"it": true, "my": true, "of": true, "on": true, "or": true, "our": true, "the": true, "this": true,
+	"to": true, "was": true, "we": true, "what": true, "when": true, "why": true, "with": true,
+}
+
+// localEmbedding embeds text without a model, for providers that can't:
+// the words it uses, hashed into localDims dimensions and normalized.
+// Names like payments-api count as the whole and each part. Texts using
+// the same words come out similar, though not texts meaning the same in
+// other words.
+func localEmbedding(text string) []float32 {
+	v := make([]float32, localDims)
+	add := func(w string) {
+		if len(w) < 2 || stopWords[w] {
+			return
+		}
+		h := fnv.New32a()
+		h.Write([]byte(w))
+		v[h.Sum32()%localDims] = 1
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+	})
+	for _, w := range words {
+		w = strings.Trim(w, "-_")
+		add(w)
+		if strings.ContainsAny(w, "-_") {
+			for _, part := range strings.FieldsFunc(w, func(r rune) bool { return r == '-' || r == '_' }) {
+				add(part)
+			}
+		}
+	}
+	normalize(v)
+	return v
+}
+
+// normalize scales v to unit length, leaving a zero vector alone
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+// cosine returns the cosine similarity of a and b, which have the same
+// length, or 0 if either is zero
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
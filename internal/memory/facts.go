@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// AddFact embeds and stores a fact, returning it as stored; a fact stored
+// before is returned as it is
+func (m *Memory) AddFact(ctx context.Context, content string) (*store.Fact, error) {
+	fact, err := m.store.AddFact(ctx, store.Fact{Content: content, Embedding: m.embed(ctx, content)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to remember fact: %w", err)
+	}
+	return fact, nil
+}
+
+// FindFacts returns up to limit facts most similar to query, most similar
+// first, leaving out those with nothing in common with it. An empty query
+// returns the most recent facts.
+func (m *Memory) FindFacts(ctx context.Context, query string, limit int) ([]store.Fact, error) {
+	facts, err := m.store.ListFacts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list facts: %w", err)
+	}
+	if query == "" {
+		slices.Reverse(facts)
+		return facts[:min(len(facts), limit)], nil
+	}
+
+	q := m.embed(ctx, query)
+	type scored struct {
+		fact       store.Fact
+		similarity float64
+	}
+	var matches []scored
+	for _, f := range facts {
+		embedding := f.Embedding
+		if len(embedding) != len(q) {
+			// Embedded another way, e.g. by another provider
+			embedding = m.embed(ctx, f.Content)
+		}
+		if sim := cosine(q, embedding); sim > 0 {
+			matches = append(matches, scored{f, sim})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return cmp.Compare(b.similarity, a.similarity) })
+
+	found := make([]store.Fact, 0, min(len(matches), limit))
+	for _, match := range matches[:min(len(matches), limit)] {
+		found = append(found, match.fact)
+	}
+	return found, nil
+}
+
+// ListFacts returns every fact, oldest first
+func (m *Memory) ListFacts(ctx context.Context) ([]store.Fact, error) {
+	return m.store.ListFacts(ctx)
+}
+
+// DeleteFact forgets the fact with id
+func (m *Memory) DeleteFact(ctx context.Context, id int64) error {
+	return m.store.DeleteFact(ctx, id)
+}
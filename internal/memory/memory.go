@@ -0,0 +1,281 @@
+// Package memory lets joe recall past conversations: when a session ends
+// it is summarized and embedded, and a new conversation starts with the
+// past sessions most like its first question, e.g. the OOM debugged on
+// 2024-03-02. It also keeps the facts the user asks joe to remember.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// recordTool is the tool the LLM records a session's summary with
+const recordTool = "record_session"
+
+// Limits on what is summarized and searched
+const (
+	maxTranscript   = 60000 // Characters of conversation sent to be summarized
+	maxToolResult   = 500   // Characters of each tool result in it
+	maxCandidates   = 500   // Most recent sessions compared on recall
+	minUserMessages = 1     // Sessions with fewer aren't summarized
+)
+
+const summarizePrompt = `You keep a log of the conversations between a user and Joe, an infrastructure assistant, so that later conversations can draw on them. Call the record_session tool once to record the conversation: what it was about, and, if it was debugging a problem, what the problem was, what caused it, and how it was resolved. Be specific: name the services, clusters, errors, versions, and commands involved. Leave out what the conversation didn't establish. Reply with the tool call only.`
+
+// tools is the definition of recordTool
+var tools = []llm.ToolDefinition{{
+	Name:        recordTool,
+	Description: "Record the summary of a conversation",
+	Parameters: llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"summary":    {Type: "string", Description: "What the conversation was about and what came of it, in two or three sentences"},
+			"issue":      {Type: "string", Description: "The problem being debugged, if any, e.g. payments-api pods OOMKilled"},
+			"root_cause": {Type: "string", Description: "What caused it, if found"},
+			"resolution": {Type: "string", Description: "How it was resolved, if it was"},
+			"components": {Type: "array", Description: "Services, clusters, databases, and other components involved", Items: &llm.Property{Type: "string"}},
+			"tags":       {Type: "array", Description: "A few keywords, e.g. oom, kubernetes, postgres", Items: &llm.Property{Type: "string"}},
+		},
+		Required: []string{"summary"},
+	},
+}}
+
+// Store is the subset of store.Store memory needs
+type Store interface {
+	GetSession(ctx context.Context, id string) (*store.Session, error)
+	UpdateSession(ctx context.Context, session store.Session) error
+	ListSessions(ctx context.Context, limit int) ([]store.Session, error)
+	AddFact(ctx context.Context, fact store.Fact) (*store.Fact, error)
+	ListFacts(ctx context.Context) ([]store.Fact, error)
+	DeleteFact(ctx context.Context, id int64) error
+}
+
+// Memory summarizes sessions and recalls the ones like a question, and
+// keeps facts
+type Memory struct {
+	llm           llm.LLMAdapter
+	store         Store
+	topK          int
+	minSimilarity float64
+	logger        *slog.Logger
+}
+
+// Option configures a Memory
+type Option func(*Memory)
+
+// WithTopK sets how many past sessions Recall returns at most, 3 by
+// default
+func WithTopK(k int) Option {
+	return func(m *Memory) { m.topK = k }
+}
+
+// WithMinSimilarity sets how similar, by the cosine of their embeddings,
+// a past session must be to a question to be recalled, 0.25 by default
+func WithMinSimilarity(s float64) Option {
+	return func(m *Memory) { m.minSimilarity = s }
+}
+
+// WithLogger sets the logger, slog.Default() otherwise
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Memory) { m.logger = logger }
+}
+
+// New creates a Memory summarizing and embedding with adapter and keeping
+// sessions in st
+func New(adapter llm.LLMAdapter, st Store, opts ...Option) *Memory {
+	m := &Memory{llm: adapter, store: st, topK: 3, minSimilarity: 0.25, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Remember summarizes the stored session with sessionID and saves the
+// summary with its embedding. Sessions without a user message are left
+// alone.
+func (m *Memory) Remember(ctx context.Context, sessionID string) error {
+	session, err := m.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	text, users := transcript(session.Messages)
+	if users < minUserMessages {
+		return nil
+	}
+
+	resp, err := m.llm.Chat(ctx, llm.ChatRequest{
+		SystemPrompt: summarizePrompt,
+		Messages:     []llm.Message{{Role: "user", Content: text}},
+		Tools:        tools,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to summarize session: %w", err)
+	}
+	i := slices.IndexFunc(resp.ToolCalls, func(tc llm.ToolCall) bool { return tc.Name == recordTool })
+	if i < 0 {
+		return fmt.Errorf("failed to summarize session: the LLM recorded no summary")
+	}
+	args := resp.ToolCalls[i].Args
+	session.Summary = stringArg(args, "summary")
+	session.Issue = stringArg(args, "issue")
+	session.RootCause = stringArg(args, "root_cause")
+	session.Resolution = stringArg(args, "resolution")
+	session.Components = listArg(args, "components")
+	session.Tags = listArg(args, "tags")
+	if session.Summary == "" {
+		return fmt.Errorf("failed to summarize session: the summary is empty")
+	}
+	session.Embedding = m.embed(ctx, describe(*session))
+
+	if err := m.store.UpdateSession(ctx, *session); err != nil {
+		return fmt.Errorf("failed to save session summary: %w", err)
+	}
+	return nil
+}
+
+// Match is a past session recalled for a question, with its similarity
+type Match struct {
+	Session    store.Session
+	Similarity float64
+}
+
+// Recall returns up to the top k summarized sessions most similar to
+// question, most similar first, leaving out excludeID
+func (m *Memory) Recall(ctx context.Context, question, excludeID string) ([]Match, error) {
+	sessions, err := m.store.ListSessions(ctx, maxCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var query []float32
+	var matches []Match
+	for _, s := range sessions {
+		if s.ID == excludeID || s.Summary == "" || len(s.Embedding) == 0 {
+			continue
+		}
+		if query == nil {
+			query = m.embed(ctx, question)
+		}
+		// Sessions embedded another way, e.g. by another provider, can't
+		// be compared and are skipped
+		if len(s.Embedding) != len(query) {
+			continue
+		}
+		if sim := cosine(query, s.Embedding); sim >= m.minSimilarity {
+			matches = append(matches, Match{Session: s, Similarity: sim})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int { return cmp.Compare(b.Similarity, a.Similarity) })
+	return matches[:min(len(matches), m.topK)], nil
+}
+
+// Context returns the past sessions recalled for question, described for
+// the system prompt, or "" if none are. It implements useragent.Recaller.
+func (m *Memory) Context(ctx context.Context, question, excludeID string) (string, error) {
+	matches, err := m.Recall(ctx, question, excludeID)
+	if err != nil || len(matches) == 0 {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("Past conversations with the user that may be relevant. Mention them when they help, e.g. \"we debugged a similar OOM on 2024-03-02\", but check that what they found still holds.\n")
+	for _, match := range matches {
+		fmt.Fprintf(&b, "\n- %s: %s", match.Session.StartedAt.Local().Format("2006-01-02"), describe(match.Session))
+	}
+	return b.String(), nil
+}
+
+// embed returns the embedding of text by the LLM, or, if it can't embed,
+// a local one of the words in text
+func (m *Memory) embed(ctx context.Context, text string) []float32 {
+	v, err := m.llm.Embed(ctx, text)
+	if err == nil && len(v) > 0 {
+		return v
+	}
+	m.logger.Debug("embedding words locally", "reason", err)
+	return localEmbedding(text)
+}
+
+// describe sums up a session on one line
+func describe(s store.Session) string {
+	parts := []string{s.Summary}
+	if s.Issue != "" {
+		parts = append(parts, "Issue: "+s.Issue)
+	}
+	if s.RootCause != "" {
+		parts = append(parts, "Root cause: "+s.RootCause)
+	}
+	if s.Resolution != "" {
+		parts = append(parts, "Resolution: "+s.Resolution)
+	}
+	if len(s.Components) > 0 {
+		parts = append(parts, "Components: "+strings.Join(s.Components, ", "))
+	}
+	return strings.Join(parts, " ")
+}
+
+// transcript renders messages as plain text for summarization, and counts
+// the user's messages
+func transcript(msgs []llm.Message) (string, int) {
+	var b strings.Builder
+	users := 0
+	for _, msg := range msgs {
+		switch {
+		case msg.ToolResultID != "":
+			content := msg.Content
+			if len(content) > maxToolResult {
+				content = content[:maxToolResult] + " …(truncated)"
+			}
+			fmt.Fprintf(&b, "Tool result (%s): %s\n\n", msg.ToolName, content)
+		case msg.Role == "assistant":
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "Joe: %s\n\n", msg.Content)
+			}
+			for _, tc := range msg.ToolCalls {
+				args, _ := json.Marshal(tc.Args)
+				fmt.Fprintf(&b, "Joe called %s %s\n\n", tc.Name, args)
+			}
+		default:
+			users++
+			fmt.Fprintf(&b, "User: %s\n\n", msg.Content)
+		}
+	}
+	text := b.String()
+	if len(text) > maxTranscript {
+		// The end holds what the conversation came to
+		text = "…(earlier messages truncated)\n\n" + text[len(text)-maxTranscript:]
+	}
+	return text, users
+}
+
+func stringArg(args map[string]any, key string) string {
+	s, _ := args[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// listArg reads a list argument, which the LLM may send as an array or a
+// comma-separated string
+func listArg(args map[string]any, key string) []string {
+	var items []string
+	switch v := args[key].(type) {
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				items = append(items, strings.TrimSpace(s))
+			}
+		}
+	case string:
+		for item := range strings.SplitSeq(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+// fakeLLM records sessions with fixed arguments and embeds with embed,
+// or fails to when it is nil
+type fakeLLM struct {
+	args    map[string]any
+	embed   func(text string) []float32
+	lastReq llm.ChatRequest
+}
+
+func (f *fakeLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	f.lastReq = req
+	return &llm.ChatResponse{ToolCalls: []llm.ToolCall{{Name: recordTool, Args: f.args}}}, nil
+}
+
+func (f *fakeLLM) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	if f.embed == nil {
+		return nil, errors.New("embeddings not yet implemented")
+	}
+	return f.embed(text), nil
+}
+
+func openStore(t *testing.T) *sqlite.Store {
+	t.Helper()
+	st, err := sqlite.Open(context.Background(), filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func TestMemory_Remember(t *testing.T) {
+	ctx := context.Background()
+	st := openStore(t)
+	st.CreateSession(ctx, store.Session{ID: "s1", StartedAt: time.Now(), Messages: []llm.Message{
+		{Role: "user", Content: "payments-api pods keep restarting"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "1", Name: "run_command", Args: map[string]any{"command": "kubectl describe pod"}}}},
+		{Role: "user", ToolResultID: "1", ToolName: "run_command", Content: "Last State: OOMKilled"},
+		{Role: "assistant", Content: "The pods run out of memory; raise the limit to 1Gi."},
+	}})
+	st.CreateSession(ctx, store.Session{ID: "empty", StartedAt: time.Now(), Messages: []llm.Message{{Role: "assistant", Content: "Hello"}}})
+
+	model := &fakeLLM{args: map[string]any{
+		"summary":    "Debugged payments-api pods restarting.",
+		"issue":      "payments-api OOMKilled",
+		"root_cause": "memory limit of 512Mi too low",
+		"resolution": "raised the limit to 1Gi",
+		"components": []any{"payments-api", "k8s/prod"},
+		"tags":       "oom, kubernetes",
+	}}
+	m := New(model, st)
+	if err := m.Remember(ctx, "s1"); err != nil {
+		t.Fatalf("Remember() error: %v", err)
+	}
+	if !strings.Contains(model.lastReq.Messages[0].Content, "Tool result (run_command): Last State: OOMKilled") {
+		t.Errorf("transcript = %q, want the tool result", model.lastReq.Messages[0].Content)
+	}
+
+	got, err := st.GetSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("GetSession() error: %v", err)
+	}
+	if got.Summary != "Debugged payments-api pods restarting." || got.RootCause != "memory limit of 512Mi too low" || got.Resolution != "raised the limit to 1Gi" {
+		t.Errorf("session = %+v, want the recorded summary", got)
+	}
+	if !reflect.DeepEqual(got.Components, []string{"payments-api", "k8s/prod"}) || !reflect.DeepEqual(got.Tags, []string{"oom", "kubernetes"}) {
+		t.Errorf("components = %v, tags = %v", got.Components, got.Tags)
+	}
+	if len(got.Embedding) != localDims {
+		t.Errorf("embedding has %d dimensions, want a local one of %d", len(got.Embedding), localDims)
+	}
+	if len(got.Messages) != 4 {
+		t.Errorf("session has %d messages, want them kept", len(got.Messages))
+	}
+
+	// Nothing to summarize without a user message
+	model.lastReq = llm.ChatRequest{}
+	if err := m.Remember(ctx, "empty"); err != nil {
+		t.Fatalf("Remember(empty) error: %v", err)
+	}
+	if model.lastReq.SystemPrompt != "" {
+		t.Error("Remember(empty) called the LLM")
+	}
+}
+
+func TestMemory_Recall(t *testing.T) {
+	ctx := context.Background()
+	st := openStore(t)
+	day := time.Date(2024, 3, 2, 12, 0, 0, 0, time.Local)
+	sessions := []store.Session{
+		{ID: "oom", Summary: "Debugged payments-api pods OOMKilled in prod.", Issue: "payments-api OOMKilled", Resolution: "raised the memory limit"},
+		{ID: "dns", Summary: "Fixed DNS resolution failures in the staging cluster.", Issue: "coredns crashloop"},
+		{ID: "current", Summary: "Debugged payments-api pods OOMKilled again."},
+		{ID: "unsummarized"},
+	}
+	for i, s := range sessions {
+		s.StartedAt = day.AddDate(0, 0, i)
+		if s.Summary != "" {
+			s.Embedding = localEmbedding(describe(s))
+		}
+		st.CreateSession(ctx, s)
+	}
+	m := New(&fakeLLM{}, st)
+
+	matches, err := m.Recall(ctx, "the payments pods are getting OOMKilled", "current")
+	if err != nil {
+		t.Fatalf("Recall() error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Session.ID != "oom" {
+		t.Fatalf("Recall() = %+v, want the OOM session only", matches)
+	}
+
+	recalled, err := m.Context(ctx, "the payments pods are getting OOMKilled", "current")
+	if err != nil {
+		t.Fatalf("Context() error: %v", err)
+	}
+	if !strings.Contains(recalled, "- 2024-03-02: Debugged payments-api pods OOMKilled in prod. Issue: payments-api OOMKilled Resolution: raised the memory limit") {
+		t.Errorf("Context() = %q, want the OOM session with its date", recalled)
+	}
+	if recalled, _ := m.Context(ctx, "rotate the TLS certificates", ""); recalled != "" {
+		t.Errorf("Context(unrelated) = %q, want nothing", recalled)
+	}
+
+	// Sessions embedded by a model are compared with the model's
+	// embedding, and the top k kept
+	model := &fakeLLM{embed: func(text string) []float32 { return []float32{1, 0} }}
+	st.CreateSession(ctx, store.Session{ID: "model", StartedAt: day, Summary: "Anything.", Embedding: []float32{0.9, 0.1}})
+	st.UpdateSession(ctx, store.Session{ID: "model2", StartedAt: day, Summary: "Anything else.", Embedding: []float32{0.8, 0.6}})
+	matches, err = New(model, st, WithTopK(1)).Recall(ctx, "anything", "")
+	if err != nil {
+		t.Fatalf("Recall() error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Session.ID != "model" {
+		t.Errorf("Recall() with a model = %+v, want the closest session", matches)
+	}
+}
+
+func TestMemory_Facts(t *testing.T) {
+	ctx := context.Background()
+	m := New(&fakeLLM{}, openStore(t))
+	for _, content := range []string{
+		"The prod database is db-prod-3 in us-east-1",
+		"Deploys to prod go through ArgoCD",
+		"The on-call rotation is in PagerDuty",
+	} {
+		if _, err := m.AddFact(ctx, content); err != nil {
+			t.Fatalf("AddFact(%q) error: %v", content, err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{query: "which database does prod use", limit: 5, want: []string{"The prod database is db-prod-3 in us-east-1", "Deploys to prod go through ArgoCD"}},
+		{query: "which database does prod use", limit: 1, want: []string{"The prod database is db-prod-3 in us-east-1"}},
+		{query: "", limit: 2, want: []string{"The on-call rotation is in PagerDuty", "Deploys to prod go through ArgoCD"}},
+		{query: "tls certificates", limit: 5, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			facts, err := m.FindFacts(ctx, tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("FindFacts() error: %v", err)
+			}
+			var got []string
+			for _, f := range facts {
+				got = append(got, f.Content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindFacts(%q, %d) = %q, want %q", tt.query, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// builders create the channel of each section of the notifications
+// config, by its key. A new backend is a Channel, its section (listed by
+// config.NotificationConfig.Channels), and an entry here.
+var builders = map[string]func(nc config.NotificationConfig, logger *slog.Logger) (Channel, error){
+	"desktop": func(config.NotificationConfig, *slog.Logger) (Channel, error) {
+		return NewDesktop(), nil
+	},
+	"slack": func(nc config.NotificationConfig, logger *slog.Logger) (Channel, error) {
+		return slackFromConfig(nc.Slack, logger)
+	},
+	"webhook": func(nc config.NotificationConfig, logger *slog.Logger) (Channel, error) {
+		if nc.Webhook.URL == "" {
+			return nil, fmt.Errorf("notifications.webhook: url is required")
+		}
+		return NewWebhook(nc.Webhook.URL, nc.Webhook.Headers, logger), nil
+	},
+	"email": func(nc config.NotificationConfig, logger *slog.Logger) (Channel, error) {
+		ec := nc.Email
+		if ec.SMTPHost == "" || ec.From == "" || len(ec.To) == 0 {
+			return nil, fmt.Errorf("notifications.email: smtp_host, from, and to are required")
+		}
+		var password string
+		if ec.Username != "" {
+			if password = os.Getenv(ec.PasswordEnv); ec.PasswordEnv == "" || password == "" {
+				return nil, fmt.Errorf("notifications.email: the SMTP password environment variable %q is not set", ec.PasswordEnv)
+			}
+		}
+		return NewEmail(ec.SMTPHost, ec.SMTPPort, ec.Username, password, ec.From, ec.To, logger), nil
+	},
+	"pagerduty": func(nc config.NotificationConfig, logger *slog.Logger) (Channel, error) {
+		key := os.Getenv(nc.PagerDuty.RoutingKeyEnv)
+		if nc.PagerDuty.RoutingKeyEnv == "" || key == "" {
+			return nil, fmt.Errorf("notifications.pagerduty: the routing key environment variable %q is not set", nc.PagerDuty.RoutingKeyEnv)
+		}
+		return NewPagerDuty(key, logger), nil
+	},
+}
@@ -0,0 +1,183 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+var testNotification = Notification{
+	Title:    "joe: argocd is failing to refresh",
+	Message:  "argocd: connection refused",
+	Priority: High,
+}
+
+// noWait makes b retry without sleeping
+func noWait(b *backoff) {
+	b.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+}
+
+func TestWebhook_Send(t *testing.T) {
+	t.Setenv("JOE_TEST_WEBHOOK_TOKEN", "s3cret")
+	var got webhookPayload
+	var auth string
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer ts.Close()
+
+	w := NewWebhook(ts.URL, map[string]string{"Authorization": "Bearer ${JOE_TEST_WEBHOOK_TOKEN}"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	noWait(&w.backoff)
+	now := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	if err := w.Send(context.Background(), testNotification); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	want := webhookPayload{Title: testNotification.Title, Message: testNotification.Message, Priority: "high", Source: "joe", Time: now}
+	if got != want || attempts != 2 {
+		t.Errorf("posted %+v after %d attempts, want %+v after 2", got, attempts, want)
+	}
+	if auth != "Bearer s3cret" {
+		t.Errorf("Authorization = %q, want the token from the environment", auth)
+	}
+}
+
+func TestPagerDuty_Send(t *testing.T) {
+	var got pagerDutyEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	p := NewPagerDuty("R0UT1NGKEY", slog.Default())
+	p.url = ts.URL
+	if err := p.Send(context.Background(), testNotification); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if got.RoutingKey != "R0UT1NGKEY" || got.EventAction != "trigger" || got.DedupKey != "joe:"+testNotification.Title {
+		t.Errorf("event = %+v, want a trigger for the routing key, deduplicated by title", got)
+	}
+	if got.Payload.Summary != testNotification.Title || got.Payload.Severity != "error" || got.Payload.CustomDetails["message"] != testNotification.Message {
+		t.Errorf("payload = %+v, want the notification at severity error", got.Payload)
+	}
+}
+
+func TestPagerDuty_SendRejected(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"status":"invalid event","message":"Event object is invalid"}`)
+	}))
+	defer ts.Close()
+
+	p := NewPagerDuty("bad", slog.Default())
+	p.url = ts.URL
+	err := p.Send(context.Background(), testNotification)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 400") || attempts != 1 {
+		t.Errorf("Send() error = %v after %d attempts, want HTTP 400 without retries", err, attempts)
+	}
+}
+
+func TestEmail_Send(t *testing.T) {
+	e := NewEmail("smtp.example.com", 0, "joe", "s3cret", "joe@example.com", []string{"ops@example.com", "me@example.com"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	noWait(&e.backoff)
+	e.now = func() time.Time { return time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC) }
+
+	var addr string
+	var to []string
+	var msg string
+	replies := []error{&textproto.Error{Code: 421, Msg: "try again later"}, nil}
+	e.send = func(a string, auth smtp.Auth, from string, rcpt []string, m []byte) error {
+		addr, to, msg = a, rcpt, string(m)
+		err := replies[0]
+		replies = replies[1:]
+		return err
+	}
+
+	if err := e.Send(context.Background(), testNotification); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if addr != "smtp.example.com:587" || !slices.Equal(to, []string{"ops@example.com", "me@example.com"}) {
+		t.Errorf("sent to %v through %s, want both recipients through port 587", to, addr)
+	}
+	for _, want := range []string{
+		"To: ops@example.com, me@example.com\r\n",
+		"Subject: [HIGH] joe: argocd is failing to refresh\r\n",
+		"Date: Mon, 02 Mar 2026 14:00:00 +0000\r\n",
+		"\r\n\r\nargocd: connection refused\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message = %q, want it to contain %q", msg, want)
+		}
+	}
+
+	// A rejected recipient is not retried
+	attempts := 0
+	e.send = func(string, smtp.Auth, string, []string, []byte) error {
+		attempts++
+		return &textproto.Error{Code: 550, Msg: "no such user"}
+	}
+	if err := e.Send(context.Background(), testNotification); err == nil || attempts != 1 {
+		t.Errorf("Send() error = %v after %d attempts, want the 550 without retries", err, attempts)
+	}
+}
+
+func TestFromConfig_Channels(t *testing.T) {
+	t.Setenv("JOE_TEST_PD_KEY", "R0UT1NGKEY")
+	nc := config.NotificationConfig{
+		Webhook:   config.WebhookConfig{Enabled: true, PriorityThreshold: "medium", URL: "https://example.com/hook"},
+		Email:     config.EmailConfig{Enabled: true, PriorityThreshold: "high", SMTPHost: "localhost", From: "joe@localhost", To: []string{"me@localhost"}},
+		PagerDuty: config.PagerDutyConfig{Enabled: true, PriorityThreshold: "urgent", RoutingKeyEnv: "JOE_TEST_PD_KEY"},
+	}
+	d, err := FromConfig(nc, slog.Default())
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+	var got []string
+	for _, r := range d.routes {
+		got = append(got, r.name+"@"+r.threshold.String())
+	}
+	if want := []string{"email@high", "pagerduty@urgent", "webhook@medium"}; !slices.Equal(got, want) {
+		t.Errorf("channels = %v, want %v", got, want)
+	}
+
+	nc.PagerDuty.RoutingKeyEnv = "JOE_TEST_UNSET"
+	if _, err := FromConfig(nc, slog.Default()); err == nil || !strings.Contains(err.Error(), "notifications.pagerduty") {
+		t.Errorf("FromConfig() error = %v, want the missing routing key", err)
+	}
+}
+
+func TestBackoff_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	b := defaultBackoff()
+	err := b.do(ctx, slog.Default(), "test", func() error {
+		attempts++
+		cancel()
+		return errors.New("connection reset")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("do() error = %v after %d attempts, want the first failure once cancelled", err, attempts)
+	}
+}
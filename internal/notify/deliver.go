@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpTimeout bounds each delivery attempt over HTTP
+const httpTimeout = 10 * time.Second
+
+// statusError is a response other than success from a notification service
+type statusError struct {
+	code       int
+	body       string
+	retryAfter time.Duration // Retry-After of a 429, if any
+}
+
+func (e *statusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("HTTP %d", e.code)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.code, e.body)
+}
+
+// permanentError is a failure retrying won't fix, e.g. a revoked token
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// postJSON posts payload to url and returns the response body. Rate limits
+// and server errors come back as a *statusError; other client errors as a
+// *permanentError.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, &permanentError{fmt.Errorf("failed to encode request: %w", err)}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &permanentError{err}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return respBody, nil
+	}
+
+	serr := &statusError{code: resp.StatusCode, body: strings.TrimSpace(string(respBody))}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		serr.retryAfter = time.Duration(seconds) * time.Second
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return nil, &permanentError{serr}
+	}
+	return nil, serr
+}
+
+// backoff retries failed deliveries, doubling the wait after each attempt
+type backoff struct {
+	attempts  int // Total, including the first
+	baseDelay time.Duration
+	maxDelay  time.Duration // Cap on the wait; a longer Retry-After gives up
+
+	// sleep waits for d or until ctx is done; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// defaultBackoff tries four times over about 7 seconds
+func defaultBackoff() backoff {
+	return backoff{attempts: 4, baseDelay: time.Second, maxDelay: 30 * time.Second, sleep: sleepContext}
+}
+
+// do calls send until it succeeds, fails permanently, or runs out of
+// attempts, and returns its last error
+func (b backoff) do(ctx context.Context, logger *slog.Logger, channel string, send func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := send()
+		var permanent *permanentError
+		if err == nil || attempt >= b.attempts || errors.As(err, &permanent) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := min(b.baseDelay<<(attempt-1), b.maxDelay)
+		var serr *statusError
+		if errors.As(err, &serr) && serr.retryAfter > 0 {
+			if serr.retryAfter > b.maxDelay {
+				return err
+			}
+			delay = serr.retryAfter
+		}
+		logger.Warn("retrying notification", "channel", channel, "attempt", attempt, "delay", delay, "error", err)
+		if b.sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// windowsAppID is PowerShell's app ID, which Windows shows toasts for
+// without joe registering one of its own
+const windowsAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// Desktop shows notifications on the desktop of the machine joecored runs
+// on: with notify-send on Linux, osascript on macOS, and a PowerShell toast
+// on Windows
+type Desktop struct {
+	goos string
+	run  func(ctx context.Context, name string, args ...string) error
+}
+
+// NewDesktop creates the desktop channel for the current OS
+func NewDesktop() *Desktop {
+	return &Desktop{goos: runtime.GOOS, run: runCommand}
+}
+
+// Send implements Channel
+func (d *Desktop) Send(ctx context.Context, n Notification) error {
+	name, args := desktopCommand(d.goos, n)
+	if err := d.run(ctx, name, args...); err != nil {
+		return fmt.Errorf("failed to show desktop notification: %w", err)
+	}
+	return nil
+}
+
+// desktopCommand returns the command showing n on goos
+func desktopCommand(goos string, n Notification) (string, []string) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(n.Message), appleScriptString(n.Title))
+		if n.Priority == Urgent {
+			script += ` sound name "Basso"`
+		}
+		return "osascript", []string{"-e", script}
+	case "windows":
+		script := strings.Join([]string{
+			"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+			"$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+			"$text = $template.GetElementsByTagName('text')",
+			"$text.Item(0).AppendChild($template.CreateTextNode(" + powerShellString(n.Title) + ")) > $null",
+			"$text.Item(1).AppendChild($template.CreateTextNode(" + powerShellString(n.Message) + ")) > $null",
+			"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(" + powerShellString(windowsAppID) +
+				").Show([Windows.UI.Notifications.ToastNotification]::new($template))",
+		}, "; ")
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}
+	default:
+		urgency := "normal"
+		switch n.Priority {
+		case Low:
+			urgency = "low"
+		case Urgent:
+			urgency = "critical"
+		}
+		return "notify-send", []string{"--app-name=joe", "--urgency=" + urgency, n.Title, n.Message}
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// powerShellString quotes s as a PowerShell literal string
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// runCommand runs a command, returning what it printed with its error
+func runCommand(ctx context.Context, name string, args ...string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends notifications by email over SMTP, upgrading to TLS when the
+// server offers STARTTLS, and retrying temporary failures with backoff
+type Email struct {
+	addr    string
+	auth    smtp.Auth // nil sends without authenticating
+	from    string
+	to      []string
+	backoff backoff
+	logger  *slog.Logger
+	now     func() time.Time
+
+	// send is smtp.SendMail; replaced in tests
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail creates a channel sending from from to each address in to
+// through the SMTP server at host:port (587 when 0), logging in as
+// username unless it is empty
+func NewEmail(host string, port int, username, password, from string, to []string, logger *slog.Logger) *Email {
+	if port == 0 {
+		port = 587
+	}
+	e := &Email{
+		addr:    net.JoinHostPort(host, strconv.Itoa(port)),
+		from:    from,
+		to:      to,
+		backoff: defaultBackoff(),
+		logger:  logger,
+		now:     time.Now,
+		send:    smtp.SendMail,
+	}
+	if username != "" {
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e
+}
+
+// Send implements Channel. SMTP sends can't be cancelled, so ctx only
+// stops retries.
+func (e *Email) Send(ctx context.Context, n Notification) error {
+	msg := e.message(n)
+	err := e.backoff.do(ctx, e.logger, "email", func() error {
+		err := e.send(e.addr, e.auth, e.from, e.to, msg)
+		// 5xx replies, e.g. a rejected login or recipient, are final
+		var reply *textproto.Error
+		if errors.As(err, &reply) && reply.Code >= 500 {
+			return &permanentError{err}
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// message formats n as a plain text email with the priority in the
+// subject, e.g. "[HIGH] joe: argocd is failing to refresh"
+func (e *Email) message(n Notification) []byte {
+	subject := "[" + strings.ToUpper(n.Priority.String()) + "] " + oneLine(n.Title)
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// oneLine keeps a header value from spanning lines
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
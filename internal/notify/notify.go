@@ -0,0 +1,255 @@
+// Package notify tells the user about problems joecored finds, e.g. sources
+// failing to refresh, through the channels enabled in the notifications
+// section of the config
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// Priority ranks a notification; a channel only sends those at or above
+// its threshold
+type Priority int
+
+// Priorities, lowest first
+const (
+	Low Priority = iota
+	Medium
+	High
+	Urgent
+)
+
+var priorityNames = []string{"low", "medium", "high", "urgent"}
+
+// String returns the priority's name in the config, e.g. "high"
+func (p Priority) String() string {
+	if p < Low || p > Urgent {
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority parses a priority name, e.g. a channel's
+// priority_threshold
+func ParsePriority(name string) (Priority, error) {
+	i := slices.Index(priorityNames, name)
+	if i < 0 {
+		return Low, fmt.Errorf("unknown priority %q (want low, medium, high, or urgent)", name)
+	}
+	return Priority(i), nil
+}
+
+// Notification is a message for the user
+type Notification struct {
+	Title    string
+	Message  string
+	Priority Priority
+}
+
+// Channel delivers notifications, e.g. to the desktop
+type Channel interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// route is a channel and the lowest priority it sends
+type route struct {
+	name      string
+	channel   Channel
+	threshold Priority
+}
+
+// maxQueued caps the notifications held for the end of quiet hours; the
+// oldest go first
+const maxQueued = 100
+
+// Dispatcher sends each notification through the channels whose threshold
+// it meets. During quiet hours it holds back all but urgent ones, which
+// Run sends when the quiet hours end, or drops them.
+type Dispatcher struct {
+	routes []route
+	quiet  *QuietHours // nil when quiet hours are off
+	drop   bool        // Drop notifications during quiet hours instead of queueing them
+	logger *slog.Logger
+	now    func() time.Time
+	after  func(d time.Duration) <-chan time.Time
+
+	mu     sync.Mutex
+	queue  []Notification // Held back by quiet hours
+	queued chan struct{}  // Signals Run that queue has notifications
+}
+
+// Option configures a Dispatcher
+type Option func(*Dispatcher)
+
+// WithChannel sends notifications at or above threshold through c
+func WithChannel(name string, c Channel, threshold Priority) Option {
+	return func(d *Dispatcher) {
+		d.routes = append(d.routes, route{name: name, channel: c, threshold: threshold})
+	}
+}
+
+// WithQuietHours holds back notifications below Urgent during q, to send
+// when q ends
+func WithQuietHours(q *QuietHours) Option {
+	return func(d *Dispatcher) { d.quiet = q }
+}
+
+// WithQuietHoursDrop drops the notifications held back during quiet hours
+// rather than sending them when they end
+func WithQuietHoursDrop() Option {
+	return func(d *Dispatcher) { d.drop = true }
+}
+
+// WithLogger sets the logger, slog.Default() otherwise
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *Dispatcher) { d.logger = logger }
+}
+
+// NewDispatcher creates a dispatcher; without channels it sends nothing
+func NewDispatcher(opts ...Option) *Dispatcher {
+	d := &Dispatcher{logger: slog.Default(), now: time.Now, after: time.After, queued: make(chan struct{}, 1)}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// FromConfig creates a dispatcher for the channels and quiet hours set in
+// the notifications section of the config
+func FromConfig(nc config.NotificationConfig, logger *slog.Logger) (*Dispatcher, error) {
+	opts := []Option{WithLogger(logger)}
+	channels := nc.Channels()
+	for _, name := range slices.Sorted(maps.Keys(channels)) {
+		cc := channels[name]
+		if !cc.Enabled {
+			continue
+		}
+		build, ok := builders[name]
+		if !ok {
+			return nil, fmt.Errorf("notifications.%s: unknown channel", name)
+		}
+		threshold, err := ParsePriority(cc.PriorityThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("notifications.%s.priority_threshold: %w", name, err)
+		}
+		c, err := build(nc, logger)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithChannel(name, c, threshold))
+	}
+	if qh := nc.QuietHours; qh.Enabled {
+		q, err := NewQuietHours(qh.Start, qh.End, qh.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("notifications.quiet_hours: %w", err)
+		}
+		opts = append(opts, WithQuietHours(q))
+		if qh.Action == "drop" {
+			opts = append(opts, WithQuietHoursDrop())
+		}
+	}
+	return NewDispatcher(opts...), nil
+}
+
+// Notify sends n through every channel whose threshold it meets, unless
+// quiet hours hold it back. A channel failing doesn't stop the others;
+// their errors are returned together.
+func (d *Dispatcher) Notify(ctx context.Context, n Notification) error {
+	if d.quiet != nil && n.Priority < Urgent && d.quiet.Contains(d.now()) {
+		if d.drop {
+			d.logger.Debug("notification dropped for quiet hours", "title", n.Title, "priority", n.Priority)
+		} else {
+			d.hold(n)
+		}
+		return nil
+	}
+	return d.send(ctx, n)
+}
+
+func (d *Dispatcher) send(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, r := range d.routes {
+		if n.Priority < r.threshold {
+			continue
+		}
+		if err := r.channel.Send(ctx, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hold queues n for the end of quiet hours. A notification with the same
+// title as one already queued replaces it, so a problem reported all night
+// is sent once, as last reported.
+func (d *Dispatcher) hold(n Notification) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue = slices.DeleteFunc(d.queue, func(q Notification) bool { return q.Title == n.Title })
+	if len(d.queue) >= maxQueued {
+		d.logger.Warn("too many notifications held for quiet hours; dropping the oldest", "title", d.queue[0].Title)
+		d.queue = d.queue[1:]
+	}
+	d.queue = append(d.queue, n)
+	d.logger.Debug("notification held for the end of quiet hours", "title", n.Title, "priority", n.Priority)
+	select {
+	case d.queued <- struct{}{}:
+	default: // Run hasn't picked up the last one yet; it will send this too
+	}
+}
+
+// Flush sends the notifications held back by quiet hours, oldest first
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	queue := d.queue
+	d.queue = nil
+	d.mu.Unlock()
+
+	var errs []error
+	for _, n := range queue {
+		if err := d.send(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run sends the notifications held back by quiet hours as soon as they
+// end, until ctx is cancelled. Notifications still held then are dropped.
+func (d *Dispatcher) Run(ctx context.Context) {
+	defer func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if len(d.queue) > 0 {
+			d.logger.Warn("dropping notifications held for quiet hours", "count", len(d.queue))
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.queued:
+		}
+		// Checked again after each wait in case the clock jumped
+		for d.quiet != nil && d.quiet.Contains(d.now()) {
+			now := d.now()
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.after(d.quiet.Ends(now).Sub(now)):
+			}
+		}
+		if err := d.Flush(ctx); err != nil {
+			d.logger.Warn("failed to send notifications held for quiet hours", "error", err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// fakeChannel records the titles it sends, or fails
+type fakeChannel struct {
+	sent []string
+	err  error
+}
+
+func (f *fakeChannel) Send(ctx context.Context, n Notification) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, n.Title)
+	return nil
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	quiet, err := NewQuietHours("22:00", "08:00", "UTC")
+	if err != nil {
+		t.Fatalf("NewQuietHours() error: %v", err)
+	}
+	day := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	night := time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		priority    Priority
+		now         time.Time
+		wantDesktop bool
+		wantSlack   bool
+	}{
+		{name: "below both thresholds", priority: Low, now: day},
+		{name: "desktop threshold", priority: Medium, now: day, wantDesktop: true},
+		{name: "both thresholds", priority: High, now: day, wantDesktop: true, wantSlack: true},
+		{name: "quiet hours", priority: High, now: night},
+		{name: "urgent during quiet hours", priority: Urgent, now: night, wantDesktop: true, wantSlack: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desktop, slack := &fakeChannel{}, &fakeChannel{}
+			d := NewDispatcher(
+				WithChannel("desktop", desktop, Medium),
+				WithChannel("slack", slack, High),
+				WithQuietHours(quiet),
+				WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+			d.now = func() time.Time { return tt.now }
+
+			if err := d.Notify(context.Background(), Notification{Title: "argocd failing", Priority: tt.priority}); err != nil {
+				t.Fatalf("Notify() error: %v", err)
+			}
+			if got := len(desktop.sent) == 1; got != tt.wantDesktop {
+				t.Errorf("desktop sent %v, want sent = %v", desktop.sent, tt.wantDesktop)
+			}
+			if got := len(slack.sent) == 1; got != tt.wantSlack {
+				t.Errorf("slack sent %v, want sent = %v", slack.sent, tt.wantSlack)
+			}
+		})
+	}
+}
+
+func TestDispatcher_NotifyFailure(t *testing.T) {
+	failing, working := &fakeChannel{err: errors.New("no display")}, &fakeChannel{}
+	d := NewDispatcher(WithChannel("desktop", failing, Low), WithChannel("other", working, Low))
+
+	err := d.Notify(context.Background(), Notification{Title: "argocd failing", Priority: High})
+	if err == nil || err.Error() != "desktop: no display" {
+		t.Errorf("Notify() error = %v, want the desktop failure", err)
+	}
+	if !slices.Equal(working.sent, []string{"argocd failing"}) {
+		t.Errorf("other channel sent %v, want the notification despite the failure", working.sent)
+	}
+}
+
+func TestDispatcher_QuietHours(t *testing.T) {
+	quiet, err := NewQuietHours("22:00", "08:00", "UTC")
+	if err != nil {
+		t.Fatalf("NewQuietHours() error: %v", err)
+	}
+	night := time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+	reports := []Notification{
+		{Title: "argocd failing", Message: "connection refused", Priority: High},
+		{Title: "vault failing", Priority: High},
+		{Title: "argocd failing", Message: "timeout", Priority: High}, // Replaces the first
+	}
+
+	for _, drop := range []bool{false, true} {
+		opts := []Option{WithQuietHours(quiet), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))}
+		want := []string{"vault failing", "argocd failing"}
+		if drop {
+			opts = append(opts, WithQuietHoursDrop())
+			want = nil
+		}
+		ch := &fakeChannel{}
+		d := NewDispatcher(append(opts, WithChannel("desktop", ch, Low))...)
+		d.now = func() time.Time { return night }
+
+		for _, n := range reports {
+			if err := d.Notify(context.Background(), n); err != nil {
+				t.Fatalf("Notify() error: %v", err)
+			}
+		}
+		if len(ch.sent) != 0 {
+			t.Errorf("drop = %v: sent %v during quiet hours, want none", drop, ch.sent)
+		}
+		if err := d.Flush(context.Background()); err != nil {
+			t.Fatalf("Flush() error: %v", err)
+		}
+		if !slices.Equal(ch.sent, want) {
+			t.Errorf("drop = %v: flushed %v, want %v", drop, ch.sent, want)
+		}
+		if len(d.queue) != 0 {
+			t.Errorf("drop = %v: %d still queued after Flush, want none", drop, len(d.queue))
+		}
+	}
+}
+
+// syncChannel passes the titles it sends to a channel
+type syncChannel chan string
+
+func (c syncChannel) Send(ctx context.Context, n Notification) error {
+	c <- n.Title
+	return nil
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	quiet, err := NewQuietHours("22:00", "08:00", "UTC")
+	if err != nil {
+		t.Fatalf("NewQuietHours() error: %v", err)
+	}
+	sent := make(syncChannel, 1)
+	d := NewDispatcher(WithChannel("desktop", sent, Low), WithQuietHours(quiet),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	var mu sync.Mutex
+	now := time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+	d.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	waits := make(chan time.Duration)
+	wake := make(chan time.Time)
+	d.after = func(wait time.Duration) <-chan time.Time {
+		waits <- wait
+		return wake
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	d.Notify(ctx, Notification{Title: "argocd failing", Priority: High})
+	if wait := <-waits; wait != 8*time.Hour+30*time.Minute {
+		t.Errorf("Run waited %v, want until 08:00", wait)
+	}
+	select {
+	case title := <-sent:
+		t.Fatalf("sent %q during quiet hours", title)
+	default:
+	}
+
+	mu.Lock()
+	now = time.Date(2026, 3, 3, 8, 0, 0, 0, time.UTC)
+	mu.Unlock()
+	wake <- now
+	select {
+	case title := <-sent:
+		if title != "argocd failing" {
+			t.Errorf("sent %q, want the queued notification", title)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued notification not sent when quiet hours ended")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	nc := config.NotificationConfig{
+		Desktop:    config.ChannelConfig{PriorityThreshold: "medium"},
+		QuietHours: config.QuietHoursConfig{Start: "22:00", End: "08:00", Timezone: "Local"},
+	}
+	d, err := FromConfig(nc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+	if len(d.routes) != 0 || d.quiet != nil {
+		t.Errorf("default config: %d channels, quiet hours %v; want none", len(d.routes), d.quiet)
+	}
+
+	nc.Desktop.Enabled = true
+	nc.QuietHours.Enabled = true
+	d, err = FromConfig(nc, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+	if len(d.routes) != 1 || d.routes[0].name != "desktop" || d.routes[0].threshold != Medium || d.quiet == nil {
+		t.Errorf("routes = %+v, quiet hours %v; want desktop at medium and quiet hours", d.routes, d.quiet)
+	}
+
+	if d.drop {
+		t.Error("quiet hours drop notifications, want them queued by default")
+	}
+	nc.QuietHours.Action = "drop"
+	if d, err = FromConfig(nc, slog.Default()); err != nil || !d.drop {
+		t.Errorf("FromConfig() with action drop = %v, %v; want notifications dropped", d, err)
+	}
+
+	nc.QuietHours.Start = "10pm"
+	if _, err := FromConfig(nc, slog.Default()); err == nil {
+		t.Error("FromConfig() with an invalid quiet_hours.start: want error")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for _, p := range []Priority{Low, Medium, High, Urgent} {
+		got, err := ParsePriority(p.String())
+		if err != nil || got != p {
+			t.Errorf("ParsePriority(%q) = %v, %v; want %v", p.String(), got, err, p)
+		}
+	}
+	if _, err := ParsePriority("critical"); err == nil {
+		t.Error("ParsePriority(\"critical\"): want error")
+	}
+}
+
+func TestDesktopCommand(t *testing.T) {
+	n := Notification{Title: `argocd "prod" failing`, Message: "it's down", Priority: Urgent}
+	tests := []struct {
+		goos     string
+		wantName string
+		wantArgs []string
+	}{
+		{
+			goos:     "linux",
+			wantName: "notify-send",
+			wantArgs: []string{"--app-name=joe", "--urgency=critical", `argocd "prod" failing`, "it's down"},
+		},
+		{
+			goos:     "darwin",
+			wantName: "osascript",
+			wantArgs: []string{"-e", `display notification "it's down" with title "argocd \"prod\" failing" sound name "Basso"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			name, args := desktopCommand(tt.goos, n)
+			if name != tt.wantName || !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("desktopCommand() = %s %q, want %s %q", name, args, tt.wantName, tt.wantArgs)
+			}
+		})
+	}
+
+	// Windows gets the text in PowerShell literal strings
+	name, args := desktopCommand("windows", n)
+	if name != "powershell" || !slices.ContainsFunc(args, func(a string) bool {
+		return strings.Contains(a, "CreateTextNode('it''s down')")
+	}) {
+		t.Errorf("desktopCommand(windows) = %s %q, want the message quoted for PowerShell", name, args)
+	}
+}
+
+func TestDesktop_Send(t *testing.T) {
+	d := &Desktop{goos: "linux", run: func(ctx context.Context, name string, args ...string) error {
+		return errors.New("exec: \"notify-send\": executable file not found in $PATH")
+	}}
+	err := d.Send(context.Background(), Notification{Title: "t", Message: "m"})
+	if err == nil || !strings.Contains(err.Error(), "failed to show desktop notification") {
+		t.Errorf("Send() error = %v, want the command failure", err)
+	}
+}
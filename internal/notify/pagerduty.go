@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"unicode/utf8"
+)
+
+// pagerDutyEventsURL is the Events API v2 endpoint
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty limits, in bytes
+const (
+	maxPagerDutySummary  = 1024
+	maxPagerDutyDedupKey = 255
+)
+
+// pagerDutySeverities map priorities to event severities
+var pagerDutySeverities = map[Priority]string{
+	Low:    "info",
+	Medium: "warning",
+	High:   "error",
+	Urgent: "critical",
+}
+
+// PagerDuty triggers PagerDuty events through the Events API v2,
+// retrying failed deliveries with backoff. Notifications with the same
+// title update one alert rather than opening a new one each.
+type PagerDuty struct {
+	routingKey string
+	url        string // Events API; replaced in tests
+	client     *http.Client
+	backoff    backoff
+	logger     *slog.Logger
+}
+
+// NewPagerDuty creates a channel triggering events for the integration
+// with routingKey
+func NewPagerDuty(routingKey string, logger *slog.Logger) *PagerDuty {
+	return &PagerDuty{
+		routingKey: routingKey,
+		url:        pagerDutyEventsURL,
+		client:     &http.Client{Timeout: httpTimeout},
+		backoff:    defaultBackoff(),
+		logger:     logger,
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// Send implements Channel
+func (p *PagerDuty) Send(ctx context.Context, n Notification) error {
+	event := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    truncate("joe:"+n.Title, maxPagerDutyDedupKey),
+		Payload: pagerDutyPayload{
+			Summary:  truncate(n.Title, maxPagerDutySummary),
+			Source:   "joe",
+			Severity: pagerDutySeverities[n.Priority],
+		},
+	}
+	if event.Payload.Severity == "" {
+		event.Payload.Severity = "info"
+	}
+	if n.Message != "" {
+		event.Payload.CustomDetails = map[string]string{"message": n.Message}
+	}
+	err := p.backoff.do(ctx, p.logger, "pagerduty", func() error {
+		_, err := postJSON(ctx, p.client, p.url, nil, event)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send PagerDuty event: %w", err)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes, keeping whole characters
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
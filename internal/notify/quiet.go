@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours is a daily span of local time, e.g. 22:00 to 08:00, during
+// which notifications wait
+type QuietHours struct {
+	start, end int // Minutes past midnight
+	loc        *time.Location
+}
+
+// NewQuietHours parses start and end as HH:MM in timezone, e.g.
+// "Europe/Madrid" or "Local". An end before start spans midnight; an end
+// equal to start is never quiet.
+func NewQuietHours(start, end, timezone string) (*QuietHours, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return nil, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", timezone)
+	}
+	return &QuietHours{start: s, end: e, loc: loc}, nil
+}
+
+// Contains reports whether t falls in the quiet hours
+func (q *QuietHours) Contains(t time.Time) bool {
+	t = t.In(q.loc)
+	m := t.Hour()*60 + t.Minute()
+	if q.start <= q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
+
+// Ends returns when the quiet hours holding t end: the next time the
+// clock in their time zone reads the end time, so a night with a DST change
+// is an hour shorter or longer
+func (q *QuietHours) Ends(t time.Time) time.Time {
+	t = t.In(q.loc)
+	end := q.endOn(t.Year(), t.Month(), t.Day())
+	if end.After(t) {
+		return end
+	}
+	if again, ok := repeated(end); ok && again.After(t) {
+		return again
+	}
+	return q.endOn(t.Year(), t.Month(), t.Day()+1)
+}
+
+// repeated returns when the clock reads t's time the second time, if
+// falling back right after t repeats it
+func repeated(t time.Time) (time.Time, bool) {
+	_, next := t.ZoneBounds()
+	if next.IsZero() {
+		return time.Time{}, false
+	}
+	_, before := t.Zone()
+	_, after := next.Zone()
+	again := t.Add(time.Duration(before-after) * time.Second)
+	return again, before > after && again.Hour() == t.Hour() && again.Minute() == t.Minute()
+}
+
+// endOn returns when the end time comes on a day or, when a DST change
+// skips it, when the clocks jump past it
+func (q *QuietHours) endOn(year int, month time.Month, day int) time.Time {
+	end := time.Date(year, month, day, q.end/60, q.end%60, 0, 0, q.loc)
+	if end.Hour()*60+end.Minute() != q.end {
+		_, end = end.ZoneBounds()
+	}
+	return end
+}
+
+// parseClock returns the minutes past midnight of an HH:MM time
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
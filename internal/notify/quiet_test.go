@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHours_Contains(t *testing.T) {
+	tests := []struct {
+		start, end string
+		clock      string
+		want       bool
+	}{
+		{start: "22:00", end: "08:00", clock: "23:30", want: true},
+		{start: "22:00", end: "08:00", clock: "03:00", want: true},
+		{start: "22:00", end: "08:00", clock: "08:00", want: false},
+		{start: "22:00", end: "08:00", clock: "12:00", want: false},
+		{start: "12:00", end: "14:00", clock: "12:00", want: true},
+		{start: "12:00", end: "14:00", clock: "14:30", want: false},
+		{start: "09:00", end: "09:00", clock: "09:00", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.start+"-"+tt.end+" at "+tt.clock, func(t *testing.T) {
+			q, err := NewQuietHours(tt.start, tt.end, "UTC")
+			if err != nil {
+				t.Fatalf("NewQuietHours() error: %v", err)
+			}
+			clock, _ := time.Parse("15:04", tt.clock)
+			at := time.Date(2026, 3, 2, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+			if got := q.Contains(at); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.clock, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHours_Timezone(t *testing.T) {
+	q, err := NewQuietHours("22:00", "08:00", "America/New_York")
+	if err != nil {
+		t.Fatalf("NewQuietHours() error: %v", err)
+	}
+	// 03:00 UTC is 22:00 the evening before in New York (EST)
+	if !q.Contains(time.Date(2026, 1, 15, 3, 0, 0, 0, time.UTC)) {
+		t.Error("Contains(03:00 UTC) = false, want true at 22:00 in New York")
+	}
+	if _, err := NewQuietHours("22:00", "08:00", "Mars/Olympus"); err == nil {
+		t.Error("NewQuietHours() with an unknown time zone: want error")
+	}
+}
+
+func TestQuietHours_DST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// In 2026, New York springs forward at 02:00 on March 8 and falls back
+	// at 02:00 on November 1
+	tests := []struct {
+		name       string
+		start, end string
+		at         time.Time
+		wantQuiet  bool
+		wantEnds   time.Time
+		wantLength time.Duration // From at to the end
+	}{
+		{
+			name:       "spring forward night is an hour shorter",
+			start:      "22:00",
+			end:        "08:00",
+			at:         time.Date(2026, 3, 7, 22, 0, 0, 0, ny),
+			wantQuiet:  true,
+			wantEnds:   time.Date(2026, 3, 8, 8, 0, 0, 0, ny),
+			wantLength: 9 * time.Hour,
+		},
+		{
+			name:       "fall back night is an hour longer",
+			start:      "22:00",
+			end:        "08:00",
+			at:         time.Date(2026, 10, 31, 22, 0, 0, 0, ny),
+			wantQuiet:  true,
+			wantEnds:   time.Date(2026, 11, 1, 8, 0, 0, 0, ny),
+			wantLength: 11 * time.Hour,
+		},
+		{
+			name:       "end skipped by spring forward",
+			start:      "22:00",
+			end:        "02:30",
+			at:         time.Date(2026, 3, 8, 1, 45, 0, 0, ny),
+			wantQuiet:  true,
+			wantEnds:   time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), // 03:00 EDT, when clocks jump
+			wantLength: 15 * time.Minute,
+		},
+		{
+			name:       "repeated hour, first time",
+			start:      "01:00",
+			end:        "01:30",
+			at:         time.Date(2026, 11, 1, 5, 15, 0, 0, time.UTC), // 01:15 EDT
+			wantQuiet:  true,
+			wantEnds:   time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+			wantLength: 15 * time.Minute,
+		},
+		{
+			name:       "repeated hour, second time",
+			start:      "01:00",
+			end:        "01:30",
+			at:         time.Date(2026, 11, 1, 6, 15, 0, 0, time.UTC), // 01:15 EST
+			wantQuiet:  true,
+			wantEnds:   time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC),
+			wantLength: 15 * time.Minute,
+		},
+		{
+			name:      "after the skipped hour",
+			start:     "01:00",
+			end:       "03:00",
+			at:        time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), // 03:00 EDT, right after 01:59 EST
+			wantQuiet: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := NewQuietHours(tt.start, tt.end, "America/New_York")
+			if err != nil {
+				t.Fatalf("NewQuietHours() error: %v", err)
+			}
+			if got := q.Contains(tt.at); got != tt.wantQuiet {
+				t.Fatalf("Contains(%v) = %v, want %v", tt.at, got, tt.wantQuiet)
+			}
+			if !tt.wantQuiet {
+				return
+			}
+			ends := q.Ends(tt.at)
+			if !ends.Equal(tt.wantEnds) || ends.Sub(tt.at) != tt.wantLength {
+				t.Errorf("Ends(%v) = %v, %v away; want %v, %v away", tt.at, ends, ends.Sub(tt.at), tt.wantEnds, tt.wantLength)
+			}
+			if q.Contains(ends) {
+				t.Errorf("Contains(Ends()) = true, want the quiet hours over at %v", ends)
+			}
+		})
+	}
+}
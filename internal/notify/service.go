@@ -1,11 +0,0 @@
-package notify
-
-// Service handles notifications
-type Service struct {
-	// TODO: Implement notification service in Phase 6
-}
-
-// NewService creates a new notification service
-func NewService() *Service {
-	return &Service{}
-}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// slackPostMessageURL is the Web API method a bot token posts with
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// slackLabels lead a message with its priority, e.g. ":red_circle: *[URGENT]*"
+var slackLabels = map[Priority]string{
+	Low:    ":white_circle: *[LOW]*",
+	Medium: ":large_blue_circle: *[MEDIUM]*",
+	High:   ":large_orange_circle: *[HIGH]*",
+	Urgent: ":red_circle: *[URGENT]*",
+}
+
+// slackEscaper escapes the characters Slack reads as markup
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Slack posts notifications to Slack, through an incoming webhook or as a
+// bot, retrying failed deliveries with backoff
+type Slack struct {
+	webhookURL string // Empty posts as the bot
+	token      string
+	channel    string
+	apiURL     string // chat.postMessage; replaced in tests
+	client     *http.Client
+	backoff    backoff
+	logger     *slog.Logger
+}
+
+// NewSlackWebhook creates a channel posting to an incoming webhook
+func NewSlackWebhook(webhookURL string, logger *slog.Logger) *Slack {
+	return &Slack{webhookURL: webhookURL, client: &http.Client{Timeout: httpTimeout}, backoff: defaultBackoff(), logger: logger}
+}
+
+// NewSlackBot creates a channel posting to channel with a bot token
+func NewSlackBot(token, channel string, logger *slog.Logger) *Slack {
+	return &Slack{
+		token:   token,
+		channel: channel,
+		apiURL:  slackPostMessageURL,
+		client:  &http.Client{Timeout: httpTimeout},
+		backoff: defaultBackoff(),
+		logger:  logger,
+	}
+}
+
+// slackFromConfig creates the channel set by notifications.slack: the
+// webhook if there is one, otherwise the bot with the token in token_env
+func slackFromConfig(sc config.SlackConfig, logger *slog.Logger) (*Slack, error) {
+	if sc.WebhookURL != "" {
+		return NewSlackWebhook(sc.WebhookURL, logger), nil
+	}
+	if sc.Channel == "" {
+		return nil, fmt.Errorf("notifications.slack: webhook_url, or channel with a bot token, is required")
+	}
+	token := os.Getenv(sc.TokenEnv)
+	if sc.TokenEnv == "" || token == "" {
+		return nil, fmt.Errorf("notifications.slack: the bot token environment variable %q is not set", sc.TokenEnv)
+	}
+	return NewSlackBot(token, sc.Channel, logger), nil
+}
+
+// Send implements Channel
+func (s *Slack) Send(ctx context.Context, n Notification) error {
+	text := slackText(n)
+	if err := s.backoff.do(ctx, s.logger, "slack", func() error { return s.post(ctx, text) }); err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	return nil
+}
+
+func (s *Slack) post(ctx context.Context, text string) error {
+	if s.webhookURL != "" {
+		_, err := postJSON(ctx, s.client, s.webhookURL, nil, map[string]string{"text": text})
+		return err
+	}
+
+	header := http.Header{"Authorization": {"Bearer " + s.token}}
+	body, err := postJSON(ctx, s.client, s.apiURL, header, map[string]string{"channel": s.channel, "text": text})
+	if err != nil {
+		return err
+	}
+	// The Web API answers 200 with ok false for errors such as a bad token
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if !resp.OK {
+		return &permanentError{fmt.Errorf("slack API error: %s", resp.Error)}
+	}
+	return nil
+}
+
+// slackText formats n as a Slack message, e.g.
+// ":large_orange_circle: *[HIGH]* *joe: argocd is failing to refresh*"
+// followed by the message
+func slackText(n Notification) string {
+	label, ok := slackLabels[n.Priority]
+	if !ok {
+		label = "*[" + strings.ToUpper(n.Priority.String()) + "]*"
+	}
+	text := label + " *" + slackEscaper.Replace(n.Title) + "*"
+	if n.Message != "" {
+		text += "\n" + slackEscaper.Replace(n.Message)
+	}
+	return text
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// slackServer answers each request with the next status and body, and
+// records the requests
+type slackServer struct {
+	statuses []int
+	bodies   []string
+	requests []map[string]string
+	auth     []string
+}
+
+func (s *slackServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]string
+	json.NewDecoder(r.Body).Decode(&payload)
+	s.requests = append(s.requests, payload)
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+
+	i := min(len(s.requests), len(s.statuses)) - 1
+	if s.statuses[i] == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "60")
+	}
+	w.WriteHeader(s.statuses[i])
+	if i < len(s.bodies) {
+		io.WriteString(w, s.bodies[i])
+	}
+}
+
+// testSlack returns s with instant retries, recording the waits
+func testSlack(s *Slack, waits *[]time.Duration) *Slack {
+	s.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	s.backoff.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return s
+}
+
+func TestSlack_Send(t *testing.T) {
+	n := Notification{Title: "joe: argocd is failing to refresh", Message: "argocd: connection refused", Priority: High}
+
+	tests := []struct {
+		name         string
+		bot          bool
+		statuses     []int
+		bodies       []string
+		wantErr      string
+		wantAttempts int
+		wantWaits    []time.Duration
+	}{
+		{name: "webhook", statuses: []int{200}, wantAttempts: 1},
+		{
+			name:         "webhook retries server errors",
+			statuses:     []int{503, 500, 200},
+			wantAttempts: 3,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:         "webhook gives up after four attempts",
+			statuses:     []int{500},
+			wantErr:      "failed to post to Slack: HTTP 500",
+			wantAttempts: 4,
+			wantWaits:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:         "webhook client error is not retried",
+			statuses:     []int{404},
+			bodies:       []string{"no_service"},
+			wantErr:      "failed to post to Slack: HTTP 404: no_service",
+			wantAttempts: 1,
+		},
+		{
+			name:         "Retry-After beyond the cap",
+			statuses:     []int{429},
+			wantErr:      "HTTP 429",
+			wantAttempts: 1,
+		},
+		{name: "bot", bot: true, statuses: []int{200}, bodies: []string{`{"ok": true}`}, wantAttempts: 1},
+		{
+			name:         "bot with a revoked token",
+			bot:          true,
+			statuses:     []int{200},
+			bodies:       []string{`{"ok": false, "error": "token_revoked"}`},
+			wantErr:      "slack API error: token_revoked",
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &slackServer{statuses: tt.statuses, bodies: tt.bodies}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			var waits []time.Duration
+			var s *Slack
+			if tt.bot {
+				s = NewSlackBot("xoxb-test", "#ops", nil)
+				s.apiURL = ts.URL
+			} else {
+				s = NewSlackWebhook(ts.URL, nil)
+			}
+			s = testSlack(s, &waits)
+
+			err := s.Send(context.Background(), n)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Send() error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Send() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(server.requests) != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", len(server.requests), tt.wantAttempts)
+			}
+			if len(waits) != len(tt.wantWaits) {
+				t.Fatalf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+			for i, w := range waits {
+				if w != tt.wantWaits[i] {
+					t.Errorf("wait %d = %v, want %v", i, w, tt.wantWaits[i])
+				}
+			}
+
+			want := map[string]string{"text": slackText(n)}
+			wantAuth := ""
+			if tt.bot {
+				want["channel"] = "#ops"
+				wantAuth = "Bearer xoxb-test"
+			}
+			if got := server.requests[0]; len(got) != len(want) || got["text"] != want["text"] || got["channel"] != want["channel"] {
+				t.Errorf("request = %v, want %v", got, want)
+			}
+			if server.auth[0] != wantAuth {
+				t.Errorf("Authorization = %q, want %q", server.auth[0], wantAuth)
+			}
+		})
+	}
+}
+
+func TestSlackText(t *testing.T) {
+	got := slackText(Notification{Title: "joe: <db> failing", Message: "a & b", Priority: Urgent})
+	if want := ":red_circle: *[URGENT]* *joe: &lt;db&gt; failing*\na &amp; b"; got != want {
+		t.Errorf("slackText() = %q, want %q", got, want)
+	}
+}
+
+func TestSlackFromConfig(t *testing.T) {
+	t.Setenv("JOE_TEST_SLACK_TOKEN", "xoxb-test")
+
+	tests := []struct {
+		name    string
+		sc      config.SlackConfig
+		wantErr string
+	}{
+		{name: "webhook", sc: config.SlackConfig{WebhookURL: "https://hooks.slack.com/services/T/B/X"}},
+		{name: "bot", sc: config.SlackConfig{Channel: "#ops", TokenEnv: "JOE_TEST_SLACK_TOKEN"}},
+		{name: "no destination", sc: config.SlackConfig{TokenEnv: "JOE_TEST_SLACK_TOKEN"}, wantErr: "webhook_url, or channel"},
+		{name: "token not set", sc: config.SlackConfig{Channel: "#ops", TokenEnv: "JOE_TEST_UNSET"}, wantErr: `"JOE_TEST_UNSET" is not set`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := slackFromConfig(tt.sc, slog.Default())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("slackFromConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("slackFromConfig() error: %v", err)
+			}
+			if s.webhookURL != tt.sc.WebhookURL || (s.webhookURL == "" && s.token != "xoxb-test") {
+				t.Errorf("slackFromConfig() = %+v, want the %s set up", s, tt.name)
+			}
+		})
+	}
+}
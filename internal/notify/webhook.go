@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Webhook posts notifications as JSON to any URL, retrying failed
+// deliveries with backoff
+type Webhook struct {
+	url     string
+	header  http.Header
+	client  *http.Client
+	backoff backoff
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+// webhookPayload is the body of each post
+type webhookPayload struct {
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Priority string    `json:"priority"` // "low", "medium", "high", or "urgent"
+	Source   string    `json:"source"`   // Always "joe"
+	Time     time.Time `json:"time"`
+}
+
+// NewWebhook creates a channel posting to url with headers, whose values
+// expand ${VAR} from the environment so secrets stay out of the config
+func NewWebhook(url string, headers map[string]string, logger *slog.Logger) *Webhook {
+	header := make(http.Header)
+	for k, v := range headers {
+		header.Set(k, os.ExpandEnv(v))
+	}
+	return &Webhook{
+		url:     url,
+		header:  header,
+		client:  &http.Client{Timeout: httpTimeout},
+		backoff: defaultBackoff(),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Send implements Channel
+func (w *Webhook) Send(ctx context.Context, n Notification) error {
+	payload := webhookPayload{
+		Title:    n.Title,
+		Message:  n.Message,
+		Priority: n.Priority.String(),
+		Source:   "joe",
+		Time:     w.now().UTC(),
+	}
+	err := w.backoff.do(ctx, w.logger, "webhook", func() error {
+		_, err := postJSON(ctx, w.client, w.url, w.header, payload)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	return nil
+}
@@ -2,10 +2,16 @@ package observability
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
@@ -27,7 +33,8 @@ const (
 
 // Config holds OpenTelemetry configuration
 type Config struct {
-	Enabled bool
+	Enabled     bool
+	ServiceName string // Reported with traces and metrics; "joe" if empty
 
 	// Tracing
 	TracesEnabled  bool
@@ -37,7 +44,22 @@ type Config struct {
 	// Metrics
 	MetricsEnabled  bool
 	MetricsExporter string // "prometheus", "none"
-	MetricsPort     int    // Prometheus port
+	MetricsPort     int    // Prometheus port; 0 registers the metrics without serving them
+}
+
+// FromConfig returns the configuration set by the telemetry section of
+// the config file, for the named service serving metrics on metricsPort
+func FromConfig(tc config.TelemetryConfig, service string, metricsPort int) Config {
+	return Config{
+		Enabled:         tc.Enabled,
+		ServiceName:     service,
+		TracesEnabled:   tc.TracesExporter != "" && tc.TracesExporter != "none",
+		TracesExporter:  tc.TracesExporter,
+		OTLPEndpoint:    tc.OTLPEndpoint,
+		MetricsEnabled:  tc.MetricsExporter != "" && tc.MetricsExporter != "none",
+		MetricsExporter: tc.MetricsExporter,
+		MetricsPort:     metricsPort,
+	}
 }
 
 // DefaultConfig returns default OpenTelemetry configuration
@@ -56,13 +78,17 @@ func DefaultConfig() Config {
 // Setup initializes OpenTelemetry with the given configuration
 func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
 	if !cfg.Enabled {
-		log.Println("OpenTelemetry disabled")
+		slog.Debug("OpenTelemetry disabled")
 		return func(context.Context) error { return nil }, nil
 	}
+	name := cfg.ServiceName
+	if name == "" {
+		name = serviceName
+	}
 
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
-			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceNameKey.String(name),
 			semconv.ServiceVersionKey.String(serviceVersion),
 		),
 	)
@@ -84,6 +110,9 @@ func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error)
 	if cfg.MetricsEnabled {
 		shutdownMetricsFn, err = setupMetrics(ctx, cfg, res)
 		if err != nil {
+			if shutdownTraceFn != nil {
+				shutdownTraceFn(ctx)
+			}
 			return nil, fmt.Errorf("failed to setup metrics: %w", err)
 		}
 	}
@@ -166,7 +195,37 @@ func setupMetrics(ctx context.Context, cfg Config, res *resource.Resource) (func
 
 	otel.SetMeterProvider(mp)
 
-	return mp.Shutdown, nil
+	if cfg.MetricsPort <= 0 {
+		return mp.Shutdown, nil
+	}
+	stopServer, err := serveMetrics(cfg.MetricsPort)
+	if err != nil {
+		mp.Shutdown(ctx)
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		return errors.Join(stopServer(ctx), mp.Shutdown(ctx))
+	}, nil
+}
+
+// serveMetrics serves the default Prometheus registry, where the exporter
+// registers, at /metrics on port. The port is bound before returning so
+// a conflict is reported to the caller.
+func serveMetrics(port int) (func(context.Context) error, error) {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+	slog.Info("serving metrics", "addr", ln.Addr().String())
+	return server.Shutdown, nil
 }
 
 // Tracer returns a tracer for the given name
@@ -0,0 +1,45 @@
+package observability
+
+import (
+	"testing"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		telemetry   config.TelemetryConfig
+		wantTraces  bool
+		wantMetrics bool
+	}{
+		{
+			name:        "otlp traces and prometheus",
+			telemetry:   config.TelemetryConfig{Enabled: true, TracesExporter: "otlp", MetricsExporter: "prometheus"},
+			wantTraces:  true,
+			wantMetrics: true,
+		},
+		{
+			name:        "metrics only",
+			telemetry:   config.TelemetryConfig{Enabled: true, TracesExporter: "none", MetricsExporter: "prometheus"},
+			wantMetrics: true,
+		},
+		{
+			name:      "nothing exported",
+			telemetry: config.TelemetryConfig{Enabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := FromConfig(tt.telemetry, "joecored", 9464)
+			if cfg.TracesEnabled != tt.wantTraces || cfg.MetricsEnabled != tt.wantMetrics {
+				t.Errorf("FromConfig() traces %v, metrics %v; want %v, %v",
+					cfg.TracesEnabled, cfg.MetricsEnabled, tt.wantTraces, tt.wantMetrics)
+			}
+			if cfg.ServiceName != "joecored" || cfg.MetricsPort != 9464 {
+				t.Errorf("FromConfig() = %+v, want service joecored on port 9464", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package onboard detects what a new Joe install can use (LLM API keys,
+// the current git repository, the kubeconfig) and writes the initial
+// config for `joe init`
+package onboard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/client"
+	"github.com/jaimegago/joe/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Model is a model joe init can set up
+type Model struct {
+	Name   string             // Key in llm.available
+	Config config.ModelConfig // Its llm.available entry
+	KeyEnv []string           // API key variables, any one of which is enough
+	Found  string             // The variable that is set; empty if none is
+}
+
+// catalog is what joe init offers, by provider
+var catalog = []Model{
+	{
+		Name: "claude-sonnet",
+		Config: config.ModelConfig{Provider: "claude", Model: "claude-sonnet-4-20250514",
+			Pricing: &config.ModelPricing{InputPerMillion: 3.00, OutputPerMillion: 15.00}},
+		KeyEnv: []string{"ANTHROPIC_API_KEY"},
+	},
+	{
+		Name:   "gemini-flash",
+		Config: config.ModelConfig{Provider: "gemini", Model: "gemini-2.5-flash"},
+		KeyEnv: []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"},
+	},
+	{
+		Name:   "gemini-pro",
+		Config: config.ModelConfig{Provider: "gemini", Model: "gemini-2.5-pro"},
+		KeyEnv: []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"},
+	},
+	{
+		Name: "gpt-4o",
+		Config: config.ModelConfig{Provider: "openai-compatible", Model: "gpt-4o",
+			BaseURL: "https://api.openai.com/v1", APIKeyEnv: "OPENAI_API_KEY"},
+		KeyEnv: []string{"OPENAI_API_KEY"},
+	},
+}
+
+// DetectModels returns the models joe init offers, marking those whose
+// API key is set in the environment, as read by getenv
+func DetectModels(getenv func(string) string) []Model {
+	models := make([]Model, len(catalog))
+	copy(models, catalog)
+	for i, m := range models {
+		for _, env := range m.KeyEnv {
+			if getenv(env) != "" {
+				models[i].Found = env
+				break
+			}
+		}
+	}
+	return models
+}
+
+// DetectSources suggests sources to register: the git repository dir is
+// in, and the current context of the kubeconfig at kubeconfig. Either is
+// left out when it can't be found.
+func DetectSources(ctx context.Context, dir, kubeconfig string) []client.Source {
+	var sources []client.Source
+	if src, ok := gitSource(ctx, dir); ok {
+		sources = append(sources, src)
+	}
+	if src, ok := kubeSource(kubeconfig); ok {
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// sourceID turns a name into a source ID, e.g. "My Repo" into "my-repo"
+func sourceID(prefix, name string) string {
+	id := strings.Trim(nonIDChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	return prefix + id
+}
+
+var nonIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// gitSource registers the repository by its origin URL, or by its path if
+// it has no origin
+func gitSource(ctx context.Context, dir string) (client.Source, bool) {
+	top, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return client.Source{}, false
+	}
+	url, err := git(ctx, dir, "remote", "get-url", "origin")
+	if err != nil {
+		url = top
+	}
+	name := filepath.Base(top)
+	return client.Source{
+		ID:                sourceID("git-", name),
+		Type:              "git",
+		URL:               url,
+		Name:              name,
+		ConnectionDetails: map[string]any{"path": top},
+		DiscoveredFrom:    "joe init",
+		DiscoveryContext:  "current git repository " + top,
+	}, true
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// kubeconfig is the part of a kubeconfig file needed to find the current
+// context's cluster
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server string `yaml:"server"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+}
+
+// kubeSource registers the cluster of the kubeconfig's current context,
+// reached through that context so its credentials apply
+func kubeSource(path string) (client.Source, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return client.Source{}, false
+	}
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil || kc.CurrentContext == "" {
+		return client.Source{}, false
+	}
+
+	var server string
+	for _, c := range kc.Contexts {
+		if c.Name != kc.CurrentContext {
+			continue
+		}
+		for _, cl := range kc.Clusters {
+			if cl.Name == c.Context.Cluster {
+				server = cl.Cluster.Server
+			}
+		}
+	}
+	return client.Source{
+		ID:   sourceID("k8s-", kc.CurrentContext),
+		Type: "kubernetes",
+		URL:  server,
+		Name: kc.CurrentContext,
+		ConnectionDetails: map[string]any{
+			"context":    kc.CurrentContext,
+			"kubeconfig": path,
+		},
+		DiscoveredFrom:   "joe init",
+		DiscoveryContext: "current context of " + path,
+	}, true
+}
+
+// KubeconfigPath returns the kubeconfig kubectl would use: the first file
+// in $KUBECONFIG, or ~/.kube/config
+func KubeconfigPath(getenv func(string) string) string {
+	if env := getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+	path, err := config.ExpandHome("~/.kube/config")
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// Render returns the config file for the chosen model. Every setting not
+// in it keeps its default, so the file stays short.
+func Render(m Model) ([]byte, error) {
+	file := struct {
+		LLM struct {
+			Current   string                        `yaml:"current"`
+			Available map[string]config.ModelConfig `yaml:"available"`
+		} `yaml:"llm"`
+	}{}
+	file.LLM.Current = m.Name
+	file.LLM.Available = map[string]config.ModelConfig{m.Name: m.Config}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Written by joe init. See CONFIG.md for all settings, and check edits\n")
+	buf.WriteString("# with: joe config validate\n\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteConfig writes data to path, creating its directory. An existing file
+// is kept as path.bak.
+func WriteConfig(path string, data []byte) error {
+	path, err := config.ExpandHome(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, path+".bak"); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
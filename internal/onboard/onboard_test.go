@@ -0,0 +1,88 @@
+package onboard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+func TestDetectModels(t *testing.T) {
+	env := map[string]string{"GOOGLE_API_KEY": "key"}
+	found := map[string]string{}
+	for _, m := range DetectModels(func(k string) string { return env[k] }) {
+		found[m.Name] = m.Found
+	}
+	if found["gemini-flash"] != "GOOGLE_API_KEY" {
+		t.Errorf("gemini-flash found by %q, want GOOGLE_API_KEY", found["gemini-flash"])
+	}
+	if found["claude-sonnet"] != "" {
+		t.Errorf("claude-sonnet found by %q without ANTHROPIC_API_KEY", found["claude-sonnet"])
+	}
+}
+
+func TestDetectSources_Kubeconfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	data := `current-context: Prod US
+contexts:
+- name: Prod US
+  context:
+    cluster: prod
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+`
+	if err := os.WriteFile(kubeconfig, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A temp dir outside any git repository, so only the cluster is found
+	sources := DetectSources(context.Background(), t.TempDir(), kubeconfig)
+	if len(sources) != 1 {
+		t.Fatalf("DetectSources() = %+v, want the cluster", sources)
+	}
+	src := sources[0]
+	if src.ID != "k8s-prod-us" || src.Type != "kubernetes" || src.URL != "https://prod.example.com:6443" {
+		t.Errorf("source = %+v", src)
+	}
+	if src.ConnectionDetails["context"] != "Prod US" {
+		t.Errorf("context = %v, want Prod US", src.ConnectionDetails["context"])
+	}
+
+	if sources := DetectSources(context.Background(), t.TempDir(), filepath.Join(t.TempDir(), "missing")); len(sources) != 0 {
+		t.Errorf("DetectSources() without a kubeconfig = %+v", sources)
+	}
+}
+
+func TestRenderAndWriteConfig(t *testing.T) {
+	models := DetectModels(func(string) string { return "" })
+	data, err := Render(models[1])
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "joe", "config.yaml")
+	for range 2 {
+		if err := WriteConfig(path, data); err != nil {
+			t.Fatalf("WriteConfig() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("existing config not backed up: %v", err)
+	}
+
+	problems, err := config.Check(path)
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("Check() = %v, %v; want a valid config", problems, err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if mc, err := cfg.LLM.CurrentModel(); err != nil || mc.Model != models[1].Config.Model {
+		t.Errorf("CurrentModel() = %+v, %v; want %s", mc, err, models[1].Config.Model)
+	}
+}
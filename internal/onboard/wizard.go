@@ -0,0 +1,223 @@
+package onboard
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jaimegago/joe/internal/client"
+)
+
+// step is a screen of the wizard
+type step int
+
+const (
+	stepModel   step = iota // Pick the default model
+	stepSources             // Pick the sources to register
+	stepConfirm             // Review and write the config
+)
+
+var (
+	headerStyle    = lipgloss.NewStyle().Bold(true)
+	highlightStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("cyan"))
+	hintStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// Wizard is a bubbletea model that walks through choosing the default
+// model and the sources to register, then confirms writing the config
+type Wizard struct {
+	models    []Model
+	sources   []client.Source
+	chosen    []bool // Per source; all start chosen
+	path      string // Config file to write
+	exists    bool   // Whether path already exists
+	step      step
+	cursor    int
+	model     int  // Index of the chosen model
+	done      bool // Confirmed
+	cancelled bool // User pressed Esc
+}
+
+// NewWizard creates a wizard offering models and sources, for writing the
+// config at path. At least one model must have its API key set.
+func NewWizard(models []Model, sources []client.Source, path string, exists bool) *Wizard {
+	w := &Wizard{
+		models:  models,
+		sources: sources,
+		chosen:  make([]bool, len(sources)),
+		path:    path,
+		exists:  exists,
+	}
+	for i := range w.chosen {
+		w.chosen[i] = true
+	}
+	for i, m := range models {
+		if m.Found != "" {
+			w.cursor = i
+			break
+		}
+	}
+	return w
+}
+
+// Init implements tea.Model
+func (w *Wizard) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (w *Wizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return w, nil
+	}
+	switch key.String() {
+	case "esc", "ctrl+c":
+		w.cancelled = true
+		return w, tea.Quit
+	}
+
+	switch w.step {
+	case stepModel:
+		switch key.String() {
+		case "up", "k":
+			w.cursor = max(w.cursor-1, 0)
+		case "down", "j":
+			w.cursor = min(w.cursor+1, len(w.models)-1)
+		case "enter":
+			if w.models[w.cursor].Found == "" {
+				return w, nil // No API key to use it with
+			}
+			w.next()
+		}
+	case stepSources:
+		switch key.String() {
+		case "up", "k":
+			w.cursor = max(w.cursor-1, 0)
+		case "down", "j":
+			w.cursor = min(w.cursor+1, len(w.sources)-1)
+		case " ", "x":
+			w.chosen[w.cursor] = !w.chosen[w.cursor]
+		case "enter":
+			w.next()
+		}
+	case stepConfirm:
+		switch key.String() {
+		case "enter", "y":
+			w.done = true
+			return w, tea.Quit
+		case "n":
+			w.cancelled = true
+			return w, tea.Quit
+		}
+	}
+	return w, nil
+}
+
+// next moves to the following step, skipping sources when none were found
+func (w *Wizard) next() {
+	if w.step == stepModel {
+		w.model = w.cursor
+	}
+	w.step++
+	if w.step == stepSources && len(w.sources) == 0 {
+		w.step++
+	}
+	w.cursor = 0
+}
+
+// View implements tea.Model
+func (w *Wizard) View() string {
+	var b strings.Builder
+	switch w.step {
+	case stepModel:
+		b.WriteString(headerStyle.Render("Choose Joe's default model:"))
+		b.WriteString("\n")
+		for i, m := range w.models {
+			status := "found " + m.Found
+			if m.Found == "" {
+				status = "set " + strings.Join(m.KeyEnv, " or ") + " to use"
+			}
+			line := fmt.Sprintf("%s %-14s %s/%s (%s)", pointer(i == w.cursor), m.Name, m.Config.Provider, m.Config.Model, status)
+			b.WriteString(w.style(i, line, m.Found == ""))
+		}
+		b.WriteString(hintStyle.Render("\nUse ↑/↓ to navigate, Enter to select, Esc to cancel"))
+
+	case stepSources:
+		b.WriteString(headerStyle.Render("Register these sources with joecored?"))
+		b.WriteString("\n")
+		for i, s := range w.sources {
+			check := "[ ]"
+			if w.chosen[i] {
+				check = "[x]"
+			}
+			line := fmt.Sprintf("%s %s %-20s %s %s", pointer(i == w.cursor), check, s.ID, s.Type, s.DiscoveryContext)
+			b.WriteString(w.style(i, line, false))
+		}
+		b.WriteString(hintStyle.Render("\nUse ↑/↓ to navigate, Space to toggle, Enter to continue, Esc to cancel"))
+
+	case stepConfirm:
+		m := w.models[w.model]
+		b.WriteString(headerStyle.Render("Write " + w.path + "?"))
+		b.WriteString(fmt.Sprintf("\n  Default model: %s (%s/%s)\n", m.Name, m.Config.Provider, m.Config.Model))
+		for _, s := range w.Sources() {
+			b.WriteString(fmt.Sprintf("  Register:      %s (%s)\n", s.ID, s.Type))
+		}
+		if w.exists {
+			b.WriteString(fmt.Sprintf("  The current file is kept as %s.bak\n", w.path))
+		}
+		b.WriteString(hintStyle.Render("\nEnter to write the config, Esc to cancel"))
+	}
+	return b.String()
+}
+
+func pointer(here bool) string {
+	if here {
+		return ">"
+	}
+	return " "
+}
+
+// style renders a list line, highlighted under the cursor and dimmed when
+// it can't be chosen
+func (w *Wizard) style(i int, line string, disabled bool) string {
+	switch {
+	case disabled:
+		line = hintStyle.Render(line)
+	case i == w.cursor:
+		line = highlightStyle.Render(line)
+	}
+	return line + "\n"
+}
+
+// Model returns the chosen model
+func (w *Wizard) Model() Model {
+	return w.models[w.model]
+}
+
+// Sources returns the sources chosen for registering
+func (w *Wizard) Sources() []client.Source {
+	var sources []client.Source
+	for i, s := range w.sources {
+		if w.chosen[i] {
+			sources = append(sources, s)
+		}
+	}
+	return sources
+}
+
+// Confirmed reports whether the user confirmed writing the config
+func (w *Wizard) Confirmed() bool {
+	return w.done && !w.cancelled
+}
+
+// RunWizard runs the wizard in the terminal and returns it once the user
+// confirms or cancels
+func RunWizard(models []Model, sources []client.Source, path string, exists bool) (*Wizard, error) {
+	final, err := tea.NewProgram(NewWizard(models, sources, path, exists)).Run()
+	if err != nil {
+		return nil, fmt.Errorf("error running onboarding: %w", err)
+	}
+	return final.(*Wizard), nil
+}
@@ -0,0 +1,66 @@
+package onboard
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jaimegago/joe/internal/client"
+)
+
+func press(w *Wizard, keys ...string) {
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		}
+		w.Update(msg)
+	}
+}
+
+func TestWizard(t *testing.T) {
+	env := map[string]string{"GEMINI_API_KEY": "key"}
+	models := DetectModels(func(k string) string { return env[k] })
+	sources := []client.Source{{ID: "git-joe", Type: "git"}, {ID: "k8s-prod", Type: "kubernetes"}}
+
+	tests := []struct {
+		name        string
+		sources     []client.Source
+		keys        []string
+		wantOK      bool
+		wantModel   string
+		wantSources int
+	}{
+		{name: "defaults", sources: sources, keys: []string{"enter", "enter", "enter"}, wantOK: true, wantModel: "gemini-flash", wantSources: 2},
+		{name: "next model, one source", sources: sources, keys: []string{"down", "enter", " ", "enter", "enter"}, wantOK: true, wantModel: "gemini-pro", wantSources: 1},
+		{name: "no key for model", sources: sources, keys: []string{"down", "down", "enter", "k", "enter", "enter", "enter"}, wantOK: true, wantModel: "gemini-pro", wantSources: 2},
+		{name: "no sources found", keys: []string{"enter", "enter"}, wantOK: true, wantModel: "gemini-flash"},
+		{name: "cancelled", sources: sources, keys: []string{"enter", "esc"}},
+		{name: "declined", sources: sources, keys: []string{"enter", "enter", "n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWizard(models, tt.sources, "~/.joe/config.yaml", false)
+			press(w, tt.keys...)
+			if w.Confirmed() != tt.wantOK {
+				t.Fatalf("Confirmed() = %v, want %v", w.Confirmed(), tt.wantOK)
+			}
+			if !tt.wantOK {
+				return
+			}
+			if got := w.Model().Name; got != tt.wantModel {
+				t.Errorf("Model() = %s, want %s", got, tt.wantModel)
+			}
+			if got := len(w.Sources()); got != tt.wantSources {
+				t.Errorf("Sources() has %d sources, want %d", got, tt.wantSources)
+			}
+		})
+	}
+}
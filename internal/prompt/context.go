@@ -0,0 +1,81 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// maxContextBytes caps each context file so a large file can't crowd the
+// conversation out of the context window
+const maxContextBytes = 32 * 1024
+
+// ContextFile is a JOE.md file whose contents are added to the system prompt
+type ContextFile struct {
+	Path      string // Absolute path
+	Content   string
+	Truncated bool // Content was cut at maxContextBytes
+}
+
+// LoadContext reads the context files among paths that exist, in order.
+// Paths may start with ~ and relative paths are resolved against the
+// working directory. Missing files are skipped; a path that resolves to an
+// already loaded file is read once.
+func LoadContext(paths []string) ([]ContextFile, error) {
+	var files []ContextFile
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		expanded, err := config.ExpandHome(p)
+		if err != nil {
+			return nil, err
+		}
+		abs, err := filepath.Abs(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid context file path %s: %w", p, err)
+		}
+		if seen[abs] {
+			continue
+		}
+		seen[abs] = true
+
+		data, err := os.ReadFile(abs)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read context file: %w", err)
+		}
+
+		file := ContextFile{Path: abs, Content: strings.TrimSpace(string(data))}
+		if len(file.Content) > maxContextBytes {
+			file.Content = file.Content[:maxContextBytes]
+			file.Truncated = true
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// WithContext appends context files to a system prompt, each under a
+// heading naming its path
+func WithContext(systemPrompt string, files []ContextFile) string {
+	if len(files) == 0 {
+		return systemPrompt
+	}
+
+	var b strings.Builder
+	b.WriteString(systemPrompt)
+	b.WriteString("\n\nThe user provided the following context about their environment and projects. Follow its instructions.")
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n\n# Context from %s\n\n%s", f.Path, f.Content)
+		if f.Truncated {
+			b.WriteString("\n\n(truncated)")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadContext(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "user.md")
+	project := filepath.Join(dir, "project.md")
+	large := filepath.Join(dir, "large.md")
+	os.WriteFile(user, []byte("Prefer kubectl over the dashboard.\n"), 0o644)
+	os.WriteFile(project, []byte("This repo deploys to eu-west-1."), 0o644)
+	os.WriteFile(large, []byte(strings.Repeat("x", maxContextBytes+10)), 0o644)
+
+	tests := []struct {
+		name          string
+		paths         []string
+		wantContents  []string
+		wantTruncated bool
+	}{
+		{
+			name:         "in order, missing skipped",
+			paths:        []string{user, filepath.Join(dir, "missing.md"), project},
+			wantContents: []string{"Prefer kubectl over the dashboard.", "This repo deploys to eu-west-1."},
+		},
+		{
+			name:         "duplicate path read once",
+			paths:        []string{project, filepath.Join(dir, ".", "project.md")},
+			wantContents: []string{"This repo deploys to eu-west-1."},
+		},
+		{
+			name:          "large file truncated",
+			paths:         []string{large},
+			wantContents:  []string{strings.Repeat("x", maxContextBytes)},
+			wantTruncated: true,
+		},
+		{
+			name: "none configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := LoadContext(tt.paths)
+			if err != nil {
+				t.Fatalf("LoadContext() error = %v", err)
+			}
+			if len(files) != len(tt.wantContents) {
+				t.Fatalf("got %d files, want %d", len(files), len(tt.wantContents))
+			}
+			for i, f := range files {
+				if f.Content != tt.wantContents[i] {
+					t.Errorf("files[%d].Content = %.40q, want %.40q", i, f.Content, tt.wantContents[i])
+				}
+				if f.Truncated != tt.wantTruncated {
+					t.Errorf("files[%d].Truncated = %v, want %v", i, f.Truncated, tt.wantTruncated)
+				}
+			}
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	if got := WithContext("base", nil); got != "base" {
+		t.Errorf("WithContext() with no files = %q, want base", got)
+	}
+
+	got := WithContext("base", []ContextFile{{Path: "/home/me/.joe/JOE.md", Content: "Use eu-west-1."}})
+	if !strings.HasPrefix(got, "base\n\n") {
+		t.Errorf("WithContext() = %q, want the base prompt first", got)
+	}
+	if !strings.Contains(got, "# Context from /home/me/.joe/JOE.md\n\nUse eu-west-1.") {
+		t.Errorf("WithContext() = %q, want the file under a heading", got)
+	}
+}
@@ -0,0 +1,84 @@
+// Package prompt builds Joe's system prompt from the prompt config section.
+// The prompt is a text/template rendered with details of the local
+// environment, so it can mention the host, directory, and tools in use.
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+// Default is the system prompt used when none is configured
+const Default = "You are Joe, an infrastructure assistant. You can use tools to help answer questions. Be concise."
+
+// Vars are the values available to prompt templates, e.g. {{.Hostname}}
+type Vars struct {
+	Hostname string // Local host name
+	Cwd      string // Directory joe was started in
+	Tools    string // Comma-separated names of the available tools
+	Time     string // Local time the prompt was rendered, RFC 1123
+	Model    string // Key of the active model in llm.available
+}
+
+// CurrentVars collects template values for this process. Lookup failures
+// leave the value empty rather than failing the prompt.
+func CurrentVars(toolNames []string, model string) Vars {
+	hostname, _ := os.Hostname()
+	cwd, _ := os.Getwd()
+	return Vars{
+		Hostname: hostname,
+		Cwd:      cwd,
+		Tools:    strings.Join(toolNames, ", "),
+		Time:     time.Now().Format(time.RFC1123),
+		Model:    model,
+	}
+}
+
+// Load returns the prompt template text: the contents of cfg.File if set,
+// else cfg.System, else Default
+func Load(cfg config.PromptConfig) (string, error) {
+	if cfg.File != "" {
+		path, err := config.ExpandHome(cfg.File)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		return string(data), nil
+	}
+	if cfg.System != "" {
+		return cfg.System, nil
+	}
+	return Default, nil
+}
+
+// Render executes text as a template with vars. Unknown fields are an error
+// so typos in a template are caught at startup.
+func Render(text string, vars Vars) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Build loads and renders the configured system prompt
+func Build(cfg config.PromptConfig, vars Vars) (string, error) {
+	text, err := Load(cfg)
+	if err != nil {
+		return "", err
+	}
+	return Render(text, vars)
+}
@@ -0,0 +1,78 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/config"
+)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "prompt.tmpl")
+	if err := os.WriteFile(file, []byte("From file on {{.Hostname}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vars := Vars{Hostname: "bastion", Cwd: "/srv/infra", Tools: "read_file, run_command", Model: "claude-sonnet"}
+
+	tests := []struct {
+		name    string
+		cfg     config.PromptConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: Default,
+		},
+		{
+			name: "inline template",
+			cfg:  config.PromptConfig{System: "You are Joe on {{.Hostname}} in {{.Cwd}} ({{.Model}}). Tools: {{.Tools}}."},
+			want: "You are Joe on bastion in /srv/infra (claude-sonnet). Tools: read_file, run_command.",
+		},
+		{
+			name: "file wins over inline",
+			cfg:  config.PromptConfig{System: "inline", File: file},
+			want: "From file on bastion",
+		},
+		{
+			name:    "missing file",
+			cfg:     config.PromptConfig{File: filepath.Join(dir, "missing")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown variable",
+			cfg:     config.PromptConfig{System: "{{.Hostnme}}"},
+			wantErr: true,
+		},
+		{
+			name:    "syntax error",
+			cfg:     config.PromptConfig{System: "{{.Hostname"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Build(tt.cfg, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentVars(t *testing.T) {
+	vars := CurrentVars([]string{"echo", "read_file"}, "gemini-flash")
+	if vars.Tools != "echo, read_file" {
+		t.Errorf("Tools = %q", vars.Tools)
+	}
+	if vars.Cwd == "" || vars.Time == "" || !strings.Contains(vars.Model, "gemini") {
+		t.Errorf("vars = %+v", vars)
+	}
+}
@@ -0,0 +1,78 @@
+// Package redact masks secrets such as API keys, cloud credentials, bearer
+// tokens, and private keys in text before it is stored, logged, or sent to
+// an LLM
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces each secret found
+const Mask = "[REDACTED]"
+
+// DefaultPatterns match common credentials. In a pattern with a capture
+// group only the first group is masked, so the key name stays readable.
+var DefaultPatterns = []string{
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`,
+	`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{8,}=*)`,
+	`\bsk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}`,
+	`\bAIza[0-9A-Za-z_\-]{35}\b`,
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bxox[abprs]-[A-Za-z0-9\-]{10,}`,
+	`(?i)(?:api[_-]?key|secret|password|token)["']?\s*[:=]\s*["']?([^\s"',}]{8,})`,
+}
+
+// Redactor masks text matching a set of patterns. A nil Redactor masks
+// nothing.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// New creates a redactor for DefaultPatterns plus extra
+func New(extra []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range append(append([]string{}, DefaultPatterns...), extra...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns s with every secret replaced by Mask
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = mask(re, s)
+	}
+	return s
+}
+
+// mask replaces the matches of re in s, or their first group if re has one
+func mask(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Mask)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
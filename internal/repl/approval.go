@@ -0,0 +1,119 @@
+package repl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/useragent"
+	"github.com/muesli/cancelreader"
+)
+
+// maxApprovalArgLength caps how much of each argument is shown in the prompt
+const maxApprovalArgLength = 200
+
+// NewApprover returns a tools.ApproveFunc that asks for y/N confirmation on
+// out and reads the answer from in. Anything other than y or yes declines.
+//
+// in is read one byte at a time so no input is buffered away from the REPL's
+// own reader on the same stream. Cancelling ctx, e.g. with Ctrl+C, stops
+// waiting for the answer and declines.
+func NewApprover(in io.Reader, out io.Writer) tools.ApproveFunc {
+	return func(ctx context.Context, name string, args map[string]any) (bool, error) {
+		fmt.Fprintf(out, "\nJoe wants to run %s:\n", name)
+		keys := make([]string, 0, len(args))
+		for k := range args {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(out, "  %s: %s\n", k, formatApprovalArg(args[k]))
+		}
+		fmt.Fprint(out, "Allow? [y/N] ")
+		return confirm(ctx, in, out)
+	}
+}
+
+// NewPlanApprover returns a useragent.PlanApprover that asks for y/N
+// confirmation of a plan, already shown by the progress display, as
+// NewApprover does for tool calls
+func NewPlanApprover(in io.Reader, out io.Writer) useragent.PlanApprover {
+	return func(ctx context.Context, plan useragent.Plan) (bool, error) {
+		fmt.Fprint(out, "Run this plan? [y/N] ")
+		return confirm(ctx, in, out)
+	}
+}
+
+// confirm reads the answer to a y/N prompt on out from in
+func confirm(ctx context.Context, in io.Reader, out io.Writer) (bool, error) {
+	answer, err := readAnswer(ctx, in)
+	if ctx.Err() != nil {
+		fmt.Fprintln(out)
+		return false, ctx.Err()
+	}
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// readAnswer reads a line from in, giving up when ctx is cancelled. Reads
+// from a terminal or pipe are cancelled outright; other readers are only
+// given up on after their next byte.
+func readAnswer(ctx context.Context, in io.Reader) (string, error) {
+	r, err := cancelreader.NewReader(in)
+	if err != nil {
+		return readLine(in) // e.g. a regular file, which can't be polled
+	}
+	defer r.Close()
+	stop := context.AfterFunc(ctx, func() { r.Cancel() })
+	defer stop()
+	return readLine(r)
+}
+
+// formatApprovalArg renders an argument value on one line
+func formatApprovalArg(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(data)
+		}
+	}
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if len(s) > maxApprovalArgLength {
+		s = strings.ToValidUTF8(s[:maxApprovalArgLength], "") + "..."
+	}
+	return s
+}
+
+// readLine reads up to and excluding the next newline
+func readLine(in io.Reader) (string, error) {
+	var sb strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return sb.String(), nil
+			}
+			sb.WriteByte(buf[0])
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+func TestNewApprover(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    bool
+		wantErr bool
+	}{
+		{"yes", "y\n", true, false},
+		{"yes spelled out", " YES \n", true, false},
+		{"empty defaults to no", "\n", false, false},
+		{"anything else", "sure\n", false, false},
+		{"answer without newline", "y", true, false},
+		{"eof", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			approve := NewApprover(strings.NewReader(tt.input), &out)
+
+			got, err := approve(context.Background(), "run_command", map[string]any{
+				"command": "rm",
+				"args":    []any{"-rf", "/tmp/build"},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("approve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("approve() = %v, want %v", got, tt.want)
+			}
+
+			prompt := out.String()
+			for _, want := range []string{"run_command", `args: ["-rf","/tmp/build"]`, "command: rm", "[y/N]"} {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("prompt %q missing %q", prompt, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewApprover_LeavesFollowingInput(t *testing.T) {
+	in := strings.NewReader("n\nnext question\n")
+	approve := NewApprover(in, &bytes.Buffer{})
+
+	if _, err := approve(context.Background(), "write_file", nil); err != nil {
+		t.Fatalf("approve() error: %v", err)
+	}
+	if rest, _ := readLine(in); rest != "next question" {
+		t.Errorf("remaining input = %q, want %q", rest, "next question")
+	}
+}
+
+func TestNewApprover_Cancelled(t *testing.T) {
+	in, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	ok, err := NewApprover(in, &bytes.Buffer{})(ctx, "run_command", nil)
+	if ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("approve() = %v, %v; want a decline with context.Canceled", ok, err)
+	}
+}
+
+func TestNewPlanApprover(t *testing.T) {
+	for input, want := range map[string]bool{"y\n": true, "\n": false, "no\n": false} {
+		var out bytes.Buffer
+		got, err := NewPlanApprover(strings.NewReader(input), &out)(context.Background(), useragent.Plan{Steps: []string{"List pods"}})
+		if err != nil {
+			t.Fatalf("approve(%q) error: %v", input, err)
+		}
+		if got != want {
+			t.Errorf("approve(%q) = %v, want %v", input, got, want)
+		}
+		if !strings.Contains(out.String(), "Run this plan? [y/N]") {
+			t.Errorf("prompt = %q, want the y/N question", out.String())
+		}
+	}
+}
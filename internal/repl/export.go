@@ -0,0 +1,206 @@
+package repl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// handleExportCommand writes the current session to a file, as JSON if it
+// ends in .json and Markdown otherwise: /export [file]. Without a file it
+// writes joe-session-<id>.md in the working directory; a leading ~ is the
+// home directory.
+func (r *REPL) handleExportCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: /export [<file.md|.json>]")
+	}
+	if len(r.session.Messages) == 0 {
+		fmt.Println("No messages in this session yet")
+		return nil
+	}
+	path := "joe-session-" + r.session.ID + ".md"
+	if len(args) == 1 {
+		var err error
+		if path, err = config.ExpandHome(args[0]); err != nil {
+			return err
+		}
+	}
+	// The model answering now, which after a failover isn't llm.current
+	model := r.config.LLM.Current
+	if r.agent != nil {
+		model = r.agent.CurrentModelName()
+	}
+
+	var buf bytes.Buffer
+	format := "Markdown"
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = "JSON"
+		if err := writeSessionJSON(&buf, r.session, model); err != nil {
+			return err
+		}
+	} else {
+		writeSessionMarkdown(&buf, r.session, model)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	fmt.Printf("Wrote %s (%s, %d messages)\n", path, format, len(r.session.Messages))
+	return nil
+}
+
+// exportedSession is the JSON form of an exported session
+type exportedSession struct {
+	ID         string            `json:"id"`
+	StartedAt  time.Time         `json:"started_at"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Model      string            `json:"model,omitempty"`
+	Tokens     exportedTokens    `json:"tokens"`
+	Messages   []exportedMessage `json:"messages"`
+}
+
+type exportedTokens struct {
+	Input   int     `json:"input"`
+	Output  int     `json:"output"`
+	Total   int     `json:"total"`
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+type exportedMessage struct {
+	Role       string             `json:"role"` // user, assistant, or tool
+	Content    string             `json:"content,omitempty"`
+	Timestamp  *time.Time         `json:"timestamp,omitempty"`
+	ToolCalls  []exportedToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string             `json:"tool_call_id,omitempty"`
+	ToolName   string             `json:"tool_name,omitempty"`
+	IsError    bool               `json:"is_error,omitempty"`
+}
+
+type exportedToolCall struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// writeSessionJSON writes session, and the model it was last run with, as
+// an indented JSON document
+func writeSessionJSON(w io.Writer, session *useragent.Session, model string) error {
+	out := exportedSession{
+		ID:         session.ID,
+		StartedAt:  session.StartedAt,
+		ExportedAt: time.Now(),
+		Model:      model,
+		Tokens: exportedTokens{
+			Input:   session.TotalInputTokens,
+			Output:  session.TotalOutputTokens,
+			Total:   session.TotalTokens,
+			CostUSD: session.TotalCostUSD,
+		},
+		Messages: make([]exportedMessage, 0, len(session.Messages)),
+	}
+	for _, msg := range session.Messages {
+		m := exportedMessage{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolResultID,
+			ToolName:   msg.ToolName,
+			IsError:    msg.IsError,
+		}
+		if msg.ToolResultID != "" {
+			m.Role = "tool"
+		}
+		if !msg.Timestamp.IsZero() {
+			m.Timestamp = &msg.Timestamp
+		}
+		for _, tc := range msg.ToolCalls {
+			m.ToolCalls = append(m.ToolCalls, exportedToolCall{ID: tc.ID, Name: tc.Name, Arguments: tc.Args})
+		}
+		out.Messages = append(out.Messages, m)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	return nil
+}
+
+// writeSessionMarkdown writes session as a Markdown document: a summary of
+// the session, then each message under a heading, with tool arguments and
+// results in code blocks
+func writeSessionMarkdown(w io.Writer, session *useragent.Session, model string) {
+	fmt.Fprintf(w, "# Joe session %s\n\n", session.ID)
+	fmt.Fprintf(w, "- Started: %s\n", session.StartedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "- Exported: %s\n", time.Now().Format(time.DateTime))
+	if model != "" {
+		fmt.Fprintf(w, "- Model: %s\n", model)
+	}
+	fmt.Fprintf(w, "- Messages: %d\n", len(session.Messages))
+	fmt.Fprintf(w, "- Tokens: %d input, %d output, %d total\n",
+		session.TotalInputTokens, session.TotalOutputTokens, session.TotalTokens)
+	if session.TotalCostUSD > 0 {
+		fmt.Fprintf(w, "- Estimated cost: $%.4f\n", session.TotalCostUSD)
+	}
+
+	for _, msg := range session.Messages {
+		fmt.Fprintf(w, "\n## %s\n\n", markdownHeading(msg))
+		switch {
+		case msg.ToolResultID != "":
+			writeCodeBlock(w, "", msg.Content)
+		case msg.Content != "":
+			fmt.Fprintf(w, "%s\n", strings.TrimRight(msg.Content, "\n"))
+		}
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(w, "\nTool call `%s` (%s):\n\n", tc.Name, tc.ID)
+			args, err := json.MarshalIndent(tc.Args, "", "  ")
+			if err != nil {
+				args = []byte(fmt.Sprint(tc.Args))
+			}
+			writeCodeBlock(w, "json", string(args))
+		}
+	}
+}
+
+// markdownHeading names who a message is from, and when
+func markdownHeading(msg llm.Message) string {
+	var heading string
+	switch {
+	case msg.ToolResultID != "" && msg.IsError:
+		heading = fmt.Sprintf("Tool error: %s (%s)", msg.ToolName, msg.ToolResultID)
+	case msg.ToolResultID != "":
+		heading = fmt.Sprintf("Tool result: %s (%s)", msg.ToolName, msg.ToolResultID)
+	case msg.Role == "assistant":
+		heading = "Joe"
+	default:
+		heading = "User"
+	}
+	if !msg.Timestamp.IsZero() {
+		heading += " · " + msg.Timestamp.Local().Format("15:04:05")
+	}
+	return heading
+}
+
+// writeCodeBlock fences text with more backticks than it contains in a
+// row, so results that hold Markdown can't end the block early
+func writeCodeBlock(w io.Writer, lang, text string) {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	fmt.Fprintf(w, "%s%s\n%s\n%s\n", fence, lang, strings.TrimRight(text, "\n"), fence)
+}
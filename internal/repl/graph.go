@@ -0,0 +1,43 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jaimegago/joe/internal/client"
+)
+
+// handleGraphCommand writes the graph joecored keeps to a file, as DOT,
+// GraphML, or JSON by its extension: /graph export <file> [<node> [<depth>]]
+// exports the whole graph, or the neighborhood of a node
+func (r *REPL) handleGraphCommand(ctx context.Context, args []string) error {
+	if r.graph == nil {
+		return fmt.Errorf("the graph is kept by joecored, which is not connected")
+	}
+	if len(args) < 2 || len(args) > 4 || args[0] != "export" {
+		return fmt.Errorf("usage: /graph export <file.dot|.graphml|.json> [<node> [<depth>]]")
+	}
+
+	e := client.GraphExport{Format: client.ExportFormat(args[1])}
+	if len(args) > 2 {
+		e.Node = args[2]
+	}
+	if len(args) > 3 {
+		depth, err := strconv.Atoi(args[3])
+		if err != nil || depth < 1 {
+			return fmt.Errorf("depth must be a positive number, got %q", args[3])
+		}
+		e.Depth = depth
+	}
+	data, err := r.graph.ExportGraph(ctx, e)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(args[1], data, 0o644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	fmt.Printf("Wrote %s (%s, %d bytes)\n", args[1], e.Format, len(data))
+	return nil
+}
@@ -0,0 +1,82 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// historyContentWidth is the maximum number of characters of message content
+// shown per /history line
+const historyContentWidth = 80
+
+// handleHistoryCommand lists the current session's messages, or clears them
+// with "/history clear"
+func (r *REPL) handleHistoryCommand(args []string) error {
+	if len(args) > 0 {
+		if args[0] != "clear" {
+			return fmt.Errorf("unknown /history argument %q. Usage: /history [clear]", args[0])
+		}
+		count := len(r.session.Messages)
+		r.session.Clear()
+		fmt.Printf("Cleared %d messages from the session\n", count)
+		return nil
+	}
+
+	if len(r.session.Messages) == 0 {
+		fmt.Println("No messages in this session yet")
+		return nil
+	}
+
+	writeHistory(os.Stdout, r.session.Messages)
+	return nil
+}
+
+// writeHistory prints one line per message: index, time, role, and
+// truncated content
+func writeHistory(w io.Writer, messages []llm.Message) {
+	for i, msg := range messages {
+		timestamp := "--:--:--"
+		if !msg.Timestamp.IsZero() {
+			timestamp = msg.Timestamp.Local().Format("15:04:05")
+		}
+		fmt.Fprintf(w, "%3d  %s  %-16s %s\n", i+1, timestamp, historyRole(msg), historyContent(msg))
+	}
+}
+
+// historyRole labels tool results with the tool name so they stand out from
+// what the user actually typed
+func historyRole(msg llm.Message) string {
+	if msg.ToolResultID != "" {
+		if msg.IsError {
+			return "tool:" + msg.ToolName + "!"
+		}
+		return "tool:" + msg.ToolName
+	}
+	return msg.Role
+}
+
+// historyContent returns the message content on a single line, truncated to
+// historyContentWidth, with any tool calls appended
+func historyContent(msg llm.Message) string {
+	content := strings.Join(strings.Fields(msg.Content), " ")
+	if runes := []rune(content); len(runes) > historyContentWidth {
+		content = string(runes[:historyContentWidth-3]) + "..."
+	}
+
+	if len(msg.ToolCalls) > 0 {
+		names := make([]string, len(msg.ToolCalls))
+		for i, tc := range msg.ToolCalls {
+			names[i] = tc.Name
+		}
+		calls := "[calls " + strings.Join(names, ", ") + "]"
+		if content == "" {
+			return calls
+		}
+		content += " " + calls
+	}
+	return content
+}
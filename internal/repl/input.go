@@ -0,0 +1,209 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errInterrupted is returned by readLine when Ctrl+C cancels the line
+var errInterrupted = errors.New("input interrupted")
+
+// lineReader reads a line of input after showing prompt. It returns io.EOF
+// at the end of input (Ctrl+D).
+type lineReader interface {
+	readLine(ctx context.Context, prompt string) (string, error)
+}
+
+// newLineReader uses the line editor, completing words with complete, when
+// in is a terminal, and reads plain lines otherwise, e.g. from a pipe
+func newLineReader(in *os.File, out io.Writer, history *History, complete completer) lineReader {
+	if isTerminal(in) {
+		return &editorReader{in: in, out: out, history: history, complete: complete}
+	}
+	return &plainReader{in: in, out: out}
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// plainReader reads lines without editing, continuing input like the line
+// editor. Like the approval prompt, it reads a byte at a time so neither
+// buffers input away from the other.
+type plainReader struct {
+	in  io.Reader
+	out io.Writer
+}
+
+func (p *plainReader) readLine(ctx context.Context, prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+	input, err := readLine(p.in)
+	for err == nil && continues(input) {
+		input = trimContinuation(input) + "\n"
+		fmt.Fprint(p.out, continuationPrompt)
+		var line string
+		line, err = readLine(p.in)
+		input += line
+	}
+	if errors.Is(err, io.EOF) && input != "" {
+		return input, nil
+	}
+	return input, err
+}
+
+// editorReader reads lines with the line editor, recording them in history
+type editorReader struct {
+	in       *os.File
+	out      io.Writer
+	history  *History
+	complete completer
+}
+
+func (e *editorReader) readLine(ctx context.Context, prompt string) (string, error) {
+	editor := newLineEditor(prompt, e.history.Entries())
+	editor.complete = e.complete
+	final, err := tea.NewProgram(editor,
+		tea.WithContext(ctx), tea.WithInput(e.in), tea.WithOutput(e.out)).Run()
+	if err != nil {
+		return "", fmt.Errorf("error reading input: %w", err)
+	}
+
+	editor = final.(*lineEditor)
+	switch editor.state {
+	case editorEOF:
+		return "", io.EOF
+	case editorInterrupted:
+		return "", errInterrupted
+	}
+	line := editor.String()
+	if strings.TrimSpace(line) != "" {
+		if err := e.history.Add(line); err != nil {
+			fmt.Fprintf(e.out, "Warning: %v\n", err)
+		}
+	}
+	return line, nil
+}
+
+// maxHistory is how many entries the history keeps
+const maxHistory = 1000
+
+// History is the REPL's input history, persisted one entry per line when
+// it has a file
+type History struct {
+	path    string // empty keeps the history in memory
+	entries []string
+	lines   int // Entries in the file, which can hold more than entries
+}
+
+// LoadHistory reads the history file at path; a missing file is an empty
+// history. An empty path keeps the history in memory for the session.
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path}
+	if path == "" {
+		return h, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, decodeHistory(line))
+			h.lines++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	return h, nil
+}
+
+// Entries returns the history, oldest first
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Add appends line to the history, unless it repeats the last entry. The
+// file is rewritten with the most recent entries once it grows past twice
+// maxHistory.
+func (h *History) Add(line string) error {
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return nil
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	// Input can hold hostnames and the odd secret, so only the user can read it
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, encodeHistory(line)); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	if h.lines++; h.lines > 2*maxHistory {
+		return h.rewrite()
+	}
+	return nil
+}
+
+// rewrite replaces the file with the entries in memory
+func (h *History) rewrite() error {
+	var b strings.Builder
+	for _, e := range h.entries {
+		b.WriteString(encodeHistory(e))
+		b.WriteString("\n")
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	h.lines = len(h.entries)
+	return nil
+}
+
+// historyEscaper and historyUnescaper keep each entry on one line
+var (
+	historyEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	historyUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+func encodeHistory(line string) string {
+	return historyEscaper.Replace(line)
+}
+
+func decodeHistory(line string) string {
+	return historyUnescaper.Replace(line)
+}
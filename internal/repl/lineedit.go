@@ -0,0 +1,368 @@
+package repl
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// editorState is how a line editor finished, if it has
+type editorState int
+
+const (
+	editorEditing     editorState = iota
+	editorSubmitted               // Enter
+	editorInterrupted             // Ctrl+C
+	editorEOF                     // Ctrl+D on an empty line
+)
+
+var cursorStyle = lipgloss.NewStyle().Reverse(true)
+
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// continuationPrompt starts the lines after the first of multi-line input
+const continuationPrompt = "… "
+
+// blockQuote opens and closes a block of multi-line input
+const blockQuote = `"""`
+
+// continues reports whether input goes on past the line just ended: the
+// line ends with a backslash, or a """ block is still open
+func continues(input string) bool {
+	return strings.HasSuffix(input, `\`) || inBlock(input)
+}
+
+// inBlock reports whether input opened a """ block it hasn't closed
+func inBlock(input string) bool {
+	return strings.Count(input, blockQuote)%2 == 1
+}
+
+// trimContinuation drops the backslash that continued input, which inside
+// a """ block is kept as typed
+func trimContinuation(input string) string {
+	if inBlock(input) {
+		return input
+	}
+	return strings.TrimSuffix(input, `\`)
+}
+
+// unquoteBlock returns the text inside a """ block, or input unchanged if
+// it isn't one
+func unquoteBlock(input string) string {
+	if len(input) < 2*len(blockQuote) || !strings.HasPrefix(input, blockQuote) || !strings.HasSuffix(input, blockQuote) {
+		return input
+	}
+	return strings.Trim(input[len(blockQuote):len(input)-len(blockQuote)], "\n")
+}
+
+// lineEditor is a bubbletea model for editing input, with history (↑/↓ and
+// Ctrl+R search) and the usual readline keys. Input spans lines when pasted,
+// with Alt+Enter (Esc then Enter), after a trailing backslash, or inside a
+// """ block; see continues.
+type lineEditor struct {
+	prompt   string
+	buf      []rune
+	pos      int      // Cursor position in buf
+	history  []string // Oldest first
+	index    int      // History entry shown; len(history) is the draft
+	draft    []rune   // What was typed before browsing history
+	search   *historySearch
+	complete completer // nil disables Tab completion
+	choices  []string  // Completions shown after an ambiguous Tab
+	state    editorState
+}
+
+// completer returns the words that can follow before, the input up to the
+// cursor; the editor keeps those that start the word being typed
+type completer func(before string) []string
+
+// historySearch is a Ctrl+R reverse incremental search
+type historySearch struct {
+	query []rune
+	match int    // Index into history; -1 when nothing matches
+	saved []rune // The line before searching, restored on cancel
+}
+
+func newLineEditor(prompt string, history []string) *lineEditor {
+	return &lineEditor{prompt: prompt, history: history, index: len(history)}
+}
+
+// String returns the line as edited
+func (e *lineEditor) String() string {
+	return string(e.buf)
+}
+
+// Init implements tea.Model
+func (e *lineEditor) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (e *lineEditor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return e, nil
+	}
+	if e.search != nil && e.updateSearch(key) {
+		return e, e.quitIfDone()
+	}
+	e.choices = nil
+
+	switch key.Type {
+	case tea.KeyTab:
+		e.completeWord()
+	case tea.KeyEnter:
+		switch {
+		case key.Alt:
+			e.insert([]rune{'\n'})
+		case e.pos == len(e.buf) && continues(string(e.buf)):
+			e.buf = append([]rune(trimContinuation(string(e.buf))), '\n')
+			e.pos = len(e.buf)
+		default:
+			e.state = editorSubmitted
+		}
+	case tea.KeyCtrlC:
+		e.state = editorInterrupted
+	case tea.KeyCtrlD:
+		if len(e.buf) == 0 {
+			e.state = editorEOF
+		} else if e.pos < len(e.buf) {
+			e.buf = slices.Delete(e.buf, e.pos, e.pos+1)
+		}
+	case tea.KeyCtrlR:
+		e.search = &historySearch{match: -1, saved: slices.Clone(e.buf)}
+	case tea.KeyRunes, tea.KeySpace:
+		e.insert(key.Runes)
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		if e.pos > 0 {
+			e.buf = slices.Delete(e.buf, e.pos-1, e.pos)
+			e.pos--
+		}
+	case tea.KeyDelete:
+		if e.pos < len(e.buf) {
+			e.buf = slices.Delete(e.buf, e.pos, e.pos+1)
+		}
+	case tea.KeyLeft, tea.KeyCtrlB:
+		e.pos = max(e.pos-1, 0)
+	case tea.KeyRight, tea.KeyCtrlF:
+		e.pos = min(e.pos+1, len(e.buf))
+	case tea.KeyHome, tea.KeyCtrlA:
+		e.pos, _ = e.lineBounds(e.pos)
+	case tea.KeyEnd, tea.KeyCtrlE:
+		_, e.pos = e.lineBounds(e.pos)
+	case tea.KeyCtrlU:
+		start, _ := e.lineBounds(e.pos)
+		e.buf = slices.Delete(e.buf, start, e.pos)
+		e.pos = start
+	case tea.KeyCtrlK:
+		_, end := e.lineBounds(e.pos)
+		e.buf = slices.Delete(e.buf, e.pos, end)
+	case tea.KeyCtrlW:
+		start := e.pos
+		for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+		e.buf = slices.Delete(e.buf, start, e.pos)
+		e.pos = start
+	case tea.KeyUp, tea.KeyCtrlP:
+		if start, _ := e.lineBounds(e.pos); start > 0 {
+			e.moveLine(start - 1)
+		} else {
+			e.browse(e.index - 1)
+		}
+	case tea.KeyDown, tea.KeyCtrlN:
+		if _, end := e.lineBounds(e.pos); end < len(e.buf) {
+			e.moveLine(end + 1)
+		} else {
+			e.browse(e.index + 1)
+		}
+	}
+	return e, e.quitIfDone()
+}
+
+func (e *lineEditor) quitIfDone() tea.Cmd {
+	if e.state == editorEditing {
+		return nil
+	}
+	return tea.Quit
+}
+
+// insert adds typed or pasted text at the cursor. Terminals paste line
+// breaks as carriage returns.
+func (e *lineEditor) insert(runes []rune) {
+	runes = []rune(lineBreaks.Replace(string(runes)))
+	e.buf = slices.Insert(e.buf, e.pos, runes...)
+	e.pos += len(runes)
+}
+
+// completeWord completes the word before the cursor: fully when one
+// completion fits, else as far as they agree, listing them when that adds
+// nothing
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	before := string(e.buf[:e.pos])
+	word := before[strings.LastIndexAny(before, " \n")+1:]
+	var matches []string
+	for _, c := range e.complete(before) {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+	case 1:
+		e.insert([]rune(matches[0][len(word):] + " "))
+	default:
+		prefix := matches[0]
+		for _, m := range matches[1:] {
+			for !strings.HasPrefix(m, prefix) {
+				prefix = prefix[:len(prefix)-1]
+			}
+		}
+		if len(prefix) > len(word) {
+			e.insert([]rune(prefix[len(word):]))
+		} else {
+			e.choices = matches
+		}
+	}
+}
+
+// lineBounds returns where the line holding pos starts and ends in buf
+func (e *lineEditor) lineBounds(pos int) (start, end int) {
+	start = pos
+	for start > 0 && e.buf[start-1] != '\n' {
+		start--
+	}
+	end = pos
+	for end < len(e.buf) && e.buf[end] != '\n' {
+		end++
+	}
+	return start, end
+}
+
+// moveLine moves the cursor to the line holding pos, keeping its column
+// where that line is long enough
+func (e *lineEditor) moveLine(pos int) {
+	start, _ := e.lineBounds(e.pos)
+	column := e.pos - start
+	start, end := e.lineBounds(pos)
+	e.pos = min(start+column, end)
+}
+
+// browse shows history entry i, or the draft past the newest entry
+func (e *lineEditor) browse(i int) {
+	if i < 0 || i > len(e.history) || i == e.index {
+		return
+	}
+	if e.index == len(e.history) {
+		e.draft = slices.Clone(e.buf)
+	}
+	e.index = i
+	if i == len(e.history) {
+		e.buf = slices.Clone(e.draft)
+	} else {
+		e.buf = []rune(e.history[i])
+	}
+	e.pos = len(e.buf)
+}
+
+// updateSearch handles a key during a Ctrl+R search, and reports whether
+// it did. Other keys end the search, keeping the match to edit, and are
+// then handled as usual.
+func (e *lineEditor) updateSearch(key tea.KeyMsg) bool {
+	s := e.search
+	switch key.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		s.query = append(s.query, key.Runes...)
+		if !e.find(len(e.history) - 1) {
+			s.match = -1
+		}
+		return true
+	case tea.KeyBackspace, tea.KeyCtrlH:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+		}
+		if !e.find(len(e.history) - 1) {
+			s.match = -1
+		}
+		return true
+	case tea.KeyCtrlR:
+		if s.match > 0 {
+			e.find(s.match - 1)
+		}
+		return true
+	case tea.KeyCtrlC, tea.KeyCtrlG, tea.KeyEsc:
+		e.buf = s.saved
+		e.pos = len(e.buf)
+		e.search = nil
+		return true
+	}
+
+	if s.match >= 0 {
+		e.buf = []rune(e.history[s.match])
+		e.pos = len(e.buf)
+	}
+	e.search = nil
+	return false
+}
+
+// find moves the search to the newest entry at or before from that
+// contains the query, and reports whether there is one
+func (e *lineEditor) find(from int) bool {
+	if len(e.search.query) == 0 {
+		return false
+	}
+	for i := from; i >= 0; i-- {
+		if strings.Contains(e.history[i], string(e.search.query)) {
+			e.search.match = i
+			return true
+		}
+	}
+	return false
+}
+
+// View implements tea.Model
+func (e *lineEditor) View() string {
+	switch e.state {
+	case editorSubmitted:
+		return e.prompt + strings.ReplaceAll(string(e.buf), "\n", "\n"+continuationPrompt) + "\n"
+	case editorInterrupted:
+		return e.prompt + strings.ReplaceAll(string(e.buf), "\n", "\n"+continuationPrompt) + "^C\n"
+	case editorEOF:
+		return e.prompt + "\n"
+	}
+
+	if e.search != nil {
+		match := ""
+		if e.search.match >= 0 {
+			match = e.history[e.search.match]
+		}
+		return "(reverse-i-search)`" + string(e.search.query) + "': " + match + cursorStyle.Render(" ")
+	}
+
+	cursor := " "
+	after := ""
+	if e.pos < len(e.buf) {
+		after = string(e.buf[e.pos+1:])
+		if e.buf[e.pos] == '\n' {
+			after = "\n" + after
+		} else {
+			cursor = string(e.buf[e.pos])
+		}
+	}
+	text := string(e.buf[:e.pos]) + cursorStyle.Render(cursor) + after
+	view := e.prompt + strings.ReplaceAll(text, "\n", "\n"+continuationPrompt)
+	if len(e.choices) > 0 {
+		view += "\n" + strings.Join(e.choices, "  ")
+	}
+	return view
+}
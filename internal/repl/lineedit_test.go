@@ -0,0 +1,172 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeKeys sends keys to the editor: special keys by name, anything else
+// as typed text
+func typeKeys(e *lineEditor, keys ...string) {
+	special := map[string]tea.KeyType{
+		"enter": tea.KeyEnter, "ctrl+c": tea.KeyCtrlC, "ctrl+d": tea.KeyCtrlD, "ctrl+r": tea.KeyCtrlR,
+		"ctrl+u": tea.KeyCtrlU, "ctrl+w": tea.KeyCtrlW, "ctrl+a": tea.KeyCtrlA, "backspace": tea.KeyBackspace,
+		"tab": tea.KeyTab, "left": tea.KeyLeft, "up": tea.KeyUp, "down": tea.KeyDown, "esc": tea.KeyEsc,
+	}
+	for _, k := range keys {
+		if k == "alt+enter" {
+			e.Update(tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+			continue
+		}
+		if t, ok := special[k]; ok {
+			e.Update(tea.KeyMsg{Type: t})
+			continue
+		}
+		e.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	history := []string{"kubectl get pods", "why is api-0 failing?", "show the nginx config"}
+
+	tests := []struct {
+		name      string
+		keys      []string
+		wantState editorState
+		wantLine  string
+	}{
+		{name: "type", keys: []string{"hello", "enter"}, wantState: editorSubmitted, wantLine: "hello"},
+		{name: "edit mid-line", keys: []string{"helo", "left", "l", "enter"}, wantState: editorSubmitted, wantLine: "hello"},
+		{name: "backspace", keys: []string{"helloo", "backspace", "enter"}, wantState: editorSubmitted, wantLine: "hello"},
+		{name: "kill word", keys: []string{"get all pods", "ctrl+w", "enter"}, wantState: editorSubmitted, wantLine: "get all "},
+		{name: "kill line", keys: []string{"oops", "ctrl+u", "ok", "enter"}, wantState: editorSubmitted, wantLine: "ok"},
+		{name: "home", keys: []string{"world", "ctrl+a", "hello ", "enter"}, wantState: editorSubmitted, wantLine: "hello world"},
+		{name: "previous entry", keys: []string{"up", "up", "enter"}, wantState: editorSubmitted, wantLine: "why is api-0 failing?"},
+		{name: "back to draft", keys: []string{"dra", "up", "down", "ft", "enter"}, wantState: editorSubmitted, wantLine: "draft"},
+		{name: "search", keys: []string{"ctrl+r", "get", "enter"}, wantState: editorSubmitted, wantLine: "kubectl get pods"},
+		{name: "search older", keys: []string{"ctrl+r", "i", "ctrl+r", "enter"}, wantState: editorSubmitted, wantLine: "why is api-0 failing?"},
+		{name: "search then edit", keys: []string{"ctrl+r", "nginx", "left", "backspace", "enter"}, wantState: editorSubmitted, wantLine: "show the nginx confg"},
+		{name: "search cancelled", keys: []string{"mine", "ctrl+r", "pods", "esc", "enter"}, wantState: editorSubmitted, wantLine: "mine"},
+		{name: "ctrl+c cancels the line", keys: []string{"half typed", "ctrl+c"}, wantState: editorInterrupted, wantLine: "half typed"},
+		{name: "ctrl+d on empty line", keys: []string{"ctrl+d"}, wantState: editorEOF},
+		{name: "ctrl+d mid-line deletes", keys: []string{"ab", "left", "ctrl+d", "enter"}, wantState: editorSubmitted, wantLine: "a"},
+		{name: "alt+enter", keys: []string{"one", "alt+enter", "two", "enter"}, wantState: editorSubmitted, wantLine: "one\ntwo"},
+		{name: "trailing backslash", keys: []string{`one \`, "enter", "two", "enter"}, wantState: editorSubmitted, wantLine: "one \ntwo"},
+		{name: "block", keys: []string{`"""`, "enter", "a: 1", "enter", `b: \`, "enter", `"""`, "enter"}, wantState: editorSubmitted, wantLine: "\"\"\"\na: 1\nb: \\\n\"\"\""},
+		{name: "pasted lines", keys: []string{"kind: Pod\rmetadata:\r\n  name: x", "enter"}, wantState: editorSubmitted, wantLine: "kind: Pod\nmetadata:\n  name: x"},
+		{name: "block after text", keys: []string{`look: """`, "enter", "x", "enter", `"""`, "enter"}, wantState: editorSubmitted, wantLine: "look: \"\"\"\nx\n\"\"\""},
+		{name: "up moves between lines", keys: []string{"abc", "alt+enter", "de", "up", "X", "enter"}, wantState: editorSubmitted, wantLine: "abXc\nde"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newLineEditor("> ", history)
+			typeKeys(e, tt.keys...)
+			if e.state != tt.wantState {
+				t.Fatalf("state = %v, want %v", e.state, tt.wantState)
+			}
+			if got := e.String(); got != tt.wantLine {
+				t.Errorf("line = %q, want %q", got, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestPlainReader(t *testing.T) {
+	in := strings.NewReader("one \\\ntwo\n\"\"\"\na: 1\n\"\"\"\nlast")
+	var out bytes.Buffer
+	r := &plainReader{in: in, out: &out}
+
+	want := []string{"one \ntwo", "\"\"\"\na: 1\n\"\"\"", "last"}
+	for _, w := range want {
+		got, err := r.readLine(context.Background(), "> ")
+		if err != nil || got != w {
+			t.Fatalf("readLine() = %q, %v; want %q", got, err, w)
+		}
+	}
+	if _, err := r.readLine(context.Background(), "> "); !errors.Is(err, io.EOF) {
+		t.Errorf("readLine() at the end error = %v, want EOF", err)
+	}
+	if got := unquoteBlock(want[1]); got != "a: 1" {
+		t.Errorf("unquoteBlock() = %q, want the block's content", got)
+	}
+}
+
+func TestLineEditor_Complete(t *testing.T) {
+	complete := func(before string) []string {
+		if strings.HasPrefix(before, "/model ") {
+			return []string{"gemini-flash", "gemini-pro", "claude-sonnet"}
+		}
+		return []string{"/model", "/history"}
+	}
+
+	tests := []struct {
+		name        string
+		keys        []string
+		wantLine    string
+		wantChoices []string
+	}{
+		{name: "one match", keys: []string{"/mo", "tab"}, wantLine: "/model "},
+		{name: "then an argument", keys: []string{"/mo", "tab", "c", "tab"}, wantLine: "/model claude-sonnet "},
+		{name: "common prefix", keys: []string{"/model g", "tab"}, wantLine: "/model gemini-"},
+		{name: "ambiguous lists them", keys: []string{"/model gemini-", "tab"}, wantLine: "/model gemini-", wantChoices: []string{"gemini-flash", "gemini-pro"}},
+		{name: "no match", keys: []string{"/x", "tab"}, wantLine: "/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newLineEditor("> ", nil)
+			e.complete = complete
+			typeKeys(e, tt.keys...)
+			if e.String() != tt.wantLine {
+				t.Errorf("line = %q, want %q", e.String(), tt.wantLine)
+			}
+			if strings.Join(e.choices, " ") != strings.Join(tt.wantChoices, " ") {
+				t.Errorf("choices = %q, want %q", e.choices, tt.wantChoices)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory() of a missing file error = %v", err)
+	}
+	for _, line := range []string{"first", "second", "second", "multi\nline \\n"} {
+		if err := h.Add(line); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	h, err = LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory() error = %v", err)
+	}
+	want := []string{"first", "second", "multi\nline \\n"}
+	got := h.Entries()
+	if len(got) != len(want) {
+		t.Fatalf("Entries() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("history file mode = %v, want 0600", perm)
+	}
+}
@@ -0,0 +1,43 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// handleMemoryCommand lists the facts joe was asked to remember, or
+// forgets one with /memory delete <id>
+func (r *REPL) handleMemoryCommand(ctx context.Context, args []string) error {
+	if r.facts == nil {
+		return fmt.Errorf("memory is disabled (see memory.enabled in config.yaml)")
+	}
+
+	if len(args) > 0 {
+		if args[0] != "delete" || len(args) != 2 {
+			return fmt.Errorf("usage: /memory [delete <id>]")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("fact id must be a number, got %q", args[1])
+		}
+		if err := r.facts.DeleteFact(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("Forgot fact %d\n", id)
+		return nil
+	}
+
+	facts, err := r.facts.ListFacts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(facts) == 0 {
+		fmt.Println("No facts remembered. Ask joe to remember one, e.g. \"remember that the prod database is db-prod-3\".")
+		return nil
+	}
+	for _, f := range facts {
+		fmt.Printf("  %4d  %s  %s\n", f.ID, f.CreatedAt.Local().Format("2006-01-02"), f.Content)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// Progress modes (tools.progress)
+const (
+	progressLines    = "lines"    // A line per tool call, kept above the answer
+	progressCollapse = "collapse" // One line, rewritten per call, then a summary
+	progressOff      = "off"
+)
+
+// maxCallSummary caps how much of a call's arguments its line shows
+const maxCallSummary = 60
+
+// progress shows the tool calls of a run as they happen, e.g.
+// "→ read_file(/etc/nginx/nginx.conf)… done 0.2s"
+type progress struct {
+	out  io.Writer
+	mode string
+	live bool // out is a terminal, so the running call's line is rewritten
+
+	running string // Line of the call in progress, if any
+	calls   int
+	failed  int
+	elapsed time.Duration
+}
+
+func newProgress(out io.Writer, mode string, live bool) *progress {
+	if mode == "" {
+		mode = progressLines
+	}
+	return &progress{out: out, mode: mode, live: live}
+}
+
+// event is a useragent.EventHandler. Plans are shown whatever the mode.
+func (p *progress) event(e useragent.Event) {
+	if e.Type == useragent.EventPlan {
+		fmt.Fprint(p.out, e.Plan.String())
+		return
+	}
+	if p.mode == progressOff {
+		return
+	}
+	switch e.Type {
+	case useragent.EventToolCall:
+		p.running = "→ " + callSummary(e.ToolCall) + "…"
+		if p.live {
+			fmt.Fprint(p.out, "\r\033[K"+p.running)
+		}
+	case useragent.EventToolResult:
+		status := "done"
+		if e.Result.IsError {
+			status = "failed"
+			p.failed++
+		}
+		p.calls++
+		p.elapsed += e.Duration
+		line := fmt.Sprintf("%s %s %s", p.running, status, formatSeconds(e.Duration))
+		p.running = ""
+		switch {
+		case p.mode == progressLines && p.live:
+			// Rewritten in place, unless an approval prompt printed since,
+			// in which case this is a fresh line
+			fmt.Fprint(p.out, "\r\033[K"+line+"\n")
+		case p.mode == progressLines:
+			fmt.Fprintln(p.out, line)
+		case p.live:
+			fmt.Fprint(p.out, "\r\033[K"+line)
+		}
+	}
+}
+
+// finish ends the display once the run is over: a call cut short gets its
+// line ended, and collapsed calls are summed up
+func (p *progress) finish() {
+	if p.mode == progressOff {
+		return
+	}
+	if p.running != "" && p.live {
+		fmt.Fprintln(p.out)
+		p.running = ""
+	}
+	if p.mode != progressCollapse || p.calls == 0 {
+		return
+	}
+	summary := fmt.Sprintf("→ %d tool call%s, %s", p.calls, plural(p.calls), formatSeconds(p.elapsed))
+	if p.failed > 0 {
+		summary += fmt.Sprintf(" (%d failed)", p.failed)
+	}
+	if p.live {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+	fmt.Fprintln(p.out, summary)
+}
+
+// callSummary shows a tool call on one line, e.g. "run_command(df -h)",
+// with its argument values in name order
+func callSummary(tc llm.ToolCall) string {
+	var values []string
+	for _, k := range slices.Sorted(maps.Keys(tc.Args)) {
+		values = append(values, formatApprovalArg(tc.Args[k]))
+	}
+	args := strings.Join(values, ", ")
+	if r := []rune(args); len(r) > maxCallSummary {
+		args = string(r[:maxCallSummary]) + "..."
+	}
+	return tc.Name + "(" + args + ")"
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,97 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+func TestProgress(t *testing.T) {
+	// Two calls, the second of which fails
+	events := []useragent.Event{
+		{Type: useragent.EventText, Text: "Let me look."},
+		{Type: useragent.EventToolCall, ToolCall: llm.ToolCall{Name: "read_file", Args: map[string]any{"path": "/etc/nginx/nginx.conf"}}},
+		{Type: useragent.EventToolResult, Duration: 200 * time.Millisecond},
+		{Type: useragent.EventToolCall, ToolCall: llm.ToolCall{Name: "run_command", Args: map[string]any{"command": "df -h"}}},
+		{Type: useragent.EventToolResult, Result: llm.Message{IsError: true}, Duration: 1200 * time.Millisecond},
+	}
+
+	tests := []struct {
+		mode string
+		live bool
+		want string
+	}{
+		{
+			mode: "lines",
+			want: "→ read_file(/etc/nginx/nginx.conf)… done 0.2s\n→ run_command(df -h)… failed 1.2s\n",
+		},
+		{
+			mode: "lines",
+			live: true,
+			want: "\r\033[K→ read_file(/etc/nginx/nginx.conf)…\r\033[K→ read_file(/etc/nginx/nginx.conf)… done 0.2s\n" +
+				"\r\033[K→ run_command(df -h)…\r\033[K→ run_command(df -h)… failed 1.2s\n",
+		},
+		{
+			mode: "collapse",
+			want: "→ 2 tool calls, 1.4s (1 failed)\n",
+		},
+		{
+			mode: "collapse",
+			live: true,
+			want: "\r\033[K→ read_file(/etc/nginx/nginx.conf)…\r\033[K→ read_file(/etc/nginx/nginx.conf)… done 0.2s" +
+				"\r\033[K→ run_command(df -h)…\r\033[K→ run_command(df -h)… failed 1.2s" +
+				"\r\033[K→ 2 tool calls, 1.4s (1 failed)\n",
+		},
+		{mode: "off", live: true},
+	}
+	for _, tt := range tests {
+		name := tt.mode
+		if tt.live {
+			name += " live"
+		}
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := newProgress(&out, tt.mode, tt.live)
+			for _, e := range events {
+				p.event(e)
+			}
+			p.finish()
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestProgress_Interrupted(t *testing.T) {
+	var out bytes.Buffer
+	p := newProgress(&out, "lines", true)
+	p.event(useragent.Event{Type: useragent.EventToolCall, ToolCall: llm.ToolCall{Name: "run_command"}})
+	p.finish()
+	if !strings.HasSuffix(out.String(), "→ run_command()…\n") {
+		t.Errorf("output = %q, want the running call's line ended", out.String())
+	}
+}
+
+func TestProgress_Plan(t *testing.T) {
+	// Plans are shown even with progress off
+	var out bytes.Buffer
+	p := newProgress(&out, "off", false)
+	p.event(useragent.Event{Type: useragent.EventPlan, Plan: useragent.Plan{Goal: "Find the OOM", Steps: []string{"List pods", "Describe the restarting one"}}})
+	p.finish()
+	if want := "Plan: Find the OOM\n  1. List pods\n  2. Describe the restarting one\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestCallSummary(t *testing.T) {
+	tc := llm.ToolCall{Name: "write_file", Args: map[string]any{"path": "a.yaml", "content": strings.Repeat("x", 100)}}
+	got := callSummary(tc)
+	if want := "write_file(" + strings.Repeat("x", 60) + "...)"; got != want {
+		t.Errorf("callSummary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/logging"
+)
+
+// configReload is where /reload re-reads the config from, and where
+// /model --save writes the default model
+type configReload struct {
+	path  string
+	level *slog.LevelVar // nil leaves the log level alone
+}
+
+// reloadConfig re-reads the config file and applies changes to the log
+// level, the models, llm.show_usage, tools.progress, and the prompt settings, printing
+// every change and whether it took effect. The model in use is kept; /model
+// switches models.
+func (r *REPL) reloadConfig(ctx context.Context) error {
+	next, err := config.Load(r.reload.path)
+	if err != nil {
+		return fmt.Errorf("failed to reload config (keeping the current one): %w", err)
+	}
+	changes := config.Changes(r.config, next)
+	if len(changes) == 0 {
+		fmt.Println("Config unchanged")
+		return nil
+	}
+
+	available := maps.Clone(next.LLM.Available)
+	current := r.config.LLM.Current
+	if _, ok := available[current]; !ok {
+		// Keep the model in use until /model switches away from it
+		available[current] = r.config.LLM.Available[current]
+	}
+	if mc := available[current]; !reflect.DeepEqual(mc, r.config.LLM.Available[current]) {
+		if err := r.agent.SwitchModel(ctx, mc.Provider, mc.Model, current); err != nil {
+			fmt.Printf("Keeping the current %s settings: %v\n", current, err)
+			available[current] = r.config.LLM.Available[current]
+		}
+	}
+
+	old := *r.config
+	r.config.LLM.Available = available
+	r.config.LLM.ShowUsage = next.LLM.ShowUsage
+	r.config.Tools.Progress = next.Tools.Progress
+	r.config.Prompt = next.Prompt
+	if r.reload.level != nil {
+		r.config.Logging.Level = next.Logging.Level
+		r.reload.level.Set(logging.ParseLevel(next.Logging.Level))
+	}
+
+	for _, c := range config.Changes(&old, r.config) {
+		fmt.Printf("  %s (applied)\n", c)
+	}
+	for _, c := range config.Changes(r.config, next) {
+		fmt.Printf("  %s (%s)\n", c, pendingNote(c))
+	}
+	return nil
+}
+
+// pendingNote tells how to apply a change /reload couldn't
+func pendingNote(c config.Change) string {
+	switch {
+	case c.In("llm.current"):
+		return "use /model to switch"
+	case c.In("refresh"):
+		return "joecored applies it on SIGHUP"
+	default:
+		return "restart joe to apply"
+	}
+}
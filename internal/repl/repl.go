@@ -1,14 +1,21 @@
 package repl
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
 
+	"github.com/jaimegago/joe/internal/client"
 	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/prompt"
+	"github.com/jaimegago/joe/internal/store"
 	"github.com/jaimegago/joe/internal/useragent"
 )
 
@@ -16,13 +23,164 @@ var ErrExit = errors.New("exit requested")
 
 // REPL implements the Read-Eval-Print-Loop for interactive mode
 type REPL struct {
-	agent   *useragent.Agent
-	config  *config.Config
-	session *useragent.Session
+	agent    *useragent.Agent
+	config   *config.Config
+	session  *useragent.Session
+	sessions useragent.SessionStore // nil disables persistence and /resume
+	memory   SessionMemory          // nil: sessions aren't summarized on exit
+	facts    FactManager            // nil disables /memory
+	prompt   PromptBuilder          // nil: /reload leaves the prompt alone
+	context  ContextLoader          // nil disables /context
+	costs    CostReporter           // nil disables /cost
+	stats    StatsReporter          // nil disables /stats
+	sources  SourceManager          // nil disables /sources
+	graph    GraphExporter          // nil disables /graph
+	tools    ToolRegistry           // nil disables /tools
+	audit    TranscriptLocator      // nil when transcripts are off
+	reload   *configReload          // nil: /reload leaves the config alone
+	history  string                 // Input history file; empty keeps it for the session
+	input    lineReader             // nil reads from stdin
+}
+
+// SessionMemory summarizes a saved session for later recall
+type SessionMemory interface {
+	Remember(ctx context.Context, sessionID string) error
+}
+
+// FactManager lists and deletes the facts joe was asked to remember
+type FactManager interface {
+	ListFacts(ctx context.Context) ([]store.Fact, error)
+	DeleteFact(ctx context.Context, id int64) error
+}
+
+// PromptBuilder renders the system prompt from its current sources
+type PromptBuilder func() (string, error)
+
+// ContextLoader reads the JOE.md context files
+type ContextLoader func() ([]prompt.ContextFile, error)
+
+// CostReporter provides the daily LLM spending shown by /cost
+type CostReporter interface {
+	Days(ctx context.Context, n int) ([]store.DailyCost, error)
+	DailyBudget() float64
+}
+
+// StatsReporter provides the LLM call statistics shown by /stats
+type StatsReporter interface {
+	Stats() []llm.ModelStats
+}
+
+// SourceManager lists and tests the sources registered with joecored
+type SourceManager interface {
+	ListSources(ctx context.Context) ([]client.Source, error)
+	TestSource(ctx context.Context, id string) (*client.ConnectionTest, error)
+}
+
+// GraphExporter serializes the graph joecored keeps
+type GraphExporter interface {
+	ExportGraph(ctx context.Context, e client.GraphExport) ([]byte, error)
+}
+
+// TranscriptLocator tells where a session's transcript is written
+type TranscriptLocator interface {
+	Path(sessionID string) string
+}
+
+// Option configures optional REPL behavior
+type Option func(*REPL)
+
+// WithSessionStore enables saving the session on exit and the /resume command
+func WithSessionStore(st useragent.SessionStore) Option {
+	return func(r *REPL) {
+		r.sessions = st
+	}
+}
+
+// WithMemory summarizes the session on exit, after saving it, so later
+// sessions can recall it. It needs WithSessionStore.
+func WithMemory(m SessionMemory) Option {
+	return func(r *REPL) {
+		r.memory = m
+	}
+}
+
+// WithFacts enables the /memory command, which lists and deletes the facts
+// remembered with the remember tool
+func WithFacts(f FactManager) Option {
+	return func(r *REPL) {
+		r.facts = f
+	}
+}
+
+// WithPromptBuilder enables the /reload command, which rebuilds the system
+// prompt (re-reading prompt files and template values)
+func WithPromptBuilder(build PromptBuilder) Option {
+	return func(r *REPL) {
+		r.prompt = build
+	}
+}
+
+// WithConfigReload makes /reload re-read the config file at path and apply
+// what it can without a restart, including the log level through level
+// (nil leaves it alone). /model --save writes to the same file.
+func WithConfigReload(path string, level *slog.LevelVar) Option {
+	return func(r *REPL) {
+		r.reload = &configReload{path: path, level: level}
+	}
+}
+
+// WithHistoryFile keeps input history across sessions in path
+func WithHistoryFile(path string) Option {
+	return func(r *REPL) {
+		r.history = path
+	}
+}
+
+// WithContextLoader enables the /context command for viewing and reloading
+// JOE.md context files
+func WithContextLoader(load ContextLoader) Option {
+	return func(r *REPL) {
+		r.context = load
+	}
+}
+
+// WithCostTracker enables the /cost command
+func WithCostTracker(costs CostReporter) Option {
+	return func(r *REPL) {
+		r.costs = costs
+	}
+}
+
+// WithStats enables the /stats command
+func WithStats(stats StatsReporter) Option {
+	return func(r *REPL) {
+		r.stats = stats
+	}
+}
+
+// WithSources enables the /sources command
+func WithSources(sources SourceManager) Option {
+	return func(r *REPL) {
+		r.sources = sources
+	}
+}
+
+// WithGraphExport enables the /graph command
+func WithGraphExport(graph GraphExporter) Option {
+	return func(r *REPL) {
+		r.graph = graph
+	}
+}
+
+// WithTranscripts tells /transcript where run transcripts are written
+func WithTranscripts(t TranscriptLocator) Option {
+	return func(r *REPL) {
+		r.audit = t
+	}
 }
 
 // New creates a new REPL with the given agent and config
-// The session is created with default settings (no message limit)
+// The session is created with default settings
 func New(a *useragent.Agent, cfg *config.Config) *REPL {
 	return &REPL{
 		agent:   a,
@@ -32,35 +190,52 @@ func New(a *useragent.Agent, cfg *config.Config) *REPL {
 }
 
 // NewWithSession creates a new REPL with the given agent, config, and session
-// This allows callers to configure session settings (like MaxMessages) before starting the REPL
-func NewWithSession(a *useragent.Agent, cfg *config.Config, session *useragent.Session) *REPL {
-	return &REPL{
+// This allows callers to resume a saved session or prepare one before starting the REPL
+func NewWithSession(a *useragent.Agent, cfg *config.Config, session *useragent.Session, opts ...Option) *REPL {
+	r := &REPL{
 		agent:   a,
 		config:  cfg,
 		session: session,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Run starts the REPL loop
 // Prints welcome message, then loops reading input and calling the agent
-// Exits on "exit", "quit", or Ctrl+D (EOF)
+// Exits on /exit, /quit, or Ctrl+D (EOF); the session is saved on exit
+// when a session store is configured
 func (r *REPL) Run(ctx context.Context) error {
+	defer r.saveSession(ctx)
+
 	fmt.Println("Joe is ready.")
 	fmt.Println()
 
-	scanner := bufio.NewScanner(os.Stdin)
+	if r.input == nil {
+		history, err := LoadHistory(r.history)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			history, _ = LoadHistory("")
+		}
+		r.input = newLineReader(os.Stdin, os.Stdout, history, r.complete)
+	}
 
 	for {
-		// Print prompt
-		fmt.Print("> ")
-
-		// Read input
-		if !scanner.Scan() {
-			// EOF (Ctrl+D) or error
+		// Read input; Ctrl+C cancels the line, Ctrl+D (EOF) exits
+		line, err := r.input.readLine(ctx, "> ")
+		if errors.Is(err, errInterrupted) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return err
+		}
 
-		input := strings.TrimSpace(scanner.Text())
+		input := unquoteBlock(strings.TrimSpace(line))
 
 		// Skip empty input
 		if input == "" {
@@ -80,27 +255,87 @@ func (r *REPL) Run(ctx context.Context) error {
 			continue
 		}
 
-		// Run the agent
-		response, err := r.agent.Run(ctx, r.session, input)
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			fmt.Println()
-			continue
-		}
-
-		// Print response
-		fmt.Println(response)
+		r.runAgent(ctx, input)
 		fmt.Println()
 	}
 
-	// Check for scanner errors
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading input: %w", err)
+	return nil
+}
+
+// runAgent runs the agent on input, showing its tool calls as they run,
+// and prints its answer. Ctrl+C (SIGINT) cancels the run instead of exiting
+// joe; the session keeps what the run added before it was interrupted.
+func (r *REPL) runAgent(ctx context.Context, input string) {
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	p := newProgress(os.Stdout, r.config.Tools.Progress, isTerminal(os.Stdout))
+	response, err := r.agent.RunWithEvents(runCtx, r.session, input, p.event)
+	p.finish()
+	if runCtx.Err() != nil && ctx.Err() == nil {
+		fmt.Println("\ninterrupted")
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	fmt.Println(response)
+	if r.session.RunIncomplete {
+		fmt.Println("\n(Incomplete: Joe hit its limit of steps for one request. Say \"continue\" to pick up from here.)")
 	}
+	if r.config.LLM.ShowUsage {
+		r.printRunUsage()
+	}
+}
+
+// commands are the REPL commands, for completion
+var commands = []string{"/model", "/tokens", "/cost", "/stats", "/history", "/resume", "/reload", "/context",
+	"/compact", "/memory", "/sources", "/graph", "/tools", "/transcript", "/export", "/sessions", "/help", "/exit", "/quit"}
 
+// complete is the line editor's completer: command names, then model and
+// provider names after /model and tool names after /tools
+func (r *REPL) complete(before string) []string {
+	if !strings.HasPrefix(before, "/") {
+		return nil
+	}
+	args := strings.Fields(before)
+	if !strings.HasSuffix(before, " ") {
+		args = args[:len(args)-1] // The word being completed
+	}
+
+	switch {
+	case len(args) == 0:
+		return commands
+	case args[0] == "/model" && len(args) == 1:
+		return append(append(r.config.LLM.ModelNames(), r.providers()...), "list", "--save")
+	case args[0] == "/model" && len(args) == 2 && args[1] == "--save":
+		return append(r.config.LLM.ModelNames(), r.providers()...)
+	case args[0] == "/model" && len(args) == 2 && args[1] == "list":
+		return r.providers()
+	case args[0] == "/sessions" && len(args) == 1:
+		return []string{"open", "rename"}
+	case args[0] == "/tools" && r.tools != nil && len(args) == 1:
+		return append(r.toolNames(), "disable", "enable")
+	case args[0] == "/tools" && r.tools != nil && len(args) == 2 && (args[1] == "disable" || args[1] == "enable"):
+		return r.toolNames()
+	}
 	return nil
 }
 
+// providers returns the providers of the configured models
+func (r *REPL) providers() []string {
+	var providers []string
+	for _, mc := range r.config.LLM.Available {
+		if !slices.Contains(providers, mc.Provider) {
+			providers = append(providers, mc.Provider)
+		}
+	}
+	slices.Sort(providers)
+	return providers
+}
+
 // handleCommand processes REPL commands starting with /
 func (r *REPL) handleCommand(ctx context.Context, input string) error {
 	cmd := strings.TrimPrefix(input, "/")
@@ -111,7 +346,37 @@ func (r *REPL) handleCommand(ctx context.Context, input string) error {
 
 	switch parts[0] {
 	case "model":
-		return r.handleModelCommand(ctx)
+		return r.handleModelCommand(ctx, parts[1:])
+	case "tokens":
+		return r.handleTokensCommand()
+	case "cost":
+		return r.handleCostCommand(ctx)
+	case "stats":
+		return r.handleStatsCommand()
+	case "history":
+		return r.handleHistoryCommand(parts[1:])
+	case "resume":
+		return r.handleResumeCommand(ctx)
+	case "sessions":
+		return r.handleSessionsCommand(ctx, parts[1:])
+	case "reload":
+		return r.handleReloadCommand(ctx)
+	case "context":
+		return r.handleContextCommand(parts[1:])
+	case "compact":
+		return r.handleCompactCommand(ctx)
+	case "memory":
+		return r.handleMemoryCommand(ctx, parts[1:])
+	case "sources":
+		return r.handleSourcesCommand(ctx, parts[1:])
+	case "graph":
+		return r.handleGraphCommand(ctx, parts[1:])
+	case "tools":
+		return r.handleToolsCommand(parts[1:])
+	case "transcript":
+		return r.handleTranscriptCommand()
+	case "export":
+		return r.handleExportCommand(parts[1:])
 	case "help":
 		return r.handleHelpCommand()
 	case "exit", "quit":
@@ -121,8 +386,11 @@ func (r *REPL) handleCommand(ctx context.Context, input string) error {
 	}
 }
 
-// handleModelCommand shows an interactive model selector and switches models
-func (r *REPL) handleModelCommand(ctx context.Context) error {
+// handleModelCommand switches models: to the one named, or through an
+// interactive selector, limited to a provider's models if one is named.
+// --save also makes it the default in the config file; /model list prints
+// the models instead.
+func (r *REPL) handleModelCommand(ctx context.Context, args []string) error {
 	models := r.config.LLM.ModelNames()
 	current := r.config.LLM.Current
 
@@ -131,7 +399,38 @@ func (r *REPL) handleModelCommand(ctx context.Context) error {
 		return nil
 	}
 
+	save := false
+	if i := slices.Index(args, "--save"); i >= 0 {
+		if r.reload == nil {
+			return fmt.Errorf("there is no config file to save the model in")
+		}
+		save = true
+		args = slices.Delete(slices.Clone(args), i, i+1)
+	}
+
+	switch {
+	case len(args) > 0 && args[0] == "list" && !save:
+		return r.listModels(args[1:])
+	case len(args) == 1:
+		if _, ok := r.config.LLM.Available[args[0]]; ok {
+			return r.switchModel(ctx, args[0], save)
+		}
+		// A provider narrows the picker to its models
+		models = r.modelsOf(args[0])
+		if len(models) == 0 {
+			return fmt.Errorf("no model or provider named %s (see /model list)", args[0])
+		}
+		if len(models) == 1 {
+			return r.switchModel(ctx, models[0], save)
+		}
+	case len(args) > 1:
+		return fmt.Errorf("usage: /model [--save] [<name> | <provider>] or /model list [<provider>]")
+	}
+
 	if len(models) == 1 {
+		if save {
+			return r.switchModel(ctx, current, save)
+		}
 		fmt.Printf("Only one model configured: %s\n", current)
 		return nil
 	}
@@ -146,34 +445,246 @@ func (r *REPL) handleModelCommand(ctx context.Context) error {
 		fmt.Println("Cancelled")
 		return nil
 	}
+	return r.switchModel(ctx, selected, save)
+}
+
+// switchModel makes the configured model name the one in use, and with
+// save, the default in the config file too
+func (r *REPL) switchModel(ctx context.Context, name string, save bool) error {
+	if save {
+		defer func() {
+			if name != r.config.LLM.Current {
+				return // The switch failed
+			}
+			if err := config.SaveCurrentModel(r.reload.path, name); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+				return
+			}
+			fmt.Printf("Saved %s as the default model in %s\n", name, r.reload.path)
+		}()
+	}
 
-	if selected == current {
-		fmt.Printf("Already using %s\n", current)
+	if name == r.config.LLM.Current {
+		fmt.Printf("Already using %s\n", name)
 		return nil
 	}
 
 	// Get the model config
-	modelCfg, ok := r.config.LLM.Available[selected]
+	modelCfg, ok := r.config.LLM.Available[name]
 	if !ok {
-		return fmt.Errorf("model %s not found in config", selected)
+		return fmt.Errorf("model %s not found in config", name)
 	}
 
 	// Switch the model
-	if err := r.agent.SwitchModel(ctx, modelCfg.Provider, modelCfg.Model, selected); err != nil {
+	if err := r.agent.SwitchModel(ctx, modelCfg.Provider, modelCfg.Model, name); err != nil {
 		return fmt.Errorf("failed to switch model: %w", err)
 	}
 
 	// Update config current
-	r.config.LLM.Current = selected
+	r.config.LLM.Current = name
+
+	fmt.Printf("\nSwitched to %s (%s/%s)\n", name, modelCfg.Provider, modelCfg.Model)
+	return nil
+}
+
+// listModels prints the configured models, only those of a provider if
+// one is given
+func (r *REPL) listModels(args []string) error {
+	models := r.config.LLM.ModelNames()
+	switch len(args) {
+	case 0:
+	case 1:
+		if models = r.modelsOf(args[0]); len(models) == 0 {
+			return fmt.Errorf("no models configured for provider %s", args[0])
+		}
+	default:
+		return fmt.Errorf("usage: /model list [<provider>]")
+	}
+	for _, name := range models {
+		mc := r.config.LLM.Available[name]
+		marker := " "
+		if name == r.config.LLM.Current {
+			marker = "*"
+		}
+		fmt.Printf("%s %-24s %s/%s\n", marker, name, mc.Provider, mc.Model)
+	}
+	return nil
+}
+
+// modelsOf returns the names of the configured models of a provider
+func (r *REPL) modelsOf(provider string) []string {
+	var names []string
+	for _, name := range r.config.LLM.ModelNames() {
+		if r.config.LLM.Available[name].Provider == provider {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// handleResumeCommand saves the current session and replaces it with the
+// most recent previous one
+func (r *REPL) handleResumeCommand(ctx context.Context) error {
+	if r.sessions == nil {
+		return fmt.Errorf("session persistence is not configured")
+	}
 
-	fmt.Printf("\nSwitched to %s (%s/%s)\n", selected, modelCfg.Provider, modelCfg.Model)
+	if err := r.persistSession(ctx); err != nil {
+		return err
+	}
+
+	previous, err := useragent.LatestSession(ctx, r.sessions, r.session.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		fmt.Println("No previous session to resume")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find previous session: %w", err)
+	}
+
+	r.session.Restore(previous)
+	fmt.Printf("Resumed session %s (%d messages)\n", previous.ID, len(previous.Messages))
+	return nil
+}
+
+// handleReloadCommand re-reads the config file, if configured, and rebuilds
+// the system prompt. The conversation is kept; the new prompt applies from
+// the next message.
+func (r *REPL) handleReloadCommand(ctx context.Context) error {
+	if r.prompt == nil && r.reload == nil {
+		return fmt.Errorf("prompt reloading is not configured")
+	}
+	if r.reload != nil {
+		if err := r.reloadConfig(ctx); err != nil {
+			return err
+		}
+	}
+	if r.prompt == nil {
+		return nil
+	}
+	return r.reloadPrompt()
+}
+
+// reloadPrompt rebuilds the system prompt
+func (r *REPL) reloadPrompt() error {
+	if r.prompt == nil {
+		return fmt.Errorf("prompt reloading is not configured")
+	}
+	systemPrompt, err := r.prompt()
+	if err != nil {
+		return fmt.Errorf("failed to reload system prompt: %w", err)
+	}
+	r.agent.SetSystemPrompt(systemPrompt)
+	fmt.Printf("Reloaded system prompt (%d characters)\n", len(systemPrompt))
+	return nil
+}
+
+// handleContextCommand shows the JOE.md context files on disk, or with
+// "reload" rebuilds the system prompt from them
+func (r *REPL) handleContextCommand(args []string) error {
+	if len(args) > 0 {
+		if args[0] != "reload" {
+			return fmt.Errorf("unknown /context option %q (use /context or /context reload)", args[0])
+		}
+		return r.reloadPrompt()
+	}
+
+	if r.context == nil {
+		return fmt.Errorf("context files are not configured")
+	}
+	files, err := r.context()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("No context files found (see prompt.context_files)")
+		return nil
+	}
+	for _, f := range files {
+		note := ""
+		if f.Truncated {
+			note = ", truncated"
+		}
+		fmt.Printf("── %s (%d bytes%s)\n%s\n\n", f.Path, len(f.Content), note, f.Content)
+	}
+	fmt.Println("Edits apply after /context reload")
+	return nil
+}
+
+// handleCompactCommand replaces the whole conversation with a summary
+// written by the current model
+func (r *REPL) handleCompactCommand(ctx context.Context) error {
+	if len(r.session.Messages) == 0 {
+		fmt.Println("Nothing to compact yet")
+		return nil
+	}
+
+	fmt.Println("Summarizing the conversation...")
+	result, err := r.agent.Compact(ctx, r.session, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Compacted %d messages: ~%s → ~%s tokens (saved ~%s)\n",
+		result.Summarized,
+		formatCount(result.TokensBefore),
+		formatCount(result.TokensAfter),
+		formatCount(result.TokensBefore-result.TokensAfter))
+	return nil
+}
+
+// saveSession titles and persists the session and has memory summarize
+// it, reporting but not failing on errors
+func (r *REPL) saveSession(ctx context.Context) {
+	if r.sessions == nil {
+		return
+	}
+	if err := r.persistSession(ctx); err != nil {
+		slog.Warn("failed to save session", "session", r.session.ID, "error", err)
+		fmt.Fprintf(os.Stderr, "Warning: session not saved: %v\n", err)
+		return
+	}
+	if r.memory != nil && len(r.session.Messages) > 0 {
+		if err := r.memory.Remember(ctx, r.session.ID); err != nil {
+			slog.Warn("failed to summarize session", "session", r.session.ID, "error", err)
+		}
+	}
+}
+
+// handleTranscriptCommand shows the current session's transcript file
+func (r *REPL) handleTranscriptCommand() error {
+	if r.audit == nil {
+		fmt.Println("Transcripts are off. Set audit.enabled: true in the config to keep them.")
+		return nil
+	}
+	path := r.audit.Path(r.session.ID)
+	if _, err := os.Stat(path); err != nil {
+		fmt.Printf("Transcript: %s (written after the first answer)\n", path)
+		return nil
+	}
+	fmt.Printf("Transcript: %s\n", path)
 	return nil
 }
 
 // handleHelpCommand displays available commands
 func (r *REPL) handleHelpCommand() error {
 	help := `Available commands:
-  /model    - Switch LLM model
+  /model    - Switch LLM model (/model <name> directly, --save to keep it as the default,
+              /model list [<provider>] to list them)
+  /history  - Show conversation history (/history clear to wipe it)
+  /resume   - Resume the previous session
+  /sessions - List saved sessions (/sessions open <id> to switch to one, /sessions rename <title>)
+  /reload   - Re-read config.yaml and the system prompt, applying what changed
+  /context  - Show JOE.md context files (/context reload to apply edits)
+  /compact  - Replace the conversation with a summary to free up context
+  /tokens   - Show token usage (and cost, if priced) for the last run and session
+  /cost     - Show estimated spending for the session, today by model, and the last 7 days
+  /stats    - Show LLM calls, errors, tokens, and latency per model since joe started
+  /memory   - List remembered facts (/memory delete <id> to forget one)
+  /sources  - List registered sources (/sources test <id> to check one)
+  /graph    - Export the graph: /graph export <file.dot|.graphml|.json> [<node> [<depth>]]
+  /tools    - List tools (/tools <name> to inspect one, /tools disable|enable <name> for this session)
+  /transcript - Show where this session's run transcript is written
+  /export   - Write this session to a file: /export [<file.md|.json>] (default joe-session-<id>.md)
   /help     - Show this help
   /exit     - Exit Joe (or use Ctrl+D)
 `
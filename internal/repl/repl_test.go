@@ -1,12 +1,28 @@
 package repl
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/jaimegago/joe/internal/audit"
+	"github.com/jaimegago/joe/internal/client"
 	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/cost"
 	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
 	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/tools/local/echo"
 	"github.com/jaimegago/joe/internal/useragent"
 )
 
@@ -72,3 +88,711 @@ func TestNew(t *testing.T) {
 // Note: Testing Run() requires mocking stdin/stdout which is complex
 // For now, we test that the REPL can be created successfully
 // Manual testing is the primary verification method for REPL functionality
+
+func TestWriteHistory(t *testing.T) {
+	ts := time.Date(2024, 3, 2, 9, 30, 15, 0, time.Local)
+	messages := []llm.Message{
+		{Role: "user", Content: "what's\nrunning?", Timestamp: ts},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{Name: "run_command"}, {Name: "local_git_status"}}, Timestamp: ts},
+		{Role: "user", Content: "permission denied", ToolResultID: "t1", ToolName: "run_command", IsError: true},
+		{Role: "assistant", Content: strings.Repeat("x", 100), Timestamp: ts},
+	}
+
+	var buf bytes.Buffer
+	writeHistory(&buf, messages)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	tests := []struct {
+		name string
+		line int
+		want []string
+	}{
+		{"user message collapsed to one line", 0, []string{"  1", "09:30:15", "user", "what's running?"}},
+		{"tool calls listed", 1, []string{"[calls run_command, local_git_status]"}},
+		{"tool error without timestamp", 2, []string{"--:--:--", "tool:run_command!", "permission denied"}},
+		{"long content truncated", 3, []string{strings.Repeat("x", historyContentWidth-3) + "..."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, want := range tt.want {
+				if !strings.Contains(lines[tt.line], want) {
+					t.Errorf("line %d = %q, want it to contain %q", tt.line, lines[tt.line], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleHistoryCommand_Clear(t *testing.T) {
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	r.session.AddMessage(llm.Message{Role: "user", Content: "hello"})
+
+	if err := r.handleCommand(context.Background(), "/history clear"); err != nil {
+		t.Fatalf("/history clear error: %v", err)
+	}
+	if len(r.session.Messages) != 0 {
+		t.Errorf("session has %d messages after clear, want 0", len(r.session.Messages))
+	}
+
+	if err := r.handleCommand(context.Background(), "/history bogus"); err == nil {
+		t.Error("/history bogus should fail")
+	}
+}
+
+func TestHandleReloadCommand(t *testing.T) {
+	registry := tools.NewRegistry()
+	agentInstance := useragent.NewAgent(&mockLLM{}, tools.NewExecutor(registry), registry, "old prompt")
+
+	r := NewWithSession(agentInstance, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(context.Background(), "/reload"); err == nil {
+		t.Error("/reload without a prompt builder should fail")
+	}
+
+	r = NewWithSession(agentInstance, &config.Config{}, useragent.NewSession(),
+		WithPromptBuilder(func() (string, error) { return "new prompt", nil }))
+	if err := r.handleCommand(context.Background(), "/reload"); err != nil {
+		t.Fatalf("/reload error: %v", err)
+	}
+	if got := agentInstance.SystemPrompt(); got != "new prompt" {
+		t.Errorf("SystemPrompt() = %q, want new prompt", got)
+	}
+}
+
+func TestHandleReloadCommand_Config(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(extra string) {
+		t.Helper()
+		data := "llm:\n  current: fast\n  available:\n    fast:\n      provider: claude\n      model: claude-haiku-4-5\n" + extra
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	registry := tools.NewRegistry()
+	agentInstance := useragent.NewAgent(&mockLLM{}, tools.NewExecutor(registry), registry, "prompt")
+	level := new(slog.LevelVar)
+	r := NewWithSession(agentInstance, cfg, useragent.NewSession(), WithConfigReload(path, level))
+
+	writeConfig(`    deep:
+      provider: claude
+      model: claude-opus-4-1
+  show_usage: true
+logging:
+  level: debug
+tools:
+  approval:
+    run_command: allow
+`)
+	if err := r.handleCommand(context.Background(), "/reload"); err != nil {
+		t.Fatalf("/reload error: %v", err)
+	}
+
+	if _, ok := cfg.LLM.Available["deep"]; !ok {
+		t.Error("added model not available after /reload")
+	}
+	if !cfg.LLM.ShowUsage {
+		t.Error("llm.show_usage not applied")
+	}
+	if level.Level() != slog.LevelDebug {
+		t.Errorf("log level = %v, want debug", level.Level())
+	}
+	if cfg.Tools.Approval["run_command"] != "ask" {
+		t.Errorf("tools.approval = %v, want it left for a restart", cfg.Tools.Approval)
+	}
+}
+
+func TestHandleCompactCommand(t *testing.T) {
+	registry := tools.NewRegistry()
+	agentInstance := useragent.NewAgent(&mockLLM{response: "User asked about nginx."}, tools.NewExecutor(registry), registry, "prompt")
+	r := NewWithSession(agentInstance, &config.Config{}, useragent.NewSession())
+
+	if err := r.handleCommand(context.Background(), "/compact"); err != nil {
+		t.Fatalf("/compact on an empty session error: %v", err)
+	}
+
+	r.session.AddMessages([]llm.Message{
+		{Role: "user", Content: "why is nginx returning 502?"},
+		{Role: "assistant", Content: strings.Repeat("The upstream is down. ", 50)},
+	})
+	if err := r.handleCommand(context.Background(), "/compact"); err != nil {
+		t.Fatalf("/compact error: %v", err)
+	}
+	if len(r.session.Messages) != 2 || !strings.Contains(r.session.Messages[0].Content, "User asked about nginx.") {
+		t.Errorf("session after /compact = %+v, want the summary only", r.session.Messages)
+	}
+}
+
+// blockingLLM waits for the request to be cancelled, signalling started
+// once it is called
+type blockingLLM struct {
+	mockLLM
+	started chan struct{}
+}
+
+func (m *blockingLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	close(m.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRunAgent_Interrupt(t *testing.T) {
+	registry := tools.NewRegistry()
+	model := &blockingLLM{started: make(chan struct{})}
+	r := NewWithSession(useragent.NewAgent(model, tools.NewExecutor(registry), registry, "prompt"), &config.Config{}, useragent.NewSession())
+
+	go func() {
+		<-model.started
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+	done := make(chan struct{})
+	go func() {
+		r.runAgent(context.Background(), "tail the nginx logs")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runAgent still running after SIGINT")
+	}
+	if len(r.session.Messages) != 1 || r.session.Messages[0].Content != "tail the nginx logs" {
+		t.Errorf("session after interrupt = %+v, want the question kept", r.session.Messages)
+	}
+}
+
+func TestHandleCostCommand(t *testing.T) {
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(context.Background(), "/cost"); err == nil {
+		t.Error("/cost without a cost tracker should fail")
+	}
+
+	costs := cost.NewTracker(nil, 5)
+	costs.Record(context.Background(), "claude-sonnet-4", llm.TokenUsage{InputTokens: 100, CostUSD: 0.5})
+	r = NewWithSession(nil, &config.Config{}, useragent.NewSession(), WithCostTracker(costs))
+	if err := r.handleCommand(context.Background(), "/cost"); err != nil {
+		t.Errorf("/cost error: %v", err)
+	}
+}
+
+func TestHandleStatsCommand(t *testing.T) {
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(context.Background(), "/stats"); err == nil {
+		t.Error("/stats without stats should fail")
+	}
+
+	stats := llm.NewStatsRegistry()
+	r = NewWithSession(nil, &config.Config{}, useragent.NewSession(), WithStats(stats))
+	if err := r.handleCommand(context.Background(), "/stats"); err != nil {
+		t.Errorf("/stats before any call: %v", err)
+	}
+	stats.Track(llm.NewInstrumentedAdapter(&mockLLM{}, nil, "claude", "claude-sonnet-4")).Chat(context.Background(), llm.ChatRequest{})
+	stats.Track(llm.NewInstrumentedAdapter(&mockLLM{}, nil, "gemini", "gemini-2.5-flash")).Chat(context.Background(), llm.ChatRequest{})
+	if err := r.handleCommand(context.Background(), "/stats"); err != nil {
+		t.Errorf("/stats: %v", err)
+	}
+}
+
+// fakeSources serves a fixed source list
+type fakeSources struct {
+	sources []client.Source
+}
+
+func (f *fakeSources) ListSources(ctx context.Context) ([]client.Source, error) {
+	return f.sources, nil
+}
+
+func (f *fakeSources) TestSource(ctx context.Context, id string) (*client.ConnectionTest, error) {
+	for _, s := range f.sources {
+		if s.ID == id {
+			return &client.ConnectionTest{Connected: true, Message: "200 OK"}, nil
+		}
+	}
+	return nil, errors.New("source " + id + ": not found")
+}
+
+func TestHandleSourcesCommand(t *testing.T) {
+	ctx := context.Background()
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(ctx, "/sources"); err == nil {
+		t.Error("/sources without joecored should fail")
+	}
+
+	sources := &fakeSources{sources: []client.Source{{ID: "prom", Type: "prometheus", URL: "http://prom:9090"}}}
+	r = NewWithSession(nil, &config.Config{}, useragent.NewSession(), WithSources(sources))
+
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{input: "/sources"},
+		{input: "/sources test prom"},
+		{input: "/sources test missing", wantErr: true},
+		{input: "/sources remove prom", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if err := r.handleCommand(ctx, tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("handleCommand(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeFacts keeps facts in memory
+type fakeFacts struct {
+	facts []store.Fact
+}
+
+func (f *fakeFacts) ListFacts(ctx context.Context) ([]store.Fact, error) {
+	return f.facts, nil
+}
+
+func (f *fakeFacts) DeleteFact(ctx context.Context, id int64) error {
+	for i, fact := range f.facts {
+		if fact.ID == id {
+			f.facts = slices.Delete(f.facts, i, i+1)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func TestHandleMemoryCommand(t *testing.T) {
+	ctx := context.Background()
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(ctx, "/memory"); err == nil {
+		t.Error("/memory without memory should fail")
+	}
+
+	facts := &fakeFacts{facts: []store.Fact{{ID: 1, Content: "The prod database is db-prod-3"}, {ID: 2, Content: "Deploys go through ArgoCD"}}}
+	r = NewWithSession(nil, &config.Config{}, useragent.NewSession(), WithFacts(facts))
+
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{input: "/memory"},
+		{input: "/memory delete 1"},
+		{input: "/memory delete 1", wantErr: true},
+		{input: "/memory delete one", wantErr: true},
+		{input: "/memory clear", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if err := r.handleCommand(ctx, tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("handleCommand(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+	if len(facts.facts) != 1 || facts.facts[0].ID != 2 {
+		t.Errorf("facts = %+v, want only fact 2 left", facts.facts)
+	}
+}
+
+// fakeGraph records the last export asked for
+type fakeGraph struct {
+	last client.GraphExport
+}
+
+func (f *fakeGraph) ExportGraph(ctx context.Context, e client.GraphExport) ([]byte, error) {
+	f.last = e
+	return []byte("digraph joe {}\n"), nil
+}
+
+func TestHandleGraphCommand(t *testing.T) {
+	ctx := context.Background()
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(ctx, "/graph export g.dot"); err == nil {
+		t.Error("/graph without joecored should fail")
+	}
+
+	g := &fakeGraph{}
+	r = NewWithSession(nil, &config.Config{}, useragent.NewSession(), WithGraphExport(g))
+	dir := t.TempDir()
+	tests := []struct {
+		input   string
+		want    client.GraphExport
+		wantErr bool
+	}{
+		{input: "/graph export " + filepath.Join(dir, "all.dot"), want: client.GraphExport{Format: "dot"}},
+		{input: "/graph export " + filepath.Join(dir, "api.graphml") + " svc/api 2",
+			want: client.GraphExport{Format: "graphml", Node: "svc/api", Depth: 2}},
+		{input: "/graph export " + filepath.Join(dir, "api.json") + " svc/api x", wantErr: true},
+		{input: "/graph", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			g.last = client.GraphExport{}
+			err := r.handleCommand(ctx, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleCommand(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(g.last, tt.want) {
+				t.Errorf("exported %+v, want %+v", g.last, tt.want)
+			}
+		})
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "all.dot")); err != nil || string(data) != "digraph joe {}\n" {
+		t.Errorf("all.dot = %q, %v; want the export", data, err)
+	}
+}
+
+func TestHandleToolsCommand(t *testing.T) {
+	ctx := context.Background()
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(ctx, "/tools"); err == nil {
+		t.Error("/tools without a registry should fail")
+	}
+
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	r = NewWithSession(useragent.NewAgent(&mockLLM{}, tools.NewExecutor(registry), registry, "prompt"), &config.Config{}, useragent.NewSession(), WithTools(registry))
+
+	tests := []struct {
+		input        string
+		wantErr      bool
+		wantDisabled bool
+	}{
+		{input: "/tools"},
+		{input: "/tools echo"},
+		{input: "/tools missing", wantErr: true},
+		{input: "/tools disable echo", wantDisabled: true},
+		{input: "/tools", wantDisabled: true},
+		{input: "/tools enable echo"},
+		{input: "/tools disable missing", wantErr: true},
+		{input: "/tools remove echo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if err := r.handleCommand(ctx, tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("handleCommand(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got := registry.IsDisabled("echo"); got != tt.wantDisabled {
+				t.Errorf("echo disabled = %v, want %v", got, tt.wantDisabled)
+			}
+		})
+	}
+}
+
+func TestHandleModelCommand_Args(t *testing.T) {
+	registry := tools.NewRegistry()
+	agentInstance := useragent.NewAgent(&mockLLM{}, tools.NewExecutor(registry), registry, "prompt",
+		useragent.WithAdapterFactory(func(ctx context.Context, provider, model string) (llm.LLMAdapter, error) {
+			return &mockLLM{}, nil
+		}))
+	cfg := &config.Config{LLM: config.LLMConfig{
+		Current: "claude-sonnet",
+		Available: map[string]config.ModelConfig{
+			"claude-sonnet": {Provider: "claude", Model: "claude-sonnet-4"},
+			"gemini-flash":  {Provider: "gemini", Model: "gemini-2.5-flash"},
+			"gpt-4o":        {Provider: "openai-compatible", Model: "gpt-4o"},
+		},
+	}}
+	r := NewWithSession(agentInstance, cfg, useragent.NewSession())
+
+	tests := []struct {
+		input       string
+		wantErr     bool
+		wantCurrent string
+	}{
+		{input: "/model list", wantCurrent: "claude-sonnet"},
+		{input: "/model list gemini", wantCurrent: "claude-sonnet"},
+		{input: "/model list bedrock", wantErr: true, wantCurrent: "claude-sonnet"},
+		{input: "/model gemini-flash", wantCurrent: "gemini-flash"},
+		{input: "/model openai-compatible", wantCurrent: "gpt-4o"}, // The provider's only model
+		{input: "/model missing", wantErr: true, wantCurrent: "gpt-4o"},
+		{input: "/model a b", wantErr: true, wantCurrent: "gpt-4o"},
+		{input: "/model --save claude-sonnet", wantErr: true, wantCurrent: "gpt-4o"}, // No config file
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if err := r.handleCommand(context.Background(), tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("handleCommand(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if cfg.LLM.Current != tt.wantCurrent {
+				t.Errorf("current model = %s, want %s", cfg.LLM.Current, tt.wantCurrent)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	WithConfigReload(path, nil)(r)
+	if err := r.handleCommand(context.Background(), "/model claude-sonnet --save"); err != nil {
+		t.Fatalf("/model --save error: %v", err)
+	}
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() of the saved config error: %v", err)
+	}
+	if saved.LLM.Current != "claude-sonnet" {
+		t.Errorf("saved llm.current = %s, want claude-sonnet", saved.LLM.Current)
+	}
+
+	complete := map[string]string{
+		"/m":            strings.Join(commands, " "),
+		"/model ":       "claude-sonnet gemini-flash gpt-4o claude gemini openai-compatible list --save",
+		"/model list ":  "claude gemini openai-compatible",
+		"/model list g": "claude gemini openai-compatible",
+		"why /model ":   "",
+	}
+	for before, want := range complete {
+		if got := strings.Join(r.complete(before), " "); got != want {
+			t.Errorf("complete(%q) = %q, want %q", before, got, want)
+		}
+	}
+}
+
+func TestHandleTranscriptCommand(t *testing.T) {
+	ctx := context.Background()
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(ctx, "/transcript"); err != nil {
+		t.Errorf("/transcript with transcripts off: %v", err)
+	}
+
+	w, err := audit.NewWriter(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	r = NewWithSession(nil, &config.Config{}, useragent.NewSession(), WithTranscripts(w))
+	if err := r.handleCommand(ctx, "/transcript"); err != nil {
+		t.Errorf("/transcript: %v", err)
+	}
+}
+
+// failedOverLLM has failed over from sonnet to haiku
+type failedOverLLM struct {
+	mockLLM
+}
+
+func (failedOverLLM) ActiveModel() string { return "haiku" }
+
+func TestHandleExportCommand(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{LLM: config.LLMConfig{Current: "sonnet"}}
+	session := useragent.NewSession()
+	session.AddMessages([]llm.Message{
+		{Role: "user", Content: "why is the disk full?"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "t1", Name: "run_command", Args: map[string]any{"command": "df -h"}}}},
+		{Role: "user", Content: "```\n/dev/sda1 100%\n```", ToolResultID: "t1", ToolName: "run_command"},
+		{Role: "assistant", Content: "/dev/sda1 is full."},
+	})
+	session.AddTokenUsage(llm.TokenUsage{InputTokens: 120, OutputTokens: 30, TotalTokens: 150})
+	registry := tools.NewRegistry()
+	agent := useragent.NewAgent(&failedOverLLM{}, tools.NewExecutor(registry), registry, "prompt",
+		useragent.WithCurrentModelName("sonnet"))
+	r := NewWithSession(agent, cfg, session)
+	dir := t.TempDir()
+
+	t.Run("markdown", func(t *testing.T) {
+		path := filepath.Join(dir, "incident.md")
+		if err := r.handleCommand(ctx, "/export "+path); err != nil {
+			t.Fatalf("/export error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		for _, want := range []string{
+			"# Joe session " + session.ID,
+			"- Model: haiku",
+			"- Tokens: 120 input, 30 output, 150 total",
+			"why is the disk full?",
+			"Tool call `run_command` (t1):\n\n```json\n{\n  \"command\": \"df -h\"\n}\n```",
+			"## Tool result: run_command (t1)",
+			"````\n```\n/dev/sda1 100%\n```\n````",
+			"/dev/sda1 is full.",
+		} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("export missing %q:\n%s", want, data)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(dir, "incident.json")
+		if err := r.handleCommand(ctx, "/export "+path); err != nil {
+			t.Fatalf("/export error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		var got exportedSession
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("export is not JSON: %v", err)
+		}
+		if got.ID != session.ID || got.Model != "haiku" || got.Tokens.Total != 150 || len(got.Messages) != 4 {
+			t.Errorf("export = %+v", got)
+		}
+		call := got.Messages[1].ToolCalls
+		if len(call) != 1 || call[0].Name != "run_command" || call[0].Arguments["command"] != "df -h" {
+			t.Errorf("tool calls = %+v", call)
+		}
+		if result := got.Messages[2]; result.Role != "tool" || result.ToolCallID != "t1" {
+			t.Errorf("tool result = %+v", result)
+		}
+	})
+
+	t.Run("default file name", func(t *testing.T) {
+		t.Chdir(dir)
+		if err := r.handleCommand(ctx, "/export"); err != nil {
+			t.Fatalf("/export error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "joe-session-"+session.ID+".md")); err != nil {
+			t.Errorf("default export not written: %v", err)
+		}
+	})
+
+	t.Run("home directory", func(t *testing.T) {
+		t.Setenv("HOME", dir)
+		if err := r.handleCommand(ctx, "/export ~/home.md"); err != nil {
+			t.Fatalf("/export error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "home.md")); err != nil {
+			t.Errorf("export to ~ not written: %v", err)
+		}
+	})
+
+	if err := r.handleCommand(ctx, "/export a.md b.md"); err == nil {
+		t.Error("/export with two files should fail")
+	}
+}
+
+// fakeSessions is an in-memory useragent.SessionStore, listing sessions in
+// the order they were created, newest first
+type fakeSessions struct {
+	sessions []store.Session
+}
+
+func (f *fakeSessions) CreateSession(ctx context.Context, s store.Session) error {
+	f.sessions = append([]store.Session{s}, f.sessions...)
+	return nil
+}
+
+func (f *fakeSessions) GetSession(ctx context.Context, id string) (*store.Session, error) {
+	for _, s := range f.sessions {
+		if s.ID == id {
+			return &s, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (f *fakeSessions) UpdateSession(ctx context.Context, s store.Session) error {
+	for i := range f.sessions {
+		if f.sessions[i].ID == s.ID {
+			f.sessions[i] = s
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (f *fakeSessions) ListSessions(ctx context.Context, limit int) ([]store.Session, error) {
+	if limit > 0 && limit < len(f.sessions) {
+		return f.sessions[:limit], nil
+	}
+	return f.sessions, nil
+}
+
+func TestHandleSessionsCommand(t *testing.T) {
+	ctx := context.Background()
+	r := NewWithSession(nil, &config.Config{}, useragent.NewSession())
+	if err := r.handleCommand(ctx, "/sessions"); err == nil {
+		t.Error("/sessions without a store should fail")
+	}
+
+	st := &fakeSessions{}
+	st.CreateSession(ctx, store.Session{ID: "20240301-100000-aaaaaa", Title: "Disk full on db-1",
+		Messages: []llm.Message{{Role: "user", Content: "why is db-1 out of disk?"}}})
+	st.CreateSession(ctx, store.Session{ID: "20240302-100000-bbbbbb",
+		Messages: []llm.Message{{Role: "user", Content: "list pods"}}})
+
+	registry := tools.NewRegistry()
+	agent := useragent.NewAgent(&mockLLM{response: "Checking certificates"}, tools.NewExecutor(registry), registry, "system")
+	session := useragent.NewSession()
+	session.AddMessages([]llm.Message{{Role: "user", Content: "when do our certs expire?"}, {Role: "assistant", Content: "In May."}})
+	r = NewWithSession(agent, &config.Config{}, session, WithSessionStore(st))
+	previousID := session.ID
+
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{input: "/sessions"},
+		{input: "/sessions open 2024030", wantErr: true}, // Ambiguous
+		{input: "/sessions open nope", wantErr: true},
+		{input: "/sessions bogus", wantErr: true},
+		{input: "/sessions open 20240301"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err := r.handleCommand(ctx, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handleCommand(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+
+	if r.session.ID != "20240301-100000-aaaaaa" || r.session.Title != "Disk full on db-1" {
+		t.Errorf("session = %s %q, want the opened one", r.session.ID, r.session.Title)
+	}
+	saved, err := st.GetSession(ctx, previousID)
+	if err != nil {
+		t.Fatalf("the previous session was not saved: %v", err)
+	}
+	if saved.Title != "Checking certificates" {
+		t.Errorf("saved title = %q, want one from the model", saved.Title)
+	}
+
+	if err := r.handleCommand(ctx, "/sessions rename Disk full on db-1, again"); err != nil {
+		t.Fatalf("/sessions rename error: %v", err)
+	}
+	if got, _ := st.GetSession(ctx, "20240301-100000-aaaaaa"); got.Title != "Disk full on db-1, again" {
+		t.Errorf("renamed title = %q", got.Title)
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1240, "1,240"},
+		{1234567, "1,234,567"},
+		{-4500, "-4,500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatCount(tt.n); got != tt.want {
+				t.Errorf("formatCount(%d) = %q, want %q", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostSuffix(t *testing.T) {
+	pricing := &config.ModelPricing{InputPerMillion: 3, OutputPerMillion: 15}
+
+	tests := []struct {
+		name    string
+		pricing *config.ModelPricing
+		in, out int
+		want    string
+	}{
+		{"unpriced model", nil, 1240, 380, ""},
+		{"sub-cent", pricing, 1240, 380, ", ~$0.0094"},
+		{"over a cent", pricing, 10000, 2000, ", ~$0.060"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := costSuffix(tt.pricing, tt.in, tt.out); got != tt.want {
+				t.Errorf("costSuffix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
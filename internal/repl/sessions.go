@@ -0,0 +1,142 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/useragent"
+)
+
+// sessionListLimit is how many sessions /sessions lists, most recent first
+const sessionListLimit = 20
+
+// sessionSummaryWidth caps the summary line under each listed session
+const sessionSummaryWidth = 76
+
+// handleSessionsCommand lists the stored sessions, switches to one with
+// /sessions open <id>, or names the current one with /sessions rename
+// <title>. IDs may be shortened to any unique prefix.
+func (r *REPL) handleSessionsCommand(ctx context.Context, args []string) error {
+	if r.sessions == nil {
+		return fmt.Errorf("session persistence is not configured")
+	}
+	switch {
+	case len(args) == 0:
+		return r.listSessions(ctx)
+	case args[0] == "open" && len(args) == 2:
+		return r.openSession(ctx, args[1])
+	case args[0] == "rename" && len(args) > 1:
+		r.session.Title = strings.Join(args[1:], " ")
+		if err := useragent.SaveSession(ctx, r.sessions, r.session); err != nil {
+			return err
+		}
+		fmt.Printf("Renamed this session to %q\n", r.session.Title)
+		return nil
+	}
+	return fmt.Errorf("usage: /sessions [open <id> | rename <title>]")
+}
+
+// listSessions prints the most recent sessions: ID, last update, title,
+// and the summary memory wrote, if any. The current session is marked *.
+func (r *REPL) listSessions(ctx context.Context) error {
+	sessions, err := r.sessions.ListSessions(ctx, sessionListLimit)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No saved sessions yet. Sessions are saved when joe exits.")
+		return nil
+	}
+	for _, s := range sessions {
+		mark := " "
+		if s.ID == r.session.ID {
+			mark = "*"
+		}
+		updated := s.UpdatedAt
+		if updated.IsZero() {
+			updated = s.StartedAt
+		}
+		fmt.Printf("%s %-22s %s  %s\n", mark, s.ID, updated.Local().Format("2006-01-02 15:04"), sessionTitle(s))
+		if s.Summary != "" {
+			fmt.Printf("    %s\n", truncate(strings.Join(strings.Fields(s.Summary), " "), sessionSummaryWidth))
+		}
+	}
+	fmt.Println("Open one with /sessions open <id>")
+	return nil
+}
+
+// sessionTitle returns the session's title or, for untitled ones, its
+// first question
+func sessionTitle(s store.Session) string {
+	if s.Title != "" {
+		return s.Title
+	}
+	for _, m := range s.Messages {
+		if m.Role == "user" && m.ToolResultID == "" {
+			return "(untitled) " + truncate(strings.Join(strings.Fields(m.Content), " "), 50)
+		}
+	}
+	return "(untitled)"
+}
+
+// openSession saves the current session and replaces it with the stored
+// session whose ID is or starts with id
+func (r *REPL) openSession(ctx context.Context, id string) error {
+	if id == r.session.ID {
+		fmt.Println("Already in that session")
+		return nil
+	}
+	sessions, err := r.sessions.ListSessions(ctx, 0)
+	if err != nil {
+		return err
+	}
+	var matches []store.Session
+	for _, s := range sessions {
+		if s.ID == id {
+			matches = []store.Session{s}
+			break
+		}
+		if strings.HasPrefix(s.ID, id) {
+			matches = append(matches, s)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return fmt.Errorf("no session %s (see /sessions)", id)
+	case len(matches) > 1:
+		return fmt.Errorf("%d sessions start with %s; give more of the ID", len(matches), id)
+	}
+
+	if err := r.persistSession(ctx); err != nil {
+		return err
+	}
+	r.session.Restore(&matches[0])
+	fmt.Printf("Opened session %s: %s (%d messages)\n", matches[0].ID, sessionTitle(matches[0]), len(matches[0].Messages))
+	return nil
+}
+
+// persistSession titles the session, if it has no title yet, and saves it.
+// Failing to title is logged, not fatal: the session is saved untitled.
+func (r *REPL) persistSession(ctx context.Context) error {
+	if r.agent != nil && len(r.session.Messages) > 0 {
+		titleCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err := r.agent.Title(titleCtx, r.session)
+		cancel()
+		if err != nil {
+			slog.Warn("failed to title session", "session", r.session.ID, "error", err)
+		}
+	}
+	return useragent.SaveSession(ctx, r.sessions, r.session)
+}
+
+// truncate shortens s to at most n runes, marking the cut with ...
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-3]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+)
+
+// handleSourcesCommand lists the sources joecored refreshes from, or
+// tests one with /sources test <id>. Sources are added and changed with
+// the joe sources command.
+func (r *REPL) handleSourcesCommand(ctx context.Context, args []string) error {
+	if r.sources == nil {
+		return fmt.Errorf("sources are managed by joecored, which is not connected")
+	}
+
+	if len(args) > 0 {
+		if args[0] != "test" || len(args) != 2 {
+			return fmt.Errorf("usage: /sources [test <id>]")
+		}
+		result, err := r.sources.TestSource(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		state := "reachable"
+		if !result.Connected {
+			state = "unreachable"
+		}
+		fmt.Printf("%s is %s: %s\n", args[1], state, result.Message)
+		return nil
+	}
+
+	sources, err := r.sources.ListSources(ctx)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		fmt.Println("No sources registered. Add one with: joe sources add -type <type> -url <url> <id>")
+		return nil
+	}
+	for _, s := range sources {
+		status := s.Status
+		if status == "" {
+			status = "untested"
+		}
+		env := ""
+		if s.Environment != "" {
+			env = " [" + s.Environment + "]"
+		}
+		fmt.Printf("  %-24s %-11s %-10s%s %s\n", s.ID, s.Type, status, env, s.URL)
+	}
+	return nil
+}
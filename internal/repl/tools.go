@@ -0,0 +1,159 @@
+package repl
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+// maxToolSummary caps the description shown per tool in the /tools list
+const maxToolSummary = 70
+
+// ToolRegistry lists the tools the agent can call and turns them off and on
+type ToolRegistry interface {
+	GetAll() []tools.Tool
+	IsDisabled(name string) bool
+	Disable(name string) error
+	Enable(name string) error
+}
+
+// WithTools enables the /tools command
+func WithTools(registry ToolRegistry) Option {
+	return func(r *REPL) {
+		r.tools = registry
+	}
+}
+
+// handleToolsCommand lists the registered tools, shows one in full, or
+// disables or enables one for the rest of the session
+func (r *REPL) handleToolsCommand(args []string) error {
+	if r.tools == nil {
+		return fmt.Errorf("tool listing is not configured")
+	}
+
+	switch {
+	case len(args) == 0:
+		r.listTools()
+		return nil
+	case len(args) == 1:
+		return r.showTool(args[0])
+	case len(args) == 2 && (args[0] == "disable" || args[0] == "enable"):
+		name := args[1]
+		if args[0] == "disable" {
+			if err := r.tools.Disable(name); err != nil {
+				return err
+			}
+			fmt.Printf("%s disabled for this session (/tools enable %s to undo)\n", name, name)
+		} else {
+			if err := r.tools.Enable(name); err != nil {
+				return err
+			}
+			fmt.Printf("%s enabled\n", name)
+		}
+		r.refreshPrompt()
+		return nil
+	default:
+		return fmt.Errorf("usage: /tools [<name> | disable <name> | enable <name>]")
+	}
+}
+
+func (r *REPL) listTools() {
+	all := r.sortedTools()
+	disabled := 0
+	for _, t := range all {
+		if r.tools.IsDisabled(t.Name()) {
+			disabled++
+		}
+	}
+	fmt.Printf("%d tools (%d disabled); /tools <name> shows one in full:\n", len(all), disabled)
+	for _, t := range all {
+		fmt.Printf("  %-24s %s%s\n", t.Name(), toolSummary(t.Description()), r.toolFlags(t.Name()))
+	}
+}
+
+func (r *REPL) showTool(name string) error {
+	all := r.tools.GetAll()
+	i := slices.IndexFunc(all, func(t tools.Tool) bool { return t.Name() == name })
+	if i < 0 {
+		return fmt.Errorf("tool not found: %s", name)
+	}
+	t := all[i]
+
+	fmt.Printf("%s%s\n", t.Name(), r.toolFlags(t.Name()))
+	fmt.Printf("  %s\n", strings.ReplaceAll(strings.TrimSpace(t.Description()), "\n", "\n  "))
+	schema := t.Parameters()
+	if len(schema.Properties) == 0 {
+		fmt.Println("  No parameters")
+		return nil
+	}
+	fmt.Println("  Parameters:")
+	for _, p := range slices.Sorted(maps.Keys(schema.Properties)) {
+		prop := schema.Properties[p]
+		required := ""
+		if slices.Contains(schema.Required, p) {
+			required = ", required"
+		}
+		fmt.Printf("    %s (%s%s) - %s\n", p, propertyType(prop), required, prop.Description)
+	}
+	return nil
+}
+
+func (r *REPL) sortedTools() []tools.Tool {
+	all := r.tools.GetAll()
+	slices.SortFunc(all, func(a, b tools.Tool) int { return strings.Compare(a.Name(), b.Name()) })
+	return all
+}
+
+func (r *REPL) toolNames() []string {
+	var names []string
+	for _, t := range r.sortedTools() {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// toolFlags marks a disabled tool and its approval policy, e.g. " [ask]"
+func (r *REPL) toolFlags(name string) string {
+	var flags string
+	if policy := r.config.Tools.Approval[name]; policy == "ask" || policy == "deny" {
+		flags += " [" + policy + "]"
+	}
+	if r.tools.IsDisabled(name) {
+		flags += " [disabled]"
+	}
+	return flags
+}
+
+// refreshPrompt re-renders the system prompt, which lists the tools
+func (r *REPL) refreshPrompt() {
+	if r.prompt == nil {
+		return
+	}
+	systemPrompt, err := r.prompt()
+	if err != nil {
+		fmt.Printf("Warning: failed to update the system prompt: %v\n", err)
+		return
+	}
+	r.agent.SetSystemPrompt(systemPrompt)
+}
+
+// toolSummary is the first line of a description, shortened for the list
+func toolSummary(description string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	if r := []rune(line); len(r) > maxToolSummary {
+		line = string(r[:maxToolSummary]) + "..."
+	}
+	return line
+}
+
+// propertyType is a parameter's type, e.g. "array of string"
+func propertyType(p llm.Property) string {
+	if p.Type == "array" && p.Items != nil {
+		return "array of " + p.Items.Type
+	}
+	return p.Type
+}
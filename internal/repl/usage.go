@@ -0,0 +1,157 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// handleTokensCommand shows token usage for the last run and the whole session
+func (r *REPL) handleTokensCommand() error {
+	s := r.session
+	pricing := r.currentPricing()
+
+	fmt.Printf("Last run: %s in / %s out (%s total) across %d LLM calls%s\n",
+		formatCount(s.RunInputTokens), formatCount(s.RunOutputTokens), formatCount(s.RunTokens),
+		s.RunLLMCalls, costSuffix(pricing, s.RunInputTokens, s.RunOutputTokens))
+	fmt.Printf("Session:  %s in / %s out (%s total)%s\n",
+		formatCount(s.TotalInputTokens), formatCount(s.TotalOutputTokens), formatCount(s.TotalTokens),
+		costSuffix(pricing, s.TotalInputTokens, s.TotalOutputTokens))
+
+	if pricing == nil {
+		fmt.Printf("Add llm.available.%s.pricing to config.yaml for cost estimates\n", r.config.LLM.Current)
+	}
+	return nil
+}
+
+// handleStatsCommand shows the LLM calls made since joe started, per
+// model: errors, tokens, and latency. Retried attempts count as calls.
+func (r *REPL) handleStatsCommand() error {
+	if r.stats == nil {
+		return fmt.Errorf("LLM stats are not configured")
+	}
+	all := r.stats.Stats()
+	if len(all) == 0 {
+		fmt.Println("No LLM calls yet")
+		return nil
+	}
+
+	var total llm.Stats
+	for _, m := range all {
+		printStats(m.Provider+"/"+m.Model, m.Stats)
+		total = total.Add(m.Stats)
+	}
+	if len(all) > 1 {
+		printStats("Total", total)
+	}
+	return nil
+}
+
+func printStats(name string, s llm.Stats) {
+	fmt.Printf("  %-32s %d calls, %d errors (%.1f%%), %s in / %s out tokens, avg %s\n",
+		name, s.TotalCalls, s.TotalErrors, 100*s.ErrorRate(),
+		formatCount(int(s.TotalInputTokens)), formatCount(int(s.TotalOutputTokens)),
+		formatSeconds(s.AverageLatency()))
+}
+
+// costReportDays is how many days /cost totals, including today
+const costReportDays = 7
+
+// handleCostCommand shows estimated spending for the session, today by
+// model, and the last week, with the daily budget if one is set
+func (r *REPL) handleCostCommand(ctx context.Context) error {
+	if r.costs == nil {
+		return fmt.Errorf("cost tracking is not configured")
+	}
+	days, err := r.costs.Days(ctx, costReportDays)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Session: ~%s\n", formatCost(r.session.TotalCostUSD))
+
+	today := time.Now().Format("2006-01-02")
+	var todayCosts []store.DailyCost
+	todayTotal, weekTotal := 0.0, 0.0
+	for _, d := range days {
+		weekTotal += d.CostUSD
+		if d.Day == today {
+			todayCosts = append(todayCosts, d)
+			todayTotal += d.CostUSD
+		}
+	}
+
+	budget := ""
+	if b := r.costs.DailyBudget(); b > 0 {
+		budget = fmt.Sprintf(" of $%.2f budget", b)
+	}
+	fmt.Printf("Today:   ~%s%s\n", formatCost(todayTotal), budget)
+	for _, d := range todayCosts {
+		cost := "~" + formatCost(d.CostUSD)
+		if d.CostUSD == 0 {
+			cost = "unpriced"
+		}
+		fmt.Printf("  %-32s %-10s %d calls, %s in / %s out\n",
+			d.Model, cost, d.Calls, formatCount(d.InputTokens), formatCount(d.OutputTokens))
+	}
+	fmt.Printf("Last %d days: ~%s\n", costReportDays, formatCost(weekTotal))
+	return nil
+}
+
+// printRunUsage prints a one-line usage summary after an answer, e.g.
+// "(1,240 in / 380 out tokens, ~$0.009)"
+func (r *REPL) printRunUsage() {
+	s := r.session
+	fmt.Printf("(%s in / %s out tokens%s)\n",
+		formatCount(s.RunInputTokens), formatCount(s.RunOutputTokens),
+		costSuffix(r.currentPricing(), s.RunInputTokens, s.RunOutputTokens))
+}
+
+// currentPricing returns the active model's pricing, or nil if none is configured
+func (r *REPL) currentPricing() *config.ModelPricing {
+	mc, err := r.config.LLM.CurrentModel()
+	if err != nil {
+		return nil
+	}
+	return mc.Pricing
+}
+
+// costSuffix returns ", ~$0.012" for priced models and "" otherwise
+func costSuffix(pricing *config.ModelPricing, inputTokens, outputTokens int) string {
+	if pricing == nil {
+		return ""
+	}
+	return ", ~" + formatCost(pricing.Cost(inputTokens, outputTokens))
+}
+
+// formatCost shows sub-cent amounts with an extra digit so they don't round to $0.000
+func formatCost(usd float64) string {
+	if usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.3f", usd)
+}
+
+// formatCount formats n with thousands separators, e.g. 1240 -> "1,240"
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,179 @@
+// Package aws maps AWS accounts: VPCs and subnets, EC2 instances, RDS
+// databases, and load balancers, and where each runs. It only calls
+// Describe APIs, so a read-only role such as ReadOnlyAccess is enough.
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	sdk "github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// Connector reads the account and region of each aws source. A source may
+// set region, profile, and role_arn (a role to assume, e.g. a read-only
+// one) in its connection details; the aws config section provides the
+// defaults and which services are read.
+type Connector struct {
+	defaults config.AWSConfig
+
+	mu      sync.Mutex
+	clients map[string]*clients // By region, profile, and role
+
+	// load returns the SDK config for a source's settings; replaced in tests
+	load func(ctx context.Context, s settings) (sdk.Config, error)
+}
+
+// clients are the API clients for one account and region
+type clients struct {
+	ec2   ec2API
+	sts   stsAPI
+	query *queryClient // RDS and ELB
+}
+
+// stsAPI is the part of the STS client the connector uses
+type stsAPI interface {
+	GetCallerIdentity(ctx context.Context, in *sts.GetCallerIdentityInput, opts ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// settings select the account and region of a source
+type settings struct {
+	region, profile, roleARN string
+}
+
+// New creates an AWS connector with defaults for the region, profile, and
+// services to read
+func New(defaults config.AWSConfig) *Connector {
+	return &Connector{
+		defaults: defaults,
+		clients:  make(map[string]*clients),
+		load:     loadConfig,
+	}
+}
+
+// Connect implements sources.SourceConnector, resolving the source's
+// credentials
+func (c *Connector) Connect(ctx context.Context, source store.Source) error {
+	_, err := c.clientsFor(ctx, source)
+	return err
+}
+
+// Discover implements sources.SourceConnector; an account's resources are
+// graph nodes, not sources
+func (c *Connector) Discover(ctx context.Context, source store.Source) ([]store.Source, error) {
+	return nil, nil
+}
+
+// HealthCheck implements sources.SourceConnector, checking the credentials
+// are accepted
+func (c *Connector) HealthCheck(ctx context.Context, source store.Source) error {
+	cl, err := c.clientsFor(ctx, source)
+	if err != nil {
+		return err
+	}
+	if _, err := cl.sts.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		return fmt.Errorf("failed to check AWS credentials: %w", err)
+	}
+	return nil
+}
+
+// Refresh implements sources.SourceConnector, reading each enabled service
+func (c *Connector) Refresh(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	cl, err := c.clientsFor(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	b := newBuilder()
+	services := c.defaults.Services
+	if services.VPC {
+		if err := readVPCs(ctx, cl.ec2, b); err != nil {
+			return nil, err
+		}
+	}
+	if services.EC2 {
+		if err := readInstances(ctx, cl.ec2, b); err != nil {
+			return nil, err
+		}
+	}
+	if services.RDS {
+		if err := readDatabases(ctx, cl.query, b); err != nil {
+			return nil, err
+		}
+	}
+	if services.ELB {
+		if err := readLoadBalancers(ctx, cl.query, b); err != nil {
+			return nil, err
+		}
+	}
+	return b.graph(), nil
+}
+
+// clientsFor returns the clients for source's account and region, created
+// on first use
+func (c *Connector) clientsFor(ctx context.Context, source store.Source) (*clients, error) {
+	s := settings{
+		region:  detail(source, "region", c.defaults.Region),
+		profile: detail(source, "profile", c.defaults.Profile),
+		roleARN: detail(source, "role_arn", ""),
+	}
+	key := s.region + "|" + s.profile + "|" + s.roleARN
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cl, ok := c.clients[key]; ok {
+		return cl, nil
+	}
+	cfg, err := c.load(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region for source %s: set its region, aws.region in config.yaml, or AWS_REGION", source.ID)
+	}
+	cl := &clients{
+		ec2:   ec2.NewFromConfig(cfg),
+		sts:   sts.NewFromConfig(cfg),
+		query: newQueryClient(cfg),
+	}
+	c.clients[key] = cl
+	return cl, nil
+}
+
+// loadConfig loads the SDK config for s, assuming its role if it has one
+func loadConfig(ctx context.Context, s settings) (sdk.Config, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if s.region != "" {
+		opts = append(opts, awsconfig.WithRegion(s.region))
+	}
+	if s.profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(s.profile))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return sdk.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if s.roleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), s.roleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "joe"
+		})
+		cfg.Credentials = sdk.NewCredentialsCache(provider)
+	}
+	return cfg, nil
+}
+
+// detail returns a string from the source's connection details, or def
+func detail(source store.Source, key, def string) string {
+	if v, _ := source.ConnectionDetails[key].(string); v != "" {
+		return v
+	}
+	return def
+}
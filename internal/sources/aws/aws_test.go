@@ -0,0 +1,213 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	sdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// fakeEC2 describes one VPC with a subnet and two instances, one of them
+// terminated
+type fakeEC2 struct{}
+
+func (fakeEC2) DescribeVpcs(ctx context.Context, in *ec2.DescribeVpcsInput, opts ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return &ec2.DescribeVpcsOutput{Vpcs: []types.Vpc{{
+		VpcId:     sdk.String("vpc-1"),
+		CidrBlock: sdk.String("10.0.0.0/16"),
+		Tags:      []types.Tag{{Key: sdk.String("Name"), Value: sdk.String("prod")}},
+	}}}, nil
+}
+
+func (fakeEC2) DescribeSubnets(ctx context.Context, in *ec2.DescribeSubnetsInput, opts ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return &ec2.DescribeSubnetsOutput{Subnets: []types.Subnet{{
+		SubnetId:         sdk.String("subnet-1"),
+		VpcId:            sdk.String("vpc-1"),
+		CidrBlock:        sdk.String("10.0.1.0/24"),
+		AvailabilityZone: sdk.String("eu-west-1a"),
+	}}}, nil
+}
+
+func (fakeEC2) DescribeInstances(ctx context.Context, in *ec2.DescribeInstancesInput, opts ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{Instances: []types.Instance{
+		{
+			InstanceId:   sdk.String("i-1"),
+			InstanceType: types.InstanceTypeT3Micro,
+			SubnetId:     sdk.String("subnet-1"),
+			State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+			Tags:         []types.Tag{{Key: sdk.String("Name"), Value: sdk.String("web-1")}},
+		},
+		{InstanceId: sdk.String("i-2"), State: &types.InstanceState{Name: types.InstanceStateNameTerminated}},
+	}}}}, nil
+}
+
+// queryResponses answer the RDS and ELB actions, the database list in two
+// pages
+var queryResponses = map[string]string{
+	"DescribeDBInstances": `<DescribeDBInstancesResponse><DescribeDBInstancesResult>
+		<DBInstances><DBInstance>
+			<DBInstanceIdentifier>orders</DBInstanceIdentifier><Engine>postgres</Engine><DBInstanceStatus>available</DBInstanceStatus>
+			<Endpoint><Address>orders.abc.eu-west-1.rds.amazonaws.com</Address><Port>5432</Port></Endpoint>
+			<DBSubnetGroup><VpcId>vpc-1</VpcId></DBSubnetGroup>
+		</DBInstance></DBInstances><Marker>page2</Marker>
+	</DescribeDBInstancesResult></DescribeDBInstancesResponse>`,
+	"DescribeDBInstances page2": `<DescribeDBInstancesResponse><DescribeDBInstancesResult>
+		<DBInstances><DBInstance><DBInstanceIdentifier>reports</DBInstanceIdentifier><Engine>mysql</Engine></DBInstance></DBInstances>
+	</DescribeDBInstancesResult></DescribeDBInstancesResponse>`,
+	"DescribeLoadBalancers": `<DescribeLoadBalancersResponse><DescribeLoadBalancersResult><LoadBalancers><member>
+		<LoadBalancerArn>arn:lb/web</LoadBalancerArn><LoadBalancerName>web</LoadBalancerName><Type>application</Type>
+		<Scheme>internet-facing</Scheme><State><Code>active</Code></State><VpcId>vpc-1</VpcId>
+		<AvailabilityZones><member><SubnetId>subnet-1</SubnetId></member></AvailabilityZones>
+	</member></LoadBalancers></DescribeLoadBalancersResult></DescribeLoadBalancersResponse>`,
+	"DescribeTargetGroups": `<DescribeTargetGroupsResponse><DescribeTargetGroupsResult><TargetGroups>
+		<member><TargetGroupArn>arn:tg/web</TargetGroupArn><TargetGroupName>web</TargetGroupName><TargetType>instance</TargetType>
+			<Protocol>HTTP</Protocol><LoadBalancerArns><member>arn:lb/web</member></LoadBalancerArns></member>
+		<member><TargetGroupArn>arn:tg/ips</TargetGroupArn><TargetType>ip</TargetType>
+			<LoadBalancerArns><member>arn:lb/web</member></LoadBalancerArns></member>
+	</TargetGroups></DescribeTargetGroupsResult></DescribeTargetGroupsResponse>`,
+	"DescribeTargetHealth": `<DescribeTargetHealthResponse><DescribeTargetHealthResult><TargetHealthDescriptions>
+		<member><Target><Id>i-1</Id><Port>8080</Port></Target></member>
+	</TargetHealthDescriptions></DescribeTargetHealthResult></DescribeTargetHealthResponse>`,
+}
+
+// newTestConnector returns a connector reading fakeEC2, and the Query APIs
+// from queryResponses, with services enabled
+func newTestConnector(t *testing.T, services config.AWSServicesConfig) (*Connector, *[]string) {
+	t.Helper()
+	var actions []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/") {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<ErrorResponse><Error><Code>IncompleteSignature</Code><Message>unsigned</Message></Error></ErrorResponse>`))
+			return
+		}
+		action := strings.TrimSpace(r.Form.Get("Action") + " " + r.Form.Get("Marker"))
+		actions = append(actions, strings.TrimPrefix(r.URL.Path, "/")+" "+action)
+		w.Write([]byte(queryResponses[action]))
+	}))
+	t.Cleanup(ts.Close)
+
+	c := New(config.AWSConfig{Region: "eu-west-1", Services: services})
+	c.load = func(ctx context.Context, s settings) (sdk.Config, error) {
+		return sdk.Config{Region: s.region, Credentials: credentials.NewStaticCredentialsProvider("AKIDTEST", "secret", "")}, nil
+	}
+	source := store.Source{ID: "aws-prod", Type: "aws"}
+	cl, err := c.clientsFor(context.Background(), source)
+	if err != nil {
+		t.Fatalf("clientsFor() error: %v", err)
+	}
+	cl.ec2 = fakeEC2{}
+	cl.query.endpoint = func(service string) string { return ts.URL + "/" + url.PathEscape(service) }
+	return c, &actions
+}
+
+func TestConnector_Refresh(t *testing.T) {
+	c, actions := newTestConnector(t, config.AWSServicesConfig{VPC: true, EC2: true, RDS: true, ELB: true})
+	state, err := c.Refresh(context.Background(), store.Source{ID: "aws-prod", Type: "aws"})
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+
+	var nodes []string
+	for _, n := range state.Nodes {
+		nodes = append(nodes, n.ID+" "+n.Type)
+	}
+	wantNodes := "vpc/vpc-1 vpc, subnet/subnet-1 subnet, ec2/i-1 ec2_instance, rds/orders rds_instance, rds/reports rds_instance, lb/web load_balancer"
+	if got := strings.Join(nodes, ", "); got != wantNodes {
+		t.Errorf("nodes = %s, want %s", got, wantNodes)
+	}
+	if name := state.Nodes[2].Metadata["name"]; name != "web-1" {
+		t.Errorf("instance name = %v, want its Name tag", name)
+	}
+
+	var edges []string
+	for _, e := range state.Edges {
+		edges = append(edges, e.From+" "+e.Relation+" "+e.To)
+	}
+	wantEdges := "vpc/vpc-1 contains subnet/subnet-1, subnet/subnet-1 contains ec2/i-1, vpc/vpc-1 contains rds/orders, vpc/vpc-1 contains lb/web, lb/web routes_to ec2/i-1"
+	if got := strings.Join(edges, ", "); got != wantEdges {
+		t.Errorf("edges = %s, want %s", got, wantEdges)
+	}
+
+	// Only instance target groups are looked up
+	wantActions := "rds DescribeDBInstances, rds DescribeDBInstances page2, elasticloadbalancing DescribeLoadBalancers, " +
+		"elasticloadbalancing DescribeTargetGroups, elasticloadbalancing DescribeTargetHealth"
+	if got := strings.Join(*actions, ", "); got != wantActions {
+		t.Errorf("actions = %s, want %s", got, wantActions)
+	}
+}
+
+func TestConnector_RefreshServices(t *testing.T) {
+	// Without VPCs, instances are read but have no subnet to be in
+	c, actions := newTestConnector(t, config.AWSServicesConfig{EC2: true})
+	state, err := c.Refresh(context.Background(), store.Source{ID: "aws-prod", Type: "aws"})
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if len(state.Nodes) != 1 || state.Nodes[0].ID != "ec2/i-1" || len(state.Edges) != 0 {
+		t.Errorf("state = %+v, want only the running instance", state)
+	}
+	if len(*actions) != 0 {
+		t.Errorf("called %v, want no RDS or ELB calls", *actions)
+	}
+}
+
+func TestConnector_Settings(t *testing.T) {
+	c := New(config.AWSConfig{Region: "eu-west-1", Profile: "ops"})
+	var got []settings
+	c.load = func(ctx context.Context, s settings) (sdk.Config, error) {
+		got = append(got, s)
+		return sdk.Config{Region: s.region}, nil
+	}
+
+	ctx := context.Background()
+	sources := []store.Source{
+		{ID: "aws-prod"},
+		{ID: "aws-prod-again"}, // Same account and region, same clients
+		{ID: "aws-us", ConnectionDetails: map[string]any{"region": "us-east-1", "role_arn": "arn:aws:iam::123:role/joe-readonly"}},
+	}
+	for _, src := range sources {
+		if err := c.Connect(ctx, src); err != nil {
+			t.Fatalf("Connect(%s) error: %v", src.ID, err)
+		}
+	}
+	want := []settings{
+		{region: "eu-west-1", profile: "ops"},
+		{region: "us-east-1", profile: "ops", roleARN: "arn:aws:iam::123:role/joe-readonly"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+
+	c = New(config.AWSConfig{})
+	c.load = func(ctx context.Context, s settings) (sdk.Config, error) { return sdk.Config{}, nil }
+	if err := c.Connect(ctx, store.Source{ID: "aws-prod"}); err == nil || !strings.Contains(err.Error(), "no AWS region") {
+		t.Errorf("Connect() without a region error = %v, want one asking for it", err)
+	}
+}
+
+func TestQueryClient_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<ErrorResponse><Error><Code>AccessDenied</Code><Message>not authorized to perform rds:DescribeDBInstances</Message></Error></ErrorResponse>`))
+	}))
+	defer ts.Close()
+
+	q := newQueryClient(sdk.Config{Region: "eu-west-1", Credentials: credentials.NewStaticCredentialsProvider("AKIDTEST", "secret", "")})
+	q.endpoint = func(string) string { return ts.URL }
+	err := readDatabases(context.Background(), q, newBuilder())
+	if err == nil || !strings.Contains(err.Error(), "DescribeDBInstances failed: AccessDenied: not authorized") {
+		t.Errorf("readDatabases() error = %v, want the AccessDenied", err)
+	}
+}
@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// ec2API is the part of the EC2 client the connector uses
+type ec2API interface {
+	ec2.DescribeVpcsAPIClient
+	ec2.DescribeSubnetsAPIClient
+	ec2.DescribeInstancesAPIClient
+}
+
+// readVPCs adds the VPCs, and their subnets, each in a vpc that contains
+// it
+func readVPCs(ctx context.Context, client ec2API, b *builder) error {
+	vpcs := ec2.NewDescribeVpcsPaginator(client, &ec2.DescribeVpcsInput{})
+	for vpcs.HasMorePages() {
+		page, err := vpcs.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe VPCs: %w", err)
+		}
+		for _, v := range page.Vpcs {
+			b.addNode(vpcID(sdk.ToString(v.VpcId)), "vpc", withName(map[string]any{
+				"cidr":    sdk.ToString(v.CidrBlock),
+				"default": sdk.ToBool(v.IsDefault),
+			}, nameTag(v.Tags)))
+		}
+	}
+
+	subnets := ec2.NewDescribeSubnetsPaginator(client, &ec2.DescribeSubnetsInput{})
+	for subnets.HasMorePages() {
+		page, err := subnets.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe subnets: %w", err)
+		}
+		for _, s := range page.Subnets {
+			id := subnetID(sdk.ToString(s.SubnetId))
+			b.addNode(id, "subnet", withName(map[string]any{
+				"cidr":              sdk.ToString(s.CidrBlock),
+				"availability_zone": sdk.ToString(s.AvailabilityZone),
+				"public":            sdk.ToBool(s.MapPublicIpOnLaunch),
+			}, nameTag(s.Tags)))
+			b.addEdge(vpcID(sdk.ToString(s.VpcId)), id, "contains", "")
+		}
+	}
+	return nil
+}
+
+// readInstances adds the EC2 instances not terminated, each in the subnet
+// that contains it
+func readInstances(ctx context.Context, client ec2API, b *builder) error {
+	pages := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, r := range page.Reservations {
+			for _, inst := range r.Instances {
+				state := ""
+				if inst.State != nil {
+					state = string(inst.State.Name)
+				}
+				if state == "terminated" {
+					continue
+				}
+				az := ""
+				if inst.Placement != nil {
+					az = sdk.ToString(inst.Placement.AvailabilityZone)
+				}
+				metadata := withName(map[string]any{
+					"instance_type":     string(inst.InstanceType),
+					"state":             state,
+					"availability_zone": az,
+					"private_ip":        sdk.ToString(inst.PrivateIpAddress),
+				}, nameTag(inst.Tags))
+				if ip := sdk.ToString(inst.PublicIpAddress); ip != "" {
+					metadata["public_ip"] = ip
+				}
+
+				id := ec2ID(sdk.ToString(inst.InstanceId))
+				b.addNode(id, "ec2_instance", metadata)
+				b.addEdge(subnetID(sdk.ToString(inst.SubnetId)), id, "contains", "")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// elbVersion is the Elastic Load Balancing (v2) Query API version
+const elbVersion = "2015-12-01"
+
+type describeLoadBalancersResponse struct {
+	Result struct {
+		LoadBalancers []loadBalancer `xml:"LoadBalancers>member"`
+		NextMarker    string         `xml:"NextMarker"`
+	} `xml:"DescribeLoadBalancersResult"`
+}
+
+type loadBalancer struct {
+	ARN     string   `xml:"LoadBalancerArn"`
+	Name    string   `xml:"LoadBalancerName"`
+	DNSName string   `xml:"DNSName"`
+	Type    string   `xml:"Type"`
+	Scheme  string   `xml:"Scheme"`
+	State   string   `xml:"State>Code"`
+	VpcID   string   `xml:"VpcId"`
+	Subnets []string `xml:"AvailabilityZones>member>SubnetId"`
+}
+
+type describeTargetGroupsResponse struct {
+	Result struct {
+		TargetGroups []targetGroup `xml:"TargetGroups>member"`
+		NextMarker   string        `xml:"NextMarker"`
+	} `xml:"DescribeTargetGroupsResult"`
+}
+
+type targetGroup struct {
+	ARN           string   `xml:"TargetGroupArn"`
+	Name          string   `xml:"TargetGroupName"`
+	TargetType    string   `xml:"TargetType"`
+	Protocol      string   `xml:"Protocol"`
+	Port          int      `xml:"Port"`
+	LoadBalancers []string `xml:"LoadBalancerArns>member"`
+}
+
+type describeTargetHealthResponse struct {
+	Result struct {
+		Targets []struct {
+			ID   string `xml:"Target>Id"`
+			Port int    `xml:"Target>Port"`
+		} `xml:"TargetHealthDescriptions>member"`
+	} `xml:"DescribeTargetHealthResult"`
+}
+
+// readLoadBalancers adds the application and network load balancers, each
+// in the vpc that contains it, routing to the instances registered in
+// their target groups
+func readLoadBalancers(ctx context.Context, q *queryClient, b *builder) error {
+	names := make(map[string]string) // ARN to name
+	err := paginate(func(params url.Values) (string, error) {
+		var resp describeLoadBalancersResponse
+		if err := q.call(ctx, "elasticloadbalancing", elbVersion, "DescribeLoadBalancers", params, &resp); err != nil {
+			return "", err
+		}
+		for _, lb := range resp.Result.LoadBalancers {
+			names[lb.ARN] = lb.Name
+			id := lbID(lb.Name)
+			b.addNode(id, "load_balancer", map[string]any{
+				"type":     lb.Type,
+				"scheme":   lb.Scheme,
+				"state":    lb.State,
+				"dns_name": lb.DNSName,
+				"subnets":  lb.Subnets,
+			})
+			b.addEdge(vpcID(lb.VpcID), id, "contains", "")
+		}
+		return resp.Result.NextMarker, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var groups []targetGroup
+	err = paginate(func(params url.Values) (string, error) {
+		var resp describeTargetGroupsResponse
+		if err := q.call(ctx, "elasticloadbalancing", elbVersion, "DescribeTargetGroups", params, &resp); err != nil {
+			return "", err
+		}
+		groups = append(groups, resp.Result.TargetGroups...)
+		return resp.Result.NextMarker, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, tg := range groups {
+		// IP and Lambda targets aren't nodes in the graph
+		if tg.TargetType != "instance" || len(tg.LoadBalancers) == 0 {
+			continue
+		}
+		var resp describeTargetHealthResponse
+		params := url.Values{"TargetGroupArn": {tg.ARN}}
+		if err := q.call(ctx, "elasticloadbalancing", elbVersion, "DescribeTargetHealth", params, &resp); err != nil {
+			return err
+		}
+		for _, lbARN := range tg.LoadBalancers {
+			for _, target := range resp.Result.Targets {
+				how := fmt.Sprintf("target group %s (%s:%d)", tg.Name, tg.Protocol, target.Port)
+				b.addEdge(lbID(names[lbARN]), ec2ID(target.ID), "routes_to", how)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/jaimegago/joe/internal/graph"
+)
+
+// builder collects an account's nodes and edges. Edges are kept only
+// between nodes that were read, so a disabled service leaves no edges
+// pointing at it.
+type builder struct {
+	nodes []graph.Node
+	ids   map[string]bool
+	edges []graph.Edge
+	seen  map[[3]string]bool // From, to, and relation of each edge
+}
+
+func newBuilder() *builder {
+	return &builder{ids: make(map[string]bool), seen: make(map[[3]string]bool)}
+}
+
+func (b *builder) addNode(id, nodeType string, metadata map[string]any) {
+	if b.ids[id] {
+		return
+	}
+	b.ids[id] = true
+	b.nodes = append(b.nodes, graph.Node{ID: id, Type: nodeType, Metadata: metadata})
+}
+
+func (b *builder) addEdge(from, to, relation, context string) {
+	key := [3]string{from, to, relation}
+	if from == "" || to == "" || b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	b.edges = append(b.edges, graph.Edge{From: from, To: to, Relation: relation, Context: context})
+}
+
+// graph returns the nodes, and the edges whose ends were both read
+func (b *builder) graph() *graph.Subgraph {
+	state := &graph.Subgraph{Nodes: b.nodes}
+	for _, e := range b.edges {
+		if b.ids[e.From] && b.ids[e.To] {
+			state.Edges = append(state.Edges, e)
+		}
+	}
+	return state
+}
+
+// Node IDs, e.g. vpc/vpc-0a1b2c, ec2/i-0a1b2c, or rds/orders
+func vpcID(id string) string    { return prefixed("vpc/", id) }
+func subnetID(id string) string { return prefixed("subnet/", id) }
+func ec2ID(id string) string    { return prefixed("ec2/", id) }
+func rdsID(id string) string    { return prefixed("rds/", id) }
+func lbID(name string) string   { return prefixed("lb/", name) }
+
+// prefixed returns "" for an empty id, so edges to unknown resources are
+// dropped
+func prefixed(prefix, id string) string {
+	if id == "" {
+		return ""
+	}
+	return prefix + id
+}
+
+// nameTag returns the Name tag, which the console shows as the name
+func nameTag(tags []types.Tag) string {
+	for _, t := range tags {
+		if t.Key != nil && *t.Key == "Name" && t.Value != nil {
+			return *t.Value
+		}
+	}
+	return ""
+}
+
+// withName adds name to metadata when it isn't empty
+func withName(metadata map[string]any, name string) map[string]any {
+	if name != "" {
+		metadata["name"] = name
+	}
+	return metadata
+}
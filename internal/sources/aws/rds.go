@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"context"
+	"net/url"
+)
+
+// rdsVersion is the RDS Query API version
+const rdsVersion = "2014-10-31"
+
+type describeDBInstancesResponse struct {
+	Result struct {
+		Instances []dbInstance `xml:"DBInstances>DBInstance"`
+		Marker    string       `xml:"Marker"`
+	} `xml:"DescribeDBInstancesResult"`
+}
+
+type dbInstance struct {
+	Identifier    string `xml:"DBInstanceIdentifier"`
+	Class         string `xml:"DBInstanceClass"`
+	Engine        string `xml:"Engine"`
+	EngineVersion string `xml:"EngineVersion"`
+	Status        string `xml:"DBInstanceStatus"`
+	MultiAZ       bool   `xml:"MultiAZ"`
+	Endpoint      struct {
+		Address string `xml:"Address"`
+		Port    int    `xml:"Port"`
+	} `xml:"Endpoint"`
+	SubnetGroup struct {
+		VpcID string `xml:"VpcId"`
+	} `xml:"DBSubnetGroup"`
+}
+
+// readDatabases adds the RDS database instances, each in the vpc that
+// contains it
+func readDatabases(ctx context.Context, q *queryClient, b *builder) error {
+	return paginate(func(params url.Values) (string, error) {
+		var resp describeDBInstancesResponse
+		if err := q.call(ctx, "rds", rdsVersion, "DescribeDBInstances", params, &resp); err != nil {
+			return "", err
+		}
+		for _, db := range resp.Result.Instances {
+			id := rdsID(db.Identifier)
+			b.addNode(id, "rds_instance", map[string]any{
+				"engine":         db.Engine,
+				"engine_version": db.EngineVersion,
+				"instance_class": db.Class,
+				"status":         db.Status,
+				"multi_az":       db.MultiAZ,
+				"endpoint":       db.Endpoint.Address,
+				"port":           db.Endpoint.Port,
+			})
+			b.addEdge(vpcID(db.SubnetGroup.VpcID), id, "contains", "")
+		}
+		return resp.Result.Marker, nil
+	})
+}
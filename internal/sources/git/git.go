@@ -0,0 +1,332 @@
+// Package git maps registered git repositories: a node for the repository
+// and whatever its .joe/ files describe, e.g. the services it deploys and
+// what they depend on. The LLM reads the .joe/ files once per change; its
+// interpretation is cached by a hash of the directory, so refreshing an
+// unchanged repository costs no LLM calls. While the LLM budget is spent,
+// changed directories can queue up and be interpreted together in one call.
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaimegago/joe/internal/config"
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// joeDir is the directory, at the top of a repository, describing it to joe
+const joeDir = ".joe"
+
+// maxJoeDirBytes caps what one repository's .joe/ files may send to the LLM
+const maxJoeDirBytes = 256 << 10
+
+// Cache is the part of the store holding .joe/ interpretations
+type Cache interface {
+	GetJoeFileCache(ctx context.Context, repoID, hash string) (*store.JoeFileCache, error)
+	SetJoeFileCache(ctx context.Context, cache store.JoeFileCache) error
+}
+
+// budget is implemented by LLM adapters limiting calls, such as
+// llm.RateLimitAdapter
+type budget interface {
+	Exhausted() bool
+}
+
+// Connector reads git repositories from a local checkout: the path in the
+// source's connection details, or its URL when that is a local path
+type Connector struct {
+	cache  Cache
+	llm    llm.LLMAdapter // nil leaves uncached .joe/ files uninterpreted
+	model  string
+	logger *slog.Logger
+
+	batch  *llm.Batcher[pendingDir] // nil interprets every directory right away
+	mu     sync.Mutex
+	queued map[string]string // Repository ID to the hash of its queued directory
+}
+
+// pendingDir is a .joe/ directory queued for interpretation
+type pendingDir struct {
+	repoID string
+	hash   string
+	files  []joeFile
+}
+
+// Option configures a Connector
+type Option func(*Connector)
+
+// WithModel records the model interpreting .joe/ files in the cache
+func WithModel(name string) Option {
+	return func(c *Connector) { c.model = name }
+}
+
+// WithLogger sets the logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Connector) { c.logger = logger }
+}
+
+// WithBatching queues uncached .joe/ directories while the LLM's budget is
+// spent, rather than waiting for it on each, and interprets up to threshold
+// of them in one call once that many are queued or the oldest has waited
+// timeout. The repositories are refreshed without their .joe/ nodes until
+// then.
+func WithBatching(threshold int, timeout time.Duration) Option {
+	return func(c *Connector) {
+		c.batch = llm.NewBatcher[pendingDir](threshold, timeout)
+		c.queued = make(map[string]string)
+	}
+}
+
+// New creates a git connector interpreting .joe/ files with adapter and
+// caching the result in cache
+func New(cache Cache, adapter llm.LLMAdapter, opts ...Option) *Connector {
+	c := &Connector{
+		cache:  cache,
+		llm:    adapter,
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Connect implements sources.SourceConnector, checking the checkout exists
+func (c *Connector) Connect(ctx context.Context, source store.Source) error {
+	_, err := checkout(source)
+	return err
+}
+
+// Discover implements sources.SourceConnector. What a repository points
+// at is described by its .joe/ files, as graph nodes, so it finds no
+// sources.
+func (c *Connector) Discover(ctx context.Context, source store.Source) ([]store.Source, error) {
+	return nil, nil
+}
+
+// HealthCheck implements sources.SourceConnector
+func (c *Connector) HealthCheck(ctx context.Context, source store.Source) error {
+	_, err := checkout(source)
+	return err
+}
+
+// Refresh implements sources.SourceConnector: the repository's node, plus
+// the nodes and edges its .joe/ files describe, each of those nodes
+// defined_in the repository
+func (c *Connector) Refresh(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	path, err := checkout(source)
+	if err != nil {
+		return nil, err
+	}
+	repo := graph.Node{
+		ID:   "repo/" + source.ID,
+		Type: "repository",
+		Metadata: map[string]any{
+			"path": path,
+		},
+	}
+	if source.URL != "" {
+		repo.Metadata["url"] = source.URL
+	}
+	if c.batch != nil {
+		// Directories queued by earlier refreshes may be due
+		c.flush(ctx)
+	}
+	state := &graph.Subgraph{Nodes: []graph.Node{repo}}
+
+	files, hash, err := readJoeDir(filepath.Join(path, joeDir))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return state, nil
+	}
+	repo.Metadata["joe_dir_hash"] = hash
+
+	calls, err := c.interpretation(ctx, source, files, hash)
+	if err != nil {
+		return nil, err
+	}
+	described := apply(calls, c.logger.With("source", source.ID))
+	for _, node := range described.Nodes {
+		state.Nodes = append(state.Nodes, node)
+		state.Edges = append(state.Edges, graph.Edge{
+			From:     node.ID,
+			To:       repo.ID,
+			Relation: "defined_in",
+			Context:  joeDir + "/",
+		})
+	}
+	state.Edges = append(state.Edges, described.Edges...)
+	return state, nil
+}
+
+// interpretation returns the tool calls describing files: cached for hash,
+// or from the LLM, and then cached
+func (c *Connector) interpretation(ctx context.Context, source store.Source, files []joeFile, hash string) ([]store.CachedToolCall, error) {
+	cached, err := c.cache.GetJoeFileCache(ctx, source.ID, hash)
+	if err == nil {
+		return cached.ToolCalls, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	if c.llm == nil {
+		return nil, fmt.Errorf("no LLM to interpret %s/", joeDir)
+	}
+	if c.queue(source.ID, files, hash) {
+		if calls, ok := c.flush(ctx)[source.ID]; ok {
+			return calls, nil
+		}
+		c.logger.Info("queued .joe/ files until the LLM budget allows", "source", source.ID, "hash", hash)
+		return nil, nil
+	}
+
+	c.logger.Info("interpreting .joe/ files", "source", source.ID, "files", len(files), "hash", hash)
+	calls, err := interpret(ctx, c.llm, files)
+	if err != nil {
+		return nil, err
+	}
+	c.save(ctx, source.ID, hash, calls)
+	return calls, nil
+}
+
+// queue adds a directory to the batch, returning false when batching is
+// off or the LLM can take a call now and nothing is queued ahead of it
+func (c *Connector) queue(repoID string, files []joeFile, hash string) bool {
+	if c.batch == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b, ok := c.llm.(budget); len(c.queued) == 0 && (!ok || !b.Exhausted()) {
+		return false
+	}
+	if c.queued[repoID] != hash {
+		c.queued[repoID] = hash
+		c.batch.Add(pendingDir{repoID: repoID, hash: hash, files: files})
+	}
+	return true
+}
+
+// flush interprets the next batch of queued directories if one is ready,
+// caching and returning their tool calls by repository ID
+func (c *Connector) flush(ctx context.Context) map[string][]store.CachedToolCall {
+	var dirs []pendingDir
+	c.mu.Lock()
+	for _, dir := range c.batch.Next() {
+		// A directory that changed again while queued was queued again too
+		if c.queued[dir.repoID] == dir.hash {
+			delete(c.queued, dir.repoID)
+			dirs = append(dirs, dir)
+		}
+	}
+	c.mu.Unlock()
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	c.logger.Info("interpreting queued .joe/ files", "repositories", len(dirs))
+	interpreted, err := interpretBatch(ctx, c.llm, dirs)
+	if err != nil {
+		// Their next refresh queues them again
+		c.logger.Warn("failed to interpret queued .joe/ files", "error", err)
+		return nil
+	}
+	for _, dir := range dirs {
+		c.save(ctx, dir.repoID, dir.hash, interpreted[dir.repoID])
+	}
+	return interpreted
+}
+
+// save caches the interpretation of a repository's .joe/ directory
+func (c *Connector) save(ctx context.Context, repoID, hash string, calls []store.CachedToolCall) {
+	err := c.cache.SetJoeFileCache(ctx, store.JoeFileCache{
+		RepoID:     repoID,
+		JoeDirHash: hash,
+		ToolCalls:  calls,
+		LLMModel:   c.model,
+	})
+	if err != nil {
+		// The interpretation is still good for this refresh
+		c.logger.Warn("failed to cache .joe/ interpretation", "source", repoID, "error", err)
+	}
+}
+
+// checkout returns the local checkout of source
+func checkout(source store.Source) (string, error) {
+	path, _ := source.ConnectionDetails["path"].(string)
+	if path == "" {
+		path = strings.TrimPrefix(source.URL, "file://")
+		if !filepath.IsAbs(path) && !strings.HasPrefix(path, "~") {
+			return "", fmt.Errorf("no local checkout of %s (set the source's path)", source.URL)
+		}
+	}
+	path, err := config.ExpandHome(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return "", fmt.Errorf("%s is not a git repository: %w", path, err)
+	}
+	return path, nil
+}
+
+// joeFile is a file in a .joe/ directory
+type joeFile struct {
+	Path    string // Relative to the .joe/ directory, slash-separated
+	Content string
+}
+
+// readJoeDir returns the regular files under dir, in lexical order, and a
+// hash of their paths and contents. A missing dir has no files.
+func readJoeDir(dir string) ([]joeFile, string, error) {
+	var (
+		files []joeFile
+		total int
+	)
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if total += len(data); total > maxJoeDirBytes {
+			return fmt.Errorf("%s is larger than %d KiB", dir, maxJoeDirBytes>>10)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		fmt.Fprintf(h, "%s\x00%d\x00", rel, len(data))
+		h.Write(data)
+		files = append(files, joeFile{Path: rel, Content: string(data)})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) && len(files) == 0 {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,268 @@
+package git
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/store/sqlite"
+)
+
+// countingLLM answers every chat with the same tool calls
+type countingLLM struct {
+	calls     int
+	lastUser  string
+	toolCalls []llm.ToolCall
+}
+
+func (c *countingLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	c.calls++
+	c.lastUser = req.Messages[len(req.Messages)-1].Content
+	return &llm.ChatResponse{ToolCalls: c.toolCalls}, nil
+}
+
+func (c *countingLLM) ChatStream(ctx context.Context, req llm.ChatRequest) (<-chan llm.StreamChunk, error) {
+	return nil, nil
+}
+
+func (c *countingLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	return nil, nil
+}
+
+// newRepo creates a repository with the given .joe/ files
+func newRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, joeDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestConnector_Refresh(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+
+	dir := newRepo(t, map[string]string{
+		"services.yaml":  "payment-api:\n  depends_on: [orders-db]\n",
+		"docs/README.md": "Owned by team-pay.\n",
+	})
+	source := store.Source{ID: "git-payments", Type: "git", URL: "git@github.com:acme/payments.git", ConnectionDetails: map[string]any{"path": dir}}
+
+	model := &countingLLM{toolCalls: []llm.ToolCall{
+		{Name: "add_node", Args: map[string]any{"id": "deploy/payment-api", "type": "deployment", "metadata": `{"owner": "team-pay"}`}},
+		{Name: "add_node", Args: map[string]any{"id": "db/orders", "type": "database", "description": "Orders database"}},
+		{Name: "add_edge", Args: map[string]any{"from": "deploy/payment-api", "to": "db/orders", "relation": "depends_on", "context": "services.yaml"}},
+		{Name: "add_edge", Args: map[string]any{"from": "deploy/payment-api", "relation": "depends_on"}},
+		{Name: "add_edge", Args: map[string]any{"from": "deploy/payment-api", "to": "team/pay", "relation": "owned_by", "implied": true}},
+	}}
+	c := New(st, model, WithModel("default"), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err := c.Connect(ctx, source); err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+
+	// The second refresh replays the cached interpretation
+	var state *graph.Subgraph
+	for range 2 {
+		if state, err = c.Refresh(ctx, source); err != nil {
+			t.Fatalf("Refresh() error: %v", err)
+		}
+	}
+	if model.calls != 1 {
+		t.Errorf("LLM called %d times, want once", model.calls)
+	}
+	if !strings.Contains(model.lastUser, "=== .joe/docs/README.md ===\nOwned by team-pay.") {
+		t.Errorf("prompt = %q, want each file under its path", model.lastUser)
+	}
+
+	var nodes []string
+	for _, n := range state.Nodes {
+		nodes = append(nodes, n.ID)
+	}
+	if strings.Join(nodes, " ") != "repo/git-payments deploy/payment-api db/orders" {
+		t.Errorf("nodes = %v, want the repository and the two described nodes", nodes)
+	}
+	if owner := state.Nodes[1].Metadata["owner"]; owner != "team-pay" {
+		t.Errorf("deploy/payment-api owner = %v, want team-pay from the metadata JSON", owner)
+	}
+	if desc := state.Nodes[2].Metadata["description"]; desc != "Orders database" {
+		t.Errorf("db/orders description = %v, want Orders database", desc)
+	}
+
+	var edges []string
+	for _, e := range state.Edges {
+		edges = append(edges, e.From+" "+e.Relation+" "+e.To)
+	}
+	want := []string{
+		"deploy/payment-api defined_in repo/git-payments",
+		"db/orders defined_in repo/git-payments",
+		"deploy/payment-api depends_on db/orders",
+		"deploy/payment-api owned_by team/pay",
+	}
+	if strings.Join(edges, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("edges = %q, want %q", edges, want)
+	}
+	if stated, implied := state.Edges[2].Confidence, state.Edges[3].Confidence; stated != graph.Explicit || implied != graph.Inferred {
+		t.Errorf("confidence = %v stated, %v implied; want explicit and inferred", stated, implied)
+	}
+
+	// Changing a file interprets the directory again
+	os.WriteFile(filepath.Join(dir, joeDir, "services.yaml"), []byte("payment-api: {}\n"), 0o644)
+	if _, err := c.Refresh(ctx, source); err != nil {
+		t.Fatalf("Refresh() after a change error: %v", err)
+	}
+	if model.calls != 2 {
+		t.Errorf("LLM called %d times after a change, want twice", model.calls)
+	}
+}
+
+// spentLLM is a countingLLM whose budget is spent
+type spentLLM struct {
+	countingLLM
+}
+
+func (s *spentLLM) Exhausted() bool { return true }
+
+func TestConnector_RefreshBatched(t *testing.T) {
+	ctx := context.Background()
+	st, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer st.Close()
+
+	payments := store.Source{ID: "git-payments", Type: "git", ConnectionDetails: map[string]any{
+		"path": newRepo(t, map[string]string{"services.yaml": "payment-api: {}\n"}),
+	}}
+	orders := store.Source{ID: "git-orders", Type: "git", ConnectionDetails: map[string]any{
+		"path": newRepo(t, map[string]string{"services.yaml": "orders-api: {}\n"}),
+	}}
+	model := &spentLLM{countingLLM{toolCalls: []llm.ToolCall{
+		{Name: "add_node", Args: map[string]any{"repo": "git-payments", "id": "deploy/payment-api", "type": "deployment"}},
+		{Name: "add_node", Args: map[string]any{"repo": "git-orders", "id": "deploy/orders-api", "type": "deployment"}},
+		{Name: "add_node", Args: map[string]any{"repo": "git-unknown", "id": "deploy/other", "type": "deployment"}},
+	}}}
+	c := New(st, model, WithBatching(2, time.Hour), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	nodes := func(source store.Source) string {
+		t.Helper()
+		state, err := c.Refresh(ctx, source)
+		if err != nil {
+			t.Fatalf("Refresh(%s) error: %v", source.ID, err)
+		}
+		var ids []string
+		for _, n := range state.Nodes {
+			ids = append(ids, n.ID)
+		}
+		return strings.Join(ids, " ")
+	}
+
+	// The first directory waits for the batch, even when refreshed again
+	for range 2 {
+		if got := nodes(payments); got != "repo/git-payments" {
+			t.Errorf("nodes = %q while queued, want only the repository", got)
+		}
+	}
+	if model.calls != 0 {
+		t.Fatalf("LLM called %d times, want the directory queued", model.calls)
+	}
+
+	// The second fills the batch: both are interpreted in one call
+	if got := nodes(orders); got != "repo/git-orders deploy/orders-api" {
+		t.Errorf("nodes = %q, want the repository and its described node", got)
+	}
+	if model.calls != 1 {
+		t.Errorf("LLM called %d times, want once for the batch", model.calls)
+	}
+	for _, heading := range []string{"##### repository git-payments #####", "##### repository git-orders #####"} {
+		if !strings.Contains(model.lastUser, heading) {
+			t.Errorf("prompt = %q, want %q", model.lastUser, heading)
+		}
+	}
+
+	// The first directory's interpretation was cached from the batch
+	if got := nodes(payments); got != "repo/git-payments deploy/payment-api" {
+		t.Errorf("nodes = %q after the batch, want the repository and its described node", got)
+	}
+	if model.calls != 1 {
+		t.Errorf("LLM called %d times, want the cached interpretation used", model.calls)
+	}
+	cached, err := st.GetJoeFileCache(ctx, "git-payments", mustHash(t, payments))
+	if err != nil {
+		t.Fatalf("GetJoeFileCache() error: %v", err)
+	}
+	if _, ok := cached.ToolCalls[0].Args["repo"]; ok {
+		t.Errorf("cached args = %v, want the repo argument dropped", cached.ToolCalls[0].Args)
+	}
+}
+
+// mustHash returns the hash of source's .joe/ directory
+func mustHash(t *testing.T, source store.Source) string {
+	t.Helper()
+	path, _ := source.ConnectionDetails["path"].(string)
+	_, hash, err := readJoeDir(filepath.Join(path, joeDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestConnector_RefreshWithoutJoeDir(t *testing.T) {
+	dir := newRepo(t, nil)
+	c := New(nil, nil)
+	state, err := c.Refresh(context.Background(), store.Source{ID: "git-tools", URL: "file://" + dir})
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if len(state.Nodes) != 1 || state.Nodes[0].ID != "repo/git-tools" || len(state.Edges) != 0 {
+		t.Errorf("state = %+v, want only the repository", state)
+	}
+}
+
+func TestConnector_Connect(t *testing.T) {
+	c := New(nil, nil)
+	tests := []struct {
+		name    string
+		source  store.Source
+		wantErr string
+	}{
+		{"remote only", store.Source{URL: "git@github.com:acme/infra.git"}, "no local checkout"},
+		{"not a repository", store.Source{URL: t.TempDir()}, "not a git repository"},
+		{"checkout", store.Source{ConnectionDetails: map[string]any{"path": newRepo(t, nil)}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Connect(context.Background(), tt.source)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Connect() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Connect() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
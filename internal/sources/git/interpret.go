@@ -0,0 +1,222 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// Tools the LLM describes .joe/ files with. Their calls are what gets
+// cached, and are replayed into the graph on every refresh.
+const (
+	addNodeTool = "add_node"
+	addEdgeTool = "add_edge"
+)
+
+const interpretPrompt = `You map infrastructure for joe, an infrastructure assistant. The user message holds the files of a git repository's .joe/ directory, where its owners describe what the repository deploys or configures and what that depends on, in any format: YAML, Markdown, or plain notes.
+
+Describe everything they state with the add_node and add_edge tools, calling them as many times as needed in a single reply:
+- add_node for each component: a service, deployment, database, queue, bucket, cluster, dashboard, or team
+- add_edge for each stated relationship between components, e.g. depends_on, deployed_to, owned_by, monitored_by
+
+Use IDs of the form <kind>/<name>, e.g. db/orders or team/payments, and for Kubernetes objects the IDs the cluster's source reports: ns/<namespace>, and deploy/, svc/, ing/, or cm/ followed by <namespace>/<name>, e.g. deploy/payments/payment-api. Only record what the files state or clearly imply; don't guess. Set implied on a relationship the files imply without stating it, e.g. a service configured with a database's connection string, so the user is asked to confirm it. Reply with tool calls only.`
+
+// batchPrompt follows interpretPrompt when several repositories are
+// interpreted in one call
+const batchPrompt = `
+
+This time the user message holds several repositories' .joe/ directories, each under a "##### repository <id> #####" heading. Set repo on every call to the ID of the repository whose files it comes from.`
+
+// repoArg is the argument naming a call's repository in a batch
+const repoArg = "repo"
+
+// tools are the definitions of addNodeTool and addEdgeTool
+var tools = []llm.ToolDefinition{
+	{
+		Name:        addNodeTool,
+		Description: "Add a component described by the .joe/ files to the infrastructure graph",
+		Parameters: llm.ParameterSchema{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"id":          {Type: "string", Description: "Node ID, e.g. db/orders or deploy/payments/payment-api"},
+				"type":        {Type: "string", Description: "Component type, e.g. deployment, service, database"},
+				"description": {Type: "string", Description: "What the files say the component is or does"},
+				"metadata":    {Type: "string", Description: `Other stated attributes as a JSON object, e.g. {"namespace": "payments", "owner": "team-pay"}`},
+			},
+			Required: []string{"id", "type"},
+		},
+	},
+	{
+		Name:        addEdgeTool,
+		Description: "Add a relationship stated by the .joe/ files between two components",
+		Parameters: llm.ParameterSchema{
+			Type: "object",
+			Properties: map[string]llm.Property{
+				"from":     {Type: "string", Description: "ID of the node the relationship starts from"},
+				"to":       {Type: "string", Description: "ID of the node it points at"},
+				"relation": {Type: "string", Description: "Relationship, e.g. depends_on, deployed_to, owned_by"},
+				"context":  {Type: "string", Description: "The file and statement the relationship comes from"},
+				"implied":  {Type: "boolean", Description: "True if the files imply the relationship without stating it"},
+			},
+			Required: []string{"from", "to", "relation"},
+		},
+	},
+}
+
+// batchTools are tools with a required repoArg
+func batchTools() []llm.ToolDefinition {
+	defs := make([]llm.ToolDefinition, len(tools))
+	for i, def := range tools {
+		def.Parameters.Properties = maps.Clone(def.Parameters.Properties)
+		def.Parameters.Properties[repoArg] = llm.Property{Type: "string", Description: "ID of the repository the call describes"}
+		def.Parameters.Required = append([]string{repoArg}, def.Parameters.Required...)
+		defs[i] = def
+	}
+	return defs
+}
+
+// interpret asks adapter to describe files with tool calls
+func interpret(ctx context.Context, adapter llm.LLMAdapter, files []joeFile) ([]store.CachedToolCall, error) {
+	var b strings.Builder
+	writeFiles(&b, files)
+	resp, err := adapter.Chat(ctx, llm.ChatRequest{
+		SystemPrompt: interpretPrompt,
+		Messages:     []llm.Message{{Role: "user", Content: b.String()}},
+		Tools:        tools,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpret .joe/ files: %w", err)
+	}
+
+	calls := make([]store.CachedToolCall, 0, len(resp.ToolCalls))
+	for _, tc := range resp.ToolCalls {
+		calls = append(calls, store.CachedToolCall{Tool: tc.Name, Args: tc.Args})
+	}
+	return calls, nil
+}
+
+// interpretBatch asks adapter to describe several directories in one call,
+// returning each one's tool calls by repository ID. Calls for a repository
+// not in dirs are dropped.
+func interpretBatch(ctx context.Context, adapter llm.LLMAdapter, dirs []pendingDir) (map[string][]store.CachedToolCall, error) {
+	if len(dirs) == 1 {
+		calls, err := interpret(ctx, adapter, dirs[0].files)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]store.CachedToolCall{dirs[0].repoID: calls}, nil
+	}
+
+	var b strings.Builder
+	interpreted := make(map[string][]store.CachedToolCall, len(dirs))
+	for _, dir := range dirs {
+		fmt.Fprintf(&b, "##### repository %s #####\n\n", dir.repoID)
+		writeFiles(&b, dir.files)
+		interpreted[dir.repoID] = []store.CachedToolCall{}
+	}
+	resp, err := adapter.Chat(ctx, llm.ChatRequest{
+		SystemPrompt: interpretPrompt + batchPrompt,
+		Messages:     []llm.Message{{Role: "user", Content: b.String()}},
+		Tools:        batchTools(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpret .joe/ files: %w", err)
+	}
+
+	for _, tc := range resp.ToolCalls {
+		repo := stringArg(tc.Args, repoArg)
+		if _, ok := interpreted[repo]; !ok {
+			continue
+		}
+		args := maps.Clone(tc.Args)
+		delete(args, repoArg)
+		interpreted[repo] = append(interpreted[repo], store.CachedToolCall{Tool: tc.Name, Args: args})
+	}
+	return interpreted, nil
+}
+
+// writeFiles writes files to b under a heading each
+func writeFiles(b *strings.Builder, files []joeFile) {
+	for _, f := range files {
+		fmt.Fprintf(b, "=== .joe/%s ===\n%s\n\n", f.Path, strings.TrimRight(f.Content, "\n"))
+	}
+}
+
+// apply replays calls into the nodes and edges they describe, skipping
+// calls with missing arguments. A node added twice keeps its last
+// description.
+func apply(calls []store.CachedToolCall, logger *slog.Logger) *graph.Subgraph {
+	state := &graph.Subgraph{}
+	index := make(map[string]int) // Node ID to its position in state.Nodes
+	for _, call := range calls {
+		switch call.Tool {
+		case addNodeTool:
+			id, typ := stringArg(call.Args, "id"), stringArg(call.Args, "type")
+			if id == "" || typ == "" {
+				logger.Warn("skipping .joe/ node without an id or type", "args", call.Args)
+				continue
+			}
+			node := graph.Node{ID: id, Type: typ, Metadata: metadata(call.Args, logger)}
+			if i, ok := index[id]; ok {
+				state.Nodes[i] = node
+				continue
+			}
+			index[id] = len(state.Nodes)
+			state.Nodes = append(state.Nodes, node)
+		case addEdgeTool:
+			edge := graph.Edge{
+				From:       stringArg(call.Args, "from"),
+				To:         stringArg(call.Args, "to"),
+				Relation:   stringArg(call.Args, "relation"),
+				Context:    stringArg(call.Args, "context"),
+				Confidence: graph.Explicit,
+			}
+			if implied, _ := call.Args["implied"].(bool); implied {
+				edge.Confidence = graph.Inferred
+			}
+			if edge.From == "" || edge.To == "" || edge.Relation == "" {
+				logger.Warn("skipping incomplete .joe/ edge", "args", call.Args)
+				continue
+			}
+			state.Edges = append(state.Edges, edge)
+		default:
+			logger.Warn("skipping unknown .joe/ tool call", "tool", call.Tool)
+		}
+	}
+	return state
+}
+
+// metadata returns a node's attributes: its metadata argument, which the
+// LLM may send as a JSON string or an object, and its description
+func metadata(args map[string]any, logger *slog.Logger) map[string]any {
+	m := make(map[string]any)
+	switch v := args["metadata"].(type) {
+	case map[string]any:
+		maps.Copy(m, v)
+	case string:
+		if v != "" {
+			if err := json.Unmarshal([]byte(v), &m); err != nil {
+				logger.Warn("ignoring invalid .joe/ node metadata", "id", args["id"], "error", err)
+			}
+		}
+	}
+	if desc := stringArg(args, "description"); desc != "" {
+		m["description"] = desc
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+func stringArg(args map[string]any, key string) string {
+	s, _ := args[key].(string)
+	return strings.TrimSpace(s)
+}
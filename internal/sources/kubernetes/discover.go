@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// discoverable are the services, by name, of tools joe can register as
+// sources of their own
+var discoverable = []struct {
+	sourceType string
+	services   []string
+}{
+	{"prometheus", []string{"prometheus", "prometheus-server", "prometheus-operated", "kube-prometheus-stack-prometheus"}},
+	{"argocd", []string{"argocd-server"}},
+	{"loki", []string{"loki", "loki-gateway"}},
+}
+
+// Discover implements sources.SourceConnector, finding the Prometheus,
+// ArgoCD, and Loki services running in the cluster. Their URLs are
+// in-cluster addresses, which joecored may need a port-forward or an
+// ingress to reach.
+func (c *Connector) Discover(ctx context.Context, source store.Source) ([]store.Source, error) {
+	services, err := c.list(ctx, source, "services")
+	if err != nil {
+		return nil, err
+	}
+
+	var found []store.Source
+	for _, svc := range services {
+		sourceType := ""
+		for _, d := range discoverable {
+			if slices.Contains(d.services, svc.Metadata.Name) {
+				sourceType = d.sourceType
+			}
+		}
+		var spec serviceSpec
+		json.Unmarshal(svc.Spec, &spec)
+		if sourceType == "" || len(spec.Ports) == 0 {
+			continue
+		}
+
+		port := spec.Ports[0]
+		scheme := "http"
+		if port.Port == 443 || port.Name == "https" {
+			scheme = "https"
+		}
+		found = append(found, store.Source{
+			ID:               source.ID + "-" + svc.Metadata.Name,
+			Type:             sourceType,
+			URL:              fmt.Sprintf("%s://%s.%s.svc:%d", scheme, svc.Metadata.Name, svc.Metadata.Namespace, port.Port),
+			Name:             svc.Metadata.Name,
+			Environment:      source.Environment,
+			DiscoveredFrom:   source.ID,
+			DiscoveryContext: fmt.Sprintf("service %s/%s in cluster %s", svc.Metadata.Namespace, svc.Metadata.Name, source.ID),
+		})
+	}
+	return found, nil
+}
@@ -0,0 +1,360 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/graph"
+)
+
+// Node ID prefixes and types of each kind read. IDs of namespaced objects
+// are <prefix>/<namespace>/<name>, e.g. deploy/payments/payment-api.
+var kinds = map[string]struct{ prefix, nodeType string }{
+	"Namespace":  {"ns", "namespace"},
+	"Deployment": {"deploy", "deployment"},
+	"Service":    {"svc", "service"},
+	"Ingress":    {"ing", "ingress"},
+	"ConfigMap":  {"cm", "configmap"},
+}
+
+// rootCAConfigMap is published into every namespace by Kubernetes; it
+// says nothing about what runs there
+const rootCAConfigMap = "kube-root-ca.crt"
+
+// object is the part of a Kubernetes object common to every kind, with
+// the rest left for its kind to decode
+type object struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec   json.RawMessage   `json:"spec"`
+	Status json.RawMessage   `json:"status"`
+	Data   map[string]string `json:"data"` // ConfigMap
+}
+
+// id returns the graph node ID of the object of kind named name in
+// namespace
+func id(kind, namespace, name string) string {
+	if namespace == "" {
+		return kinds[kind].prefix + "/" + name
+	}
+	return kinds[kind].prefix + "/" + namespace + "/" + name
+}
+
+type deploymentSpec struct {
+	Replicas *int `json:"replicas"`
+	Template struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			Volumes []struct {
+				Name      string `json:"name"`
+				ConfigMap *struct {
+					Name string `json:"name"`
+				} `json:"configMap"`
+				Projected *struct {
+					Sources []struct {
+						ConfigMap *struct {
+							Name string `json:"name"`
+						} `json:"configMap"`
+					} `json:"sources"`
+				} `json:"projected"`
+			} `json:"volumes"`
+			Containers []struct {
+				Name    string `json:"name"`
+				Image   string `json:"image"`
+				EnvFrom []struct {
+					ConfigMapRef *struct {
+						Name string `json:"name"`
+					} `json:"configMapRef"`
+				} `json:"envFrom"`
+				Env []struct {
+					Name      string `json:"name"`
+					ValueFrom *struct {
+						ConfigMapKeyRef *struct {
+							Name string `json:"name"`
+						} `json:"configMapKeyRef"`
+					} `json:"valueFrom"`
+				} `json:"env"`
+			} `json:"containers"`
+		} `json:"spec"`
+	} `json:"template"`
+}
+
+type serviceSpec struct {
+	Type      string            `json:"type"`
+	ClusterIP string            `json:"clusterIP"`
+	Selector  map[string]string `json:"selector"`
+	Ports     []struct {
+		Name     string `json:"name"`
+		Port     int    `json:"port"`
+		Protocol string `json:"protocol"`
+	} `json:"ports"`
+}
+
+type ingressBackend struct {
+	Service *struct {
+		Name string `json:"name"`
+	} `json:"service"`
+}
+
+type ingressSpec struct {
+	IngressClassName string          `json:"ingressClassName"`
+	DefaultBackend   *ingressBackend `json:"defaultBackend"`
+	Rules            []struct {
+		Host string `json:"host"`
+		HTTP *struct {
+			Paths []struct {
+				Path    string         `json:"path"`
+				Backend ingressBackend `json:"backend"`
+			} `json:"paths"`
+		} `json:"http"`
+	} `json:"rules"`
+}
+
+// builder collects the nodes and edges of a cluster
+type builder struct {
+	state *graph.Subgraph
+	nodes map[string]bool
+	edges map[[3]string]int // From, to, and relation to the edge's index
+}
+
+// buildGraph maps objects to nodes, with edges from namespaces and owners
+// to what they own, services to the deployments they select, ingresses to
+// the services they route to, and deployments to the configmaps they
+// mount or read
+func buildGraph(objects []object) *graph.Subgraph {
+	b := &builder{
+		state: &graph.Subgraph{},
+		nodes: make(map[string]bool),
+		edges: make(map[[3]string]int),
+	}
+
+	// Nodes first, so edges only point at objects that exist
+	var deployments []object
+	for _, obj := range objects {
+		if _, ok := kinds[obj.Kind]; !ok || (obj.Kind == "ConfigMap" && obj.Metadata.Name == rootCAConfigMap) {
+			continue
+		}
+		b.addNode(obj)
+		if obj.Kind == "Deployment" {
+			deployments = append(deployments, obj)
+		}
+	}
+
+	for _, obj := range objects {
+		self := id(obj.Kind, obj.Metadata.Namespace, obj.Metadata.Name)
+		if !b.nodes[self] {
+			continue
+		}
+		if ns := obj.Metadata.Namespace; ns != "" {
+			b.addEdge(id("Namespace", "", ns), self, "owns", "")
+		}
+		for _, owner := range obj.Metadata.OwnerReferences {
+			if _, ok := kinds[owner.Kind]; ok {
+				b.addEdge(id(owner.Kind, obj.Metadata.Namespace, owner.Name), self, "owns", "ownerReference")
+			}
+		}
+
+		switch obj.Kind {
+		case "Deployment":
+			var spec deploymentSpec
+			json.Unmarshal(obj.Spec, &spec)
+			for cm, context := range configMapRefs(spec) {
+				b.addEdge(self, id("ConfigMap", obj.Metadata.Namespace, cm), "mounts", context)
+			}
+		case "Service":
+			var spec serviceSpec
+			json.Unmarshal(obj.Spec, &spec)
+			if len(spec.Selector) == 0 {
+				continue
+			}
+			for _, d := range deployments {
+				var dspec deploymentSpec
+				json.Unmarshal(d.Spec, &dspec)
+				if d.Metadata.Namespace == obj.Metadata.Namespace && selects(spec.Selector, dspec.Template.Metadata.Labels) {
+					b.addEdge(self, id("Deployment", d.Metadata.Namespace, d.Metadata.Name), "routes_to", "selector "+labelString(spec.Selector))
+				}
+			}
+		case "Ingress":
+			var spec ingressSpec
+			json.Unmarshal(obj.Spec, &spec)
+			if be := spec.DefaultBackend; be != nil && be.Service != nil {
+				b.addEdge(self, id("Service", obj.Metadata.Namespace, be.Service.Name), "routes_to", "default backend")
+			}
+			for _, rule := range spec.Rules {
+				if rule.HTTP == nil {
+					continue
+				}
+				for _, path := range rule.HTTP.Paths {
+					if path.Backend.Service != nil {
+						b.addEdge(self, id("Service", obj.Metadata.Namespace, path.Backend.Service.Name), "routes_to", rule.Host+path.Path)
+					}
+				}
+			}
+		}
+	}
+	return b.state
+}
+
+// addNode adds the node of obj, with what describes it as metadata
+func (b *builder) addNode(obj object) {
+	metadata := map[string]any{"name": obj.Metadata.Name}
+	if obj.Metadata.Namespace != "" {
+		metadata["namespace"] = obj.Metadata.Namespace
+	}
+	if len(obj.Metadata.Labels) > 0 {
+		metadata["labels"] = obj.Metadata.Labels
+	}
+
+	switch obj.Kind {
+	case "Namespace":
+		var status struct {
+			Phase string `json:"phase"`
+		}
+		json.Unmarshal(obj.Status, &status)
+		if status.Phase != "" {
+			metadata["phase"] = status.Phase
+		}
+	case "Deployment":
+		var spec deploymentSpec
+		var status struct {
+			ReadyReplicas int `json:"readyReplicas"`
+		}
+		json.Unmarshal(obj.Spec, &spec)
+		json.Unmarshal(obj.Status, &status)
+		replicas := 1 // The API server's default
+		if spec.Replicas != nil {
+			replicas = *spec.Replicas
+		}
+		var images []string
+		for _, c := range spec.Template.Spec.Containers {
+			images = append(images, c.Image)
+		}
+		metadata["replicas"] = replicas
+		metadata["ready_replicas"] = status.ReadyReplicas
+		metadata["images"] = images
+	case "Service":
+		var spec serviceSpec
+		json.Unmarshal(obj.Spec, &spec)
+		var ports []string
+		for _, p := range spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+		}
+		metadata["type"] = spec.Type
+		metadata["cluster_ip"] = spec.ClusterIP
+		metadata["ports"] = ports
+	case "Ingress":
+		var spec ingressSpec
+		json.Unmarshal(obj.Spec, &spec)
+		var hosts []string
+		for _, rule := range spec.Rules {
+			if rule.Host != "" && !slices.Contains(hosts, rule.Host) {
+				hosts = append(hosts, rule.Host)
+			}
+		}
+		if spec.IngressClassName != "" {
+			metadata["class"] = spec.IngressClassName
+		}
+		metadata["hosts"] = hosts
+	case "ConfigMap":
+		// Keys only: values may hold anything, and belong to the cluster
+		metadata["keys"] = slices.Sorted(maps.Keys(obj.Data))
+	}
+
+	node := graph.Node{
+		ID:       id(obj.Kind, obj.Metadata.Namespace, obj.Metadata.Name),
+		Type:     kinds[obj.Kind].nodeType,
+		Metadata: metadata,
+	}
+	b.nodes[node.ID] = true
+	b.state.Nodes = append(b.state.Nodes, node)
+}
+
+// addEdge adds an edge between nodes that exist; an edge found again, e.g.
+// an ingress routing several paths to one service, lists each context
+func (b *builder) addEdge(from, to, relation, context string) {
+	if !b.nodes[from] || !b.nodes[to] {
+		return
+	}
+	key := [3]string{from, to, relation}
+	if i, ok := b.edges[key]; ok {
+		if e := &b.state.Edges[i]; context != "" && !slices.Contains(strings.Split(e.Context, ", "), context) {
+			e.Context = strings.TrimPrefix(e.Context+", "+context, ", ")
+		}
+		return
+	}
+	b.edges[key] = len(b.state.Edges)
+	b.state.Edges = append(b.state.Edges, graph.Edge{From: from, To: to, Relation: relation, Context: context})
+}
+
+// configMapRefs returns the configmaps a deployment's pods use, each with
+// how: through a volume, envFrom, or an environment variable
+func configMapRefs(spec deploymentSpec) map[string]string {
+	refs := make(map[string]string)
+	add := func(name, how string) {
+		if name == "" {
+			return
+		}
+		if refs[name] != "" {
+			how = refs[name] + ", " + how
+		}
+		refs[name] = how
+	}
+	pod := spec.Template.Spec
+	for _, v := range pod.Volumes {
+		if v.ConfigMap != nil {
+			add(v.ConfigMap.Name, "volume "+v.Name)
+		}
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.ConfigMap != nil {
+					add(s.ConfigMap.Name, "volume "+v.Name)
+				}
+			}
+		}
+	}
+	for _, c := range pod.Containers {
+		for _, ef := range c.EnvFrom {
+			if ef.ConfigMapRef != nil {
+				add(ef.ConfigMapRef.Name, "envFrom in "+c.Name)
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				add(env.ValueFrom.ConfigMapKeyRef.Name, "env "+env.Name)
+			}
+		}
+	}
+	return refs
+}
+
+// selects reports whether a service selector matches a pod's labels
+func selects(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// labelString formats labels like kubectl's selectors, e.g. app=api,tier=web
+func labelString(labels map[string]string) string {
+	var pairs []string
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,131 @@
+// Package kubernetes maps Kubernetes clusters: their namespaces,
+// deployments, services, ingresses, and configmaps, and how they connect.
+// Clusters are read with kubectl, so the kubeconfig's credentials and auth
+// plugins apply.
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// resources are the kinds read on each refresh
+const resources = "namespaces,deployments,services,ingresses,configmaps"
+
+// Timeouts for a cluster's API server
+const (
+	listTimeout   = 30 * time.Second
+	healthTimeout = 5 * time.Second
+)
+
+// Connector reads clusters registered with a kubeconfig context, or with
+// the API server URL, using the current kubeconfig's credentials
+type Connector struct {
+	// run runs kubectl and returns its output; replaced in tests
+	run func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// New creates a Kubernetes connector
+func New() *Connector {
+	return &Connector{run: kubectl}
+}
+
+// Connect implements sources.SourceConnector, checking the source says
+// how to reach its cluster and kubectl is installed
+func (c *Connector) Connect(ctx context.Context, source store.Source) error {
+	if _, err := clusterArgs(source); err != nil {
+		return err
+	}
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return fmt.Errorf("kubectl not found: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck implements sources.SourceConnector
+func (c *Connector) HealthCheck(ctx context.Context, source store.Source) error {
+	args, err := clusterArgs(source)
+	if err != nil {
+		return err
+	}
+	_, err = c.run(ctx, append(args, "version", "--request-timeout="+healthTimeout.String())...)
+	return err
+}
+
+// Refresh implements sources.SourceConnector. The whole state is read
+// each time; the refresh loop only writes the nodes and edges that
+// changed, so nodes carry what describes an object, not counters that
+// change on every read.
+func (c *Connector) Refresh(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	objects, err := c.list(ctx, source, resources)
+	if err != nil {
+		return nil, err
+	}
+	return buildGraph(objects), nil
+}
+
+// list returns the objects of kinds, a comma-separated list, in every
+// namespace
+func (c *Connector) list(ctx context.Context, source store.Source, kinds string) ([]object, error) {
+	args, err := clusterArgs(source)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.run(ctx, append(args, "get", kinds, "--all-namespaces", "--output=json",
+		"--request-timeout="+listTimeout.String())...)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []object `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("invalid kubectl output: %w", err)
+	}
+	return list.Items, nil
+}
+
+// clusterArgs returns the kubectl flags selecting source's cluster: its
+// kubeconfig context, or its API server URL
+func clusterArgs(source store.Source) ([]string, error) {
+	if kubeContext := detail(source, "context"); kubeContext != "" {
+		args := []string{"--context", kubeContext}
+		if kubeconfig := detail(source, "kubeconfig"); kubeconfig != "" {
+			args = append(args, "--kubeconfig", kubeconfig)
+		}
+		return args, nil
+	}
+	if source.URL != "" {
+		return []string{"--server", source.URL}, nil
+	}
+	return nil, fmt.Errorf("kubernetes source %s has neither a kubeconfig context nor a url", source.ID)
+}
+
+// detail returns a string from the source's connection details
+func detail(source store.Source, key string) string {
+	v, _ := source.ConnectionDetails[key].(string)
+	return v
+}
+
+// kubectl runs kubectl, returning its error output as the error
+func kubectl(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("kubectl failed: %s", msg)
+		}
+		return nil, fmt.Errorf("kubectl failed: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// clusterJSON is kubectl's output for a namespace running an API behind an
+// ingress, configured by a configmap, with Prometheus in another
+const clusterJSON = `{"apiVersion": "v1", "kind": "List", "items": [
+	{"kind": "Namespace", "metadata": {"name": "payments"}, "status": {"phase": "Active"}},
+	{"kind": "Namespace", "metadata": {"name": "monitoring"}, "status": {"phase": "Active"}},
+	{"kind": "Deployment", "metadata": {"name": "api", "namespace": "payments", "resourceVersion": "1234"},
+	 "spec": {"replicas": 3, "template": {
+		"metadata": {"labels": {"app": "api", "pod-template-hash": "5d8f"}},
+		"spec": {
+			"volumes": [{"name": "config", "configMap": {"name": "api-config"}}],
+			"containers": [{"name": "api", "image": "acme/api:1.4.2",
+				"env": [{"name": "FLAGS", "valueFrom": {"configMapKeyRef": {"name": "api-config", "key": "flags"}}}]}]}}},
+	 "status": {"readyReplicas": 2}},
+	{"kind": "Service", "metadata": {"name": "api", "namespace": "payments"},
+	 "spec": {"type": "ClusterIP", "clusterIP": "10.0.0.12", "selector": {"app": "api"}, "ports": [{"port": 80, "protocol": "TCP"}]}},
+	{"kind": "Service", "metadata": {"name": "legacy", "namespace": "payments"},
+	 "spec": {"type": "ExternalName"}},
+	{"kind": "Ingress", "metadata": {"name": "api", "namespace": "payments"},
+	 "spec": {"ingressClassName": "nginx", "rules": [
+		{"host": "api.example.com", "http": {"paths": [
+			{"path": "/", "backend": {"service": {"name": "api", "port": {"number": 80}}}},
+			{"path": "/v2", "backend": {"service": {"name": "api", "port": {"number": 80}}}}]}}]}},
+	{"kind": "ConfigMap", "metadata": {"name": "api-config", "namespace": "payments"}, "data": {"flags": "fast", "level": "info"}},
+	{"kind": "ConfigMap", "metadata": {"name": "kube-root-ca.crt", "namespace": "payments"}, "data": {"ca.crt": "..."}},
+	{"kind": "Service", "metadata": {"name": "prometheus-server", "namespace": "monitoring"},
+	 "spec": {"type": "ClusterIP", "ports": [{"name": "http", "port": 9090, "protocol": "TCP"}]}}
+]}`
+
+func newTestConnector(out string, err error) (*Connector, *[]string) {
+	var args []string
+	c := New()
+	c.run = func(ctx context.Context, a ...string) ([]byte, error) {
+		args = a
+		return []byte(out), err
+	}
+	return c, &args
+}
+
+func TestConnector_Refresh(t *testing.T) {
+	c, args := newTestConnector(clusterJSON, nil)
+	source := store.Source{ID: "k8s-prod", Type: "kubernetes", ConnectionDetails: map[string]any{"context": "prod", "kubeconfig": "/etc/kube/config"}}
+	state, err := c.Refresh(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if got := strings.Join(*args, " "); !strings.HasPrefix(got, "--context prod --kubeconfig /etc/kube/config get "+resources+" --all-namespaces") {
+		t.Errorf("kubectl %s, want the source's context and every namespace", got)
+	}
+
+	var nodes []string
+	for _, n := range state.Nodes {
+		nodes = append(nodes, n.ID)
+	}
+	wantNodes := []string{"ns/payments", "ns/monitoring", "deploy/payments/api", "svc/payments/api", "svc/payments/legacy", "ing/payments/api", "cm/payments/api-config", "svc/monitoring/prometheus-server"}
+	if !slices.Equal(nodes, wantNodes) {
+		t.Errorf("nodes = %v, want %v", nodes, wantNodes)
+	}
+	deploy := state.Nodes[2].Metadata
+	if deploy["replicas"] != 3 || deploy["ready_replicas"] != 2 || !slices.Equal(deploy["images"].([]string), []string{"acme/api:1.4.2"}) {
+		t.Errorf("deployment metadata = %v, want replicas, ready replicas, and images", deploy)
+	}
+	if keys := state.Nodes[6].Metadata["keys"]; !slices.Equal(keys.([]string), []string{"flags", "level"}) {
+		t.Errorf("configmap keys = %v, want its keys without values", keys)
+	}
+
+	edges := make(map[string]string)
+	for _, e := range state.Edges {
+		edges[e.From+" "+e.Relation+" "+e.To] = e.Context
+	}
+	for edge, context := range map[string]string{
+		"ns/payments owns deploy/payments/api":                "",
+		"ns/monitoring owns svc/monitoring/prometheus-server": "",
+		"svc/payments/api routes_to deploy/payments/api":      "selector app=api",
+		"ing/payments/api routes_to svc/payments/api":         "api.example.com/, api.example.com/v2",
+		"deploy/payments/api mounts cm/payments/api-config":   "volume config, env FLAGS",
+	} {
+		if got, ok := edges[edge]; !ok || got != context {
+			t.Errorf("edge %q context = %q (found %v), want %q", edge, got, ok, context)
+		}
+	}
+	if len(edges) != 9 {
+		t.Errorf("edges = %v, want 9: one from a namespace to each of its 6 objects, and the 3 above", edges)
+	}
+}
+
+func TestConnector_RefreshFails(t *testing.T) {
+	c, _ := newTestConnector("", errors.New("kubectl failed: Unauthorized"))
+	_, err := c.Refresh(context.Background(), store.Source{ID: "k8s-prod", URL: "https://10.0.0.1:6443"})
+	if err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("Refresh() error = %v, want kubectl's", err)
+	}
+}
+
+func TestConnector_Discover(t *testing.T) {
+	c, _ := newTestConnector(clusterJSON, nil)
+	found, err := c.Discover(context.Background(), store.Source{ID: "k8s-prod", Environment: "prod", URL: "https://10.0.0.1:6443"})
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	want := store.Source{
+		ID:               "k8s-prod-prometheus-server",
+		Type:             "prometheus",
+		URL:              "http://prometheus-server.monitoring.svc:9090",
+		Name:             "prometheus-server",
+		Environment:      "prod",
+		DiscoveredFrom:   "k8s-prod",
+		DiscoveryContext: "service monitoring/prometheus-server in cluster k8s-prod",
+	}
+	if len(found) != 1 || found[0].ID != want.ID || found[0].URL != want.URL || found[0].Type != want.Type ||
+		found[0].DiscoveredFrom != want.DiscoveredFrom || found[0].Environment != want.Environment {
+		t.Errorf("Discover() = %+v, want %+v", found, want)
+	}
+}
+
+func TestClusterArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  store.Source
+		want    []string
+		wantErr bool
+	}{
+		{"context", store.Source{ConnectionDetails: map[string]any{"context": "prod"}}, []string{"--context", "prod"}, false},
+		{"url", store.Source{URL: "https://10.0.0.1:6443"}, []string{"--server", "https://10.0.0.1:6443"}, false},
+		{"neither", store.Source{ID: "k8s-prod"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := clusterArgs(tt.source)
+			if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+				t.Errorf("clusterArgs() = %v, %v; want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Package sources defines how joecored reads the infrastructure it maps:
+// a SourceConnector per source type, e.g. "kubernetes", kept in a Registry
+// the refresh loop looks connectors up in
+package sources
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// SourceConnector reads the sources of one type. One connector serves
+// every source of its type, so each method is given the source; a refresh
+// calls Connect first, and a connector may keep clients keyed by
+// source.ID between calls.
+type SourceConnector interface {
+	// Connect checks the source's settings and credentials and prepares
+	// what the other methods need, e.g. an API client
+	Connect(ctx context.Context, source store.Source) error
+
+	// Discover returns the sources this one points at, e.g. the Prometheus
+	// a cluster runs, with DiscoveredFrom set to source.ID. They are
+	// offered to the user rather than registered.
+	Discover(ctx context.Context, source store.Source) ([]store.Source, error)
+
+	// Refresh reads the source's current state as graph nodes and edges
+	Refresh(ctx context.Context, source store.Source) (*graph.Subgraph, error)
+
+	// HealthCheck reports whether the source can be reached, without
+	// reading its state
+	HealthCheck(ctx context.Context, source store.Source) error
+}
+
+// Registry holds the connector of each source type
+type Registry struct {
+	connectors map[string]SourceConnector
+}
+
+// NewRegistry creates an empty connector registry
+func NewRegistry() *Registry {
+	return &Registry{connectors: make(map[string]SourceConnector)}
+}
+
+// Register adds the connector for sources of sourceType; a type has one
+// connector
+func (r *Registry) Register(sourceType string, c SourceConnector) error {
+	if _, ok := r.connectors[sourceType]; ok {
+		return fmt.Errorf("connector already registered for source type %q", sourceType)
+	}
+	r.connectors[sourceType] = c
+	return nil
+}
+
+// Get returns the connector for sources of sourceType
+func (r *Registry) Get(sourceType string) (SourceConnector, bool) {
+	c, ok := r.connectors[sourceType]
+	return c, ok
+}
+
+// Types returns the source types with a connector, sorted
+func (r *Registry) Types() []string {
+	return slices.Sorted(maps.Keys(r.connectors))
+}
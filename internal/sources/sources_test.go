@@ -0,0 +1,44 @@
+package sources
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/graph"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// nopConnector reads nothing
+type nopConnector struct{}
+
+func (nopConnector) Connect(ctx context.Context, source store.Source) error { return nil }
+func (nopConnector) Discover(ctx context.Context, source store.Source) ([]store.Source, error) {
+	return nil, nil
+}
+func (nopConnector) Refresh(ctx context.Context, source store.Source) (*graph.Subgraph, error) {
+	return &graph.Subgraph{}, nil
+}
+func (nopConnector) HealthCheck(ctx context.Context, source store.Source) error { return nil }
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	for _, sourceType := range []string{"kubernetes", "git"} {
+		if err := r.Register(sourceType, nopConnector{}); err != nil {
+			t.Fatalf("Register(%q) error: %v", sourceType, err)
+		}
+	}
+	if err := r.Register("git", nopConnector{}); err == nil {
+		t.Error("Register() of a second git connector: want error")
+	}
+
+	if got := r.Types(); !slices.Equal(got, []string{"git", "kubernetes"}) {
+		t.Errorf("Types() = %v, want [git kubernetes]", got)
+	}
+	if _, ok := r.Get("kubernetes"); !ok {
+		t.Error("Get(kubernetes) not found")
+	}
+	if _, ok := r.Get("prometheus"); ok {
+		t.Error("Get(prometheus) found, want no connector")
+	}
+}
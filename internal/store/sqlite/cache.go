@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// GetJoeFileCache returns the cached interpretation of a repo's .joe/
+// directory for the given content hash, or store.ErrNotFound
+func (s *Store) GetJoeFileCache(ctx context.Context, repoID, hash string) (*store.JoeFileCache, error) {
+	var (
+		cache     store.JoeFileCache
+		toolCalls string
+		cachedAt  string
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT repo_id, joe_dir_hash, tool_calls, cached_at, llm_model
+		FROM joe_file_cache WHERE repo_id = ? AND joe_dir_hash = ?`, repoID, hash).
+		Scan(&cache.RepoID, &cache.JoeDirHash, &toolCalls, &cachedAt, &cache.LLMModel)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("joe file cache %s@%s: %w", repoID, hash, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get joe file cache for %s: %w", repoID, err)
+	}
+
+	if err := decodeJSON(toolCalls, &cache.ToolCalls); err != nil {
+		return nil, fmt.Errorf("invalid cached tool calls for %s: %w", repoID, err)
+	}
+	if cache.CachedAt, err = parseTime(cachedAt); err != nil {
+		return nil, err
+	}
+
+	return &cache, nil
+}
+
+// SetJoeFileCache stores (or replaces) a cached .joe/ interpretation
+func (s *Store) SetJoeFileCache(ctx context.Context, cache store.JoeFileCache) error {
+	if cache.CachedAt.IsZero() {
+		cache.CachedAt = time.Now()
+	}
+
+	toolCalls, err := encodeJSON(cache.ToolCalls, "[]")
+	if err != nil {
+		return fmt.Errorf("failed to encode cached tool calls for %s: %w", cache.RepoID, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO joe_file_cache (repo_id, joe_dir_hash, tool_calls, cached_at, llm_model)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (repo_id, joe_dir_hash) DO UPDATE SET
+			tool_calls = excluded.tool_calls,
+			cached_at = excluded.cached_at,
+			llm_model = excluded.llm_model`,
+		cache.RepoID, cache.JoeDirHash, toolCalls, formatTime(cache.CachedAt), cache.LLMModel)
+	if err != nil {
+		return fmt.Errorf("failed to set joe file cache for %s: %w", cache.RepoID, err)
+	}
+	return nil
+}
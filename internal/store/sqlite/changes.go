@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// AddGraphChanges inserts changes in one transaction
+func (s *Store) AddGraphChanges(ctx context.Context, changes []store.GraphChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to add graph changes: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, c := range changes {
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = now
+		}
+		before, err := encodeNullJSON(c.Before)
+		if err != nil {
+			return fmt.Errorf("failed to encode graph change %s: %w", c.Target, err)
+		}
+		after, err := encodeNullJSON(c.After)
+		if err != nil {
+			return fmt.Errorf("failed to encode graph change %s: %w", c.Target, err)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO graph_changes
+			(run_id, source_id, kind, action, target, before, after, priority, reason, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			c.RunID, c.SourceID, c.Kind, c.Action, c.Target, before, after, c.Priority, c.Reason, formatTime(c.CreatedAt))
+		if err != nil {
+			return fmt.Errorf("failed to insert graph change %s: %w", c.Target, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add graph changes: %w", err)
+	}
+	return nil
+}
+
+// ListGraphChanges returns the changes made by a refresh run, in the order
+// they were added
+func (s *Store) ListGraphChanges(ctx context.Context, runID string) ([]store.GraphChange, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, run_id, source_id, kind, action, target, before, after,
+		priority, reason, created_at FROM graph_changes WHERE run_id = ? ORDER BY id`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list graph changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []store.GraphChange
+	for rows.Next() {
+		var (
+			c             store.GraphChange
+			before, after sql.NullString
+			createdAt     string
+		)
+		if err := rows.Scan(&c.ID, &c.RunID, &c.SourceID, &c.Kind, &c.Action, &c.Target, &before, &after,
+			&c.Priority, &c.Reason, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan graph change: %w", err)
+		}
+		if err := decodeJSON(before.String, &c.Before); err != nil {
+			return nil, fmt.Errorf("invalid graph change %d: %w", c.ID, err)
+		}
+		if err := decodeJSON(after.String, &c.After); err != nil {
+			return nil, fmt.Errorf("invalid graph change %d: %w", c.ID, err)
+		}
+		if c.CreatedAt, err = parseTime(createdAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list graph changes: %w", err)
+	}
+	return changes, nil
+}
+
+// encodeNullJSON marshals v, storing nil as NULL
+func encodeNullJSON(v map[string]any) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	s, err := encodeJSON(v, "{}")
+	return sql.NullString{String: s, Valid: err == nil}, err
+}
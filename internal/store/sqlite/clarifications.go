@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+const clarificationColumns = `id, type, subject, question, options, context, status, answer,
+	created_at, answered_at`
+
+// AddClarification queues c and returns it as stored. When there already
+// is a clarification of the same type and subject, whatever its status,
+// that one is returned and c is dropped.
+func (s *Store) AddClarification(ctx context.Context, c store.Clarification) (*store.Clarification, error) {
+	if c.Type == "" || c.Subject == "" {
+		return nil, fmt.Errorf("clarification type and subject are required")
+	}
+	if c.Status == "" {
+		c.Status = store.ClarificationPending
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	options, err := encodeJSON(c.Options, "[]")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode clarification %s: %w", c.Subject, err)
+	}
+	cctx, err := encodeJSON(c.Context, "{}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode clarification %s: %w", c.Subject, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO clarifications
+		(type, subject, question, options, context, status, answer, created_at, answered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (type, subject) DO NOTHING`,
+		c.Type, c.Subject, c.Question, options, cctx, c.Status, c.Answer, formatTime(c.CreatedAt), formatNullTime(c.AnsweredAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert clarification %s: %w", c.Subject, err)
+	}
+
+	row := s.db.QueryRowContext(ctx, `SELECT `+clarificationColumns+` FROM clarifications
+		WHERE type = ? AND subject = ?`, c.Type, c.Subject)
+	stored, err := scanClarification(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clarification %s: %w", c.Subject, err)
+	}
+	return stored, nil
+}
+
+// GetClarification returns the clarification with the given ID, or
+// store.ErrNotFound
+func (s *Store) GetClarification(ctx context.Context, id int64) (*store.Clarification, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+clarificationColumns+` FROM clarifications WHERE id = ?`, id)
+	c, err := scanClarification(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("clarification %d: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get clarification %d: %w", id, err)
+	}
+	return c, nil
+}
+
+// ListClarifications returns the clarifications with the given status, or
+// all of them if status is empty, oldest first
+func (s *Store) ListClarifications(ctx context.Context, status string) ([]store.Clarification, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+clarificationColumns+` FROM clarifications
+		WHERE ? = '' OR status = ? ORDER BY id`, status, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clarifications: %w", err)
+	}
+	defer rows.Close()
+
+	var list []store.Clarification
+	for rows.Next() {
+		c, err := scanClarification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan clarification: %w", err)
+		}
+		list = append(list, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list clarifications: %w", err)
+	}
+	return list, nil
+}
+
+// UpdateClarification records the status and answer of an existing
+// clarification
+func (s *Store) UpdateClarification(ctx context.Context, c store.Clarification) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE clarifications SET status = ?, answer = ?, answered_at = ?
+		WHERE id = ?`, c.Status, c.Answer, formatNullTime(c.AnsweredAt), c.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update clarification %d: %w", c.ID, err)
+	}
+	return requireAffected(res, "clarification", fmt.Sprint(c.ID))
+}
+
+func scanClarification(row rowScanner) (*store.Clarification, error) {
+	var (
+		c             store.Clarification
+		options, cctx string
+		createdAt     string
+		answeredAt    sql.NullString
+	)
+	if err := row.Scan(&c.ID, &c.Type, &c.Subject, &c.Question, &options, &cctx, &c.Status, &c.Answer,
+		&createdAt, &answeredAt); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(options, &c.Options); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	if err := decodeJSON(cctx, &c.Context); err != nil {
+		return nil, fmt.Errorf("invalid context: %w", err)
+	}
+
+	var err error
+	if c.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if c.AnsweredAt, err = parseNullTime(answeredAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// AddDailyCost adds cost's calls, tokens, and dollars to the totals stored
+// for its day and model
+func (s *Store) AddDailyCost(ctx context.Context, cost store.DailyCost) error {
+	if cost.Day == "" {
+		return fmt.Errorf("daily cost day is required")
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO daily_costs (day, model, calls, input_tokens, output_tokens, cost_usd)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (day, model) DO UPDATE SET
+			calls = calls + excluded.calls,
+			input_tokens = input_tokens + excluded.input_tokens,
+			output_tokens = output_tokens + excluded.output_tokens,
+			cost_usd = cost_usd + excluded.cost_usd`,
+		cost.Day, cost.Model, cost.Calls, cost.InputTokens, cost.OutputTokens, cost.CostUSD)
+	if err != nil {
+		return fmt.Errorf("failed to add daily cost for %s: %w", cost.Day, err)
+	}
+	return nil
+}
+
+// ListDailyCosts returns the per-model totals of every day from since
+// ("2006-01-02") on, ordered by day and model
+func (s *Store) ListDailyCosts(ctx context.Context, since string) ([]store.DailyCost, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT day, model, calls, input_tokens, output_tokens, cost_usd
+		FROM daily_costs WHERE day >= ? ORDER BY day, model`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily costs: %w", err)
+	}
+	defer rows.Close()
+
+	var costs []store.DailyCost
+	for rows.Next() {
+		var c store.DailyCost
+		if err := rows.Scan(&c.Day, &c.Model, &c.Calls, &c.InputTokens, &c.OutputTokens, &c.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan daily cost: %w", err)
+		}
+		costs = append(costs, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list daily costs: %w", err)
+	}
+	return costs, nil
+}
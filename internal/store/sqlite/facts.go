@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+const factColumns = `id, content, embedding, created_at`
+
+// AddFact stores fact and returns it as stored. When the same fact is
+// already stored, that one is returned and fact is dropped.
+func (s *Store) AddFact(ctx context.Context, fact store.Fact) (*store.Fact, error) {
+	fact.Content = strings.TrimSpace(fact.Content)
+	if fact.Content == "" {
+		return nil, fmt.Errorf("fact content is required")
+	}
+	if fact.CreatedAt.IsZero() {
+		fact.CreatedAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO facts (content, embedding, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (content) DO NOTHING`,
+		fact.Content, encodeEmbedding(fact.Embedding), formatTime(fact.CreatedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert fact: %w", err)
+	}
+
+	row := s.db.QueryRowContext(ctx, `SELECT `+factColumns+` FROM facts WHERE content = ?`, fact.Content)
+	stored, err := scanFact(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fact: %w", err)
+	}
+	return stored, nil
+}
+
+// ListFacts returns every stored fact, oldest first
+func (s *Store) ListFacts(ctx context.Context) ([]store.Fact, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+factColumns+` FROM facts ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list facts: %w", err)
+	}
+	defer rows.Close()
+
+	var facts []store.Fact
+	for rows.Next() {
+		f, err := scanFact(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fact: %w", err)
+		}
+		facts = append(facts, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list facts: %w", err)
+	}
+	return facts, nil
+}
+
+// DeleteFact removes a fact by ID
+func (s *Store) DeleteFact(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM facts WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete fact %d: %w", id, err)
+	}
+	return requireAffected(res, "fact", fmt.Sprint(id))
+}
+
+func scanFact(row rowScanner) (*store.Fact, error) {
+	var (
+		f         store.Fact
+		embedding []byte
+		createdAt string
+	)
+	if err := row.Scan(&f.ID, &f.Content, &embedding, &createdAt); err != nil {
+		return nil, err
+	}
+	f.Embedding = decodeEmbedding(embedding)
+
+	var err error
+	if f.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
@@ -0,0 +1,39 @@
+CREATE TABLE sources (
+    id                 TEXT PRIMARY KEY,
+    type               TEXT NOT NULL,
+    url                TEXT NOT NULL DEFAULT '',
+    name               TEXT NOT NULL DEFAULT '',
+    environment        TEXT NOT NULL DEFAULT '',
+    categories         TEXT NOT NULL DEFAULT '[]',
+    connection_details TEXT NOT NULL DEFAULT '{}',
+    status             TEXT NOT NULL DEFAULT '',
+    last_connected     TEXT,
+    discovered_from    TEXT NOT NULL DEFAULT '',
+    discovery_context  TEXT NOT NULL DEFAULT '',
+    metadata           TEXT NOT NULL DEFAULT '{}',
+    created_at         TEXT NOT NULL
+);
+
+CREATE TABLE sessions (
+    id         TEXT PRIMARY KEY,
+    started_at TEXT NOT NULL,
+    ended_at   TEXT,
+    summary    TEXT NOT NULL DEFAULT '',
+    issue      TEXT NOT NULL DEFAULT '',
+    root_cause TEXT NOT NULL DEFAULT '',
+    resolution TEXT NOT NULL DEFAULT '',
+    components TEXT NOT NULL DEFAULT '[]',
+    tags       TEXT NOT NULL DEFAULT '[]',
+    embedding  BLOB
+);
+
+CREATE INDEX idx_sessions_started_at ON sessions (started_at);
+
+CREATE TABLE joe_file_cache (
+    repo_id      TEXT NOT NULL,
+    joe_dir_hash TEXT NOT NULL,
+    tool_calls   TEXT NOT NULL DEFAULT '[]',
+    cached_at    TEXT NOT NULL,
+    llm_model    TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (repo_id, joe_dir_hash)
+);
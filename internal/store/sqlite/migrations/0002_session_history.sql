@@ -0,0 +1,7 @@
+ALTER TABLE sessions ADD COLUMN messages TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE sessions ADD COLUMN input_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN output_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN total_tokens INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN updated_at TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_sessions_updated_at ON sessions (updated_at);
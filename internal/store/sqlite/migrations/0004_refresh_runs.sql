@@ -0,0 +1,15 @@
+CREATE TABLE refresh_runs (
+    id            TEXT PRIMARY KEY,
+    trigger       TEXT NOT NULL DEFAULT '',
+    status        TEXT NOT NULL,
+    started_at    TEXT NOT NULL,
+    finished_at   TEXT,
+    sources       INTEGER NOT NULL DEFAULT 0,
+    refreshed     INTEGER NOT NULL DEFAULT 0,
+    nodes_updated INTEGER NOT NULL DEFAULT 0,
+    edges_updated INTEGER NOT NULL DEFAULT 0,
+    nodes_removed INTEGER NOT NULL DEFAULT 0,
+    errors        TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX idx_refresh_runs_started_at ON refresh_runs (started_at);
@@ -0,0 +1,17 @@
+ALTER TABLE refresh_runs ADD COLUMN edges_removed INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE graph_changes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id     TEXT NOT NULL,
+    source_id  TEXT NOT NULL,
+    kind       TEXT NOT NULL,
+    action     TEXT NOT NULL,
+    target     TEXT NOT NULL,
+    before     TEXT,
+    after      TEXT,
+    priority   TEXT NOT NULL DEFAULT 'low',
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_graph_changes_run_id ON graph_changes (run_id);
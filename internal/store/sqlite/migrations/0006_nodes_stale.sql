@@ -0,0 +1 @@
+ALTER TABLE refresh_runs ADD COLUMN nodes_stale INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+CREATE TABLE clarifications (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    type        TEXT NOT NULL,
+    subject     TEXT NOT NULL,
+    question    TEXT NOT NULL,
+    options     TEXT NOT NULL DEFAULT '[]',
+    context     TEXT NOT NULL DEFAULT '{}',
+    status      TEXT NOT NULL DEFAULT 'pending',
+    answer      TEXT NOT NULL DEFAULT '',
+    created_at  TEXT NOT NULL,
+    answered_at TEXT,
+    UNIQUE (type, subject)
+);
+
+CREATE INDEX idx_clarifications_status ON clarifications (status);
@@ -0,0 +1,6 @@
+CREATE TABLE facts (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    content    TEXT NOT NULL UNIQUE,
+    embedding  BLOB,
+    created_at TEXT NOT NULL
+);
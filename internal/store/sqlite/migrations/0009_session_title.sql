@@ -0,0 +1 @@
+ALTER TABLE sessions ADD COLUMN title TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE sessions ADD COLUMN origin TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_sessions_origin_updated_at ON sessions (origin, updated_at);
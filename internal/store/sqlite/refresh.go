@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+const refreshRunColumns = `id, trigger, status, started_at, finished_at, sources, refreshed,
+	nodes_updated, edges_updated, nodes_removed, edges_removed, nodes_stale, errors`
+
+// CreateRefreshRun inserts a new refresh run. StartedAt defaults to now
+// when unset.
+func (s *Store) CreateRefreshRun(ctx context.Context, run store.RefreshRun) error {
+	if run.ID == "" {
+		return fmt.Errorf("refresh run id is required")
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+
+	args, err := refreshRunArgs(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode refresh run %s: %w", run.ID, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO refresh_runs (`+refreshRunColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+// UpdateRefreshRun replaces all mutable fields of an existing refresh run
+func (s *Store) UpdateRefreshRun(ctx context.Context, run store.RefreshRun) error {
+	args, err := refreshRunArgs(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode refresh run %s: %w", run.ID, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `UPDATE refresh_runs SET
+		trigger = ?, status = ?, started_at = ?, finished_at = ?, sources = ?, refreshed = ?,
+		nodes_updated = ?, edges_updated = ?, nodes_removed = ?, edges_removed = ?, nodes_stale = ?,
+		errors = ?
+		WHERE id = ?`, append(args[1:], run.ID)...)
+	if err != nil {
+		return fmt.Errorf("failed to update refresh run %s: %w", run.ID, err)
+	}
+	return requireAffected(res, "refresh run", run.ID)
+}
+
+// GetRefreshRun returns the refresh run with the given ID, or
+// store.ErrNotFound
+func (s *Store) GetRefreshRun(ctx context.Context, id string) (*store.RefreshRun, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+refreshRunColumns+` FROM refresh_runs WHERE id = ?`, id)
+	run, err := scanRefreshRun(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("refresh run %s: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh run %s: %w", id, err)
+	}
+	return run, nil
+}
+
+// ListRefreshRuns returns up to limit refresh runs, most recent first.
+// A limit <= 0 returns all runs.
+func (s *Store) ListRefreshRuns(ctx context.Context, limit int) ([]store.RefreshRun, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite treats a negative LIMIT as unbounded
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+refreshRunColumns+` FROM refresh_runs
+		ORDER BY started_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refresh runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []store.RefreshRun
+	for rows.Next() {
+		run, err := scanRefreshRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan refresh run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list refresh runs: %w", err)
+	}
+	return runs, nil
+}
+
+// refreshRunArgs returns column values in refreshRunColumns order
+func refreshRunArgs(run store.RefreshRun) ([]any, error) {
+	errs, err := encodeJSON(run.Errors, "{}")
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		run.ID,
+		run.Trigger,
+		run.Status,
+		formatTime(run.StartedAt),
+		formatNullTime(run.FinishedAt),
+		run.Sources,
+		run.Refreshed,
+		run.NodesUpdated,
+		run.EdgesUpdated,
+		run.NodesRemoved,
+		run.EdgesRemoved,
+		run.NodesStale,
+		errs,
+	}, nil
+}
+
+func scanRefreshRun(row rowScanner) (*store.RefreshRun, error) {
+	var (
+		run        store.RefreshRun
+		startedAt  string
+		finishedAt sql.NullString
+		errs       string
+	)
+	if err := row.Scan(
+		&run.ID,
+		&run.Trigger,
+		&run.Status,
+		&startedAt,
+		&finishedAt,
+		&run.Sources,
+		&run.Refreshed,
+		&run.NodesUpdated,
+		&run.EdgesUpdated,
+		&run.NodesRemoved,
+		&run.EdgesRemoved,
+		&run.NodesStale,
+		&errs,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := decodeJSON(errs, &run.Errors); err != nil {
+		return nil, fmt.Errorf("invalid errors: %w", err)
+	}
+
+	var err error
+	if run.StartedAt, err = parseTime(startedAt); err != nil {
+		return nil, err
+	}
+	if run.FinishedAt, err = parseNullTime(finishedAt); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
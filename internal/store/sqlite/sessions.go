@@ -0,0 +1,223 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+const sessionColumns = `id, started_at, ended_at, summary, issue, root_cause, resolution,
+	components, tags, embedding, messages, input_tokens, output_tokens, total_tokens, updated_at, cost_usd, title, origin`
+
+// CreateSession inserts a new session. StartedAt defaults to now when unset;
+// UpdatedAt is always set to now.
+func (s *Store) CreateSession(ctx context.Context, session store.Session) error {
+	if session.ID == "" {
+		return fmt.Errorf("session id is required")
+	}
+	if session.StartedAt.IsZero() {
+		session.StartedAt = time.Now()
+	}
+	session.UpdatedAt = time.Now()
+
+	args, err := sessionArgs(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", session.ID, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO sessions (`+sessionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert session %s: %w", session.ID, err)
+	}
+	return nil
+}
+
+// GetSession returns the session with the given ID, or store.ErrNotFound
+func (s *Store) GetSession(ctx context.Context, id string) (*store.Session, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("session %s: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session %s: %w", id, err)
+	}
+	return session, nil
+}
+
+// ListSessions returns up to limit sessions, most recently updated first.
+// A limit <= 0 returns all sessions.
+func (s *Store) ListSessions(ctx context.Context, limit int) ([]store.Session, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite treats a negative LIMIT as unbounded
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sessionColumns+` FROM sessions
+		ORDER BY updated_at DESC, started_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []store.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// UpdateSession replaces all mutable fields of an existing session and sets
+// UpdatedAt to now
+func (s *Store) UpdateSession(ctx context.Context, session store.Session) error {
+	session.UpdatedAt = time.Now()
+	args, err := sessionArgs(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", session.ID, err)
+	}
+
+	res, err := s.db.ExecContext(ctx, `UPDATE sessions SET
+		started_at = ?, ended_at = ?, summary = ?, issue = ?, root_cause = ?, resolution = ?,
+		components = ?, tags = ?, embedding = ?, messages = ?, input_tokens = ?, output_tokens = ?,
+		total_tokens = ?, updated_at = ?, cost_usd = ?, title = ?, origin = ?
+		WHERE id = ?`, append(args[1:], session.ID)...)
+	if err != nil {
+		return fmt.Errorf("failed to update session %s: %w", session.ID, err)
+	}
+	return requireAffected(res, "session", session.ID)
+}
+
+// PruneSessions deletes the sessions from origin last updated before
+// updatedBefore, and all but the keep most recently updated. A zero
+// updatedBefore or keep <= 0 leaves out that condition. Returns how many
+// sessions were deleted.
+func (s *Store) PruneSessions(ctx context.Context, origin string, updatedBefore time.Time, keep int) (int, error) {
+	before := "" // No stored time sorts before it
+	if !updatedBefore.IsZero() {
+		before = formatTime(updatedBefore)
+	}
+	if keep <= 0 {
+		keep = -1 // SQLite treats a negative LIMIT as unbounded
+	}
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE origin = ? AND (updated_at < ? OR id NOT IN (
+		SELECT id FROM sessions WHERE origin = ? ORDER BY updated_at DESC, started_at DESC LIMIT ?))`,
+		origin, before, origin, keep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune sessions: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pruned sessions: %w", err)
+	}
+	return int(n), nil
+}
+
+// sessionArgs returns column values in sessionColumns order
+func sessionArgs(session store.Session) ([]any, error) {
+	components, err := encodeJSON(session.Components, "[]")
+	if err != nil {
+		return nil, err
+	}
+	tags, err := encodeJSON(session.Tags, "[]")
+	if err != nil {
+		return nil, err
+	}
+	messages, err := encodeJSON(session.Messages, "[]")
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		session.ID,
+		formatTime(session.StartedAt),
+		formatNullTime(session.EndedAt),
+		session.Summary,
+		session.Issue,
+		session.RootCause,
+		session.Resolution,
+		components,
+		tags,
+		encodeEmbedding(session.Embedding),
+		messages,
+		session.InputTokens,
+		session.OutputTokens,
+		session.TotalTokens,
+		formatTime(session.UpdatedAt),
+		session.CostUSD,
+		session.Title,
+		session.Origin,
+	}, nil
+}
+
+func scanSession(row rowScanner) (*store.Session, error) {
+	var (
+		session          store.Session
+		startedAt        string
+		endedAt          sql.NullString
+		components, tags string
+		embedding        []byte
+		messages         string
+		updatedAt        string
+	)
+	if err := row.Scan(
+		&session.ID,
+		&startedAt,
+		&endedAt,
+		&session.Summary,
+		&session.Issue,
+		&session.RootCause,
+		&session.Resolution,
+		&components,
+		&tags,
+		&embedding,
+		&messages,
+		&session.InputTokens,
+		&session.OutputTokens,
+		&session.TotalTokens,
+		&updatedAt,
+		&session.CostUSD,
+		&session.Title,
+		&session.Origin,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := decodeJSON(components, &session.Components); err != nil {
+		return nil, fmt.Errorf("invalid components: %w", err)
+	}
+	if err := decodeJSON(tags, &session.Tags); err != nil {
+		return nil, fmt.Errorf("invalid tags: %w", err)
+	}
+	if err := decodeJSON(messages, &session.Messages); err != nil {
+		return nil, fmt.Errorf("invalid messages: %w", err)
+	}
+	session.Embedding = decodeEmbedding(embedding)
+
+	var err error
+	if session.StartedAt, err = parseTime(startedAt); err != nil {
+		return nil, err
+	}
+	if session.EndedAt, err = parseNullTime(endedAt); err != nil {
+		return nil, err
+	}
+	// Rows written before updated_at existed have an empty value
+	if updatedAt != "" {
+		if session.UpdatedAt, err = parseTime(updatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,193 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+const sourceColumns = `id, type, url, name, environment, categories, connection_details,
+	status, last_connected, discovered_from, discovery_context, metadata, created_at`
+
+// AddSource inserts a new source. CreatedAt defaults to now when unset.
+func (s *Store) AddSource(ctx context.Context, source store.Source) error {
+	if source.ID == "" {
+		return fmt.Errorf("source id is required")
+	}
+	if source.CreatedAt.IsZero() {
+		source.CreatedAt = time.Now()
+	}
+
+	args, err := sourceArgs(source)
+	if err != nil {
+		return fmt.Errorf("failed to encode source %s: %w", source.ID, err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO sources (`+sourceColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert source %s: %w", source.ID, err)
+	}
+	return nil
+}
+
+// GetSource returns the source with the given ID, or store.ErrNotFound
+func (s *Store) GetSource(ctx context.Context, id string) (*store.Source, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sourceColumns+` FROM sources WHERE id = ?`, id)
+	source, err := scanSource(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("source %s: %w", id, store.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source %s: %w", id, err)
+	}
+	return source, nil
+}
+
+// ListSources returns all sources ordered by ID
+func (s *Store) ListSources(ctx context.Context) ([]store.Source, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sourceColumns+` FROM sources ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []store.Source
+	for rows.Next() {
+		source, err := scanSource(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan source: %w", err)
+		}
+		sources = append(sources, *source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	return sources, nil
+}
+
+// UpdateSource replaces all fields of an existing source
+func (s *Store) UpdateSource(ctx context.Context, source store.Source) error {
+	args, err := sourceArgs(source)
+	if err != nil {
+		return fmt.Errorf("failed to encode source %s: %w", source.ID, err)
+	}
+
+	// Move id to the end for the WHERE clause; created_at is immutable
+	res, err := s.db.ExecContext(ctx, `UPDATE sources SET
+		type = ?, url = ?, name = ?, environment = ?, categories = ?, connection_details = ?,
+		status = ?, last_connected = ?, discovered_from = ?, discovery_context = ?, metadata = ?
+		WHERE id = ?`, append(args[1:12], source.ID)...)
+	if err != nil {
+		return fmt.Errorf("failed to update source %s: %w", source.ID, err)
+	}
+	return requireAffected(res, "source", source.ID)
+}
+
+// DeleteSource removes a source by ID
+func (s *Store) DeleteSource(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sources WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete source %s: %w", id, err)
+	}
+	return requireAffected(res, "source", id)
+}
+
+// sourceArgs returns column values in sourceColumns order
+func sourceArgs(source store.Source) ([]any, error) {
+	categories, err := encodeJSON(source.Categories, "[]")
+	if err != nil {
+		return nil, err
+	}
+	details, err := encodeJSON(source.ConnectionDetails, "{}")
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := encodeJSON(source.Metadata, "{}")
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		source.ID,
+		source.Type,
+		source.URL,
+		source.Name,
+		source.Environment,
+		categories,
+		details,
+		source.Status,
+		formatNullTime(source.LastConnected),
+		source.DiscoveredFrom,
+		source.DiscoveryContext,
+		metadata,
+		formatTime(source.CreatedAt),
+	}, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSource(row rowScanner) (*store.Source, error) {
+	var (
+		source                    store.Source
+		categories, details, meta string
+		lastConnected             sql.NullString
+		createdAt                 string
+	)
+	if err := row.Scan(
+		&source.ID,
+		&source.Type,
+		&source.URL,
+		&source.Name,
+		&source.Environment,
+		&categories,
+		&details,
+		&source.Status,
+		&lastConnected,
+		&source.DiscoveredFrom,
+		&source.DiscoveryContext,
+		&meta,
+		&createdAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := decodeJSON(categories, &source.Categories); err != nil {
+		return nil, fmt.Errorf("invalid categories: %w", err)
+	}
+	if err := decodeJSON(details, &source.ConnectionDetails); err != nil {
+		return nil, fmt.Errorf("invalid connection_details: %w", err)
+	}
+	if err := decodeJSON(meta, &source.Metadata); err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	var err error
+	if source.LastConnected, err = parseNullTime(lastConnected); err != nil {
+		return nil, err
+	}
+	if source.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+
+	return &source, nil
+}
+
+// requireAffected returns store.ErrNotFound when an UPDATE/DELETE touched no rows
+func requireAffected(res sql.Result, kind, id string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check affected rows for %s %s: %w", kind, id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %s: %w", kind, id, store.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,583 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(context.Background(), filepath.Join(t.TempDir(), "joe.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestOpen_MigrationsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "joe.db")
+	ctx := context.Background()
+
+	s, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("first Open() error: %v", err)
+	}
+	s.Close()
+
+	// Reopening must not re-apply migrations
+	s, err = Open(ctx, path)
+	if err != nil {
+		t.Fatalf("second Open() error: %v", err)
+	}
+	defer s.Close()
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatalf("count migrations: %v", err)
+	}
+	if count == 0 {
+		t.Error("expected at least one recorded migration")
+	}
+}
+
+func TestStore_Sources(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	connected := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	src := store.Source{
+		ID:                "k8s/prod-us",
+		Type:              "kubernetes",
+		URL:               "https://k8s.example.com",
+		Name:              "Production US",
+		Environment:       "prod",
+		Categories:        []string{"orchestration"},
+		ConnectionDetails: map[string]any{"context": "prod"},
+		Status:            "connected",
+		LastConnected:     &connected,
+		DiscoveredFrom:    "user_input",
+		Metadata:          map[string]any{"owner": "platform"},
+		CreatedAt:         time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	if err := s.AddSource(ctx, src); err != nil {
+		t.Fatalf("AddSource() error: %v", err)
+	}
+	if err := s.AddSource(ctx, src); err == nil {
+		t.Error("AddSource() with duplicate ID should fail")
+	}
+
+	got, err := s.GetSource(ctx, src.ID)
+	if err != nil {
+		t.Fatalf("GetSource() error: %v", err)
+	}
+	if !reflect.DeepEqual(*got, src) {
+		t.Errorf("GetSource() = %+v, want %+v", *got, src)
+	}
+
+	src.Status = "error"
+	src.LastConnected = nil
+	if err := s.UpdateSource(ctx, src); err != nil {
+		t.Fatalf("UpdateSource() error: %v", err)
+	}
+	got, _ = s.GetSource(ctx, src.ID)
+	if got.Status != "error" || got.LastConnected != nil {
+		t.Errorf("UpdateSource() not applied: %+v", got)
+	}
+
+	if err := s.AddSource(ctx, store.Source{ID: "git/app", Type: "git"}); err != nil {
+		t.Fatalf("AddSource() error: %v", err)
+	}
+	list, err := s.ListSources(ctx)
+	if err != nil {
+		t.Fatalf("ListSources() error: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != "git/app" || list[1].ID != "k8s/prod-us" {
+		t.Errorf("ListSources() = %v, want [git/app k8s/prod-us]", list)
+	}
+
+	if err := s.DeleteSource(ctx, src.ID); err != nil {
+		t.Fatalf("DeleteSource() error: %v", err)
+	}
+	if _, err := s.GetSource(ctx, src.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetSource() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_NotFound(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"get source", func() error { _, err := s.GetSource(ctx, "missing"); return err }},
+		{"update source", func() error { return s.UpdateSource(ctx, store.Source{ID: "missing"}) }},
+		{"delete source", func() error { return s.DeleteSource(ctx, "missing") }},
+		{"get session", func() error { _, err := s.GetSession(ctx, "missing"); return err }},
+		{"update session", func() error { return s.UpdateSession(ctx, store.Session{ID: "missing"}) }},
+		{"get cache", func() error { _, err := s.GetJoeFileCache(ctx, "repo", "hash"); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(); !errors.Is(err, store.ErrNotFound) {
+				t.Errorf("error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStore_Sessions(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	sess := store.Session{
+		ID:         "s1",
+		Title:      "payment-api OOM crashes",
+		Origin:     "api",
+		StartedAt:  time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC),
+		Summary:    "debugged OOM",
+		Components: []string{"payment-api"},
+		Tags:       []string{"oom"},
+		Embedding:  []float32{0.25, -1.5, 3},
+		Messages: []llm.Message{
+			{Role: "user", Content: "why is payment-api crashing?"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "t1", Name: "run_command", Args: map[string]any{"command": "kubectl"}}}},
+			{Role: "user", Content: "OOMKilled", ToolResultID: "t1", ToolName: "run_command"},
+		},
+		InputTokens:  120,
+		OutputTokens: 45,
+		TotalTokens:  165,
+		CostUSD:      0.0012,
+	}
+	if err := s.CreateSession(ctx, sess); err != nil {
+		t.Fatalf("CreateSession() error: %v", err)
+	}
+
+	got, err := s.GetSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("GetSession() error: %v", err)
+	}
+	if got.UpdatedAt.IsZero() {
+		t.Error("GetSession() UpdatedAt not set")
+	}
+	got.UpdatedAt = time.Time{}
+	if !reflect.DeepEqual(*got, sess) {
+		t.Errorf("GetSession() = %+v, want %+v", *got, sess)
+	}
+
+	ended := sess.StartedAt.Add(time.Hour)
+	sess.EndedAt = &ended
+	sess.RootCause = "memory limit too low"
+	if err := s.UpdateSession(ctx, sess); err != nil {
+		t.Fatalf("UpdateSession() error: %v", err)
+	}
+	got, _ = s.GetSession(ctx, "s1")
+	if got.EndedAt == nil || !got.EndedAt.Equal(ended) || got.RootCause != sess.RootCause {
+		t.Errorf("UpdateSession() not applied: %+v", got)
+	}
+}
+
+func TestStore_ListSessions(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"old", "mid", "new"} {
+		if err := s.CreateSession(ctx, store.Session{ID: id}); err != nil {
+			t.Fatalf("CreateSession(%s) error: %v", id, err)
+		}
+	}
+	// Touching a session moves it to the front
+	if err := s.UpdateSession(ctx, store.Session{ID: "old", Summary: "resumed"}); err != nil {
+		t.Fatalf("UpdateSession() error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"all", 0, []string{"old", "new", "mid"}},
+		{"limited", 2, []string{"old", "new"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, err := s.ListSessions(ctx, tt.limit)
+			if err != nil {
+				t.Fatalf("ListSessions() error: %v", err)
+			}
+			var ids []string
+			for _, sess := range sessions {
+				ids = append(ids, sess.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ListSessions(%d) = %v, want %v", tt.limit, ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_PruneSessions(t *testing.T) {
+	tests := []struct {
+		name       string
+		expire     bool // Prune sessions updated before "mid" was created
+		keep       int
+		wantPruned int
+		want       []string
+	}{
+		{"nothing", false, 0, 0, []string{"repl", "new", "mid", "old"}},
+		{"expired", true, 0, 1, []string{"repl", "new", "mid"}},
+		{"over the limit", false, 1, 2, []string{"repl", "new"}},
+		{"both", true, 2, 1, []string{"repl", "new", "mid"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t)
+			ctx := context.Background()
+
+			var cutoff time.Time
+			for _, sess := range []store.Session{
+				{ID: "old", Origin: "api"},
+				{ID: "mid", Origin: "api"},
+				{ID: "new", Origin: "api"},
+				{ID: "repl"}, // Other origins are left alone
+			} {
+				if sess.ID == "mid" {
+					cutoff = time.Now()
+				}
+				if err := s.CreateSession(ctx, sess); err != nil {
+					t.Fatalf("CreateSession(%s) error: %v", sess.ID, err)
+				}
+			}
+			before := time.Time{}
+			if tt.expire {
+				before = cutoff
+			}
+
+			pruned, err := s.PruneSessions(ctx, "api", before, tt.keep)
+			if err != nil {
+				t.Fatalf("PruneSessions() error: %v", err)
+			}
+			if pruned != tt.wantPruned {
+				t.Errorf("PruneSessions() = %d, want %d", pruned, tt.wantPruned)
+			}
+			sessions, err := s.ListSessions(ctx, 0)
+			if err != nil {
+				t.Fatalf("ListSessions() error: %v", err)
+			}
+			var ids []string
+			for _, sess := range sessions {
+				ids = append(ids, sess.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("sessions left = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_JoeFileCache(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	cache := store.JoeFileCache{
+		RepoID:     "github.com/acme/app",
+		JoeDirHash: "abc123",
+		ToolCalls: []store.CachedToolCall{
+			{Tool: "graph_add_node", Args: map[string]any{"id": "svc/app"}},
+		},
+		CachedAt: time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC),
+		LLMModel: "claude-sonnet-4-20250514",
+	}
+	if err := s.SetJoeFileCache(ctx, cache); err != nil {
+		t.Fatalf("SetJoeFileCache() error: %v", err)
+	}
+
+	// Overwrite with a new model to exercise the upsert path
+	cache.LLMModel = "gemini-2.5-flash"
+	if err := s.SetJoeFileCache(ctx, cache); err != nil {
+		t.Fatalf("SetJoeFileCache() upsert error: %v", err)
+	}
+
+	got, err := s.GetJoeFileCache(ctx, cache.RepoID, cache.JoeDirHash)
+	if err != nil {
+		t.Fatalf("GetJoeFileCache() error: %v", err)
+	}
+	if !reflect.DeepEqual(*got, cache) {
+		t.Errorf("GetJoeFileCache() = %+v, want %+v", *got, cache)
+	}
+}
+
+func TestStore_DailyCosts(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	for _, c := range []store.DailyCost{
+		{Day: "2024-03-01", Model: "claude-sonnet-4", Calls: 1, InputTokens: 100, OutputTokens: 10, CostUSD: 0.5},
+		{Day: "2024-03-02", Model: "claude-sonnet-4", Calls: 1, InputTokens: 200, OutputTokens: 20, CostUSD: 1},
+		{Day: "2024-03-02", Model: "claude-sonnet-4", Calls: 2, InputTokens: 300, OutputTokens: 30, CostUSD: 1.5},
+		{Day: "2024-03-02", Model: "gemini-2.5-flash", Calls: 1, InputTokens: 50, OutputTokens: 5},
+	} {
+		if err := s.AddDailyCost(ctx, c); err != nil {
+			t.Fatalf("AddDailyCost() error: %v", err)
+		}
+	}
+
+	got, err := s.ListDailyCosts(ctx, "2024-03-02")
+	if err != nil {
+		t.Fatalf("ListDailyCosts() error: %v", err)
+	}
+	want := []store.DailyCost{
+		{Day: "2024-03-02", Model: "claude-sonnet-4", Calls: 3, InputTokens: 500, OutputTokens: 50, CostUSD: 2.5},
+		{Day: "2024-03-02", Model: "gemini-2.5-flash", Calls: 1, InputTokens: 50, OutputTokens: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListDailyCosts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStore_RefreshRuns(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	start := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	for i, id := range []string{"r1", "r2"} {
+		run := store.RefreshRun{
+			ID:        id,
+			Trigger:   "scheduled",
+			Status:    store.RefreshRunning,
+			StartedAt: start.Add(time.Duration(i) * 5 * time.Minute),
+			Sources:   2,
+		}
+		if err := s.CreateRefreshRun(ctx, run); err != nil {
+			t.Fatalf("CreateRefreshRun() error: %v", err)
+		}
+	}
+
+	finished := start.Add(time.Minute)
+	run := store.RefreshRun{
+		ID:           "r1",
+		Trigger:      "scheduled",
+		Status:       store.RefreshCompleted,
+		StartedAt:    start,
+		FinishedAt:   &finished,
+		Sources:      2,
+		Refreshed:    2,
+		NodesUpdated: 12,
+		EdgesUpdated: 7,
+		NodesRemoved: 1,
+		EdgesRemoved: 3,
+		NodesStale:   2,
+		Errors:       map[string]string{"k8s-prod": "connection refused"},
+	}
+	if err := s.UpdateRefreshRun(ctx, run); err != nil {
+		t.Fatalf("UpdateRefreshRun() error: %v", err)
+	}
+
+	got, err := s.GetRefreshRun(ctx, "r1")
+	if err != nil {
+		t.Fatalf("GetRefreshRun() error: %v", err)
+	}
+	if !reflect.DeepEqual(*got, run) {
+		t.Errorf("GetRefreshRun() = %+v, want %+v", *got, run)
+	}
+
+	runs, err := s.ListRefreshRuns(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListRefreshRuns() error: %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != "r2" {
+		t.Errorf("ListRefreshRuns(1) = %+v, want the latest run r2", runs)
+	}
+
+	if _, err := s.GetRefreshRun(ctx, "missing"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetRefreshRun(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_GraphChanges(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	at := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	changes := []store.GraphChange{
+		{RunID: "r1", SourceID: "aws-prod", Kind: store.ChangeNode, Action: store.ChangeAdded, Target: "lb/web",
+			After:    map[string]any{"type": "load_balancer", "metadata": map[string]any{"scheme": "internet-facing"}},
+			Priority: "high", Reason: "new public load_balancer lb/web: internet-facing", CreatedAt: at},
+		{RunID: "r1", SourceID: "aws-prod", Kind: store.ChangeEdge, Action: store.ChangeRemoved, Target: "lb/web -[routes_to]-> ec2/i-1",
+			Before: map[string]any{"from": "lb/web", "to": "ec2/i-1", "relation": "routes_to"}, Priority: "low", CreatedAt: at},
+		{RunID: "r2", SourceID: "aws-prod", Kind: store.ChangeNode, Action: store.ChangeRemoved, Target: "ec2/i-1", Priority: "medium"},
+	}
+	if err := s.AddGraphChanges(ctx, changes); err != nil {
+		t.Fatalf("AddGraphChanges() error: %v", err)
+	}
+
+	got, err := s.ListGraphChanges(ctx, "r1")
+	if err != nil {
+		t.Fatalf("ListGraphChanges() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ListGraphChanges(r1) = %+v, want 2 changes", got)
+	}
+	for i := range got {
+		want := changes[i]
+		want.ID = got[i].ID
+		if !reflect.DeepEqual(got[i], want) {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want)
+		}
+	}
+	if got[0].ID >= got[1].ID {
+		t.Errorf("IDs = %d, %d; want them in insertion order", got[0].ID, got[1].ID)
+	}
+	if got, _ := s.ListGraphChanges(ctx, "missing"); len(got) != 0 {
+		t.Errorf("ListGraphChanges(missing) = %+v, want none", got)
+	}
+}
+
+func TestStore_Clarifications(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	at := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	c := store.Clarification{
+		Type:      store.ClarifyEdge,
+		Subject:   "api -[depends_on]-> postgres",
+		Question:  "Is it right that api depends on postgres?",
+		Options:   []string{"yes", "no"},
+		Context:   map[string]any{"from": "api", "to": "postgres", "relation": "depends_on"},
+		CreatedAt: at,
+	}
+	added, err := s.AddClarification(ctx, c)
+	if err != nil {
+		t.Fatalf("AddClarification() error: %v", err)
+	}
+	want := c
+	want.ID = added.ID
+	want.Status = store.ClarificationPending
+	if !reflect.DeepEqual(*added, want) {
+		t.Errorf("AddClarification() = %+v, want %+v", *added, want)
+	}
+
+	// The same question isn't queued twice
+	again, err := s.AddClarification(ctx, store.Clarification{Type: c.Type, Subject: c.Subject, Question: "again?"})
+	if err != nil {
+		t.Fatalf("AddClarification(again) error: %v", err)
+	}
+	if again.ID != added.ID || again.Question != c.Question {
+		t.Errorf("AddClarification(again) = %+v, want the first one", *again)
+	}
+	other, err := s.AddClarification(ctx, store.Clarification{Type: c.Type, Subject: "web -[uses]-> redis", Question: "Is it right that web uses redis?"})
+	if err != nil {
+		t.Fatalf("AddClarification(other) error: %v", err)
+	}
+
+	answered := *added
+	answered.Status = store.ClarificationAnswered
+	answered.Answer = "yes"
+	answered.AnsweredAt = &at
+	if err := s.UpdateClarification(ctx, answered); err != nil {
+		t.Fatalf("UpdateClarification() error: %v", err)
+	}
+	got, err := s.GetClarification(ctx, added.ID)
+	if err != nil {
+		t.Fatalf("GetClarification() error: %v", err)
+	}
+	if !reflect.DeepEqual(*got, answered) {
+		t.Errorf("GetClarification() = %+v, want %+v", *got, answered)
+	}
+
+	tests := []struct {
+		status string
+		want   []int64
+	}{
+		{store.ClarificationPending, []int64{other.ID}},
+		{store.ClarificationAnswered, []int64{added.ID}},
+		{store.ClarificationDismissed, nil},
+		{"", []int64{added.ID, other.ID}},
+	}
+	for _, tt := range tests {
+		t.Run("status="+tt.status, func(t *testing.T) {
+			list, err := s.ListClarifications(ctx, tt.status)
+			if err != nil {
+				t.Fatalf("ListClarifications() error: %v", err)
+			}
+			var ids []int64
+			for _, c := range list {
+				ids = append(ids, c.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ListClarifications(%q) IDs = %v, want %v", tt.status, ids, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.GetClarification(ctx, 999); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetClarification(999) error = %v, want ErrNotFound", err)
+	}
+	if err := s.UpdateClarification(ctx, store.Clarification{ID: 999}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("UpdateClarification(999) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_Facts(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	at := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	fact := store.Fact{
+		Content:   " The prod database is db-prod-3 in us-east-1 ",
+		Embedding: []float32{0.5, -1},
+		CreatedAt: at,
+	}
+	added, err := s.AddFact(ctx, fact)
+	if err != nil {
+		t.Fatalf("AddFact() error: %v", err)
+	}
+	want := fact
+	want.ID = added.ID
+	want.Content = "The prod database is db-prod-3 in us-east-1"
+	if !reflect.DeepEqual(*added, want) {
+		t.Errorf("AddFact() = %+v, want %+v", *added, want)
+	}
+
+	again, err := s.AddFact(ctx, store.Fact{Content: want.Content})
+	if err != nil {
+		t.Fatalf("AddFact(again) error: %v", err)
+	}
+	if !reflect.DeepEqual(*again, want) {
+		t.Errorf("AddFact(again) = %+v, want the first one", *again)
+	}
+	other, err := s.AddFact(ctx, store.Fact{Content: "Deploys go through ArgoCD"})
+	if err != nil {
+		t.Fatalf("AddFact(other) error: %v", err)
+	}
+	if _, err := s.AddFact(ctx, store.Fact{Content: "  "}); err == nil {
+		t.Error("AddFact(blank) should fail")
+	}
+
+	if err := s.DeleteFact(ctx, added.ID); err != nil {
+		t.Fatalf("DeleteFact() error: %v", err)
+	}
+	facts, err := s.ListFacts(ctx)
+	if err != nil {
+		t.Fatalf("ListFacts() error: %v", err)
+	}
+	if len(facts) != 1 || facts[0].ID != other.ID {
+		t.Errorf("ListFacts() = %+v, want only %q", facts, other.Content)
+	}
+	if err := s.DeleteFact(ctx, added.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteFact(deleted) error = %v, want ErrNotFound", err)
+	}
+}
@@ -2,9 +2,15 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
 )
 
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("not found")
+
 // Store is the interface for SQL storage (SQLite)
 type Store interface {
 	// Sources
@@ -18,6 +24,33 @@ type Store interface {
 	CreateSession(ctx context.Context, session Session) error
 	GetSession(ctx context.Context, id string) (*Session, error)
 	UpdateSession(ctx context.Context, session Session) error
+	ListSessions(ctx context.Context, limit int) ([]Session, error)
+	PruneSessions(ctx context.Context, origin string, updatedBefore time.Time, keep int) (int, error)
+
+	// Costs
+	AddDailyCost(ctx context.Context, cost DailyCost) error
+	ListDailyCosts(ctx context.Context, since string) ([]DailyCost, error)
+
+	// Refresh runs
+	CreateRefreshRun(ctx context.Context, run RefreshRun) error
+	UpdateRefreshRun(ctx context.Context, run RefreshRun) error
+	GetRefreshRun(ctx context.Context, id string) (*RefreshRun, error)
+	ListRefreshRuns(ctx context.Context, limit int) ([]RefreshRun, error)
+
+	// Graph changes
+	AddGraphChanges(ctx context.Context, changes []GraphChange) error
+	ListGraphChanges(ctx context.Context, runID string) ([]GraphChange, error)
+
+	// Clarifications
+	AddClarification(ctx context.Context, c Clarification) (*Clarification, error)
+	GetClarification(ctx context.Context, id int64) (*Clarification, error)
+	ListClarifications(ctx context.Context, status string) ([]Clarification, error)
+	UpdateClarification(ctx context.Context, c Clarification) error
+
+	// Facts
+	AddFact(ctx context.Context, fact Fact) (*Fact, error)
+	ListFacts(ctx context.Context) ([]Fact, error)
+	DeleteFact(ctx context.Context, id int64) error
 
 	// Cache
 	GetJoeFileCache(ctx context.Context, repoID, hash string) (*JoeFileCache, error)
@@ -47,6 +80,8 @@ type Source struct {
 // Session represents a conversation session
 type Session struct {
 	ID         string
+	Title      string // A few words naming the conversation, shown by /sessions
+	Origin     string // Where the session ran: "" for joe, "api" for joecored's chat API
 	StartedAt  time.Time
 	EndedAt    *time.Time
 	Summary    string
@@ -56,6 +91,111 @@ type Session struct {
 	Components []string
 	Tags       []string
 	Embedding  []float32
+
+	// Conversation history and token totals, used to resume a session
+	Messages     []llm.Message
+	InputTokens  int
+	OutputTokens int
+	TotalTokens  int
+	CostUSD      float64   // Estimated cost of the session's LLM calls
+	UpdatedAt    time.Time // Set by the store on every create/update
+}
+
+// DailyCost is one day's LLM usage of a model. AddDailyCost adds to the
+// stored totals, so joe and joecored can both record into the same day.
+type DailyCost struct {
+	Day          string // Local date, "2006-01-02"
+	Model        string
+	Calls        int
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+}
+
+// Refresh run statuses
+const (
+	RefreshRunning   = "running"
+	RefreshCompleted = "completed" // Every source refreshed, possibly with errors
+	RefreshCancelled = "cancelled" // Stopped early, e.g. by shutdown
+)
+
+// RefreshRun records one pass of the Core Agent's background refresh over
+// the registered sources
+type RefreshRun struct {
+	ID           string
+	Trigger      string // "scheduled" or "manual"
+	Status       string
+	StartedAt    time.Time
+	FinishedAt   *time.Time
+	Sources      int               // Sources with a collector, to be refreshed
+	Refreshed    int               // Sources refreshed so far, failed ones included
+	NodesUpdated int               // Nodes added or updated
+	EdgesUpdated int               // Edges added or updated
+	NodesRemoved int               // Nodes no longer reported by their source
+	EdgesRemoved int               // Edges no longer reported by their source
+	NodesStale   int               // Nodes marked stale, kept until they expire
+	Errors       map[string]string // Source ID to the error refreshing it
+}
+
+// Graph change kinds and actions
+const (
+	ChangeNode = "node"
+	ChangeEdge = "edge"
+
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+	ChangeStale    = "stale" // A node its source stopped reporting, kept until it expires
+)
+
+// GraphChange records a node or edge a refresh added, removed, or
+// modified
+type GraphChange struct {
+	ID        int64 // Assigned by the store
+	RunID     string
+	SourceID  string
+	Kind      string         // ChangeNode or ChangeEdge
+	Action    string         // ChangeAdded, ChangeRemoved, ChangeModified, or ChangeStale
+	Target    string         // Node ID, or "from -[relation]-> to"
+	Before    map[string]any // The node or edge before the change, nil when added
+	After     map[string]any // The node or edge after the change, nil when removed
+	Priority  string         // Notification priority, e.g. "high"
+	Reason    string         // Why the change was rated above low, if it was
+	CreatedAt time.Time      // Defaults to now
+}
+
+// Clarification types and statuses
+const (
+	ClarifyEdge = "edge_confirm" // Whether an inferred edge holds
+
+	ClarificationPending   = "pending"
+	ClarificationAnswered  = "answered"
+	ClarificationDismissed = "dismissed"
+)
+
+// Clarification is a question joe has for the user about the graph, e.g.
+// whether an edge it inferred holds. There is at most one per Type and
+// Subject, so a question is asked once however often it comes up.
+type Clarification struct {
+	ID         int64  // Assigned by the store
+	Type       string // e.g. ClarifyEdge
+	Subject    string // What the question is about, e.g. "from -[relation]-> to"
+	Question   string
+	Options    []string       // Answers to pick from; any answer is accepted if empty
+	Context    map[string]any // What the question is about, e.g. the edge
+	Status     string         // ClarificationPending (default), ClarificationAnswered, or ClarificationDismissed
+	Answer     string
+	CreatedAt  time.Time  // Defaults to now
+	AnsweredAt *time.Time // When answered or dismissed
+}
+
+// Fact is something the user told joe to remember across sessions, e.g.
+// "the prod database is db-prod-3 in us-east-1". Each is stored once.
+type Fact struct {
+	ID        int64 // Assigned by the store
+	Content   string
+	Embedding []float32 // For recall by similarity
+	CreatedAt time.Time // Defaults to now
 }
 
 // JoeFileCache stores cached interpretations of .joe/ files
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotApproved is returned when a tool call is denied by policy or by the user
+var ErrNotApproved = errors.New("tool call not approved")
+
+// ApprovalPolicy controls whether a tool may run without confirmation
+type ApprovalPolicy string
+
+const (
+	// PolicyAllow runs the tool without asking
+	PolicyAllow ApprovalPolicy = "allow"
+	// PolicyAsk asks the user before every call
+	PolicyAsk ApprovalPolicy = "ask"
+	// PolicyDeny never runs the tool
+	PolicyDeny ApprovalPolicy = "deny"
+)
+
+// ParseApprovalPolicy validates a policy name from configuration
+func ParseApprovalPolicy(s string) (ApprovalPolicy, error) {
+	switch p := ApprovalPolicy(s); p {
+	case PolicyAllow, PolicyAsk, PolicyDeny:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid approval policy %q (use allow, ask, or deny)", s)
+}
+
+// ParseApprovalPolicies converts configured tool policies (see
+// config.ToolsConfig) into executor policies
+func ParseApprovalPolicies(configured map[string]string) (map[string]ApprovalPolicy, error) {
+	policies := make(map[string]ApprovalPolicy, len(configured))
+	for name, s := range configured {
+		p, err := ParseApprovalPolicy(s)
+		if err != nil {
+			return nil, fmt.Errorf("tools.approval.%s: %w", name, err)
+		}
+		policies[name] = p
+	}
+	return policies, nil
+}
+
+// ApproveFunc asks whether a tool call may run. It returns false to deny the
+// call; an error aborts the call as well.
+type ApproveFunc func(ctx context.Context, name string, args map[string]any) (bool, error)
+
+// ExecutorOption configures optional Executor behavior
+type ExecutorOption func(*Executor)
+
+// WithApproval gates tool calls on per-tool policies. Tools without a policy
+// are allowed. Calls with PolicyAsk are passed to approve; autoApprove
+// allows them without asking but still enforces PolicyDeny.
+func WithApproval(policies map[string]ApprovalPolicy, approve ApproveFunc, autoApprove bool) ExecutorOption {
+	return func(e *Executor) {
+		e.policies = policies
+		e.approve = approve
+		e.autoApprove = autoApprove
+	}
+}
+
+// checkApproval returns nil if the call may run
+func (e *Executor) checkApproval(ctx context.Context, name string, args map[string]any) error {
+	switch e.policies[name] {
+	case PolicyDeny:
+		return fmt.Errorf("%w: %s is disabled by policy", ErrNotApproved, name)
+	case PolicyAsk:
+		if e.autoApprove {
+			return nil
+		}
+		if e.approve == nil {
+			return fmt.Errorf("%w: %s requires approval but no one can be asked", ErrNotApproved, name)
+		}
+		ok, err := e.approve(ctx, name, args)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrNotApproved, err)
+		}
+		if !ok {
+			return fmt.Errorf("%w: the user declined to run %s", ErrNotApproved, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExecutor_Approval(t *testing.T) {
+	policies := map[string]ApprovalPolicy{
+		"write_file": PolicyAsk,
+		"delete_all": PolicyDeny,
+		"read_file":  PolicyAllow,
+	}
+
+	tests := []struct {
+		name        string
+		tool        string
+		answer      bool
+		autoApprove bool
+		wantAsked   bool
+		wantErr     bool
+	}{
+		{"unlisted tool runs", "echo", false, false, false, false},
+		{"allowed tool runs", "read_file", false, false, false, false},
+		{"ask and approved", "write_file", true, false, true, false},
+		{"ask and declined", "write_file", false, false, true, true},
+		{"auto-approve skips asking", "write_file", false, true, false, false},
+		{"deny wins over auto-approve", "delete_all", true, true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			ran := false
+			registry.Register(&mockTool{
+				name: tt.tool,
+				executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+					ran = true
+					return "ok", nil
+				},
+			})
+
+			asked := false
+			approve := func(ctx context.Context, name string, args map[string]any) (bool, error) {
+				asked = true
+				return tt.answer, nil
+			}
+			executor := NewExecutor(registry, WithApproval(policies, approve, tt.autoApprove))
+
+			_, err := executor.Execute(context.Background(), tt.tool, map[string]any{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrNotApproved) {
+				t.Errorf("Execute() error = %v, want ErrNotApproved", err)
+			}
+			if asked != tt.wantAsked {
+				t.Errorf("asked = %v, want %v", asked, tt.wantAsked)
+			}
+			if ran == tt.wantErr {
+				t.Errorf("tool ran = %v, want %v", ran, !tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecutor_Approval_QualifiedName(t *testing.T) {
+	registry := NewRegistry()
+	ran := false
+	registry.RegisterIn("mcpsrv", &mockTool{
+		name: "echo",
+		executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+			ran = true
+			return "ok", nil
+		},
+	})
+	executor := NewExecutor(registry, WithApproval(map[string]ApprovalPolicy{"mcpsrv_echo": PolicyDeny}, nil, false))
+
+	for _, name := range []string{"mcpsrv_echo", "mcpsrv.echo"} {
+		if _, err := executor.Execute(context.Background(), name, map[string]any{}); !errors.Is(err, ErrNotApproved) {
+			t.Errorf("Execute(%s) error = %v, want ErrNotApproved", name, err)
+		}
+	}
+	if ran {
+		t.Error("denied tool ran")
+	}
+}
+
+func TestParseApprovalPolicy(t *testing.T) {
+	if p, err := ParseApprovalPolicy("ask"); err != nil || p != PolicyAsk {
+		t.Errorf("ParseApprovalPolicy(ask) = %q, %v", p, err)
+	}
+	if _, err := ParseApprovalPolicy("sometimes"); err == nil {
+		t.Error("ParseApprovalPolicy(sometimes) should fail")
+	}
+}
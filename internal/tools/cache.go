@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// WithResultCache reuses successful results of the tools in ttls for
+// identical arguments until their TTL expires. A successful call to any tool
+// in invalidatedBy (typically the ones that write files or run commands)
+// clears the whole cache, since it may have changed what the cached tools
+// would return.
+func WithResultCache(ttls map[string]time.Duration, invalidatedBy []string) ExecutorOption {
+	return func(e *Executor) {
+		c := &resultCache{
+			ttls:         ttls,
+			invalidators: make(map[string]bool, len(invalidatedBy)),
+			entries:      make(map[string]cacheEntry),
+			now:          time.Now,
+		}
+		for _, name := range invalidatedBy {
+			c.invalidators[name] = true
+		}
+		e.cache = c
+	}
+}
+
+type cacheEntry struct {
+	result  any
+	expires time.Time
+}
+
+// resultCache holds tool results keyed by tool name and argument hash
+type resultCache struct {
+	ttls         map[string]time.Duration
+	invalidators map[string]bool
+	now          func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// key returns the cache key for a call, or "" if the call isn't cacheable.
+// Like get and store, it is safe to call on a nil cache.
+func (c *resultCache) key(name string, args map[string]any) string {
+	if c == nil || c.ttls[name] <= 0 {
+		return ""
+	}
+	// encoding/json sorts map keys, so equal arguments hash the same
+	data, err := json.Marshal(args)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return name + ":" + hex.EncodeToString(sum[:])
+}
+
+// get returns an unexpired result for key
+func (c *resultCache) get(key string) (any, bool) {
+	if key == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// store records the successful result of a call to name. Calls to an
+// invalidating tool clear the cache first.
+func (c *resultCache) store(key, name string, result any) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.invalidators[name] {
+		clear(c.entries)
+	}
+	if key != "" {
+		c.entries[key] = cacheEntry{result: result, expires: c.now().Add(c.ttls[name])}
+	}
+}
+
+// ParseCacheTTLs converts configured cache TTLs in seconds (see
+// config.ToolsConfig) into executor TTLs
+func ParseCacheTTLs(seconds map[string]int) map[string]time.Duration {
+	ttls := make(map[string]time.Duration, len(seconds))
+	for name, s := range seconds {
+		ttls[name] = time.Duration(s) * time.Second
+	}
+	return ttls
+}
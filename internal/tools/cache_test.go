@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExecutor_ResultCache(t *testing.T) {
+	calls := map[string]int{}
+	fail := false
+	registry := NewRegistry()
+	for _, name := range []string{"read_file", "write_file", "echo"} {
+		registry.Register(&mockTool{
+			name: name,
+			executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+				calls[name]++
+				if fail {
+					return nil, errors.New("boom")
+				}
+				return calls[name], nil
+			},
+		})
+	}
+
+	executor := NewExecutor(registry, WithResultCache(
+		map[string]time.Duration{"read_file": time.Minute},
+		[]string{"write_file"},
+	))
+	now := time.Now()
+	executor.cache.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	read := func(path string) any {
+		t.Helper()
+		result, err := executor.Execute(ctx, "read_file", map[string]any{"path": path})
+		if err != nil {
+			t.Fatalf("Execute() error: %v", err)
+		}
+		return result
+	}
+
+	if got := read("a"); got != 1 {
+		t.Fatalf("first read = %v, want 1", got)
+	}
+	if got := read("a"); got != 1 {
+		t.Errorf("repeated read = %v, want cached 1", got)
+	}
+	if got := read("b"); got != 2 {
+		t.Errorf("read with other args = %v, want 2", got)
+	}
+
+	// Tools without a TTL always run
+	executor.Execute(ctx, "echo", nil)
+	executor.Execute(ctx, "echo", nil)
+	if calls["echo"] != 2 {
+		t.Errorf("echo ran %d times, want 2", calls["echo"])
+	}
+
+	// TTL expiry
+	now = now.Add(2 * time.Minute)
+	if got := read("a"); got != 3 {
+		t.Errorf("read after TTL = %v, want 3", got)
+	}
+
+	// A write clears the cache
+	executor.Execute(ctx, "write_file", nil)
+	if got := read("a"); got != 4 {
+		t.Errorf("read after write = %v, want 4", got)
+	}
+
+	// Errors are not cached
+	now = now.Add(2 * time.Minute)
+	fail = true
+	if _, err := executor.Execute(ctx, "read_file", map[string]any{"path": "a"}); err == nil {
+		t.Fatal("Execute() succeeded, want error")
+	}
+	fail = false
+	if got := read("a"); got != 6 {
+		t.Errorf("read after error = %v, want 6", got)
+	}
+}
@@ -1,18 +1,205 @@
 package tools
 
 import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jaimegago/joe/internal/tools/graphtools"
+	"github.com/jaimegago/joe/internal/tools/local/archive"
 	"github.com/jaimegago/joe/internal/tools/local/askuser"
+	"github.com/jaimegago/joe/internal/tools/local/awstools"
+	"github.com/jaimegago/joe/internal/tools/local/dataquery"
+	"github.com/jaimegago/joe/internal/tools/local/docker"
 	"github.com/jaimegago/joe/internal/tools/local/echo"
+	"github.com/jaimegago/joe/internal/tools/local/editfile"
+	"github.com/jaimegago/joe/internal/tools/local/fetchurl"
+	"github.com/jaimegago/joe/internal/tools/local/fileinfo"
+	"github.com/jaimegago/joe/internal/tools/local/gitblame"
 	"github.com/jaimegago/joe/internal/tools/local/gitdiff"
+	"github.com/jaimegago/joe/internal/tools/local/gitlog"
 	"github.com/jaimegago/joe/internal/tools/local/gitstatus"
 	"github.com/jaimegago/joe/internal/tools/local/readfile"
+	"github.com/jaimegago/joe/internal/tools/local/remotegit"
 	"github.com/jaimegago/joe/internal/tools/local/runcmd"
+	"github.com/jaimegago/joe/internal/tools/local/searchfiles"
+	"github.com/jaimegago/joe/internal/tools/local/sqlquery"
+	"github.com/jaimegago/joe/internal/tools/local/sshrun"
+	"github.com/jaimegago/joe/internal/tools/local/systemd"
+	"github.com/jaimegago/joe/internal/tools/local/tablequery"
+	"github.com/jaimegago/joe/internal/tools/local/tailfile"
 	"github.com/jaimegago/joe/internal/tools/local/writefile"
+	"github.com/jaimegago/joe/internal/tools/memorytools"
 )
 
+// DefaultAllowedCommands are the binaries run_command may execute unless
+// configured otherwise: file inspection, system diagnostics, and the
+// cluster and repository CLIs
+var DefaultAllowedCommands = []string{
+	"ls", "cat", "head", "tail", "grep", "find", "wc",
+	"df", "du", "ps", "uptime", "free",
+	"kubectl", "helm", "argocd", "git",
+}
+
+// DefaultOption configures the tool set built by NewDefaultRegistry
+type DefaultOption func(*defaultOptions)
+
+type defaultOptions struct {
+	disabled        []string
+	allowedCommands []string
+	sandbox         runcmd.Sandbox
+	commandDirs     []string
+	commandEnv      []string
+	writablePaths   []string
+	ssh             *sshOptions
+	databases       map[string]sqlquery.Database
+	fetch           []fetchurl.Option
+	maxRows         int
+	aws             *awsOptions
+	graph           graphtools.Client
+	facts           memorytools.Facts
+	sourceTypes     []string
+	sourceTypesSet  bool
+}
+
+type sshOptions struct {
+	hosts          map[string]sshrun.Host
+	allowed        []string
+	connectTimeout time.Duration
+}
+
+type awsOptions struct {
+	region, profile string
+}
+
+// WithDisabledTools leaves out tools whose name matches one of patterns
+// (exact names or globs such as "docker_*")
+func WithDisabledTools(patterns []string) DefaultOption {
+	return func(o *defaultOptions) {
+		o.disabled = patterns
+	}
+}
+
+// WithAllowedCommands replaces DefaultAllowedCommands for run_command. An
+// empty list keeps the defaults.
+func WithAllowedCommands(commands []string) DefaultOption {
+	return func(o *defaultOptions) {
+		if len(commands) > 0 {
+			o.allowedCommands = commands
+		}
+	}
+}
+
+// WithCommandSandbox runs run_command's commands in s (see runcmd.NewSandbox)
+// instead of directly on the host
+func WithCommandSandbox(s runcmd.Sandbox) DefaultOption {
+	return func(o *defaultOptions) {
+		o.sandbox = s
+	}
+}
+
+// WithCommandDirs restricts the directories run_command may run in (its
+// cwd parameter) to dirs and those under them. An empty list allows any.
+func WithCommandDirs(dirs []string) DefaultOption {
+	return func(o *defaultOptions) {
+		o.commandDirs = dirs
+	}
+}
+
+// WithCommandEnv lets run_command set the named environment variables (its
+// env parameter). An empty list allows none.
+func WithCommandEnv(names []string) DefaultOption {
+	return func(o *defaultOptions) {
+		o.commandEnv = names
+	}
+}
+
+// WithWritablePaths restricts write_file and edit_file to files under the
+// given directories. An empty list allows any path.
+func WithWritablePaths(prefixes []string) DefaultOption {
+	return func(o *defaultOptions) {
+		o.writablePaths = prefixes
+	}
+}
+
+// WithSSH adds ssh_run, which runs allowed binaries (an empty list uses
+// sshrun.DefaultAllowedCommands) on hosts, keyed by alias. No hosts leaves
+// it out.
+func WithSSH(hosts map[string]sshrun.Host, allowed []string, connectTimeout time.Duration) DefaultOption {
+	return func(o *defaultOptions) {
+		o.ssh = &sshOptions{hosts: hosts, allowed: allowed, connectTimeout: connectTimeout}
+	}
+}
+
+// WithSQL adds sql_query, which runs read-only queries against databases,
+// keyed by alias, returning at most maxRows rows. No databases leaves it
+// out.
+func WithSQL(databases map[string]sqlquery.Database, maxRows int) DefaultOption {
+	return func(o *defaultOptions) {
+		o.databases = databases
+		o.maxRows = maxRows
+	}
+}
+
+// WithFetchDomains limits fetch_url to allowed domains (any if empty),
+// never denied ones, and public addresses unless allowPrivate is set
+func WithFetchDomains(allowed, denied []string, allowPrivate bool) DefaultOption {
+	return func(o *defaultOptions) {
+		o.fetch = []fetchurl.Option{
+			fetchurl.WithAllowedDomains(allowed),
+			fetchurl.WithDeniedDomains(denied),
+			fetchurl.WithPrivateNetworks(allowPrivate),
+		}
+	}
+}
+
+// WithAWS adds the read-only AWS inventory tools (see RegisterAWSTools)
+func WithAWS(region, profile string) DefaultOption {
+	return func(o *defaultOptions) {
+		o.aws = &awsOptions{region: region, profile: profile}
+	}
+}
+
+// WithGraph adds the infrastructure graph tools, which read joecored's
+// graph through c (see RegisterGraphTools)
+func WithGraph(c graphtools.Client) DefaultOption {
+	return func(o *defaultOptions) {
+		o.graph = c
+	}
+}
+
+// WithFacts adds remember and recall, which keep facts in f across
+// sessions (see RegisterMemoryTools)
+func WithFacts(f memorytools.Facts) DefaultOption {
+	return func(o *defaultOptions) {
+		o.facts = f
+	}
+}
+
+// SourceNamespaces maps the namespaces of tools that read one kind of
+// source to that source type; WithSourceTypes leaves them out unless such
+// a source is registered
+var SourceNamespaces = map[string]string{
+	"aws": "aws",
+}
+
+// WithSourceTypes leaves out the tools of SourceNamespaces whose source
+// type isn't among types, the types of the sources registered with
+// joecored. Without it every tool is kept.
+func WithSourceTypes(types []string) DefaultOption {
+	return func(o *defaultOptions) {
+		o.sourceTypes = types
+		o.sourceTypesSet = true
+	}
+}
+
 // NewDefaultRegistry creates a registry with all default tools registered
 // These tools are useful for the agentic loop and testing
-func NewDefaultRegistry() *Registry {
+func NewDefaultRegistry(opts ...DefaultOption) *Registry {
+	o := defaultOptions{allowedCommands: DefaultAllowedCommands}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	registry := NewRegistry()
 
 	// Register basic tools
@@ -21,17 +208,105 @@ func NewDefaultRegistry() *Registry {
 
 	// Register file tools
 	registry.Register(readfile.New())
-	registry.Register(writefile.New())
+	registry.Register(tailfile.New())
+	registry.Register(fileinfo.New())
+	registry.Register(archive.NewListTool())
+	registry.Register(archive.NewExtractTool())
+	registry.Register(dataquery.New())
+	registry.Register(tablequery.New())
+	registry.Register(writefile.New(o.writablePaths...))
+	registry.Register(editfile.New(o.writablePaths...))
+	registry.Register(searchfiles.New())
+
+	// Register web tools
+	registry.Register(fetchurl.New(o.fetch...))
 
 	// Register git tools
-	registry.Register(gitstatus.New())
-	registry.Register(gitdiff.New())
+	registry.RegisterIn("local_git", gitstatus.New())
+	registry.RegisterIn("local_git", gitdiff.New())
+	registry.RegisterIn("local_git", gitlog.New())
+	registry.RegisterIn("local_git", gitblame.New())
+
+	// Register GitHub/GitLab tools (tokens come from the environment)
+	hosts := remotegit.NewHosts()
+	registry.RegisterIn("remote_git", remotegit.NewPullRequestsTool(hosts))
+	registry.RegisterIn("remote_git", remotegit.NewDiffTool(hosts))
+	registry.RegisterIn("remote_git", remotegit.NewCIRunsTool(hosts))
+	registry.RegisterIn("remote_git", remotegit.NewIssueTool(hosts))
+
+	// Register docker inspection tools. They report an error when called if
+	// the daemon isn't running; only a malformed DOCKER_HOST skips them.
+	if dockerClient, err := docker.NewClient(); err != nil {
+		slog.Warn("docker tools disabled", "error", err)
+	} else {
+		registry.RegisterIn("docker", docker.NewContainersTool(dockerClient))
+		registry.RegisterIn("docker", docker.NewInspectTool(dockerClient))
+		registry.RegisterIn("docker", docker.NewLogsTool(dockerClient))
+		registry.RegisterIn("docker", docker.NewImageTool(dockerClient))
+	}
+
+	// Register systemd inspection tools (read-only; they report an error
+	// when called on hosts without systemd)
+	registry.RegisterIn("systemd", systemd.NewUnitsTool())
+	registry.RegisterIn("systemd", systemd.NewStatusTool())
+	registry.RegisterIn("systemd", systemd.NewJournalTool())
 
 	// Register command runner (with safe defaults)
-	registry.Register(runcmd.New([]string{
-		"ls", "cat", "head", "tail", "grep", "find", "wc",
-		"kubectl", "helm", "argocd",
-	}))
+	registry.Register(runcmd.New(o.allowedCommands,
+		runcmd.WithSandbox(o.sandbox),
+		runcmd.WithDirs(o.commandDirs),
+		runcmd.WithEnv(o.commandEnv)))
+	if o.ssh != nil && len(o.ssh.hosts) > 0 {
+		registry.Register(sshrun.New(o.ssh.hosts, o.ssh.allowed,
+			sshrun.WithConnectTimeout(o.ssh.connectTimeout)))
+	}
+	if len(o.databases) > 0 {
+		registry.Register(sqlquery.New(o.databases, o.maxRows))
+	}
+
+	if o.aws != nil {
+		RegisterAWSTools(registry, o.aws.region, o.aws.profile)
+	}
+	if o.graph != nil {
+		RegisterGraphTools(registry, o.graph)
+	}
+	if o.facts != nil {
+		RegisterMemoryTools(registry, o.facts)
+	}
 
+	if o.sourceTypesSet {
+		for namespace, sourceType := range SourceNamespaces {
+			if !slices.Contains(o.sourceTypes, sourceType) {
+				registry.UnregisterNamespace(namespace)
+			}
+		}
+	}
+	registry.UnregisterMatching(o.disabled)
 	return registry
 }
+
+// RegisterAWSTools adds the read-only AWS inventory tools. Empty region and
+// profile use the AWS SDK's default resolution; credentials are only loaded
+// when a tool is first called.
+func RegisterAWSTools(registry *Registry, region, profile string) {
+	provider := awstools.NewProvider(region, profile)
+	registry.RegisterIn("aws", awstools.NewInstancesTool(provider))
+	registry.RegisterIn("aws", awstools.NewSecurityGroupsTool(provider))
+	registry.RegisterIn("aws", awstools.NewBucketsTool(provider))
+	registry.RegisterIn("aws", awstools.NewRolesTool(provider))
+}
+
+// RegisterGraphTools adds graph_query, graph_related, and graph_summary,
+// which answer from the graph joecored keeps through c
+func RegisterGraphTools(registry *Registry, c graphtools.Client) {
+	registry.RegisterIn("graph", graphtools.NewQueryTool(c))
+	registry.RegisterIn("graph", graphtools.NewRelatedTool(c))
+	registry.RegisterIn("graph", graphtools.NewSummaryTool(c))
+}
+
+// RegisterMemoryTools adds remember and recall, which keep facts the user
+// tells joe in f
+func RegisterMemoryTools(registry *Registry, f memorytools.Facts) {
+	registry.Register(memorytools.NewRememberTool(f))
+	registry.Register(memorytools.NewRecallTool(f))
+}
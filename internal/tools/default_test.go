@@ -1,7 +1,14 @@
 package tools
 
 import (
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/jaimegago/joe/internal/client"
+	"github.com/jaimegago/joe/internal/tools/local/sqlquery"
+	"github.com/jaimegago/joe/internal/tools/local/sshrun"
 )
 
 func TestNewDefaultRegistry(t *testing.T) {
@@ -16,10 +23,35 @@ func TestNewDefaultRegistry(t *testing.T) {
 		"echo":             true,
 		"ask_user":         true,
 		"read_file":        true,
+		"tail_file":        true,
+		"file_info":        true,
+		"archive_list":     true,
+		"archive_extract":  true,
+		"query_data":       true,
+		"query_table":      true,
+		"fetch_url":        true,
 		"write_file":       true,
+		"edit_file":        true,
+		"search_files":     true,
 		"local_git_status": true,
 		"local_git_diff":   true,
+		"local_git_log":    true,
+		"local_git_blame":  true,
 		"run_command":      true,
+
+		"remote_git_list_prs": true,
+		"remote_git_pr_diff":  true,
+		"remote_git_ci_runs":  true,
+		"remote_git_issue":    true,
+
+		"docker_list_containers":   true,
+		"docker_inspect_container": true,
+		"docker_container_logs":    true,
+		"docker_image_info":        true,
+
+		"systemd_list_units":  true,
+		"systemd_unit_status": true,
+		"systemd_journal":     true,
 	}
 
 	// Test that all expected tools are registered
@@ -52,3 +84,107 @@ func TestNewDefaultRegistry(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterAWSTools(t *testing.T) {
+	registry := NewRegistry()
+	RegisterAWSTools(registry, "us-east-1", "")
+
+	for _, name := range []string{"aws_ec2_instances", "aws_security_groups", "aws_s3_buckets", "aws_iam_roles"} {
+		if _, err := registry.Get(name); err != nil {
+			t.Errorf("RegisterAWSTools() missing %s: %v", name, err)
+		}
+	}
+}
+
+func TestRegisterGraphTools(t *testing.T) {
+	registry := NewRegistry()
+	RegisterGraphTools(registry, client.New("http://localhost:7777"))
+
+	for _, name := range []string{"graph_query", "graph_related", "graph_summary"} {
+		if _, err := registry.Get(name); err != nil {
+			t.Errorf("RegisterGraphTools() missing %s: %v", name, err)
+		}
+	}
+}
+
+func TestNewDefaultRegistry_Namespaces(t *testing.T) {
+	registry := NewDefaultRegistry(WithAWS("us-east-1", ""))
+
+	want := []string{"aws", "docker", "local_git", "remote_git", "systemd"}
+	if got := registry.Namespaces(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Namespaces() = %v, want %v", got, want)
+	}
+	if got := registry.QualifiedName("local_git_status"); got != "local_git.status" {
+		t.Errorf("QualifiedName(local_git_status) = %q, want local_git.status", got)
+	}
+	if got := registry.Namespace("read_file"); got != "" {
+		t.Errorf("Namespace(read_file) = %q, want none", got)
+	}
+}
+
+func TestNewDefaultRegistry_SourceTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []DefaultOption
+		wantAWS bool
+	}{
+		{name: "no source types given", opts: nil, wantAWS: true},
+		{name: "aws source registered", opts: []DefaultOption{WithSourceTypes([]string{"kubernetes", "aws"})}, wantAWS: true},
+		{name: "no aws source", opts: []DefaultOption{WithSourceTypes([]string{"kubernetes"})}, wantAWS: false},
+		{name: "no sources", opts: []DefaultOption{WithSourceTypes(nil)}, wantAWS: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewDefaultRegistry(append(tt.opts, WithAWS("us-east-1", ""))...)
+			_, err := registry.Get("aws.ec2_instances")
+			if got := err == nil; got != tt.wantAWS {
+				t.Errorf("aws tools registered = %v, want %v", got, tt.wantAWS)
+			}
+			if _, err := registry.Get("docker_list_containers"); err != nil {
+				t.Errorf("docker tools should not depend on sources: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewDefaultRegistry_Options(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewDefaultRegistry(
+		WithAWS("us-east-1", ""),
+		WithDisabledTools([]string{"echo", "docker_*", "aws_iam_roles"}),
+		WithAllowedCommands([]string{"df"}),
+		WithWritablePaths([]string{dir}),
+		WithSSH(map[string]sshrun.Host{"db-1": {Address: "db-1.internal"}}, nil, 0),
+		WithSQL(map[string]sqlquery.Database{"jobs": {Driver: "sqlite", DSN: filepath.Join(dir, "jobs.db")}}, 0),
+	)
+
+	for _, name := range []string{"echo", "docker_list_containers", "docker_image_info", "aws_iam_roles"} {
+		if _, err := registry.Get(name); err == nil {
+			t.Errorf("disabled tool %s is registered", name)
+		}
+	}
+	if _, err := registry.Get("aws_s3_buckets"); err != nil {
+		t.Errorf("WithAWS() missing aws_s3_buckets: %v", err)
+	}
+
+	if _, err := registry.Get("ssh_run"); err != nil {
+		t.Errorf("WithSSH() missing ssh_run: %v", err)
+	}
+	if _, err := registry.Get("sql_query"); err != nil {
+		t.Errorf("WithSQL() missing sql_query: %v", err)
+	}
+
+	runCommand, _ := registry.Get("run_command")
+	if _, err := runCommand.Execute(context.Background(), map[string]any{"command": "ls"}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("run_command ls error = %v, want not allowed", err)
+	}
+
+	writeFile, _ := registry.Get("write_file")
+	if _, err := writeFile.Execute(context.Background(), map[string]any{"path": filepath.Join(dir, "ok.txt"), "content": "x"}); err != nil {
+		t.Errorf("write_file inside allowed path: %v", err)
+	}
+	outside := filepath.Join(t.TempDir(), "no.txt")
+	if _, err := writeFile.Execute(context.Background(), map[string]any{"path": outside, "content": "x"}); err == nil {
+		t.Error("write_file outside allowed paths succeeded")
+	}
+}
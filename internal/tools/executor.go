@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ErrAllToolsFailed is returned when all tools in a batch fail
@@ -15,28 +20,62 @@ var ErrAllToolsFailed = errors.New("all tools in batch failed")
 // Executor executes tool calls from the LLM
 type Executor struct {
 	registry *Registry
+
+	// Approval gate (see WithApproval); no policies means every call runs
+	policies    map[string]ApprovalPolicy
+	approve     ApproveFunc
+	autoApprove bool
+
+	// Timeouts (see WithTimeouts); zero means no timeout
+	defaultTimeout time.Duration
+	timeouts       map[string]time.Duration
+
+	// Result cache (see WithResultCache); nil disables caching
+	cache *resultCache
+
+	// Secret masking of results (see WithRedactor); nil masks nothing
+	redactor Redactor
+
+	// Middleware around every call (see WithMiddleware), and the handler
+	// calls run through
+	middleware []Middleware
+	handler    ToolHandler
 }
 
-// NewExecutor creates a new tool executor
-func NewExecutor(registry *Registry) *Executor {
-	return &Executor{
+// NewExecutor creates a new tool executor. Options are applied in order.
+func NewExecutor(registry *Registry, opts ...ExecutorOption) *Executor {
+	e := &Executor{
 		registry: registry,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.handler = e.buildHandler()
+	return e
 }
 
-// Execute executes a single tool call
-func (e *Executor) Execute(ctx context.Context, name string, args map[string]any) (any, error) {
-	tool, err := e.registry.Get(name)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tool %s: %w", name, err)
-	}
+// Execute executes a single tool call. A namespaced tool may be named
+// either way (ns.tool or ns_tool); middleware always sees the registered
+// name, so policies, timeouts, and cache entries apply to both.
+func (e *Executor) Execute(ctx context.Context, name string, args map[string]any) (result any, err error) {
+	name = e.registry.resolve(name)
+	ctx, span := observability.Tracer("joe/tools").Start(ctx, "tool.execute",
+		trace.WithAttributes(attribute.String("tool.name", name)))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+		}
+		span.End()
+	}()
 
-	result, err := tool.Execute(ctx, args)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute tool %s: %w", name, err)
+	// Unknown tools fail before any middleware, so no one is asked to
+	// approve them
+	if _, err := e.registry.Get(name); err != nil {
+		return nil, fmt.Errorf("failed to get tool %s: %w", name, err)
 	}
 
-	return result, nil
+	return e.handler(ctx, name, args)
 }
 
 // ExecuteBatch executes multiple tool calls
@@ -44,24 +83,41 @@ func (e *Executor) Execute(ctx context.Context, name string, args map[string]any
 // Individual tool errors are stored in each ToolCallResult.Error field.
 // This allows partial success - the caller can inspect individual results.
 func (e *Executor) ExecuteBatch(ctx context.Context, calls []ToolCallRequest) ([]ToolCallResult, error) {
+	return e.ExecuteBatchWithProgress(ctx, calls, nil)
+}
+
+// BatchProgress is told as each call of a batch starts, with a nil result,
+// and again when it finishes
+type BatchProgress func(call ToolCallRequest, result *ToolCallResult)
+
+// ExecuteBatchWithProgress is ExecuteBatch, reporting each call to
+// progress, which may be nil
+func (e *Executor) ExecuteBatchWithProgress(ctx context.Context, calls []ToolCallRequest, progress BatchProgress) ([]ToolCallResult, error) {
 	if len(calls) == 0 {
 		return nil, nil
 	}
+	if progress == nil {
+		progress = func(ToolCallRequest, *ToolCallResult) {}
+	}
 
 	results := make([]ToolCallResult, len(calls))
 	errorCount := 0
 
 	for i, call := range calls {
+		progress(call, nil)
+		start := time.Now()
 		result, err := e.Execute(ctx, call.Name, call.Args)
 		results[i] = ToolCallResult{
-			ID:     call.ID,
-			Name:   call.Name,
-			Result: result,
-			Error:  err,
+			ID:       call.ID,
+			Name:     call.Name,
+			Result:   result,
+			Error:    err,
+			Duration: time.Since(start),
 		}
 		if err != nil {
 			errorCount++
 		}
+		progress(call, &results[i])
 	}
 
 	// Return error only if ALL tools failed
@@ -79,12 +135,17 @@ func (e *Executor) ResultsToMessages(results []ToolCallResult) []llm.Message {
 
 	for i, result := range results {
 		messages[i] = ResultToMessage(result)
+		if e.redactor != nil {
+			messages[i].Content = e.redactor.Redact(messages[i].Content)
+		}
 	}
 
 	return messages
 }
 
-// ResultToMessage converts a single tool call result to an LLM message
+// ResultToMessage converts a single tool call result to an LLM message.
+// The message carries the call ID, tool name, and error flag so adapters
+// send it as a tool_result / FunctionResponse rather than user text.
 func ResultToMessage(result ToolCallResult) llm.Message {
 	var content string
 	isError := result.Error != nil
@@ -96,15 +157,23 @@ func ResultToMessage(result ToolCallResult) llm.Message {
 		jsonBytes, err := json.Marshal(result.Result)
 		if err != nil {
 			content = fmt.Sprintf("Error marshaling result: %v", err)
+			isError = true
 		} else {
 			content = string(jsonBytes)
 		}
 	}
 
+	// Providers without call IDs (Gemini) match results by name; never let a
+	// result degrade into a plain user message
+	id := result.ID
+	if id == "" {
+		id = result.Name
+	}
+
 	return llm.Message{
 		Role:         "user",
 		Content:      content,
-		ToolResultID: result.ID,
+		ToolResultID: id,
 		ToolName:     result.Name,
 		IsError:      isError,
 	}
@@ -119,8 +188,9 @@ type ToolCallRequest struct {
 
 // ToolCallResult represents the result of executing a tool
 type ToolCallResult struct {
-	ID     string
-	Name   string
-	Result any
-	Error  error
+	ID       string
+	Name     string
+	Result   any
+	Error    error
+	Duration time.Duration // How long the call took, including approval
 }
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 )
 
@@ -271,6 +272,43 @@ func TestExecutor_ExecuteBatch(t *testing.T) {
 	}
 }
 
+func TestExecutor_ExecuteBatchWithProgress(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockTool{
+		name: "echo",
+		executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+			return args["message"], nil
+		},
+	})
+	executor := NewExecutor(registry)
+
+	var got []string
+	calls := []ToolCallRequest{
+		{ID: "call-1", Name: "echo", Args: map[string]any{"message": "a"}},
+		{ID: "call-2", Name: "missing"},
+	}
+	results, err := executor.ExecuteBatchWithProgress(context.Background(), calls, func(call ToolCallRequest, result *ToolCallResult) {
+		switch {
+		case result == nil:
+			got = append(got, "start "+call.ID)
+		case result.Error != nil:
+			got = append(got, "failed "+result.ID)
+		default:
+			got = append(got, "done "+result.ID)
+		}
+	})
+	if err != nil {
+		t.Fatalf("ExecuteBatchWithProgress() error: %v", err)
+	}
+	want := []string{"start call-1", "done call-1", "start call-2", "failed call-2"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("progress = %v, want %v", got, want)
+	}
+	if len(results) != 2 || results[0].Result != "a" {
+		t.Errorf("results = %+v, want both calls with the echo's result", results)
+	}
+}
+
 func TestExecutor_ContextCancellation(t *testing.T) {
 	registry := NewRegistry()
 	registry.Register(&mockTool{
@@ -369,6 +407,35 @@ func TestResultToMessage(t *testing.T) {
 	}
 }
 
+func TestResultToMessage_Attribution(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    ToolCallResult
+		wantID    string
+		wantError bool
+	}{
+		{"call ID", ToolCallResult{ID: "toolu_1", Name: "read_file", Result: "ok"}, "toolu_1", false},
+		{"falls back to name", ToolCallResult{Name: "read_file", Result: "ok"}, "read_file", false},
+		{"error", ToolCallResult{ID: "toolu_2", Name: "read_file", Error: errors.New("boom")}, "toolu_2", true},
+		{"unmarshalable result", ToolCallResult{ID: "toolu_3", Name: "read_file", Result: make(chan int)}, "toolu_3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := ResultToMessage(tt.result)
+			if msg.ToolResultID != tt.wantID {
+				t.Errorf("ToolResultID = %q, want %q", msg.ToolResultID, tt.wantID)
+			}
+			if msg.ToolName != tt.result.Name {
+				t.Errorf("ToolName = %q, want %q", msg.ToolName, tt.result.Name)
+			}
+			if msg.IsError != tt.wantError {
+				t.Errorf("IsError = %v, want %v", msg.IsError, tt.wantError)
+			}
+		})
+	}
+}
+
 func TestExecutor_ResultsToMessages(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -510,3 +577,21 @@ func deepEqual(a, b any) bool {
 		return a == b
 	}
 }
+
+// wordRedactor masks the word "secret"
+type wordRedactor struct{}
+
+func (wordRedactor) Redact(s string) string { return strings.ReplaceAll(s, "secret", "[REDACTED]") }
+
+func TestExecutor_ResultsToMessages_Redacted(t *testing.T) {
+	executor := NewExecutor(NewRegistry(), WithRedactor(wordRedactor{}))
+	messages := executor.ResultsToMessages([]ToolCallResult{
+		{ID: "call-1", Name: "read_file", Result: "password is secret"},
+		{ID: "call-2", Name: "run_command", Error: errors.New("secret rejected")},
+	})
+	for _, m := range messages {
+		if strings.Contains(m.Content, "secret") || !strings.Contains(m.Content, "[REDACTED]") {
+			t.Errorf("message %s not redacted: %q", m.ToolResultID, m.Content)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package graphtools lets the LLM read the infrastructure graph kept by
+// joecored, so answers about what runs where and what depends on what come
+// from discovered state rather than guesses
+package graphtools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/client"
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+const (
+	defaultQueryLimit = 50
+	maxQueryLimit     = 200
+	defaultDepth      = 1
+	maxDepth          = 3
+)
+
+// Client is the part of the joecored client the graph tools use
+type Client interface {
+	QueryGraph(ctx context.Context, q client.GraphQuery) (*client.GraphQueryResult, error)
+	RelatedNodes(ctx context.Context, nodeID string, depth int, types, relations []string) (*client.Subgraph, error)
+	GetGraphSummary(ctx context.Context) (*client.GraphSummary, error)
+}
+
+// QueryTool searches graph nodes
+type QueryTool struct {
+	client Client
+}
+
+// NewQueryTool creates the graph_query tool
+func NewQueryTool(c Client) *QueryTool {
+	return &QueryTool{client: c}
+}
+
+func (t *QueryTool) Name() string {
+	return "graph_query"
+}
+
+func (t *QueryTool) Description() string {
+	return "Search the infrastructure graph that joecored builds from registered sources (clusters, repos, monitoring). Matches words against node IDs, sources, and metadata, e.g. 'payment' finds deploy/payment-api and svc/payment; type:<t>, source:<id>, and key=value (e.g. labels.env=prod) filter exactly. Follow edges with hops written as separate words: -> (outgoing), <- (incoming), -- (either), restricted to relations and up to N edges with -[depends_on|uses*3]->; the query returns the nodes of its first part, e.g. 'type:service -[depends_on*3]-> postgres' finds services depending on postgres. Use it to find what exists before looking at it with other tools."
+}
+
+func (t *QueryTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"query": {
+				Type:        "string",
+				Description: "Words that must all appear in the node ID, source, or metadata, optionally chained with hops (empty for all nodes)",
+			},
+			"types": {
+				Type:        "string",
+				Description: "Comma-separated node types to keep, e.g. deployment,service",
+			},
+			"source": {
+				Type:        "string",
+				Description: "Only nodes from this source ID, e.g. k8s/prod-us",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Maximum nodes to return (default %d, max %d)", defaultQueryLimit, maxQueryLimit),
+			},
+			"offset": {
+				Type:        "integer",
+				Description: "Skip this many matches, to page through large results",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *QueryTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	q := client.GraphQuery{Limit: defaultQueryLimit}
+	q.Text, _ = args["query"].(string)
+	q.Source, _ = args["source"].(string)
+	q.Types = splitList(args["types"])
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		q.Limit = int(min(n, maxQueryLimit))
+	}
+	if n, ok := args["offset"].(float64); ok && n > 0 {
+		q.Offset = int(min(n, math.MaxInt32))
+	}
+
+	result, err := t.client.QueryGraph(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("graph query failed: %w", err)
+	}
+
+	out := map[string]any{
+		"nodes": nodesOutput(result.Nodes),
+		"count": len(result.Nodes),
+		"total": result.Total,
+	}
+	if result.NextOffset != nil {
+		out["next_offset"] = *result.NextOffset
+	}
+	return out, nil
+}
+
+// RelatedTool shows a node's neighborhood
+type RelatedTool struct {
+	client Client
+}
+
+// NewRelatedTool creates the graph_related tool
+func NewRelatedTool(c Client) *RelatedTool {
+	return &RelatedTool{client: c}
+}
+
+func (t *RelatedTool) Name() string {
+	return "graph_related"
+}
+
+func (t *RelatedTool) Description() string {
+	return "Show what a node in the infrastructure graph is connected to: the nodes within depth hops and the relationships between them (e.g. routes_to, depends_on, deployed_by). Use it to trace dependencies and blast radius. Edges with confidence 'inferred' were guessed and may be wrong; 'corroborated' ones were guessed but have held up over several refreshes."
+}
+
+func (t *RelatedTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"node_id": {
+				Type:        "string",
+				Description: "Node ID as returned by graph_query, e.g. deploy/payment-api",
+			},
+			"depth": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Hops to follow in either direction (default %d, max %d)", defaultDepth, maxDepth),
+			},
+			"types": {
+				Type:        "string",
+				Description: "Comma-separated node types to keep, e.g. database,service",
+			},
+			"relations": {
+				Type:        "string",
+				Description: "Comma-separated relations to keep, e.g. depends_on",
+			},
+		},
+		Required: []string{"node_id"},
+	}
+}
+
+func (t *RelatedTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	nodeID, ok := args["node_id"].(string)
+	if !ok || nodeID == "" {
+		return nil, fmt.Errorf("node_id parameter is required")
+	}
+	depth := defaultDepth
+	if n, ok := args["depth"].(float64); ok {
+		depth = int(max(0, min(n, maxDepth)))
+	}
+
+	sub, err := t.client.RelatedNodes(ctx, nodeID, depth, splitList(args["types"]), splitList(args["relations"]))
+	if err != nil {
+		return nil, fmt.Errorf("graph lookup of %s failed: %w", nodeID, err)
+	}
+
+	edges := make([]map[string]any, len(sub.Edges))
+	for i, e := range sub.Edges {
+		edge := map[string]any{
+			"from":       e.From,
+			"relation":   e.Relation,
+			"to":         e.To,
+			"confidence": confidenceLabel(e.Confidence),
+		}
+		if e.Context != "" {
+			edge["context"] = e.Context
+		}
+		edges[i] = edge
+	}
+
+	return map[string]any{
+		"node_id": nodeID,
+		"depth":   depth,
+		"nodes":   nodesOutput(sub.Nodes),
+		"edges":   edges,
+	}, nil
+}
+
+// SummaryTool gives an overview of the graph
+type SummaryTool struct {
+	client Client
+}
+
+// NewSummaryTool creates the graph_summary tool
+func NewSummaryTool(c Client) *SummaryTool {
+	return &SummaryTool{client: c}
+}
+
+func (t *SummaryTool) Name() string {
+	return "graph_summary"
+}
+
+func (t *SummaryTool) Description() string {
+	return "Summarize the infrastructure graph: how many nodes of each type and edges it holds, and which nodes were added or changed most recently. Use it to get oriented or to spot recent changes."
+}
+
+func (t *SummaryTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type:       "object",
+		Properties: map[string]llm.Property{},
+		Required:   []string{},
+	}
+}
+
+func (t *SummaryTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	summary, err := t.client.GetGraphSummary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("graph summary failed: %w", err)
+	}
+
+	recent := func(nodes []client.Node, at func(client.Node) string) []map[string]any {
+		out := make([]map[string]any, len(nodes))
+		for i, n := range nodes {
+			out[i] = map[string]any{"id": n.ID, "type": n.Type, "at": at(n)}
+		}
+		return out
+	}
+	return map[string]any{
+		"node_count":       summary.NodeCount,
+		"edge_count":       summary.EdgeCount,
+		"nodes_by_type":    summary.NodesByType,
+		"recently_added":   recent(summary.RecentlyAdded, func(n client.Node) string { return n.FirstSeen.Format("2006-01-02 15:04") }),
+		"recently_updated": recent(summary.RecentlyUpdated, func(n client.Node) string { return n.LastSeen.Format("2006-01-02 15:04") }),
+	}, nil
+}
+
+// nodesOutput keeps the fields useful to the LLM
+func nodesOutput(nodes []client.Node) []map[string]any {
+	out := make([]map[string]any, len(nodes))
+	for i, n := range nodes {
+		node := map[string]any{"id": n.ID, "type": n.Type}
+		if n.SourceID != "" {
+			node["source"] = n.SourceID
+		}
+		if len(n.Metadata) > 0 {
+			node["metadata"] = n.Metadata
+		}
+		out[i] = node
+	}
+	return out
+}
+
+// confidenceLabel names an edge confidence level
+func confidenceLabel(c int) string {
+	switch {
+	case c >= 4:
+		return "confirmed"
+	case c == 3:
+		return "explicit"
+	case c == 2:
+		return "corroborated"
+	}
+	return "inferred"
+}
+
+// splitList parses a comma-separated string argument
+func splitList(v any) []string {
+	s, _ := v.(string)
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,123 @@
+package graphtools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/client"
+)
+
+// fakeClient serves a fixed graph and records the last request
+type fakeClient struct {
+	lastQuery     client.GraphQuery
+	lastDepth     int
+	lastTypes     []string
+	lastRelations []string
+}
+
+func (f *fakeClient) QueryGraph(ctx context.Context, q client.GraphQuery) (*client.GraphQueryResult, error) {
+	f.lastQuery = q
+	next := q.Offset + q.Limit
+	return &client.GraphQueryResult{
+		Nodes:      []client.Node{{ID: "deploy/payment-api", Type: "deployment", SourceID: "k8s/prod", Metadata: map[string]any{"replicas": 3.0}}},
+		Total:      120,
+		NextOffset: &next,
+	}, nil
+}
+
+func (f *fakeClient) RelatedNodes(ctx context.Context, nodeID string, depth int, types, relations []string) (*client.Subgraph, error) {
+	if nodeID != "deploy/payment-api" {
+		return nil, errors.New("node " + nodeID + ": not found (status 404)")
+	}
+	f.lastDepth, f.lastTypes, f.lastRelations = depth, types, relations
+	return &client.Subgraph{
+		Nodes: []client.Node{{ID: "deploy/payment-api", Type: "deployment"}, {ID: "db/payments", Type: "database"}},
+		Edges: []client.Edge{{From: "deploy/payment-api", To: "db/payments", Relation: "depends_on", Confidence: 1}},
+	}, nil
+}
+
+func (f *fakeClient) GetGraphSummary(ctx context.Context) (*client.GraphSummary, error) {
+	return &client.GraphSummary{
+		NodeCount:     2,
+		EdgeCount:     1,
+		NodesByType:   map[string]int{"deployment": 1, "database": 1},
+		RecentlyAdded: []client.Node{{ID: "db/payments", Type: "database", FirstSeen: time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)}},
+	}, nil
+}
+
+func TestQueryTool(t *testing.T) {
+	fake := &fakeClient{}
+	result, err := NewQueryTool(fake).Execute(context.Background(), map[string]any{
+		"query":  "payment",
+		"types":  "deployment, service",
+		"limit":  500.0,
+		"offset": 50.0,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	want := client.GraphQuery{Text: "payment", Types: []string{"deployment", "service"}, Limit: maxQueryLimit, Offset: 50}
+	if !reflect.DeepEqual(fake.lastQuery, want) {
+		t.Errorf("query = %+v, want %+v", fake.lastQuery, want)
+	}
+	got := result.(map[string]any)
+	if got["count"] != 1 || got["total"] != 120 || got["next_offset"] != 250 {
+		t.Errorf("result = %v", got)
+	}
+	node := got["nodes"].([]map[string]any)[0]
+	if node["source"] != "k8s/prod" || node["metadata"] == nil {
+		t.Errorf("node = %v, want source and metadata", node)
+	}
+}
+
+func TestRelatedTool(t *testing.T) {
+	fake := &fakeClient{}
+	tool := NewRelatedTool(fake)
+
+	tests := []struct {
+		name      string
+		args      map[string]any
+		wantDepth int
+		wantErr   bool
+	}{
+		{name: "default depth", args: map[string]any{"node_id": "deploy/payment-api"}, wantDepth: defaultDepth},
+		{name: "depth capped", args: map[string]any{"node_id": "deploy/payment-api", "depth": 10.0, "relations": "depends_on"}, wantDepth: maxDepth},
+		{name: "missing node_id", args: map[string]any{}, wantErr: true},
+		{name: "unknown node", args: map[string]any{"node_id": "deploy/nope"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tool.Execute(context.Background(), tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if fake.lastDepth != tt.wantDepth {
+				t.Errorf("depth = %d, want %d", fake.lastDepth, tt.wantDepth)
+			}
+			edge := result.(map[string]any)["edges"].([]map[string]any)[0]
+			if edge["confidence"] != "inferred" || edge["relation"] != "depends_on" {
+				t.Errorf("edge = %v", edge)
+			}
+		})
+	}
+}
+
+func TestSummaryTool(t *testing.T) {
+	result, err := NewSummaryTool(&fakeClient{}).Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	got := result.(map[string]any)
+	added := got["recently_added"].([]map[string]any)
+	if got["node_count"] != 2 || len(added) != 1 || added[0]["at"] != "2024-03-02 09:00" {
+		t.Errorf("result = %v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jaimegago/joe/internal/observability"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewInstrumentation returns middleware recording OpenTelemetry metrics of
+// tool calls by tool name: calls, errors by kind, and duration. Each call's
+// outcome is added to its tool.execute span as well.
+func NewInstrumentation() (Middleware, error) {
+	meter := observability.Meter("joe/tools")
+
+	calls, err := meter.Int64Counter("tool.calls",
+		metric.WithDescription("Number of tool calls"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create call counter: %w", err)
+	}
+	failures, err := meter.Int64Counter("tool.errors",
+		metric.WithDescription("Number of failed tool calls"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error counter: %w", err)
+	}
+	duration, err := meter.Float64Histogram("tool.duration",
+		metric.WithDescription("Tool call duration, including approval"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create duration histogram: %w", err)
+	}
+
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]any) (any, error) {
+			attrs := metric.WithAttributes(attribute.String("tool", name))
+			calls.Add(ctx, 1, attrs)
+
+			start := time.Now()
+			result, err := next(ctx, name, args)
+			elapsed := time.Since(start)
+			duration.Record(ctx, float64(elapsed.Milliseconds()), attrs)
+
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(attribute.Int64("tool.duration_ms", elapsed.Milliseconds()))
+			if err != nil {
+				kind := errorKind(err)
+				failures.Add(ctx, 1, metric.WithAttributes(
+					attribute.String("tool", name),
+					attribute.String("error.type", kind),
+				))
+				span.SetAttributes(attribute.String("error.type", kind))
+			}
+			return result, err
+		}
+	}, nil
+}
+
+// errorKind classifies a failed call for metrics, so denials and timeouts
+// can be told apart from tools failing
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrNotApproved):
+		return "not_approved"
+	case errors.Is(err, ErrToolTimeout):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "error"
+}
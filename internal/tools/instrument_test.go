@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestNewInstrumentation(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(previous)
+
+	instrument, err := NewInstrumentation()
+	if err != nil {
+		t.Fatalf("NewInstrumentation() error = %v", err)
+	}
+	registry := NewRegistry()
+	registry.Register(&mockTool{name: "echo", executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+		return "ok", nil
+	}})
+	registry.Register(&mockTool{name: "fail", executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+		return nil, errors.New("boom")
+	}})
+	registry.Register(&mockTool{name: "write_file"})
+	executor := NewExecutor(registry,
+		WithApproval(map[string]ApprovalPolicy{"write_file": PolicyDeny}, nil, false),
+		WithMiddleware(instrument))
+
+	ctx := context.Background()
+	for _, name := range []string{"echo", "echo", "fail", "write_file"} {
+		executor.Execute(ctx, name, nil)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	calls := map[string]int64{}
+	errorTypes := map[string]string{}
+	durations := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					tool, _ := dp.Attributes.Value(attribute.Key("tool"))
+					switch m.Name {
+					case "tool.calls":
+						calls[tool.AsString()] += dp.Value
+					case "tool.errors":
+						kind, _ := dp.Attributes.Value(attribute.Key("error.type"))
+						errorTypes[tool.AsString()] = kind.AsString()
+					}
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					tool, _ := dp.Attributes.Value(attribute.Key("tool"))
+					durations[tool.AsString()] += dp.Count
+				}
+			}
+		}
+	}
+
+	wantCalls := map[string]int64{"echo": 2, "fail": 1, "write_file": 1}
+	for tool, want := range wantCalls {
+		if calls[tool] != want {
+			t.Errorf("tool.calls{tool=%s} = %d, want %d", tool, calls[tool], want)
+		}
+		if durations[tool] != uint64(want) {
+			t.Errorf("tool.duration{tool=%s} count = %d, want %d", tool, durations[tool], want)
+		}
+	}
+	wantErrors := map[string]string{"fail": "error", "write_file": "not_approved"}
+	if len(errorTypes) != len(wantErrors) {
+		t.Errorf("tool.errors = %v, want %v", errorTypes, wantErrors)
+	}
+	for tool, want := range wantErrors {
+		if errorTypes[tool] != want {
+			t.Errorf("tool.errors{tool=%s} error.type = %q, want %q", tool, errorTypes[tool], want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package archive lists and extracts tar, zip, and gzip archives, such as
+// support bundles and collected logs
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Entry is a file in an archive
+type Entry struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"` // -1 when unknown (a gzipped file)
+	Type     string    `json:"type"` // file, dir, symlink, or other
+	Mode     string    `json:"mode"`
+	Modified time.Time `json:"modified,omitzero"`
+}
+
+// walkFunc is called for each entry of an archive; r reads a file's
+// content and is nil for other types
+type walkFunc func(e Entry, r io.Reader) error
+
+// errStop ends a walk early without an error
+var errStop = fmt.Errorf("stop walking")
+
+// walk calls fn for each entry of the archive at path, which may be a zip,
+// a tar (optionally gzipped), or a single gzipped file
+func walk(path string, fn walkFunc) (format string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	head, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")) || bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		info, err := f.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to stat archive: %w", err)
+		}
+		return "zip", walkZip(f, info.Size(), fn)
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return "", fmt.Errorf("invalid gzip data: %w", err)
+		}
+		defer gz.Close()
+		inner := bufio.NewReader(gz)
+		if isTar(inner) {
+			return "tar.gz", walkTar(inner, fn)
+		}
+		// A single compressed file, named after the archive
+		name := gz.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		return "gzip", ignoreStop(fn(Entry{Name: name, Size: -1, Type: "file", Mode: "-rw-r--r--", Modified: gz.ModTime}, inner))
+	case isTar(br):
+		return "tar", walkTar(br, fn)
+	}
+	return "", fmt.Errorf("%s is not a tar, zip, or gzip archive", path)
+}
+
+// isTar reports whether r starts with a tar header
+func isTar(r *bufio.Reader) bool {
+	head, _ := r.Peek(262)
+	return len(head) == 262 && string(head[257:262]) == "ustar"
+}
+
+func walkTar(r io.Reader, fn walkFunc) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar data: %w", err)
+		}
+		e := Entry{Name: hdr.Name, Size: hdr.Size, Mode: hdr.FileInfo().Mode().String(), Modified: hdr.ModTime.UTC()}
+		var content io.Reader
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			e.Type, content = "file", tr
+		case tar.TypeDir:
+			e.Type = "dir"
+		case tar.TypeSymlink, tar.TypeLink:
+			e.Type = "symlink"
+		default:
+			e.Type = "other"
+		}
+		if err := fn(e, content); err != nil {
+			return ignoreStop(err)
+		}
+	}
+}
+
+func walkZip(r io.ReaderAt, size int64, fn walkFunc) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("invalid zip data: %w", err)
+	}
+	for _, zf := range zr.File {
+		mode := zf.Mode()
+		e := Entry{Name: zf.Name, Size: int64(zf.UncompressedSize64), Mode: mode.String(), Modified: zf.Modified.UTC()}
+		switch {
+		case mode.IsDir():
+			e.Type = "dir"
+		case mode&fs.ModeSymlink != 0:
+			e.Type = "symlink"
+		case mode.IsRegular():
+			e.Type = "file"
+		default:
+			e.Type = "other"
+		}
+		if e.Type != "file" {
+			if err := fn(e, nil); err != nil {
+				return ignoreStop(err)
+			}
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", zf.Name, err)
+		}
+		err = fn(e, rc)
+		rc.Close()
+		if err != nil {
+			return ignoreStop(err)
+		}
+	}
+	return nil
+}
+
+func ignoreStop(err error) error {
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+// safeName returns name as a relative path inside the extraction
+// directory, or false if it would land outside it
+func safeName(name string) (string, bool) {
+	clean := filepath.Clean(filepath.FromSlash(strings.TrimLeft(name, "/")))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) || filepath.IsAbs(clean) {
+		return "", false
+	}
+	return clean, true
+}
@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testFiles are the contents of every test archive; the last one tries to
+// escape the extraction directory
+var testFiles = []struct{ name, content string }{
+	{"bundle/app.log", "started\nfailed\n"},
+	{"bundle/config.yaml", "replicas: 3\n"},
+	{"../escape.txt", "nope"},
+}
+
+func writeTarGz(t *testing.T, path string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range testFiles {
+		tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(f.content))
+	}
+	tw.Close()
+	gz.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeZip(t *testing.T, path string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range testFiles {
+		w, _ := zw.Create(f.name)
+		w.Write([]byte(f.content))
+	}
+	zw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeGzip(t *testing.T, path string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("one line\n"))
+	gz.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTools(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name       string
+		file       string
+		write      func(*testing.T, string)
+		wantFormat string
+		wantNames  []string
+		patterns   []any
+		wantFiles  []string
+		wantSkip   int
+	}{
+		{
+			name:       "tar.gz",
+			file:       "bundle.tgz",
+			write:      writeTarGz,
+			wantFormat: "tar.gz",
+			wantNames:  []string{"bundle/app.log", "bundle/config.yaml", "../escape.txt"},
+			wantFiles:  []string{filepath.Join("bundle", "app.log"), filepath.Join("bundle", "config.yaml")},
+			wantSkip:   1,
+		},
+		{
+			name:       "zip with a pattern",
+			file:       "bundle.zip",
+			write:      writeZip,
+			wantFormat: "zip",
+			wantNames:  []string{"bundle/app.log", "bundle/config.yaml", "../escape.txt"},
+			patterns:   []any{"*.log"},
+			wantFiles:  []string{filepath.Join("bundle", "app.log")},
+		},
+		{
+			name:       "single gzipped file",
+			file:       "syslog.gz",
+			write:      writeGzip,
+			wantFormat: "gzip",
+			wantNames:  []string{"syslog"},
+			wantFiles:  []string{"syslog"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			tt.write(t, path)
+
+			got, err := NewListTool().Execute(context.Background(), map[string]any{"path": path})
+			if err != nil {
+				t.Fatalf("archive_list error = %v", err)
+			}
+			list := got.(map[string]any)
+			var names []string
+			for _, e := range list["entries"].([]Entry) {
+				names = append(names, e.Name)
+			}
+			if list["format"] != tt.wantFormat || !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("archive_list = %s %v, want %s %v", list["format"], names, tt.wantFormat, tt.wantNames)
+			}
+
+			args := map[string]any{"path": path}
+			if tt.patterns != nil {
+				args["patterns"] = tt.patterns
+			}
+			got, err = NewExtractTool().Execute(context.Background(), args)
+			if err != nil {
+				t.Fatalf("archive_extract error = %v", err)
+			}
+			result := got.(map[string]any)
+			out := result["directory"].(string)
+			defer os.RemoveAll(out)
+			if !reflect.DeepEqual(result["files"], tt.wantFiles) || result["files_skipped"] != tt.wantSkip {
+				t.Errorf("archive_extract files = %v (%v skipped), want %v (%d skipped)", result["files"], result["files_skipped"], tt.wantFiles, tt.wantSkip)
+			}
+			for _, f := range tt.wantFiles {
+				if _, err := os.Stat(filepath.Join(out, f)); err != nil {
+					t.Errorf("extracted file missing: %v", err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(out), "escape.txt")); err == nil {
+				t.Error("a file was extracted outside the directory")
+			}
+		})
+	}
+}
+
+func TestTools_NotAnArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("just text"), 0o644)
+	if _, err := NewListTool().Execute(context.Background(), map[string]any{"path": path}); err == nil {
+		t.Error("archive_list of a text file succeeded")
+	}
+}
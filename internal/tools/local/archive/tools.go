@@ -0,0 +1,257 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	maxListEntries  = 1000
+	maxExtractFiles = 1000
+	maxExtractSize  = 200 * 1024 * 1024 // bytes extracted per call
+)
+
+// ListTool lists the files in an archive
+type ListTool struct{}
+
+// NewListTool creates the archive_list tool
+func NewListTool() *ListTool {
+	return &ListTool{}
+}
+
+func (t *ListTool) Name() string {
+	return "archive_list"
+}
+
+func (t *ListTool) Description() string {
+	return "List the files in a tar, tar.gz, zip, or gzip archive, such as a support bundle or collected logs, with their sizes and modification times."
+}
+
+func (t *ListTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "Path to the archive (~ expands to home directory)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+func (t *ListTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	absPath, err := archivePath(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	total := 0
+	format, err := walk(absPath, func(e Entry, _ io.Reader) error {
+		total++
+		if len(entries) < maxListEntries {
+			entries = append(entries, e)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{
+		"path":    absPath,
+		"format":  format,
+		"entries": entries,
+		"count":   total,
+	}
+	if total > len(entries) {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Only the first %d of %d entries are listed. Extract by pattern to get at the rest.", len(entries), total)
+	}
+	return result, nil
+}
+
+// ExtractTool extracts files from an archive into a new temporary
+// directory
+type ExtractTool struct{}
+
+// NewExtractTool creates the archive_extract tool
+func NewExtractTool() *ExtractTool {
+	return &ExtractTool{}
+}
+
+func (t *ExtractTool) Name() string {
+	return "archive_extract"
+}
+
+func (t *ExtractTool) Description() string {
+	return fmt.Sprintf("Extract files from a tar, tar.gz, zip, or gzip archive into a new temporary directory, to read them with read_file, tail_file, or search_files. Extract only what you need with patterns; at most %d files and %dMB are extracted per call.", maxExtractFiles, maxExtractSize/(1024*1024))
+}
+
+func (t *ExtractTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "Path to the archive (~ expands to home directory)",
+			},
+			"patterns": {
+				Type:        "array",
+				Description: "Files to extract, as names or globs matched against the whole name or its base name, e.g. \"*.log\" (optional; default everything)",
+				Items: &llm.Property{
+					Type:        "string",
+					Description: "A name or glob",
+				},
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+func (t *ExtractTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	absPath, err := archivePath(args)
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	if list, ok := args["patterns"].([]any); ok {
+		for _, p := range list {
+			if s, ok := p.(string); ok && s != "" {
+				if _, err := path.Match(s, ""); err != nil {
+					return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+				}
+				patterns = append(patterns, s)
+			}
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "joe-archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+
+	var (
+		files     []string
+		skipped   []string
+		written   int64
+		truncated bool
+	)
+	_, err = walk(absPath, func(e Entry, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if e.Type != "file" || !matches(e.Name, patterns) {
+			return nil
+		}
+		name, ok := safeName(e.Name)
+		if !ok {
+			skipped = append(skipped, e.Name)
+			return nil
+		}
+		if len(files) == maxExtractFiles || written >= maxExtractSize {
+			truncated = true
+			return errStop
+		}
+		n, err := extractFile(filepath.Join(dir, name), r, maxExtractSize-written)
+		written += n
+		if errors.Is(err, errTooLarge) {
+			truncated = true
+			files = append(files, name)
+			return errStop
+		}
+		if err != nil {
+			return err
+		}
+		files = append(files, name)
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	result := map[string]any{
+		"path":          absPath,
+		"directory":     dir,
+		"files":         files,
+		"size_bytes":    written,
+		"files_skipped": len(skipped),
+	}
+	if len(files) == 0 {
+		result["message"] = "No files matched."
+	}
+	if len(skipped) > 0 {
+		result["skipped_message"] = fmt.Sprintf("Skipped %d files with names outside the archive, e.g. %s.", len(skipped), skipped[0])
+	}
+	if truncated {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Stopped at %d files or %dMB; the last file may be cut short. Extract fewer files with patterns.", maxExtractFiles, maxExtractSize/(1024*1024))
+	}
+	return result, nil
+}
+
+// errTooLarge reports extraction stopped at the size limit
+var errTooLarge = errors.New("extraction size limit reached")
+
+// extractFile writes at most limit bytes of r to target
+func extractFile(target string, r io.Reader, limit int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	defer f.Close()
+	n, err := io.Copy(f, io.LimitReader(r, limit+1))
+	if err != nil {
+		return n, fmt.Errorf("failed to extract %s: %w", target, err)
+	}
+	if n > limit {
+		if err := f.Truncate(limit); err != nil {
+			return n, fmt.Errorf("failed to extract %s: %w", target, err)
+		}
+		return limit, errTooLarge
+	}
+	return n, nil
+}
+
+// matches reports whether name matches one of patterns, by its whole name
+// or its base name; no patterns match everything
+func matches(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// archivePath returns the path argument, expanded
+func archivePath(args map[string]any) (string, error) {
+	pathArg, ok := args["path"].(string)
+	if !ok || pathArg == "" {
+		return "", fmt.Errorf("path parameter is required and must be a string")
+	}
+	absPath, err := local.ExpandPath(pathArg)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand path: %w", err)
+	}
+	return absPath, nil
+}
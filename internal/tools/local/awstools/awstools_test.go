@@ -0,0 +1,184 @@
+package awstools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// fakeEC2 returns instances in two pages and records the last input
+type fakeEC2 struct {
+	lastInstancesInput *ec2.DescribeInstancesInput
+}
+
+func (f *fakeEC2) DescribeInstances(ctx context.Context, in *ec2.DescribeInstancesInput, opts ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	f.lastInstancesInput = in
+	if aws.ToString(in.NextToken) == "" {
+		return &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{
+				InstanceId:       aws.String("i-1"),
+				InstanceType:     ec2types.InstanceTypeT3Micro,
+				State:            &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				Placement:        &ec2types.Placement{AvailabilityZone: aws.String("us-east-1a")},
+				PrivateIpAddress: aws.String("10.0.0.5"),
+				LaunchTime:       aws.Time(time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)),
+				Tags:             []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("web-1")}},
+				SecurityGroups:   []ec2types.GroupIdentifier{{GroupId: aws.String("sg-1"), GroupName: aws.String("web")}},
+			}}}},
+			NextToken: aws.String("page2"),
+		}, nil
+	}
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{InstanceId: aws.String("i-2")}}}},
+	}, nil
+}
+
+func (f *fakeEC2) DescribeSecurityGroups(ctx context.Context, in *ec2.DescribeSecurityGroupsInput, opts ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: []ec2types.SecurityGroup{{
+		GroupId:   aws.String("sg-1"),
+		GroupName: aws.String("web"),
+		IpPermissions: []ec2types.IpPermission{
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(443), ToPort: aws.Int32(443), IpRanges: []ec2types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}}},
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(8000), ToPort: aws.Int32(8080), UserIdGroupPairs: []ec2types.UserIdGroupPair{{GroupId: aws.String("sg-2")}}},
+		},
+		IpPermissionsEgress: []ec2types.IpPermission{
+			{IpProtocol: aws.String("-1"), IpRanges: []ec2types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}}},
+		},
+	}}}, nil
+}
+
+func fakeEC2Client(f *fakeEC2) ec2ClientFunc {
+	return func(ctx context.Context, region string) (ec2API, error) { return f, nil }
+}
+
+func TestInstancesTool(t *testing.T) {
+	fake := &fakeEC2{}
+	tool := &InstancesTool{client: fakeEC2Client(fake)}
+
+	result, err := tool.Execute(context.Background(), map[string]any{"state": "running", "instance_ids": []any{"i-1", "i-2"}})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	got := result.(map[string]any)
+	if got["count"] != 2 {
+		t.Fatalf("count = %v, want 2 (both pages)", got["count"])
+	}
+	first := got["instances"].([]map[string]any)[0]
+	want := map[string]any{
+		"id":                "i-1",
+		"name":              "web-1",
+		"type":              "t3.micro",
+		"state":             "running",
+		"availability_zone": "us-east-1a",
+		"private_ip":        "10.0.0.5",
+		"public_ip":         "",
+		"vpc_id":            "",
+		"subnet_id":         "",
+		"launch_time":       "2024-03-02T09:00:00Z",
+		"security_groups":   []string{"sg-1 (web)"},
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("instance = %v, want %v", first, want)
+	}
+
+	if ids := fake.lastInstancesInput.InstanceIds; !reflect.DeepEqual(ids, []string{"i-1", "i-2"}) {
+		t.Errorf("InstanceIds = %v", ids)
+	}
+	if f := fake.lastInstancesInput.Filters; len(f) != 1 || aws.ToString(f[0].Name) != "instance-state-name" {
+		t.Errorf("Filters = %+v", f)
+	}
+}
+
+func TestSecurityGroupsTool(t *testing.T) {
+	tool := &SecurityGroupsTool{client: fakeEC2Client(&fakeEC2{})}
+
+	result, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	group := result.(map[string]any)["security_groups"].([]map[string]any)[0]
+	tests := []struct {
+		name string
+		key  string
+		want []string
+	}{
+		{"inbound rules", "inbound", []string{"tcp 443 from 0.0.0.0/0", "tcp 8000-8080 from sg-2"}},
+		{"outbound rules", "outbound", []string{"all all to 0.0.0.0/0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := group[tt.key]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeS3 struct{}
+
+func (fakeS3) ListBuckets(ctx context.Context, in *s3.ListBucketsInput, opts ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return &s3.ListBucketsOutput{Buckets: []s3types.Bucket{
+		{Name: aws.String("acme-logs"), CreationDate: aws.Time(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{Name: aws.String("acme-assets")},
+	}}, nil
+}
+
+func TestBucketsTool_Filter(t *testing.T) {
+	tool := &BucketsTool{client: func(ctx context.Context, region string) (s3API, error) { return fakeS3{}, nil }}
+
+	result, err := tool.Execute(context.Background(), map[string]any{"name_contains": "LOGS"})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	want := []map[string]any{{"name": "acme-logs", "created": "2023-01-01T00:00:00Z"}}
+	if got := result.(map[string]any)["buckets"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("buckets = %v, want %v", got, want)
+	}
+}
+
+type fakeIAM struct{}
+
+func (fakeIAM) ListRoles(ctx context.Context, in *iam.ListRolesInput, opts ...func(*iam.Options)) (*iam.ListRolesOutput, error) {
+	if in.Marker == nil {
+		return &iam.ListRolesOutput{
+			Roles:       []iamtypes.Role{{RoleName: aws.String("deploy"), Arn: aws.String("arn:aws:iam::1:role/deploy")}},
+			IsTruncated: true,
+			Marker:      aws.String("next"),
+		}, nil
+	}
+	return &iam.ListRolesOutput{Roles: []iamtypes.Role{{RoleName: aws.String("readonly"), MaxSessionDuration: aws.Int32(3600)}}}, nil
+}
+
+func TestRolesTool_Paginates(t *testing.T) {
+	tool := &RolesTool{client: func(ctx context.Context) (iamAPI, error) { return fakeIAM{}, nil }}
+
+	result, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	roles := result.(map[string]any)["roles"].([]map[string]any)
+	if len(roles) != 2 || roles[1]["max_session_seconds"] != int32(3600) {
+		t.Errorf("roles = %v", roles)
+	}
+}
+
+func TestTools_ClientError(t *testing.T) {
+	wantErr := errors.New("no credentials")
+	tool := &InstancesTool{client: func(ctx context.Context, region string) (ec2API, error) { return nil, wantErr }}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{}); !errors.Is(err, wantErr) {
+		t.Errorf("Execute() error = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,279 @@
+package awstools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// ec2API is the subset of the EC2 client the tools use
+type ec2API interface {
+	DescribeInstances(ctx context.Context, in *ec2.DescribeInstancesInput, opts ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeSecurityGroups(ctx context.Context, in *ec2.DescribeSecurityGroupsInput, opts ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+}
+
+// ec2ClientFunc returns an EC2 client for a region
+type ec2ClientFunc func(ctx context.Context, region string) (ec2API, error)
+
+func ec2Client(p *Provider) ec2ClientFunc {
+	return func(ctx context.Context, region string) (ec2API, error) {
+		cfg, err := p.Config(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		return ec2.NewFromConfig(cfg), nil
+	}
+}
+
+// InstancesTool lists EC2 instances
+type InstancesTool struct {
+	client ec2ClientFunc
+}
+
+// NewInstancesTool creates the aws_ec2_instances tool
+func NewInstancesTool(p *Provider) *InstancesTool {
+	return &InstancesTool{client: ec2Client(p)}
+}
+
+func (t *InstancesTool) Name() string {
+	return "aws_ec2_instances"
+}
+
+func (t *InstancesTool) Description() string {
+	return "List EC2 instances with name tag, type, state, availability zone, IPs, VPC/subnet, and security groups. Read-only."
+}
+
+func (t *InstancesTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"region": {
+				Type:        "string",
+				Description: regionProperty,
+			},
+			"instance_ids": {
+				Type:        "array",
+				Description: "Only these instance IDs",
+				Items:       &llm.Property{Type: "string"},
+			},
+			"state": {
+				Type:        "string",
+				Description: "Only instances in this state (pending, running, stopping, stopped, terminated)",
+			},
+			"name": {
+				Type:        "string",
+				Description: "Only instances whose Name tag matches this value (* wildcards allowed)",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *InstancesTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	client, err := t.client(ctx, stringArg(args, "region"))
+	if err != nil {
+		return nil, err
+	}
+
+	input := &ec2.DescribeInstancesInput{InstanceIds: stringSliceArg(args, "instance_ids")}
+	if state := stringArg(args, "state"); state != "" {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("instance-state-name"), Values: []string{state}})
+	}
+	if name := stringArg(args, "name"); name != "" {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("tag:Name"), Values: []string{name}})
+	}
+
+	instances := []map[string]any{}
+	truncated := false
+	for {
+		out, err := client.DescribeInstances(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances: %w", err)
+		}
+		for _, res := range out.Reservations {
+			for _, inst := range res.Instances {
+				if len(instances) == maxResults {
+					truncated = true
+					break
+				}
+				instances = append(instances, instanceSummary(inst))
+			}
+		}
+		if truncated || aws.ToString(out.NextToken) == "" {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+
+	return map[string]any{
+		"instances": instances,
+		"count":     len(instances),
+		"truncated": truncated,
+	}, nil
+}
+
+func instanceSummary(inst types.Instance) map[string]any {
+	groups := make([]string, 0, len(inst.SecurityGroups))
+	for _, g := range inst.SecurityGroups {
+		groups = append(groups, fmt.Sprintf("%s (%s)", aws.ToString(g.GroupId), aws.ToString(g.GroupName)))
+	}
+
+	summary := map[string]any{
+		"id":              aws.ToString(inst.InstanceId),
+		"name":            tagValue(inst.Tags, "Name"),
+		"type":            string(inst.InstanceType),
+		"private_ip":      aws.ToString(inst.PrivateIpAddress),
+		"public_ip":       aws.ToString(inst.PublicIpAddress),
+		"vpc_id":          aws.ToString(inst.VpcId),
+		"subnet_id":       aws.ToString(inst.SubnetId),
+		"security_groups": groups,
+	}
+	if inst.State != nil {
+		summary["state"] = string(inst.State.Name)
+	}
+	if inst.Placement != nil {
+		summary["availability_zone"] = aws.ToString(inst.Placement.AvailabilityZone)
+	}
+	if inst.LaunchTime != nil {
+		summary["launch_time"] = inst.LaunchTime.UTC().Format(time.RFC3339)
+	}
+	return summary
+}
+
+func tagValue(tags []types.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
+
+// SecurityGroupsTool lists security groups and their rules
+type SecurityGroupsTool struct {
+	client ec2ClientFunc
+}
+
+// NewSecurityGroupsTool creates the aws_security_groups tool
+func NewSecurityGroupsTool(p *Provider) *SecurityGroupsTool {
+	return &SecurityGroupsTool{client: ec2Client(p)}
+}
+
+func (t *SecurityGroupsTool) Name() string {
+	return "aws_security_groups"
+}
+
+func (t *SecurityGroupsTool) Description() string {
+	return "List EC2 security groups with their inbound and outbound rules (e.g. 'tcp 443 from 0.0.0.0/0'). Read-only."
+}
+
+func (t *SecurityGroupsTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"region": {
+				Type:        "string",
+				Description: regionProperty,
+			},
+			"group_ids": {
+				Type:        "array",
+				Description: "Only these security group IDs",
+				Items:       &llm.Property{Type: "string"},
+			},
+			"vpc_id": {
+				Type:        "string",
+				Description: "Only security groups in this VPC",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *SecurityGroupsTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	client, err := t.client(ctx, stringArg(args, "region"))
+	if err != nil {
+		return nil, err
+	}
+
+	input := &ec2.DescribeSecurityGroupsInput{GroupIds: stringSliceArg(args, "group_ids")}
+	if vpc := stringArg(args, "vpc_id"); vpc != "" {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String("vpc-id"), Values: []string{vpc}})
+	}
+
+	groups := []map[string]any{}
+	truncated := false
+	for {
+		out, err := client.DescribeSecurityGroups(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe security groups: %w", err)
+		}
+		for _, g := range out.SecurityGroups {
+			if len(groups) == maxResults {
+				truncated = true
+				break
+			}
+			groups = append(groups, map[string]any{
+				"id":          aws.ToString(g.GroupId),
+				"name":        aws.ToString(g.GroupName),
+				"description": aws.ToString(g.Description),
+				"vpc_id":      aws.ToString(g.VpcId),
+				"inbound":     describeRules(g.IpPermissions, "from"),
+				"outbound":    describeRules(g.IpPermissionsEgress, "to"),
+			})
+		}
+		if truncated || aws.ToString(out.NextToken) == "" {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+
+	return map[string]any{
+		"security_groups": groups,
+		"count":           len(groups),
+		"truncated":       truncated,
+	}, nil
+}
+
+// describeRules renders each permission as "<proto> <ports> <from|to> <peer>"
+func describeRules(perms []types.IpPermission, direction string) []string {
+	rules := []string{}
+	for _, p := range perms {
+		proto := aws.ToString(p.IpProtocol)
+		if proto == "-1" {
+			proto = "all"
+		}
+
+		ports := "all"
+		if p.FromPort != nil && p.ToPort != nil && proto != "all" {
+			from, to := aws.ToInt32(p.FromPort), aws.ToInt32(p.ToPort)
+			ports = fmt.Sprint(from)
+			if to != from {
+				ports = fmt.Sprintf("%d-%d", from, to)
+			}
+		}
+
+		var peers []string
+		for _, r := range p.IpRanges {
+			peers = append(peers, aws.ToString(r.CidrIp))
+		}
+		for _, r := range p.Ipv6Ranges {
+			peers = append(peers, aws.ToString(r.CidrIpv6))
+		}
+		for _, g := range p.UserIdGroupPairs {
+			peers = append(peers, aws.ToString(g.GroupId))
+		}
+		for _, pl := range p.PrefixListIds {
+			peers = append(peers, aws.ToString(pl.PrefixListId))
+		}
+
+		rules = append(rules, fmt.Sprintf("%s %s %s %s", proto, ports, direction, strings.Join(peers, ", ")))
+	}
+	return rules
+}
@@ -0,0 +1,94 @@
+// Package awstools provides read-only AWS inventory tools (EC2 instances,
+// security groups, S3 buckets, IAM roles) built on the AWS SDK
+package awstools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// maxResults caps how many items a single tool call returns so large
+// accounts don't flood the LLM context
+const maxResults = 200
+
+// Provider lazily loads the AWS configuration shared by all AWS tools.
+// Credentials are only resolved on first use, so Joe starts without them.
+type Provider struct {
+	region  string
+	profile string
+
+	mu  sync.Mutex
+	cfg *aws.Config
+}
+
+// NewProvider creates a provider with a default region and profile. Empty
+// values fall back to the SDK's usual resolution (AWS_REGION, AWS_PROFILE,
+// ~/.aws/config).
+func NewProvider(region, profile string) *Provider {
+	return &Provider{region: region, profile: profile}
+}
+
+// Config returns the AWS configuration, overriding the region when region
+// is non-empty
+func (p *Provider) Config(ctx context.Context, region string) (aws.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cfg == nil {
+		var opts []func(*awsconfig.LoadOptions) error
+		if p.region != "" {
+			opts = append(opts, awsconfig.WithRegion(p.region))
+		}
+		if p.profile != "" {
+			opts = append(opts, awsconfig.WithSharedConfigProfile(p.profile))
+		}
+
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+		}
+		p.cfg = &cfg
+	}
+
+	cfg := p.cfg.Copy()
+	if region != "" {
+		cfg.Region = region
+	}
+	if cfg.Region == "" {
+		return aws.Config{}, fmt.Errorf("no AWS region configured: set aws.region in config.yaml, AWS_REGION, or pass region")
+	}
+	return cfg, nil
+}
+
+// regionProperty is the common optional "region" parameter description
+const regionProperty = "AWS region, e.g. us-east-1 (defaults to the configured region)"
+
+// stringArg returns a string argument or ""
+func stringArg(args map[string]any, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+// stringSliceArg returns a string list argument, accepting a single string too
+func stringSliceArg(args map[string]any, name string) []string {
+	switch v := args[name].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
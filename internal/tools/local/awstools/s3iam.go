@@ -0,0 +1,204 @@
+package awstools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// s3API is the subset of the S3 client the tools use
+type s3API interface {
+	ListBuckets(ctx context.Context, in *s3.ListBucketsInput, opts ...func(*s3.Options)) (*s3.ListBucketsOutput, error)
+}
+
+// BucketsTool lists S3 buckets
+type BucketsTool struct {
+	client func(ctx context.Context, region string) (s3API, error)
+}
+
+// NewBucketsTool creates the aws_s3_buckets tool
+func NewBucketsTool(p *Provider) *BucketsTool {
+	return &BucketsTool{client: func(ctx context.Context, region string) (s3API, error) {
+		cfg, err := p.Config(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		return s3.NewFromConfig(cfg), nil
+	}}
+}
+
+func (t *BucketsTool) Name() string {
+	return "aws_s3_buckets"
+}
+
+func (t *BucketsTool) Description() string {
+	return "List S3 buckets in the account with their creation dates. Read-only; does not list objects."
+}
+
+func (t *BucketsTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"name_contains": {
+				Type:        "string",
+				Description: "Only buckets whose name contains this text",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *BucketsTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	client, err := t.client(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := strings.ToLower(stringArg(args, "name_contains"))
+	input := &s3.ListBucketsInput{}
+
+	buckets := []map[string]any{}
+	truncated := false
+	for {
+		out, err := client.ListBuckets(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", err)
+		}
+		for _, b := range out.Buckets {
+			name := aws.ToString(b.Name)
+			if filter != "" && !strings.Contains(strings.ToLower(name), filter) {
+				continue
+			}
+			if len(buckets) == maxResults {
+				truncated = true
+				break
+			}
+			bucket := map[string]any{"name": name}
+			if b.CreationDate != nil {
+				bucket["created"] = b.CreationDate.UTC().Format(time.RFC3339)
+			}
+			if region := aws.ToString(b.BucketRegion); region != "" {
+				bucket["region"] = region
+			}
+			buckets = append(buckets, bucket)
+		}
+		if truncated || aws.ToString(out.ContinuationToken) == "" {
+			break
+		}
+		input.ContinuationToken = out.ContinuationToken
+	}
+
+	return map[string]any{
+		"buckets":   buckets,
+		"count":     len(buckets),
+		"truncated": truncated,
+	}, nil
+}
+
+// iamAPI is the subset of the IAM client the tools use
+type iamAPI interface {
+	ListRoles(ctx context.Context, in *iam.ListRolesInput, opts ...func(*iam.Options)) (*iam.ListRolesOutput, error)
+}
+
+// RolesTool lists IAM roles
+type RolesTool struct {
+	client func(ctx context.Context) (iamAPI, error)
+}
+
+// NewRolesTool creates the aws_iam_roles tool
+func NewRolesTool(p *Provider) *RolesTool {
+	return &RolesTool{client: func(ctx context.Context) (iamAPI, error) {
+		cfg, err := p.Config(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		return iam.NewFromConfig(cfg), nil
+	}}
+}
+
+func (t *RolesTool) Name() string {
+	return "aws_iam_roles"
+}
+
+func (t *RolesTool) Description() string {
+	return "List IAM roles with ARN, path, description, creation date, and max session duration. Read-only; does not return policy documents."
+}
+
+func (t *RolesTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path_prefix": {
+				Type:        "string",
+				Description: "Only roles under this path, e.g. /service-role/",
+			},
+			"name_contains": {
+				Type:        "string",
+				Description: "Only roles whose name contains this text",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *RolesTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	client, err := t.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := strings.ToLower(stringArg(args, "name_contains"))
+	input := &iam.ListRolesInput{}
+	if prefix := stringArg(args, "path_prefix"); prefix != "" {
+		input.PathPrefix = aws.String(prefix)
+	}
+
+	roles := []map[string]any{}
+	truncated := false
+	for {
+		out, err := client.ListRoles(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list roles: %w", err)
+		}
+		for _, r := range out.Roles {
+			name := aws.ToString(r.RoleName)
+			if filter != "" && !strings.Contains(strings.ToLower(name), filter) {
+				continue
+			}
+			if len(roles) == maxResults {
+				truncated = true
+				break
+			}
+			role := map[string]any{
+				"name":        name,
+				"arn":         aws.ToString(r.Arn),
+				"path":        aws.ToString(r.Path),
+				"description": aws.ToString(r.Description),
+			}
+			if r.CreateDate != nil {
+				role["created"] = r.CreateDate.UTC().Format(time.RFC3339)
+			}
+			if r.MaxSessionDuration != nil {
+				role["max_session_seconds"] = aws.ToInt32(r.MaxSessionDuration)
+			}
+			roles = append(roles, role)
+		}
+		if truncated || !out.IsTruncated {
+			break
+		}
+		input.Marker = out.Marker
+	}
+
+	return map[string]any{
+		"roles":     roles,
+		"count":     len(roles),
+		"truncated": truncated,
+	}, nil
+}
@@ -0,0 +1,27 @@
+package local
+
+import (
+	"fmt"
+	"path"
+)
+
+// findActions are the find options that run other commands or write or
+// delete files
+var findActions = map[string]bool{
+	"-exec": true, "-execdir": true, "-ok": true, "-okdir": true,
+	"-delete": true, "-fls": true, "-fprint": true, "-fprint0": true, "-fprintf": true,
+}
+
+// CheckArgs returns an error if args would have an allowlisted command run
+// commands that aren't allowlisted or change files, as find -exec does
+func CheckArgs(name string, args []string) error {
+	if path.Base(name) != "find" {
+		return nil
+	}
+	for _, arg := range args {
+		if findActions[arg] {
+			return fmt.Errorf("find %s is not allowed: it runs other commands or changes files", arg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Package dataquery answers precise questions about JSON and YAML
+// documents, such as Kubernetes manifests, with JSONPath or jq style paths
+package dataquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	maxFileSize   = 10 * 1024 * 1024 // 10MB
+	maxMatches    = 200
+	maxResultSize = 100 * 1024 // bytes of matched values returned
+)
+
+type Tool struct{}
+
+func New() *Tool {
+	return &Tool{}
+}
+
+func (t *Tool) Name() string {
+	return "query_data"
+}
+
+func (t *Tool) Description() string {
+	return "Look up values in a JSON or YAML document, such as a Kubernetes manifest, Helm values, or an API response, with a JSONPath or jq style path. Returns each matching value with its exact location, so read values from here rather than from a long file. Multi-document YAML is an array of its documents."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "File to read (~ expands to home directory); or give content",
+			},
+			"content": {
+				Type:        "string",
+				Description: "The document itself, instead of a file",
+			},
+			"format": {
+				Type:        "string",
+				Description: "json or yaml (optional; by the file extension, else detected)",
+			},
+			"query": {
+				Type:        "string",
+				Description: "What to select, e.g. $.spec.template.spec.containers[0].image, .items[].metadata.name, $..image, or $.spec.containers[?(@.name == 'api')].resources. $ alone returns the whole document.",
+			},
+		},
+		Required: []string{"query"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	query, _ := args["query"].(string)
+	if query == "" {
+		return nil, fmt.Errorf("query parameter is required and must be a string")
+	}
+	segs, err := parsePath(query)
+	if err != nil {
+		return nil, err
+	}
+
+	data, name, err := readInput(args)
+	if err != nil {
+		return nil, err
+	}
+	format, _ := args["format"].(string)
+	if format == "" {
+		format = detectFormat(name, data)
+	}
+	doc, err := decode(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := eval(doc, segs)
+	result := map[string]any{
+		"query": query,
+		"count": len(matches),
+	}
+	if name != "" {
+		result["path"] = name
+	}
+	if len(matches) == 0 {
+		result["matches"] = []match{}
+		result["message"] = "Nothing matched. Check the keys with a shorter query, e.g. the parent path."
+		return result, nil
+	}
+
+	var kept []match
+	size := 0
+	for _, m := range matches {
+		encoded, _ := json.Marshal(m.Value)
+		if len(kept) == maxMatches || (len(kept) > 0 && size+len(encoded) > maxResultSize) {
+			break
+		}
+		if len(encoded) > maxResultSize {
+			m.Value = string(encoded[:maxResultSize]) + "... (truncated)"
+		}
+		size += len(encoded)
+		kept = append(kept, m)
+	}
+	result["matches"] = kept
+	if len(kept) < len(matches) {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Only %d of %d matches are shown. Narrow the query.", len(kept), len(matches))
+	}
+	return result, nil
+}
+
+// readInput returns the content argument, or the file at path and its name
+func readInput(args map[string]any) (data []byte, name string, err error) {
+	if content, ok := args["content"].(string); ok && content != "" {
+		return []byte(content), "", nil
+	}
+	pathArg, _ := args["path"].(string)
+	if pathArg == "" {
+		return nil, "", fmt.Errorf("path or content is required")
+	}
+	absPath, err := local.ExpandPath(pathArg)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to expand path: %w", err)
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", fmt.Errorf("file not found: %s", absPath)
+		}
+		return nil, "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.Size() > maxFileSize {
+		return nil, "", fmt.Errorf("file too large (%.1fMB), max %dMB supported", float64(info.Size())/(1024*1024), maxFileSize/(1024*1024))
+	}
+	data, err = os.ReadFile(absPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, absPath, nil
+}
+
+// detectFormat guesses json or yaml from the file extension, else from
+// the content
+func detectFormat(name string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "json"
+	}
+	return "yaml"
+}
+
+// decode parses data; several YAML documents become an array of them
+func decode(data []byte, format string) (any, error) {
+	switch format {
+	case "json":
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return doc, nil
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		var docs []any
+		for {
+			var doc any
+			err := dec.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid YAML: %w", err)
+			}
+			if doc != nil {
+				docs = append(docs, normalize(doc))
+			}
+		}
+		if len(docs) == 1 {
+			return docs[0], nil
+		}
+		return docs, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (use json or yaml)", format)
+}
+
+// normalize turns YAML maps with non-string keys into string-keyed ones,
+// as in JSON, so paths can address them
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+		return v
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[fmt.Sprint(k)] = normalize(e)
+		}
+		return out
+	case []any:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+		return v
+	}
+	return v
+}
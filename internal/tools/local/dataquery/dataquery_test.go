@@ -0,0 +1,121 @@
+package dataquery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const manifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: payment-api
+  labels:
+    app.kubernetes.io/name: payment
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: api
+          image: payment-api:1.4.2
+          resources:
+            limits:
+              memory: 512Mi
+        - name: sidecar
+          image: envoy:1.29
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: payment-api
+`
+
+func TestTool_Execute(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payment.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    []match
+		wantErr string
+	}{
+		{
+			name: "JSONPath into a document",
+			args: map[string]any{"path": path, "query": "$[0].spec.template.spec.containers[0].image"},
+			want: []match{{Path: "$[0].spec.template.spec.containers[0].image", Value: "payment-api:1.4.2"}},
+		},
+		{
+			name: "jq style with iteration",
+			args: map[string]any{"path": path, "query": ".[].kind"},
+			want: []match{{Path: "$[0].kind", Value: "Deployment"}, {Path: "$[1].kind", Value: "Service"}},
+		},
+		{
+			name: "recursive descent",
+			args: map[string]any{"path": path, "query": "$..image"},
+			want: []match{
+				{Path: "$[0].spec.template.spec.containers[0].image", Value: "payment-api:1.4.2"},
+				{Path: "$[0].spec.template.spec.containers[1].image", Value: "envoy:1.29"},
+			},
+		},
+		{
+			name: "filter",
+			args: map[string]any{"path": path, "query": "$..containers[?(@.name == 'api')].resources.limits.memory"},
+			want: []match{{Path: "$[0].spec.template.spec.containers[0].resources.limits.memory", Value: "512Mi"}},
+		},
+		{
+			name: "numeric filter and negative index",
+			args: map[string]any{"path": path, "query": "$[?(@.spec.replicas >= 2)].spec.template.spec.containers[-1].name"},
+			want: []match{{Path: "$[0].spec.template.spec.containers[1].name", Value: "sidecar"}},
+		},
+		{
+			name: "quoted key",
+			args: map[string]any{"path": path, "query": "$[0].metadata.labels['app.kubernetes.io/name']"},
+			want: []match{{Path: "$[0].metadata.labels['app.kubernetes.io/name']", Value: "payment"}},
+		},
+		{
+			name: "inline JSON",
+			args: map[string]any{"content": `{"items": [{"id": 1, "ok": true}, {"id": 2, "ok": false}]}`, "query": "items[?(@.ok == true)].id"},
+			want: []match{{Path: "$.items[0].id", Value: float64(1)}},
+		},
+		{
+			name: "no match",
+			args: map[string]any{"path": path, "query": "$[0].spec.nope"},
+			want: []match{},
+		},
+		{
+			name:    "invalid query",
+			args:    map[string]any{"path": path, "query": "$.spec[?(name)]"},
+			wantErr: "unsupported filter",
+		},
+		{
+			name:    "invalid document",
+			args:    map[string]any{"content": "{not json", "format": "json", "query": "$"},
+			wantErr: "invalid JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New().Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if matches := got.(map[string]any)["matches"]; !reflect.DeepEqual(matches, tt.want) {
+				t.Errorf("matches = %v, want %v", matches, tt.want)
+			}
+		})
+	}
+}
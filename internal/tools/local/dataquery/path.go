@@ -0,0 +1,364 @@
+package dataquery
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// segmentKind is what a path segment selects
+type segmentKind int
+
+const (
+	segKey        segmentKind = iota // a map key
+	segIndex                         // an array index; negative counts from the end
+	segWildcard                      // every value of a map or array
+	segDescendant                    // the node and everything under it
+	segFilter                        // array elements (or map values) a condition holds for
+)
+
+type segment struct {
+	kind   segmentKind
+	key    string
+	index  int
+	filter *condition
+}
+
+// condition is a filter such as @.name == 'api'; with an empty op it
+// checks that the field exists
+type condition struct {
+	field []string
+	op    string
+	value any
+}
+
+// match is a value found by a path, with where it was found
+type match struct {
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// parsePath parses a JSONPath or jq style path: $.spec.containers[0].image,
+// .items[].metadata.name, $..image, or
+// $.spec.containers[?(@.name == 'api')].image. The leading $ or . is
+// optional, and [] is the same as [*].
+func parsePath(expr string) ([]segment, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	var segs []segment
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			segs = append(segs, segment{kind: segDescendant})
+			s = s[2:]
+			if s != "" && s[0] != '[' {
+				key, rest := readKey(s)
+				if key == "" {
+					return nil, fmt.Errorf("invalid path %q: expected a key after ..", expr)
+				}
+				segs = append(segs, keySegment(key))
+				s = rest
+			}
+		case s[0] == '.':
+			s = s[1:]
+			if s == "" || s[0] == '[' {
+				continue // jq's . before [ and a lone .
+			}
+			key, rest := readKey(s)
+			if key == "" {
+				return nil, fmt.Errorf("invalid path %q: expected a key after . at %q", expr, s)
+			}
+			segs = append(segs, keySegment(key))
+			s = rest
+		case s[0] == '[':
+			seg, rest, err := readBracket(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+			}
+			segs = append(segs, seg)
+			s = rest
+		default:
+			if len(segs) > 0 {
+				return nil, fmt.Errorf("invalid path %q at %q", expr, s)
+			}
+			// A path may start with a bare key: spec.replicas
+			key, rest := readKey(s)
+			segs = append(segs, keySegment(key))
+			s = rest
+		}
+	}
+	return segs, nil
+}
+
+func keySegment(key string) segment {
+	if key == "*" {
+		return segment{kind: segWildcard}
+	}
+	return segment{kind: segKey, key: key}
+}
+
+// readKey reads a key up to the next . or [
+func readKey(s string) (key, rest string) {
+	i := strings.IndexAny(s, ".[")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// readBracket reads a [...] segment
+func readBracket(s string) (segment, string, error) {
+	end := closingBracket(s)
+	if end < 0 {
+		return segment{}, "", fmt.Errorf("unclosed [ in %q", s)
+	}
+	inner, rest := strings.TrimSpace(s[1:end]), s[end+1:]
+	switch {
+	case inner == "" || inner == "*":
+		return segment{kind: segWildcard}, rest, nil
+	case strings.HasPrefix(inner, "?"):
+		cond, err := parseCondition(inner[1:])
+		if err != nil {
+			return segment{}, "", err
+		}
+		return segment{kind: segFilter, filter: cond}, rest, nil
+	case inner[0] == '\'' || inner[0] == '"':
+		key, err := unquote(inner)
+		if err != nil {
+			return segment{}, "", err
+		}
+		return segment{kind: segKey, key: key}, rest, nil
+	}
+	n, err := strconv.Atoi(inner)
+	if err != nil {
+		return segment{}, "", fmt.Errorf("invalid index [%s]", inner)
+	}
+	return segment{kind: segIndex, index: n}, rest, nil
+}
+
+// closingBracket returns the index of the ] closing the [ at s[0],
+// skipping brackets and quotes inside it
+func closingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// conditionPattern matches the filters supported: (@.field op literal) or
+// (@.field), with or without parentheses
+var conditionPattern = regexp.MustCompile(`^\(?\s*@((?:\.[^.\s=!<>()]+)+)\s*(?:(==|!=|<=|>=|<|>)\s*(.+?))?\s*\)?$`)
+
+func parseCondition(s string) (*condition, error) {
+	m := conditionPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, fmt.Errorf("unsupported filter %q, want e.g. ?(@.name == 'api')", s)
+	}
+	cond := &condition{field: strings.Split(m[1][1:], "."), op: m[2]}
+	if cond.op != "" {
+		v, err := parseLiteral(m[3])
+		if err != nil {
+			return nil, err
+		}
+		cond.value = v
+	}
+	return cond, nil
+}
+
+func parseLiteral(s string) (any, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if s != "" && (s[0] == '\'' || s[0] == '"') {
+		return unquote(s)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid value %q in filter; quote strings", s)
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	return s[1 : len(s)-1], nil
+}
+
+// eval returns the values path selects in doc, in document order (map
+// keys sorted)
+func eval(doc any, path []segment) []match {
+	current := []match{{Path: "$", Value: doc}}
+	for _, seg := range path {
+		var next []match
+		for _, m := range current {
+			next = append(next, apply(m, seg)...)
+		}
+		current = next
+	}
+	return current
+}
+
+// apply returns what seg selects from m
+func apply(m match, seg segment) []match {
+	switch seg.kind {
+	case segKey:
+		if obj, ok := m.Value.(map[string]any); ok {
+			if v, ok := obj[seg.key]; ok {
+				return []match{{Path: keyPath(m.Path, seg.key), Value: v}}
+			}
+		}
+	case segIndex:
+		if arr, ok := m.Value.([]any); ok {
+			i := seg.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				return []match{{Path: fmt.Sprintf("%s[%d]", m.Path, i), Value: arr[i]}}
+			}
+		}
+	case segWildcard:
+		return children(m)
+	case segDescendant:
+		out := []match{m}
+		for _, c := range children(m) {
+			out = append(out, apply(c, seg)...)
+		}
+		return out
+	case segFilter:
+		var out []match
+		for _, c := range children(m) {
+			if seg.filter.holds(c.Value) {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// children returns the values of a map, by sorted key, or an array
+func children(m match) []match {
+	switch v := m.Value.(type) {
+	case map[string]any:
+		out := make([]match, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			out = append(out, match{Path: keyPath(m.Path, k), Value: v[k]})
+		}
+		return out
+	case []any:
+		out := make([]match, len(v))
+		for i, e := range v {
+			out[i] = match{Path: fmt.Sprintf("%s[%d]", m.Path, i), Value: e}
+		}
+		return out
+	}
+	return nil
+}
+
+// identifier matches keys that can be written after a dot
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func keyPath(parent, key string) string {
+	if identifier.MatchString(key) {
+		return parent + "." + key
+	}
+	return parent + "['" + key + "']"
+}
+
+// holds reports whether the condition is true of v
+func (c *condition) holds(v any) bool {
+	for _, k := range c.field {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return false
+		}
+		if v, ok = obj[k]; !ok {
+			return false
+		}
+	}
+	if c.op == "" {
+		return true
+	}
+
+	if a, ok := toFloat(v); ok {
+		if b, ok := toFloat(c.value); ok {
+			switch c.op {
+			case "==":
+				return a == b
+			case "!=":
+				return a != b
+			case "<":
+				return a < b
+			case ">":
+				return a > b
+			case "<=":
+				return a <= b
+			case ">=":
+				return a >= b
+			}
+		}
+	}
+	equal := fmt.Sprint(v) == fmt.Sprint(c.value) && (v == nil) == (c.value == nil)
+	switch c.op {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	}
+	if a, ok := v.(string); ok {
+		if b, ok := c.value.(string); ok {
+			switch c.op {
+			case "<":
+				return a < b
+			case ">":
+				return a > b
+			case "<=":
+				return a <= b
+			case ">=":
+				return a >= b
+			}
+		}
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,200 @@
+// Package docker provides read-only container inspection tools backed by
+// the Docker Engine API
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultSocket is where the Docker daemon listens unless DOCKER_HOST says otherwise
+const defaultSocket = "/var/run/docker.sock"
+
+// maxResponseBytes caps how much of any API response is read
+const maxResponseBytes = 8 << 20
+
+// Client is a minimal Docker Engine API client
+type Client struct {
+	http    *http.Client
+	baseURL string
+}
+
+// NewClient creates a client for DOCKER_HOST (unix:// or tcp://), falling
+// back to the default unix socket
+func NewClient() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = "unix://" + defaultSocket
+	}
+	return NewClientForHost(host)
+}
+
+// NewClientForHost creates a client for a Docker host URL such as
+// "unix:///var/run/docker.sock" or "tcp://127.0.0.1:2375"
+func NewClientForHost(host string) (*Client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		// The host part is ignored when dialing a socket
+		return &Client{http: &http.Client{Transport: transport}, baseURL: "http://docker"}, nil
+	case "tcp", "http":
+		return &Client{http: &http.Client{}, baseURL: "http://" + u.Host}, nil
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q (use unix:// or tcp://)", u.Scheme)
+	}
+}
+
+// get performs a GET request and returns the body, turning API errors into Go errors
+func (c *Client) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach docker daemon (is it running?): %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("docker: %s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("docker: %s", resp.Status)
+	}
+	return body, nil
+}
+
+// getJSON performs a GET and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	body, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid docker response for %s: %w", path, err)
+	}
+	return nil
+}
+
+// container is the subset of GET /containers/{id}/json the tools use
+type container struct {
+	ID           string    `json:"Id"`
+	Name         string    `json:"Name"`
+	Image        string    `json:"Image"`
+	Created      time.Time `json:"Created"`
+	RestartCount int       `json:"RestartCount"`
+	Path         string    `json:"Path"`
+	Args         []string  `json:"Args"`
+	State        struct {
+		Status     string    `json:"Status"`
+		Running    bool      `json:"Running"`
+		Restarting bool      `json:"Restarting"`
+		OOMKilled  bool      `json:"OOMKilled"`
+		ExitCode   int       `json:"ExitCode"`
+		Error      string    `json:"Error"`
+		StartedAt  time.Time `json:"StartedAt"`
+		FinishedAt time.Time `json:"FinishedAt"`
+		Health     *struct {
+			Status        string `json:"Status"`
+			FailingStreak int    `json:"FailingStreak"`
+			Log           []struct {
+				ExitCode int    `json:"ExitCode"`
+				Output   string `json:"Output"`
+			} `json:"Log"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Tty    bool              `json:"Tty"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	HostConfig struct {
+		RestartPolicy struct {
+			Name              string `json:"Name"`
+			MaximumRetryCount int    `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+		Memory   int64 `json:"Memory"`
+		NanoCpus int64 `json:"NanoCpus"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+		RW          bool   `json:"RW"`
+	} `json:"Mounts"`
+	NetworkSettings struct {
+		Ports    map[string][]struct{ HostIP, HostPort string } `json:"Ports"`
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+func (c *Client) inspectContainer(ctx context.Context, id string) (*container, error) {
+	var ctr container
+	if err := c.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/json", nil, &ctr); err != nil {
+		return nil, err
+	}
+	return &ctr, nil
+}
+
+// demuxLogs converts Docker's multiplexed log stream (used when a container
+// has no TTY) into plain text. Each frame is an 8-byte header — stream type
+// and big-endian payload length — followed by the payload.
+func demuxLogs(data []byte) string {
+	var b strings.Builder
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		data = data[8:]
+		if size > len(data) {
+			size = len(data)
+		}
+		b.Write(data[:size])
+		data = data[size:]
+	}
+	return b.String()
+}
+
+// shortID truncates a container or image ID the way the docker CLI does
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
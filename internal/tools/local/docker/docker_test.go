@@ -0,0 +1,208 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newFakeDaemon serves canned Engine API responses on a unix socket, like
+// the real daemon
+func newFakeDaemon(t *testing.T) *Client {
+	t.Helper()
+
+	// Socket paths are length-limited, so avoid the long t.TempDir() path
+	dir, err := os.MkdirTemp("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "docker.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"Id":"0123456789abcdef","Names":["/nginx"],"Image":"nginx:1.25","State":"restarting","Status":"Restarting (1) 5 seconds ago","Created":1700000000}]`))
+	})
+	mux.HandleFunc("GET /containers/nginx/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"Id":"0123456789abcdef","Name":"/nginx","RestartCount":7,"Path":"nginx","Args":["-g","daemon off;"],
+			"State":{"Status":"restarting","Restarting":true,"ExitCode":1,"OOMKilled":false,
+				"Health":{"Status":"unhealthy","FailingStreak":3,"Log":[{"ExitCode":1,"Output":"connection refused\n"}]}},
+			"Config":{"Image":"nginx:1.25","Tty":false,"Env":["SECRET=hunter2"]},
+			"HostConfig":{"RestartPolicy":{"Name":"always"}},
+			"NetworkSettings":{"Ports":{"80/tcp":[{"HostIp":"0.0.0.0","HostPort":"8080"}]},"Networks":{"bridge":{"IPAddress":"172.17.0.2"}}}
+		}`))
+	})
+	mux.HandleFunc("GET /containers/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"No such container: missing"}`))
+	})
+	mux.HandleFunc("GET /containers/nginx/logs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tail") != "5" {
+			t.Errorf("tail = %q, want 5", r.URL.Query().Get("tail"))
+		}
+		w.Write(frame(1, "2024-03-02T09:00:00Z starting nginx\n"))
+		w.Write(frame(2, "2024-03-02T09:00:01Z emerg: bind() failed\n"))
+	})
+	mux.HandleFunc("GET /images/nginx:1.25/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Id":"sha256:abcdef0123456789","RepoTags":["nginx:1.25"],"Size":1000,"Architecture":"amd64","Os":"linux",
+			"Config":{"Cmd":["nginx","-g","daemon off;"],"ExposedPorts":{"80/tcp":{}}}}`))
+	})
+
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener = listener
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	client, err := NewClientForHost("unix://" + socket)
+	if err != nil {
+		t.Fatalf("NewClientForHost() error: %v", err)
+	}
+	return client
+}
+
+// frame encodes a payload in Docker's multiplexed stream format
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestContainersTool(t *testing.T) {
+	tool := NewContainersTool(newFakeDaemon(t))
+
+	result, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	containers := result.(map[string]any)["containers"].([]map[string]any)
+	if len(containers) != 1 {
+		t.Fatalf("got %d containers, want 1", len(containers))
+	}
+	want := map[string]any{
+		"id":      "0123456789ab",
+		"name":    "nginx",
+		"image":   "nginx:1.25",
+		"state":   "restarting",
+		"status":  "Restarting (1) 5 seconds ago",
+		"created": "2023-11-14T22:13:20Z",
+	}
+	if !reflect.DeepEqual(containers[0], want) {
+		t.Errorf("container = %v, want %v", containers[0], want)
+	}
+}
+
+func TestInspectTool(t *testing.T) {
+	tool := NewInspectTool(newFakeDaemon(t))
+
+	tests := []struct {
+		name      string
+		container string
+		wantErr   string
+	}{
+		{"existing container", "nginx", ""},
+		{"missing container", "missing", "No such container"},
+		{"no container given", "", "container parameter is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tool.Execute(context.Background(), map[string]any{"container": tt.container})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+
+			got := result.(map[string]any)
+			if got["restart_count"] != 7 || got["restart_policy"] != "always" {
+				t.Errorf("restart info = %v/%v", got["restart_count"], got["restart_policy"])
+			}
+			if ports := got["ports"].([]string); len(ports) != 1 || ports[0] != "0.0.0.0:8080->80/tcp" {
+				t.Errorf("ports = %v", ports)
+			}
+			health := got["state"].(map[string]any)["health"].(map[string]any)
+			if health["last_output"] != "connection refused" {
+				t.Errorf("health = %v", health)
+			}
+			if _, leaked := got["env"]; leaked {
+				t.Error("inspect result must not include environment variables")
+			}
+		})
+	}
+}
+
+func TestLogsTool_Demuxes(t *testing.T) {
+	tool := NewLogsTool(newFakeDaemon(t))
+
+	result, err := tool.Execute(context.Background(), map[string]any{"container": "nginx", "lines": float64(5)})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	got := result.(map[string]any)
+	want := "2024-03-02T09:00:00Z starting nginx\n2024-03-02T09:00:01Z emerg: bind() failed\n"
+	if got["logs"] != want {
+		t.Errorf("logs = %q, want %q", got["logs"], want)
+	}
+	if got["lines"] != 2 {
+		t.Errorf("lines = %v, want 2", got["lines"])
+	}
+}
+
+func TestImageTool(t *testing.T) {
+	tool := NewImageTool(newFakeDaemon(t))
+
+	result, err := tool.Execute(context.Background(), map[string]any{"image": "nginx:1.25"})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	got := result.(map[string]any)
+	if got["id"] != "abcdef012345" || got["platform"] != "linux/amd64" {
+		t.Errorf("image = %v", got)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"image": "nginx?x=1"}); err == nil {
+		t.Error("Execute() should reject image names with query characters")
+	}
+}
+
+func TestNewClientForHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{"unix:///var/run/docker.sock", false},
+		{"tcp://127.0.0.1:2375", false},
+		{"ssh://user@host", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			_, err := NewClientForHost(tt.host)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClientForHost(%q) error = %v, wantErr %v", tt.host, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+const (
+	defaultLogLines = 100
+	maxLogLines     = 1000
+)
+
+// ContainersTool lists containers
+type ContainersTool struct {
+	client *Client
+}
+
+// NewContainersTool creates the docker_list_containers tool
+func NewContainersTool(client *Client) *ContainersTool {
+	return &ContainersTool{client: client}
+}
+
+func (t *ContainersTool) Name() string {
+	return "docker_list_containers"
+}
+
+func (t *ContainersTool) Description() string {
+	return "List Docker containers with their image, state, and status (e.g. 'Restarting (1) 5 seconds ago'). Includes stopped containers unless running_only is true."
+}
+
+func (t *ContainersTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"running_only": {
+				Type:        "boolean",
+				Description: "Only list running containers (default false)",
+			},
+			"name": {
+				Type:        "string",
+				Description: "Only list containers whose name contains this text",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *ContainersTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	query := url.Values{}
+	if runningOnly, _ := args["running_only"].(bool); !runningOnly {
+		query.Set("all", "1")
+	}
+	if name, _ := args["name"].(string); name != "" {
+		filters, _ := json.Marshal(map[string][]string{"name": {name}})
+		query.Set("filters", string(filters))
+	}
+
+	var list []struct {
+		ID      string   `json:"Id"`
+		Names   []string `json:"Names"`
+		Image   string   `json:"Image"`
+		State   string   `json:"State"`
+		Status  string   `json:"Status"`
+		Created int64    `json:"Created"`
+	}
+	if err := t.client.getJSON(ctx, "/containers/json", query, &list); err != nil {
+		return nil, err
+	}
+
+	containers := make([]map[string]any, 0, len(list))
+	for _, c := range list {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		containers = append(containers, map[string]any{
+			"id":      shortID(c.ID),
+			"name":    name,
+			"image":   c.Image,
+			"state":   c.State,
+			"status":  c.Status,
+			"created": time.Unix(c.Created, 0).UTC().Format(time.RFC3339),
+		})
+	}
+
+	return map[string]any{
+		"containers": containers,
+		"count":      len(containers),
+	}, nil
+}
+
+// InspectTool shows a container's state and configuration
+type InspectTool struct {
+	client *Client
+}
+
+// NewInspectTool creates the docker_inspect_container tool
+func NewInspectTool(client *Client) *InspectTool {
+	return &InspectTool{client: client}
+}
+
+func (t *InspectTool) Name() string {
+	return "docker_inspect_container"
+}
+
+func (t *InspectTool) Description() string {
+	return "Inspect a Docker container: state, exit code, OOM kills, restart count and policy, health checks, ports, mounts, and networks. Environment variables are not returned."
+}
+
+func (t *InspectTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"container": {
+				Type:        "string",
+				Description: "Container name or ID",
+			},
+		},
+		Required: []string{"container"},
+	}
+}
+
+func (t *InspectTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	id, ok := args["container"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("container parameter is required")
+	}
+
+	ctr, err := t.client.inspectContainer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	state := map[string]any{
+		"status":      ctr.State.Status,
+		"running":     ctr.State.Running,
+		"restarting":  ctr.State.Restarting,
+		"oom_killed":  ctr.State.OOMKilled,
+		"exit_code":   ctr.State.ExitCode,
+		"started_at":  ctr.State.StartedAt,
+		"finished_at": ctr.State.FinishedAt,
+	}
+	if ctr.State.Error != "" {
+		state["error"] = ctr.State.Error
+	}
+	if h := ctr.State.Health; h != nil {
+		health := map[string]any{
+			"status":         h.Status,
+			"failing_streak": h.FailingStreak,
+		}
+		if n := len(h.Log); n > 0 {
+			last := h.Log[n-1]
+			health["last_exit_code"] = last.ExitCode
+			health["last_output"] = strings.TrimSpace(last.Output)
+		}
+		state["health"] = health
+	}
+
+	ports := []string{}
+	for containerPort, bindings := range ctr.NetworkSettings.Ports {
+		if len(bindings) == 0 {
+			ports = append(ports, containerPort)
+		}
+		for _, b := range bindings {
+			ports = append(ports, fmt.Sprintf("%s:%s->%s", b.HostIP, b.HostPort, containerPort))
+		}
+	}
+	sort.Strings(ports)
+
+	mounts := make([]string, 0, len(ctr.Mounts))
+	for _, m := range ctr.Mounts {
+		mode := "ro"
+		if m.RW {
+			mode = "rw"
+		}
+		mounts = append(mounts, fmt.Sprintf("%s:%s (%s, %s)", m.Source, m.Destination, m.Type, mode))
+	}
+
+	networks := make(map[string]string, len(ctr.NetworkSettings.Networks))
+	for name, n := range ctr.NetworkSettings.Networks {
+		networks[name] = n.IPAddress
+	}
+
+	return map[string]any{
+		"id":             shortID(ctr.ID),
+		"name":           strings.TrimPrefix(ctr.Name, "/"),
+		"image":          ctr.Config.Image,
+		"command":        strings.TrimSpace(ctr.Path + " " + strings.Join(ctr.Args, " ")),
+		"created":        ctr.Created,
+		"state":          state,
+		"restart_count":  ctr.RestartCount,
+		"restart_policy": ctr.HostConfig.RestartPolicy.Name,
+		"memory_limit":   ctr.HostConfig.Memory,
+		"cpu_limit":      float64(ctr.HostConfig.NanoCpus) / 1e9,
+		"ports":          ports,
+		"mounts":         mounts,
+		"networks":       networks,
+		"labels":         ctr.Config.Labels,
+	}, nil
+}
+
+// LogsTool fetches recent container logs
+type LogsTool struct {
+	client *Client
+}
+
+// NewLogsTool creates the docker_container_logs tool
+func NewLogsTool(client *Client) *LogsTool {
+	return &LogsTool{client: client}
+}
+
+func (t *LogsTool) Name() string {
+	return "docker_container_logs"
+}
+
+func (t *LogsTool) Description() string {
+	return fmt.Sprintf("Fetch recent stdout/stderr logs from a Docker container, with timestamps. Returns the last %d lines by default (max %d).", defaultLogLines, maxLogLines)
+}
+
+func (t *LogsTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"container": {
+				Type:        "string",
+				Description: "Container name or ID",
+			},
+			"lines": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Number of lines from the end of the log (default %d, max %d)", defaultLogLines, maxLogLines),
+			},
+			"since": {
+				Type:        "string",
+				Description: "Only return logs newer than this duration, e.g. '10m' or '2h'",
+			},
+		},
+		Required: []string{"container"},
+	}
+}
+
+func (t *LogsTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	id, ok := args["container"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("container parameter is required")
+	}
+
+	lines := defaultLogLines
+	if n, ok := args["lines"].(float64); ok && n > 0 {
+		lines = int(min(n, maxLogLines))
+	}
+
+	query := url.Values{
+		"stdout":     {"1"},
+		"stderr":     {"1"},
+		"timestamps": {"1"},
+		"tail":       {strconv.Itoa(lines)},
+	}
+	if since, ok := args["since"].(string); ok && since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since duration %q: %w", since, err)
+		}
+		query.Set("since", strconv.FormatInt(time.Now().Add(-d).Unix(), 10))
+	}
+
+	// TTY containers stream raw output; others use the multiplexed format
+	ctr, err := t.client.inspectContainer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := t.client.get(ctx, "/containers/"+url.PathEscape(id)+"/logs", query)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := string(body)
+	if !ctr.Config.Tty {
+		logs = demuxLogs(body)
+	}
+
+	return map[string]any{
+		"container": strings.TrimPrefix(ctr.Name, "/"),
+		"lines":     strings.Count(logs, "\n"),
+		"logs":      logs,
+	}, nil
+}
+
+// ImageTool shows image details
+type ImageTool struct {
+	client *Client
+}
+
+// NewImageTool creates the docker_image_info tool
+func NewImageTool(client *Client) *ImageTool {
+	return &ImageTool{client: client}
+}
+
+func (t *ImageTool) Name() string {
+	return "docker_image_info"
+}
+
+func (t *ImageTool) Description() string {
+	return "Show details of a local Docker image: tags, creation time, size, platform, entrypoint/cmd, exposed ports, and labels."
+}
+
+func (t *ImageTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"image": {
+				Type:        "string",
+				Description: "Image name, name:tag, or ID",
+			},
+		},
+		Required: []string{"image"},
+	}
+}
+
+func (t *ImageTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	name, ok := args["image"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("image parameter is required")
+	}
+	if strings.ContainsAny(name, "?# \t\n") {
+		return nil, fmt.Errorf("invalid image name %q", name)
+	}
+
+	var img struct {
+		ID           string   `json:"Id"`
+		RepoTags     []string `json:"RepoTags"`
+		Created      string   `json:"Created"`
+		Size         int64    `json:"Size"`
+		Architecture string   `json:"Architecture"`
+		Os           string   `json:"Os"`
+		Config       struct {
+			Entrypoint   []string            `json:"Entrypoint"`
+			Cmd          []string            `json:"Cmd"`
+			ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+			WorkingDir   string              `json:"WorkingDir"`
+			User         string              `json:"User"`
+			Labels       map[string]string   `json:"Labels"`
+		} `json:"Config"`
+	}
+	// Image names contain slashes and colons that must stay unescaped
+	if err := t.client.getJSON(ctx, "/images/"+name+"/json", nil, &img); err != nil {
+		return nil, err
+	}
+
+	ports := make([]string, 0, len(img.Config.ExposedPorts))
+	for p := range img.Config.ExposedPorts {
+		ports = append(ports, p)
+	}
+	sort.Strings(ports)
+
+	return map[string]any{
+		"id":            shortID(img.ID),
+		"tags":          img.RepoTags,
+		"created":       img.Created,
+		"size_bytes":    img.Size,
+		"platform":      img.Os + "/" + img.Architecture,
+		"entrypoint":    img.Config.Entrypoint,
+		"cmd":           img.Config.Cmd,
+		"exposed_ports": ports,
+		"working_dir":   img.Config.WorkingDir,
+		"user":          img.Config.User,
+		"labels":        img.Config.Labels,
+	}, nil
+}
@@ -0,0 +1,184 @@
+package editfile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const maxFileSize = 1 * 1024 * 1024 // 1MB
+
+type Tool struct {
+	allowedPaths []string // empty allows any path
+}
+
+// New creates the tool. When allowedPaths is given, only files under one of
+// those directories may be changed.
+func New(allowedPaths ...string) *Tool {
+	return &Tool{allowedPaths: allowedPaths}
+}
+
+func (t *Tool) Name() string {
+	return "edit_file"
+}
+
+func (t *Tool) Description() string {
+	return "Edit an existing file without rewriting it: either replace old_string with new_string, or apply a unified diff. " +
+		"old_string must match exactly once unless replace_all is true; ambiguous or missing matches are refused. " +
+		"Set dry_run to preview the change as a diff without writing. Prefer this over write_file for changing existing files."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "Path to file (absolute or relative to current directory, ~ expands to home directory)",
+			},
+			"old_string": {
+				Type:        "string",
+				Description: "Exact text to replace, including enough surrounding lines to be unique",
+			},
+			"new_string": {
+				Type:        "string",
+				Description: "Replacement text (used with old_string)",
+			},
+			"replace_all": {
+				Type:        "boolean",
+				Description: "Replace every occurrence of old_string instead of requiring exactly one (default false)",
+			},
+			"diff": {
+				Type:        "string",
+				Description: "Unified diff to apply to this file (alternative to old_string/new_string)",
+			},
+			"dry_run": {
+				Type:        "boolean",
+				Description: "Return the resulting diff without modifying the file (default false)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pathArg, ok := args["path"].(string)
+	if !ok || pathArg == "" {
+		return nil, fmt.Errorf("path parameter is required and must be a string")
+	}
+
+	oldString, hasOld := args["old_string"].(string)
+	newString, hasNew := args["new_string"].(string)
+	diff, hasDiff := args["diff"].(string)
+	hasDiff = hasDiff && diff != ""
+	hasOld = hasOld && oldString != ""
+
+	switch {
+	case hasDiff && hasOld:
+		return nil, fmt.Errorf("use either diff or old_string/new_string, not both")
+	case !hasDiff && !hasOld:
+		return nil, fmt.Errorf("either diff or old_string is required")
+	case hasOld && !hasNew:
+		return nil, fmt.Errorf("new_string is required with old_string")
+	}
+
+	// Expand path
+	absPath, err := local.ExpandPath(pathArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+	if !local.PathAllowed(absPath, t.allowedPaths) {
+		return nil, fmt.Errorf("writing to %s is not allowed. Allowed paths: %s", absPath, strings.Join(t.allowedPaths, ", "))
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s (use write_file to create new files)", absPath)
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", absPath)
+	}
+	if info.Size() > maxFileSize {
+		return nil, fmt.Errorf("file too large (%.1fMB), max 1MB supported", float64(info.Size())/(1024*1024))
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	original := string(data)
+
+	var updated string
+	replacements := 0
+	if hasDiff {
+		updated, err = applyDiff(original, diff)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		replaceAll, _ := args["replace_all"].(bool)
+		updated, replacements, err = replace(original, oldString, newString, replaceAll)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if updated == original {
+		return nil, fmt.Errorf("edit makes no changes to %s", absPath)
+	}
+
+	result := map[string]any{
+		"path": absPath,
+		"diff": preview(absPath, original, updated),
+	}
+	if replacements > 0 {
+		result["replacements"] = replacements
+	}
+
+	if dryRun, _ := args["dry_run"].(bool); dryRun {
+		result["dry_run"] = true
+		return result, nil
+	}
+
+	if err := os.WriteFile(absPath, []byte(updated), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	result["applied"] = true
+	return result, nil
+}
+
+// replace substitutes newString for oldString, refusing missing matches and,
+// unless replaceAll, ambiguous ones
+func replace(content, oldString, newString string, replaceAll bool) (string, int, error) {
+	count := strings.Count(content, oldString)
+	if count == 0 {
+		return "", 0, fmt.Errorf("old_string not found in file")
+	}
+	if count > 1 && !replaceAll {
+		return "", 0, fmt.Errorf("old_string matches %d times (at lines %s); include more surrounding text to make it unique or set replace_all",
+			count, matchLines(content, oldString))
+	}
+	return strings.ReplaceAll(content, oldString, newString), count, nil
+}
+
+// matchLines lists the line numbers where s starts in content
+func matchLines(content, s string) string {
+	var lines []string
+	offset := 0
+	for {
+		i := strings.Index(content[offset:], s)
+		if i < 0 {
+			break
+		}
+		lines = append(lines, fmt.Sprint(strings.Count(content[:offset+i], "\n")+1))
+		offset += i + len(s)
+	}
+	return strings.Join(lines, ", ")
+}
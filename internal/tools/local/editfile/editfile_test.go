@@ -0,0 +1,141 @@
+package editfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const config = `server:
+  port: 8080
+  timeout: 30s
+client:
+  timeout: 30s
+`
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    string
+		wantErr string
+	}{
+		{
+			name: "unique replacement",
+			args: map[string]any{"old_string": "port: 8080", "new_string": "port: 9090"},
+			want: strings.Replace(config, "8080", "9090", 1),
+		},
+		{
+			name:    "ambiguous replacement",
+			args:    map[string]any{"old_string": "timeout: 30s", "new_string": "timeout: 60s"},
+			wantErr: "matches 2 times (at lines 3, 5)",
+		},
+		{
+			name: "replace all",
+			args: map[string]any{"old_string": "timeout: 30s", "new_string": "timeout: 60s", "replace_all": true},
+			want: strings.ReplaceAll(config, "30s", "60s"),
+		},
+		{
+			name:    "missing match",
+			args:    map[string]any{"old_string": "port: 1", "new_string": "port: 2"},
+			wantErr: "not found",
+		},
+		{
+			name: "unified diff",
+			args: map[string]any{"diff": "--- a/config.yaml\n+++ b/config.yaml\n@@ -4,2 +4,3 @@\n client:\n   timeout: 30s\n+  retries: 3\n"},
+			want: config + "  retries: 3\n",
+		},
+		{
+			name: "unified diff with wrong line numbers",
+			args: map[string]any{"diff": "@@ -1,2 +1,2 @@\n client:\n-  timeout: 30s\n+  timeout: 5s\n"},
+			want: strings.Replace(config, "client:\n  timeout: 30s", "client:\n  timeout: 5s", 1),
+		},
+		{
+			name:    "ambiguous diff context",
+			args:    map[string]any{"diff": "@@ -10,1 +10,1 @@\n-  timeout: 30s\n+  timeout: 5s\n"},
+			wantErr: "matches 2 places",
+		},
+		{
+			name:    "diff and old_string together",
+			args:    map[string]any{"diff": "@@ -1 +1 @@\n", "old_string": "x", "new_string": "y"},
+			wantErr: "not both",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			tt.args["path"] = path
+
+			_, err := New().Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.want {
+				t.Errorf("file = %q, want %q", data, tt.want)
+			}
+			if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+				t.Errorf("mode = %v, want 0600 preserved", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestExecute_DryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().Execute(context.Background(), map[string]any{
+		"path":       path,
+		"old_string": "port: 8080",
+		"new_string": "port: 9090",
+		"dry_run":    true,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	want := "--- " + path + "\n+++ " + path + "\n@@ -1,5 +1,5 @@\n server:\n-  port: 8080\n+  port: 9090\n   timeout: 30s\n client:\n   timeout: 30s\n"
+	if got := result.(map[string]any)["diff"]; got != want {
+		t.Errorf("diff = %q, want %q", got, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != config {
+		t.Error("dry run modified the file")
+	}
+}
+
+func TestExecute_AllowedPaths(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := New(filepath.Join(dir, "allowed"))
+	_, err := tool.Execute(context.Background(), map[string]any{
+		"path":       path,
+		"old_string": "port: 8080",
+		"new_string": "port: 9090",
+	})
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Execute() error = %v, want not allowed", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != config {
+		t.Error("file outside allowed paths was modified")
+	}
+}
@@ -0,0 +1,167 @@
+package editfile
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// hunk is one @@ section of a unified diff
+type hunk struct {
+	oldStart int // 1-based line number from the header
+	old      []string
+	new      []string
+}
+
+// parseDiff parses a single-file unified diff. File headers (---/+++, diff
+// --git, index) are optional and ignored.
+func parseDiff(diff string) ([]hunk, error) {
+	var hunks []hunk
+	var current *hunk
+	files := 0
+
+	lines := splitLines(strings.TrimRight(diff, "\n"))
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			files++
+			if files > 1 {
+				return nil, fmt.Errorf("diff touches more than one file; edit one file at a time")
+			}
+			current = nil
+			continue
+		case strings.HasPrefix(line, "+++ ") && i > 0 && strings.HasPrefix(lines[i-1], "--- "):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", line)
+			}
+			start, _ := strconv.Atoi(m[1])
+			hunks = append(hunks, hunk{oldStart: start})
+			current = &hunks[len(hunks)-1]
+			continue
+		}
+
+		if current == nil {
+			// Preamble such as "--- a/file" or "diff --git"
+			continue
+		}
+		switch {
+		case line == "":
+			// Editors and models often strip the space from empty context lines
+			current.old = append(current.old, "")
+			current.new = append(current.new, "")
+		case line[0] == ' ':
+			current.old = append(current.old, line[1:])
+			current.new = append(current.new, line[1:])
+		case line[0] == '-':
+			current.old = append(current.old, line[1:])
+		case line[0] == '+':
+			current.new = append(current.new, line[1:])
+		case line[0] == '\\':
+			// "\ No newline at end of file"
+		default:
+			return nil, fmt.Errorf("invalid diff line %q", line)
+		}
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("diff contains no hunks")
+	}
+	return hunks, nil
+}
+
+// applyDiff applies a unified diff to content. Each hunk must match exactly
+// once after the previous hunk; the header line number only breaks ties in
+// favour of the expected position.
+func applyDiff(content, diff string) (string, error) {
+	hunks, err := parseDiff(diff)
+	if err != nil {
+		return "", err
+	}
+
+	lines := splitLines(content)
+	trailingNewline := strings.HasSuffix(content, "\n")
+
+	offset := 0 // line shift caused by earlier hunks
+	next := 0   // hunks apply in order, so search after the previous one
+	for i, h := range hunks {
+		pos, err := locate(lines, h, h.oldStart-1+offset, next)
+		if err != nil {
+			return "", fmt.Errorf("hunk %d: %w", i+1, err)
+		}
+
+		updated := make([]string, 0, len(lines)-len(h.old)+len(h.new))
+		updated = append(updated, lines[:pos]...)
+		updated = append(updated, h.new...)
+		updated = append(updated, lines[pos+len(h.old):]...)
+		lines = updated
+
+		offset += len(h.new) - len(h.old)
+		next = pos + len(h.new)
+	}
+
+	result := strings.Join(lines, "\n")
+	if trailingNewline && len(lines) > 0 {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// locate finds where a hunk's old lines start, searching from line from
+func locate(lines []string, h hunk, expected, from int) (int, error) {
+	if len(h.old) == 0 {
+		// Pure insertion: only the header says where
+		pos := expected + 1
+		if h.oldStart == 0 {
+			pos = 0
+		}
+		if pos < from || pos > len(lines) {
+			return 0, fmt.Errorf("insertion point line %d is out of range", h.oldStart)
+		}
+		return pos, nil
+	}
+
+	if expected >= from && matchesAt(lines, h.old, expected) {
+		return expected, nil
+	}
+
+	var found []int
+	for pos := from; pos+len(h.old) <= len(lines); pos++ {
+		if matchesAt(lines, h.old, pos) {
+			found = append(found, pos)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return 0, fmt.Errorf("context and removed lines not found in file (near line %d)", h.oldStart)
+	case 1:
+		return found[0], nil
+	default:
+		return 0, fmt.Errorf("context matches %d places; add more context lines", len(found))
+	}
+}
+
+func matchesAt(lines, block []string, pos int) bool {
+	if pos < 0 || pos+len(block) > len(lines) {
+		return false
+	}
+	for i, line := range block {
+		if lines[pos+i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// splitLines splits s into lines without a trailing empty element
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,61 @@
+package editfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	contextLines   = 3
+	maxPreviewSize = 20 * 1024 // 20KB
+)
+
+// preview renders the change from before to after as a single-hunk unified
+// diff covering everything between the first and last changed line
+func preview(path, before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	start := max(prefix-contextLines, 0)
+	aEnd := min(len(a)-suffix+contextLines, len(a))
+	bEnd := min(len(b)-suffix+contextLines, len(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", path, path)
+	fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(start, aEnd-start), hunkRange(start, bEnd-start))
+	for _, line := range a[start:prefix] {
+		sb.WriteString(" " + line + "\n")
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		sb.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		sb.WriteString("+" + line + "\n")
+	}
+	for _, line := range a[len(a)-suffix : aEnd] {
+		sb.WriteString(" " + line + "\n")
+	}
+
+	out := sb.String()
+	if len(out) > maxPreviewSize {
+		out = out[:maxPreviewSize] + "\n... (preview truncated at 20KB)"
+	}
+	return out
+}
+
+// hunkRange formats a hunk header range; empty ranges point at the line
+// before them, as diff does
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
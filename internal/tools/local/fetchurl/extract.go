@@ -0,0 +1,188 @@
+package fetchurl
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped are elements whose content is never readable text
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Object: true, atom.Canvas: true,
+	atom.Head: true, atom.Nav: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Select: true,
+}
+
+// blocks are elements that start on a new line
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Header: true, atom.Br: true, atom.Hr: true, atom.Ul: true, atom.Ol: true, atom.Li: true,
+	atom.Table: true, atom.Tr: true, atom.Blockquote: true, atom.Pre: true, atom.Dl: true,
+	atom.Dt: true, atom.Dd: true, atom.Figure: true, atom.Figcaption: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+var headings = map[atom.Atom]string{
+	atom.H1: "# ", atom.H2: "## ", atom.H3: "### ", atom.H4: "#### ", atom.H5: "##### ", atom.H6: "###### ",
+}
+
+// extract returns the title and readable text of an HTML page: the main
+// or article element if there is one, else the body, without scripts,
+// styles, navigation, or forms. Headings keep a Markdown # prefix, list
+// items a -, and pre blocks their whitespace.
+func extract(r io.Reader) (title, text string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", err
+	}
+	if t := find(doc, atom.Title); t != nil {
+		title = strings.Join(strings.Fields(textOf(t)), " ")
+	}
+	root := find(doc, atom.Main)
+	if root == nil {
+		root = find(doc, atom.Article)
+	}
+	if root == nil {
+		root = find(doc, atom.Body)
+	}
+	if root == nil {
+		root = doc
+	}
+	w := &writer{}
+	w.walk(root)
+	return title, strings.TrimSpace(w.b.String()), nil
+}
+
+// find returns the first element a in n's tree
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
+
+// writer collects text, collapsing whitespace outside pre blocks and
+// keeping at most one blank line between blocks
+type writer struct {
+	b         strings.Builder
+	pre       int
+	space     bool // A space is owed before the next word
+	lineStart bool // Nothing but a prefix has been written on the line
+}
+
+func (w *writer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] || hidden(n) {
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blocks[n.DataAtom]
+	if block {
+		w.newline()
+		if n.DataAtom == atom.P || headings[n.DataAtom] != "" || n.DataAtom == atom.Pre {
+			w.blankLine()
+		}
+		switch {
+		case headings[n.DataAtom] != "":
+			w.prefix(headings[n.DataAtom])
+		case n.DataAtom == atom.Li:
+			w.prefix("- ")
+		case n.DataAtom == atom.Pre:
+			w.pre++
+			defer func() { w.pre-- }()
+		}
+	}
+	if n.Type == html.ElementNode && (n.DataAtom == atom.Td || n.DataAtom == atom.Th) && n.PrevSibling != nil {
+		w.prefix(" | ")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+	if block {
+		w.newline()
+	}
+}
+
+func (w *writer) text(s string) {
+	if w.pre > 0 {
+		w.b.WriteString(s)
+		w.lineStart = strings.HasSuffix(s, "\n")
+		return
+	}
+	if s != "" && isSpace(s[0]) {
+		w.space = true
+	}
+	for i, word := range strings.Fields(s) {
+		if (i > 0 || w.space) && !w.lineStart && w.b.Len() > 0 {
+			w.b.WriteByte(' ')
+		}
+		w.b.WriteString(word)
+		w.space = false
+		w.lineStart = false
+	}
+	if s != "" && isSpace(s[len(s)-1]) {
+		w.space = true
+	}
+}
+
+// prefix writes s before the next word, such as a list marker
+func (w *writer) prefix(s string) {
+	w.b.WriteString(s)
+	w.space = false
+	w.lineStart = true
+}
+
+func (w *writer) newline() {
+	w.space = false
+	w.lineStart = true
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		w.b.WriteByte('\n')
+	}
+}
+
+func (w *writer) blankLine() {
+	if s := w.b.String(); s != "" && !strings.HasSuffix(s, "\n\n") {
+		w.b.WriteByte('\n')
+	}
+}
+
+// hidden reports elements the page hides from readers
+func hidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch {
+		case a.Key == "hidden", a.Key == "aria-hidden" && a.Val == "true":
+			return true
+		case a.Key == "style" && strings.Contains(strings.ReplaceAll(a.Val, " ", ""), "display:none"):
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
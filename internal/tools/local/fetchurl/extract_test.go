@@ -0,0 +1,54 @@
+package fetchurl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		html      string
+		wantTitle string
+		want      string
+	}{
+		{
+			name: "main content without chrome",
+			html: `<html><head><title> Upgrading
+				etcd </title><style>p{}</style></head><body>
+				<nav><a href="/">Home</a></nav>
+				<main><h1>Upgrade guide</h1><p>Back up <b>first</b>,
+				then   upgrade.</p><script>track()</script>
+				<ul><li>Check <code>etcdctl</code></li><li>Restart</li></ul></main>
+				<footer>© 2026</footer></body></html>`,
+			wantTitle: "Upgrading etcd",
+			want:      "# Upgrade guide\n\nBack up first, then upgrade.\n- Check etcdctl\n- Restart",
+		},
+		{
+			name: "pre keeps whitespace",
+			html: "<body><p>Run:</p><pre>kubectl get pods\n  -n kube-system</pre></body>",
+			want: "Run:\n\nkubectl get pods\n  -n kube-system",
+		},
+		{
+			name: "tables and hidden elements",
+			html: `<body><table><tr><th>Version</th><th>Status</th></tr><tr><td>1.29</td><td>EOL</td></tr></table>
+				<div hidden>secret</div><p style="display: none">also hidden</p><span>a - b</span></body>`,
+			want: "Version | Status\n1.29 | EOL\na - b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, text, err := extract(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("extract() error = %v", err)
+			}
+			if title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", title, tt.wantTitle)
+			}
+			if text != tt.want {
+				t.Errorf("text = %q, want %q", text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Package fetchurl downloads web pages and reduces them to readable text
+package fetchurl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+const (
+	requestTimeout   = 30 * time.Second
+	maxRedirects     = 5
+	maxBodySize      = 5 * 1024 * 1024 // 5MB
+	defaultMaxLength = 20000           // characters
+	maxLength        = 100000
+	userAgent        = "joe/fetch_url (+https://github.com/jaimegago/joe)"
+)
+
+// errPrivate is returned when a host resolves to an address that
+// WithPrivateNetworks hasn't allowed
+var errPrivate = errors.New("private, loopback, and link-local addresses are not allowed")
+
+type Tool struct {
+	allowed      []string
+	denied       []string
+	allowPrivate bool
+	client       *http.Client
+}
+
+// Option configures optional Tool behavior
+type Option func(*Tool)
+
+// WithAllowedDomains limits fetching to domains and their subdomains.
+// Without it, any domain not denied may be fetched.
+func WithAllowedDomains(domains []string) Option {
+	return func(t *Tool) {
+		t.allowed = normalize(domains)
+	}
+}
+
+// WithDeniedDomains refuses domains and their subdomains, even if allowed
+func WithDeniedDomains(domains []string) Option {
+	return func(t *Tool) {
+		t.denied = normalize(domains)
+	}
+}
+
+// WithPrivateNetworks allows hosts that resolve to loopback, private, or
+// link-local addresses, such as internal wikis. Those include cloud
+// metadata endpoints, so it is off by default.
+func WithPrivateNetworks(allow bool) Option {
+	return func(t *Tool) {
+		t.allowPrivate = allow
+	}
+}
+
+func New(opts ...Option) *Tool {
+	t := &Tool{}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	// Addresses are checked as they are dialed, so neither redirects nor
+	// DNS answers can reach a private address
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if !t.allowPrivate && (ip == nil || private(ip)) {
+				return fmt.Errorf("%s: %w", host, errPrivate)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !t.allowPrivate {
+		transport.Proxy = nil // A proxy would dial for us, unchecked
+	}
+	transport.DialContext = dialer.DialContext
+	t.client = &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return t.check(req.URL)
+		},
+	}
+	return t
+}
+
+func (t *Tool) Name() string {
+	return "fetch_url"
+}
+
+func (t *Tool) Description() string {
+	description := "Fetch a web page or text document over HTTP(S) and return its readable text (scripts, styles, and navigation removed; headings and lists kept), e.g. to read documentation, a changelog, or a status page."
+	if len(t.allowed) > 0 {
+		description += " Only these domains and their subdomains may be fetched: " + strings.Join(t.allowed, ", ") + "."
+	}
+	return description
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"url": {
+				Type:        "string",
+				Description: "http or https URL to fetch",
+			},
+			"max_length": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Most characters of text to return (default %d, max %d)", defaultMaxLength, maxLength),
+			},
+			"offset": {
+				Type:        "integer",
+				Description: "Characters of text to skip, to read on from a truncated result (optional)",
+			},
+		},
+		Required: []string{"url"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	rawURL, ok := args["url"].(string)
+	if !ok || rawURL == "" {
+		return nil, fmt.Errorf("url parameter is required and must be a string")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := t.check(u); err != nil {
+		return nil, err
+	}
+	limit := defaultMaxLength
+	if n, ok := args["max_length"].(float64); ok && n > 0 {
+		limit = int(min(n, maxLength)) // Clamped first: converting a huge float could overflow
+	}
+	offset := 0
+	if n, ok := args["offset"].(float64); ok && n > 0 {
+		offset = int(min(n, math.MaxInt32))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html, text/plain, application/json, */*;q=0.5")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch %s: %s", u.Redacted(), resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", u.Redacted(), err)
+	}
+	bodyTruncated := len(body) > maxBodySize
+	body = body[:min(len(body), maxBodySize)]
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	var title, text string
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		if title, text, err = extract(bytes.NewReader(body)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", u.Redacted(), err)
+		}
+	case textual(mediaType) && utf8.Valid(body):
+		text = string(body)
+	default:
+		return nil, fmt.Errorf("%s is %s, not a page or text document", u.Redacted(), mediaType)
+	}
+
+	runes := []rune(text)
+	total := len(runes)
+	start := min(offset, total)
+	end := min(start+limit, total)
+	result := map[string]any{
+		"url":          resp.Request.URL.Redacted(), // After redirects
+		"status":       resp.StatusCode,
+		"content_type": mediaType,
+		"text":         string(runes[start:end]),
+		"length":       total,
+	}
+	if title != "" {
+		result["title"] = title
+	}
+	if end < total {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Showing characters %d-%d of %d. Use offset %d for more.", start, end, total, end)
+	} else if bodyTruncated {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("The page is larger than %dMB; only its start was read.", maxBodySize/(1024*1024))
+	}
+	return result, nil
+}
+
+// check returns an error unless u is an http(s) URL on a domain that may be
+// fetched
+func (t *Tool) check(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("only http and https URLs may be fetched, not %q", u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("url %q has no host", u.Redacted())
+	}
+	if matches(host, t.denied) {
+		return fmt.Errorf("fetching from %s is not allowed", host)
+	}
+	if len(t.allowed) > 0 && !matches(host, t.allowed) {
+		return fmt.Errorf("fetching from %s is not allowed. Allowed domains: %s", host, strings.Join(t.allowed, ", "))
+	}
+	return nil
+}
+
+// matches reports whether host is one of domains or a subdomain of one
+func matches(host string, domains []string) bool {
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(domains []string) []string {
+	var out []string
+	for _, d := range domains {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "*.")
+		if d = strings.Trim(d, "."); d != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func private(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsInterfaceLocalMulticast()
+}
+
+// textual reports media types worth returning as they are
+func textual(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json", mediaType == "application/xml", mediaType == "application/yaml",
+		mediaType == "application/x-yaml", mediaType == "application/javascript",
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
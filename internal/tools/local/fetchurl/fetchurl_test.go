@@ -0,0 +1,148 @@
+package fetchurl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTool_Execute(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><title>Runbook</title></head><body><article><h2>Restart</h2><p>Drain the node first.</p></article></body></html>"))
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("0123456789"))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs", http.StatusFound)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		args          map[string]any
+		wantText      string
+		wantTitle     string
+		wantTruncated bool
+		wantErr       string
+	}{
+		{
+			name:      "html page",
+			args:      map[string]any{"url": server.URL + "/docs"},
+			wantText:  "## Restart\n\nDrain the node first.",
+			wantTitle: "Runbook",
+		},
+		{
+			name:      "follows redirects",
+			args:      map[string]any{"url": server.URL + "/moved"},
+			wantText:  "## Restart\n\nDrain the node first.",
+			wantTitle: "Runbook",
+		},
+		{
+			name:          "text paged by offset",
+			args:          map[string]any{"url": server.URL + "/notes.txt", "offset": float64(2), "max_length": float64(5)},
+			wantText:      "23456",
+			wantTruncated: true,
+		},
+		{
+			name:     "huge offset and length",
+			args:     map[string]any{"url": server.URL + "/notes.txt", "offset": 1e300, "max_length": 1e300},
+			wantText: "",
+		},
+		{
+			name:    "binary content",
+			args:    map[string]any{"url": server.URL + "/image.png"},
+			wantErr: "image/png, not a page",
+		},
+		{
+			name:    "error status",
+			args:    map[string]any{"url": server.URL + "/missing"},
+			wantErr: "404 Not Found",
+		},
+		{
+			name:    "private address refused by default",
+			opts:    []Option{WithPrivateNetworks(false)},
+			args:    map[string]any{"url": server.URL + "/docs"},
+			wantErr: "private, loopback, and link-local addresses are not allowed",
+		},
+		{
+			name:    "scheme",
+			args:    map[string]any{"url": "file:///etc/passwd"},
+			wantErr: `only http and https URLs may be fetched, not "file"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithPrivateNetworks(true)}, tt.opts...)
+			got, err := New(opts...).Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			result := got.(map[string]any)
+			if result["text"] != tt.wantText {
+				t.Errorf("text = %q, want %q", result["text"], tt.wantText)
+			}
+			if title, _ := result["title"].(string); title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", title, tt.wantTitle)
+			}
+			if truncated, _ := result["truncated"].(bool); truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestTool_check(t *testing.T) {
+	tool := New(WithAllowedDomains([]string{"kubernetes.io", "*.example.com"}), WithDeniedDomains([]string{"internal.example.com"}))
+
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{url: "https://kubernetes.io/docs/"},
+		{url: "https://KUBERNETES.IO./docs/"},
+		{url: "https://docs.example.com/a"},
+		{url: "https://example.com/a"},
+		{url: "https://notkubernetes.io/", wantErr: "Allowed domains: kubernetes.io, example.com"},
+		{url: "https://wiki.internal.example.com/", wantErr: "fetching from wiki.internal.example.com is not allowed"},
+		{url: "ftp://kubernetes.io/", wantErr: "only http and https"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tool.check(u)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("check() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
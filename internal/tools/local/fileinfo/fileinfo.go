@@ -0,0 +1,213 @@
+package fileinfo
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	sniffSize      = 512  // bytes read to detect the type
+	defaultHexSize = 256  // bytes dumped when hexdump is set without length
+	maxHexSize     = 4096 // bytes dumped at most
+)
+
+type Tool struct{}
+
+func New() *Tool {
+	return &Tool{}
+}
+
+func (t *Tool) Name() string {
+	return "file_info"
+}
+
+func (t *Tool) Description() string {
+	return "Describe a file without reading it as text: size, detected type (e.g. gzip, tar, ELF executable, core dump), permissions, and modification time. Set hexdump to see a bounded hex dump of its bytes. Use this for binary files read_file refuses, such as core dumps or tarballs."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "Path to file (absolute or relative to current directory, ~ expands to home directory)",
+			},
+			"hexdump": {
+				Type:        "boolean",
+				Description: "Include a hex dump of the file's bytes (optional)",
+			},
+			"offset": {
+				Type:        "integer",
+				Description: "Byte to start the hex dump at (optional, default 0)",
+			},
+			"length": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Bytes to dump (optional, default %d, max %d)", defaultHexSize, maxHexSize),
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pathArg, ok := args["path"].(string)
+	if !ok || pathArg == "" {
+		return nil, fmt.Errorf("path parameter is required and must be a string")
+	}
+	absPath, err := local.ExpandPath(pathArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", absPath)
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	result := map[string]any{
+		"path":        absPath,
+		"size_bytes":  info.Size(),
+		"mode":        info.Mode().String(),
+		"permissions": fmt.Sprintf("%04o", info.Mode().Perm()),
+		"modified":    info.ModTime().UTC().Format(time.RFC3339),
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		result["type"] = "symlink"
+		if target, err := os.Readlink(absPath); err == nil {
+			result["target"] = target
+		}
+		return result, nil
+	case info.IsDir():
+		result["type"] = "directory"
+		return result, nil
+	case !info.Mode().IsRegular():
+		result["type"] = "special file"
+		return result, nil
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, fmt.Errorf("permission denied: %s", absPath)
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+	result["type"], result["mime_type"] = detect(absPath, head)
+
+	if dump, _ := args["hexdump"].(bool); dump {
+		offset := int64(0)
+		if v, ok := args["offset"].(float64); ok && v > 0 {
+			offset = int64(min(v, 1<<53))
+		}
+		length := int64(defaultHexSize)
+		if v, ok := args["length"].(float64); ok && v > 0 {
+			length = int64(min(v, maxHexSize))
+		}
+		if offset >= info.Size() {
+			return nil, fmt.Errorf("offset %d is past the end of the file (%d bytes)", offset, info.Size())
+		}
+		buf := make([]byte, min(length, info.Size()-offset))
+		if _, err := f.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		result["hexdump"] = hexDump(buf, offset)
+		result["hexdump_range"] = fmt.Sprintf("%d-%d", offset, offset+int64(len(buf)))
+	}
+	return result, nil
+}
+
+// magic are signatures http.DetectContentType doesn't know, checked first
+var magic = []struct {
+	offset int
+	sig    []byte
+	kind   string
+	mime   string
+}{
+	{0, []byte("\x7fELF"), "ELF", "application/x-elf"},
+	{257, []byte("ustar"), "tar archive", "application/x-tar"},
+	{0, []byte("\x28\xb5\x2f\xfd"), "zstd compressed data", "application/zstd"},
+	{0, []byte("\xfd7zXZ\x00"), "xz compressed data", "application/x-xz"},
+	{0, []byte("BZh"), "bzip2 compressed data", "application/x-bzip2"},
+	{0, []byte("SQLite format 3\x00"), "SQLite database", "application/vnd.sqlite3"},
+}
+
+// elfTypes names ELF files by their e_type
+var elfTypes = map[byte]string{1: "ELF relocatable object", 2: "ELF executable", 3: "ELF shared object", 4: "ELF core dump"}
+
+// detect names the type of a file from its first bytes, falling back to
+// its extension
+func detect(path string, head []byte) (kind, mimeType string) {
+	for _, m := range magic {
+		if len(head) >= m.offset+len(m.sig) && bytes.Equal(head[m.offset:m.offset+len(m.sig)], m.sig) {
+			kind = m.kind
+			if kind == "ELF" && len(head) > 17 {
+				// e_type is 2 bytes at 16; byte 5 says which end is low
+				low := head[16]
+				if head[5] == 2 {
+					low = head[17]
+				}
+				if name, ok := elfTypes[low]; ok {
+					kind = name
+				}
+			}
+			return kind, m.mime
+		}
+	}
+	if len(head) == 0 {
+		return "empty", "application/octet-stream"
+	}
+
+	mimeType = http.DetectContentType(head)
+	if mimeType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(path)); byExt != "" {
+			mimeType = byExt
+		}
+	}
+	kind = "binary"
+	if bytes.IndexByte(head, 0) < 0 && bytes.HasPrefix([]byte(mimeType), []byte("text/")) {
+		kind = "text"
+	}
+	switch mimeType {
+	case "application/x-gzip":
+		kind = "gzip compressed data"
+	case "application/zip":
+		kind = "zip archive"
+	case "application/pdf":
+		kind = "PDF document"
+	}
+	return kind, mimeType
+}
+
+// hexDump formats data like hexdump -C, addressed from offset
+func hexDump(data []byte, offset int64) string {
+	var b bytes.Buffer
+	for i := 0; i < len(data); i += 16 {
+		line := hex.Dump(data[i:min(i+16, len(data))])
+		// hex.Dump addresses from 0; replace its 8-digit address
+		fmt.Fprintf(&b, "%08x%s", offset+int64(i), line[8:])
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package fileinfo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("hello"))
+	w.Close()
+
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar")
+
+	elfCore := append([]byte("\x7fELF\x02\x01\x01"), make([]byte, 9)...)
+	elfCore = append(elfCore, 4, 0)
+
+	tests := []struct {
+		name     string
+		path     string
+		head     []byte
+		wantKind string
+		wantMime string
+	}{
+		{"text", "notes.txt", []byte("hello\n"), "text", "text/plain; charset=utf-8"},
+		{"gzip", "logs.gz", gz.Bytes(), "gzip compressed data", "application/x-gzip"},
+		{"tar", "backup.tar", tar, "tar archive", "application/x-tar"},
+		{"core dump", "core.1234", elfCore, "ELF core dump", "application/x-elf"},
+		{"unknown binary", "blob", []byte{0x01, 0x02, 0x00, 0xff}, "binary", "application/octet-stream"},
+		{"empty", "empty.log", nil, "empty", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, mimeType := detect(tt.path, tt.head)
+			if kind != tt.wantKind || mimeType != tt.wantMime {
+				t.Errorf("detect() = %q, %q; want %q, %q", kind, mimeType, tt.wantKind, tt.wantMime)
+			}
+		})
+	}
+}
+
+func TestTool_Execute(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	data := make([]byte, 5000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		args      map[string]any
+		wantDump  string // prefix of the hex dump; "" for none
+		wantLines int
+		wantErr   bool
+	}{
+		{name: "info only", args: map[string]any{}},
+		{name: "default dump", args: map[string]any{"hexdump": true}, wantDump: "00000000  00 01 02 03", wantLines: 16},
+		{name: "dump at offset", args: map[string]any{"hexdump": true, "offset": float64(32), "length": float64(20)}, wantDump: "00000020  20 21 22 23", wantLines: 2},
+		{name: "dump capped", args: map[string]any{"hexdump": true, "length": float64(1 << 20)}, wantDump: "00000000", wantLines: maxHexSize / 16},
+		{name: "offset past the end", args: map[string]any{"hexdump": true, "offset": float64(6000)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["path"] = path
+			got, err := New().Execute(context.Background(), tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			result := got.(map[string]any)
+			if result["size_bytes"] != int64(5000) || result["permissions"] != "0600" || result["type"] != "binary" {
+				t.Errorf("Execute() = %v, want a 5000-byte 0600 binary", result)
+			}
+			dump, _ := result["hexdump"].(string)
+			if !strings.HasPrefix(dump, tt.wantDump) {
+				t.Errorf("hexdump = %q, want prefix %q", dump, tt.wantDump)
+			}
+			if lines := strings.Count(dump, "\n"); lines != tt.wantLines {
+				t.Errorf("hexdump has %d lines, want %d", lines, tt.wantLines)
+			}
+		})
+	}
+
+	got, err := New().Execute(context.Background(), map[string]any{"path": dir})
+	if err != nil || got.(map[string]any)["type"] != "directory" {
+		t.Errorf("Execute() of a directory = %v, %v; want type directory", got, err)
+	}
+}
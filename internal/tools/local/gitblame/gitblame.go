@@ -0,0 +1,169 @@
+package gitblame
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const maxLines = 500
+
+type Tool struct{}
+
+type Line struct {
+	Line    int    `json:"line"`
+	Commit  string `json:"commit"`
+	Author  string `json:"author"`
+	Date    string `json:"date"`
+	Summary string `json:"summary"`
+	Content string `json:"content"`
+}
+
+func New() *Tool {
+	return &Tool{}
+}
+
+func (t *Tool) Name() string {
+	return "local_git_blame"
+}
+
+func (t *Tool) Description() string {
+	return "Show who last changed each line of a file, with commit, author, date, and commit summary. Use start_line and end_line to focus on specific lines."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "File to blame (~ expands to home directory)",
+			},
+			"start_line": {
+				Type:        "integer",
+				Description: "First line to blame (1-based, optional)",
+			},
+			"end_line": {
+				Type:        "integer",
+				Description: "Last line to blame (inclusive, optional)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pathArg, ok := args["path"].(string)
+	if !ok || pathArg == "" {
+		return nil, fmt.Errorf("path parameter is required")
+	}
+	absPath, err := local.ExpandPath(pathArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	start, _ := args["start_line"].(float64)
+	end, _ := args["end_line"].(float64)
+	if start < 0 || end < 0 || (end > 0 && end < start) {
+		return nil, fmt.Errorf("invalid line range %v-%v", start, end)
+	}
+	start, end = min(start, math.MaxInt32), min(end, math.MaxInt32)
+
+	gitArgs := []string{"blame", "--porcelain"}
+	switch {
+	case start > 0 && end > 0:
+		gitArgs = append(gitArgs, fmt.Sprintf("-L%d,%d", int(start), int(end)))
+	case start > 0:
+		gitArgs = append(gitArgs, fmt.Sprintf("-L%d,+%d", int(start), maxLines))
+	case end > 0:
+		gitArgs = append(gitArgs, fmt.Sprintf("-L1,%d", int(end)))
+	}
+	gitArgs = append(gitArgs, "--", absPath)
+
+	output, err := local.RunGit(ctx, filepath.Dir(absPath), gitArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := parsePorcelain(output)
+	truncated := false
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		truncated = true
+	}
+
+	result := map[string]any{
+		"path":      absPath,
+		"lines":     lines,
+		"truncated": truncated,
+	}
+	if truncated {
+		result["truncated_message"] = fmt.Sprintf("Only the first %d lines are shown. Use start_line and end_line to blame other lines.", maxLines)
+	}
+	return result, nil
+}
+
+// parsePorcelain parses git blame --porcelain output. Commit details are
+// only printed the first time a commit appears, so they're remembered by hash.
+func parsePorcelain(output string) []Line {
+	type commitInfo struct {
+		author  string
+		date    string
+		summary string
+	}
+	commits := make(map[string]*commitInfo)
+
+	lines := []Line{}
+	var current *Line
+	var info *commitInfo
+	for _, raw := range strings.Split(output, "\n") {
+		if current == nil {
+			// Header: <hash> <orig-line> <final-line> [<group-size>]
+			fields := strings.Fields(raw)
+			if len(fields) < 3 {
+				continue
+			}
+			lineNo, err := strconv.Atoi(fields[2])
+			if err != nil {
+				continue
+			}
+			hash := fields[0]
+			if commits[hash] == nil {
+				commits[hash] = &commitInfo{}
+			}
+			info = commits[hash]
+			current = &Line{Line: lineNo, Commit: hash[:min(len(hash), 12)]}
+			continue
+		}
+
+		if content, ok := strings.CutPrefix(raw, "\t"); ok {
+			current.Author = info.author
+			current.Date = info.date
+			current.Summary = info.summary
+			current.Content = content
+			lines = append(lines, *current)
+			current = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(raw, " ")
+		switch key {
+		case "author":
+			info.author = value
+		case "author-time":
+			if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+				info.date = time.Unix(sec, 0).UTC().Format(time.RFC3339)
+			}
+		case "summary":
+			info.summary = value
+		}
+	}
+	return lines
+}
@@ -0,0 +1,27 @@
+package gitblame
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePorcelain(t *testing.T) {
+	// The second line reuses the first commit, so its details are omitted
+	output := "0123456789abcdef0123456789abcdef01234567 1 1 2\n" +
+		"author Ana\n" +
+		"author-mail <ana@example.com>\n" +
+		"author-time 1709370000\n" +
+		"summary Raise timeout\n" +
+		"filename config.yaml\n" +
+		"\ttimeout: 30s\n" +
+		"0123456789abcdef0123456789abcdef01234567 2 2\n" +
+		"\tretries: 3\n"
+
+	want := []Line{
+		{Line: 1, Commit: "0123456789ab", Author: "Ana", Date: "2024-03-02T09:00:00Z", Summary: "Raise timeout", Content: "timeout: 30s"},
+		{Line: 2, Commit: "0123456789ab", Author: "Ana", Date: "2024-03-02T09:00:00Z", Summary: "Raise timeout", Content: "retries: 3"},
+	}
+	if got := parsePorcelain(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePorcelain() = %+v, want %+v", got, want)
+	}
+}
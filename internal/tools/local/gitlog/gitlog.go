@@ -0,0 +1,152 @@
+package gitlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 200
+
+	// Record and field separators for the log format, so subjects and
+	// file names can't be confused with the structure
+	recordSep = "\x1e"
+	fieldSep  = "\x1f"
+)
+
+type Tool struct{}
+
+type Commit struct {
+	Hash    string   `json:"hash"`
+	Author  string   `json:"author"`
+	Email   string   `json:"email"`
+	Date    string   `json:"date"`
+	Subject string   `json:"subject"`
+	Files   []string `json:"files"`
+}
+
+func New() *Tool {
+	return &Tool{}
+}
+
+func (t *Tool) Name() string {
+	return "local_git_log"
+}
+
+func (t *Tool) Description() string {
+	return "Get recent git commits (hash, author, date, subject, files changed), optionally only those touching a file or directory. Use this to find who changed something and when."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "Only commits touching this file or directory (optional, defaults to the whole repository of the current working directory)",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Maximum number of commits (default %d, max %d)", defaultLimit, maxLimit),
+			},
+			"since": {
+				Type:        "string",
+				Description: "Only commits after this date, e.g. 2024-03-01 or '2 weeks ago'",
+			},
+			"author": {
+				Type:        "string",
+				Description: "Only commits whose author name or email contains this text",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	limit := defaultLimit
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(min(n, maxLimit))
+	}
+
+	gitArgs := []string{
+		"log",
+		fmt.Sprintf("--max-count=%d", limit),
+		"--name-only",
+		"--format=" + recordSep + strings.Join([]string{"%H", "%an", "%ae", "%aI", "%s"}, fieldSep),
+	}
+	if since, ok := args["since"].(string); ok && since != "" {
+		gitArgs = append(gitArgs, "--since="+since)
+	}
+	if author, ok := args["author"].(string); ok && author != "" {
+		gitArgs = append(gitArgs, "--author="+author)
+	}
+
+	// Run git next to the path so files outside the CWD's repository work
+	if pathArg, ok := args["path"].(string); ok && pathArg != "" {
+		absPath, err := local.ExpandPath(pathArg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand path: %w", err)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat path: %w", err)
+		}
+		dir = absPath
+		if !info.IsDir() {
+			dir = filepath.Dir(absPath)
+		}
+		gitArgs = append(gitArgs, "--", absPath)
+	}
+
+	output, err := local.RunGit(ctx, dir, gitArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := parseLog(output)
+	return map[string]any{
+		"commits": commits,
+		"count":   len(commits),
+	}, nil
+}
+
+// parseLog parses the record/field separated output of git log --name-only
+func parseLog(output string) []Commit {
+	commits := []Commit{}
+	for _, record := range strings.Split(output, recordSep) {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.Split(lines[0], fieldSep)
+		if len(fields) != 5 {
+			continue
+		}
+
+		files := []string{}
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				files = append(files, line)
+			}
+		}
+
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    fields[3],
+			Subject: fields[4],
+			Files:   files,
+		})
+	}
+	return commits
+}
@@ -0,0 +1,19 @@
+package gitlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	output := "\x1eabc123\x1fAna\x1fana@example.com\x1f2024-03-02T09:00:00+00:00\x1fRaise timeout\n\nconfig.yaml\ndeploy/app.yaml\n" +
+		"\x1edef456\x1fBo\x1fbo@example.com\x1f2024-03-01T08:00:00+00:00\x1fEmpty commit\n"
+
+	want := []Commit{
+		{Hash: "abc123", Author: "Ana", Email: "ana@example.com", Date: "2024-03-02T09:00:00+00:00", Subject: "Raise timeout", Files: []string{"config.yaml", "deploy/app.yaml"}},
+		{Hash: "def456", Author: "Bo", Email: "bo@example.com", Date: "2024-03-01T08:00:00+00:00", Subject: "Empty commit", Files: []string{}},
+	}
+	if got := parseLog(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLog() = %+v, want %+v", got, want)
+	}
+}
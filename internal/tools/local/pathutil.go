@@ -21,3 +21,44 @@ func ExpandPath(path string) (string, error) {
 	}
 	return filepath.Abs(path)
 }
+
+// PathAllowed reports whether path is one of prefixes or inside one. An
+// empty prefix list allows every path. Symlinks in the existing part of
+// either path are resolved so a link can't be used to escape a prefix.
+func PathAllowed(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	resolved := resolveExisting(path)
+	for _, prefix := range prefixes {
+		expanded, err := ExpandPath(prefix)
+		if err != nil {
+			continue
+		}
+		expanded = resolveExisting(expanded)
+		rel, err := filepath.Rel(expanded, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveExisting resolves symlinks in the longest existing ancestor of an
+// absolute path and appends the remaining, not yet created, elements
+func resolveExisting(path string) string {
+	path = filepath.Clean(path)
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				real = filepath.Join(real, missing[i])
+			}
+			return real
+		}
+		if filepath.Dir(dir) == dir {
+			return path
+		}
+		missing = append(missing, filepath.Base(dir))
+	}
+}
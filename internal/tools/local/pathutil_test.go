@@ -0,0 +1,42 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathAllowed(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "work")
+	if err := os.Mkdir(allowed, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A link inside the allowed directory that points outside it
+	if err := os.Symlink(root, filepath.Join(allowed, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		prefixes []string
+		want     bool
+	}{
+		{"no prefixes allows everything", "/etc/passwd", nil, true},
+		{"prefix itself", allowed, []string{allowed}, true},
+		{"new file inside prefix", filepath.Join(allowed, "a", "b.txt"), []string{allowed}, true},
+		{"sibling with same name prefix", allowed + "2/file", []string{allowed}, false},
+		{"parent traversal", filepath.Join(allowed, "..", "secret"), []string{allowed}, false},
+		{"symlink escape", filepath.Join(allowed, "escape", "secret"), []string{allowed}, false},
+		{"second prefix", "/etc/hosts", []string{allowed, "/etc"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PathAllowed(tt.path, tt.prefixes); got != tt.want {
+				t.Errorf("PathAllowed(%q, %v) = %v, want %v", tt.path, tt.prefixes, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,24 @@
 package readfile
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"strings"
 
 	"github.com/jaimegago/joe/internal/llm"
 	"github.com/jaimegago/joe/internal/tools/local"
 )
 
-const maxFileSize = 1 * 1024 * 1024 // 1MB
+const (
+	maxFileSize  = 1 * 1024 * 1024 // 1MB
+	maxLines     = 2000            // per call when reading a line range
+	maxRangeSize = maxFileSize     // bytes per call when reading a line range
+)
 
 type Tool struct{}
 
@@ -22,7 +31,7 @@ func (t *Tool) Name() string {
 }
 
 func (t *Tool) Description() string {
-	return "Read contents of a file from the local filesystem. Use this to read configuration files, source code, or any text files the user asks about."
+	return "Read contents of a file from the local filesystem. Use this to read configuration files, source code, or any text files the user asks about. For large files such as logs, read a range with start_line and end_line; ranges come back with line numbers."
 }
 
 func (t *Tool) Parameters() llm.ParameterSchema {
@@ -33,6 +42,14 @@ func (t *Tool) Parameters() llm.ParameterSchema {
 				Type:        "string",
 				Description: "Path to file (absolute or relative to current directory, ~ expands to home directory)",
 			},
+			"start_line": {
+				Type:        "integer",
+				Description: "First line to read (1-based, optional)",
+			},
+			"end_line": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Last line to read (inclusive, optional; at most %d lines are returned per call)", maxLines),
+			},
 		},
 		Required: []string{"path"},
 	}
@@ -67,10 +84,20 @@ func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
 		return nil, fmt.Errorf("path is a directory, not a file: %s", absPath)
 	}
 
+	start, _ := args["start_line"].(float64)
+	end, _ := args["end_line"].(float64)
+	if start < 0 || end < 0 || (end > 0 && end < start) {
+		return nil, fmt.Errorf("invalid line range %v-%v", start, end)
+	}
+	start, end = min(start, math.MaxInt32), min(end, math.MaxInt32)
+	if start > 0 || end > 0 {
+		return readRange(absPath, max(int(start), 1), int(end))
+	}
+
 	// Check file size
 	if info.Size() > maxFileSize {
 		sizeMB := float64(info.Size()) / (1024 * 1024)
-		return nil, fmt.Errorf("file too large (%.1fMB), max 1MB supported", sizeMB)
+		return nil, fmt.Errorf("file too large (%.1fMB), max 1MB at once; read it in parts with start_line and end_line", sizeMB)
 	}
 
 	// Read file
@@ -81,7 +108,7 @@ func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
 
 	// Check if binary
 	if isBinary(data) {
-		return nil, fmt.Errorf("file appears to be binary, not text: %s", absPath)
+		return nil, fmt.Errorf("file appears to be binary, not text: %s (file_info can describe it)", absPath)
 	}
 
 	return map[string]any{
@@ -91,6 +118,67 @@ func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	}, nil
 }
 
+// readRange reads lines start to end (0 for as many as allowed) of the file
+// at path, numbering them. The whole file is scanned to count its lines, so
+// the caller knows how far it has to go.
+func readRange(path string, start, end int) (any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if head, _ := r.Peek(512); isBinary(head) {
+		return nil, fmt.Errorf("file appears to be binary, not text: %s (file_info can describe it)", path)
+	}
+
+	var content strings.Builder
+	last := 0     // last line included
+	total := 0    // lines in the file
+	full := false // the range was cut short by the limits
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			total++
+			inRange := total >= start && (end == 0 || total <= end)
+			if inRange && !full {
+				if total-start >= maxLines || content.Len()+len(line) > maxRangeSize {
+					full = true
+				} else {
+					fmt.Fprintf(&content, "%6d\t%s", total, line)
+					last = total
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+	if last > 0 && !strings.HasSuffix(content.String(), "\n") {
+		content.WriteString("\n")
+	}
+
+	result := map[string]any{
+		"path":        path,
+		"content":     content.String(),
+		"start_line":  start,
+		"end_line":    last,
+		"total_lines": total,
+	}
+	if last == 0 {
+		result["message"] = fmt.Sprintf("The file has only %d lines.", total)
+	}
+	if full {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Only lines %d-%d are shown. Continue with start_line %d.", start, last, last+1)
+	}
+	return result, nil
+}
+
 // isBinary checks if data appears to be binary by looking for null bytes
 func isBinary(data []byte) bool {
 	// Check first 512 bytes for null bytes
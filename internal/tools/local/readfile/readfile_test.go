@@ -0,0 +1,89 @@
+package readfile
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTool_Execute_Range(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	var log strings.Builder
+	for i := 1; i <= maxLines+500; i++ {
+		fmt.Fprintf(&log, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(log.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		start, end    float64
+		wantFirst     string
+		wantLast      int
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{name: "range", start: 10, end: 12, wantFirst: "    10\tline 10\n", wantLast: 12},
+		{name: "to the end", start: maxLines + 400, wantFirst: fmt.Sprintf("%6d\tline %d\n", maxLines+400, maxLines+400), wantLast: maxLines + 500},
+		{name: "from the start", end: 3, wantFirst: "     1\tline 1\n", wantLast: 3},
+		{name: "capped at maxLines", start: 1, wantFirst: "     1\tline 1\n", wantLast: maxLines, wantTruncated: true},
+		{name: "past the end", start: maxLines + 501},
+		{name: "end before start", start: 5, end: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]any{"path": path}
+			if tt.start != 0 {
+				args["start_line"] = tt.start
+			}
+			if tt.end != 0 {
+				args["end_line"] = tt.end
+			}
+			got, err := New().Execute(context.Background(), args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			result := got.(map[string]any)
+			content := result["content"].(string)
+			if !strings.HasPrefix(content, tt.wantFirst) {
+				t.Errorf("content starts %q, want %q", content[:min(len(content), 40)], tt.wantFirst)
+			}
+			if result["end_line"] != tt.wantLast {
+				t.Errorf("end_line = %v, want %d", result["end_line"], tt.wantLast)
+			}
+			if result["total_lines"] != maxLines+500 {
+				t.Errorf("total_lines = %v, want %d", result["total_lines"], maxLines+500)
+			}
+			if truncated, _ := result["truncated"].(bool); truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestTool_Execute_Large(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", maxFileSize)+"\nlast\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := New()
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path}); err == nil || !strings.Contains(err.Error(), "start_line") {
+		t.Errorf("Execute() of a large file error = %v, want a hint to read a range", err)
+	}
+	got, err := tool.Execute(context.Background(), map[string]any{"path": path, "start_line": float64(2)})
+	if err != nil {
+		t.Fatalf("Execute() with a range error = %v", err)
+	}
+	if content := got.(map[string]any)["content"]; content != "     2\tlast\n" {
+		t.Errorf("content = %q, want the last line", content)
+	}
+}
@@ -0,0 +1,184 @@
+package remotegit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// gitHub talks to the GitHub REST API
+type gitHub struct {
+	api *apiClient
+}
+
+func newGitHub(baseURL, token string) *gitHub {
+	return &gitHub{api: &apiClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		setAuth: func(req *http.Request) {
+			req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		},
+	}}
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+func (g *gitHub) ListPullRequests(ctx context.Context, repo, state string, limit int) ([]PullRequest, error) {
+	// GitHub has no "merged" state filter; merged PRs are closed PRs with
+	// a merge time
+	apiState := state
+	if state == "merged" {
+		apiState = "closed"
+	}
+
+	query := url.Values{
+		"state":     {apiState},
+		"per_page":  {strconv.Itoa(limit)},
+		"sort":      {"updated"},
+		"direction": {"desc"},
+	}
+	var pulls []struct {
+		Number    int        `json:"number"`
+		Title     string     `json:"title"`
+		State     string     `json:"state"`
+		User      githubUser `json:"user"`
+		Draft     bool       `json:"draft"`
+		HTMLURL   string     `json:"html_url"`
+		CreatedAt string     `json:"created_at"`
+		UpdatedAt string     `json:"updated_at"`
+		MergedAt  string     `json:"merged_at"`
+		Head      struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	}
+	if err := g.api.getJSON(ctx, "/repos/"+repo+"/pulls", query, &pulls); err != nil {
+		return nil, err
+	}
+
+	prs := make([]PullRequest, 0, len(pulls))
+	for _, p := range pulls {
+		if state == "merged" && p.MergedAt == "" {
+			continue
+		}
+		prState := p.State
+		if p.MergedAt != "" {
+			prState = "merged"
+		}
+		prs = append(prs, PullRequest{
+			Number:    p.Number,
+			Title:     p.Title,
+			State:     prState,
+			Author:    p.User.Login,
+			Branch:    p.Head.Ref,
+			Base:      p.Base.Ref,
+			Draft:     p.Draft,
+			URL:       p.HTMLURL,
+			CreatedAt: p.CreatedAt,
+			UpdatedAt: p.UpdatedAt,
+			MergedAt:  p.MergedAt,
+		})
+	}
+	return prs, nil
+}
+
+func (g *gitHub) PullRequestDiff(ctx context.Context, repo string, number int) (string, error) {
+	body, err := g.api.get(ctx, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), nil, "application/vnd.github.diff")
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (g *gitHub) ListCIRuns(ctx context.Context, repo, branch string, limit int) ([]CIRun, error) {
+	query := url.Values{"per_page": {strconv.Itoa(limit)}}
+	if branch != "" {
+		query.Set("branch", branch)
+	}
+
+	var resp struct {
+		WorkflowRuns []struct {
+			ID         int64  `json:"id"`
+			Name       string `json:"name"`
+			HeadBranch string `json:"head_branch"`
+			HeadSHA    string `json:"head_sha"`
+			Event      string `json:"event"`
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+			HTMLURL    string `json:"html_url"`
+			CreatedAt  string `json:"created_at"`
+			UpdatedAt  string `json:"updated_at"`
+		} `json:"workflow_runs"`
+	}
+	if err := g.api.getJSON(ctx, "/repos/"+repo+"/actions/runs", query, &resp); err != nil {
+		return nil, err
+	}
+
+	runs := make([]CIRun, 0, len(resp.WorkflowRuns))
+	for _, r := range resp.WorkflowRuns {
+		runs = append(runs, CIRun{
+			ID:         r.ID,
+			Name:       r.Name,
+			Branch:     r.HeadBranch,
+			Commit:     shortSHA(r.HeadSHA),
+			Event:      r.Event,
+			Status:     r.Status,
+			Conclusion: r.Conclusion,
+			URL:        r.HTMLURL,
+			CreatedAt:  r.CreatedAt,
+			UpdatedAt:  r.UpdatedAt,
+		})
+	}
+	return runs, nil
+}
+
+func (g *gitHub) GetIssue(ctx context.Context, repo string, number int) (*Issue, error) {
+	var issue struct {
+		Number    int        `json:"number"`
+		Title     string     `json:"title"`
+		State     string     `json:"state"`
+		User      githubUser `json:"user"`
+		Body      string     `json:"body"`
+		Comments  int        `json:"comments"`
+		HTMLURL   string     `json:"html_url"`
+		CreatedAt string     `json:"created_at"`
+		Labels    []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := g.api.getJSON(ctx, fmt.Sprintf("/repos/%s/issues/%d", repo, number), nil, &issue); err != nil {
+		return nil, err
+	}
+
+	labels := make([]string, 0, len(issue.Labels))
+	for _, l := range issue.Labels {
+		labels = append(labels, l.Name)
+	}
+	return &Issue{
+		Number:    issue.Number,
+		Title:     issue.Title,
+		State:     issue.State,
+		Author:    issue.User.Login,
+		Labels:    labels,
+		Body:      issue.Body,
+		Comments:  issue.Comments,
+		URL:       issue.HTMLURL,
+		CreatedAt: issue.CreatedAt,
+	}, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,189 @@
+package remotegit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// gitLab talks to the GitLab REST API (v4)
+type gitLab struct {
+	api *apiClient
+}
+
+func newGitLab(baseURL, token string) *gitLab {
+	return &gitLab{api: &apiClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: requestTimeout},
+		setAuth: func(req *http.Request) {
+			if token != "" {
+				req.Header.Set("PRIVATE-TOKEN", token)
+			}
+		},
+	}}
+}
+
+// project returns the API path for a project given as "group/name"
+func project(repo string) string {
+	return "/projects/" + url.PathEscape(repo)
+}
+
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+func (g *gitLab) ListPullRequests(ctx context.Context, repo, state string, limit int) ([]PullRequest, error) {
+	apiState := state
+	if state == "open" {
+		apiState = "opened"
+	}
+
+	query := url.Values{
+		"state":    {apiState},
+		"per_page": {strconv.Itoa(limit)},
+		"order_by": {"updated_at"},
+	}
+	var mrs []struct {
+		IID          int        `json:"iid"`
+		Title        string     `json:"title"`
+		State        string     `json:"state"`
+		Author       gitlabUser `json:"author"`
+		SourceBranch string     `json:"source_branch"`
+		TargetBranch string     `json:"target_branch"`
+		Draft        bool       `json:"draft"`
+		WebURL       string     `json:"web_url"`
+		CreatedAt    string     `json:"created_at"`
+		UpdatedAt    string     `json:"updated_at"`
+		MergedAt     string     `json:"merged_at"`
+	}
+	if err := g.api.getJSON(ctx, project(repo)+"/merge_requests", query, &mrs); err != nil {
+		return nil, err
+	}
+
+	prs := make([]PullRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		mrState := mr.State
+		if mrState == "opened" {
+			mrState = "open"
+		}
+		prs = append(prs, PullRequest{
+			Number:    mr.IID,
+			Title:     mr.Title,
+			State:     mrState,
+			Author:    mr.Author.Username,
+			Branch:    mr.SourceBranch,
+			Base:      mr.TargetBranch,
+			Draft:     mr.Draft,
+			URL:       mr.WebURL,
+			CreatedAt: mr.CreatedAt,
+			UpdatedAt: mr.UpdatedAt,
+			MergedAt:  mr.MergedAt,
+		})
+	}
+	return prs, nil
+}
+
+// PullRequestDiff rebuilds a unified diff from the merge request's changes,
+// since GitLab returns per-file hunks without git headers
+func (g *gitLab) PullRequestDiff(ctx context.Context, repo string, number int) (string, error) {
+	var resp struct {
+		Changes []struct {
+			OldPath     string `json:"old_path"`
+			NewPath     string `json:"new_path"`
+			Diff        string `json:"diff"`
+			NewFile     bool   `json:"new_file"`
+			DeletedFile bool   `json:"deleted_file"`
+		} `json:"changes"`
+	}
+	if err := g.api.getJSON(ctx, fmt.Sprintf("%s/merge_requests/%d/changes", project(repo), number), nil, &resp); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, c := range resp.Changes {
+		oldPath, newPath := "a/"+c.OldPath, "b/"+c.NewPath
+		if c.NewFile {
+			oldPath = "/dev/null"
+		}
+		if c.DeletedFile {
+			newPath = "/dev/null"
+		}
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- %s\n+++ %s\n%s", c.OldPath, c.NewPath, oldPath, newPath, c.Diff)
+		if !strings.HasSuffix(c.Diff, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func (g *gitLab) ListCIRuns(ctx context.Context, repo, branch string, limit int) ([]CIRun, error) {
+	query := url.Values{"per_page": {strconv.Itoa(limit)}}
+	if branch != "" {
+		query.Set("ref", branch)
+	}
+
+	var pipelines []struct {
+		ID        int64  `json:"id"`
+		Ref       string `json:"ref"`
+		SHA       string `json:"sha"`
+		Source    string `json:"source"`
+		Status    string `json:"status"`
+		WebURL    string `json:"web_url"`
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	if err := g.api.getJSON(ctx, project(repo)+"/pipelines", query, &pipelines); err != nil {
+		return nil, err
+	}
+
+	runs := make([]CIRun, 0, len(pipelines))
+	for _, p := range pipelines {
+		runs = append(runs, CIRun{
+			ID:        p.ID,
+			Branch:    p.Ref,
+			Commit:    shortSHA(p.SHA),
+			Event:     p.Source,
+			Status:    p.Status,
+			URL:       p.WebURL,
+			CreatedAt: p.CreatedAt,
+			UpdatedAt: p.UpdatedAt,
+		})
+	}
+	return runs, nil
+}
+
+func (g *gitLab) GetIssue(ctx context.Context, repo string, number int) (*Issue, error) {
+	var issue struct {
+		IID            int        `json:"iid"`
+		Title          string     `json:"title"`
+		State          string     `json:"state"`
+		Author         gitlabUser `json:"author"`
+		Description    string     `json:"description"`
+		Labels         []string   `json:"labels"`
+		UserNotesCount int        `json:"user_notes_count"`
+		WebURL         string     `json:"web_url"`
+		CreatedAt      string     `json:"created_at"`
+	}
+	if err := g.api.getJSON(ctx, fmt.Sprintf("%s/issues/%d", project(repo), number), nil, &issue); err != nil {
+		return nil, err
+	}
+
+	state := issue.State
+	if state == "opened" {
+		state = "open"
+	}
+	return &Issue{
+		Number:    issue.IID,
+		Title:     issue.Title,
+		State:     state,
+		Author:    issue.Author.Username,
+		Labels:    issue.Labels,
+		Body:      issue.Description,
+		Comments:  issue.UserNotesCount,
+		URL:       issue.WebURL,
+		CreatedAt: issue.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,264 @@
+// Package remotegit provides read-only tools for GitHub and GitLab: pull
+// requests, diffs, CI runs, and issues. Tokens come from the environment
+// (GITHUB_TOKEN, GITLAB_TOKEN); public repositories work without one.
+package remotegit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+	maxDiffSize    = 100 * 1024 // 100KB
+	maxBodySize    = 10 << 20   // 10MB
+	defaultLimit   = 20
+	maxLimit       = 100
+)
+
+// repoPattern matches owner/name and GitLab group/subgroup/name paths
+var repoPattern = regexp.MustCompile(`^[\w.-]+(/[\w.-]+)+$`)
+
+// PullRequest is a GitHub pull request or GitLab merge request
+type PullRequest struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	State     string `json:"state"`
+	Author    string `json:"author"`
+	Branch    string `json:"branch"`
+	Base      string `json:"base"`
+	Draft     bool   `json:"draft"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	MergedAt  string `json:"merged_at,omitempty"`
+}
+
+// CIRun is a GitHub Actions workflow run or GitLab pipeline
+type CIRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name,omitempty"`
+	Branch     string `json:"branch"`
+	Commit     string `json:"commit"`
+	Event      string `json:"event"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion,omitempty"`
+	URL        string `json:"url"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// Issue is a GitHub or GitLab issue
+type Issue struct {
+	Number    int      `json:"number"`
+	Title     string   `json:"title"`
+	State     string   `json:"state"`
+	Author    string   `json:"author"`
+	Labels    []string `json:"labels"`
+	Body      string   `json:"body"`
+	Comments  int      `json:"comments"`
+	URL       string   `json:"url"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// host is the API surface shared by GitHub and GitLab
+type host interface {
+	ListPullRequests(ctx context.Context, repo, state string, limit int) ([]PullRequest, error)
+	PullRequestDiff(ctx context.Context, repo string, number int) (string, error)
+	ListCIRuns(ctx context.Context, repo, branch string, limit int) ([]CIRun, error)
+	GetIssue(ctx context.Context, repo string, number int) (*Issue, error)
+}
+
+// Hosts holds the GitHub and GitLab clients shared by the tools
+type Hosts struct {
+	github     host
+	gitlab     host
+	gitlabHost string
+
+	// remoteURL returns the origin URL of the git repository at dir
+	remoteURL func(ctx context.Context, dir string) (string, error)
+}
+
+// NewHosts creates clients for github.com (or GITHUB_API_URL) and gitlab.com
+// (or GITLAB_URL for self-hosted instances)
+func NewHosts() *Hosts {
+	githubAPI := os.Getenv("GITHUB_API_URL")
+	if githubAPI == "" {
+		githubAPI = "https://api.github.com"
+	}
+	githubToken := os.Getenv("GITHUB_TOKEN")
+	if githubToken == "" {
+		githubToken = os.Getenv("GH_TOKEN")
+	}
+
+	gitlabURL := os.Getenv("GITLAB_URL")
+	if gitlabURL == "" {
+		gitlabURL = "https://gitlab.com"
+	}
+	gitlabHost := "gitlab.com"
+	if u, err := url.Parse(gitlabURL); err == nil && u.Host != "" {
+		gitlabHost = u.Host
+	}
+
+	return &Hosts{
+		github:     newGitHub(githubAPI, githubToken),
+		gitlab:     newGitLab(strings.TrimSuffix(gitlabURL, "/")+"/api/v4", os.Getenv("GITLAB_TOKEN")),
+		gitlabHost: gitlabHost,
+		remoteURL: func(ctx context.Context, dir string) (string, error) {
+			out, err := local.RunGit(ctx, dir, "remote", "get-url", "origin")
+			return strings.TrimSpace(out), err
+		},
+	}
+}
+
+// resolve picks the host and "owner/name" repository for a tool call. An
+// explicit repo needs a host (github is assumed); otherwise both come from
+// the origin remote of the local repository at path (default CWD).
+func (h *Hosts) resolve(ctx context.Context, args map[string]any) (host, string, error) {
+	hostName, _ := args["host"].(string)
+	repo, _ := args["repo"].(string)
+
+	if repo == "" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		if pathArg, ok := args["path"].(string); ok && pathArg != "" {
+			if dir, err = local.ExpandPath(pathArg); err != nil {
+				return nil, "", fmt.Errorf("failed to expand path: %w", err)
+			}
+		}
+
+		remote, err := h.remoteURL(ctx, dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("no repo given and could not read origin remote: %w", err)
+		}
+		remoteHost, remoteRepo, err := parseRemote(remote)
+		if err != nil {
+			return nil, "", err
+		}
+		repo = remoteRepo
+		if hostName == "" {
+			switch {
+			case remoteHost == h.gitlabHost || strings.Contains(remoteHost, "gitlab"):
+				hostName = "gitlab"
+			case strings.Contains(remoteHost, "github"):
+				hostName = "github"
+			default:
+				return nil, "", fmt.Errorf("cannot tell whether %s is GitHub or GitLab; pass host", remoteHost)
+			}
+		}
+	}
+
+	if !repoPattern.MatchString(repo) || strings.Contains(repo, "..") {
+		return nil, "", fmt.Errorf("invalid repo %q (want owner/name)", repo)
+	}
+
+	switch hostName {
+	case "", "github":
+		return h.github, repo, nil
+	case "gitlab":
+		return h.gitlab, repo, nil
+	default:
+		return nil, "", fmt.Errorf("unknown host %q (use github or gitlab)", hostName)
+	}
+}
+
+// parseRemote splits a git remote URL (https, ssh, or scp-like) into its
+// hostname and repository path without the .git suffix
+func parseRemote(remote string) (string, string, error) {
+	var hostname, path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid remote URL %q: %w", remote, err)
+		}
+		hostname, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remote, ":"); ok {
+		// scp-like: git@github.com:owner/repo.git
+		_, hostname, _ = strings.Cut(at, "@")
+		if hostname == "" {
+			hostname = at
+		}
+		path = rest
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if hostname == "" || !strings.Contains(path, "/") {
+		return "", "", fmt.Errorf("cannot parse repository from remote %q", remote)
+	}
+	return hostname, path, nil
+}
+
+// apiClient is the HTTP plumbing shared by the GitHub and GitLab clients
+type apiClient struct {
+	baseURL    string
+	httpClient *http.Client
+	setAuth    func(req *http.Request)
+}
+
+// get performs a GET request and returns the body, turning non-2xx
+// responses into errors that include the API's message
+func (c *apiClient) get(ctx context.Context, path string, query url.Values, accept string) ([]byte, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	c.setAuth(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+			Error   string `json:"error"`
+		}
+		msg := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &apiErr) == nil {
+			if apiErr.Message != "" {
+				msg = apiErr.Message
+			} else if apiErr.Error != "" {
+				msg = apiErr.Error
+			}
+		}
+		return nil, fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, msg)
+	}
+	return body, nil
+}
+
+func (c *apiClient) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	body, err := c.get(ctx, path, query, "")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
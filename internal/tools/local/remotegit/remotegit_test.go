@@ -0,0 +1,175 @@
+package remotegit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestHosts serves canned GitHub and GitLab API responses and returns
+// Hosts pointed at them, with the local origin remote set to remote
+func newTestHosts(t *testing.T, remote string) *Hosts {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /github/repos/acme/api/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("state") != "closed" {
+			t.Errorf("state = %q, want closed", r.URL.Query().Get("state"))
+		}
+		w.Write([]byte(`[
+			{"number":12,"title":"Bump timeout","state":"closed","user":{"login":"ana"},"head":{"ref":"timeout"},"base":{"ref":"main"},"merged_at":"2024-03-02T09:00:00Z"},
+			{"number":11,"title":"Abandoned","state":"closed","user":{"login":"bo"},"head":{"ref":"old"},"base":{"ref":"main"},"merged_at":null}
+		]`))
+	})
+	mux.HandleFunc("GET /github/repos/acme/api/pulls/12", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/vnd.github.diff" {
+			t.Errorf("Accept = %q", r.Header.Get("Accept"))
+		}
+		w.Write([]byte("diff --git a/config.yaml b/config.yaml\n"))
+	})
+	mux.HandleFunc("GET /github/repos/acme/api/issues/404", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+	})
+	mux.HandleFunc("GET /gitlab/projects/{project}/merge_requests/{iid}/changes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "gl-token" {
+			t.Errorf("PRIVATE-TOKEN = %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		w.Write([]byte(`{"changes":[{"old_path":"a.txt","new_path":"a.txt","diff":"@@ -1 +1 @@\n-old\n+new\n"},{"old_path":"b.txt","new_path":"b.txt","new_file":true,"diff":"@@ -0,0 +1 @@\n+hi"}]}`))
+	})
+	mux.HandleFunc("GET /gitlab/projects/{project}/pipelines", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.PathValue("project"); got != "infra/platform/api" {
+			t.Errorf("project = %q", got)
+		}
+		w.Write([]byte(`[{"id":99,"ref":"main","sha":"0123456789abcdef","source":"push","status":"failed"}]`))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return &Hosts{
+		github:     newGitHub(srv.URL+"/github", "gh-token"),
+		gitlab:     newGitLab(srv.URL+"/gitlab", "gl-token"),
+		gitlabHost: "gitlab.example.com",
+		remoteURL: func(ctx context.Context, dir string) (string, error) {
+			return remote, nil
+		},
+	}
+}
+
+func TestPullRequestsTool_Merged(t *testing.T) {
+	tool := NewPullRequestsTool(newTestHosts(t, ""))
+
+	result, err := tool.Execute(context.Background(), map[string]any{"repo": "acme/api", "state": "merged"})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	prs := result.(map[string]any)["pull_requests"].([]PullRequest)
+	if len(prs) != 1 || prs[0].Number != 12 || prs[0].State != "merged" || prs[0].Branch != "timeout" {
+		t.Errorf("pull_requests = %+v", prs)
+	}
+}
+
+func TestDiffTool(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote string
+		args   map[string]any
+		want   string
+	}{
+		{
+			name: "github explicit repo",
+			args: map[string]any{"repo": "acme/api", "number": float64(12)},
+			want: "diff --git a/config.yaml b/config.yaml\n",
+		},
+		{
+			name:   "gitlab from origin remote",
+			remote: "git@gitlab.example.com:infra/platform/api.git",
+			args:   map[string]any{"number": float64(3)},
+			want: "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-old\n+new\n" +
+				"diff --git a/b.txt b/b.txt\n--- /dev/null\n+++ b/b.txt\n@@ -0,0 +1 @@\n+hi\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewDiffTool(newTestHosts(t, tt.remote))
+			result, err := tool.Execute(context.Background(), tt.args)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if got := result.(map[string]any)["diff"]; got != tt.want {
+				t.Errorf("diff = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIRunsTool_GitLab(t *testing.T) {
+	tool := NewCIRunsTool(newTestHosts(t, "https://gitlab.example.com/infra/platform/api"))
+
+	result, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	runs := result.(map[string]any)["runs"].([]CIRun)
+	if len(runs) != 1 || runs[0].Status != "failed" || runs[0].Commit != "0123456789ab" {
+		t.Errorf("runs = %+v", runs)
+	}
+}
+
+func TestTools_Errors(t *testing.T) {
+	hosts := newTestHosts(t, "https://bitbucket.org/acme/api.git")
+
+	tests := []struct {
+		name string
+		tool interface {
+			Execute(context.Context, map[string]any) (any, error)
+		}
+		args    map[string]any
+		wantErr string
+	}{
+		{"api error message", NewIssueTool(hosts), map[string]any{"repo": "acme/api", "number": float64(404)}, "Not Found"},
+		{"unknown remote host", NewCIRunsTool(hosts), map[string]any{}, "pass host"},
+		{"path traversal", NewCIRunsTool(hosts), map[string]any{"repo": "acme/../admin"}, "invalid repo"},
+		{"missing number", NewDiffTool(hosts), map[string]any{"repo": "acme/api"}, "number parameter is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.tool.Execute(context.Background(), tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		remote   string
+		wantHost string
+		wantRepo string
+	}{
+		{"git@github.com:acme/api.git", "github.com", "acme/api"},
+		{"https://github.com/acme/api", "github.com", "acme/api"},
+		{"ssh://git@gitlab.com:2222/group/sub/api.git", "gitlab.com", "group/sub/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			host, repo, err := parseRemote(tt.remote)
+			if err != nil {
+				t.Fatalf("parseRemote() error: %v", err)
+			}
+			if host != tt.wantHost || repo != tt.wantRepo {
+				t.Errorf("parseRemote() = %q, %q, want %q, %q", host, repo, tt.wantHost, tt.wantRepo)
+			}
+		})
+	}
+}
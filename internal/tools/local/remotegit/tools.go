@@ -0,0 +1,275 @@
+package remotegit
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// repoProperties are the parameters every tool uses to pick a repository
+var repoProperties = map[string]llm.Property{
+	"repo": {
+		Type:        "string",
+		Description: "Repository as owner/name (GitLab: group/subgroup/name). Defaults to the origin remote of the local repository.",
+	},
+	"host": {
+		Type:        "string",
+		Description: "github or gitlab (detected from the origin remote when repo is omitted, otherwise github)",
+	},
+	"path": {
+		Type:        "string",
+		Description: "Local repository whose origin remote is used when repo is omitted (defaults to current working directory)",
+	},
+}
+
+// withRepoProperties returns props plus the repository selection parameters
+func withRepoProperties(props map[string]llm.Property) map[string]llm.Property {
+	for name, prop := range repoProperties {
+		props[name] = prop
+	}
+	return props
+}
+
+// limitArg returns the "limit" argument clamped to [1, maxLimit]
+func limitArg(args map[string]any) int {
+	limit := defaultLimit
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(min(n, maxLimit))
+	}
+	return limit
+}
+
+// numberArg returns the required "number" argument
+func numberArg(args map[string]any) (int, error) {
+	n, ok := args["number"].(float64)
+	if !ok || n < 1 {
+		return 0, fmt.Errorf("number parameter is required")
+	}
+	return int(min(n, math.MaxInt32)), nil
+}
+
+// PullRequestsTool lists pull/merge requests
+type PullRequestsTool struct {
+	hosts *Hosts
+}
+
+// NewPullRequestsTool creates the remote_git_list_prs tool
+func NewPullRequestsTool(hosts *Hosts) *PullRequestsTool {
+	return &PullRequestsTool{hosts: hosts}
+}
+
+func (t *PullRequestsTool) Name() string {
+	return "remote_git_list_prs"
+}
+
+func (t *PullRequestsTool) Description() string {
+	return "List pull requests (GitHub) or merge requests (GitLab) with author, branches, state, and last update, most recently updated first."
+}
+
+func (t *PullRequestsTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: withRepoProperties(map[string]llm.Property{
+			"state": {
+				Type:        "string",
+				Description: "open, closed, merged, or all (default open)",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Maximum number of results (default %d, max %d)", defaultLimit, maxLimit),
+			},
+		}),
+		Required: []string{},
+	}
+}
+
+func (t *PullRequestsTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	h, repo, err := t.hosts.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	state, _ := args["state"].(string)
+	switch state {
+	case "":
+		state = "open"
+	case "open", "closed", "merged", "all":
+	default:
+		return nil, fmt.Errorf("invalid state %q (use open, closed, merged, or all)", state)
+	}
+
+	prs, err := h.ListPullRequests(ctx, repo, state, limitArg(args))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests for %s: %w", repo, err)
+	}
+
+	return map[string]any{
+		"repo":          repo,
+		"pull_requests": prs,
+		"count":         len(prs),
+	}, nil
+}
+
+// DiffTool fetches the diff of a pull/merge request
+type DiffTool struct {
+	hosts *Hosts
+}
+
+// NewDiffTool creates the remote_git_pr_diff tool
+func NewDiffTool(hosts *Hosts) *DiffTool {
+	return &DiffTool{hosts: hosts}
+}
+
+func (t *DiffTool) Name() string {
+	return "remote_git_pr_diff"
+}
+
+func (t *DiffTool) Description() string {
+	return "Get the unified diff of a pull request (GitHub) or merge request (GitLab)."
+}
+
+func (t *DiffTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: withRepoProperties(map[string]llm.Property{
+			"number": {
+				Type:        "integer",
+				Description: "Pull request number (GitLab: merge request IID)",
+			},
+		}),
+		Required: []string{"number"},
+	}
+}
+
+func (t *DiffTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	number, err := numberArg(args)
+	if err != nil {
+		return nil, err
+	}
+	h, repo, err := t.hosts.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := h.PullRequestDiff(ctx, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff for %s#%d: %w", repo, number, err)
+	}
+
+	truncated := false
+	if len(diff) > maxDiffSize {
+		diff = diff[:maxDiffSize] + "\n... (truncated at 100KB)"
+		truncated = true
+	}
+
+	return map[string]any{
+		"repo":      repo,
+		"number":    number,
+		"diff":      diff,
+		"truncated": truncated,
+	}, nil
+}
+
+// CIRunsTool lists recent CI runs
+type CIRunsTool struct {
+	hosts *Hosts
+}
+
+// NewCIRunsTool creates the remote_git_ci_runs tool
+func NewCIRunsTool(hosts *Hosts) *CIRunsTool {
+	return &CIRunsTool{hosts: hosts}
+}
+
+func (t *CIRunsTool) Name() string {
+	return "remote_git_ci_runs"
+}
+
+func (t *CIRunsTool) Description() string {
+	return "List recent CI runs (GitHub Actions workflow runs or GitLab pipelines) with status, conclusion, branch, and commit, newest first."
+}
+
+func (t *CIRunsTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: withRepoProperties(map[string]llm.Property{
+			"branch": {
+				Type:        "string",
+				Description: "Only runs for this branch",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Maximum number of results (default %d, max %d)", defaultLimit, maxLimit),
+			},
+		}),
+		Required: []string{},
+	}
+}
+
+func (t *CIRunsTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	h, repo, err := t.hosts.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, _ := args["branch"].(string)
+	runs, err := h.ListCIRuns(ctx, repo, branch, limitArg(args))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CI runs for %s: %w", repo, err)
+	}
+
+	return map[string]any{
+		"repo":  repo,
+		"runs":  runs,
+		"count": len(runs),
+	}, nil
+}
+
+// IssueTool fetches a single issue
+type IssueTool struct {
+	hosts *Hosts
+}
+
+// NewIssueTool creates the remote_git_issue tool
+func NewIssueTool(hosts *Hosts) *IssueTool {
+	return &IssueTool{hosts: hosts}
+}
+
+func (t *IssueTool) Name() string {
+	return "remote_git_issue"
+}
+
+func (t *IssueTool) Description() string {
+	return "Get a GitHub or GitLab issue: title, state, author, labels, body, and comment count."
+}
+
+func (t *IssueTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: withRepoProperties(map[string]llm.Property{
+			"number": {
+				Type:        "integer",
+				Description: "Issue number (GitLab: issue IID)",
+			},
+		}),
+		Required: []string{"number"},
+	}
+}
+
+func (t *IssueTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	number, err := numberArg(args)
+	if err != nil {
+		return nil, err
+	}
+	h, repo, err := t.hosts.resolve(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err := h.GetIssue(ctx, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue %s#%d: %w", repo, number, err)
+	}
+	return issue, nil
+}
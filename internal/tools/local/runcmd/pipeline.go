@@ -0,0 +1,107 @@
+package runcmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxStages is the most commands a pipeline may chain
+const maxStages = 8
+
+// stage is one command of a pipeline
+type stage struct {
+	name string
+	args []string
+}
+
+// shellSyntax are characters that mean something to a shell but not to
+// parsePipeline; unquoted, they are rejected rather than passed on as
+// arguments the model expects a shell to have interpreted
+const shellSyntax = ";&<>`$(){}*?~"
+
+// parsePipeline splits a pipeline such as "ps aux | grep 'java app'" into
+// its commands. Words are separated by spaces and may be quoted: single
+// quotes keep everything literally, double quotes allow \" and \\, and a
+// backslash outside quotes escapes the next character. Nothing is
+// expanded or interpolated; redirection, variables, globs, and command
+// lists are errors.
+func parsePipeline(s string) ([]stage, error) {
+	var (
+		stages  []stage
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune // ' or " while inside quotes
+		escaped bool
+	)
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endStage := func() error {
+		endWord()
+		if len(words) == 0 {
+			return fmt.Errorf("empty command in pipeline %q", s)
+		}
+		stages = append(stages, stage{name: words[0], args: words[1:]})
+		words = nil
+		return nil
+	}
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				word.WriteRune('\\') // Only \" and \\ are escapes in double quotes
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == '|':
+			if err := endStage(); err != nil {
+				return nil, err
+			}
+		case r == ' ' || r == '\t':
+			endWord()
+		case r == '\n' || r == '\r' || strings.ContainsRune(shellSyntax, r):
+			return nil, fmt.Errorf("shell syntax %q is not supported; quote it to pass it literally", r)
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in pipeline %q", quote, s)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in pipeline %q", s)
+	}
+	if err := endStage(); err != nil {
+		return nil, err
+	}
+	if len(stages) > maxStages {
+		return nil, fmt.Errorf("pipeline has %d commands, at most %d are allowed", len(stages), maxStages)
+	}
+	return stages, nil
+}
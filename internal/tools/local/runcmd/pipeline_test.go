@@ -0,0 +1,135 @@
+package runcmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []stage
+		wantErr string
+	}{
+		{
+			name:  "single command",
+			input: "df -h",
+			want:  []stage{{name: "df", args: []string{"-h"}}},
+		},
+		{
+			name:  "pipeline",
+			input: "ps aux | grep java|wc -l",
+			want:  []stage{{name: "ps", args: []string{"aux"}}, {name: "grep", args: []string{"java"}}, {name: "wc", args: []string{"-l"}}},
+		},
+		{
+			name:  "quotes keep spaces and syntax",
+			input: `grep -E 'a|b' "two words" 'it''s' ""`,
+			want:  []stage{{name: "grep", args: []string{"-E", "a|b", "two words", "its", ""}}},
+		},
+		{
+			name:  "escapes",
+			input: `grep "say \"hi\" \d" a\ b \*.go`,
+			want:  []stage{{name: "grep", args: []string{`say "hi" \d`, "a b", "*.go"}}},
+		},
+		{name: "redirection", input: "ls > out.txt", wantErr: `shell syntax '>'`},
+		{name: "variable", input: "echo $HOME", wantErr: `shell syntax '$'`},
+		{name: "command list", input: "ls; rm -rf /", wantErr: `shell syntax ';'`},
+		{name: "and", input: "ls && rm x", wantErr: `shell syntax '&'`},
+		{name: "glob", input: "ls *.log", wantErr: `shell syntax '*'`},
+		{name: "substitution", input: "echo `id`", wantErr: "shell syntax '`'"},
+		{name: "empty stage", input: "ps aux | | grep java", wantErr: "empty command"},
+		{name: "trailing pipe", input: "ps aux |", wantErr: "empty command"},
+		{name: "unterminated quote", input: "grep 'java", wantErr: "unterminated ' quote"},
+		{name: "too many stages", input: strings.Repeat("cat | ", maxStages) + "cat", wantErr: "at most 8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePipeline(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parsePipeline() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePipeline() error = %v", err)
+			}
+			for i := range got {
+				if len(got[i].args) == 0 {
+					got[i].args = nil
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePipeline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTool_Pipeline(t *testing.T) {
+	tool := New([]string{"echo", "grep", "tr", "find"})
+
+	tests := []struct {
+		name          string
+		args          map[string]any
+		wantStdout    string
+		wantExitCodes []int
+		wantErr       string
+	}{
+		{
+			name:          "stages are piped",
+			args:          map[string]any{"pipeline": "echo 'java app' | tr a-z A-Z | grep JAVA"},
+			wantStdout:    "JAVA APP\n",
+			wantExitCodes: []int{0, 0, 0},
+		},
+		{
+			name:          "exit code of the last stage",
+			args:          map[string]any{"pipeline": "echo python | grep java"},
+			wantExitCodes: []int{0, 1},
+		},
+		{
+			name:    "every stage must be allowed",
+			args:    map[string]any{"pipeline": "echo hi | cat"},
+			wantErr: "command 'cat' is not allowed",
+		},
+		{
+			name:    "find may not delete",
+			args:    map[string]any{"pipeline": "echo hi | find . -name '*.log' -delete"},
+			wantErr: "find -delete is not allowed",
+		},
+		{
+			name:    "not both command and pipeline",
+			args:    map[string]any{"command": "echo", "pipeline": "echo hi"},
+			wantErr: "not both",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			result := got.(map[string]any)
+			if result["stdout"] != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", result["stdout"], tt.wantStdout)
+			}
+			if !reflect.DeepEqual(result["exit_codes"], tt.wantExitCodes) {
+				t.Errorf("exit_codes = %v, want %v", result["exit_codes"], tt.wantExitCodes)
+			}
+			if want := tt.wantExitCodes[len(tt.wantExitCodes)-1]; result["exit_code"] != want {
+				t.Errorf("exit_code = %v, want %d", result["exit_code"], want)
+			}
+		})
+	}
+}
@@ -4,30 +4,74 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"maps"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
 )
 
 const (
-	commandTimeout = 30 * time.Second
-	maxOutputSize  = 100 * 1024 // 100KB
+	commandTimeout = 30 * time.Second // when the context has no deadline
+	maxOutputSize  = 100 * 1024       // 100KB
 )
 
 type Tool struct {
 	allowedCommands map[string]bool
+	sandbox         Sandbox
+	allowedDirs     []string
+	allowedEnv      map[string]bool
 }
 
-func New(allowed []string) *Tool {
+// Option configures optional Tool behavior
+type Option func(*Tool)
+
+// WithSandbox runs commands in s instead of directly on the host
+func WithSandbox(s Sandbox) Option {
+	return func(t *Tool) {
+		if s != nil {
+			t.sandbox = s
+		}
+	}
+}
+
+// WithDirs restricts the cwd parameter to the given directories and those
+// under them. Without it, commands may run in any directory.
+func WithDirs(prefixes []string) Option {
+	return func(t *Tool) {
+		t.allowedDirs = prefixes
+	}
+}
+
+// WithEnv lets the env parameter set the named variables. Without it, the
+// environment can't be changed.
+func WithEnv(names []string) Option {
+	return func(t *Tool) {
+		for _, name := range names {
+			t.allowedEnv[name] = true
+		}
+	}
+}
+
+// New creates the tool for the allowed binaries
+func New(allowed []string, opts ...Option) *Tool {
 	allowedMap := make(map[string]bool)
 	for _, cmd := range allowed {
 		allowedMap[cmd] = true
 	}
-	return &Tool{
+	t := &Tool{
 		allowedCommands: allowedMap,
+		sandbox:         Host{},
+		allowedEnv:      make(map[string]bool),
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 func (t *Tool) Name() string {
@@ -35,11 +79,14 @@ func (t *Tool) Name() string {
 }
 
 func (t *Tool) Description() string {
-	allowedList := make([]string, 0, len(t.allowedCommands))
-	for cmd := range t.allowedCommands {
-		allowedList = append(allowedList, cmd)
+	// Sorted so the description is identical from turn to turn, as prompt
+	// caches need
+	allowedList := slices.Sorted(maps.Keys(t.allowedCommands))
+	description := fmt.Sprintf("Run a safe shell command (limited to: %s). Use this to inspect system state, list files, or run read-only commands.", strings.Join(allowedList, ", "))
+	if sandboxed := t.sandbox.Describe(); sandboxed != "" {
+		description += " Commands run " + sandboxed + "."
 	}
-	return fmt.Sprintf("Run a safe shell command (limited to: %s). Use this to inspect system state, list files, or run read-only commands.", strings.Join(allowedList, ", "))
+	return description
 }
 
 func (t *Tool) Parameters() llm.ParameterSchema {
@@ -58,65 +105,70 @@ func (t *Tool) Parameters() llm.ParameterSchema {
 					Description: "A command argument",
 				},
 			},
+			"cwd": {
+				Type:        "string",
+				Description: "Directory to run in, e.g. a repository to run git log in (optional; default: where joe was started)",
+			},
+			"env": {
+				Type:        "array",
+				Description: "Environment variables to set, as NAME=value (optional; only allowed names)",
+				Items: &llm.Property{
+					Type:        "string",
+					Description: "A variable, e.g. KUBECONFIG=~/.kube/staging",
+				},
+			},
+			"pipeline": {
+				Type:        "string",
+				Description: "Instead of command and args, commands to pipe into each other, e.g. \"ps aux | grep java\". Every command must be in the allowed list. Arguments are split on spaces and may be quoted; there is no shell, so redirection, variables, globs, ; and && are not supported.",
+			},
 		},
-		Required: []string{"command"},
 	}
 }
 
 func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
-	// Get command
-	cmdName, ok := args["command"].(string)
-	if !ok || cmdName == "" {
-		return nil, fmt.Errorf("command parameter is required and must be a string")
+	stages, err := t.parseStages(args)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if command is allowed
-	if !t.allowedCommands[cmdName] {
-		allowedList := make([]string, 0, len(t.allowedCommands))
-		for cmd := range t.allowedCommands {
-			allowedList = append(allowedList, cmd)
+	// Check if every command is allowed
+	for _, st := range stages {
+		if !t.allowedCommands[st.name] {
+			allowedList := slices.Sorted(maps.Keys(t.allowedCommands))
+			return nil, fmt.Errorf("command '%s' is not allowed. Allowed: %s", st.name, strings.Join(allowedList, ", "))
 		}
-		return nil, fmt.Errorf("command '%s' is not allowed. Allowed: %s", cmdName, strings.Join(allowedList, ", "))
-	}
-
-	// Get arguments
-	var cmdArgs []string
-	if argsRaw, ok := args["args"]; ok && argsRaw != nil {
-		if argsList, ok := argsRaw.([]any); ok {
-			for _, arg := range argsList {
-				if argStr, ok := arg.(string); ok {
-					cmdArgs = append(cmdArgs, argStr)
-				}
-			}
+		if err := local.CheckArgs(st.name, st.args); err != nil {
+			return nil, err
 		}
 	}
 
-	// Create context with timeout
-	execCtx, cancel := context.WithTimeout(ctx, commandTimeout)
-	defer cancel()
-
-	// Execute command (NOT through shell, direct execution)
-	cmd := exec.CommandContext(execCtx, cmdName, cmdArgs...)
+	dir, err := t.parseDir(args)
+	if err != nil {
+		return nil, err
+	}
+	env, err := t.parseEnv(args)
+	if err != nil {
+		return nil, err
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Apply the fallback timeout unless the caller (normally the executor)
+	// already set a deadline
+	execCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+	}
 
-	err := cmd.Run()
-	exitCode := 0
+	// Execute commands (NOT through shell, direct execution)
+	stdout, stderr, exitCodes, err := t.run(execCtx, stages, dir, env)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			exitCode = exitErr.ExitCode()
-		} else if execCtx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("command timed out after 30s")
-		} else {
-			return nil, fmt.Errorf("failed to execute command: %w", err)
-		}
+		return nil, err
 	}
 
 	// Truncate output if too large
-	stdoutStr := stdout.String()
-	stderrStr := stderr.String()
+	stdoutStr := stdout
+	stderrStr := stderr
 	truncated := false
 
 	if len(stdoutStr) > maxOutputSize {
@@ -129,16 +181,168 @@ func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	}
 
 	result := map[string]any{
-		"command":   cmdName,
-		"args":      cmdArgs,
 		"stdout":    stdoutStr,
 		"stderr":    stderrStr,
-		"exit_code": exitCode,
+		"exit_code": exitCodes[len(exitCodes)-1], // As a shell reports a pipeline
+	}
+	if pipeline, ok := args["pipeline"].(string); ok && pipeline != "" {
+		result["pipeline"] = pipeline
+		result["exit_codes"] = exitCodes
+	} else {
+		result["command"] = stages[0].name
+		result["args"] = stages[0].args
 	}
 
+	if dir != "" {
+		result["cwd"] = dir
+	}
 	if truncated {
 		result["truncated"] = true
 	}
+	if sandboxed := t.sandbox.Describe(); sandboxed != "" {
+		result["sandbox"] = sandboxed
+	}
 
 	return result, nil
 }
+
+// parseStages reads either the command and its args or a pipeline
+func (t *Tool) parseStages(args map[string]any) ([]stage, error) {
+	cmdName, _ := args["command"].(string)
+	pipeline, _ := args["pipeline"].(string)
+	switch {
+	case cmdName != "" && pipeline != "":
+		return nil, fmt.Errorf("pass either command or pipeline, not both")
+	case pipeline != "":
+		return parsePipeline(pipeline)
+	case cmdName == "":
+		return nil, fmt.Errorf("command parameter is required and must be a string")
+	}
+
+	var cmdArgs []string
+	if argsRaw, ok := args["args"]; ok && argsRaw != nil {
+		if argsList, ok := argsRaw.([]any); ok {
+			for _, arg := range argsList {
+				if argStr, ok := arg.(string); ok {
+					cmdArgs = append(cmdArgs, argStr)
+				}
+			}
+		}
+	}
+	return []stage{{name: cmdName, args: cmdArgs}}, nil
+}
+
+// parseDir returns the cwd argument as an absolute directory, or "" if it
+// wasn't given
+func (t *Tool) parseDir(args map[string]any) (string, error) {
+	cwd, _ := args["cwd"].(string)
+	if cwd == "" {
+		return "", nil
+	}
+	dir, err := local.ExpandPath(cwd)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand cwd: %w", err)
+	}
+	if !local.PathAllowed(dir, t.allowedDirs) {
+		return "", fmt.Errorf("running commands in %s is not allowed. Allowed directories: %s", dir, strings.Join(t.allowedDirs, ", "))
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("invalid cwd: %w", err)
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("invalid cwd: %s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// parseEnv returns the env argument as NAME=value pairs, checking every
+// name is allowed
+func (t *Tool) parseEnv(args map[string]any) ([]string, error) {
+	list, _ := args["env"].([]any)
+	var env []string
+	for _, item := range list {
+		kv, _ := item.(string)
+		name, _, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid env entry %q, want NAME=value", kv)
+		}
+		if !t.allowedEnv[name] {
+			allowedList := slices.Sorted(maps.Keys(t.allowedEnv))
+			return nil, fmt.Errorf("setting %s is not allowed. Allowed variables: %s", name, strings.Join(allowedList, ", "))
+		}
+		env = append(env, kv)
+	}
+	return env, nil
+}
+
+// run runs stages in dir with env added to their environment, each one's
+// stdout piped into the next one's stdin. It returns the last stage's
+// stdout, the stderr of all of them, and each one's exit code.
+func (t *Tool) run(ctx context.Context, stages []stage, dir string, env []string) (stdout, stderr string, exitCodes []int, err error) {
+	var out bytes.Buffer
+	errs := make([]bytes.Buffer, len(stages))
+	cmds := make([]*exec.Cmd, len(stages))
+	for i, st := range stages {
+		cmds[i] = t.sandbox.Command(ctx, Invocation{Name: st.name, Args: st.args, Dir: dir, Env: env})
+		cmds[i].Stderr = &errs[i]
+	}
+	cmds[len(cmds)-1].Stdout = &out
+
+	// Connect the stages; the parent's copies of each pipe are closed once
+	// the commands holding them have started
+	var pipes []*os.File
+	defer func() {
+		for _, f := range pipes {
+			f.Close()
+		}
+	}()
+	for i := 0; i < len(cmds)-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to create pipe: %w", err)
+		}
+		pipes = append(pipes, r, w)
+		cmds[i].Stdout = w
+		cmds[i+1].Stdin = r
+	}
+
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			for _, started := range cmds[:i] {
+				started.Process.Kill()
+				started.Wait()
+			}
+			return "", "", nil, fmt.Errorf("failed to execute command: %w", err)
+		}
+	}
+	for _, f := range pipes {
+		f.Close()
+	}
+	pipes = nil
+
+	exitCodes = make([]int, len(cmds))
+	var runErr error
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				exitCodes[i] = exitErr.ExitCode()
+			} else if runErr == nil {
+				runErr = err
+			}
+		}
+	}
+	// A killed command also reports an ExitError, so check the context first
+	if ctx.Err() != nil {
+		return "", "", nil, fmt.Errorf("command did not finish: %w", ctx.Err())
+	}
+	if runErr != nil {
+		return "", "", nil, fmt.Errorf("failed to execute command: %w", runErr)
+	}
+
+	var stderrs []string
+	for i := range errs {
+		if errs[i].Len() > 0 {
+			stderrs = append(stderrs, errs[i].String())
+		}
+	}
+	return out.String(), strings.Join(stderrs, ""), exitCodes, nil
+}
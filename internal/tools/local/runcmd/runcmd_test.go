@@ -0,0 +1,82 @@
+package runcmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTool_DirAndEnv(t *testing.T) {
+	allowed := t.TempDir()
+	repo := filepath.Join(allowed, "repo")
+	if err := os.Mkdir(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tool := New([]string{"pwd", "env"}, WithDirs([]string{allowed}), WithEnv([]string{"KUBECONFIG"}))
+
+	tests := []struct {
+		name       string
+		args       map[string]any
+		wantStdout string
+		wantErr    string
+	}{
+		{
+			name:       "runs in cwd",
+			args:       map[string]any{"command": "pwd", "cwd": repo},
+			wantStdout: repo + "\n",
+		},
+		{
+			name:    "cwd outside the allowed directories",
+			args:    map[string]any{"command": "pwd", "cwd": t.TempDir()},
+			wantErr: "is not allowed",
+		},
+		{
+			name:    "cwd that doesn't exist",
+			args:    map[string]any{"command": "pwd", "cwd": filepath.Join(allowed, "missing")},
+			wantErr: "invalid cwd",
+		},
+		{
+			name:       "sets allowed variables",
+			args:       map[string]any{"command": "env", "env": []any{"KUBECONFIG=/tmp/staging"}},
+			wantStdout: "KUBECONFIG=/tmp/staging\n",
+		},
+		{
+			name:    "refuses other variables",
+			args:    map[string]any{"command": "env", "env": []any{"LD_PRELOAD=/tmp/evil.so"}},
+			wantErr: "setting LD_PRELOAD is not allowed",
+		},
+		{
+			name:    "malformed variable",
+			args:    map[string]any{"command": "env", "env": []any{"KUBECONFIG"}},
+			wantErr: "want NAME=value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			stdout := got.(map[string]any)["stdout"].(string)
+			if !strings.Contains(stdout, tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout, tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestTool_Description(t *testing.T) {
+	tool := New([]string{"ps", "df", "kubectl", "ls"})
+	if desc := tool.Description(); !strings.Contains(desc, "limited to: df, kubectl, ls, ps)") {
+		t.Errorf("Description() = %q, want the allowed commands sorted", desc)
+	}
+}
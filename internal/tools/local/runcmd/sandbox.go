@@ -0,0 +1,144 @@
+package runcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Invocation is a command for a Sandbox to run
+type Invocation struct {
+	Name string
+	Args []string
+	Dir  string   // Working directory; "" is joe's
+	Env  []string // NAME=value pairs added to the environment
+}
+
+// Sandbox decides where and how a command runs
+type Sandbox interface {
+	// Command returns the command that runs inv
+	Command(ctx context.Context, inv Invocation) *exec.Cmd
+	// Describe says how commands are isolated, for the tool description
+	// and results; "" means they aren't
+	Describe() string
+}
+
+// NewSandbox returns the sandbox for backend: "none" (or "") runs commands
+// on the host, "container" in a throwaway container of image started with
+// runtime (docker or podman), and "restricted" in new user and network
+// namespaces, via unshare (Linux only), with a minimal environment.
+// network allows network access from the container or restricted sandbox.
+func NewSandbox(backend, runtime, image string, network bool) (Sandbox, error) {
+	switch backend {
+	case "", "none":
+		return Host{}, nil
+	case "container":
+		if image == "" {
+			return nil, fmt.Errorf("sandbox backend container needs an image")
+		}
+		if runtime == "" {
+			runtime = "docker"
+		}
+		return Container{Runtime: runtime, Image: image, Network: network}, nil
+	case "restricted":
+		return Restricted{Network: network}, nil
+	}
+	return nil, fmt.Errorf("unknown sandbox backend %q (use none, container, or restricted)", backend)
+}
+
+// Host runs commands directly on the host
+type Host struct{}
+
+func (Host) Command(ctx context.Context, inv Invocation) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, inv.Name, inv.Args...)
+	cmd.Dir = inv.Dir
+	if len(inv.Env) > 0 {
+		cmd.Env = append(os.Environ(), inv.Env...)
+	}
+	return cmd
+}
+
+func (Host) Describe() string { return "" }
+
+// Container runs each command in a new container that is removed when it
+// exits. The container has no capabilities, a read-only root filesystem,
+// and the working directory (joe's, unless the invocation names one)
+// mounted read-only at /work.
+type Container struct {
+	Runtime string // docker or podman
+	Image   string
+	Network bool
+}
+
+// Resource limits of each container
+const (
+	containerMemory = "512m"
+	containerPids   = "256"
+)
+
+func (c Container) Command(ctx context.Context, inv Invocation) *exec.Cmd {
+	run := []string{"run", "--rm", "-i", // -i passes stdin through, for pipelines
+		"--read-only", "--cap-drop", "ALL", "--security-opt", "no-new-privileges",
+		"--memory", containerMemory, "--pids-limit", containerPids,
+	}
+	if !c.Network {
+		run = append(run, "--network", "none")
+	}
+	for _, kv := range inv.Env {
+		run = append(run, "-e", kv)
+	}
+	wd := inv.Dir
+	if wd == "" {
+		wd, _ = os.Getwd()
+	}
+	if wd != "" {
+		run = append(run, "-v", wd+":/work:ro", "-w", "/work")
+	}
+	run = append(run, c.Image, inv.Name)
+	return exec.CommandContext(ctx, c.Runtime, append(run, inv.Args...)...)
+}
+
+func (c Container) Describe() string {
+	if c.Network {
+		return fmt.Sprintf("in a throwaway %s container, with the working directory read-only at /work", c.Image)
+	}
+	return fmt.Sprintf("in a throwaway %s container without network access, with the working directory read-only at /work", c.Image)
+}
+
+// Restricted runs commands on the host in new user and network namespaces,
+// so they can't gain privileges (sudo and setuid binaries fail) or reach
+// the network, with only PATH, HOME, LANG, and TERM in their environment
+// (plus any the invocation sets) so credentials held in environment
+// variables don't leak into them
+type Restricted struct {
+	Network bool
+}
+
+// restrictedEnv are the environment variables kept by Restricted
+var restrictedEnv = []string{"PATH", "HOME", "LANG", "TERM"}
+
+func (r Restricted) Command(ctx context.Context, inv Invocation) *exec.Cmd {
+	unshare := []string{"--user", "--map-root-user"}
+	if !r.Network {
+		unshare = append(unshare, "--net")
+	}
+	unshare = append(unshare, "--", inv.Name)
+	cmd := exec.CommandContext(ctx, "unshare", append(unshare, inv.Args...)...)
+	cmd.Dir = inv.Dir
+	cmd.Env = []string{}
+	for _, key := range restrictedEnv {
+		if v, ok := os.LookupEnv(key); ok {
+			cmd.Env = append(cmd.Env, key+"="+v)
+		}
+	}
+	cmd.Env = append(cmd.Env, inv.Env...)
+	return cmd
+}
+
+func (r Restricted) Describe() string {
+	if r.Network {
+		return "unprivileged, with a minimal environment"
+	}
+	return "unprivileged and without network access, with a minimal environment"
+}
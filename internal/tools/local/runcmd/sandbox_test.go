@@ -0,0 +1,134 @@
+package runcmd
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewSandbox(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		runtime string
+		image   string
+		want    Sandbox
+		wantErr bool
+	}{
+		{name: "default runs on the host", want: Host{}},
+		{name: "none", backend: "none", want: Host{}},
+		{name: "container defaults to docker", backend: "container", image: "alpine:3", want: Container{Runtime: "docker", Image: "alpine:3"}},
+		{name: "container with podman", backend: "container", runtime: "podman", image: "alpine:3", want: Container{Runtime: "podman", Image: "alpine:3"}},
+		{name: "container without image", backend: "container", wantErr: true},
+		{name: "restricted", backend: "restricted", want: Restricted{}},
+		{name: "unknown backend", backend: "jail", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSandbox(tt.backend, tt.runtime, tt.image, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSandbox() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewSandbox() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSandbox_Command(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		sandbox  Sandbox
+		inv      Invocation
+		want     []string // the full argv
+		wantEnv  bool     // whether the environment is restricted
+		notInEnv string
+	}{
+		{
+			name:    "host",
+			sandbox: Host{},
+			want:    []string{"ls", "-la"},
+		},
+		{
+			name:    "host with env",
+			sandbox: Host{},
+			inv:     Invocation{Env: []string{"KUBECONFIG=/tmp/kc"}},
+			want:    []string{"ls", "-la"},
+			wantEnv: true,
+		},
+		{
+			name:    "container without network",
+			sandbox: Container{Runtime: "docker", Image: "alpine:3"},
+			want: []string{"docker", "run", "--rm", "-i", "--read-only", "--cap-drop", "ALL", "--security-opt", "no-new-privileges",
+				"--memory", "512m", "--pids-limit", "256", "--network", "none", "-v", wd + ":/work:ro", "-w", "/work", "alpine:3", "ls", "-la"},
+		},
+		{
+			name:    "container with network",
+			sandbox: Container{Runtime: "podman", Image: "alpine:3", Network: true},
+			want: []string{"podman", "run", "--rm", "-i", "--read-only", "--cap-drop", "ALL", "--security-opt", "no-new-privileges",
+				"--memory", "512m", "--pids-limit", "256", "-v", wd + ":/work:ro", "-w", "/work", "alpine:3", "ls", "-la"},
+		},
+		{
+			name:    "container with dir and env",
+			sandbox: Container{Runtime: "docker", Image: "alpine:3"},
+			inv:     Invocation{Dir: "/srv/repo", Env: []string{"TZ=UTC"}},
+			want: []string{"docker", "run", "--rm", "-i", "--read-only", "--cap-drop", "ALL", "--security-opt", "no-new-privileges",
+				"--memory", "512m", "--pids-limit", "256", "--network", "none", "-e", "TZ=UTC", "-v", "/srv/repo:/work:ro", "-w", "/work", "alpine:3", "ls", "-la"},
+		},
+		{
+			name:     "restricted without network",
+			sandbox:  Restricted{},
+			want:     []string{"unshare", "--user", "--map-root-user", "--net", "--", "ls", "-la"},
+			wantEnv:  true,
+			notInEnv: "JOE_SANDBOX_SECRET",
+		},
+		{
+			name:     "restricted with network",
+			sandbox:  Restricted{Network: true},
+			want:     []string{"unshare", "--user", "--map-root-user", "--", "ls", "-la"},
+			wantEnv:  true,
+			notInEnv: "JOE_SANDBOX_SECRET",
+		},
+	}
+
+	t.Setenv("JOE_SANDBOX_SECRET", "hunter2")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.inv.Name, tt.inv.Args = "ls", []string{"-la"}
+			cmd := tt.sandbox.Command(context.Background(), tt.inv)
+			if !reflect.DeepEqual(cmd.Args, tt.want) {
+				t.Errorf("Command() args = %q, want %q", cmd.Args, tt.want)
+			}
+			if (cmd.Env != nil) != tt.wantEnv {
+				t.Errorf("Command() env restricted = %v, want %v", cmd.Env != nil, tt.wantEnv)
+			}
+			for _, kv := range tt.inv.Env {
+				if tt.wantEnv && !slices.Contains(cmd.Env, kv) {
+					t.Errorf("Command() env = %q, want %s", cmd.Env, kv)
+				}
+			}
+			if tt.notInEnv != "" && slices.ContainsFunc(cmd.Env, func(kv string) bool { return strings.HasPrefix(kv, tt.notInEnv+"=") }) {
+				t.Errorf("Command() env = %q, want no %s", cmd.Env, tt.notInEnv)
+			}
+		})
+	}
+}
+
+func TestTool_Sandboxed(t *testing.T) {
+	tool := New([]string{"echo"}, WithSandbox(Container{Runtime: "docker", Image: "alpine:3"}))
+	if desc := tool.Description(); !strings.Contains(desc, "alpine:3 container without network access") {
+		t.Errorf("Description() = %q, want the sandbox described", desc)
+	}
+	if desc := New([]string{"echo"}).Description(); strings.Contains(desc, "Commands run") {
+		t.Errorf("Description() = %q, want no sandbox on the host", desc)
+	}
+}
@@ -0,0 +1,234 @@
+package searchfiles
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	defaultMaxResults = 100
+	maxResultsLimit   = 500
+	maxFileSize       = 1 * 1024 * 1024 // 1MB
+	maxLineLength     = 200
+)
+
+// skipDirs are directories that are never searched
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	".venv":        true,
+	"__pycache__":  true,
+}
+
+type Tool struct{}
+
+type Match struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+func New() *Tool {
+	return &Tool{}
+}
+
+func (t *Tool) Name() string {
+	return "search_files"
+}
+
+func (t *Tool) Description() string {
+	return "Search files under a directory for a regular expression and return matching lines as file:line snippets. Use this instead of reading many files to find where something is defined or configured."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"pattern": {
+				Type:        "string",
+				Description: "Regular expression to search for (Go RE2 syntax)",
+			},
+			"path": {
+				Type:        "string",
+				Description: "Directory to search (defaults to current working directory, ~ expands to home directory)",
+			},
+			"include": {
+				Type:        "array",
+				Description: "Only search files whose name matches one of these globs, e.g. [\"*.go\", \"*.yaml\"]",
+				Items: &llm.Property{
+					Type: "string",
+				},
+			},
+			"ignore_case": {
+				Type:        "boolean",
+				Description: "Match case-insensitively (default false)",
+			},
+			"max_results": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Maximum number of matching lines (default %d, max %d)", defaultMaxResults, maxResultsLimit),
+			},
+		},
+		Required: []string{"pattern"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern parameter is required and must be a string")
+	}
+	if ignoreCase, _ := args["ignore_case"].(bool); ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	if pathArg, ok := args["path"].(string); ok && pathArg != "" {
+		root, err = local.ExpandPath(pathArg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand path: %w", err)
+		}
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("failed to stat path: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("path is not a directory: %s", root)
+	}
+
+	var include []string
+	if list, ok := args["include"].([]any); ok {
+		for _, item := range list {
+			if glob, ok := item.(string); ok && glob != "" {
+				if _, err := filepath.Match(glob, ""); err != nil {
+					return nil, fmt.Errorf("invalid include glob %q: %w", glob, err)
+				}
+				include = append(include, glob)
+			}
+		}
+	}
+
+	maxResults := defaultMaxResults
+	if n, ok := args["max_results"].(float64); ok && n > 0 {
+		maxResults = int(min(n, maxResultsLimit))
+	}
+
+	matches := []Match{}
+	filesSearched := 0
+	truncated := false
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable entries are skipped rather than failing the search
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			if path != root && skipDirs[d.Name()] {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !matchesAny(include, d.Name()) {
+			return nil
+		}
+
+		fileMatches, searched := searchFile(path, re, maxResults-len(matches)+1)
+		if !searched {
+			return nil
+		}
+		filesSearched++
+
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			rel = path
+		}
+		for _, m := range fileMatches {
+			if len(matches) == maxResults {
+				truncated = true
+				return fs.SkipAll
+			}
+			m.File = rel
+			matches = append(matches, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	return map[string]any{
+		"path":           root,
+		"matches":        matches,
+		"count":          len(matches),
+		"files_searched": filesSearched,
+		"truncated":      truncated,
+	}, nil
+}
+
+// matchesAny reports whether name matches one of the globs; no globs
+// matches everything
+func matchesAny(globs []string, name string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// searchFile returns up to limit matching lines. Large, unreadable, and
+// binary files are skipped and reported as not searched.
+func searchFile(path string, re *regexp.Regexp, limit int) ([]Match, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxFileSize {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 512)], 0) >= 0 {
+		return nil, false
+	}
+
+	var matches []Match
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if !re.MatchString(line) {
+			continue
+		}
+		text := strings.TrimSpace(line)
+		if len(text) > maxLineLength {
+			text = strings.ToValidUTF8(text[:maxLineLength], "") + "..."
+		}
+		matches = append(matches, Match{Line: lineNo, Text: text})
+		if len(matches) == limit {
+			break
+		}
+	}
+	return matches, true
+}
@@ -0,0 +1,85 @@
+package searchfiles
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "config.yaml"), "port: 8080\ntimeout: 30s\n")
+	writeFile(t, filepath.Join(root, "deploy", "app.yaml"), "replicas: 2\nTimeout: 60s\n")
+	writeFile(t, filepath.Join(root, "main.go"), "// timeout is set in config.yaml\n")
+	writeFile(t, filepath.Join(root, ".git", "config"), "timeout = 1\n")
+	writeFile(t, filepath.Join(root, "bin.dat"), "timeout\x00\x01")
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want []Match
+	}{
+		{
+			name: "include filter",
+			args: map[string]any{"pattern": "^timeout:", "include": []any{"*.yaml"}},
+			want: []Match{{File: "config.yaml", Line: 2, Text: "timeout: 30s"}},
+		},
+		{
+			name: "ignore case",
+			args: map[string]any{"pattern": "^timeout:", "ignore_case": true},
+			want: []Match{
+				{File: "config.yaml", Line: 2, Text: "timeout: 30s"},
+				{File: filepath.Join("deploy", "app.yaml"), Line: 2, Text: "Timeout: 60s"},
+			},
+		},
+		{
+			name: "skips .git and binary files",
+			args: map[string]any{"pattern": "timeout", "include": []any{"config", "*.dat", "*.go"}},
+			want: []Match{{File: "main.go", Line: 1, Text: "// timeout is set in config.yaml"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["path"] = root
+			result, err := New().Execute(context.Background(), tt.args)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if got := result.(map[string]any)["matches"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matches = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute_MaxResults(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "x\nx\nx\n")
+	writeFile(t, filepath.Join(root, "b.txt"), "x\n")
+
+	result, err := New().Execute(context.Background(), map[string]any{"pattern": "x", "path": root, "max_results": float64(2)})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	got := result.(map[string]any)
+	if got["count"] != 2 || got["truncated"] != true {
+		t.Errorf("count = %v, truncated = %v, want 2, true", got["count"], got["truncated"])
+	}
+
+	if _, err := New().Execute(context.Background(), map[string]any{"pattern": "(", "path": root}); err == nil {
+		t.Error("Execute() should reject an invalid pattern")
+	}
+}
@@ -0,0 +1,244 @@
+// Package sqlquery runs read-only SQL queries against configured Postgres,
+// MySQL, and SQLite databases
+package sqlquery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/go-sql-driver/mysql" // registers the "mysql" driver
+	_ "github.com/lib/pq"              // registers the "postgres" driver
+	_ "modernc.org/sqlite"             // registers the "sqlite" driver
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	queryTimeout   = 30 * time.Second // when the context has no deadline
+	defaultMaxRows = 200
+	maxCellSize    = 1024       // 1KB
+	maxResultSize  = 100 * 1024 // 100KB
+)
+
+// Database is how to reach one database
+type Database struct {
+	Driver string // postgres, mysql, or sqlite
+	DSN    string // Connection string, or for sqlite a file path
+	DSNEnv string // Environment variable holding the connection string, instead of DSN
+}
+
+type Tool struct {
+	databases map[string]Database
+	maxRows   int
+}
+
+// New creates the tool for databases, keyed by alias, returning at most
+// maxRows rows per query (a default if 0)
+func New(databases map[string]Database, maxRows int) *Tool {
+	if maxRows <= 0 {
+		maxRows = defaultMaxRows
+	}
+	return &Tool{
+		databases: databases,
+		maxRows:   maxRows,
+	}
+}
+
+func (t *Tool) Name() string {
+	return "sql_query"
+}
+
+func (t *Tool) Description() string {
+	// Sorted so the description is identical from turn to turn, as prompt
+	// caches need
+	var names []string
+	for _, alias := range slices.Sorted(maps.Keys(t.databases)) {
+		names = append(names, fmt.Sprintf("%s (%s)", alias, t.databases[alias].Driver))
+	}
+	return fmt.Sprintf("Run a read-only SQL query (SELECT, WITH, SHOW, EXPLAIN, or DESCRIBE; one statement) against a configured database (databases: %s), e.g. to count stuck rows in a jobs table. Returns at most %d rows; prefer COUNT(*) and GROUP BY over fetching rows to count them.", strings.Join(names, ", "), t.maxRows)
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"database": {
+				Type:        "string",
+				Description: "Database alias to query (must be a configured database)",
+			},
+			"query": {
+				Type:        "string",
+				Description: "SQL query in the database's dialect",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Rows to return (default and max %d)", t.maxRows),
+			},
+		},
+		Required: []string{"database", "query"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	alias, ok := args["database"].(string)
+	if !ok || alias == "" {
+		return nil, fmt.Errorf("database parameter is required and must be a string")
+	}
+	if _, ok := t.databases[alias]; !ok {
+		return nil, fmt.Errorf("unknown database %q. Configured databases: %s", alias, strings.Join(slices.Sorted(maps.Keys(t.databases)), ", "))
+	}
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query parameter is required and must be a string")
+	}
+	if err := checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	limit := t.maxRows
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(min(n, float64(t.maxRows)))
+	}
+
+	// Queries are occasional, so each gets its own connection rather than
+	// keeping a pool per database open for the life of the process
+	db, err := t.open(alias)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Apply the fallback timeout unless the caller (normally the executor)
+	// already set a deadline
+	execCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+	}
+
+	// The transaction is never committed, so even a statement that gets
+	// past checkReadOnly leaves nothing behind
+	tx, err := db.BeginTx(execCtx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", alias, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(execCtx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	var out [][]any
+	size := 0
+	truncated := false
+	for rows.Next() {
+		if len(out) == limit || size > maxResultSize {
+			truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		for i, v := range values {
+			values[i] = cell(v)
+			size += len(fmt.Sprint(values[i]))
+		}
+		out = append(out, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	result := map[string]any{
+		"database":  alias,
+		"columns":   columns,
+		"rows":      out,
+		"row_count": len(out),
+	}
+	if truncated {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Stopped after %d rows. Use COUNT(*), aggregates, or a narrower WHERE for the full picture.", len(out))
+	}
+	return result, nil
+}
+
+// open opens alias; the caller closes it
+func (t *Tool) open(alias string) (*sql.DB, error) {
+	d := t.databases[alias]
+	dsn := d.DSN
+	if d.DSNEnv != "" {
+		if dsn = os.Getenv(d.DSNEnv); dsn == "" {
+			return nil, fmt.Errorf("database %s: the connection string environment variable %q is not set", alias, d.DSNEnv)
+		}
+	}
+	if d.Driver == "sqlite" {
+		var err error
+		if dsn, err = sqliteDSN(dsn); err != nil {
+			return nil, fmt.Errorf("database %s: %w", alias, err)
+		}
+	}
+	db, err := sql.Open(d.Driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("database %s: %w", alias, err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// sqliteDSN opens path read-only, so SQLite itself refuses writes
+func sqliteDSN(path string) (string, error) {
+	path = strings.TrimPrefix(path, "file:")
+	path, _, _ = strings.Cut(path, "?")
+	abs, err := local.ExpandPath(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand path: %w", err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return "", fmt.Errorf("invalid sqlite database: %w", err)
+	}
+	u := url.URL{Scheme: "file", OmitHost: true, Path: abs, RawQuery: "mode=ro&_pragma=query_only(1)"}
+	return u.String(), nil
+}
+
+// cell makes a scanned value readable in JSON
+func cell(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		if !utf8.Valid(v) {
+			return fmt.Sprintf("(%d bytes of binary data)", len(v))
+		}
+		return truncate(string(v))
+	case string:
+		return truncate(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func truncate(s string) string {
+	if len(s) > maxCellSize {
+		return s[:maxCellSize] + "... (truncated)"
+	}
+	return s
+}
@@ -0,0 +1,149 @@
+package sqlquery
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// jobsDB creates a SQLite database with a jobs table, in a directory with
+// a space in its name
+func jobsDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "job queue", "jobs.db")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE jobs (id INTEGER, status TEXT, payload BLOB);
+		INSERT INTO jobs VALUES (1, 'done', 'x'), (2, 'stuck', NULL), (3, 'stuck', X'00FF'), (4, 'queued', NULL)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTool_Execute(t *testing.T) {
+	path := jobsDB(t)
+	t.Setenv("JOBS_DSN", path)
+
+	tests := []struct {
+		name          string
+		databases     map[string]Database
+		args          map[string]any
+		wantColumns   []string
+		wantRows      [][]any
+		wantTruncated bool
+		wantErr       string
+	}{
+		{
+			name:        "count stuck jobs",
+			args:        map[string]any{"database": "jobs", "query": "SELECT count(*) AS stuck FROM jobs WHERE status = 'stuck'"},
+			wantColumns: []string{"stuck"},
+			wantRows:    [][]any{{int64(2)}},
+		},
+		{
+			name:          "limit",
+			args:          map[string]any{"database": "jobs", "query": "SELECT id, status FROM jobs ORDER BY id", "limit": float64(2)},
+			wantColumns:   []string{"id", "status"},
+			wantRows:      [][]any{{int64(1), "done"}, {int64(2), "stuck"}},
+			wantTruncated: true,
+		},
+		{
+			name:        "binary and null values",
+			args:        map[string]any{"database": "jobs", "query": "SELECT payload FROM jobs WHERE id IN (2, 3) ORDER BY id"},
+			wantColumns: []string{"payload"},
+			wantRows:    [][]any{{nil}, {"(2 bytes of binary data)"}},
+		},
+		{
+			name:        "connection string from the environment",
+			databases:   map[string]Database{"jobs": {Driver: "sqlite", DSNEnv: "JOBS_DSN"}},
+			args:        map[string]any{"database": "jobs", "query": "SELECT max(id) FROM jobs"},
+			wantColumns: []string{"max(id)"},
+			wantRows:    [][]any{{int64(4)}},
+		},
+		{
+			name:      "environment variable not set",
+			databases: map[string]Database{"jobs": {Driver: "sqlite", DSNEnv: "UNSET_JOBS_DSN"}},
+			args:      map[string]any{"database": "jobs", "query": "SELECT 1"},
+			wantErr:   `"UNSET_JOBS_DSN" is not set`,
+		},
+		{
+			name:    "write refused",
+			args:    map[string]any{"database": "jobs", "query": "DELETE FROM jobs"},
+			wantErr: "only SELECT",
+		},
+		{
+			name:    "unknown database",
+			args:    map[string]any{"database": "billing", "query": "SELECT 1"},
+			wantErr: `unknown database "billing". Configured databases: jobs`,
+		},
+		{
+			name:    "syntax error",
+			args:    map[string]any{"database": "jobs", "query": "SELECT FROM"},
+			wantErr: "query failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			databases := tt.databases
+			if databases == nil {
+				databases = map[string]Database{"jobs": {Driver: "sqlite", DSN: path}}
+			}
+			got, err := New(databases, 0).Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			result := got.(map[string]any)
+			if columns := result["columns"].([]string); !reflect.DeepEqual(columns, tt.wantColumns) {
+				t.Errorf("columns = %v, want %v", columns, tt.wantColumns)
+			}
+			if rows := result["rows"].([][]any); !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows = %v, want %v", rows, tt.wantRows)
+			}
+			if truncated, _ := result["truncated"].(bool); truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestTool_Execute_HugeLimit(t *testing.T) {
+	tool := New(map[string]Database{"jobs": {Driver: "sqlite", DSN: jobsDB(t)}}, 2)
+	got, err := tool.Execute(context.Background(), map[string]any{"database": "jobs", "query": "SELECT id FROM jobs", "limit": 1e300})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	result := got.(map[string]any)
+	if rows := result["rows"].([][]any); len(rows) != 2 || result["truncated"] != true {
+		t.Errorf("rows = %v, truncated = %v; want the 2 row cap kept", rows, result["truncated"])
+	}
+}
+
+func TestTool_openReadOnly(t *testing.T) {
+	tool := New(map[string]Database{"jobs": {Driver: "sqlite", DSN: jobsDB(t)}}, 0)
+	db, err := tool.open("jobs")
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	defer db.Close()
+	// Even past checkReadOnly, SQLite refuses to write
+	if _, err := db.Exec("DELETE FROM jobs"); err == nil {
+		t.Error("DELETE succeeded on a read-only connection")
+	}
+}
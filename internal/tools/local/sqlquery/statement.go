@@ -0,0 +1,130 @@
+package sqlquery
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// readStatements are the statements a query may start with
+var readStatements = map[string]bool{
+	"SELECT": true, "WITH": true, "SHOW": true, "EXPLAIN": true, "DESCRIBE": true, "DESC": true,
+}
+
+// writeKeywords may not appear anywhere in a query outside literals. They
+// catch writes hidden inside reads, such as Postgres's data-modifying CTEs
+// (WITH d AS (DELETE ...) SELECT ...), SELECT ... INTO, row locks, and
+// EXPLAIN ANALYZE, which runs the statement it explains.
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true, "REPLACE": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "RENAME": true,
+	"GRANT": true, "REVOKE": true, "INTO": true, "COPY": true, "CALL": true, "EXEC": true, "EXECUTE": true,
+	"LOCK": true, "FOR": true, "ANALYZE": true, "VACUUM": true, "ATTACH": true, "DETACH": true,
+	"PRAGMA": true, "SET": true, "LOAD": true,
+}
+
+// checkReadOnly returns an error unless query is a single statement that
+// only reads. It is a first line of defense; queries also run in a
+// read-only transaction. Postgres and MySQL disagree on backslashes in
+// strings, # comments, and dollar quotes, so the query must pass read
+// either way: text one of them would run can't hide in what the other
+// takes for a literal.
+func checkReadOnly(query string) error {
+	if strings.Contains(query, "/*!") {
+		return fmt.Errorf("MySQL executable comments (/*! ... */) are not allowed")
+	}
+	for _, mysql := range []bool{false, true} {
+		words, err := keywords(query, mysql)
+		if err != nil {
+			return err
+		}
+		if len(words) == 0 {
+			return fmt.Errorf("query is empty")
+		}
+		if !readStatements[words[0]] {
+			return fmt.Errorf("only SELECT, WITH, SHOW, EXPLAIN, and DESCRIBE queries are allowed, not %s", words[0])
+		}
+		for _, w := range words {
+			if writeKeywords[w] {
+				return fmt.Errorf("query contains %s, which is not allowed in a read-only query", w)
+			}
+		}
+	}
+	return nil
+}
+
+// keywords returns the unquoted words of query, upper-cased, skipping
+// string literals, quoted identifiers, and comments, as MySQL reads them
+// if mysql is set and as standard SQL (and Postgres) does otherwise. A ;
+// is only allowed at the end.
+func keywords(query string, mysql bool) ([]string, error) {
+	var words []string
+	rs := []rune(query)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			end := closing(rs, i+1, r, mysql)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %c quote", r)
+			}
+			i = end
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-' && (!mysql || i+2 == len(rs) || unicode.IsSpace(rs[i+2])),
+			r == '#' && mysql:
+			// MySQL only takes -- followed by a space as a comment
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			end := strings.Index(string(rs[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated /* comment")
+			}
+			i += 2 + len([]rune(string(rs[i+2:])[:end])) + 1
+		case r == '$' && !mysql && i+1 < len(rs) && (rs[i+1] == '$' || unicode.IsLetter(rs[i+1])):
+			// Postgres dollar quoting: $$...$$ or $tag$...$tag$
+			tagEnd := i + 1
+			for tagEnd < len(rs) && rs[tagEnd] != '$' && (unicode.IsLetter(rs[tagEnd]) || unicode.IsDigit(rs[tagEnd]) || rs[tagEnd] == '_') {
+				tagEnd++
+			}
+			if tagEnd >= len(rs) || rs[tagEnd] != '$' {
+				continue
+			}
+			tag := string(rs[i : tagEnd+1])
+			end := strings.Index(string(rs[tagEnd+1:]), tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %s quote", tag)
+			}
+			i = tagEnd + len([]rune(string(rs[tagEnd+1:])[:end])) + len([]rune(tag))
+		case r == ';':
+			if strings.TrimSpace(string(rs[i+1:])) != "" {
+				return nil, fmt.Errorf("only one statement may be run at a time")
+			}
+			i = len(rs)
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i+1 < len(rs) && (unicode.IsLetter(rs[i+1]) || unicode.IsDigit(rs[i+1]) || rs[i+1] == '_' || rs[i+1] == '$') {
+				i++
+			}
+			words = append(words, strings.ToUpper(string(rs[start:i+1])))
+		}
+	}
+	return words, nil
+}
+
+// closing returns the index of the quote ending the one opened before
+// start, where a doubled quote (or in MySQL, one after a backslash) is an
+// escaped one, or -1
+func closing(rs []rune, start int, quote rune, mysql bool) int {
+	for i := start; i < len(rs); i++ {
+		switch {
+		case rs[i] == '\\' && mysql && quote != '`':
+			i++
+		case rs[i] == quote && i+1 < len(rs) && rs[i+1] == quote:
+			i++
+		case rs[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
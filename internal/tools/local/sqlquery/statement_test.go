@@ -0,0 +1,52 @@
+package sqlquery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{name: "select", query: "SELECT status, count(*) FROM jobs WHERE status = 'stuck' GROUP BY status;"},
+		{name: "with", query: "with recent as (select * from jobs) select * from recent"},
+		{name: "explain", query: "EXPLAIN SELECT * FROM jobs"},
+		{name: "show", query: "SHOW TABLES"},
+		{name: "keywords in literals", query: `SELECT 'DELETE FROM jobs; DROP TABLE x' AS "update", ` + "`insert`" + ` FROM t`},
+		{name: "escaped quote", query: "SELECT 'it''s' FROM t"},
+		{name: "comments", query: "SELECT 1 -- DROP TABLE jobs\n/* ; DELETE */"},
+		{name: "dollar quoted", query: "SELECT $$text$$"},
+		{name: "placeholder-like dollar", query: "SELECT $1"},
+		{name: "update", query: "UPDATE jobs SET status = 'done'", wantErr: "not UPDATE"},
+		{name: "second statement", query: "SELECT 1; DELETE FROM jobs", wantErr: "only one statement"},
+		{name: "data-modifying CTE", query: "WITH d AS (DELETE FROM jobs RETURNING *) SELECT * FROM d", wantErr: "contains DELETE"},
+		{name: "select into", query: "SELECT * INTO backup FROM jobs", wantErr: "contains INTO"},
+		{name: "row locks", query: "SELECT * FROM jobs FOR UPDATE", wantErr: "contains FOR"},
+		{name: "explain analyze", query: "EXPLAIN ANALYZE DELETE FROM jobs", wantErr: "contains ANALYZE"},
+		{name: "backslash hides a statement in Postgres", query: `SELECT 'a\' ; DELETE FROM jobs; --'`, wantErr: "only one statement"},
+		{name: "backslash hides a statement in MySQL", query: `SELECT 'a\', 1 FROM t WHERE x = '; DELETE FROM jobs -- '`, wantErr: "only one statement"},
+		{name: "dollar quotes hide a statement in MySQL", query: "SELECT $t$ ; DELETE FROM jobs $t$", wantErr: "only one statement"},
+		{name: "hash hides a statement in Postgres", query: "SELECT 1 # 2; DELETE FROM jobs", wantErr: "only one statement"},
+		{name: "MySQL executable comment", query: "SELECT 1 /*! , sleep(10) */", wantErr: "executable comments"},
+		{name: "unterminated string", query: "SELECT 'abc", wantErr: "unterminated"},
+		{name: "empty", query: "  -- nothing\n", wantErr: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReadOnly(tt.query)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkReadOnly() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkReadOnly() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
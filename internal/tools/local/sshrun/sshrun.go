@@ -0,0 +1,246 @@
+// Package sshrun runs allowlisted commands on configured remote hosts
+// through the system ssh client
+package sshrun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	commandTimeout        = 30 * time.Second // when the context has no deadline
+	defaultConnectTimeout = 10 * time.Second
+	maxOutputSize         = 100 * 1024 // 100KB
+)
+
+// DefaultAllowedCommands are the read-only binaries ssh_run may execute
+// unless configured otherwise
+var DefaultAllowedCommands = []string{
+	"ls", "cat", "head", "tail", "grep", "find", "wc",
+	"df", "du", "ps", "uptime", "free", "uname", "hostname",
+	"journalctl", "dmesg",
+}
+
+// Host is where an alias connects to
+type Host struct {
+	Address string // host or host:port
+	User    string // "" uses ssh's default
+	KeyFile string // Private key; "" uses ssh's default keys and agent
+}
+
+type Tool struct {
+	hosts           map[string]Host
+	allowedCommands map[string]bool
+	connectTimeout  time.Duration
+	binary          string
+}
+
+// Option configures optional Tool behavior
+type Option func(*Tool)
+
+// WithConnectTimeout bounds how long connecting to a host may take
+func WithConnectTimeout(d time.Duration) Option {
+	return func(t *Tool) {
+		if d > 0 {
+			t.connectTimeout = d
+		}
+	}
+}
+
+// New creates the tool for hosts, keyed by alias, and the allowed
+// binaries. An empty allowed list uses DefaultAllowedCommands.
+func New(hosts map[string]Host, allowed []string, opts ...Option) *Tool {
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedCommands
+	}
+	allowedMap := make(map[string]bool)
+	for _, cmd := range allowed {
+		allowedMap[cmd] = true
+	}
+	t := &Tool{
+		hosts:           hosts,
+		allowedCommands: allowedMap,
+		connectTimeout:  defaultConnectTimeout,
+		binary:          "ssh",
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Tool) Name() string {
+	return "ssh_run"
+}
+
+func (t *Tool) Description() string {
+	// Sorted so the description is identical from turn to turn, as prompt
+	// caches need
+	hostList := slices.Sorted(maps.Keys(t.hosts))
+	allowedList := slices.Sorted(maps.Keys(t.allowedCommands))
+	return fmt.Sprintf("Run a read-only command on a remote host over SSH (hosts: %s; commands limited to: %s). Use this to investigate machines other than the local one.", strings.Join(hostList, ", "), strings.Join(allowedList, ", "))
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"host": {
+				Type:        "string",
+				Description: "Host alias to run on (must be a configured host)",
+			},
+			"command": {
+				Type:        "string",
+				Description: "Command to run (must be in allowed list)",
+			},
+			"args": {
+				Type:        "array",
+				Description: "Command arguments as an array of strings (optional). Each is passed as is; there is no shell expansion, so globs, variables, and pipes don't work.",
+				Items: &llm.Property{
+					Type:        "string",
+					Description: "A command argument",
+				},
+			},
+		},
+		Required: []string{"host", "command"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	alias, ok := args["host"].(string)
+	if !ok || alias == "" {
+		return nil, fmt.Errorf("host parameter is required and must be a string")
+	}
+	host, ok := t.hosts[alias]
+	if !ok {
+		return nil, fmt.Errorf("unknown host %q. Configured hosts: %s", alias, strings.Join(slices.Sorted(maps.Keys(t.hosts)), ", "))
+	}
+	cmdName, ok := args["command"].(string)
+	if !ok || cmdName == "" {
+		return nil, fmt.Errorf("command parameter is required and must be a string")
+	}
+	if !t.allowedCommands[cmdName] {
+		allowedList := slices.Sorted(maps.Keys(t.allowedCommands))
+		return nil, fmt.Errorf("command '%s' is not allowed. Allowed: %s", cmdName, strings.Join(allowedList, ", "))
+	}
+	var cmdArgs []string
+	if argsList, ok := args["args"].([]any); ok {
+		for _, arg := range argsList {
+			if argStr, ok := arg.(string); ok {
+				cmdArgs = append(cmdArgs, argStr)
+			}
+		}
+	}
+	if err := local.CheckArgs(cmdName, cmdArgs); err != nil {
+		return nil, err
+	}
+
+	sshArgs, err := t.sshArgs(host, remoteCommand(cmdName, cmdArgs))
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the fallback timeout unless the caller (normally the executor)
+	// already set a deadline
+	execCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(execCtx, t.binary, sshArgs...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		switch {
+		case execCtx.Err() != nil:
+			return nil, fmt.Errorf("command on %s did not finish: %w", alias, execCtx.Err())
+		case !ok:
+			return nil, fmt.Errorf("failed to run ssh: %w", err)
+		case exitErr.ExitCode() == 255:
+			// ssh's own failures: refused connections, unknown host keys,
+			// rejected keys
+			return nil, fmt.Errorf("ssh to %s failed: %s", alias, strings.TrimSpace(stderr.String()))
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	stdoutStr, stdoutTruncated := truncate(stdout.String())
+	stderrStr, stderrTruncated := truncate(stderr.String())
+	result := map[string]any{
+		"host":      alias,
+		"command":   cmdName,
+		"args":      cmdArgs,
+		"stdout":    stdoutStr,
+		"stderr":    stderrStr,
+		"exit_code": exitCode,
+	}
+	if stdoutTruncated || stderrTruncated {
+		result["truncated"] = true
+	}
+	return result, nil
+}
+
+// sshArgs returns the ssh arguments that run command on host. BatchMode
+// makes ssh fail rather than prompt for passwords or unknown host keys.
+func (t *Tool) sshArgs(host Host, command string) ([]string, error) {
+	address, port := host.Address, ""
+	if h, p, err := net.SplitHostPort(host.Address); err == nil {
+		address, port = h, p
+	}
+	args := []string{
+		"-T",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=" + strconv.Itoa(int(t.connectTimeout.Seconds())),
+	}
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	if host.User != "" {
+		args = append(args, "-l", host.User)
+	}
+	if host.KeyFile != "" {
+		keyFile, err := local.ExpandPath(host.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand key file: %w", err)
+		}
+		args = append(args, "-i", keyFile, "-o", "IdentitiesOnly=yes")
+	}
+	return append(args, "--", address, command), nil
+}
+
+// remoteCommand quotes name and args for the remote shell, so each arrives
+// as a single literal word
+func remoteCommand(name string, args []string) string {
+	words := []string{quote(name)}
+	for _, arg := range args {
+		words = append(words, quote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func truncate(s string) (string, bool) {
+	if len(s) > maxOutputSize {
+		return s[:maxOutputSize] + "\n... (truncated at 100KB)", true
+	}
+	return s, false
+}
@@ -0,0 +1,146 @@
+package sshrun
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTool_sshArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		host Host
+		want []string
+	}{
+		{
+			name: "address only",
+			host: Host{Address: "db-1.internal"},
+			want: []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "--", "db-1.internal", "'uptime'"},
+		},
+		{
+			name: "port, user, and key",
+			host: Host{Address: "10.0.0.5:2222", User: "ops", KeyFile: "/keys/ops"},
+			want: []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "-p", "2222", "-l", "ops", "-i", "/keys/ops", "-o", "IdentitiesOnly=yes", "--", "10.0.0.5", "'uptime'"},
+		},
+		{
+			name: "IPv6 with port",
+			host: Host{Address: "[fd00::5]:22"},
+			want: []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=5", "-p", "22", "--", "fd00::5", "'uptime'"},
+		},
+	}
+
+	tool := New(nil, nil, WithConnectTimeout(5*time.Second))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.sshArgs(tt.host, "'uptime'")
+			if err != nil {
+				t.Fatalf("sshArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sshArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoteCommand(t *testing.T) {
+	got := remoteCommand("grep", []string{"-r", "it's $HOME; rm -rf /", "*.log"})
+	want := `'grep' '-r' 'it'\''s $HOME; rm -rf /' '*.log'`
+	if got != want {
+		t.Errorf("remoteCommand() = %s, want %s", got, want)
+	}
+}
+
+// fakeSSH writes a script standing in for ssh that prints its last
+// argument (the remote command) and exits with code
+func fakeSSH(t *testing.T, code int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ssh")
+	script := "#!/bin/sh\nfor last; do :; done\necho \"$last\"\necho denied >&2\nexit " + strconv.Itoa(code) + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTool_Execute(t *testing.T) {
+	hosts := map[string]Host{"db-1": {Address: "db-1.internal"}}
+
+	tests := []struct {
+		name       string
+		code       int
+		args       map[string]any
+		wantStdout string
+		wantExit   int
+		wantErr    string
+	}{
+		{
+			name:       "runs the quoted command",
+			args:       map[string]any{"host": "db-1", "command": "df", "args": []any{"-h", "/var"}},
+			wantStdout: "'df' '-h' '/var'\n",
+		},
+		{
+			name:       "reports the command's exit code",
+			code:       1,
+			args:       map[string]any{"host": "db-1", "command": "grep", "args": []any{"ERROR", "/var/log/app.log"}},
+			wantStdout: "'grep' 'ERROR' '/var/log/app.log'\n",
+			wantExit:   1,
+		},
+		{
+			name:    "ssh failure",
+			code:    255,
+			args:    map[string]any{"host": "db-1", "command": "uptime"},
+			wantErr: "ssh to db-1 failed: denied",
+		},
+		{
+			name:    "unknown host",
+			args:    map[string]any{"host": "web-9", "command": "uptime"},
+			wantErr: `unknown host "web-9". Configured hosts: db-1`,
+		},
+		{
+			name:    "command not allowed",
+			args:    map[string]any{"host": "db-1", "command": "rm", "args": []any{"-rf", "/"}},
+			wantErr: "command 'rm' is not allowed",
+		},
+		{
+			name:    "find running another command",
+			args:    map[string]any{"host": "db-1", "command": "find", "args": []any{"/etc", "-exec", "rm", "{}", ";"}},
+			wantErr: "find -exec is not allowed",
+		},
+		{
+			name:    "missing command",
+			args:    map[string]any{"host": "db-1"},
+			wantErr: "command parameter is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := New(hosts, nil)
+			tool.binary = fakeSSH(t, tt.code)
+
+			got, err := tool.Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			result := got.(map[string]any)
+			if result["stdout"] != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", result["stdout"], tt.wantStdout)
+			}
+			if result["exit_code"] != tt.wantExit {
+				t.Errorf("exit_code = %v, want %d", result["exit_code"], tt.wantExit)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+// Package systemd provides read-only tools for inspecting systemd units and
+// their journal logs on the local host
+package systemd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+const (
+	commandTimeout  = 30 * time.Second
+	defaultLogLines = 100
+	maxLogLines     = 1000
+	maxOutputSize   = 100 * 1024 // 100KB
+)
+
+// unitPattern matches unit names and globs; the leading character rules out
+// values that systemctl or journalctl would parse as flags
+var unitPattern = regexp.MustCompile(`^[A-Za-z0-9_@*?\[\]][A-Za-z0-9_@.:\-\\*?\[\]]*$`)
+
+// statusProperties are the unit properties systemd_unit_status reports
+var statusProperties = []string{
+	"Id", "Description", "LoadState", "ActiveState", "SubState",
+	"UnitFileState", "Result", "MainPID", "ExecMainStatus", "NRestarts",
+	"ActiveEnterTimestamp", "InactiveEnterTimestamp", "FragmentPath",
+}
+
+// runFunc runs a command and returns its stdout
+type runFunc func(ctx context.Context, name string, args ...string) (string, error)
+
+// execRunner runs commands directly, without a shell
+func execRunner(ctx context.Context, name string, args ...string) (string, error) {
+	execCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(execCtx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if execCtx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timed out after %s", name, commandTimeout)
+		}
+		if _, ok := err.(*exec.ExitError); !ok {
+			return "", fmt.Errorf("failed to run %s: %w", name, err)
+		}
+		// systemctl exits non-zero for inactive units; only fail when
+		// there is nothing useful on stdout
+		if stdout.Len() == 0 {
+			return "", fmt.Errorf("%s %s: %s", name, strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+		}
+	}
+	return stdout.String(), nil
+}
+
+// validUnit returns an error if unit is not a plausible unit name
+func validUnit(unit string) error {
+	if !unitPattern.MatchString(unit) {
+		return fmt.Errorf("invalid unit name %q", unit)
+	}
+	return nil
+}
+
+// UnitsTool lists systemd units
+type UnitsTool struct {
+	run runFunc
+}
+
+// NewUnitsTool creates the systemd_list_units tool
+func NewUnitsTool() *UnitsTool {
+	return &UnitsTool{run: execRunner}
+}
+
+func (t *UnitsTool) Name() string {
+	return "systemd_list_units"
+}
+
+func (t *UnitsTool) Description() string {
+	return "List systemd units on this host with their load, active, and sub states. Use state=failed to find broken services."
+}
+
+func (t *UnitsTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"type": {
+				Type:        "string",
+				Description: "Unit type, e.g. service, timer, socket (default service)",
+			},
+			"state": {
+				Type:        "string",
+				Description: "Only units in this state, e.g. running, failed, inactive (default all)",
+			},
+			"pattern": {
+				Type:        "string",
+				Description: "Only units matching this glob, e.g. nginx* or *.mount",
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *UnitsTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	unitType, _ := args["type"].(string)
+	if unitType == "" {
+		unitType = "service"
+	}
+	cmdArgs := []string{"list-units", "--all", "--no-legend", "--no-pager", "--plain", "--type=" + unitType}
+	if state, _ := args["state"].(string); state != "" {
+		cmdArgs = append(cmdArgs, "--state="+state)
+	}
+	if pattern, _ := args["pattern"].(string); pattern != "" {
+		if err := validUnit(pattern); err != nil {
+			return nil, err
+		}
+		cmdArgs = append(cmdArgs, pattern)
+	}
+
+	out, err := t.run(ctx, "systemctl", cmdArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	units := []map[string]any{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		units = append(units, map[string]any{
+			"unit":        fields[0],
+			"load":        fields[1],
+			"active":      fields[2],
+			"sub":         fields[3],
+			"description": strings.Join(fields[4:], " "),
+		})
+	}
+
+	return map[string]any{
+		"units": units,
+		"count": len(units),
+	}, nil
+}
+
+// StatusTool shows the state of one unit
+type StatusTool struct {
+	run runFunc
+}
+
+// NewStatusTool creates the systemd_unit_status tool
+func NewStatusTool() *StatusTool {
+	return &StatusTool{run: execRunner}
+}
+
+func (t *StatusTool) Name() string {
+	return "systemd_unit_status"
+}
+
+func (t *StatusTool) Description() string {
+	return "Show the status of a systemd unit: load/active/sub state, enablement, main PID, last exit status, restart count, and when it last changed state."
+}
+
+func (t *StatusTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"unit": {
+				Type:        "string",
+				Description: "Unit name, e.g. nginx.service (.service is assumed if omitted)",
+			},
+		},
+		Required: []string{"unit"},
+	}
+}
+
+func (t *StatusTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	unit, ok := args["unit"].(string)
+	if !ok || unit == "" {
+		return nil, fmt.Errorf("unit parameter is required")
+	}
+	if err := validUnit(unit); err != nil {
+		return nil, err
+	}
+
+	out, err := t.run(ctx, "systemctl", "show", "--no-pager", "--property="+strings.Join(statusProperties, ","), unit)
+	if err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			props[key] = value
+		}
+	}
+	if props["LoadState"] == "not-found" {
+		return nil, fmt.Errorf("unit %s not found", unit)
+	}
+
+	return map[string]any{
+		"unit":            props["Id"],
+		"description":     props["Description"],
+		"load_state":      props["LoadState"],
+		"active_state":    props["ActiveState"],
+		"sub_state":       props["SubState"],
+		"unit_file_state": props["UnitFileState"],
+		"result":          props["Result"],
+		"main_pid":        props["MainPID"],
+		"exit_status":     props["ExecMainStatus"],
+		"restarts":        props["NRestarts"],
+		"active_since":    props["ActiveEnterTimestamp"],
+		"inactive_since":  props["InactiveEnterTimestamp"],
+		"unit_file":       props["FragmentPath"],
+	}, nil
+}
+
+// JournalTool tails the journal for one unit
+type JournalTool struct {
+	run runFunc
+}
+
+// NewJournalTool creates the systemd_journal tool
+func NewJournalTool() *JournalTool {
+	return &JournalTool{run: execRunner}
+}
+
+func (t *JournalTool) Name() string {
+	return "systemd_journal"
+}
+
+func (t *JournalTool) Description() string {
+	return "Show recent journal log lines for a systemd unit, optionally since a time and filtered by priority."
+}
+
+func (t *JournalTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"unit": {
+				Type:        "string",
+				Description: "Unit name, e.g. nginx.service",
+			},
+			"lines": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Number of most recent lines (default %d, max %d)", defaultLogLines, maxLogLines),
+			},
+			"since": {
+				Type:        "string",
+				Description: "Only entries newer than this duration, e.g. 15m or 2h",
+			},
+			"priority": {
+				Type:        "string",
+				Description: "Only entries at this priority or more severe: emerg, alert, crit, err, warning, notice, info, debug",
+			},
+		},
+		Required: []string{"unit"},
+	}
+}
+
+func (t *JournalTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	unit, ok := args["unit"].(string)
+	if !ok || unit == "" {
+		return nil, fmt.Errorf("unit parameter is required")
+	}
+	if err := validUnit(unit); err != nil {
+		return nil, err
+	}
+
+	lines := defaultLogLines
+	if n, ok := args["lines"].(float64); ok && n > 0 {
+		lines = int(min(n, maxLogLines))
+	}
+
+	cmdArgs := []string{"--no-pager", "--output=short-iso", "--unit=" + unit, fmt.Sprintf("--lines=%d", lines)}
+	if since, _ := args["since"].(string); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since duration %q: %w", since, err)
+		}
+		cmdArgs = append(cmdArgs, fmt.Sprintf("--since=-%ds", int(d.Seconds())))
+	}
+	if priority, _ := args["priority"].(string); priority != "" {
+		if !validPriority(priority) {
+			return nil, fmt.Errorf("invalid priority %q", priority)
+		}
+		cmdArgs = append(cmdArgs, "--priority="+priority)
+	}
+
+	out, err := t.run(ctx, "journalctl", cmdArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	truncated := false
+	if len(out) > maxOutputSize {
+		out = out[len(out)-maxOutputSize:]
+		truncated = true
+	}
+
+	count := strings.Count(out, "\n")
+	if strings.HasPrefix(out, "-- No entries --") {
+		count = 0
+	}
+
+	return map[string]any{
+		"unit":      unit,
+		"logs":      out,
+		"lines":     count,
+		"truncated": truncated,
+	}, nil
+}
+
+func validPriority(p string) bool {
+	switch p {
+	case "emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+		"0", "1", "2", "3", "4", "5", "6", "7":
+		return true
+	}
+	return false
+}
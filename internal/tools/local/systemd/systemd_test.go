@@ -0,0 +1,118 @@
+package systemd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeRunner returns canned output and records the command it was given
+type fakeRunner struct {
+	output string
+	name   string
+	args   []string
+}
+
+func (f *fakeRunner) run(ctx context.Context, name string, args ...string) (string, error) {
+	f.name = name
+	f.args = args
+	return f.output, nil
+}
+
+func TestUnitsTool(t *testing.T) {
+	fake := &fakeRunner{output: "nginx.service loaded failed failed A high performance web server\n" +
+		"sshd.service  loaded active running OpenSSH Daemon\n"}
+	tool := &UnitsTool{run: fake.run}
+
+	result, err := tool.Execute(context.Background(), map[string]any{"state": "failed"})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	units := result.(map[string]any)["units"].([]map[string]any)
+	want := map[string]any{
+		"unit":        "nginx.service",
+		"load":        "loaded",
+		"active":      "failed",
+		"sub":         "failed",
+		"description": "A high performance web server",
+	}
+	if len(units) != 2 || !reflect.DeepEqual(units[0], want) {
+		t.Errorf("units = %v", units)
+	}
+	if fake.name != "systemctl" || !contains(fake.args, "--state=failed") || !contains(fake.args, "--type=service") {
+		t.Errorf("ran %s %v", fake.name, fake.args)
+	}
+}
+
+func TestStatusTool(t *testing.T) {
+	tests := []struct {
+		name    string
+		unit    string
+		output  string
+		wantErr string
+	}{
+		{"active unit", "nginx.service", "Id=nginx.service\nLoadState=loaded\nActiveState=active\nSubState=running\nNRestarts=3\n", ""},
+		{"unknown unit", "nope.service", "Id=nope.service\nLoadState=not-found\n", "not found"},
+		{"flag injection", "--root=/", "", "invalid unit name"},
+		{"missing unit", "", "", "unit parameter is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &StatusTool{run: (&fakeRunner{output: tt.output}).run}
+			result, err := tool.Execute(context.Background(), map[string]any{"unit": tt.unit})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			got := result.(map[string]any)
+			if got["sub_state"] != "running" || got["restarts"] != "3" {
+				t.Errorf("status = %v", got)
+			}
+		})
+	}
+}
+
+func TestJournalTool(t *testing.T) {
+	fake := &fakeRunner{output: "2024-03-02T09:00:00+0000 host nginx[1]: start\n2024-03-02T09:00:01+0000 host nginx[1]: bind() failed\n"}
+	tool := &JournalTool{run: fake.run}
+
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"unit":     "nginx.service",
+		"lines":    float64(5000),
+		"since":    "15m",
+		"priority": "err",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if got := result.(map[string]any)["lines"]; got != 2 {
+		t.Errorf("lines = %v, want 2", got)
+	}
+
+	for _, want := range []string{"--unit=nginx.service", "--lines=1000", "--since=-900s", "--priority=err"} {
+		if !contains(fake.args, want) {
+			t.Errorf("journalctl args %v missing %s", fake.args, want)
+		}
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"unit": "nginx", "priority": "loud"}); err == nil {
+		t.Error("Execute() should reject unknown priorities")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
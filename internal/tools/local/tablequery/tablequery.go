@@ -0,0 +1,562 @@
+// Package tablequery summarizes and queries CSV and TSV files, such as
+// billing exports and inventory dumps
+package tablequery
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	maxFileSize  = 20 * 1024 * 1024 // 20MB
+	defaultLimit = 20
+	maxLimit     = 500
+)
+
+type Tool struct{}
+
+func New() *Tool {
+	return &Tool{}
+}
+
+func (t *Tool) Name() string {
+	return "query_table"
+}
+
+func (t *Tool) Description() string {
+	return "Summarize and query a CSV or TSV file with a header row, such as a billing export or inventory dump. Always returns the columns with their types and the row count; can filter rows, pick columns, sort, and sum, average, count, or take the min or max of columns, optionally grouped by a column. Compute totals here rather than adding numbers up yourself."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "CSV or TSV file (~ expands to home directory)",
+			},
+			"columns": {
+				Type:        "array",
+				Description: "Columns to return for each row (optional; default all)",
+				Items:       &llm.Property{Type: "string", Description: "A column name"},
+			},
+			"where": {
+				Type:        "array",
+				Description: "Conditions rows must all meet, as column op value with op one of == != < <= > >= ~ (contains), e.g. \"service == AmazonEC2\" or \"cost > 100\"",
+				Items:       &llm.Property{Type: "string", Description: "A condition"},
+			},
+			"group_by": {
+				Type:        "string",
+				Description: "Column to group rows by for aggregates (optional)",
+			},
+			"aggregates": {
+				Type:        "array",
+				Description: "Aggregates to compute, as count or function:column with function sum, avg, min, or max, e.g. \"sum:cost\"",
+				Items:       &llm.Property{Type: "string", Description: "An aggregate"},
+			},
+			"sort_by": {
+				Type:        "string",
+				Description: "Column (or aggregate, e.g. sum:cost) to sort by; prefix with - for descending (optional)",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Rows to return (default %d, max %d)", defaultLimit, maxLimit),
+			},
+			"offset": {
+				Type:        "integer",
+				Description: "Rows to skip, to page through results (optional)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+// table is a parsed file
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (tb *table) column(name string) (int, error) {
+	i := slices.Index(tb.header, name)
+	if i < 0 {
+		return 0, fmt.Errorf("unknown column %q (columns: %s)", name, strings.Join(tb.header, ", "))
+	}
+	return i, nil
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pathArg, ok := args["path"].(string)
+	if !ok || pathArg == "" {
+		return nil, fmt.Errorf("path parameter is required and must be a string")
+	}
+	absPath, err := local.ExpandPath(pathArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+	tb, err := load(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := tb.rows
+	for _, w := range stringList(args["where"]) {
+		cond, err := parseCondition(tb, w)
+		if err != nil {
+			return nil, err
+		}
+		rows = slices.DeleteFunc(slices.Clone(rows), func(r []string) bool { return !cond.holds(r) })
+	}
+
+	limit := defaultLimit
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(min(n, maxLimit))
+	}
+	offset := 0
+	if n, ok := args["offset"].(float64); ok && n > 0 {
+		offset = int(min(n, math.MaxInt32)) // Converting a larger float could overflow
+	}
+
+	result := map[string]any{
+		"path":         absPath,
+		"schema":       schemaOf(tb),
+		"total_rows":   len(tb.rows),
+		"matched_rows": len(rows),
+	}
+
+	groupBy, _ := args["group_by"].(string)
+	aggregates := stringList(args["aggregates"])
+	sortBy, _ := args["sort_by"].(string)
+	desc := strings.HasPrefix(sortBy, "-")
+	sortBy = strings.TrimPrefix(sortBy, "-")
+	var outColumns []string
+	var out [][]any
+	if groupBy != "" || len(aggregates) > 0 {
+		outColumns, out, err = aggregate(tb, rows, groupBy, aggregates)
+		if err != nil {
+			return nil, err
+		}
+		if sortBy != "" {
+			i := slices.Index(outColumns, sortBy)
+			if i < 0 {
+				return nil, fmt.Errorf("cannot sort by %q (columns: %s)", sortBy, strings.Join(outColumns, ", "))
+			}
+			slices.SortStableFunc(out, func(a, b []any) int { return order(a[i], b[i], desc) })
+		}
+	} else {
+		// Sort before projecting so any column can be sorted by
+		if sortBy != "" {
+			i, err := tb.column(sortBy)
+			if err != nil {
+				return nil, err
+			}
+			rows = slices.Clone(rows)
+			slices.SortStableFunc(rows, func(a, b []string) int { return order(value(a[i]), value(b[i]), desc) })
+		}
+		outColumns, out, err = project(tb, rows, stringList(args["columns"]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	total := len(out)
+	start := min(offset, total)
+	out = out[start : start+min(limit, total-start)]
+	result["columns"] = outColumns
+	result["rows"] = out
+	if offset+len(out) < total {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Showing %d of %d rows from offset %d. Use offset %d for more.", len(out), total, offset, offset+len(out))
+	}
+	return result, nil
+}
+
+// load reads a CSV or TSV file; the delimiter is chosen by extension, else
+// by which of comma, tab, and semicolon the header has most of
+func load(path string) (*table, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", path)
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.Size() > maxFileSize {
+		return nil, fmt.Errorf("file too large (%.1fMB), max %dMB supported", float64(info.Size())/(1024*1024), maxFileSize/(1024*1024))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel's byte order mark
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delimiter(path, data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	tb := &table{header: records[0]}
+	for i, name := range tb.header {
+		tb.header[i] = strings.TrimSpace(name)
+	}
+	for _, rec := range records[1:] {
+		// Pad or cut short rows so every row has every column
+		row := make([]string, len(tb.header))
+		copy(row, rec)
+		tb.rows = append(tb.rows, row)
+	}
+	return tb, nil
+}
+
+func delimiter(path string, data []byte) rune {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".tab":
+		return '\t'
+	}
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	best, count := ',', bytes.Count(header, []byte(","))
+	for _, d := range []rune{'\t', ';'} {
+		if n := bytes.Count(header, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// column types reported in the schema
+const (
+	typeInteger = "integer"
+	typeNumber  = "number"
+	typeString  = "string"
+	typeEmpty   = "empty"
+)
+
+// schemaOf describes each column: its type, how many values are empty,
+// and an example value
+func schemaOf(tb *table) []map[string]any {
+	schema := make([]map[string]any, len(tb.header))
+	for i, name := range tb.header {
+		typ, empty, example := typeEmpty, 0, ""
+		for _, row := range tb.rows {
+			v := strings.TrimSpace(row[i])
+			if v == "" {
+				empty++
+				continue
+			}
+			if example == "" {
+				example = v
+			}
+			switch {
+			case typ == typeString:
+			case isInteger(v) && typ != typeNumber:
+				typ = typeInteger
+			case isNumber(v):
+				typ = typeNumber
+			default:
+				typ = typeString
+			}
+		}
+		col := map[string]any{"name": name, "type": typ, "empty": empty}
+		if example != "" {
+			col["example"] = example
+		}
+		schema[i] = col
+	}
+	return schema
+}
+
+func isInteger(v string) bool {
+	_, err := strconv.ParseInt(v, 10, 64)
+	return err == nil
+}
+
+func isNumber(v string) bool {
+	_, ok := number(v)
+	return ok
+}
+
+// number parses v as a number, allowing a currency sign, thousands
+// separators, and a percent sign, as exports often have
+func number(v string) (float64, bool) {
+	v = strings.TrimSpace(v)
+	v = strings.TrimLeft(v, "$€£")
+	v = strings.TrimSuffix(v, "%")
+	v = strings.ReplaceAll(v, ",", "")
+	f, err := strconv.ParseFloat(v, 64)
+	return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// value converts a cell to a number when it is one, so results sort and
+// read as numbers
+func value(v string) any {
+	if f, ok := number(v); ok {
+		return f
+	}
+	return v
+}
+
+// project returns rows with only columns, or every column
+func project(tb *table, rows [][]string, columns []string) ([]string, [][]any, error) {
+	if len(columns) == 0 {
+		columns = tb.header
+	}
+	idx := make([]int, len(columns))
+	for i, name := range columns {
+		var err error
+		if idx[i], err = tb.column(name); err != nil {
+			return nil, nil, err
+		}
+	}
+	out := make([][]any, len(rows))
+	for r, row := range rows {
+		out[r] = make([]any, len(idx))
+		for i, c := range idx {
+			out[r][i] = value(row[c])
+		}
+	}
+	return columns, out, nil
+}
+
+// aggregate computes aggregates over rows, per value of groupBy if set.
+// Non-numeric cells are left out of sums, averages, minimums, and maximums.
+func aggregate(tb *table, rows [][]string, groupBy string, aggregates []string) ([]string, [][]any, error) {
+	if len(aggregates) == 0 {
+		aggregates = []string{"count"}
+	}
+	type agg struct {
+		fn  string
+		col int
+	}
+	aggs := make([]agg, len(aggregates))
+	for i, a := range aggregates {
+		fn, col, _ := strings.Cut(a, ":")
+		switch fn {
+		case "count":
+			aggs[i] = agg{fn: fn, col: -1}
+			continue
+		case "sum", "avg", "min", "max":
+		default:
+			return nil, nil, fmt.Errorf("unknown aggregate %q (use count, sum:col, avg:col, min:col, or max:col)", a)
+		}
+		c, err := tb.column(col)
+		if err != nil {
+			return nil, nil, err
+		}
+		aggs[i] = agg{fn: fn, col: c}
+	}
+	groupCol := -1
+	if groupBy != "" {
+		var err error
+		if groupCol, err = tb.column(groupBy); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	type acc struct {
+		count         int
+		sum, min, max []float64
+		n             []int
+	}
+	var order []string
+	groups := make(map[string]*acc)
+	for _, row := range rows {
+		key := ""
+		if groupCol >= 0 {
+			key = row[groupCol]
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &acc{sum: make([]float64, len(aggs)), min: make([]float64, len(aggs)), max: make([]float64, len(aggs)), n: make([]int, len(aggs))}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.count++
+		for i, a := range aggs {
+			if a.col < 0 {
+				continue
+			}
+			f, ok := number(row[a.col])
+			if !ok {
+				continue
+			}
+			if g.n[i] == 0 || f < g.min[i] {
+				g.min[i] = f
+			}
+			if g.n[i] == 0 || f > g.max[i] {
+				g.max[i] = f
+			}
+			g.sum[i] += f
+			g.n[i]++
+		}
+	}
+	if groupCol < 0 && len(order) == 0 {
+		order = []string{""}
+		groups[""] = &acc{sum: make([]float64, len(aggs)), min: make([]float64, len(aggs)), max: make([]float64, len(aggs)), n: make([]int, len(aggs))}
+	}
+
+	var columns []string
+	if groupCol >= 0 {
+		columns = append(columns, groupBy)
+	}
+	columns = append(columns, aggregates...)
+	out := make([][]any, 0, len(order))
+	for _, key := range order {
+		g := groups[key]
+		var row []any
+		if groupCol >= 0 {
+			row = append(row, key)
+		}
+		for i, a := range aggs {
+			var v any
+			switch {
+			case a.fn == "count":
+				v = g.count
+			case g.n[i] == 0:
+				v = nil
+			case a.fn == "sum":
+				v = round(g.sum[i])
+			case a.fn == "avg":
+				v = round(g.sum[i] / float64(g.n[i]))
+			case a.fn == "min":
+				v = g.min[i]
+			case a.fn == "max":
+				v = g.max[i]
+			}
+			row = append(row, v)
+		}
+		out = append(out, row)
+	}
+	return columns, out, nil
+}
+
+// round drops the float noise sums pick up, e.g. 0.30000000000000004
+func round(f float64) float64 {
+	return math.Round(f*1e6) / 1e6
+}
+
+func order(a, b any, desc bool) int {
+	if desc {
+		return -compare(a, b)
+	}
+	return compare(a, b)
+}
+
+// compare orders numbers before strings, and nil last
+func compare(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	fa, aNum := toFloat(a)
+	fb, bNum := toFloat(b)
+	switch {
+	case aNum && bNum:
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// condition is a where clause
+type condition struct {
+	col   int
+	op    string
+	value string
+}
+
+var conditionPattern = regexp.MustCompile(`^\s*(.+?)\s*(==|!=|<=|>=|<|>|~)\s*(.*?)\s*$`)
+
+func parseCondition(tb *table, s string) (*condition, error) {
+	m := conditionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid condition %q, want e.g. \"cost > 100\"", s)
+	}
+	col, err := tb.column(m[1])
+	if err != nil {
+		return nil, err
+	}
+	return &condition{col: col, op: m[2], value: strings.Trim(m[3], `"'`)}, nil
+}
+
+// holds compares numerically when both sides are numbers, else as text
+// (case-insensitively for ~)
+func (c *condition) holds(row []string) bool {
+	cell := strings.TrimSpace(row[c.col])
+	if c.op == "~" {
+		return strings.Contains(strings.ToLower(cell), strings.ToLower(c.value))
+	}
+	cmp := strings.Compare(cell, c.value)
+	if a, ok := number(cell); ok {
+		if b, ok := number(c.value); ok {
+			cmp = compare(a, b)
+		}
+	}
+	switch c.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// stringList reads an array argument of strings
+func stringList(v any) []string {
+	list, _ := v.([]any)
+	var out []string
+	for _, item := range list {
+		if s, ok := item.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package tablequery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const billing = `service,region,cost,resources
+AmazonEC2,us-east-1,120.50,12
+AmazonS3,us-east-1,"1,000.25",3
+AmazonEC2,eu-west-1,80.10,8
+AWSLambda,us-east-1,0.20,
+`
+
+func TestTool_Execute(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "billing.csv")
+	if err := os.WriteFile(path, []byte(billing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tsv := filepath.Join(dir, "hosts.tsv")
+	if err := os.WriteFile(tsv, []byte("host\trole\nweb-1\tfrontend\ndb-1\tdatabase\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantColumns []string
+		wantRows    [][]any
+		wantErr     string
+	}{
+		{
+			name:        "selected columns",
+			args:        map[string]any{"path": path, "columns": []any{"service", "cost"}, "limit": float64(2)},
+			wantColumns: []string{"service", "cost"},
+			wantRows:    [][]any{{"AmazonEC2", 120.5}, {"AmazonS3", 1000.25}},
+		},
+		{
+			name:        "huge offset and limit",
+			args:        map[string]any{"path": path, "columns": []any{"service"}, "offset": float64(1e300), "limit": float64(1e300)},
+			wantColumns: []string{"service"},
+			wantRows:    [][]any{},
+		},
+		{
+			name:        "numeric where and sort",
+			args:        map[string]any{"path": path, "columns": []any{"service"}, "where": []any{"cost > 50"}, "sort_by": "-cost"},
+			wantColumns: []string{"service"},
+			wantRows:    [][]any{{"AmazonS3"}, {"AmazonEC2"}, {"AmazonEC2"}},
+		},
+		{
+			name:        "text where",
+			args:        map[string]any{"path": path, "columns": []any{"region"}, "where": []any{"service == AmazonEC2", "region ~ EU"}},
+			wantColumns: []string{"region"},
+			wantRows:    [][]any{{"eu-west-1"}},
+		},
+		{
+			name:        "grouped aggregates",
+			args:        map[string]any{"path": path, "group_by": "service", "aggregates": []any{"count", "sum:cost"}, "sort_by": "service"},
+			wantColumns: []string{"service", "count", "sum:cost"},
+			wantRows:    [][]any{{"AWSLambda", 1, 0.2}, {"AmazonEC2", 2, 200.6}, {"AmazonS3", 1, 1000.25}},
+		},
+		{
+			name:        "aggregates skip empty cells",
+			args:        map[string]any{"path": path, "aggregates": []any{"avg:resources", "max:resources"}},
+			wantColumns: []string{"avg:resources", "max:resources"},
+			wantRows:    [][]any{{round(23.0 / 3), 12.0}},
+		},
+		{
+			name:        "TSV by extension",
+			args:        map[string]any{"path": tsv},
+			wantColumns: []string{"host", "role"},
+			wantRows:    [][]any{{"web-1", "frontend"}, {"db-1", "database"}},
+		},
+		{
+			name:    "unknown column",
+			args:    map[string]any{"path": path, "columns": []any{"owner"}},
+			wantErr: `unknown column "owner"`,
+		},
+		{
+			name:    "invalid condition",
+			args:    map[string]any{"path": path, "where": []any{"cost"}},
+			wantErr: "invalid condition",
+		},
+		{
+			name:    "unknown aggregate",
+			args:    map[string]any{"path": path, "aggregates": []any{"median:cost"}},
+			wantErr: "unknown aggregate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New().Execute(context.Background(), tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			result := got.(map[string]any)
+			if columns := result["columns"].([]string); !reflect.DeepEqual(columns, tt.wantColumns) {
+				t.Errorf("columns = %v, want %v", columns, tt.wantColumns)
+			}
+			if rows := result["rows"].([][]any); !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows = %v, want %v", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestTool_ExecuteSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "billing.csv")
+	if err := os.WriteFile(path, []byte(billing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := New().Execute(context.Background(), map[string]any{"path": path, "limit": float64(1)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	result := got.(map[string]any)
+	if result["total_rows"] != 4 {
+		t.Errorf("total_rows = %v, want 4", result["total_rows"])
+	}
+	if result["truncated"] != true {
+		t.Error("expected truncated with limit 1")
+	}
+
+	types := map[string]string{}
+	for _, col := range result["schema"].([]map[string]any) {
+		types[col["name"].(string)] = col["type"].(string)
+	}
+	want := map[string]string{"service": "string", "region": "string", "cost": "number", "resources": "integer"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("schema types = %v, want %v", types, want)
+	}
+}
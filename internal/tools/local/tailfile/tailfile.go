@@ -0,0 +1,200 @@
+package tailfile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools/local"
+)
+
+const (
+	defaultLines = 50
+	maxLines     = 1000
+	maxReadSize  = 1 * 1024 * 1024 // bytes read per call
+)
+
+// Timestamp layouts recognized at the start of a line by since
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+type Tool struct{}
+
+func New() *Tool {
+	return &Tool{}
+}
+
+func (t *Tool) Name() string {
+	return "tail_file"
+}
+
+func (t *Tool) Description() string {
+	return "Show the end of a file, such as a log: its last lines, the lines since a timestamp, or the lines written since an earlier call. Every result has an offset; pass it back to see only what was written after it, e.g. to watch a log while the user retries a request."
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"path": {
+				Type:        "string",
+				Description: "Path to file (absolute or relative to current directory, ~ expands to home directory)",
+			},
+			"lines": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Number of lines to show from the end (default %d, max %d)", defaultLines, maxLines),
+			},
+			"offset": {
+				Type:        "integer",
+				Description: "Show lines written after this offset, from a previous tail_file result (optional)",
+			},
+			"since": {
+				Type:        "string",
+				Description: "Show lines whose timestamp is at or after this time, RFC 3339, e.g. 2024-03-02T09:00:00Z (optional; lines must start with a timestamp, read as UTC if it has no zone)",
+			},
+		},
+		Required: []string{"path"},
+	}
+}
+
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	pathArg, ok := args["path"].(string)
+	if !ok || pathArg == "" {
+		return nil, fmt.Errorf("path parameter is required and must be a string")
+	}
+	absPath, err := local.ExpandPath(pathArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand path: %w", err)
+	}
+
+	n := defaultLines
+	if v, ok := args["lines"].(float64); ok && v > 0 {
+		n = int(min(v, maxLines)) // Clamped first: converting a huge float could overflow
+	}
+	offset := int64(-1)
+	if v, ok := args["offset"].(float64); ok && v >= 0 {
+		offset = int64(min(v, 1<<53))
+	}
+	var since time.Time
+	if s, ok := args["since"].(string); ok && s != "" {
+		if since, err = time.Parse(time.RFC3339, s); err != nil {
+			return nil, fmt.Errorf("invalid since %q, want RFC 3339 such as 2024-03-02T09:00:00Z", s)
+		}
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", absPath)
+		}
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", absPath)
+	}
+	size := info.Size()
+
+	// A file smaller than the offset was truncated or rotated since the
+	// last call, so everything in it is new
+	rotated := offset > size
+	from := max(size-maxReadSize, 0)
+	switch {
+	case rotated:
+		from = 0
+	case offset >= 0:
+		from = max(offset, size-maxReadSize)
+	}
+	data, err := io.ReadAll(io.NewSectionReader(f, from, size-from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if bytes.IndexByte(data[:min(len(data), 512)], 0) >= 0 {
+		return nil, fmt.Errorf("file appears to be binary, not text: %s", absPath)
+	}
+
+	// Only whole lines are shown; a partial last line is left for the next
+	// call, and a partial first one (from cutting at maxReadSize) dropped
+	end := bytes.LastIndexByte(data, '\n') + 1
+	data = data[:end]
+	skipped := from > 0 && (offset < 0 || from > offset) && !rotated
+	if skipped {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	lines = lines[:len(lines)-1] // After the final newline
+	if !since.IsZero() {
+		lines = linesSince(lines, since)
+	}
+	truncated := skipped
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+		truncated = true
+	}
+
+	result := map[string]any{
+		"path":    absPath,
+		"content": strings.Join(lines, ""),
+		"lines":   len(lines),
+		"offset":  from + int64(end),
+	}
+	if rotated {
+		result["rotated"] = true
+	}
+	if truncated {
+		result["truncated"] = true
+		result["truncated_message"] = fmt.Sprintf("Only the last %d lines are shown.", len(lines))
+	}
+	return result, nil
+}
+
+// linesSince returns the lines from the first one stamped at or after
+// since. Lines without a timestamp, such as stack traces, go with the
+// line before them.
+func linesSince(lines []string, since time.Time) []string {
+	for i, line := range lines {
+		if ts, ok := parseTimestamp(line); ok && !ts.Before(since) {
+			return lines[i:]
+		}
+	}
+	return nil
+}
+
+// parseTimestamp reads a timestamp at the start of line, bracketed or not
+func parseTimestamp(line string) (time.Time, bool) {
+	line = strings.TrimLeft(line, "[")
+	field, _, _ := strings.Cut(line, " ")
+	for _, layout := range timestampLayouts {
+		candidate := field
+		if strings.Contains(layout, " ") {
+			// Date and time separated by a space: take two fields
+			if date, rest, ok := strings.Cut(line, " "); ok {
+				clock, _, _ := strings.Cut(rest, " ")
+				candidate = date + " " + clock
+			}
+		}
+		candidate = strings.TrimRight(candidate, "],")
+		if len(candidate) > len(layout) && layout != time.RFC3339Nano {
+			candidate = candidate[:len(layout)] // Drop fractions and zones
+		}
+		if ts, err := time.Parse(layout, candidate); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,142 @@
+package tailfile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTool_Execute(t *testing.T) {
+	log := "2024-03-02T09:00:00Z start\n" +
+		"2024-03-02T09:05:00Z request failed\n" +
+		"  at handler.go:12\n" +
+		"2024-03-02 09:10:00,123 retrying\n" +
+		"partial"
+
+	tests := []struct {
+		name        string
+		args        map[string]any
+		wantContent string
+		wantOffset  int64
+		wantErr     bool
+	}{
+		{
+			name:        "last lines",
+			args:        map[string]any{"lines": float64(2)},
+			wantContent: "  at handler.go:12\n2024-03-02 09:10:00,123 retrying\n",
+			wantOffset:  int64(len(log) - len("partial")),
+		},
+		{
+			name:        "since a timestamp keeps continuation lines",
+			args:        map[string]any{"since": "2024-03-02T09:05:00Z"},
+			wantContent: "2024-03-02T09:05:00Z request failed\n  at handler.go:12\n2024-03-02 09:10:00,123 retrying\n",
+			wantOffset:  int64(len(log) - len("partial")),
+		},
+		{
+			name:        "after an offset",
+			args:        map[string]any{"offset": float64(len("2024-03-02T09:00:00Z start\n"))},
+			wantContent: "2024-03-02T09:05:00Z request failed\n  at handler.go:12\n2024-03-02 09:10:00,123 retrying\n",
+			wantOffset:  int64(len(log) - len("partial")),
+		},
+		{
+			name:        "huge lines and offset",
+			args:        map[string]any{"lines": 1e300, "offset": 1e300},
+			wantContent: log[:len(log)-len("partial")],
+			wantOffset:  int64(len(log) - len("partial")),
+		},
+		{
+			name:    "invalid since",
+			args:    map[string]any{"since": "yesterday"},
+			wantErr: true,
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["path"] = path
+			got, err := New().Execute(context.Background(), tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			result := got.(map[string]any)
+			if result["content"] != tt.wantContent {
+				t.Errorf("content = %q, want %q", result["content"], tt.wantContent)
+			}
+			if result["offset"] != tt.wantOffset {
+				t.Errorf("offset = %v, want %d", result["offset"], tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestTool_Execute_Follow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := New()
+	tail := func(offset int64) map[string]any {
+		t.Helper()
+		got, err := tool.Execute(context.Background(), map[string]any{"path": path, "offset": float64(offset)})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		return got.(map[string]any)
+	}
+
+	first := tail(0)
+	offset := first["offset"].(int64)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("three\n")
+	f.Close()
+	if next := tail(offset); next["content"] != "three\n" {
+		t.Errorf("content after appending = %q, want only the new line", next["content"])
+	}
+
+	// Rotation: the file is now shorter than the offset
+	if err := os.WriteFile(path, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rotated := tail(offset + 6)
+	if rotated["content"] != "new\n" || rotated["rotated"] != true {
+		t.Errorf("after rotation = %v, want the whole new file flagged rotated", rotated)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"2024-03-02T09:00:00Z start", "2024-03-02T09:00:00Z", true},
+		{"2024-03-02T09:00:00.123+01:00 start", "2024-03-02T08:00:00.123Z", true},
+		{"[2024-03-02T09:00:00Z] start", "2024-03-02T09:00:00Z", true},
+		{"2024-03-02 09:00:00,123 INFO start", "2024-03-02T09:00:00Z", true},
+		{"  at handler.go:12", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseTimestamp(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseTimestamp() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got.UTC().Format(time.RFC3339Nano) != tt.want {
+				t.Errorf("parseTimestamp() = %s, want %s", got.UTC().Format(time.RFC3339Nano), tt.want)
+			}
+		})
+	}
+}
@@ -5,15 +5,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/jaimegago/joe/internal/llm"
 	"github.com/jaimegago/joe/internal/tools/local"
 )
 
-type Tool struct{}
+type Tool struct {
+	allowedPaths []string // empty allows any path
+}
 
-func New() *Tool {
-	return &Tool{}
+// New creates the tool. When allowedPaths is given, only files under one of
+// those directories may be changed.
+func New(allowedPaths ...string) *Tool {
+	return &Tool{allowedPaths: allowedPaths}
 }
 
 func (t *Tool) Name() string {
@@ -21,7 +26,7 @@ func (t *Tool) Name() string {
 }
 
 func (t *Tool) Description() string {
-	return "Write content to a file on the local filesystem. Creates the file if it doesn't exist, overwrites if it does. Parent directories are created automatically."
+	return "Write content to a file on the local filesystem. Creates the file if it doesn't exist, overwrites if it does. Parent directories are created automatically. To change part of an existing file, use edit_file instead."
 }
 
 func (t *Tool) Parameters() llm.ParameterSchema {
@@ -57,6 +62,9 @@ func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to expand path: %w", err)
 	}
+	if !local.PathAllowed(absPath, t.allowedPaths) {
+		return nil, fmt.Errorf("writing to %s is not allowed. Allowed paths: %s", absPath, strings.Join(t.allowedPaths, ", "))
+	}
 
 	// Check if file exists to determine if we're creating or overwriting
 	_, err = os.Stat(absPath)
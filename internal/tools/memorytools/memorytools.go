@@ -0,0 +1,124 @@
+// Package memorytools lets the LLM keep facts the user tells it across
+// sessions, e.g. "the prod database is db-prod-3 in us-east-1", and look
+// them up later
+package memorytools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+const (
+	defaultRecallLimit = 10
+	maxRecallLimit     = 50
+)
+
+// Facts is the part of memory.Memory the tools use
+type Facts interface {
+	AddFact(ctx context.Context, content string) (*store.Fact, error)
+	FindFacts(ctx context.Context, query string, limit int) ([]store.Fact, error)
+}
+
+// RememberTool stores a fact
+type RememberTool struct {
+	facts Facts
+}
+
+// NewRememberTool creates the remember tool
+func NewRememberTool(f Facts) *RememberTool {
+	return &RememberTool{facts: f}
+}
+
+func (t *RememberTool) Name() string {
+	return "remember"
+}
+
+func (t *RememberTool) Description() string {
+	return "Remember a fact about the user's infrastructure or preferences for later sessions, e.g. 'The prod database is db-prod-3 in us-east-1'. Only remember what the user stated or confirmed, not your own guesses, and write each fact so it stands on its own. The user can list and delete facts with /memory."
+}
+
+func (t *RememberTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"fact": {
+				Type:        "string",
+				Description: "The fact, in one self-contained sentence",
+			},
+		},
+		Required: []string{"fact"},
+	}
+}
+
+func (t *RememberTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	content, _ := args["fact"].(string)
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("fact parameter is required")
+	}
+	fact, err := t.facts.AddFact(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"id": fact.ID, "fact": fact.Content}, nil
+}
+
+// RecallTool looks facts up
+type RecallTool struct {
+	facts Facts
+}
+
+// NewRecallTool creates the recall tool
+func NewRecallTool(f Facts) *RecallTool {
+	return &RecallTool{facts: f}
+}
+
+func (t *RecallTool) Name() string {
+	return "recall"
+}
+
+func (t *RecallTool) Description() string {
+	return "Look up facts the user asked you to remember in earlier sessions, most relevant first, e.g. 'prod database' finds where the prod database runs. Use it before asking the user for something they may have told you already."
+}
+
+func (t *RecallTool) Parameters() llm.ParameterSchema {
+	return llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"query": {
+				Type:        "string",
+				Description: "What to look for (empty for the most recent facts)",
+			},
+			"limit": {
+				Type:        "integer",
+				Description: fmt.Sprintf("Maximum facts to return (default %d, max %d)", defaultRecallLimit, maxRecallLimit),
+			},
+		},
+		Required: []string{},
+	}
+}
+
+func (t *RecallTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	query, _ := args["query"].(string)
+	limit := defaultRecallLimit
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(min(n, maxRecallLimit))
+	}
+
+	facts, err := t.facts.FindFacts(ctx, strings.TrimSpace(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, len(facts))
+	for i, f := range facts {
+		out[i] = map[string]any{
+			"id":         f.ID,
+			"fact":       f.Content,
+			"remembered": f.CreatedAt.Format("2006-01-02"),
+		}
+	}
+	return map[string]any{"facts": out, "count": len(out)}, nil
+}
@@ -0,0 +1,75 @@
+package memorytools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// fakeFacts stores facts in order and finds them all
+type fakeFacts struct {
+	facts     []store.Fact
+	lastQuery string
+	lastLimit int
+}
+
+func (f *fakeFacts) AddFact(ctx context.Context, content string) (*store.Fact, error) {
+	fact := store.Fact{ID: int64(len(f.facts) + 1), Content: content, CreatedAt: time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)}
+	f.facts = append(f.facts, fact)
+	return &fact, nil
+}
+
+func (f *fakeFacts) FindFacts(ctx context.Context, query string, limit int) ([]store.Fact, error) {
+	f.lastQuery, f.lastLimit = query, limit
+	return f.facts, nil
+}
+
+func TestRememberTool(t *testing.T) {
+	fake := &fakeFacts{}
+	tool := NewRememberTool(fake)
+	result, err := tool.Execute(context.Background(), map[string]any{"fact": "The prod database is db-prod-3"})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	want := map[string]any{"id": int64(1), "fact": "The prod database is db-prod-3"}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Execute() = %v, want %v", result, want)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"fact": " "}); err == nil {
+		t.Error("Execute() without a fact should fail")
+	}
+}
+
+func TestRecallTool(t *testing.T) {
+	fake := &fakeFacts{}
+	fake.AddFact(context.Background(), "The prod database is db-prod-3")
+
+	tests := []struct {
+		name      string
+		args      map[string]any
+		wantQuery string
+		wantLimit int
+	}{
+		{name: "defaults", args: map[string]any{}, wantLimit: defaultRecallLimit},
+		{name: "query", args: map[string]any{"query": " prod database ", "limit": 3.0}, wantQuery: "prod database", wantLimit: 3},
+		{name: "capped", args: map[string]any{"limit": 500.0}, wantLimit: maxRecallLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewRecallTool(fake).Execute(context.Background(), tt.args)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if fake.lastQuery != tt.wantQuery || fake.lastLimit != tt.wantLimit {
+				t.Errorf("FindFacts(%q, %d), want (%q, %d)", fake.lastQuery, fake.lastLimit, tt.wantQuery, tt.wantLimit)
+			}
+			want := map[string]any{"count": 1, "facts": []map[string]any{{"id": int64(1), "fact": "The prod database is db-prod-3", "remembered": "2024-03-02"}}}
+			if !reflect.DeepEqual(result, want) {
+				t.Errorf("Execute() = %v, want %v", result, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ToolHandler runs a tool call
+type ToolHandler func(ctx context.Context, name string, args map[string]any) (any, error)
+
+// Middleware wraps a ToolHandler to add behavior around every tool call,
+// e.g. logging, metrics, or a dry run, without changing the tools. It may
+// run next, run it with different arguments, or answer the call itself.
+type Middleware func(next ToolHandler) ToolHandler
+
+// WithMiddleware wraps tool calls in mw. The first middleware is the
+// outermost; all of them run outside the executor's own approval, result
+// cache, and timeout, so they see denied and cached calls as well. Repeated
+// options add to the chain.
+func WithMiddleware(mw ...Middleware) ExecutorOption {
+	return func(e *Executor) {
+		e.middleware = append(e.middleware, mw...)
+	}
+}
+
+// chain wraps handler in mw, the first outermost
+func chain(handler ToolHandler, mw ...Middleware) ToolHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		handler = mw[i](handler)
+	}
+	return handler
+}
+
+// buildHandler assembles the handler Execute runs calls through: the
+// configured middleware, then approval, the result cache, and the tool
+// itself with its timeout
+func (e *Executor) buildHandler() ToolHandler {
+	builtin := []Middleware{e.approvalMiddleware, e.cacheMiddleware}
+	return chain(e.call, slices.Concat(e.middleware, builtin)...)
+}
+
+// call runs the tool registered as name with its timeout
+func (e *Executor) call(ctx context.Context, name string, args map[string]any) (any, error) {
+	tool, err := e.registry.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tool %s: %w", name, err)
+	}
+	result, err := e.run(ctx, tool, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute tool %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// approvalMiddleware runs only the calls checkApproval allows
+func (e *Executor) approvalMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, name string, args map[string]any) (any, error) {
+		if err := e.checkApproval(ctx, name, args); err != nil {
+			return nil, err
+		}
+		return next(ctx, name, args)
+	}
+}
+
+// cacheMiddleware answers calls from the result cache when it can and
+// stores the results of successful ones
+func (e *Executor) cacheMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, name string, args map[string]any) (any, error) {
+		key := e.cache.key(name, args)
+		if result, ok := e.cache.get(key); ok {
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("tool.cached", true))
+			return result, nil
+		}
+		result, err := next(ctx, name, args)
+		if err != nil {
+			return nil, err
+		}
+		e.cache.store(key, name, result)
+		return result, nil
+	}
+}
@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExecutor_Middleware(t *testing.T) {
+	// record returns a middleware noting when each call enters and leaves it
+	record := func(label string, log *[]string) Middleware {
+		return func(next ToolHandler) ToolHandler {
+			return func(ctx context.Context, name string, args map[string]any) (any, error) {
+				*log = append(*log, label+" "+name)
+				result, err := next(ctx, name, args)
+				*log = append(*log, label+" done")
+				return result, err
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		tool       string
+		middleware func(log *[]string) []Middleware
+		policies   map[string]ApprovalPolicy
+		want       any
+		wantErr    bool
+		wantRan    bool
+		wantLog    []string
+	}{
+		{
+			name: "first middleware is outermost",
+			tool: "echo",
+			middleware: func(log *[]string) []Middleware {
+				return []Middleware{record("a", log), record("b", log)}
+			},
+			want:    "hello",
+			wantRan: true,
+			wantLog: []string{"a echo", "b echo", "b done", "a done"},
+		},
+		{
+			name: "middleware can answer without running the tool",
+			tool: "echo",
+			middleware: func(log *[]string) []Middleware {
+				return []Middleware{func(next ToolHandler) ToolHandler {
+					return func(ctx context.Context, name string, args map[string]any) (any, error) {
+						return "dry run: " + name, nil
+					}
+				}}
+			},
+			want: "dry run: echo",
+		},
+		{
+			name: "middleware can change arguments",
+			tool: "echo",
+			middleware: func(log *[]string) []Middleware {
+				return []Middleware{func(next ToolHandler) ToolHandler {
+					return func(ctx context.Context, name string, args map[string]any) (any, error) {
+						return next(ctx, name, map[string]any{"message": "changed"})
+					}
+				}}
+			},
+			want:    "changed",
+			wantRan: true,
+		},
+		{
+			name: "middleware sees denied calls",
+			tool: "echo",
+			middleware: func(log *[]string) []Middleware {
+				return []Middleware{record("a", log)}
+			},
+			policies: map[string]ApprovalPolicy{"echo": PolicyDeny},
+			wantErr:  true,
+			wantLog:  []string{"a echo", "a done"},
+		},
+		{
+			name: "unknown tools never reach middleware",
+			tool: "nonexistent",
+			middleware: func(log *[]string) []Middleware {
+				return []Middleware{record("a", log)}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			ran := false
+			registry.Register(&mockTool{
+				name: "echo",
+				executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+					ran = true
+					return args["message"], nil
+				},
+			})
+
+			var log []string
+			executor := NewExecutor(registry,
+				WithApproval(tt.policies, nil, false),
+				WithMiddleware(tt.middleware(&log)...))
+
+			got, err := executor.Execute(context.Background(), tt.tool, map[string]any{"message": "hello"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+			if ran != tt.wantRan {
+				t.Errorf("tool ran = %v, want %v", ran, tt.wantRan)
+			}
+			if !reflect.DeepEqual(log, tt.wantLog) {
+				t.Errorf("middleware log = %q, want %q", log, tt.wantLog)
+			}
+		})
+	}
+}
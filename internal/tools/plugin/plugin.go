@@ -0,0 +1,228 @@
+// Package plugin adds tools from executables in a directory, so teams can
+// add their own without changing joe. Each executable describes one tool:
+//
+//	$ ~/.joe/tools/ticket --describe
+//	{"name": "ticket_lookup", "description": "...", "parameters": {"type": "object", "properties": {...}, "required": [...]}}
+//
+// To call the tool, joe runs the executable without arguments and writes
+// the call's arguments to its stdin as a JSON object. Whatever it prints to
+// stdout is the result, parsed if it is JSON; a non-zero exit status is an
+// error, reported with what it printed to stderr.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+const (
+	describeTimeout = 5 * time.Second
+	callTimeout     = 60 * time.Second // when the context has no deadline
+	maxOutputSize   = 100 * 1024       // 100KB
+)
+
+// validName is what every LLM provider accepts as a tool name
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Description is what an executable prints for --describe
+type Description struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Parameters  Schema `json:"parameters"`
+}
+
+// Schema is the JSON Schema of a tool's arguments, or one of them
+type Schema struct {
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	Properties  map[string]Schema `json:"properties"`
+	Required    []string          `json:"required"`
+	Items       *Schema           `json:"items"`
+}
+
+// Tool runs an executable as a tool
+type Tool struct {
+	path string
+	desc Description
+}
+
+// Compile-time check that Tool implements tools.Tool
+var _ tools.Tool = (*Tool)(nil)
+
+// Load asks the executable at path to describe its tool
+func Load(ctx context.Context, path string) (*Tool, error) {
+	ctx, cancel := context.WithTimeout(ctx, describeTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "--describe")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s --describe: %w%s", path, err, stderrSuffix(stderr.String()))
+	}
+
+	var desc Description
+	if err := json.Unmarshal(stdout.Bytes(), &desc); err != nil {
+		return nil, fmt.Errorf("%s --describe: invalid JSON: %w", path, err)
+	}
+	if !validName.MatchString(desc.Name) {
+		return nil, fmt.Errorf("%s --describe: invalid tool name %q (use letters, digits, _ and -)", path, desc.Name)
+	}
+	if desc.Description == "" {
+		return nil, fmt.Errorf("%s --describe: description is required", path)
+	}
+	if desc.Parameters.Type == "" {
+		desc.Parameters.Type = "object"
+	}
+	if desc.Parameters.Type != "object" {
+		return nil, fmt.Errorf("%s --describe: parameters must be an object schema, not %q", path, desc.Parameters.Type)
+	}
+	return &Tool{path: path, desc: desc}, nil
+}
+
+func (t *Tool) Name() string {
+	return t.desc.Name
+}
+
+// Description returns the plugin's description, noting where the tool lives
+func (t *Tool) Description() string {
+	return fmt.Sprintf("%s (via plugin %s)", t.desc.Description, filepath.Base(t.path))
+}
+
+func (t *Tool) Parameters() llm.ParameterSchema {
+	schema := llm.ParameterSchema{
+		Type:       "object",
+		Properties: make(map[string]llm.Property, len(t.desc.Parameters.Properties)),
+		Required:   t.desc.Parameters.Required,
+	}
+	for name, prop := range t.desc.Parameters.Properties {
+		schema.Properties[name] = convertProperty(prop)
+	}
+	return schema
+}
+
+// Execute runs the executable with args on its stdin
+func (t *Tool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	input, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	// Apply the fallback timeout unless the caller (normally the executor)
+	// already set a deadline
+	execCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, callTimeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(execCtx, t.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "JOE_TOOL_NAME="+t.desc.Name)
+	if err := cmd.Run(); err != nil {
+		if execCtx.Err() != nil {
+			return nil, fmt.Errorf("plugin %s did not finish: %w", t.desc.Name, execCtx.Err())
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w%s", t.desc.Name, err, stderrSuffix(stderr.String()))
+	}
+
+	out := stdout.Bytes()
+	if len(out) > maxOutputSize {
+		return map[string]any{
+			"output":    string(out[:maxOutputSize]) + "\n... (truncated at 100KB)",
+			"truncated": true,
+		}, nil
+	}
+	var result any
+	if json.Unmarshal(out, &result) == nil {
+		return result, nil
+	}
+	return map[string]any{"output": string(out)}, nil
+}
+
+// convertProperty maps a JSON Schema property onto llm.Property
+func convertProperty(prop Schema) llm.Property {
+	p := llm.Property{Type: prop.Type, Description: prop.Description}
+	if p.Type == "" {
+		p.Type = "string"
+	}
+	if prop.Items != nil {
+		items := convertProperty(*prop.Items)
+		p.Items = &items
+	}
+	return p
+}
+
+func stderrSuffix(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if len(stderr) > 1024 {
+		stderr = stderr[:1024] + "..."
+	}
+	if stderr == "" {
+		return ""
+	}
+	return ": " + stderr
+}
+
+// RegisterTools loads every executable in dir and adds its tool to
+// registry. Hidden files, files others may write to, and tools whose
+// names are already registered are skipped, so built-in tools keep
+// precedence. A missing dir is not an error. Returns the names of the
+// tools registered; the error joins the plugins that failed to load.
+func RegisterTools(ctx context.Context, dir string, registry *tools.Registry) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %w", err)
+	}
+
+	var registered []string
+	var errs []error
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path) // Follows symlinks
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		if info.Mode().Perm()&0o022 != 0 {
+			slog.Warn("plugin: skipping executable others may write to", "path", path, "mode", info.Mode().Perm())
+			continue
+		}
+
+		tool, err := Load(ctx, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := registry.Get(tool.Name()); err == nil {
+			slog.Warn("plugin: skipping tool that shadows an existing tool", "path", path, "tool", tool.Name())
+			continue
+		}
+		registry.Register(tool)
+		registered = append(registered, tool.Name())
+	}
+	return registered, errors.Join(errs...)
+}
@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+// writePlugin writes a shell script that prints describe for --describe
+// and otherwise runs body
+func writePlugin(t *testing.T, dir, name, describe, body string, mode os.FileMode) {
+	t.Helper()
+	script := "#!/bin/sh\nif [ \"$1\" = --describe ]; then\ncat <<'EOF'\n" + describe + "\nEOF\nexit 0\nfi\n" + body + "\n"
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(script), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil { // Not masked by umask
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterTools(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "ticket", `{"name": "ticket_lookup", "description": "Look up a ticket", "parameters": {"type": "object", "properties": {"id": {"type": "string", "description": "Ticket ID"}, "fields": {"type": "array", "items": {"type": "string"}}}, "required": ["id"]}}`,
+		`read args; echo "{\"args\": $args, \"tool\": \"$JOE_TOOL_NAME\"}"`, 0o755)
+	writePlugin(t, dir, "uptime-report", `{"name": "uptime_report", "description": "Report uptime"}`, `echo "up 3 days"`, 0o755)
+	writePlugin(t, dir, "broken", `not json`, ``, 0o755)
+	writePlugin(t, dir, "shadow", `{"name": "echo", "description": "Replaces echo"}`, ``, 0o755)
+	writePlugin(t, dir, "notes.txt", `{"name": "not_executable", "description": "x"}`, ``, 0o644)
+	writePlugin(t, dir, "writable", `{"name": "group_writable", "description": "x"}`, ``, 0o775)
+	writePlugin(t, dir, ".hidden", `{"name": "hidden", "description": "x"}`, ``, 0o755)
+
+	registry := tools.NewRegistry()
+	registry.Register(&builtin{})
+	registered, err := RegisterTools(context.Background(), dir, registry)
+	if err == nil || !strings.Contains(err.Error(), "broken --describe: invalid JSON") {
+		t.Errorf("RegisterTools() error = %v, want the broken plugin reported", err)
+	}
+	slices.Sort(registered)
+	if want := []string{"ticket_lookup", "uptime_report"}; !reflect.DeepEqual(registered, want) {
+		t.Fatalf("RegisterTools() = %v, want %v", registered, want)
+	}
+
+	ticket, _ := registry.Get("ticket_lookup")
+	wantParams := llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"id":     {Type: "string", Description: "Ticket ID"},
+			"fields": {Type: "array", Items: &llm.Property{Type: "string"}},
+		},
+		Required: []string{"id"},
+	}
+	if got := ticket.Parameters(); !reflect.DeepEqual(got, wantParams) {
+		t.Errorf("Parameters() = %+v, want %+v", got, wantParams)
+	}
+	if got := ticket.Description(); got != "Look up a ticket (via plugin ticket)" {
+		t.Errorf("Description() = %q", got)
+	}
+	got, err := ticket.Execute(context.Background(), map[string]any{"id": "OPS-42"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := map[string]any{"args": map[string]any{"id": "OPS-42"}, "tool": "ticket_lookup"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Execute() = %v, want %v", got, want)
+	}
+
+	uptime, _ := registry.Get("uptime_report")
+	got, err = uptime.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if want := map[string]any{"output": "up 3 days\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Execute() = %v, want %v", got, want)
+	}
+}
+
+func TestTool_ExecuteFailure(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "flaky", `{"name": "flaky", "description": "Fails"}`, `echo "ticket system unreachable" >&2; exit 3`, 0o755)
+	tool, err := Load(context.Background(), filepath.Join(dir, "flaky"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	_, err = tool.Execute(context.Background(), map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "exit status 3: ticket system unreachable") {
+		t.Errorf("Execute() error = %v, want exit status and stderr", err)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		describe string
+		wantErr  string
+	}{
+		{name: "bad name", describe: `{"name": "look up", "description": "x"}`, wantErr: `invalid tool name "look up"`},
+		{name: "no description", describe: `{"name": "lookup"}`, wantErr: "description is required"},
+		{name: "non-object parameters", describe: `{"name": "lookup", "description": "x", "parameters": {"type": "string"}}`, wantErr: "must be an object schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePlugin(t, dir, "plugin", tt.describe, ``, 0o755)
+			_, err := Load(context.Background(), filepath.Join(dir, "plugin"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterTools_MissingDir(t *testing.T) {
+	registered, err := RegisterTools(context.Background(), filepath.Join(t.TempDir(), "none"), tools.NewRegistry())
+	if err != nil || len(registered) != 0 {
+		t.Errorf("RegisterTools() = %v, %v, want nothing", registered, err)
+	}
+}
+
+// builtin stands in for a built-in tool a plugin might shadow
+type builtin struct{}
+
+func (builtin) Name() string                                         { return "echo" }
+func (builtin) Description() string                                  { return "Echo" }
+func (builtin) Parameters() llm.ParameterSchema                      { return llm.ParameterSchema{Type: "object"} }
+func (builtin) Execute(context.Context, map[string]any) (any, error) { return nil, nil }
@@ -0,0 +1,24 @@
+package tools
+
+// Redactor masks secrets in text
+type Redactor interface {
+	Redact(s string) string
+}
+
+// WithRedactor masks secrets in tool results before they become messages,
+// so they are never sent to the LLM, saved with the session, or written to
+// a transcript
+func WithRedactor(r Redactor) ExecutorOption {
+	return func(e *Executor) {
+		e.redactor = r
+	}
+}
+
+// Redact masks secrets in s with the WithRedactor redactor, for results
+// that reach a caller some other way than ResultsToMessages
+func (e *Executor) Redact(s string) string {
+	if e.redactor == nil {
+		return s
+	}
+	return e.redactor.Redact(s)
+}
@@ -2,37 +2,183 @@ package tools
 
 import (
 	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
 
 	"github.com/jaimegago/joe/internal/llm"
 )
 
-// Registry manages available tools
+// Registry manages available tools. Tools may belong to a namespace, such
+// as "aws" or an MCP server's name, which keeps tools of different origins
+// from colliding. Providers only accept letters, digits, _ and - in tool
+// names, so a namespaced tool is offered as namespace_name (aws_ec2_instances);
+// its qualified name, namespace.name (aws.ec2_instances), is accepted
+// wherever a name is.
 type Registry struct {
-	tools map[string]Tool
+	tools      map[string]Tool
+	disabled   map[string]bool   // Registered, but neither offered nor run
+	namespaces map[string]string // Tool name to namespace, for namespaced tools
 }
 
 // NewRegistry creates a new tool registry
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]Tool),
+		tools:      make(map[string]Tool),
+		disabled:   make(map[string]bool),
+		namespaces: make(map[string]string),
 	}
 }
 
 // Register adds a tool to the registry
 func (r *Registry) Register(tool Tool) {
 	r.tools[tool.Name()] = tool
+	delete(r.namespaces, tool.Name())
 }
 
-// Get retrieves a tool by name
+// RegisterIn adds a tool to namespace. Its name is prefixed with
+// namespace_ unless it already starts with it. Returns the name it was
+// registered under.
+func (r *Registry) RegisterIn(namespace string, tool Tool) string {
+	name := tool.Name()
+	if !strings.HasPrefix(name, namespace+"_") {
+		name = namespace + "_" + name
+		tool = &renamedTool{Tool: tool, name: name}
+	}
+	r.tools[name] = tool
+	r.namespaces[name] = namespace
+	return name
+}
+
+// renamedTool offers a tool under another name; calls still reach it as is
+type renamedTool struct {
+	Tool
+	name string
+}
+
+func (t *renamedTool) Name() string {
+	return t.name
+}
+
+// Namespace returns the namespace of the tool name, or "" for tools
+// registered without one
+func (r *Registry) Namespace(name string) string {
+	return r.namespaces[r.resolve(name)]
+}
+
+// Namespaces returns the namespaces with registered tools, sorted
+func (r *Registry) Namespaces() []string {
+	seen := make(map[string]bool)
+	for _, ns := range r.namespaces {
+		seen[ns] = true
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// QualifiedName returns namespace.name for a namespaced tool, and the name
+// unchanged otherwise
+func (r *Registry) QualifiedName(name string) string {
+	name = r.resolve(name)
+	ns, ok := r.namespaces[name]
+	if !ok {
+		return name
+	}
+	return ns + "." + strings.TrimPrefix(name, ns+"_")
+}
+
+// resolve turns a qualified name into the name the tool is registered
+// under; other names are returned unchanged
+func (r *Registry) resolve(name string) string {
+	if _, ok := r.tools[name]; ok {
+		return name
+	}
+	if ns, rest, ok := strings.Cut(name, "."); ok {
+		if registered := ns + "_" + rest; r.namespaces[registered] == ns {
+			return registered
+		}
+	}
+	return name
+}
+
+// Unregister removes a tool from the registry; unknown names are ignored
+func (r *Registry) Unregister(name string) {
+	name = r.resolve(name)
+	delete(r.tools, name)
+	delete(r.disabled, name)
+	delete(r.namespaces, name)
+}
+
+// UnregisterNamespace removes every tool in namespace
+func (r *Registry) UnregisterNamespace(namespace string) {
+	for name, ns := range r.namespaces {
+		if ns == namespace {
+			r.Unregister(name)
+		}
+	}
+}
+
+// UnregisterMatching removes every tool whose name or qualified name
+// matches one of patterns, which are exact names or path.Match globs such
+// as "docker_*" or "aws.*"
+func (r *Registry) UnregisterMatching(patterns []string) {
+	for name := range r.tools {
+		qualified := r.QualifiedName(name)
+		for _, pattern := range patterns {
+			ok, _ := path.Match(pattern, name)
+			if !ok {
+				ok, _ = path.Match(pattern, qualified)
+			}
+			if ok {
+				r.Unregister(name)
+				break
+			}
+		}
+	}
+}
+
+// Disable keeps a registered tool from being offered to the LLM or run,
+// until Enable
+func (r *Registry) Disable(name string) error {
+	name = r.resolve(name)
+	if _, ok := r.tools[name]; !ok {
+		return fmt.Errorf("tool not found: %s", name)
+	}
+	r.disabled[name] = true
+	return nil
+}
+
+// Enable undoes Disable
+func (r *Registry) Enable(name string) error {
+	name = r.resolve(name)
+	if _, ok := r.tools[name]; !ok {
+		return fmt.Errorf("tool not found: %s", name)
+	}
+	delete(r.disabled, name)
+	return nil
+}
+
+// IsDisabled reports whether the tool name was disabled
+func (r *Registry) IsDisabled(name string) bool {
+	return r.disabled[r.resolve(name)]
+}
+
+// Get retrieves a tool by name or qualified name
 func (r *Registry) Get(name string) (Tool, error) {
+	name = r.resolve(name)
 	tool, ok := r.tools[name]
 	if !ok {
 		return nil, fmt.Errorf("tool not found: %s", name)
 	}
+	if r.disabled[name] {
+		return nil, fmt.Errorf("tool is disabled: %s", name)
+	}
 	return tool, nil
 }
 
-// GetAll returns all registered tools
+// GetAll returns all registered tools, disabled ones included
 func (r *Registry) GetAll() []Tool {
 	tools := make([]Tool, 0, len(r.tools))
 	for _, tool := range r.tools {
@@ -41,15 +187,88 @@ func (r *Registry) GetAll() []Tool {
 	return tools
 }
 
-// ToDefinitions converts all registered tools to LLM tool definitions
+// ToDefinitions converts the enabled tools to LLM tool definitions, sorted
+// by name so requests are identical from turn to turn (which prompt
+// and response caches depend on)
 func (r *Registry) ToDefinitions() []llm.ToolDefinition {
 	definitions := make([]llm.ToolDefinition, 0, len(r.tools))
-	for _, tool := range r.tools {
+	for name, tool := range r.tools {
+		if r.disabled[name] {
+			continue
+		}
 		definitions = append(definitions, llm.ToolDefinition{
 			Name:        tool.Name(),
 			Description: tool.Description(),
 			Parameters:  tool.Parameters(),
 		})
 	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
 	return definitions
 }
+
+// RelevantDefinitions is ToDefinitions capped at limit tools, for providers
+// that accept only so many. Tools are ranked by how many words of query
+// appear in their name and description; tools outside any namespace, the
+// core ones, rank first. limit <= 0 means no cap. The result is sorted by
+// name, and stays the same as long as query does.
+func (r *Registry) RelevantDefinitions(query string, limit int) []llm.ToolDefinition {
+	definitions := r.ToDefinitions()
+	if limit <= 0 || len(definitions) <= limit {
+		return definitions
+	}
+
+	scores := make(map[string]int, len(definitions))
+	for _, def := range definitions {
+		scores[def.Name] = r.Relevance(query, def)
+		if r.namespaces[def.Name] == "" {
+			scores[def.Name] += 1000
+		}
+	}
+	ranked := slices.Clone(definitions)
+	slices.SortStableFunc(ranked, func(a, b llm.ToolDefinition) int {
+		return scores[b.Name] - scores[a.Name]
+	})
+	ranked = ranked[:limit]
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked
+}
+
+// Relevance scores how well def matches query: 3 for each word of query in
+// the tool's qualified name, and 1 for each in its description
+func (r *Registry) Relevance(query string, def llm.ToolDefinition) int {
+	words := make(map[string]bool)
+	for _, word := range splitWords(query) {
+		words[word] = true
+	}
+	n := 0
+	for _, word := range splitWords(r.QualifiedName(def.Name)) {
+		if words[word] {
+			n += 3 // A match in the name says more than one in the description
+		}
+	}
+	for _, word := range splitWords(def.Description) {
+		if words[word] {
+			n++
+		}
+	}
+	return n
+}
+
+// splitWords lowercases s and splits it into words of two or more letters
+// or digits; snake_case and dotted names split into their parts
+func splitWords(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if len(f) >= 2 {
+			words = append(words, f)
+		}
+	}
+	return words
+}
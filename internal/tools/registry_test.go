@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/jaimegago/joe/internal/llm"
@@ -240,6 +241,22 @@ func TestRegistry_ToDefinitions(t *testing.T) {
 			},
 			wantLen: 2,
 		},
+		{
+			name: "sorted by name",
+			register: []Tool{
+				&mockTool{name: "write_file"},
+				&mockTool{name: "ask_user"},
+				&mockTool{name: "read_file"},
+			},
+			wantLen: 3,
+			validate: func(t *testing.T, defs []llm.ToolDefinition) {
+				for i, want := range []string{"ask_user", "read_file", "write_file"} {
+					if defs[i].Name != want {
+						t.Errorf("defs[%d] = %s, want %s", i, defs[i].Name, want)
+					}
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -260,3 +277,126 @@ func TestRegistry_ToDefinitions(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistry_Disable(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockTool{name: "read_file"})
+	registry.Register(&mockTool{name: "run_command"})
+
+	if err := registry.Disable("run_command"); err != nil {
+		t.Fatalf("Disable() error: %v", err)
+	}
+	if err := registry.Disable("missing"); err == nil {
+		t.Error("Disable() of an unknown tool should fail")
+	}
+	if !registry.IsDisabled("run_command") {
+		t.Error("IsDisabled() = false after Disable")
+	}
+	if defs := registry.ToDefinitions(); len(defs) != 1 || defs[0].Name != "read_file" {
+		t.Errorf("ToDefinitions() = %+v, want read_file only", defs)
+	}
+	if _, err := registry.Get("run_command"); err == nil {
+		t.Error("Get() of a disabled tool should fail")
+	}
+	if len(registry.GetAll()) != 2 {
+		t.Error("GetAll() should still list disabled tools")
+	}
+
+	if err := registry.Enable("run_command"); err != nil {
+		t.Fatalf("Enable() error: %v", err)
+	}
+	if _, err := registry.Get("run_command"); err != nil || len(registry.ToDefinitions()) != 2 {
+		t.Errorf("run_command not back after Enable: %v", err)
+	}
+}
+
+func TestRegistry_RegisterIn(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockTool{name: "get_pods"})
+	if got := registry.RegisterIn("k8s", &mockTool{name: "get_pods"}); got != "k8s_get_pods" {
+		t.Errorf("RegisterIn() = %q, want k8s_get_pods", got)
+	}
+	if got := registry.RegisterIn("aws", &mockTool{name: "aws_ec2_instances"}); got != "aws_ec2_instances" {
+		t.Errorf("RegisterIn() of a prefixed tool = %q, want aws_ec2_instances", got)
+	}
+
+	tests := []struct {
+		name      string
+		lookup    string
+		wantName  string
+		namespace string
+		qualified string
+	}{
+		{name: "plain tool", lookup: "get_pods", wantName: "get_pods", qualified: "get_pods"},
+		{name: "namespaced by name", lookup: "k8s_get_pods", wantName: "k8s_get_pods", namespace: "k8s", qualified: "k8s.get_pods"},
+		{name: "namespaced by qualified name", lookup: "k8s.get_pods", wantName: "k8s_get_pods", namespace: "k8s", qualified: "k8s.get_pods"},
+		{name: "already prefixed", lookup: "aws.ec2_instances", wantName: "aws_ec2_instances", namespace: "aws", qualified: "aws.ec2_instances"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, err := registry.Get(tt.lookup)
+			if err != nil {
+				t.Fatalf("Get() error: %v", err)
+			}
+			if tool.Name() != tt.wantName {
+				t.Errorf("Name() = %q, want %q", tool.Name(), tt.wantName)
+			}
+			if got := registry.Namespace(tt.lookup); got != tt.namespace {
+				t.Errorf("Namespace() = %q, want %q", got, tt.namespace)
+			}
+			if got := registry.QualifiedName(tt.lookup); got != tt.qualified {
+				t.Errorf("QualifiedName() = %q, want %q", got, tt.qualified)
+			}
+		})
+	}
+
+	if got := registry.Namespaces(); len(got) != 2 || got[0] != "aws" || got[1] != "k8s" {
+		t.Errorf("Namespaces() = %v, want [aws k8s]", got)
+	}
+
+	if err := registry.Disable("k8s.get_pods"); err != nil || !registry.IsDisabled("k8s_get_pods") {
+		t.Errorf("Disable() by qualified name: %v", err)
+	}
+	registry.UnregisterMatching([]string{"aws.*"})
+	if _, err := registry.Get("aws_ec2_instances"); err == nil {
+		t.Error("UnregisterMatching(aws.*) left aws_ec2_instances")
+	}
+	registry.UnregisterNamespace("k8s")
+	if names := len(registry.GetAll()); names != 1 {
+		t.Errorf("%d tools left after UnregisterNamespace, want 1", names)
+	}
+}
+
+func TestRegistry_RelevantDefinitions(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockTool{name: "read_file", description: "Read a file"})
+	registry.RegisterIn("k8s", &mockTool{name: "get_pods", description: "List pods in a namespace"})
+	registry.RegisterIn("aws", &mockTool{name: "ec2_instances", description: "List EC2 instances"})
+	registry.RegisterIn("docker", &mockTool{name: "containers", description: "List running containers"})
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{name: "no limit", query: "pods", limit: 0, want: []string{"aws_ec2_instances", "docker_containers", "k8s_get_pods", "read_file"}},
+		{name: "under limit", query: "pods", limit: 10, want: []string{"aws_ec2_instances", "docker_containers", "k8s_get_pods", "read_file"}},
+		{name: "name match", query: "why are my pods crashing?", limit: 2, want: []string{"k8s_get_pods", "read_file"}},
+		{name: "namespace match", query: "anything odd in AWS", limit: 2, want: []string{"aws_ec2_instances", "read_file"}},
+		{name: "description match, ties by name", query: "which containers are running", limit: 3, want: []string{"aws_ec2_instances", "docker_containers", "read_file"}},
+		{name: "core tools first", query: "pods", limit: 1, want: []string{"read_file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs := registry.RelevantDefinitions(tt.query, tt.limit)
+			var got []string
+			for _, def := range defs {
+				got = append(got, def.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("RelevantDefinitions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrToolTimeout is returned when a tool call runs past its timeout
+var ErrToolTimeout = errors.New("tool call timed out")
+
+// WithTimeouts bounds how long a tool call may run. perTool overrides
+// defaultTimeout by tool name; zero or negative means no timeout. Tools that
+// ignore their context are abandoned when the timeout expires so the agent
+// loop can continue.
+func WithTimeouts(defaultTimeout time.Duration, perTool map[string]time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.defaultTimeout = defaultTimeout
+		e.timeouts = perTool
+	}
+}
+
+// timeoutFor returns the timeout for a tool, or zero for none
+func (e *Executor) timeoutFor(name string) time.Duration {
+	if timeout, ok := e.timeouts[name]; ok {
+		return timeout
+	}
+	return e.defaultTimeout
+}
+
+// run executes tool with the configured timeout. It returns as soon as the
+// timeout expires or ctx is cancelled, even if the tool has not.
+func (e *Executor) run(ctx context.Context, tool Tool, args map[string]any) (any, error) {
+	timeout := e.timeoutFor(tool.Name())
+	if timeout <= 0 {
+		return tool.Execute(ctx, args)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := tool.Execute(ctx, args)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s: %w", ErrToolTimeout, timeout, o.err)
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w after %s", ErrToolTimeout, timeout)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// ParseTimeouts converts configured tool timeouts in seconds (see
+// config.ToolsConfig) into executor timeouts
+func ParseTimeouts(defaultSeconds int, perTool map[string]int) (time.Duration, map[string]time.Duration) {
+	timeouts := make(map[string]time.Duration, len(perTool))
+	for name, seconds := range perTool {
+		timeouts[name] = time.Duration(seconds) * time.Second
+	}
+	return time.Duration(defaultSeconds) * time.Second, timeouts
+}
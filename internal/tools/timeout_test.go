@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExecutor_Timeouts(t *testing.T) {
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+
+	registry := NewRegistry()
+	// stuck ignores its context entirely
+	registry.Register(&mockTool{
+		name: "stuck",
+		executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+			<-block
+			return "late", nil
+		},
+	})
+	// polite stops when its context is done
+	registry.Register(&mockTool{
+		name: "polite",
+		executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	registry.Register(&mockTool{
+		name: "quick",
+		executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+			if _, ok := ctx.Deadline(); ok {
+				return "deadline", nil
+			}
+			return "no deadline", nil
+		},
+	})
+
+	tests := []struct {
+		name     string
+		defaults time.Duration
+		perTool  map[string]time.Duration
+		tool     string
+		want     any
+		wantErr  error
+	}{
+		{"stuck tool is abandoned", 20 * time.Millisecond, nil, "stuck", nil, ErrToolTimeout},
+		{"context-aware tool", 20 * time.Millisecond, nil, "polite", nil, ErrToolTimeout},
+		{"per-tool override", time.Hour, map[string]time.Duration{"polite": 20 * time.Millisecond}, "polite", nil, ErrToolTimeout},
+		{"fast tool gets a deadline", time.Hour, nil, "quick", "deadline", nil},
+		{"zero disables the timeout", time.Hour, map[string]time.Duration{"quick": 0}, "quick", "no deadline", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewExecutor(registry, WithTimeouts(tt.defaults, tt.perTool))
+			got, err := executor.Execute(context.Background(), tt.tool, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutor_Cancellation(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&mockTool{
+		name: "stuck",
+		executeFunc: func(ctx context.Context, args map[string]any) (any, error) {
+			select {}
+		},
+	})
+	executor := NewExecutor(registry, WithTimeouts(time.Hour, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	if _, err := executor.Execute(ctx, "stuck", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute() error = %v, want context.Canceled", err)
+	}
+}
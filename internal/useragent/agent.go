@@ -4,12 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/jaimegago/joe/internal/audit"
 	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/observability"
 	"github.com/jaimegago/joe/internal/tools"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracerName names the agent loop's spans' instrumentation
+const tracerName = "joe/agent"
+
 // AdapterFactory creates a new LLM adapter for the given provider and model.
 // Used by SwitchModel to hot-swap the underlying LLM without restarting.
 type AdapterFactory func(ctx context.Context, provider, model string) (llm.LLMAdapter, error)
@@ -27,9 +37,18 @@ func WithCurrentModelName(name string) AgentOption {
 	return func(a *Agent) { a.currentModel = name }
 }
 
+// ModelInfoFunc returns the capabilities of a model by its display name
+type ModelInfoFunc func(name string) llm.ModelInfo
+
+// WithModelInfo sets how the agent learns the active model's context
+// window and tool support. Without it, no context limit is enforced.
+func WithModelInfo(f ModelInfoFunc) AgentOption {
+	return func(a *Agent) { a.modelInfo = f }
+}
+
 // Agent runs the agentic loop: LLM → tool calls → LLM → ...
 type Agent struct {
-	mu             sync.RWMutex // protects llm and currentModel
+	mu             sync.RWMutex // protects llm, currentModel, and systemPrompt
 	llm            llm.LLMAdapter
 	executor       *tools.Executor
 	registry       *tools.Registry
@@ -37,6 +56,19 @@ type Agent struct {
 	maxIterations  int
 	adapterFactory AdapterFactory // optional, for hot-swap
 	currentModel   string         // display name of active model
+
+	modelInfo ModelInfoFunc  // optional, for context limits
+	auditor   Auditor        // optional, for run transcripts
+	recaller  Recaller       // optional, for past sessions like a new one
+	selection *toolSelection // optional, see WithToolSelection
+
+	// Plans before multi-step tasks, see WithPlanning
+	planning    bool
+	approvePlan PlanApprover
+
+	// Automatic compaction, see WithCompaction
+	compactThreshold int
+	compactKeepTurns int
 }
 
 // NewAgent creates a new agent. Options are applied after defaults.
@@ -71,13 +103,66 @@ func (a *Agent) SwitchModel(ctx context.Context, provider, model, displayName st
 	return nil
 }
 
-// CurrentModelName returns the display name of the active model.
+// SetSystemPrompt replaces the system prompt used from the next request on
+func (a *Agent) SetSystemPrompt(prompt string) {
+	a.mu.Lock()
+	a.systemPrompt = prompt
+	a.mu.Unlock()
+}
+
+// SystemPrompt returns the current system prompt
+func (a *Agent) SystemPrompt() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.systemPrompt
+}
+
+// CurrentModelName returns the display name of the active model. If the
+// adapter has failed over to another model, that model's name is returned.
 func (a *Agent) CurrentModelName() string {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
+	if f, ok := a.llm.(interface{ ActiveModel() string }); ok {
+		return f.ActiveModel()
+	}
 	return a.currentModel
 }
 
+// ModelInfo returns the capabilities of the active model
+func (a *Agent) ModelInfo() llm.ModelInfo {
+	if a.modelInfo == nil {
+		return llm.ModelInfo{Tools: true, Streaming: true}
+	}
+	return a.modelInfo(a.CurrentModelName())
+}
+
+// EventType identifies a step of a run reported to an EventHandler
+type EventType string
+
+// Run events
+const (
+	EventText       EventType = "text"        // Assistant text that accompanies tool calls
+	EventToolCall   EventType = "tool_call"   // A tool call about to execute
+	EventToolResult EventType = "tool_result" // The result of a tool call
+	EventPlan       EventType = "plan"        // A plan laid out before running tools, see WithPlanning
+)
+
+// Event is a step of a run. Text is set for EventText, ToolCall for
+// EventToolCall, Result and Duration for EventToolResult, and Plan and the
+// ToolCall that laid it out for EventPlan.
+type Event struct {
+	Type     EventType
+	Text     string
+	ToolCall llm.ToolCall
+	Result   llm.Message
+	Duration time.Duration // How long the tool call took
+	Plan     Plan
+}
+
+// EventHandler receives the steps of a run as they happen. It is called
+// from the goroutine running the agent and should return quickly.
+type EventHandler func(Event)
+
 // Run executes the agentic loop for a user message
 // The loop:
 // 1. Adds user message to session history
@@ -85,8 +170,51 @@ func (a *Agent) CurrentModelName() string {
 // 3. If LLM returns tool calls, executes them and loops back to step 2
 // 4. If LLM returns no tool calls, returns the final response
 func (a *Agent) Run(ctx context.Context, session *Session, userMessage string) (string, error) {
-	// Reset per-run token tracking
+	return a.RunWithEvents(ctx, session, userMessage, nil)
+}
+
+// RunWithEvents is Run, reporting text that accompanies tool calls, tool
+// calls, and their results to onEvent, which may be nil. The final answer
+// is returned, not reported.
+func (a *Agent) RunWithEvents(ctx context.Context, session *Session, userMessage string, onEvent EventHandler) (answer string, err error) {
+	if onEvent == nil {
+		onEvent = func(Event) {}
+	}
+
+	// One span for the run, with a child per iteration, under which the
+	// LLM call and tool executions add theirs
+	ctx, span := observability.Tracer(tracerName).Start(ctx, "agent.run",
+		trace.WithAttributes(
+			attribute.String("agent.session_id", session.ID),
+			attribute.String("llm.model", a.CurrentModelName()),
+		))
+	iterations := 0
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("agent.iterations", iterations),
+			attribute.Int("llm.calls", session.RunLLMCalls),
+			attribute.Int("llm.tokens.input", session.RunInputTokens),
+			attribute.Int("llm.tokens.output", session.RunOutputTokens),
+		)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+		}
+		span.End()
+	}()
+
+	if a.auditor != nil {
+		var transcript *audit.Record
+		transcript, onEvent = a.startTranscript(session, userMessage, onEvent)
+		defer func() { a.finishTranscript(transcript, session, answer, err) }()
+	}
+
+	// Reset per-run token tracking, and any failover from the last run
 	session.ResetRunStats()
+	if f, ok := a.llm.(interface{ ResetFailover() }); ok {
+		f.ResetFailover()
+	}
+	a.recall(ctx, session, userMessage)
 
 	// Add user message to history
 	session.AddMessage(llm.Message{
@@ -94,11 +222,15 @@ func (a *Agent) Run(ctx context.Context, session *Session, userMessage string) (
 		Content: userMessage,
 	})
 
-	// Get tool definitions for the LLM
-	toolDefs := a.registry.ToDefinitions()
+	// Get tool definitions for the LLM, once per run so every request of
+	// the run offers the same ones
+	toolDefs := a.toolDefinitions(ctx, session, userMessage)
+	if a.planning {
+		toolDefs = append(toolDefs, planDefinition)
+	}
 
 	// Agentic loop
-	for i := 0; i < a.maxIterations; i++ {
+	for iterations < a.maxIterations {
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
@@ -106,67 +238,160 @@ func (a *Agent) Run(ctx context.Context, session *Session, userMessage string) (
 		default:
 		}
 
-		// Build request with current conversation history
-		req := llm.ChatRequest{
-			SystemPrompt: a.systemPrompt,
-			Messages:     session.Messages,
-			Tools:        toolDefs,
+		iterations++
+		answer, done, err := a.iterate(ctx, session, toolDefs, onEvent, iterations)
+		if err != nil || done {
+			return answer, err
 		}
+	}
+
+	return a.partialAnswer(ctx, session)
+}
+
+// partialPrompt asks for what a run that hit the iteration limit found
+const partialPrompt = "You have reached the limit of tool calls for this request. Without calling any more tools, sum up what you found so far, what is still unknown, and what to look at next. Start with \"I didn't get to the bottom of this\" or similar so it is clear the answer is incomplete."
+
+// partialAnswer ends a run that hit the iteration limit with the LLM's
+// summary of what it found so far, asked for without tools, and marks the
+// run incomplete. If the LLM can't give one, the limit is the error.
+func (a *Agent) partialAnswer(ctx context.Context, session *Session) (string, error) {
+	limitErr := fmt.Errorf("max iterations (%d) reached without final response", a.maxIterations)
+
+	// The instruction is only sent, not kept in the history
+	messages := append(slices.Clone(session.Messages), llm.Message{Role: "user", Content: partialPrompt})
+	a.mu.RLock()
+	resp, err := a.llm.Chat(ctx, llm.ChatRequest{
+		SystemPrompt: a.systemPromptFor(session),
+		Messages:     messages,
+	})
+	a.mu.RUnlock()
+	if err != nil {
+		return "", fmt.Errorf("%w (summary failed: %w)", limitErr, err)
+	}
+	session.AddTokenUsage(resp.Usage)
+	if resp.Content == "" {
+		return "", limitErr
+	}
 
-		// Call LLM (under read lock so SwitchModel can't swap mid-call)
-		a.mu.RLock()
-		resp, err := a.llm.Chat(ctx, req)
-		a.mu.RUnlock()
+	session.AddMessage(llm.Message{Role: "assistant", Content: resp.Content})
+	session.RunIncomplete = true
+	return resp.Content, nil
+}
+
+// iterate makes one LLM call and executes the tool calls it returns.
+// done is set when the LLM answered without tool calls.
+func (a *Agent) iterate(ctx context.Context, session *Session, toolDefs []llm.ToolDefinition, onEvent EventHandler, iteration int) (answer string, done bool, err error) {
+	ctx, span := observability.Tracer(tracerName).Start(ctx, "agent.iteration",
+		trace.WithAttributes(attribute.Int("agent.iteration", iteration)))
+	defer func() {
 		if err != nil {
-			return "", fmt.Errorf("llm chat failed: %w", err)
+			span.SetStatus(codes.Error, err.Error())
 		}
+		span.End()
+	}()
 
-		// Track token usage
-		session.AddTokenUsage(resp.Usage)
+	// Build request with current conversation history. Models without
+	// tool support get none, and answer from the conversation alone.
+	info := a.ModelInfo()
+	req := llm.ChatRequest{
+		SystemPrompt: a.systemPromptFor(session),
+		Messages:     session.Messages,
+	}
+	if info.Tools {
+		req.Tools = toolDefs
+	}
 
-		// If no tool calls, we have the final response
-		if len(resp.ToolCalls) == 0 {
-			// Add assistant's final response to history
-			if resp.Content != "" {
-				session.AddMessage(llm.Message{
-					Role:    "assistant",
-					Content: resp.Content,
-				})
-			}
+	// Summarize older turns if the request is nearing the context limit
+	if err := a.fitContext(ctx, session, req, info); err != nil {
+		return "", false, err
+	}
+	req.Messages = session.Messages
 
-			return resp.Content, nil
-		}
+	// Call LLM (under read lock so SwitchModel can't swap mid-call)
+	a.mu.RLock()
+	resp, err := a.llm.Chat(ctx, req)
+	a.mu.RUnlock()
+	if err != nil {
+		return "", false, fmt.Errorf("llm chat failed: %w", err)
+	}
+
+	// Track token usage
+	session.AddTokenUsage(resp.Usage)
+	span.SetAttributes(
+		attribute.Int("llm.tokens.input", resp.Usage.InputTokens),
+		attribute.Int("llm.tokens.output", resp.Usage.OutputTokens),
+		attribute.Int("llm.tool_calls.count", len(resp.ToolCalls)),
+	)
 
-		// Add assistant's response (with tool calls) to history
-		// The tool calls must be preserved so the LLM sees them on the next iteration
-		session.AddMessage(llm.Message{
-			Role:      "assistant",
-			Content:   resp.Content,
-			ToolCalls: resp.ToolCalls,
-		})
-
-		// Execute tool calls
-		toolCallRequests := make([]tools.ToolCallRequest, len(resp.ToolCalls))
-		for i, tc := range resp.ToolCalls {
-			toolCallRequests[i] = tools.ToolCallRequest{
-				ID:   tc.ID,
-				Name: tc.Name,
-				Args: tc.Args,
-			}
+	// If no tool calls, we have the final response
+	if len(resp.ToolCalls) == 0 {
+		// Add assistant's final response to history
+		if resp.Content != "" {
+			session.AddMessage(llm.Message{
+				Role:    "assistant",
+				Content: resp.Content,
+			})
 		}
 
-		results, err := a.executor.ExecuteBatch(ctx, toolCallRequests)
-		if err != nil && !errors.Is(err, tools.ErrAllToolsFailed) {
-			// Only return fatal errors, not tool execution failures
-			// Tool failures are added to conversation for LLM to handle
-			return "", fmt.Errorf("tool execution failed: %w", err)
+		return resp.Content, true, nil
+	}
+
+	// Add assistant's response (with tool calls) to history
+	// The tool calls must be preserved so the LLM sees them on the next iteration
+	session.AddMessage(llm.Message{
+		Role:      "assistant",
+		Content:   resp.Content,
+		ToolCalls: resp.ToolCalls,
+	})
+
+	if resp.Content != "" {
+		onEvent(Event{Type: EventText, Text: resp.Content})
+	}
+
+	// Show plans, and stop here if one is rejected
+	toolCalls, planResults, rejected, err := a.reviewPlans(ctx, resp.ToolCalls, onEvent)
+	if err != nil {
+		return "", false, err
+	}
+	session.AddMessages(planResults)
+	if rejected {
+		session.AddMessage(llm.Message{Role: "assistant", Content: planRejected})
+		return planRejected, true, nil
+	}
+	if len(toolCalls) == 0 {
+		return "", false, nil
+	}
+
+	// Execute tool calls, reporting each as it starts and finishes
+	toolCallRequests := make([]tools.ToolCallRequest, len(toolCalls))
+	for i, tc := range toolCalls {
+		toolCallRequests[i] = tools.ToolCallRequest{
+			ID:   tc.ID,
+			Name: tc.Name,
+			Args: tc.Args,
 		}
+	}
 
-		// Convert tool results to messages and add to history
-		// This includes error messages for failed tools, which the LLM can respond to
-		resultMessages := a.executor.ResultsToMessages(results)
-		session.AddMessages(resultMessages)
+	// Convert tool results to messages as they come in, to add to history.
+	// This includes error messages for failed tools, which the LLM can respond to
+	var resultMessages []llm.Message
+	_, err = a.executor.ExecuteBatchWithProgress(ctx, toolCallRequests, func(call tools.ToolCallRequest, result *tools.ToolCallResult) {
+		if result == nil {
+			// From the request itself: IDs needn't be unique, e.g. Gemini
+			// uses the tool name
+			onEvent(Event{Type: EventToolCall, ToolCall: llm.ToolCall{ID: call.ID, Name: call.Name, Args: call.Args}})
+			return
+		}
+		m := a.executor.ResultsToMessages([]tools.ToolCallResult{*result})[0]
+		resultMessages = append(resultMessages, m)
+		onEvent(Event{Type: EventToolResult, Result: m, Duration: result.Duration})
+	})
+	if err != nil && !errors.Is(err, tools.ErrAllToolsFailed) {
+		// Only return fatal errors, not tool execution failures
+		// Tool failures are added to conversation for LLM to handle
+		return "", false, fmt.Errorf("tool execution failed: %w", err)
 	}
 
-	return "", fmt.Errorf("max iterations (%d) reached without final response", a.maxIterations)
+	session.AddMessages(resultMessages)
+	return "", false, nil
 }
@@ -3,11 +3,16 @@ package useragent
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
+	"github.com/jaimegago/joe/internal/audit"
 	"github.com/jaimegago/joe/internal/llm"
 	"github.com/jaimegago/joe/internal/tools"
 	"github.com/jaimegago/joe/internal/tools/local/echo"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // mockLLM is a mock LLM adapter for testing
@@ -160,6 +165,102 @@ func TestAgent_Run_WithToolCall(t *testing.T) {
 	}
 }
 
+func TestAgent_RunWithEvents(t *testing.T) {
+	mockLLM := &mockLLM{
+		responses: []*llm.ChatResponse{
+			{
+				Content: "Let me echo that.",
+				ToolCalls: []llm.ToolCall{
+					{ID: "call-1", Name: "echo", Args: map[string]any{"message": "hi"}},
+					{ID: "call-2", Name: "echo", Args: map[string]any{"message": "again"}},
+				},
+			},
+			{Content: "Done"},
+		},
+	}
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	agent := NewAgent(mockLLM, tools.NewExecutor(registry), registry, "You are a helpful assistant")
+
+	var events []Event
+	response, err := agent.RunWithEvents(context.Background(), NewSession(), "Echo hi", func(e Event) {
+		events = append(events, e)
+	})
+	if err != nil {
+		t.Fatalf("RunWithEvents() returned error: %v", err)
+	}
+	if response != "Done" {
+		t.Errorf("RunWithEvents() response = %q, want %q", response, "Done")
+	}
+
+	// Each call's result is reported before the next call starts
+	want := []EventType{EventText, EventToolCall, EventToolResult, EventToolCall, EventToolResult}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events %+v, want %v", len(events), events, want)
+	}
+	for i, e := range events {
+		if e.Type != want[i] {
+			t.Errorf("event %d type = %s, want %s", i, e.Type, want[i])
+		}
+	}
+	if events[1].ToolCall.ID != "call-1" || events[2].Result.ToolResultID != "call-1" || events[4].Result.ToolResultID != "call-2" {
+		t.Errorf("tool events = %+v, want the echo calls and their results", events[1:])
+	}
+}
+
+func TestAgent_RunWithEvents_RepeatedIDs(t *testing.T) {
+	// Gemini names calls by their tool, so two echo calls share an ID
+	mockLLM := &mockLLM{
+		responses: []*llm.ChatResponse{
+			{ToolCalls: []llm.ToolCall{
+				{ID: "echo", Name: "echo", Args: map[string]any{"message": "hi"}},
+				{ID: "echo", Name: "echo", Args: map[string]any{"message": "again"}},
+			}},
+			{Content: "Done"},
+		},
+	}
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	agent := NewAgent(mockLLM, tools.NewExecutor(registry), registry, "You are a helpful assistant")
+
+	var messages []any
+	_, err := agent.RunWithEvents(context.Background(), NewSession(), "Echo twice", func(e Event) {
+		if e.Type == EventToolCall {
+			messages = append(messages, e.ToolCall.Args["message"])
+		}
+	})
+	if err != nil {
+		t.Fatalf("RunWithEvents() returned error: %v", err)
+	}
+	if want := []any{"hi", "again"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("tool call events had messages %v, want %v", messages, want)
+	}
+}
+
+// failoverLLM is a mockLLM counting failover resets
+type failoverLLM struct {
+	mockLLM
+	resets int
+}
+
+func (f *failoverLLM) ResetFailover() { f.resets++ }
+
+func TestAgent_Run_ResetsFailover(t *testing.T) {
+	mock := &failoverLLM{mockLLM: mockLLM{responses: []*llm.ChatResponse{{Content: "one"}, {Content: "two"}}}}
+	registry := tools.NewRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "You are a helpful assistant")
+
+	session := NewSession()
+	for _, msg := range []string{"first", "second"} {
+		if _, err := agent.Run(context.Background(), session, msg); err != nil {
+			t.Fatalf("Run(%q) returned error: %v", msg, err)
+		}
+	}
+	if mock.resets != 2 {
+		t.Errorf("failover reset %d times, want once per run", mock.resets)
+	}
+}
+
 func TestAgent_Run_MultipleToolCalls(t *testing.T) {
 	// Mock LLM that:
 	// 1. First call: returns two tool calls
@@ -298,39 +399,71 @@ func TestAgent_Run_ToolNotFound(t *testing.T) {
 }
 
 func TestAgent_Run_MaxIterations(t *testing.T) {
-	// Mock LLM that always returns tool calls (infinite loop scenario)
-	responses := make([]*llm.ChatResponse, 15)
-	for i := range responses {
-		responses[i] = &llm.ChatResponse{
-			Content: "",
-			ToolCalls: []llm.ToolCall{
-				{
-					ID:   "call-1",
-					Name: "echo",
-					Args: map[string]any{"message": "loop"},
-				},
-			},
+	// Mock LLM that always returns tool calls (infinite loop scenario),
+	// then maybe sums up what it found
+	loop := func(summary ...*llm.ChatResponse) []*llm.ChatResponse {
+		responses := make([]*llm.ChatResponse, 10)
+		for i := range responses {
+			responses[i] = &llm.ChatResponse{
+				ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "echo", Args: map[string]any{"message": "loop"}}},
+			}
 		}
+		return append(responses, summary...)
+	}
+
+	tests := []struct {
+		name      string
+		responses []*llm.ChatResponse
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "partial answer",
+			responses: loop(&llm.ChatResponse{Content: "I didn't get to the bottom of this: echo keeps looping."}),
+			want:      "I didn't get to the bottom of this: echo keeps looping.",
+		},
+		{
+			name:      "empty summary",
+			responses: loop(&llm.ChatResponse{}),
+			wantErr:   true,
+		},
+		{
+			name:      "summary fails",
+			responses: loop(),
+			wantErr:   true,
+		},
 	}
-
-	mockLLM := &mockLLM{
-		responses: responses,
-	}
-
-	registry := tools.NewRegistry()
-	registry.Register(echo.NewTool())
-	executor := tools.NewExecutor(registry)
-	agent := NewAgent(mockLLM, executor, registry, "You are a helpful assistant")
-
-	session := NewSession()
-	_, err := agent.Run(context.Background(), session, "Test")
-
-	if err == nil {
-		t.Fatal("Run() expected error for max iterations, got nil")
-	}
-
-	if !contains(err.Error(), "max iterations") {
-		t.Errorf("Run() error = %v, want error containing 'max iterations'", err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLM := &mockLLM{responses: tt.responses}
+			registry := tools.NewRegistry()
+			registry.Register(echo.NewTool())
+			agent := NewAgent(mockLLM, tools.NewExecutor(registry), registry, "You are a helpful assistant")
+
+			session := NewSession()
+			answer, err := agent.Run(context.Background(), session, "Test")
+			if tt.wantErr {
+				if err == nil || !contains(err.Error(), "max iterations") {
+					t.Errorf("Run() error = %v, want error containing 'max iterations'", err)
+				}
+				if session.RunIncomplete {
+					t.Error("RunIncomplete set for a failed run")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if answer != tt.want || !session.RunIncomplete {
+				t.Errorf("Run() = %q, incomplete %v; want %q, incomplete", answer, session.RunIncomplete, tt.want)
+			}
+			if len(mockLLM.lastReq.Tools) != 0 || mockLLM.lastReq.Messages[len(mockLLM.lastReq.Messages)-1].Content != partialPrompt {
+				t.Error("the summary was asked for with tools, or without the instruction")
+			}
+			if last := session.Messages[len(session.Messages)-1]; last.Role != "assistant" || last.Content != tt.want {
+				t.Errorf("last message = %+v, want the partial answer", last)
+			}
+		})
 	}
 }
 
@@ -395,6 +528,27 @@ func TestAgent_Run_ToolDefinitionsIncluded(t *testing.T) {
 	}
 }
 
+func TestAgent_Run_ToolLimit(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{{Content: "Done"}}}
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	registry.RegisterIn("aws", echo.NewTool())
+	registry.RegisterIn("k8s", echo.NewTool())
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system",
+		WithModelInfo(func(string) llm.ModelInfo { return llm.ModelInfo{Tools: true, MaxTools: 2} }))
+
+	if _, err := agent.Run(context.Background(), NewSession(), "is k8s healthy?"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var names []string
+	for _, def := range mock.lastReq.Tools {
+		names = append(names, def.Name)
+	}
+	if len(names) != 2 || names[0] != "echo" || names[1] != "k8s_echo" {
+		t.Errorf("LLM received tools %v, want [echo k8s_echo]", names)
+	}
+}
+
 // Helper function
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || findSubstring(s, substr))
@@ -408,3 +562,136 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestAgent_Run_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	mockLLM := &mockLLM{
+		responses: []*llm.ChatResponse{
+			{ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "echo", Args: map[string]any{"message": "hi"}}}},
+			{Content: "Done", Usage: llm.TokenUsage{InputTokens: 20, OutputTokens: 5}},
+		},
+	}
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	agent := NewAgent(mockLLM, tools.NewExecutor(registry), registry, "You are a helpful assistant")
+	if _, err := agent.Run(context.Background(), NewSession(), "Echo hi"); err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = append(spans[s.Name()], s)
+	}
+	if len(spans["agent.run"]) != 1 || len(spans["agent.iteration"]) != 2 || len(spans["tool.execute"]) != 1 {
+		t.Fatalf("spans = %v, want 1 run, 2 iterations, and 1 tool execution", spans)
+	}
+	run := spans["agent.run"][0]
+	for _, it := range spans["agent.iteration"] {
+		if it.Parent().SpanID() != run.SpanContext().SpanID() {
+			t.Errorf("iteration span parent = %s, want the run span", it.Parent().SpanID())
+		}
+	}
+	if tool := spans["tool.execute"][0]; tool.Parent().SpanID() != spans["agent.iteration"][0].SpanContext().SpanID() {
+		t.Errorf("tool span is not a child of the first iteration")
+	}
+	for _, attr := range run.Attributes() {
+		if attr.Key == "llm.tokens.input" && attr.Value.AsInt64() != 20 {
+			t.Errorf("run llm.tokens.input = %d, want 20", attr.Value.AsInt64())
+		}
+	}
+}
+
+// fakeAuditor keeps the records written to it
+type fakeAuditor struct {
+	records []audit.Record
+}
+
+func (f *fakeAuditor) Write(r audit.Record) error {
+	f.records = append(f.records, r)
+	return nil
+}
+
+func TestAgent_Run_Transcript(t *testing.T) {
+	mockLLM := &mockLLM{
+		responses: []*llm.ChatResponse{
+			{ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "echo", Args: map[string]any{"message": "hi"}}}},
+			{Content: "Done", Usage: llm.TokenUsage{InputTokens: 20, OutputTokens: 5}},
+		},
+	}
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	auditor := &fakeAuditor{}
+	agent := NewAgent(mockLLM, tools.NewExecutor(registry), registry, "You are a helpful assistant",
+		WithCurrentModelName("claude-sonnet"), WithAuditor(auditor))
+
+	session := NewSession()
+	if _, err := agent.Run(context.Background(), session, "Echo hi"); err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+	if _, err := agent.Run(context.Background(), session, "Again"); err == nil {
+		t.Fatal("Run() with no responses left should fail")
+	}
+
+	if len(auditor.records) != 2 {
+		t.Fatalf("wrote %d records, want one per run", len(auditor.records))
+	}
+	first := auditor.records[0]
+	if first.SessionID != session.ID || first.Model != "claude-sonnet" || first.Prompt != "Echo hi" || first.Answer != "Done" {
+		t.Errorf("record = %+v, want the run's session, model, prompt, and answer", first)
+	}
+	if len(first.ToolCalls) != 1 || first.ToolCalls[0].Name != "echo" || first.ToolCalls[0].Result == "" {
+		t.Errorf("tool calls = %+v, want the echo call with its result", first.ToolCalls)
+	}
+	if first.Usage.InputTokens != 20 || first.Usage.LLMCalls != 2 {
+		t.Errorf("usage = %+v, want 20 input tokens over 2 calls", first.Usage)
+	}
+	if auditor.records[1].Error == "" {
+		t.Error("failed run recorded without its error")
+	}
+}
+
+// fakeRecaller recalls a fixed context, recording the questions asked
+type fakeRecaller struct {
+	context   string
+	questions []string
+}
+
+func (f *fakeRecaller) Context(ctx context.Context, question, excludeID string) (string, error) {
+	f.questions = append(f.questions, question)
+	return f.context, nil
+}
+
+func TestAgent_Run_Recall(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{{Content: "Checking."}, {Content: "Done."}, {Content: "Hi."}}}
+	recaller := &fakeRecaller{context: "Past conversations: the OOM on 2024-03-02."}
+	registry := tools.NewRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "You are joe.", WithRecall(recaller))
+
+	session := NewSession()
+	for _, msg := range []string{"payments pods are OOMKilled", "and now?"} {
+		if _, err := agent.Run(context.Background(), session, msg); err != nil {
+			t.Fatalf("Run(%q) error: %v", msg, err)
+		}
+		if want := "You are joe.\n\nPast conversations: the OOM on 2024-03-02."; mock.lastReq.SystemPrompt != want {
+			t.Errorf("Run(%q) system prompt = %q, want %q", msg, mock.lastReq.SystemPrompt, want)
+		}
+	}
+	if len(recaller.questions) != 1 || recaller.questions[0] != "payments pods are OOMKilled" {
+		t.Errorf("recalled for %q, want only the first message", recaller.questions)
+	}
+
+	// A cleared session starts over
+	session.Clear()
+	recaller.context = ""
+	if _, err := agent.Run(context.Background(), session, "hello"); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if mock.lastReq.SystemPrompt != "You are joe." {
+		t.Errorf("system prompt after Clear = %q, want the plain prompt", mock.lastReq.SystemPrompt)
+	}
+}
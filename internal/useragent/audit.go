@@ -0,0 +1,64 @@
+package useragent
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/jaimegago/joe/internal/audit"
+)
+
+// Auditor keeps a transcript of every run
+type Auditor interface {
+	Write(r audit.Record) error
+}
+
+// WithAuditor records each run (prompt, model, tool calls with their
+// arguments and results, answer, and usage) to au
+func WithAuditor(au Auditor) AgentOption {
+	return func(a *Agent) { a.auditor = au }
+}
+
+// startTranscript begins the record of a run, returning an event handler
+// that fills in its tool calls before passing events on
+func (a *Agent) startTranscript(session *Session, prompt string, onEvent EventHandler) (*audit.Record, EventHandler) {
+	rec := &audit.Record{
+		Time:      time.Now(),
+		SessionID: session.ID,
+		Model:     a.CurrentModelName(),
+		Prompt:    prompt,
+	}
+	return rec, func(e Event) {
+		switch e.Type {
+		case EventToolCall, EventPlan:
+			rec.ToolCalls = append(rec.ToolCalls, audit.ToolCall{ID: e.ToolCall.ID, Name: e.ToolCall.Name, Args: e.ToolCall.Args})
+		case EventToolResult:
+			for i := range rec.ToolCalls {
+				if rec.ToolCalls[i].ID == e.Result.ToolResultID {
+					rec.ToolCalls[i].Result = e.Result.Content
+					rec.ToolCalls[i].IsError = e.Result.IsError
+				}
+			}
+		}
+		onEvent(e)
+	}
+}
+
+// finishTranscript completes the record of a run and writes it. A failed
+// write is logged rather than failing the run.
+func (a *Agent) finishTranscript(rec *audit.Record, session *Session, answer string, err error) {
+	rec.Answer = answer
+	rec.Incomplete = session.RunIncomplete
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	rec.Usage = audit.Usage{
+		InputTokens:  session.RunInputTokens,
+		OutputTokens: session.RunOutputTokens,
+		LLMCalls:     session.RunLLMCalls,
+		CostUSD:      session.RunCostUSD,
+	}
+	rec.DurationMS = time.Since(rec.Time).Milliseconds()
+	if werr := a.auditor.Write(*rec); werr != nil {
+		slog.Warn("failed to write transcript", "session", session.ID, "error", werr)
+	}
+}
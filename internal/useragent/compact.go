@@ -0,0 +1,182 @@
+package useragent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// summaryPrefix marks the user message that carries a compaction summary
+const summaryPrefix = "Summary of the earlier conversation:\n\n"
+
+// summaryAck is the assistant reply that follows a summary, so the history
+// keeps alternating user and assistant turns for every provider
+const summaryAck = "Understood. I'll continue from that summary."
+
+// maxTranscriptResult caps each tool result in the text sent for
+// summarization; large outputs matter less than what was concluded from them
+const maxTranscriptResult = 2000
+
+const summarizePrompt = `You compress conversations between a user and Joe, an infrastructure assistant, so they can continue with less context.
+
+Write a concise summary of the transcript that keeps everything needed to carry on: the user's goals and open questions, facts learned about their systems (names, versions, paths, errors), what each tool call found, decisions made, and anything still pending. Omit pleasantries and raw output that led nowhere. Write plain prose or short bullet points, no preamble.`
+
+// CompactResult reports what a compaction did
+type CompactResult struct {
+	Summarized   int // Messages replaced by the summary
+	TokensBefore int // Estimated history tokens before compaction
+	TokensAfter  int // Estimated history tokens after compaction
+}
+
+// WithCompaction summarizes older turns once the estimated request size
+// reaches thresholdTokens, or nears the model's context window, keeping the
+// last keepTurns user turns verbatim. A threshold of 0 disables automatic
+// compaction.
+func WithCompaction(thresholdTokens, keepTurns int) AgentOption {
+	return func(a *Agent) {
+		a.compactThreshold = thresholdTokens
+		a.compactKeepTurns = keepTurns
+	}
+}
+
+// contextFill is the share of the model's context window a request may
+// fill before older turns are compacted. The rest is left for the answer
+// and for the imprecision of the token estimate.
+const contextFill = 0.8
+
+// ErrContextTooLarge is returned when a request can't be made to fit the
+// active model's context window
+var ErrContextTooLarge = errors.New("conversation exceeds the model's context window")
+
+// fitContext compacts the session when the request would grow past the
+// compaction threshold or near the model's context window, and refuses
+// requests still too large for the window rather than letting the API
+// reject them. Failure to compact is logged, not fatal: the request may
+// still fit.
+func (a *Agent) fitContext(ctx context.Context, session *Session, req llm.ChatRequest, info llm.ModelInfo) error {
+	size := llm.CountRequestTokens(info.Model, req)
+
+	limit := a.compactThreshold
+	if a.compactThreshold > 0 && info.ContextWindow > 0 {
+		limit = min(limit, int(float64(info.ContextWindow)*contextFill))
+	}
+	if limit > 0 && size >= limit {
+		// The turn in progress is always kept, or its tool calls would be
+		// cut off from the user message that asked for them
+		result, err := a.Compact(ctx, session, max(a.compactKeepTurns, 1))
+		switch {
+		case err != nil:
+			slog.Warn("conversation compaction failed", "error", err)
+		case result.Summarized > 0:
+			slog.Info("compacted conversation",
+				"messages", result.Summarized,
+				"tokens_before", result.TokensBefore,
+				"tokens_after", result.TokensAfter)
+			req.Messages = session.Messages
+			size = llm.CountRequestTokens(info.Model, req)
+		}
+	}
+
+	if info.ContextWindow > 0 && size > info.ContextWindow {
+		return fmt.Errorf("%w: about %d tokens, %s accepts %d; try /compact or /history clear",
+			ErrContextTooLarge, size, a.CurrentModelName(), info.ContextWindow)
+	}
+	return nil
+}
+
+// Compact replaces all but the last keepTurns user turns with an
+// LLM-written summary. A turn starts at a user message that isn't a tool
+// result, so tool calls are never separated from their results. With
+// keepTurns 0 the whole history is summarized.
+func (a *Agent) Compact(ctx context.Context, session *Session, keepTurns int) (CompactResult, error) {
+	model := a.ModelInfo().Model
+	result := CompactResult{TokensBefore: llm.CountTokens(model, session.Messages)}
+
+	split := turnBoundary(session.Messages, keepTurns)
+	if split == 0 {
+		result.TokensAfter = result.TokensBefore
+		return result, nil
+	}
+	older := session.Messages[:split]
+
+	a.mu.RLock()
+	resp, err := a.llm.Chat(ctx, llm.ChatRequest{
+		SystemPrompt: summarizePrompt,
+		Messages:     []llm.Message{{Role: "user", Content: transcript(older)}},
+	})
+	a.mu.RUnlock()
+	if err != nil {
+		return result, fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	session.AddTokenUsage(resp.Usage)
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return result, fmt.Errorf("failed to summarize conversation: empty summary")
+	}
+
+	compacted := []llm.Message{
+		{Role: "user", Content: summaryPrefix + summary, Timestamp: older[0].Timestamp},
+		{Role: "assistant", Content: summaryAck, Timestamp: older[len(older)-1].Timestamp},
+	}
+	session.Messages = append(compacted, session.Messages[split:]...)
+
+	result.Summarized = len(older)
+	result.TokensAfter = llm.CountTokens(model, session.Messages)
+	return result, nil
+}
+
+// turnBoundary returns the index of the first message of the last keepTurns
+// user turns, or len(msgs) when keepTurns is 0. It returns 0 when there is
+// nothing older to summarize.
+func turnBoundary(msgs []llm.Message, keepTurns int) int {
+	if keepTurns <= 0 {
+		return len(msgs)
+	}
+	turns := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "user" && msgs[i].ToolResultID == "" {
+			turns++
+			if turns == keepTurns {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// transcript renders messages as plain text for summarization, so the
+// request carries no tool_use/tool_result blocks and needs no tools
+func transcript(msgs []llm.Message) string {
+	var b strings.Builder
+	b.WriteString("Summarize this conversation:\n\n")
+	for _, m := range msgs {
+		switch {
+		case m.ToolResultID != "":
+			content := m.Content
+			if len(content) > maxTranscriptResult {
+				content = content[:maxTranscriptResult] + " …(truncated)"
+			}
+			label := "Tool result"
+			if m.IsError {
+				label = "Tool error"
+			}
+			fmt.Fprintf(&b, "%s (%s): %s\n\n", label, m.ToolName, content)
+		case m.Role == "assistant":
+			if m.Content != "" {
+				fmt.Fprintf(&b, "Joe: %s\n\n", m.Content)
+			}
+			for _, tc := range m.ToolCalls {
+				args, _ := json.Marshal(tc.Args)
+				fmt.Fprintf(&b, "Joe called %s %s\n\n", tc.Name, args)
+			}
+		default:
+			fmt.Fprintf(&b, "User: %s\n\n", m.Content)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,233 @@
+package useragent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/tools/local/echo"
+)
+
+// toolTurn returns a user turn in which the assistant made one tool call
+func toolTurn(question, answer string) []llm.Message {
+	return []llm.Message{
+		{Role: "user", Content: question},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "t1", Name: "read_file", Args: map[string]any{"path": "/etc/hosts"}}}},
+		{Role: "user", ToolResultID: "t1", ToolName: "read_file", Content: strings.Repeat("127.0.0.1 localhost\n", 200)},
+		{Role: "assistant", Content: answer},
+	}
+}
+
+func TestTurnBoundary(t *testing.T) {
+	var msgs []llm.Message
+	msgs = append(msgs, toolTurn("first", "one")...)
+	msgs = append(msgs, toolTurn("second", "two")...)
+	msgs = append(msgs, toolTurn("third", "three")...)
+
+	tests := []struct {
+		name      string
+		keepTurns int
+		want      int
+	}{
+		{name: "summarize everything", keepTurns: 0, want: 12},
+		{name: "keep last turn", keepTurns: 1, want: 8},
+		{name: "keep two turns", keepTurns: 2, want: 4},
+		{name: "keep all turns", keepTurns: 3, want: 0},
+		{name: "more turns than exist", keepTurns: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := turnBoundary(msgs, tt.keepTurns)
+			if got != tt.want {
+				t.Errorf("turnBoundary() = %d, want %d", got, tt.want)
+			}
+			if got > 0 && got < len(msgs) && msgs[got].ToolResultID != "" {
+				t.Errorf("split at a tool result, separating it from its call")
+			}
+		})
+	}
+}
+
+func TestAgent_Compact(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{
+		{Content: "The user asked about /etc/hosts twice.", Usage: llm.TokenUsage{InputTokens: 500, OutputTokens: 20, TotalTokens: 520}},
+	}}
+	registry := tools.NewRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system")
+
+	session := NewSession()
+	session.AddMessages(toolTurn("first", "one"))
+	session.AddMessages(toolTurn("second", "two"))
+	session.AddMessages(toolTurn("third", "three"))
+
+	result, err := agent.Compact(context.Background(), session, 1)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if result.Summarized != 8 {
+		t.Errorf("Summarized = %d, want 8", result.Summarized)
+	}
+	if result.TokensAfter >= result.TokensBefore {
+		t.Errorf("TokensAfter = %d, want less than %d", result.TokensAfter, result.TokensBefore)
+	}
+
+	// Summary pair, then the kept turn verbatim
+	if len(session.Messages) != 6 {
+		t.Fatalf("len(Messages) = %d, want 6", len(session.Messages))
+	}
+	if got := session.Messages[0].Content; !strings.HasPrefix(got, summaryPrefix) || !strings.Contains(got, "/etc/hosts twice") {
+		t.Errorf("Messages[0] = %q, want the summary", got)
+	}
+	if session.Messages[1].Role != "assistant" || session.Messages[2].Content != "third" {
+		t.Errorf("Messages = %+v, want summary pair then the last turn", session.Messages[:3])
+	}
+
+	// The summarization request is plain text without tools
+	if len(mock.lastReq.Tools) != 0 || len(mock.lastReq.Messages) != 1 {
+		t.Errorf("summary request has %d tools, %d messages; want 0, 1", len(mock.lastReq.Tools), len(mock.lastReq.Messages))
+	}
+	sent := mock.lastReq.Messages[0].Content
+	if !strings.Contains(sent, "User: second") || strings.Contains(sent, "User: third") {
+		t.Errorf("transcript should cover the older turns only:\n%s", sent)
+	}
+	if !strings.Contains(sent, "(truncated)") {
+		t.Errorf("transcript should truncate long tool results")
+	}
+	if session.TotalTokens != 520 {
+		t.Errorf("TotalTokens = %d, want summary usage counted", session.TotalTokens)
+	}
+}
+
+func TestAgent_Compact_NothingToSummarize(t *testing.T) {
+	mock := &mockLLM{}
+	registry := tools.NewRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system")
+
+	session := NewSession()
+	session.AddMessages(toolTurn("only", "one"))
+
+	result, err := agent.Compact(context.Background(), session, 1)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if result.Summarized != 0 || mock.callCount != 0 || len(session.Messages) != 4 {
+		t.Errorf("Compact() = %+v after %d calls, want no change", result, mock.callCount)
+	}
+}
+
+func TestAgent_Run_CompactsPastThreshold(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{
+		{Content: "Earlier: read /etc/hosts."},
+		{Content: "Done."},
+	}}
+	registry := tools.NewRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system", WithCompaction(1000, 1))
+
+	session := NewSession()
+	session.AddMessages(toolTurn("first", "one"))
+	session.AddMessages(toolTurn("second", "two"))
+
+	if _, err := agent.Run(context.Background(), session, "third"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if mock.callCount != 2 {
+		t.Fatalf("callCount = %d, want a summary call then the answer", mock.callCount)
+	}
+
+	// The answer request carried the summary and the new question only
+	msgs := mock.lastReq.Messages
+	if len(msgs) != 3 || !strings.HasPrefix(msgs[0].Content, summaryPrefix) || msgs[2].Content != "third" {
+		t.Errorf("request messages = %+v, want summary pair and the new question", msgs)
+	}
+	if mock.lastReq.SystemPrompt != "system" {
+		t.Errorf("SystemPrompt = %q, want the agent's prompt", mock.lastReq.SystemPrompt)
+	}
+}
+
+func TestAgent_Run_BelowThreshold(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{{Content: "Done."}}}
+	registry := tools.NewRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system", WithCompaction(100000, 1))
+
+	session := NewSession()
+	session.AddMessages(toolTurn("first", "one"))
+
+	if _, err := agent.Run(context.Background(), session, "second"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if mock.callCount != 1 || len(session.Messages) != 6 {
+		t.Errorf("callCount = %d, messages = %d; want no compaction", mock.callCount, len(session.Messages))
+	}
+}
+
+func TestAgent_Run_ContextWindow(t *testing.T) {
+	window := func(n int) ModelInfoFunc {
+		return func(string) llm.ModelInfo { return llm.ModelInfo{ContextWindow: n, Tools: true} }
+	}
+
+	tests := []struct {
+		name      string
+		opts      []AgentOption
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "compacts near the window below the threshold",
+			opts:      []AgentOption{WithCompaction(100000, 1), WithModelInfo(window(2500))},
+			wantCalls: 2,
+		},
+		{
+			name:    "refuses when compaction is off",
+			opts:    []AgentOption{WithModelInfo(window(1500))},
+			wantErr: true,
+		},
+		{
+			name:      "fits the window",
+			opts:      []AgentOption{WithModelInfo(window(100000))},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockLLM{responses: []*llm.ChatResponse{{Content: "Summary."}, {Content: "Done."}}}
+			registry := tools.NewRegistry()
+			agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system", tt.opts...)
+
+			session := NewSession()
+			session.AddMessages(toolTurn("first", "one"))
+			session.AddMessages(toolTurn("second", "two"))
+
+			_, err := agent.Run(context.Background(), session, "third")
+			if tt.wantErr {
+				if !errors.Is(err, ErrContextTooLarge) {
+					t.Errorf("Run() error = %v, want ErrContextTooLarge", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if mock.callCount != tt.wantCalls {
+				t.Errorf("callCount = %d, want %d", mock.callCount, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAgent_Run_ModelWithoutTools(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{{Content: "Done."}}}
+	registry := tools.NewRegistry()
+	registry.Register(echo.NewTool())
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system",
+		WithModelInfo(func(string) llm.ModelInfo { return llm.ModelInfo{} }))
+
+	if _, err := agent.Run(context.Background(), NewSession(), "hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(mock.lastReq.Tools) != 0 {
+		t.Errorf("sent %d tools to a model without tool support", len(mock.lastReq.Tools))
+	}
+}
@@ -0,0 +1,93 @@
+package useragent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// SessionStore is the subset of store.Store needed to persist and resume
+// sessions
+type SessionStore interface {
+	CreateSession(ctx context.Context, session store.Session) error
+	GetSession(ctx context.Context, id string) (*store.Session, error)
+	UpdateSession(ctx context.Context, session store.Session) error
+	ListSessions(ctx context.Context, limit int) ([]store.Session, error)
+}
+
+// SaveSession writes the session's history and token totals to st, creating
+// the record on first save and preserving any other stored fields (summary,
+// tags, ...) on later saves. Sessions with no messages are not saved.
+func SaveSession(ctx context.Context, st SessionStore, s *Session) error {
+	if len(s.Messages) == 0 {
+		return nil
+	}
+
+	record, err := st.GetSession(ctx, s.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		record = &store.Session{ID: s.ID, StartedAt: s.StartedAt, Origin: s.Origin}
+		applySession(record, s)
+		if err := st.CreateSession(ctx, *record); err != nil {
+			return fmt.Errorf("failed to save session: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	applySession(record, s)
+	if err := st.UpdateSession(ctx, *record); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// applySession copies the conversation state of s into record
+func applySession(record *store.Session, s *Session) {
+	if s.Title != "" {
+		record.Title = s.Title
+	}
+	record.Messages = append([]llm.Message(nil), s.Messages...)
+	record.InputTokens = s.TotalInputTokens
+	record.OutputTokens = s.TotalOutputTokens
+	record.TotalTokens = s.TotalTokens
+	record.CostUSD = s.TotalCostUSD
+}
+
+// LatestSession returns the most recently updated stored session, skipping
+// excludeID (typically the current session). Returns store.ErrNotFound if
+// there is none.
+func LatestSession(ctx context.Context, st SessionStore, excludeID string) (*store.Session, error) {
+	// Two is enough: at most one of them is the excluded session
+	sessions, err := st.ListSessions(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		if session.ID != excludeID {
+			return &session, nil
+		}
+	}
+	return nil, fmt.Errorf("no previous session: %w", store.ErrNotFound)
+}
+
+// Restore replaces the session's history and token totals with a stored
+// session. The session adopts the stored ID, so later saves update that
+// record instead of creating a new one.
+func (s *Session) Restore(stored *store.Session) {
+	s.ID = stored.ID
+	s.StartedAt = stored.StartedAt
+	s.Title = stored.Title
+	s.Origin = stored.Origin
+	s.Messages = append(make([]llm.Message, 0, len(stored.Messages)), stored.Messages...)
+	s.Recalled = ""
+	s.TotalInputTokens = stored.InputTokens
+	s.TotalOutputTokens = stored.OutputTokens
+	s.TotalTokens = stored.TotalTokens
+	s.TotalCostUSD = stored.CostUSD
+	s.ResetRunStats()
+}
@@ -0,0 +1,157 @@
+package useragent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// PlanTool is the pseudo-tool the LLM lays out a multi-step task with
+// before running any other tool. The agent handles it itself: the plan is
+// reported as EventPlan and, with a PlanApprover, waits for approval.
+const PlanTool = "plan"
+
+// planRejected is the answer of a run whose plan was rejected
+const planRejected = "Plan rejected; nothing was run. Tell me what to do differently."
+
+// planDefinition is the definition of PlanTool given to the LLM
+var planDefinition = llm.ToolDefinition{
+	Name:        PlanTool,
+	Description: "Lay out a plan before a task that takes more than two tool calls or changes anything, e.g. debugging a failing deployment. Call it alone, before any other tool, with numbered steps naming the tools you will use; the user sees the plan and may reject it. Call it again if the plan has to change. Don't plan single lookups or questions you can answer directly.",
+	Parameters: llm.ParameterSchema{
+		Type: "object",
+		Properties: map[string]llm.Property{
+			"goal":  {Type: "string", Description: "What the plan achieves, in one sentence"},
+			"steps": {Type: "array", Description: "The steps, in order, e.g. \"Check the pods of payment-api with run_command (kubectl get pods)\"", Items: &llm.Property{Type: "string"}},
+		},
+		Required: []string{"steps"},
+	},
+}
+
+// Plan is the steps the LLM means to take for a task
+type Plan struct {
+	Goal  string
+	Steps []string
+}
+
+// String renders the plan as a numbered list
+func (p Plan) String() string {
+	var b strings.Builder
+	if p.Goal != "" {
+		fmt.Fprintf(&b, "Plan: %s\n", p.Goal)
+	} else {
+		b.WriteString("Plan:\n")
+	}
+	for i, step := range p.Steps {
+		fmt.Fprintf(&b, "  %d. %s\n", i+1, step)
+	}
+	return b.String()
+}
+
+// PlanApprover decides whether a plan may be carried out. It returns false
+// to reject the plan; an error aborts the run.
+type PlanApprover func(ctx context.Context, plan Plan) (bool, error)
+
+// WithPlanning offers the LLM PlanTool, so multi-step tasks start with a
+// plan reported as EventPlan. With approve, no tool runs until it approves
+// the plan; without it, plans are only shown.
+func WithPlanning(approve PlanApprover) AgentOption {
+	return func(a *Agent) {
+		a.planning = true
+		a.approvePlan = approve
+	}
+}
+
+// reviewPlans reports the plans among calls and has them approved. It
+// returns the calls left to execute and the results of the plan calls.
+// rejected is set when a plan was rejected, in which case results answers
+// every call and none may run.
+func (a *Agent) reviewPlans(ctx context.Context, calls []llm.ToolCall, onEvent EventHandler) (rest []llm.ToolCall, results []llm.Message, rejected bool, err error) {
+	if !a.planning {
+		return calls, nil, false, nil
+	}
+	for _, tc := range calls {
+		if tc.Name != PlanTool {
+			rest = append(rest, tc)
+			continue
+		}
+		plan := parsePlan(tc.Args)
+		if len(plan.Steps) == 0 {
+			results = append(results, planResult(tc, "Error: a plan needs at least one step", true))
+			continue
+		}
+		onEvent(Event{Type: EventPlan, ToolCall: tc, Plan: plan})
+
+		if a.approvePlan == nil {
+			results = append(results, planResult(tc, "The plan was shown to the user. Carry it out one step at a time, and say so if it has to change.", false))
+			continue
+		}
+		ok, err := a.approvePlan(ctx, plan)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("plan approval failed: %w", err)
+		}
+		if !ok {
+			rejected = true
+			results = append(results, planResult(tc, "The user rejected the plan. Nothing was run.", true))
+			continue
+		}
+		results = append(results, planResult(tc, "The user approved the plan. Carry it out one step at a time, and call plan again if it has to change.", false))
+	}
+
+	if rejected {
+		for _, tc := range rest {
+			results = append(results, planResult(tc, "Not run: the user rejected the plan.", true))
+		}
+		rest = nil
+	}
+	return rest, results, rejected, nil
+}
+
+// stepNumber matches the numbering or bullet a step may come with, which
+// Plan.String adds back
+var stepNumber = regexp.MustCompile(`^(?:\d+[.)]|[-*])\s+`)
+
+// parsePlan reads the plan tool's arguments. Steps may come as an array or
+// as a string with one step per line.
+func parsePlan(args map[string]any) Plan {
+	var plan Plan
+	plan.Goal, _ = args["goal"].(string)
+	plan.Goal = strings.TrimSpace(plan.Goal)
+
+	var steps []string
+	switch v := args["steps"].(type) {
+	case []any:
+		for _, step := range v {
+			if s, ok := step.(string); ok {
+				steps = append(steps, s)
+			}
+		}
+	case string:
+		steps = strings.Split(v, "\n")
+	}
+	for _, step := range steps {
+		if step = stepNumber.ReplaceAllString(strings.TrimSpace(step), ""); step != "" {
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+	return plan
+}
+
+// planResult is the tool result of a plan call, or of a call not run
+// because of one
+func planResult(tc llm.ToolCall, content string, isError bool) llm.Message {
+	id := tc.ID
+	if id == "" {
+		id = tc.Name // As tools.ResultToMessage does for providers without call IDs
+	}
+	return llm.Message{
+		Role:         "user",
+		Content:      content,
+		ToolResultID: id,
+		ToolName:     tc.Name,
+		IsError:      isError,
+	}
+}
@@ -0,0 +1,137 @@
+package useragent
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+	"github.com/jaimegago/joe/internal/tools/local/echo"
+)
+
+func TestAgent_Run_Plan(t *testing.T) {
+	planCall := llm.ToolCall{ID: "plan-1", Name: PlanTool, Args: map[string]any{
+		"goal":  "Echo twice",
+		"steps": []any{"Echo hi", " ", "Echo bye"},
+	}}
+	echoCall := llm.ToolCall{ID: "echo-1", Name: "echo", Args: map[string]any{"message": "hi"}}
+
+	tests := []struct {
+		name        string
+		approve     PlanApprover
+		wantAnswer  string
+		wantErr     bool
+		wantResults map[string]bool // Tool call ID to whether its result is an error
+	}{
+		{
+			name:        "shown",
+			wantAnswer:  "Done",
+			wantResults: map[string]bool{"plan-1": false, "echo-1": false},
+		},
+		{
+			name:        "approved",
+			approve:     func(ctx context.Context, plan Plan) (bool, error) { return true, nil },
+			wantAnswer:  "Done",
+			wantResults: map[string]bool{"plan-1": false, "echo-1": false},
+		},
+		{
+			name:        "rejected",
+			approve:     func(ctx context.Context, plan Plan) (bool, error) { return false, nil },
+			wantAnswer:  planRejected,
+			wantResults: map[string]bool{"plan-1": true, "echo-1": true},
+		},
+		{
+			name:    "approval failed",
+			approve: func(ctx context.Context, plan Plan) (bool, error) { return false, context.Canceled },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockLLM{responses: []*llm.ChatResponse{
+				{ToolCalls: []llm.ToolCall{planCall, echoCall}},
+				{Content: "Done"},
+			}}
+			registry := tools.NewRegistry()
+			registry.Register(echo.NewTool())
+			agent := NewAgent(mock, tools.NewExecutor(registry), registry, "prompt", WithPlanning(tt.approve))
+
+			var plans []Plan
+			var ran []string
+			session := NewSession()
+			answer, err := agent.RunWithEvents(context.Background(), session, "echo hi then bye", func(e Event) {
+				switch e.Type {
+				case EventPlan:
+					plans = append(plans, e.Plan)
+				case EventToolCall:
+					ran = append(ran, e.ToolCall.Name)
+				}
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := []Plan{{Goal: "Echo twice", Steps: []string{"Echo hi", "Echo bye"}}}; !reflect.DeepEqual(plans, want) {
+				t.Errorf("plans = %+v, want %+v", plans, want)
+			}
+			if tt.wantErr {
+				return
+			}
+			if answer != tt.wantAnswer {
+				t.Errorf("Run() = %q, want %q", answer, tt.wantAnswer)
+			}
+			if !slices.ContainsFunc(mock.lastReq.Tools, func(d llm.ToolDefinition) bool { return d.Name == PlanTool }) {
+				t.Error("the plan tool wasn't offered")
+			}
+
+			results := make(map[string]bool)
+			for _, m := range session.Messages {
+				if m.ToolResultID != "" {
+					results[m.ToolResultID] = m.IsError
+				}
+			}
+			if !reflect.DeepEqual(results, tt.wantResults) {
+				t.Errorf("tool results (ID to error) = %v, want %v", results, tt.wantResults)
+			}
+			if rejected := tt.wantAnswer == planRejected; rejected != (len(ran) == 0) {
+				t.Errorf("ran %v, rejected = %v", ran, rejected)
+			}
+		})
+	}
+}
+
+func TestAgent_Run_PlanOff(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{{Content: "Hi"}}}
+	registry := tools.NewRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "prompt")
+	if _, err := agent.Run(context.Background(), NewSession(), "hello"); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if slices.ContainsFunc(mock.lastReq.Tools, func(d llm.ToolDefinition) bool { return d.Name == PlanTool }) {
+		t.Error("the plan tool was offered without WithPlanning")
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]any
+		want Plan
+	}{
+		{"array", map[string]any{"goal": " Fix it ", "steps": []any{"a", 2.0, "b"}}, Plan{Goal: "Fix it", Steps: []string{"a", "b"}}},
+		{"numbered lines", map[string]any{"steps": "1. a\n\n2) b\n- c"}, Plan{Steps: []string{"a", "b", "c"}}},
+		{"none", map[string]any{}, Plan{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePlan(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePlan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got, want := (Plan{Goal: "Fix it", Steps: []string{"a", "b"}}).String(), "Plan: Fix it\n  1. a\n  2. b\n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package useragent
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Recaller finds what past conversations have to say about a question
+type Recaller interface {
+	// Context describes the past sessions like question for the system
+	// prompt, leaving out excludeID, or returns "" if there are none
+	Context(ctx context.Context, question, excludeID string) (string, error)
+}
+
+// WithRecall starts each new session with what r recalls about its first
+// message, added to the system prompt for the rest of the session
+func WithRecall(r Recaller) AgentOption {
+	return func(a *Agent) { a.recaller = r }
+}
+
+// recall fills in session.Recalled when message starts the session.
+// Failing to recall is logged, not fatal: the run goes on without it.
+func (a *Agent) recall(ctx context.Context, session *Session, message string) {
+	if a.recaller == nil || len(session.Messages) > 0 {
+		return
+	}
+	recalled, err := a.recaller.Context(ctx, message, session.ID)
+	if err != nil {
+		slog.Warn("failed to recall past sessions", "error", err)
+		return
+	}
+	session.Recalled = recalled
+}
+
+// systemPromptFor returns the system prompt with what was recalled for
+// session
+func (a *Agent) systemPromptFor(session *Session) string {
+	if session.Recalled == "" {
+		return a.SystemPrompt()
+	}
+	return a.SystemPrompt() + "\n\n" + session.Recalled
+}
@@ -0,0 +1,183 @@
+package useragent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jaimegago/joe/internal/store"
+)
+
+// RunnerOrigin is the Origin of the sessions a Runner starts
+const RunnerOrigin = "api"
+
+// RunnerStore is the subset of store.Store a Runner keeps sessions in
+type RunnerStore interface {
+	SessionStore
+	PruneSessions(ctx context.Context, origin string, updatedBefore time.Time, keep int) (int, error)
+}
+
+// ChatResult is the outcome of one message in a Runner conversation
+type ChatResult struct {
+	SessionID string
+	Answer    string
+
+	// Incomplete is set when the run hit the iteration limit, and Answer
+	// only sums up what was found so far
+	Incomplete bool
+
+	// Token usage and cost of this message's run
+	InputTokens  int
+	OutputTokens int
+	LLMCalls     int
+	CostUSD      float64
+}
+
+// Runner runs conversations for remote clients, which only hold a session
+// ID between messages. Sessions live in the store: each message loads its
+// session, runs the agent, and saves it again, so any client can continue
+// any conversation. Messages to the same session run one at a time, and
+// messages to different sessions concurrently.
+type Runner struct {
+	agent       *Agent
+	store       RunnerStore
+	expiry      time.Duration
+	maxSessions int
+
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+// sessionLock serializes runs on a session; refs counts the runs holding
+// or waiting for it, so idle sessions don't keep a lock around
+type sessionLock struct {
+	sync.Mutex
+	refs int
+}
+
+// RunnerOption configures a Runner
+type RunnerOption func(*Runner)
+
+// WithSessionExpiry ends the Runner's sessions once they have been idle
+// for ttl: they can't be continued, and are deleted when the next session
+// starts. ttl <= 0 keeps sessions until WithMaxSessions prunes them.
+func WithSessionExpiry(ttl time.Duration) RunnerOption {
+	return func(r *Runner) {
+		r.expiry = ttl
+	}
+}
+
+// WithMaxSessions keeps only the n most recently used of the Runner's
+// sessions, deleting the rest whenever a session starts. n <= 0 keeps all.
+func WithMaxSessions(n int) RunnerOption {
+	return func(r *Runner) {
+		r.maxSessions = n
+	}
+}
+
+// NewRunner creates a Runner that persists sessions in st. Sessions
+// started in joe, rather than through the Runner, never expire and don't
+// count towards WithMaxSessions.
+func NewRunner(agent *Agent, st RunnerStore, opts ...RunnerOption) *Runner {
+	r := &Runner{agent: agent, store: st, locks: make(map[string]*sessionLock)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Chat sends message to the session with sessionID, or to a new session if
+// sessionID is empty, and saves the session with the answer. Steps of the
+// run are reported to onEvent, which may be nil. If the run fails, the
+// result still names the session, which keeps the message. Returns an
+// error wrapping store.ErrNotFound if there is no session with sessionID,
+// or it has expired.
+func (r *Runner) Chat(ctx context.Context, sessionID, message string, onEvent EventHandler) (*ChatResult, error) {
+	session := NewSession()
+	session.Origin = RunnerOrigin
+	if sessionID != "" {
+		unlock := r.lock(sessionID)
+		defer unlock()
+
+		stored, err := r.store.GetSession(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+		}
+		if r.expired(stored) {
+			return nil, fmt.Errorf("session %s expired: %w", sessionID, store.ErrNotFound)
+		}
+		session.Restore(stored)
+	} else {
+		defer r.prune(context.WithoutCancel(ctx))
+	}
+
+	answer, runErr := r.agent.RunWithEvents(ctx, session, message, onEvent)
+
+	// Save even a failed run, so the message and any tool results so far
+	// are kept for the next attempt
+	if err := SaveSession(context.WithoutCancel(ctx), r.store, session); err != nil {
+		return nil, err
+	}
+	if runErr != nil {
+		return &ChatResult{SessionID: session.ID}, runErr
+	}
+	return &ChatResult{
+		SessionID:    session.ID,
+		Answer:       answer,
+		Incomplete:   session.RunIncomplete,
+		InputTokens:  session.RunInputTokens,
+		OutputTokens: session.RunOutputTokens,
+		LLMCalls:     session.RunLLMCalls,
+		CostUSD:      session.RunCostUSD,
+	}, nil
+}
+
+// expired reports whether stored is one of the Runner's sessions and has
+// been idle longer than WithSessionExpiry allows
+func (r *Runner) expired(stored *store.Session) bool {
+	return r.expiry > 0 && stored.Origin == RunnerOrigin && time.Since(stored.UpdatedAt) > r.expiry
+}
+
+// prune deletes the Runner's expired sessions and those over the limit
+// of WithMaxSessions. Failing to is only logged: the chat went through.
+func (r *Runner) prune(ctx context.Context) {
+	if r.expiry <= 0 && r.maxSessions <= 0 {
+		return
+	}
+	var before time.Time
+	if r.expiry > 0 {
+		before = time.Now().Add(-r.expiry)
+	}
+	pruned, err := r.store.PruneSessions(ctx, RunnerOrigin, before, r.maxSessions)
+	if err != nil {
+		slog.Warn("failed to prune chat sessions", "error", err)
+		return
+	}
+	if pruned > 0 {
+		slog.Debug("pruned chat sessions", "count", pruned)
+	}
+}
+
+// lock acquires the session's lock and returns the func that releases it
+func (r *Runner) lock(sessionID string) func() {
+	r.mu.Lock()
+	l, ok := r.locks[sessionID]
+	if !ok {
+		l = &sessionLock{}
+		r.locks[sessionID] = l
+	}
+	l.refs++
+	r.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		r.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(r.locks, sessionID)
+		}
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,199 @@
+package useragent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+func TestRunner_Chat(t *testing.T) {
+	ctx := context.Background()
+	mockLLM := &mockLLM{
+		responses: []*llm.ChatResponse{
+			{Content: "Hello!", Usage: llm.TokenUsage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}},
+			{Content: "You said hi."},
+		},
+	}
+	registry := tools.NewRegistry()
+	st := newFakeSessionStore()
+	runner := NewRunner(NewAgent(mockLLM, tools.NewExecutor(registry), registry, "prompt"), st)
+
+	first, err := runner.Chat(ctx, "", "hi", nil)
+	if err != nil {
+		t.Fatalf("Chat() new session error: %v", err)
+	}
+	if first.SessionID == "" || first.Answer != "Hello!" || first.InputTokens != 10 || first.LLMCalls != 1 {
+		t.Errorf("Chat() = %+v, want a new session answering Hello! with 10 input tokens", first)
+	}
+
+	second, err := runner.Chat(ctx, first.SessionID, "what did I say?", nil)
+	if err != nil {
+		t.Fatalf("Chat() existing session error: %v", err)
+	}
+	if second.SessionID != first.SessionID {
+		t.Errorf("SessionID = %q, want %q", second.SessionID, first.SessionID)
+	}
+	// The second request carries the whole conversation
+	if got := len(mockLLM.lastReq.Messages); got != 3 {
+		t.Errorf("second request had %d messages, want 3", got)
+	}
+	if got := st.sessions[first.SessionID]; len(got.Messages) != 4 || got.InputTokens != 10 {
+		t.Errorf("stored session = %+v, want 4 messages and 10 input tokens", got)
+	}
+	if len(runner.locks) != 0 {
+		t.Errorf("%d session locks left after runs", len(runner.locks))
+	}
+
+	if _, err := runner.Chat(ctx, "missing", "hi", nil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Chat() unknown session error = %v, want ErrNotFound", err)
+	}
+}
+
+// loadLLM calls the lookup tool once for each question, then answers with
+// how many questions the conversation has had. Safe for concurrent use.
+type loadLLM struct {
+	mockLLM
+}
+
+func (m *loadLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	if last := req.Messages[len(req.Messages)-1]; last.ToolResultID == "" {
+		id := fmt.Sprintf("call-%d", len(req.Messages))
+		return &llm.ChatResponse{ToolCalls: []llm.ToolCall{{ID: id, Name: "lookup"}}}, nil
+	}
+	questions := 0
+	for _, msg := range req.Messages {
+		if msg.Role == "user" && msg.ToolResultID == "" {
+			questions++
+		}
+	}
+	return &llm.ChatResponse{Content: fmt.Sprint(questions)}, nil
+}
+
+func newLoadRunner(st RunnerStore, opts ...RunnerOption) *Runner {
+	registry := tools.NewRegistry()
+	registry.Register(&fakeTool{name: "lookup", description: "Look something up"})
+	return NewRunner(NewAgent(&loadLLM{}, tools.NewExecutor(registry), registry, "prompt"), st, opts...)
+}
+
+func TestRunner_Chat_Concurrent(t *testing.T) {
+	const sessions, messages = 20, 10
+	ctx := context.Background()
+	st := newFakeSessionStore()
+	runner := newLoadRunner(st, WithMaxSessions(sessions))
+
+	// Start every session at once, then send them all their other messages
+	// at once
+	ids := make([]string, sessions)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Go(func() {
+			result, err := runner.Chat(ctx, "", "first question", nil)
+			if err != nil {
+				t.Errorf("Chat() new session error: %v", err)
+				return
+			}
+			ids[i] = result.SessionID
+		})
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	for _, id := range ids {
+		for range messages - 1 {
+			wg.Go(func() {
+				if _, err := runner.Chat(ctx, id, "another question", nil); err != nil {
+					t.Errorf("Chat(%s) error: %v", id, err)
+				}
+			})
+		}
+	}
+	wg.Wait()
+
+	if len(st.sessions) != sessions {
+		t.Errorf("%d sessions stored, want %d", len(st.sessions), sessions)
+	}
+	for _, id := range ids {
+		// Each message adds a question, a tool call, its result, and an
+		// answer. Runs on a session that overlapped would lose some.
+		got := st.sessions[id]
+		if len(got.Messages) != 4*messages {
+			t.Errorf("session %s has %d messages, want %d", id, len(got.Messages), 4*messages)
+			continue
+		}
+		if answer := got.Messages[len(got.Messages)-1].Content; answer != fmt.Sprint(messages) {
+			t.Errorf("session %s last answer = %q, want %d", id, answer, messages)
+		}
+	}
+	if len(runner.locks) != 0 {
+		t.Errorf("%d session locks left after runs", len(runner.locks))
+	}
+}
+
+func TestRunner_Chat_Expiry(t *testing.T) {
+	ctx := context.Background()
+	st := newFakeSessionStore()
+	st.clock = time.Now()
+	st.sessions["repl"] = store.Session{ID: "repl", Messages: []llm.Message{{Role: "user", Content: "hi"}}}
+	runner := newLoadRunner(st, WithSessionExpiry(time.Hour))
+
+	first, err := runner.Chat(ctx, "", "hi", nil)
+	if err != nil {
+		t.Fatalf("Chat() new session error: %v", err)
+	}
+	if _, err := runner.Chat(ctx, first.SessionID, "still there?", nil); err != nil {
+		t.Fatalf("Chat() on an idle session error: %v", err)
+	}
+
+	// Idle for two hours
+	record := st.sessions[first.SessionID]
+	record.UpdatedAt = time.Now().Add(-2 * time.Hour)
+	st.sessions[first.SessionID] = record
+	if _, err := runner.Chat(ctx, first.SessionID, "still there?", nil); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Chat() on an expired session error = %v, want ErrNotFound", err)
+	}
+	// Sessions started in joe don't expire
+	if _, err := runner.Chat(ctx, "repl", "still there?", nil); err != nil {
+		t.Errorf("Chat() on a joe session error: %v", err)
+	}
+
+	// The next session deletes the expired one
+	if _, err := runner.Chat(ctx, "", "hello again", nil); err != nil {
+		t.Fatalf("Chat() new session error: %v", err)
+	}
+	if _, ok := st.sessions[first.SessionID]; ok {
+		t.Error("expired session was not deleted")
+	}
+}
+
+func TestRunner_Chat_MaxSessions(t *testing.T) {
+	ctx := context.Background()
+	st := newFakeSessionStore()
+	st.sessions["repl"] = store.Session{ID: "repl", Messages: []llm.Message{{Role: "user", Content: "hi"}}}
+	runner := newLoadRunner(st, WithMaxSessions(2))
+
+	var ids []string
+	for range 3 {
+		result, err := runner.Chat(ctx, "", "hi", nil)
+		if err != nil {
+			t.Fatalf("Chat() new session error: %v", err)
+		}
+		ids = append(ids, result.SessionID)
+	}
+
+	if _, ok := st.sessions[ids[0]]; ok {
+		t.Errorf("oldest session %s was kept over the limit", ids[0])
+	}
+	for _, id := range append(ids[1:], "repl") {
+		if _, ok := st.sessions[id]; !ok {
+			t.Errorf("session %s was deleted", id)
+		}
+	}
+}
@@ -1,60 +1,91 @@
 package useragent
 
-import "github.com/jaimegago/joe/internal/llm"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
 
 // Session holds the conversation history for an agentic interaction
 type Session struct {
+	// ID identifies the session in the store; StartedAt is when it began
+	ID        string
+	StartedAt time.Time
+
+	// Title names the conversation in a few words (see Agent.Title)
+	Title string
+
+	// Origin is where the session ran: "" for joe, RunnerOrigin for
+	// sessions started through a Runner
+	Origin string
+
 	Messages []llm.Message
 
+	// Recalled describes past sessions like this one, for the system
+	// prompt (see WithRecall)
+	Recalled string
+
 	// Token usage tracking
 	TotalInputTokens  int
 	TotalOutputTokens int
 	TotalTokens       int
+	TotalCostUSD      float64 // Only counts models with pricing configured
 
 	// Per-run token tracking (reset at start of each Run)
 	RunInputTokens  int
 	RunOutputTokens int
 	RunTokens       int
 	RunLLMCalls     int
+	RunCostUSD      float64
 
-	// MaxMessages limits conversation history size to prevent unbounded growth
-	// When 0, no limit is applied. Recommended: 100-200 for typical conversations.
-	MaxMessages int
+	// RunIncomplete is set when the run hit the iteration limit, and its
+	// answer only sums up what was found so far
+	RunIncomplete bool
 }
 
 // NewSession creates a new session with empty conversation history
 func NewSession() *Session {
 	return &Session{
-		Messages: make([]llm.Message, 0),
+		ID:        newSessionID(),
+		StartedAt: time.Now(),
+		Messages:  make([]llm.Message, 0),
 	}
 }
 
-// AddMessage adds a message to the conversation history.
-// If MaxMessages is set and exceeded, older messages are pruned while
-// preserving the most recent messages for context.
-func (s *Session) AddMessage(message llm.Message) {
-	s.Messages = append(s.Messages, message)
+// newSessionID returns a sortable, human-readable ID like 20240302-090000-a1b2c3
+func newSessionID() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
 
-	// Prune old messages if we've exceeded the limit
-	if s.MaxMessages > 0 && len(s.Messages) > s.MaxMessages {
-		// Keep the most recent MaxMessages/2 messages
-		// This aggressive pruning ensures we don't slowly grow near the limit
-		keepCount := s.MaxMessages / 2
-		if keepCount < 10 {
-			keepCount = 10 // Always keep at least 10 messages for context
-		}
-		s.Messages = s.Messages[len(s.Messages)-keepCount:]
+// AddMessage adds a message to the conversation history, stamping it with
+// the current time if it has no timestamp
+func (s *Session) AddMessage(message llm.Message) {
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
 	}
+	s.Messages = append(s.Messages, message)
 }
 
-// AddMessages adds multiple messages to the conversation history
+// AddMessages adds multiple messages to the conversation history, stamping
+// any without a timestamp with the current time
 func (s *Session) AddMessages(messages []llm.Message) {
-	s.Messages = append(s.Messages, messages...)
+	now := time.Now()
+	for _, message := range messages {
+		if message.Timestamp.IsZero() {
+			message.Timestamp = now
+		}
+		s.Messages = append(s.Messages, message)
+	}
 }
 
-// Clear clears the conversation history
+// Clear clears the conversation history, and what was recalled for it
 func (s *Session) Clear() {
 	s.Messages = make([]llm.Message, 0)
+	s.Recalled = ""
 }
 
 // ResetRunStats resets per-run token tracking (called at start of each Run)
@@ -63,6 +94,8 @@ func (s *Session) ResetRunStats() {
 	s.RunOutputTokens = 0
 	s.RunTokens = 0
 	s.RunLLMCalls = 0
+	s.RunCostUSD = 0
+	s.RunIncomplete = false
 }
 
 // AddTokenUsage adds token usage from an LLM response
@@ -72,9 +105,11 @@ func (s *Session) AddTokenUsage(usage llm.TokenUsage) {
 	s.RunOutputTokens += usage.OutputTokens
 	s.RunTokens += usage.TotalTokens
 	s.RunLLMCalls++
+	s.RunCostUSD += usage.CostUSD
 
 	// Update total session stats
 	s.TotalInputTokens += usage.InputTokens
 	s.TotalOutputTokens += usage.OutputTokens
 	s.TotalTokens += usage.TotalTokens
+	s.TotalCostUSD += usage.CostUSD
 }
@@ -1,9 +1,16 @@
 package useragent
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/store"
 )
 
 func TestNewSession(t *testing.T) {
@@ -126,3 +133,191 @@ func TestSession_Clear(t *testing.T) {
 		t.Errorf("Session has %d messages after clear and add, want 1", len(session.Messages))
 	}
 }
+
+// fakeSessionStore is an in-memory RunnerStore for persistence tests. Each
+// write advances its clock by a second.
+type fakeSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]store.Session
+	clock    time.Time
+}
+
+func newFakeSessionStore() *fakeSessionStore {
+	return &fakeSessionStore{sessions: make(map[string]store.Session), clock: time.Unix(0, 0)}
+}
+
+func (f *fakeSessionStore) touch(session store.Session) {
+	f.clock = f.clock.Add(time.Second)
+	session.UpdatedAt = f.clock
+	f.sessions[session.ID] = session
+}
+
+func (f *fakeSessionStore) CreateSession(ctx context.Context, session store.Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.touch(session)
+	return nil
+}
+
+func (f *fakeSessionStore) GetSession(ctx context.Context, id string) (*store.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	session, ok := f.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session %s: %w", id, store.ErrNotFound)
+	}
+	return &session, nil
+}
+
+func (f *fakeSessionStore) UpdateSession(ctx context.Context, session store.Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.touch(session)
+	return nil
+}
+
+func (f *fakeSessionStore) ListSessions(ctx context.Context, limit int) ([]store.Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var sessions []store.Session
+	for _, session := range f.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt) })
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+func (f *fakeSessionStore) PruneSessions(ctx context.Context, origin string, updatedBefore time.Time, keep int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := len(f.sessions)
+	var kept []store.Session
+	for id, session := range f.sessions {
+		if session.Origin != origin {
+			continue
+		}
+		if session.UpdatedAt.Before(updatedBefore) {
+			delete(f.sessions, id)
+		} else {
+			kept = append(kept, session)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].UpdatedAt.After(kept[j].UpdatedAt) })
+	if keep > 0 && len(kept) > keep {
+		for _, session := range kept[keep:] {
+			delete(f.sessions, session.ID)
+		}
+	}
+	return stored - len(f.sessions), nil
+}
+
+func TestSaveSession_SkipsEmpty(t *testing.T) {
+	st := newFakeSessionStore()
+	if err := SaveSession(context.Background(), st, NewSession()); err != nil {
+		t.Fatalf("SaveSession() error: %v", err)
+	}
+	if len(st.sessions) != 0 {
+		t.Errorf("empty session was saved: %v", st.sessions)
+	}
+}
+
+func TestSaveSession_PreservesStoredFields(t *testing.T) {
+	ctx := context.Background()
+	st := newFakeSessionStore()
+
+	session := NewSession()
+	session.AddMessage(llm.Message{Role: "user", Content: "hello"})
+	session.AddTokenUsage(llm.TokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, CostUSD: 0.25})
+	if err := SaveSession(ctx, st, session); err != nil {
+		t.Fatalf("SaveSession() create error: %v", err)
+	}
+
+	// Simulate joecored summarizing the session between saves
+	record := st.sessions[session.ID]
+	record.Summary = "greeting"
+	record.Title = "Greetings"
+	st.sessions[session.ID] = record
+
+	session.AddMessage(llm.Message{Role: "assistant", Content: "hi"})
+	if err := SaveSession(ctx, st, session); err != nil {
+		t.Fatalf("SaveSession() update error: %v", err)
+	}
+
+	got := st.sessions[session.ID]
+	if len(got.Messages) != 2 || got.TotalTokens != 15 || got.CostUSD != 0.25 {
+		t.Errorf("saved session = %+v, want 2 messages, 15 tokens, and $0.25", got)
+	}
+	if got.Summary != "greeting" {
+		t.Errorf("Summary = %q, want it preserved", got.Summary)
+	}
+	if got.Title != "Greetings" {
+		t.Errorf("Title = %q, want it preserved while the session has none", got.Title)
+	}
+}
+
+func TestLatestSession(t *testing.T) {
+	ctx := context.Background()
+	st := newFakeSessionStore()
+
+	if _, err := LatestSession(ctx, st, ""); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("LatestSession() on empty store error = %v, want ErrNotFound", err)
+	}
+
+	st.CreateSession(ctx, store.Session{ID: "older"})
+	st.CreateSession(ctx, store.Session{ID: "current"})
+
+	tests := []struct {
+		name    string
+		exclude string
+		want    string
+	}{
+		{"no exclusion", "", "current"},
+		{"skips current session", "current", "older"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LatestSession(ctx, st, tt.exclude)
+			if err != nil {
+				t.Fatalf("LatestSession() error: %v", err)
+			}
+			if got.ID != tt.want {
+				t.Errorf("LatestSession() = %s, want %s", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession_Restore(t *testing.T) {
+	session := NewSession()
+	session.AddTokenUsage(llm.TokenUsage{InputTokens: 1, OutputTokens: 1, TotalTokens: 2})
+
+	stored := &store.Session{
+		ID:           "20240302-090000-abcdef",
+		Title:        "Greetings",
+		Origin:       RunnerOrigin,
+		Messages:     []llm.Message{{Role: "user", Content: "hello"}, {Role: "assistant", Content: "hi"}},
+		InputTokens:  100,
+		OutputTokens: 50,
+		TotalTokens:  150,
+		CostUSD:      1.5,
+	}
+	session.Restore(stored)
+
+	if session.ID != stored.ID || session.Title != stored.Title || session.Origin != stored.Origin {
+		t.Errorf("ID, Title, Origin = %s, %q, %q, want %s, %q, %q",
+			session.ID, session.Title, session.Origin, stored.ID, stored.Title, stored.Origin)
+	}
+	if len(session.Messages) != 2 || session.TotalTokens != 150 || session.TotalCostUSD != 1.5 || session.RunTokens != 0 {
+		t.Errorf("Restore() = %+v", session)
+	}
+
+	// The restored history must not alias the stored slice
+	session.AddMessage(llm.Message{Role: "user", Content: "again"})
+	if len(stored.Messages) != 2 {
+		t.Errorf("Restore() aliased stored messages")
+	}
+}
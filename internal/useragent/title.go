@@ -0,0 +1,73 @@
+package useragent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// maxTitleLength caps a generated title, in runes
+const maxTitleLength = 60
+
+// maxTitleSource caps how much of the first question and answer is sent
+// to name the session
+const maxTitleSource = 1000
+
+const titlePrompt = `You name conversations between a user and Joe, an infrastructure assistant, so they can be found again in a list.
+
+Reply with a title of at most six words for the conversation: the system or problem it is about, e.g. "payment-api OOM crashes" or "Expiring TLS certs on ingress". No quotes, no trailing period, nothing else.`
+
+// Title has the model name session in a few words, from its first
+// question and answer, and sets session.Title. Sessions that already have
+// a title or no answer yet are left as they are.
+func (a *Agent) Title(ctx context.Context, session *Session) error {
+	if session.Title != "" {
+		return nil
+	}
+	var question, answer string
+	for _, m := range session.Messages {
+		switch {
+		case m.ToolResultID != "":
+		case m.Role == "user" && question == "" && !strings.HasPrefix(m.Content, summaryPrefix):
+			question = m.Content
+		case m.Role == "assistant" && question != "" && m.Content != "":
+			answer = m.Content
+		}
+		if answer != "" {
+			break
+		}
+	}
+	if question == "" {
+		return nil
+	}
+
+	a.mu.RLock()
+	resp, err := a.llm.Chat(ctx, llm.ChatRequest{
+		SystemPrompt: titlePrompt,
+		Messages: []llm.Message{{Role: "user", Content: fmt.Sprintf("Question: %s\n\nAnswer: %s",
+			truncateRunes(question, maxTitleSource), truncateRunes(answer, maxTitleSource))}},
+	})
+	a.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to title session: %w", err)
+	}
+	session.AddTokenUsage(resp.Usage)
+
+	title, _, _ := strings.Cut(strings.TrimSpace(resp.Content), "\n")
+	title = strings.TrimSuffix(strings.Trim(strings.TrimSpace(title), `"'`+"`"), ".")
+	if title == "" {
+		return fmt.Errorf("failed to title session: empty title")
+	}
+	session.Title = truncateRunes(title, maxTitleLength)
+	return nil
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with …
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
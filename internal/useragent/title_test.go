@@ -0,0 +1,58 @@
+package useragent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+func TestAgent_Title(t *testing.T) {
+	conversation := []llm.Message{
+		{Role: "user", Content: "why is payment-api crashing?"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "t1", Name: "run_command"}}},
+		{Role: "user", Content: "OOMKilled", ToolResultID: "t1", ToolName: "run_command"},
+		{Role: "assistant", Content: "It runs out of memory."},
+	}
+	tests := []struct {
+		name     string
+		title    string
+		messages []llm.Message
+		reply    string
+		want     string
+		wantCall bool
+	}{
+		{name: "titled", messages: conversation, reply: "\"Payment-api OOM crashes.\"\nextra", want: "Payment-api OOM crashes", wantCall: true},
+		{name: "long title cut", messages: conversation, reply: strings.Repeat("x", 100), want: strings.Repeat("x", maxTitleLength-1) + "…", wantCall: true},
+		{name: "already titled", title: "Kept", messages: conversation, want: "Kept"},
+		{name: "empty session", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockLLM{responses: []*llm.ChatResponse{{Content: tt.reply}}}
+			registry := tools.NewRegistry()
+			agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system")
+			session := NewSession()
+			session.Title = tt.title
+			session.AddMessages(tt.messages)
+
+			if err := agent.Title(context.Background(), session); err != nil {
+				t.Fatalf("Title() error = %v", err)
+			}
+			if session.Title != tt.want {
+				t.Errorf("Title = %q, want %q", session.Title, tt.want)
+			}
+			if called := mock.callCount > 0; called != tt.wantCall {
+				t.Fatalf("LLM called = %v, want %v", called, tt.wantCall)
+			}
+			if tt.wantCall {
+				sent := mock.lastReq.Messages[0].Content
+				if !strings.Contains(sent, "payment-api crashing") || !strings.Contains(sent, "runs out of memory") {
+					t.Errorf("sent %q, want the first question and answer", sent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package useragent
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/jaimegago/joe/internal/llm"
+)
+
+// Tool selection modes, see WithToolSelection
+const (
+	SelectByKeywords   = "keywords"
+	SelectByEmbeddings = "embeddings"
+)
+
+// toolSelection holds the settings of WithToolSelection and the tool
+// embeddings computed so far
+type toolSelection struct {
+	mode     string
+	maxTools int
+	always   []string
+
+	mu         sync.Mutex           // protects embedModel and embeddings
+	embedModel string               // Model the cached embeddings came from
+	embeddings map[string][]float32 // Keyed by tool name and description
+}
+
+// WithToolSelection sends each run only the maxTools tools most relevant
+// to the user's message, rather than all of them, plus the always tools and
+// those the session has already called. mode SelectByKeywords ranks tools
+// by the words they share with the message; SelectByEmbeddings by the
+// similarity of their embeddings, falling back to keywords when the model
+// can't embed. maxTools <= 0 turns selection off.
+func WithToolSelection(mode string, maxTools int, always []string) AgentOption {
+	return func(a *Agent) {
+		if maxTools <= 0 {
+			a.selection = nil
+			return
+		}
+		a.selection = &toolSelection{mode: mode, maxTools: maxTools, always: always}
+	}
+}
+
+// toolDefinitions returns the tools to offer for message: all of them, the
+// selected ones with WithToolSelection, and no more than the model accepts
+func (a *Agent) toolDefinitions(ctx context.Context, session *Session, message string) []llm.ToolDefinition {
+	limit := a.ModelInfo().MaxTools
+	if limit > 1 && a.planning {
+		limit-- // Leave room for plan
+	}
+	if a.selection == nil || (limit > 0 && limit <= a.selection.maxTools) {
+		return a.registry.RelevantDefinitions(message, limit)
+	}
+	return a.selectTools(ctx, session, message)
+}
+
+// selectTools picks the tools for message as WithToolSelection describes,
+// sorted by name
+func (a *Agent) selectTools(ctx context.Context, session *Session, message string) []llm.ToolDefinition {
+	definitions := a.registry.ToDefinitions()
+	if len(definitions) <= a.selection.maxTools {
+		return definitions
+	}
+
+	keep := make(map[string]bool)
+	for _, name := range a.selection.always {
+		keep[name] = true
+	}
+	for _, msg := range session.Messages {
+		for _, call := range msg.ToolCalls {
+			keep[call.Name] = true
+		}
+	}
+
+	scores := a.keywordScores(message, definitions)
+	if a.selection.mode == SelectByEmbeddings {
+		if similarities, err := a.embeddingScores(ctx, message, definitions); err != nil {
+			slog.Warn("tool selection by embeddings failed, using keywords", "error", err)
+		} else {
+			scores = similarities
+		}
+	}
+
+	var selected, rest []llm.ToolDefinition
+	for _, def := range definitions {
+		if keep[def.Name] {
+			selected = append(selected, def)
+		} else {
+			rest = append(rest, def)
+		}
+	}
+	// Definitions are sorted by name, so ties keep that order
+	slices.SortStableFunc(rest, func(x, y llm.ToolDefinition) int {
+		switch {
+		case scores[x.Name] > scores[y.Name]:
+			return -1
+		case scores[x.Name] < scores[y.Name]:
+			return 1
+		}
+		return 0
+	})
+	selected = append(selected, rest[:min(a.selection.maxTools, len(rest))]...)
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+	return selected
+}
+
+// keywordScores ranks definitions by the words they share with message
+func (a *Agent) keywordScores(message string, definitions []llm.ToolDefinition) map[string]float64 {
+	scores := make(map[string]float64, len(definitions))
+	for _, def := range definitions {
+		scores[def.Name] = float64(a.registry.Relevance(message, def))
+	}
+	return scores
+}
+
+// embeddingScores ranks definitions by the cosine similarity of their
+// embeddings to message's. Tool embeddings are computed once per model.
+func (a *Agent) embeddingScores(ctx context.Context, message string, definitions []llm.ToolDefinition) (map[string]float64, error) {
+	sel := a.selection
+	sel.mu.Lock()
+	defer sel.mu.Unlock()
+	a.mu.RLock() // So SwitchModel can't swap the adapter mid-way
+	defer a.mu.RUnlock()
+
+	if sel.embeddings == nil || sel.embedModel != a.currentModel {
+		sel.embedModel = a.currentModel
+		sel.embeddings = make(map[string][]float32)
+	}
+	query, err := a.llm.Embed(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(definitions))
+	for _, def := range definitions {
+		key := def.Name + "\n" + def.Description
+		embedding, ok := sel.embeddings[key]
+		if !ok {
+			if embedding, err = a.llm.Embed(ctx, key); err != nil {
+				return nil, err
+			}
+			sel.embeddings[key] = embedding
+		}
+		scores[def.Name] = cosine(query, embedding)
+	}
+	return scores, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if they differ in
+// length or either is zero
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,144 @@
+package useragent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jaimegago/joe/internal/llm"
+	"github.com/jaimegago/joe/internal/tools"
+)
+
+// embedLLM embeds text as which of a few topics it mentions
+type embedLLM struct {
+	mockLLM
+	embedded int
+}
+
+func (m *embedLLM) Embed(ctx context.Context, text string) ([]float32, error) {
+	m.embedded++
+	var v []float32
+	for _, topic := range []string{"disk", "log", "network"} {
+		if strings.Contains(strings.ToLower(text), topic) {
+			v = append(v, 1)
+		} else {
+			v = append(v, 0)
+		}
+	}
+	return v, nil
+}
+
+// fakeTool is a tool that only has a name and description
+type fakeTool struct {
+	name, description string
+}
+
+func (f *fakeTool) Name() string                    { return f.name }
+func (f *fakeTool) Description() string             { return f.description }
+func (f *fakeTool) Parameters() llm.ParameterSchema { return llm.ParameterSchema{Type: "object"} }
+func (f *fakeTool) Execute(ctx context.Context, args map[string]any) (any, error) {
+	return "ok", nil
+}
+
+func selectionRegistry() *tools.Registry {
+	registry := tools.NewRegistry()
+	for name, description := range map[string]string{
+		"ask_user":    "Ask the user a question",
+		"disk_usage":  "Show free space on each filesystem",
+		"tail_file":   "Show the last lines of a log file",
+		"ping_host":   "Check that a host answers on the network",
+		"query_table": "Summarize a CSV file",
+	} {
+		registry.Register(&fakeTool{name: name, description: description})
+	}
+	return registry
+}
+
+func TestAgent_ToolSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		history []llm.Message
+		message string
+		want    []string
+	}{
+		{
+			name:    "keywords",
+			mode:    SelectByKeywords,
+			message: "what's in the log file?",
+			want:    []string{"ask_user", "tail_file"},
+		},
+		{
+			name:    "embeddings",
+			mode:    SelectByEmbeddings,
+			message: "is the disk full?",
+			want:    []string{"ask_user", "disk_usage"},
+		},
+		{
+			name: "tools the session called are kept",
+			mode: SelectByKeywords,
+			history: []llm.Message{
+				{Role: "user", Content: "can you reach db-1?"},
+				{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "1", Name: "ping_host"}}},
+				{Role: "user", ToolResultID: "1", Content: "ok"},
+				{Role: "assistant", Content: "Yes."},
+			},
+			message: "and the log file?",
+			want:    []string{"ask_user", "ping_host", "tail_file"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &embedLLM{mockLLM: mockLLM{responses: []*llm.ChatResponse{{Content: "Done"}}}}
+			registry := selectionRegistry()
+			agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system",
+				WithToolSelection(tt.mode, 1, []string{"ask_user"}))
+
+			session := NewSession()
+			session.AddMessages(tt.history)
+			if _, err := agent.Run(context.Background(), session, tt.message); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			var got []string
+			for _, def := range mock.lastReq.Tools {
+				got = append(got, def.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tools sent = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgent_ToolSelection_CachesEmbeddings(t *testing.T) {
+	mock := &embedLLM{mockLLM: mockLLM{responses: []*llm.ChatResponse{{Content: "One"}, {Content: "Two"}}}}
+	registry := selectionRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system",
+		WithToolSelection(SelectByEmbeddings, 2, nil))
+
+	session := NewSession()
+	for _, message := range []string{"disk?", "network?"} {
+		if _, err := agent.Run(context.Background(), session, message); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+	// Five tools once, and each message
+	if mock.embedded != 7 {
+		t.Errorf("Embed() called %d times, want 7", mock.embedded)
+	}
+}
+
+func TestAgent_ToolSelection_FallsBackToKeywords(t *testing.T) {
+	mock := &mockLLM{responses: []*llm.ChatResponse{{Content: "Done"}}}
+	registry := selectionRegistry()
+	agent := NewAgent(mock, tools.NewExecutor(registry), registry, "system",
+		WithToolSelection(SelectByEmbeddings, 1, nil))
+
+	if _, err := agent.Run(context.Background(), NewSession(), "summarize this csv"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(mock.lastReq.Tools) != 1 || mock.lastReq.Tools[0].Name != "query_table" {
+		t.Errorf("tools sent = %+v, want query_table", mock.lastReq.Tools)
+	}
+}